@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// AuditClient makes proxied requests to the registry's audit endpoint
+type AuditClient struct {
+	client *Client
+}
+
+// List returns the audit events recorded for an org since the given time,
+// oldest first.
+func (a *AuditClient) List(ctx context.Context, orgID *identity.ID, since time.Time) ([]apitypes.AuditEvent, error) {
+	if err := a.client.require(ctx, FeatureAudit); err != nil {
+		return nil, err
+	}
+
+	v := &url.Values{}
+	v.Set("org_id", orgID.String())
+	v.Set("since", since.UTC().Format(time.RFC3339))
+
+	req, _, err := a.client.NewRequest("GET", "/audit", v, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []apitypes.AuditEvent{}
+	_, err = a.client.Do(ctx, req, &events, nil, nil)
+	return events, err
+}
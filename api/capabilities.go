@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+// Features that newer registries support, and older or self-hosted ones may
+// not. Requests for a feature the registry lacks fail with an
+// *UnsupportedError, rather than whatever the registry makes of an unknown
+// endpoint.
+const (
+	FeatureAudit        = "audit"
+	FeatureSessions     = "sessions"
+	FeatureOrgSettings  = "org_settings"
+	FeatureInviteResend = "invite_resend"
+	FeatureShares       = "shares"
+	FeatureFreeze       = "keyring_freeze"
+	FeatureDirectory    = "directory"
+	FeatureEvents       = "events"
+	FeatureRecovery     = "recovery_codes"
+	FeatureInviteReject = "invite_reject"
+	FeatureHoneytokens  = "honeytokens"
+	FeatureBroker       = "dynamic_secrets"
+	FeatureMFA          = "mfa"
+	FeatureArchive      = "project_archive"
+	FeatureOrgDelete    = "org_delete"
+	FeatureSignedPolicy = "signed_policies"
+)
+
+var featureDescriptions = map[string]string{
+	FeatureAudit:        "the org audit log",
+	FeatureSessions:     "managing sessions",
+	FeatureOrgSettings:  "org settings",
+	FeatureInviteResend: "resending invites",
+	FeatureShares:       "sharing secrets through one-time links",
+	FeatureFreeze:       "freezing keyrings",
+	FeatureDirectory:    "looking up users by email",
+	FeatureEvents:       "streaming org events",
+	FeatureRecovery:     "recovery codes",
+	FeatureInviteReject: "rejecting invites",
+	FeatureHoneytokens:  "honeytoken alerts",
+	FeatureBroker:       "dynamic secrets",
+	FeatureMFA:          "multi-factor authentication",
+	FeatureArchive:      "archiving projects",
+	FeatureOrgDelete:    "deleting orgs",
+	FeatureSignedPolicy: "signed policies",
+}
+
+// UnsupportedError is returned when the registry does not support a feature.
+type UnsupportedError struct {
+	Feature string
+}
+
+func (e *UnsupportedError) Error() string {
+	desc, ok := featureDescriptions[e.Feature]
+	if !ok {
+		desc = e.Feature
+	}
+	return "the registry does not support " + desc + "; it may need to be upgraded"
+}
+
+// Capabilities returns the registry's capabilities, asking it only the first
+// time it is called. Registries without a capabilities endpoint support no
+// optional features.
+func (c *Client) Capabilities(ctx context.Context) (*apitypes.Capabilities, error) {
+	c.capsMutex.Lock()
+	defer c.capsMutex.Unlock()
+
+	if c.caps != nil {
+		return c.caps, nil
+	}
+
+	req, _, err := c.NewRequest("GET", "/capabilities", nil, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := &apitypes.Capabilities{}
+	_, err = c.Do(ctx, req, caps, nil, nil)
+	if apitypes.IsNotFoundError(err) {
+		caps, err = &apitypes.Capabilities{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.caps = caps
+	return caps, nil
+}
+
+// Supports returns whether the registry supports feature.
+func (c *Client) Supports(ctx context.Context, feature string) (bool, error) {
+	caps, err := c.Capabilities(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return caps.Supports(feature), nil
+}
+
+// require returns an *UnsupportedError if the registry does not support
+// feature.
+func (c *Client) require(ctx context.Context, feature string) error {
+	ok, err := c.Supports(ctx, feature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &UnsupportedError{Feature: feature}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// registryTransport serves fixed responses by path, and 404s for the rest, as
+// an older self-hosted registry does for endpoints it doesn't have.
+type registryTransport struct {
+	responses map[string]string
+	requested []string
+}
+
+func (rt *registryTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	rt.requested = append(rt.requested, r.URL.Path)
+
+	rec := httptest.NewRecorder()
+	body, ok := rt.responses[r.URL.Path]
+	if !ok {
+		rec.WriteHeader(http.StatusNotFound)
+		body = `{"type":"not_found","error":["Not found"]}`
+	}
+	rec.WriteString(body)
+
+	return rec.Result(), nil
+}
+
+func TestCapabilities(t *testing.T) {
+	ctx := context.Background()
+	orgID := identity.ID{}
+
+	t.Run("legacy registry", func(t *testing.T) {
+		rt := &registryTransport{}
+		c := NewClientWithTransport(rt)
+
+		_, err := c.Sessions.List(ctx)
+		if uerr, ok := err.(*UnsupportedError); !ok || uerr.Feature != FeatureSessions {
+			t.Errorf("expected an UnsupportedError, got %v", err)
+		}
+
+		settings, err := c.Orgs.GetSettings(ctx, orgID)
+		if err != nil || len(settings.Environments) != 0 {
+			t.Errorf("expected default settings, got %+v, %v", settings, err)
+		}
+
+		if len(rt.requested) != 1 || rt.requested[0] != "/proxy/capabilities" {
+			t.Errorf("expected only the capabilities to be requested, got %v", rt.requested)
+		}
+	})
+
+	t.Run("current registry", func(t *testing.T) {
+		rt := &registryTransport{responses: map[string]string{
+			"/proxy/capabilities": `{"features":["sessions"]}`,
+			"/proxy/sessions":     `[]`,
+		}}
+		c := NewClientWithTransport(rt)
+
+		if _, err := c.Sessions.List(ctx); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if _, err := c.Sessions.List(ctx); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		want := []string{"/proxy/capabilities", "/proxy/sessions", "/proxy/sessions"}
+		if len(rt.requested) != len(want) {
+			t.Errorf("requested %v, want %v", rt.requested, want)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// ClaimTreeClient makes proxied requests to the registry's claimtree
+// endpoint, for reading the public keys of every member of an org.
+type ClaimTreeClient struct {
+	client *Client
+}
+
+// ClaimTree holds the public keys, and the claims made against them, of
+// every member of an org.
+type ClaimTree struct {
+	Org        *envelope.Org               `json:"org"`
+	PublicKeys []apitypes.PublicKeySegment `json:"public_keys"`
+}
+
+// Get returns the claim tree for the given org.
+func (c *ClaimTreeClient) Get(ctx context.Context, orgID *identity.ID) (*ClaimTree, error) {
+	v := &url.Values{}
+	v.Set("org_id", orgID.String())
+
+	req, _, err := c.client.NewRequest("GET", "/claimtree", v, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	trees := []ClaimTree{}
+	_, err = c.client.Do(ctx, req, &trees, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tree := range trees {
+		if tree.Org != nil && *tree.Org.ID == *orgID {
+			return &tree, nil
+		}
+	}
+
+	return &ClaimTree{}, nil
+}
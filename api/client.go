@@ -6,9 +6,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"sync"
 
 	"github.com/donovanhide/eventsource"
 	"github.com/satori/go.uuid"
@@ -21,6 +24,24 @@ import (
 type Client struct {
 	client *http.Client
 
+	// retries is how many times a GET request is retried if the daemon or
+	// registry can't be reached. breaker is nil if the circuit breaker is
+	// turned off.
+	retries int
+	breaker *circuitBreaker
+
+	// breakGlass is the reason sent with every request for reading secrets
+	// from frozen keyrings, if any.
+	breakGlass string
+
+	// caps caches the registry's capabilities once they are fetched.
+	capsMutex sync.Mutex
+	caps      *apitypes.Capabilities
+
+	Audit        *AuditClient
+	ClaimTree    *ClaimTreeClient
+	Directory    *DirectoryClient
+	Events       *EventsClient
 	Orgs         *OrgsClient
 	Users        *UsersClient
 	Machines     *MachinesClient
@@ -29,7 +50,12 @@ type Client struct {
 	Memberships  *MembershipsClient
 	Invites      *InvitesClient
 	Keypairs     *KeypairsClient
+	Keyrings     *KeyringsClient
+	Leases       *LeasesClient
+	MFA          *MFAClient
 	Session      *SessionClient
+	Sessions     *SessionsClient
+	Shares       *SharesClient
 	Services     *ServicesClient
 	Policies     *PoliciesClient
 	Environments *EnvironmentsClient
@@ -41,16 +67,51 @@ type Client struct {
 
 // NewClient returns a new Client.
 func NewClient(cfg *config.Config) *Client {
-	c := &Client{
-		client: &http.Client{
-			Transport: &http.Transport{
-				Dial: func(network, address string) (net.Conn, error) {
-					return net.Dial("unix", cfg.SocketPath)
-				},
-			},
+	rt := NewTransport(cfg)
+	if cfg.TraceFile != "" {
+		f, err := os.OpenFile(cfg.TraceFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err == nil {
+			rt = NewTracer(rt, f, true)
+		} else {
+			fmt.Fprintf(os.Stderr, "Could not open trace file: %s\n", err)
+			rt = NewTracer(rt, os.Stderr, false)
+		}
+	} else if cfg.Trace {
+		rt = NewTracer(rt, os.Stderr, false)
+	}
+
+	c := NewClientWithTransport(rt)
+	c.retries = cfg.Retries
+	c.breakGlass = cfg.BreakGlass
+	if cfg.CircuitBreaker {
+		c.breaker = newCircuitBreaker()
+	}
+
+	return c
+}
+
+// NewTransport returns an http.RoundTripper that talks to the daemon over its
+// unix socket.
+func NewTransport(cfg *config.Config) http.RoundTripper {
+	return &http.Transport{
+		Dial: func(network, address string) (net.Conn, error) {
+			return net.Dial("unix", cfg.SocketPath)
 		},
 	}
+}
+
+// NewClientWithTransport returns a new Client that makes its requests with the
+// given http.RoundTripper. This is useful for tests, in combination with a
+// Recorder.
+func NewClientWithTransport(rt http.RoundTripper) *Client {
+	c := &Client{
+		client: &http.Client{Transport: rt},
+	}
 
+	c.Audit = &AuditClient{client: c}
+	c.ClaimTree = &ClaimTreeClient{client: c}
+	c.Directory = &DirectoryClient{client: c}
+	c.Events = &EventsClient{client: c}
 	c.Orgs = &OrgsClient{client: c}
 	c.Users = &UsersClient{client: c}
 	c.Machines = &MachinesClient{client: c}
@@ -59,7 +120,12 @@ func NewClient(cfg *config.Config) *Client {
 	c.Memberships = &MembershipsClient{client: c}
 	c.Invites = &InvitesClient{client: c}
 	c.Keypairs = &KeypairsClient{client: c}
+	c.Keyrings = &KeyringsClient{client: c}
+	c.Leases = &LeasesClient{client: c}
+	c.MFA = &MFAClient{client: c}
 	c.Session = &SessionClient{client: c}
+	c.Sessions = &SessionsClient{client: c}
+	c.Shares = &SharesClient{client: c}
 	c.Projects = &ProjectsClient{client: c}
 	c.Services = &ServicesClient{client: c}
 	c.Environments = &EnvironmentsClient{client: c}
@@ -107,6 +173,9 @@ func (c *Client) NewRequest(method, path string, query *url.Values, body interfa
 	req.Header.Set("Host", "localhost")
 	req.Header.Set("X-Request-ID", requestID)
 	req.Header.Set("Content-type", "application/json")
+	if c.breakGlass != "" {
+		req.Header.Set(apitypes.BreakGlassHeader, c.breakGlass)
+	}
 
 	return req, requestID, nil
 }
@@ -116,6 +185,10 @@ func (c *Client) NewRequest(method, path string, query *url.Values, body interfa
 //
 // If the request errors with a JSON formatted response body, it will be
 // unmarshaled into the returned error.
+//
+// GET requests that fail because the daemon or registry can't be reached are
+// retried, as configured by the core.retries and core.circuit_breaker
+// preferences.
 func (c *Client) Do(ctx context.Context, r *http.Request, v interface{}, reqID *string, progress *ProgressFunc) (*http.Response, error) {
 	done := make(chan bool)
 	if progress != nil {
@@ -159,7 +232,11 @@ func (c *Client) Do(ctx context.Context, r *http.Request, v interface{}, reqID *
 		}()
 	}
 
-	resp, err := c.client.Do(r)
+	if code := mfaCode(ctx); code != "" {
+		r.Header.Set(apitypes.MFACodeHeader, code)
+	}
+
+	resp, err := c.do(ctx, r)
 	if progress != nil {
 		done <- true
 	}
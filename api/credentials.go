@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"net/url"
+	"sync"
+	"time"
 
 	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
 )
 
 // CredentialsClient provides access to unencrypted credentials for viewing,
@@ -20,6 +23,29 @@ func (c *CredentialsClient) Search(ctx context.Context, pathexp string) ([]apity
 	v := &url.Values{}
 	v.Set("pathexp", pathexp)
 
+	resp := []apitypes.CredentialResp{}
+	err := c.client.Pages("/credentials", v, false).All(ctx, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := make([]apitypes.CredentialEnvelope, len(resp))
+	for i, c := range resp {
+		v, err := createEnvelopeFromResp(c)
+		if err != nil {
+			return nil, err
+		}
+		creds[i] = *v
+	}
+
+	return creds, err
+}
+
+// Get returns all credentials at the given path.
+func (c *CredentialsClient) Get(ctx context.Context, path string) ([]apitypes.CredentialEnvelope, error) {
+	v := &url.Values{}
+	v.Set("path", path)
+
 	req, _, err := c.client.NewRequest("GET", "/credentials", v, nil, false)
 	if err != nil {
 		return nil, err
@@ -44,12 +70,86 @@ func (c *CredentialsClient) Search(ctx context.Context, pathexp string) ([]apity
 	return creds, err
 }
 
-// Get returns all credentials at the given path.
-func (c *CredentialsClient) Get(ctx context.Context, path string) ([]apitypes.CredentialEnvelope, error) {
+// GetMany returns the credentials at each of the given paths, like Get. The
+// paths are fetched in parallel. The credentials found at paths[i] are
+// returned in the i'th element of the result.
+func (c *CredentialsClient) GetMany(ctx context.Context, paths []string) ([][]apitypes.CredentialEnvelope, error) {
+	creds := make([][]apitypes.CredentialEnvelope, len(paths))
+	errs := make([]error, len(paths))
+
+	var wg sync.WaitGroup
+	wg.Add(len(paths))
+	for i, path := range paths {
+		go func(i int, path string) {
+			creds[i], errs[i] = c.Get(ctx, path)
+			wg.Done()
+		}(i, path)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return creds, nil
+}
+
+// GetCached returns all credentials at the given path, like Get. If offline
+// is true, or the daemon can not reach the registry, its cached copy is
+// returned instead, along with the time it was fetched. The time is nil for
+// credentials fresh from the registry.
+func (c *CredentialsClient) GetCached(ctx context.Context, path string,
+	offline bool) ([]apitypes.CredentialEnvelope, *time.Time, error) {
+
 	v := &url.Values{}
 	v.Set("path", path)
+	if offline {
+		v.Set("offline", "true")
+	}
 
 	req, _, err := c.client.NewRequest("GET", "/credentials", v, nil, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp := []apitypes.CredentialResp{}
+
+	res, err := c.client.Do(ctx, req, &resp, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cachedAt *time.Time
+	if h := res.Header.Get(apitypes.CachedAtHeader); h != "" {
+		t, err := time.Parse(time.RFC3339, h)
+		if err != nil {
+			return nil, nil, err
+		}
+		cachedAt = &t
+	}
+
+	creds := make([]apitypes.CredentialEnvelope, len(resp))
+	for i, c := range resp {
+		v, err := createEnvelopeFromResp(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		creds[i] = *v
+	}
+
+	return creds, cachedAt, nil
+}
+
+// History returns every version of the named credential at the given
+// pathexp that can be decrypted, newest first.
+func (c *CredentialsClient) History(ctx context.Context, pathexp, name string) ([]apitypes.CredentialEnvelope, error) {
+	v := &url.Values{}
+	v.Set("pathexp", pathexp)
+	v.Set("name", name)
+
+	req, _, err := c.client.NewRequest("GET", "/credentials/history", v, nil, false)
 	if err != nil {
 		return nil, err
 	}
@@ -73,12 +173,66 @@ func (c *CredentialsClient) Get(ctx context.Context, path string) ([]apitypes.Cr
 	return creds, err
 }
 
-// Create creates the given credential
+// SearchNames returns where the secrets in the org whose names match pattern
+// are set. pattern is a glob matching the whole name, or, if regex is true, a
+// regular expression. If path is not empty, only secrets set at paths that
+// overlap it are returned, or, if exact is true, set at exactly it. Values
+// are never returned.
+func (c *CredentialsClient) SearchNames(ctx context.Context, orgID *identity.ID,
+	pattern string, regex bool, path string, exact bool) ([]apitypes.CredentialLocation, error) {
+
+	v := &url.Values{}
+	v.Set("org_id", orgID.String())
+	v.Set("pattern", pattern)
+	if regex {
+		v.Set("regex", "true")
+	}
+	if path != "" {
+		v.Set("path", path)
+	}
+	if exact {
+		v.Set("exact", "true")
+	}
+
+	req, _, err := c.client.NewRequest("GET", "/credentials/search", v, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := []apitypes.CredentialLocation{}
+	_, err = c.client.Do(ctx, req, &locations, nil, nil)
+	return locations, err
+}
+
+// Create creates the given credential.
+//
+// The credential fails to be created with a conflict error if another version
+// of it is written at the same time, unless force is true.
+//
+// Writing to a protected environment fails with a confirmation required error
+// unless confirmed is true.
 func (c *CredentialsClient) Create(ctx context.Context, cred *apitypes.Credential,
+	force, confirmed bool, progress *ProgressFunc) (*apitypes.CredentialEnvelope, error) {
+
+	return c.create(ctx, cred, writeQuery(force, confirmed), progress)
+}
+
+// Generate creates the given credential like Create, with a value generated
+// by the daemon as described by spec. The value of cred is ignored.
+func (c *CredentialsClient) Generate(ctx context.Context, cred *apitypes.Credential,
+	spec *apitypes.GeneratorSpec, force, confirmed bool,
 	progress *ProgressFunc) (*apitypes.CredentialEnvelope, error) {
 
-	env := apitypes.CredentialEnvelope{Version: 2, Body: cred}
-	req, reqID, err := c.client.NewRequest("POST", "/credentials", nil, &env, false)
+	v := writeQuery(force, confirmed)
+	v.Set("generate", spec.String())
+	return c.create(ctx, cred, v, progress)
+}
+
+func (c *CredentialsClient) create(ctx context.Context, cred *apitypes.Credential,
+	v *url.Values, progress *ProgressFunc) (*apitypes.CredentialEnvelope, error) {
+
+	env := apitypes.CredentialEnvelope{Version: 3, Body: cred}
+	req, reqID, err := c.client.NewRequest("POST", "/credentials", v, &env, false)
 	if err != nil {
 		return nil, err
 	}
@@ -93,6 +247,126 @@ func (c *CredentialsClient) Create(ctx context.Context, cred *apitypes.Credentia
 	return out, err
 }
 
+// CreateBatch creates all of the given credentials at once. They must all
+// share the same pathexp.
+//
+// Like Create, the credentials fail to be created with a conflict error if
+// another version of any of them is written at the same time, unless force is
+// true, or with a confirmation required error if they are in a protected
+// environment, unless confirmed is true.
+func (c *CredentialsClient) CreateBatch(ctx context.Context, creds []apitypes.Credential,
+	force, confirmed bool, progress *ProgressFunc) ([]apitypes.CredentialEnvelope, error) {
+
+	v := writeQuery(force, confirmed)
+
+	envs := make([]apitypes.CredentialEnvelope, len(creds))
+	for i := range creds {
+		envs[i] = apitypes.CredentialEnvelope{Version: 3, Body: &creds[i]}
+	}
+
+	req, reqID, err := c.client.NewRequest("POST", "/credentials/batch", v, envs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := []apitypes.CredentialResp{}
+	_, err = c.client.Do(ctx, req, &resp, &reqID, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]apitypes.CredentialEnvelope, len(resp))
+	for i, c := range resp {
+		v, err := createEnvelopeFromResp(c)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = *v
+	}
+
+	return out, nil
+}
+
+// Clone copies the secrets in one environment of a project to another, as
+// req asks, in a single operation of the daemon.
+//
+// Writing to a protected environment fails with a confirmation required error
+// unless confirmed is true.
+func (c *CredentialsClient) Clone(ctx context.Context, req *apitypes.EnvironmentCloneRequest,
+	confirmed bool, progress *ProgressFunc) (*apitypes.EnvironmentClone, error) {
+
+	r, reqID, err := c.client.NewRequest("POST", "/credentials/clone",
+		writeQuery(false, confirmed), req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := &apitypes.EnvironmentClone{}
+	_, err = c.client.Do(ctx, r, clone, &reqID, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return clone, nil
+}
+
+// writeQuery returns the query parameters for writing credentials.
+func writeQuery(force, confirmed bool) *url.Values {
+	v := &url.Values{}
+	if force {
+		v.Set("force", "true")
+	}
+	if confirmed {
+		v.Set("confirmed", "true")
+	}
+
+	return v
+}
+
+// Watch waits for the credentials at the given path to change from revision,
+// and returns their new revision. It returns the same revision if they did
+// not change within the daemon's timeout, so it should be called in a loop.
+// An empty revision returns the current revision right away.
+func (c *CredentialsClient) Watch(ctx context.Context, path, revision string) (*apitypes.CredentialsRevision, error) {
+	v := &url.Values{}
+	v.Set("path", path)
+	v.Set("revision", revision)
+
+	req, _, err := c.client.NewRequest("GET", "/credentials/watch", v, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rev := &apitypes.CredentialsRevision{}
+	_, err = c.client.Do(ctx, req, rev, nil, nil)
+	return rev, err
+}
+
+// Prefetch asks the daemon to keep the credentials at the given path cached
+// while it is idle.
+func (c *CredentialsClient) Prefetch(ctx context.Context, path string) error {
+	body := apitypes.PrefetchRequest{Path: path}
+	req, _, err := c.client.NewRequest("POST", "/credentials/prefetch", nil, &body, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.Do(ctx, req, nil, nil, nil)
+	return err
+}
+
+// PrefetchStats returns the state of the daemon's credential prefetch cache.
+func (c *CredentialsClient) PrefetchStats(ctx context.Context) (*apitypes.PrefetchStats, error) {
+	req, _, err := c.client.NewRequest("GET", "/credentials/prefetch", nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &apitypes.PrefetchStats{}
+	_, err = c.client.Do(ctx, req, stats, nil, nil)
+	return stats, err
+}
+
 func createEnvelopeFromResp(c apitypes.CredentialResp) (*apitypes.CredentialEnvelope, error) {
 	var envelope apitypes.CredentialEnvelope
 	var cBody apitypes.Credential
@@ -113,6 +387,14 @@ func createEnvelopeFromResp(c apitypes.CredentialResp) (*apitypes.CredentialEnve
 		}
 
 		cBody = &cBodyV2
+	case 3:
+		cBodyV3 := apitypes.CredentialV3{}
+		err := json.Unmarshal(c.Body, &cBodyV3)
+		if err != nil {
+			return nil, err
+		}
+
+		cBody = &cBodyV3
 	default:
 		return nil, errors.New("Unknown credential version")
 	}
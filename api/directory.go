@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// DirectoryClient makes proxied requests to the registry's user directory,
+// which finds the accounts of users who have chosen to be discoverable by
+// their email address.
+type DirectoryClient struct {
+	client *Client
+}
+
+// Lookup looks up the account for email, for an invitation to the given org.
+func (d *DirectoryClient) Lookup(ctx context.Context, orgID *identity.ID,
+	email string) (*apitypes.DirectoryEntry, error) {
+
+	if err := d.client.require(ctx, FeatureDirectory); err != nil {
+		return nil, err
+	}
+
+	v := &url.Values{}
+	v.Set("org_id", orgID.String())
+	v.Set("email", email)
+
+	req, _, err := d.client.NewRequest("GET", "/directory", v, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &apitypes.DirectoryEntry{}
+	_, err = d.client.Do(ctx, req, entry, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// Settings returns whether the current user is discoverable.
+func (d *DirectoryClient) Settings(ctx context.Context) (*apitypes.DirectorySettings, error) {
+	if err := d.client.require(ctx, FeatureDirectory); err != nil {
+		return nil, err
+	}
+
+	req, _, err := d.client.NewRequest("GET", "/directory/self", nil, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &apitypes.DirectorySettings{}
+	_, err = d.client.Do(ctx, req, settings, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// SetDiscoverable sets whether the current user can be found by their email
+// address.
+func (d *DirectoryClient) SetDiscoverable(ctx context.Context, discoverable bool) (*apitypes.DirectorySettings, error) {
+	if err := d.client.require(ctx, FeatureDirectory); err != nil {
+		return nil, err
+	}
+
+	body := apitypes.DirectorySettings{Discoverable: discoverable}
+	req, _, err := d.client.NewRequest("PUT", "/directory/self", nil, &body, true)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &apitypes.DirectorySettings{}
+	_, err = d.client.Do(ctx, req, settings, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
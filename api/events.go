@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/donovanhide/eventsource"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// EventFunc is called with each org event as it is received, or with an error
+// encountered while receiving them.
+type EventFunc func(evt *apitypes.OrgEvent, err error)
+
+// EventsClient streams the activity in an org from the daemon.
+type EventsClient struct {
+	client *Client
+}
+
+// Follow calls fn with each event in the org from now on, until ctx is done.
+// The stream is reconnected if the daemon goes away, so errors passed to fn
+// are not fatal. It returns an error if the stream can't be started.
+func (e *EventsClient) Follow(ctx context.Context, orgID *identity.ID, fn EventFunc) error {
+	if err := e.client.require(ctx, FeatureEvents); err != nil {
+		return err
+	}
+
+	v := &url.Values{}
+	v.Set("org_id", orgID.String())
+
+	req, _, err := e.client.NewRequest("GET", "/events", v, nil, false)
+	if err != nil {
+		return err
+	}
+
+	stream, err := eventsource.SubscribeWith("", e.client.client, req)
+	if subErr, ok := err.(eventsource.SubscriptionError); ok {
+		rErr := &apitypes.Error{StatusCode: subErr.Code}
+		if json.Unmarshal([]byte(subErr.Message), rErr) == nil {
+			return apitypes.FormatError(rErr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-stream.Events:
+			evt := &apitypes.OrgEvent{}
+			err := json.Unmarshal([]byte(ev.Data()), evt)
+			if err != nil {
+				fn(nil, err)
+				continue
+			}
+			fn(evt, nil)
+		case err := <-stream.Errors:
+			fn(nil, err)
+		}
+	}
+}
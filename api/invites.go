@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/manifoldco/torus-cli/apitypes"
@@ -35,8 +36,10 @@ func (i *InvitesClient) List(ctx context.Context, orgID *identity.ID, states []s
 	return invites, err
 }
 
-// Send creates a new org invitation
-func (i *InvitesClient) Send(ctx context.Context, email string, orgID, inviterID identity.ID, teamIDs []identity.ID) error {
+// Send creates a new org invitation. If inviteeID is set, the invite is
+// associated with that user's account as it is created.
+func (i *InvitesClient) Send(ctx context.Context, email string, orgID, inviterID identity.ID,
+	inviteeID *identity.ID, teamIDs []identity.ID) error {
 	now := time.Now()
 
 	inviteBody := primitive.OrgInvite{
@@ -45,8 +48,8 @@ func (i *InvitesClient) Send(ctx context.Context, email string, orgID, inviterID
 		PendingTeams: teamIDs,
 		Email:        email,
 		Created:      &now,
+		InviteeID:    inviteeID,
 		// Null values below
-		InviteeID:  nil,
 		ApproverID: nil,
 		Accepted:   nil,
 		Approved:   nil,
@@ -72,6 +75,39 @@ func (i *InvitesClient) Send(ctx context.Context, email string, orgID, inviterID
 	return err
 }
 
+// inviteWorkers is how many invites SendMany sends at once.
+const inviteWorkers = 4
+
+// Invitation is one of the invites sent by SendMany.
+type Invitation struct {
+	Email     string
+	InviteeID *identity.ID
+	TeamIDs   []identity.ID
+}
+
+// SendMany creates an org invitation for each of invites, like Send, several
+// at a time. The error from sending invites[i], if any, is returned in the
+// i'th element of the result.
+func (i *InvitesClient) SendMany(ctx context.Context, orgID, inviterID identity.ID,
+	invites []Invitation) []error {
+	errs := make([]error, len(invites))
+	sem := make(chan struct{}, inviteWorkers)
+
+	var wg sync.WaitGroup
+	wg.Add(len(invites))
+	for n, invite := range invites {
+		go func(n int, invite Invitation) {
+			sem <- struct{}{}
+			errs[n] = i.Send(ctx, invite.Email, orgID, inviterID, invite.InviteeID, invite.TeamIDs)
+			<-sem
+			wg.Done()
+		}(n, invite)
+	}
+	wg.Wait()
+
+	return errs
+}
+
 // Accept executes the accept invite request
 func (i *InvitesClient) Accept(ctx context.Context, org, email, code string) error {
 	data := apitypes.InviteAccept{
@@ -118,3 +154,54 @@ func (i *InvitesClient) Approve(ctx context.Context, inviteID identity.ID, outpu
 	_, err = i.client.Do(ctx, req, nil, &reqID, output)
 	return err
 }
+
+// ApproveAll approves every accepted invite to an org at once, sharing the
+// org's keyrings with all of the invitees in a single pass. The outcome for
+// each invite is returned.
+func (i *InvitesClient) ApproveAll(ctx context.Context, orgID identity.ID,
+	output *ProgressFunc) ([]apitypes.InviteApproval, error) {
+
+	body := apitypes.InvitesApproveRequest{OrgID: &orgID}
+	req, reqID, err := i.client.NewRequest("POST", "/org-invites/approve", nil, &body, false)
+	if err != nil {
+		return nil, err
+	}
+
+	approvals := []apitypes.InviteApproval{}
+	_, err = i.client.Do(ctx, req, &approvals, &reqID, output)
+	return approvals, err
+}
+
+// Reject declines an invite that is waiting for approval. The invitee is not
+// added to the org, and the invite can't be accepted again.
+func (i *InvitesClient) Reject(ctx context.Context, inviteID identity.ID) error {
+	if err := i.client.require(ctx, FeatureInviteReject); err != nil {
+		return err
+	}
+
+	req, _, err := i.client.NewRequest("POST", "/org-invites/"+inviteID.String()+"/reject", nil, nil, true)
+	if err != nil {
+		return err
+	}
+
+	_, err = i.client.Do(ctx, req, nil, nil, nil)
+	return err
+}
+
+// Resend asks the registry to deliver the invitation email for the given
+// invite again. This is useful when a previous delivery bounced, or was never
+// opened.
+func (i *InvitesClient) Resend(ctx context.Context, inviteID identity.ID) (*envelope.OrgInvite, error) {
+	if err := i.client.require(ctx, FeatureInviteResend); err != nil {
+		return nil, err
+	}
+
+	req, _, err := i.client.NewRequest("POST", "/org-invites/"+inviteID.String()+"/resend", nil, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	invite := envelope.OrgInvite{}
+	_, err = i.client.Do(ctx, req, &invite, nil, nil)
+	return &invite, err
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"net/url"
+	"sync"
 
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
@@ -37,10 +38,16 @@ type keypairsRequest struct {
 	OrgID *identity.ID `json:"org_id"`
 }
 
-// Generate generates new keypairs for the user in the given org.
+// Generate generates new keypairs for the user in the given org. Its progress
+// is sent on progress, if given, which is closed once it's done.
 func (k *KeypairsClient) Generate(ctx context.Context, orgID *identity.ID,
-	output *ProgressFunc) error {
+	progress chan<- Progress) error {
 
+	defer closeProgress(progress)
+	return k.generate(ctx, orgID, progressSender(progress))
+}
+
+func (k *KeypairsClient) generate(ctx context.Context, orgID *identity.ID, output *ProgressFunc) error {
 	kpr := keypairsRequest{OrgID: orgID}
 
 	req, reqID, err := k.client.NewRequest("POST", "/keypairs/generate", nil, &kpr, false)
@@ -52,20 +59,50 @@ func (k *KeypairsClient) Generate(ctx context.Context, orgID *identity.ID,
 	return err
 }
 
-// List retrieves relevant keypairs by orgID
-func (k *KeypairsClient) List(ctx context.Context, orgID *identity.ID) ([]KeypairResult, error) {
-	v := &url.Values{}
-	if orgID != nil {
-		v.Set("org_id", orgID.String())
+// GenerateMany generates keypairs for the user in each of the given orgs, like
+// Generate, with up to workers requests in flight at once. The progress of
+// every org is combined into one stream on progress, with Completed and Total
+// counting steps across all orgs. The error generating keypairs for orgIDs[i]
+// is returned in the i'th element of the result.
+func (k *KeypairsClient) GenerateMany(ctx context.Context, orgIDs []*identity.ID,
+	workers int, progress chan<- Progress) []error {
+
+	defer closeProgress(progress)
+	output := progressSender(progress)
+
+	errs := make([]error, len(orgIDs))
+	if workers < 1 {
+		workers = 1
 	}
 
-	req, _, err := k.client.NewRequest("GET", "/keypairs", v, nil, true)
-	if err != nil {
-		return nil, err
+	agg := newProgressAggregate(len(orgIDs), output)
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				errs[i] = k.generate(ctx, orgIDs[i], agg.progressFunc(i))
+				agg.done(i)
+			}
+		}()
 	}
 
+	for i := range orgIDs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return errs
+}
+
+// List retrieves relevant keypairs by orgID
+func (k *KeypairsClient) List(ctx context.Context, orgID *identity.ID) ([]KeypairResult, error) {
 	var keypairs []KeypairResult
-	_, err = k.client.Do(ctx, req, &keypairs, nil, nil)
+	err := k.Iter(orgID).All(ctx, &keypairs)
 	if err != nil {
 		return nil, err
 	}
@@ -73,8 +110,22 @@ func (k *KeypairsClient) List(ctx context.Context, orgID *identity.ID) ([]Keypai
 	return keypairs, nil
 }
 
-// Revoke revokes the existing keypairs for the user in the given org.
-func (k *KeypairsClient) Revoke(ctx context.Context, orgID *identity.ID, output *ProgressFunc) error {
+// Iter returns an iterator over pages of the keypairs for orgID. Each page is
+// a []KeypairResult.
+func (k *KeypairsClient) Iter(orgID *identity.ID) *Pages {
+	v := &url.Values{}
+	if orgID != nil {
+		v.Set("org_id", orgID.String())
+	}
+
+	return k.client.Pages("/keypairs", v, true)
+}
+
+// Revoke revokes the existing keypairs for the user in the given org. Its
+// progress is sent on progress, if given, which is closed once it's done.
+func (k *KeypairsClient) Revoke(ctx context.Context, orgID *identity.ID, progress chan<- Progress) error {
+	defer closeProgress(progress)
+
 	kpr := keypairsRequest{OrgID: orgID}
 
 	req, reqID, err := k.client.NewRequest("POST", "/keypairs/revoke", nil, &kpr, false)
@@ -82,6 +133,23 @@ func (k *KeypairsClient) Revoke(ctx context.Context, orgID *identity.ID, output
 		return err
 	}
 
-	_, err = k.client.Do(ctx, req, nil, &reqID, output)
+	_, err = k.client.Do(ctx, req, nil, &reqID, progressSender(progress))
+	return err
+}
+
+// Renew replaces the user's keypairs in the given org with new ones, moving
+// their keyring memberships over to the new keys before revoking the old. Its
+// progress is sent on progress, if given, which is closed once it's done.
+func (k *KeypairsClient) Renew(ctx context.Context, orgID *identity.ID, progress chan<- Progress) error {
+	defer closeProgress(progress)
+
+	kpr := keypairsRequest{OrgID: orgID}
+
+	req, reqID, err := k.client.NewRequest("POST", "/keypairs/renew", nil, &kpr, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = k.client.Do(ctx, req, nil, &reqID, progressSender(progress))
 	return err
 }
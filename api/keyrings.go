@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// KeyringsClient makes requests to the daemon's keyrings endpoints
+type KeyringsClient struct {
+	client *Client
+}
+
+// Audit checks the integrity of every keyring the user can see in the given
+// org.
+func (k *KeyringsClient) Audit(ctx context.Context, orgID *identity.ID,
+	output *ProgressFunc) (*apitypes.KeyringAudit, error) {
+
+	v := &url.Values{}
+	v.Set("org_id", orgID.String())
+
+	req, reqID, err := k.client.NewRequest("GET", "/keyrings/audit", v, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := &apitypes.KeyringAudit{}
+	_, err = k.client.Do(ctx, req, audit, &reqID, output)
+	if err != nil {
+		return nil, err
+	}
+
+	return audit, nil
+}
+
+// Members returns the current version of every keyring in the given org whose
+// path expression overlaps pathexp, along with who each is shared with.
+func (k *KeyringsClient) Members(ctx context.Context, orgID *identity.ID,
+	pathexp string) ([]apitypes.KeyringMembers, error) {
+
+	v := &url.Values{}
+	v.Set("org_id", orgID.String())
+	v.Set("pathexp", pathexp)
+
+	req, reqID, err := k.client.NewRequest("GET", "/keyrings/members", v, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	keyrings := []apitypes.KeyringMembers{}
+	_, err = k.client.Do(ctx, req, &keyrings, &reqID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return keyrings, nil
+}
+
+// Rotate creates a new version of the keyring for the given path expression,
+// with a new master encryption key shared only with its current members, and
+// encrypts its secrets again with it.
+func (k *KeyringsClient) Rotate(ctx context.Context, pathexp string,
+	output *ProgressFunc) (*apitypes.KeyringRotation, error) {
+
+	body := apitypes.KeyringRotationRequest{PathExp: pathexp}
+	req, reqID, err := k.client.NewRequest("POST", "/keyrings/rotate", nil, &body, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rotation := &apitypes.KeyringRotation{}
+	_, err = k.client.Do(ctx, req, rotation, &reqID, output)
+	if err != nil {
+		return nil, err
+	}
+
+	return rotation, nil
+}
+
+// Freeze freezes every keyring in the org matching the given path expression,
+// blocking writes to its secrets, and reads by anyone not breaking the glass.
+func (k *KeyringsClient) Freeze(ctx context.Context, orgID *identity.ID,
+	pathexp, reason string) (*apitypes.KeyringFreezeResult, error) {
+
+	body := apitypes.KeyringFreezeRequest{OrgID: orgID, PathExp: pathexp, Reason: reason}
+	return k.freeze(ctx, "/keyrings/freeze", &body)
+}
+
+// Unfreeze lifts the freeze on every keyring in the org matching the given
+// path expression.
+func (k *KeyringsClient) Unfreeze(ctx context.Context, orgID *identity.ID,
+	pathexp string) (*apitypes.KeyringFreezeResult, error) {
+
+	body := apitypes.KeyringFreezeRequest{OrgID: orgID, PathExp: pathexp}
+	return k.freeze(ctx, "/keyrings/unfreeze", &body)
+}
+
+func (k *KeyringsClient) freeze(ctx context.Context, path string,
+	body *apitypes.KeyringFreezeRequest) (*apitypes.KeyringFreezeResult, error) {
+
+	if err := k.client.require(ctx, FeatureFreeze); err != nil {
+		return nil, err
+	}
+
+	req, reqID, err := k.client.NewRequest("POST", path, nil, body, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &apitypes.KeyringFreezeResult{}
+	_, err = k.client.Do(ctx, req, result, &reqID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
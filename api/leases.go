@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+// LeasesClient holds secrets in the daemon for processes run by torus run,
+// rather than in their environment.
+type LeasesClient struct {
+	client *Client
+}
+
+// Create asks the daemon to hold vars until ttl has passed.
+func (l *LeasesClient) Create(ctx context.Context, vars []apitypes.LeaseVar, ttl time.Duration) (*apitypes.Lease, error) {
+	body := &apitypes.LeaseRequest{TTL: int64(ttl / time.Second), Vars: vars}
+	req, _, err := l.client.NewRequest("POST", "/leases", nil, body, false)
+	if err != nil {
+		return nil, err
+	}
+
+	lease := apitypes.Lease{}
+	_, err = l.client.Do(ctx, req, &lease, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lease, nil
+}
+
+// Get returns the variables held by the lease with the given id and token.
+func (l *LeasesClient) Get(ctx context.Context, id, token string) ([]apitypes.LeaseVar, error) {
+	req, _, err := l.client.NewRequest("GET", "/leases/"+id, nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(apitypes.LeaseTokenHeader, token)
+
+	var vars []apitypes.LeaseVar
+	_, err = l.client.Do(ctx, req, &vars, nil, nil)
+	return vars, err
+}
+
+// Revoke revokes the lease with the given id and token, so its variables can
+// no longer be read.
+func (l *LeasesClient) Revoke(ctx context.Context, id, token string) error {
+	req, _, err := l.client.NewRequest("DELETE", "/leases/"+id, nil, nil, false)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(apitypes.LeaseTokenHeader, token)
+
+	_, err = l.client.Do(ctx, req, nil, nil, nil)
+	return err
+}
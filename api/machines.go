@@ -74,13 +74,8 @@ func (m *MachinesClient) List(ctx context.Context, orgID *identity.ID, state *st
 		v.Add("name", *name)
 	}
 
-	req, reqID, err := m.client.NewRequest("GET", "/machines", v, nil, true)
-	if err != nil {
-		return nil, err
-	}
-
 	var results []*apitypes.MachineSegment
-	_, err = m.client.Do(ctx, req, &results, &reqID, nil)
+	err := m.client.Pages("/machines", v, true).All(ctx, &results)
 	if err != nil {
 		return nil, err
 	}
@@ -100,6 +95,23 @@ func (m *MachinesClient) Destroy(ctx context.Context, machineID *identity.ID) er
 	return err
 }
 
+// Restrict the networks the active tokens of a machine can be used from.
+// Passing no CIDRs lifts any existing restriction.
+func (m *MachinesClient) Restrict(ctx context.Context, machineID *identity.ID, cidrs []string) error {
+	if cidrs == nil {
+		cidrs = []string{}
+	}
+
+	body := apitypes.MachineTokenRestriction{AllowedCIDRs: cidrs}
+	req, reqID, err := m.client.NewRequest("PATCH", "/machines/"+machineID.String()+"/tokens", nil, &body, true)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.client.Do(ctx, req, nil, &reqID, nil)
+	return err
+}
+
 // Get machine by ID
 func (m *MachinesClient) Get(ctx context.Context, machineID *identity.ID) (*apitypes.MachineSegment, error) {
 	req, reqID, err := m.client.NewRequest("GET", "/machines/"+machineID.String(), nil, nil, true)
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+type mfaCodeKey struct{}
+
+// WithMFACode returns a copy of ctx carrying a code from the user's
+// authenticator app. Requests made with it send the code along, for
+// operations the registry requires multi-factor authentication for.
+func WithMFACode(ctx context.Context, code string) context.Context {
+	return context.WithValue(ctx, mfaCodeKey{}, code)
+}
+
+func mfaCode(ctx context.Context) string {
+	code, _ := ctx.Value(mfaCodeKey{}).(string)
+	return code
+}
+
+// MFAClient manages the current user's multi-factor authentication, with
+// codes from a TOTP authenticator app.
+type MFAClient struct {
+	client *Client
+}
+
+// Status returns whether the current user has multi-factor authentication
+// enabled.
+func (m *MFAClient) Status(ctx context.Context) (*apitypes.MFAStatus, error) {
+	if err := m.client.require(ctx, FeatureMFA); err != nil {
+		return nil, err
+	}
+
+	req, _, err := m.client.NewRequest("GET", "/users/self/mfa", nil, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	status := apitypes.MFAStatus{}
+	_, err = m.client.Do(ctx, req, &status, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// Enroll creates a new TOTP secret for the current user. Multi-factor
+// authentication isn't enabled until a code generated from it is confirmed.
+func (m *MFAClient) Enroll(ctx context.Context) (*apitypes.MFAEnrollment, error) {
+	if err := m.client.require(ctx, FeatureMFA); err != nil {
+		return nil, err
+	}
+
+	req, _, err := m.client.NewRequest("POST", "/users/self/mfa", nil, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment := apitypes.MFAEnrollment{}
+	_, err = m.client.Do(ctx, req, &enrollment, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+// Confirm enables multi-factor authentication, given a code generated from
+// the secret returned by Enroll.
+func (m *MFAClient) Confirm(ctx context.Context, code string) (*apitypes.MFAStatus, error) {
+	if err := m.client.require(ctx, FeatureMFA); err != nil {
+		return nil, err
+	}
+
+	req, _, err := m.client.NewRequest("POST", "/users/self/mfa/confirm", nil, &apitypes.MFACode{Code: code}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	status := apitypes.MFAStatus{}
+	_, err = m.client.Do(ctx, req, &status, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// Disable turns off multi-factor authentication. The registry requires a
+// current code, given with WithMFACode.
+func (m *MFAClient) Disable(ctx context.Context) error {
+	if err := m.client.require(ctx, FeatureMFA); err != nil {
+		return err
+	}
+
+	req, _, err := m.client.NewRequest("DELETE", "/users/self/mfa", nil, nil, true)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.client.Do(ctx, req, nil, nil, nil)
+	return err
+}
@@ -76,16 +76,17 @@ func (o *OrgsClient) GetByName(ctx context.Context, name string) (*envelope.Org,
 
 // List returns all organizations that the signed-in user has access to
 func (o *OrgsClient) List(ctx context.Context) ([]envelope.Org, error) {
-	req, _, err := o.client.NewRequest("GET", "/orgs", nil, nil, true)
-	if err != nil {
-		return nil, err
-	}
-
 	var orgs []envelope.Org
-	_, err = o.client.Do(ctx, req, &orgs, nil, nil)
+	err := o.Iter().All(ctx, &orgs)
 	return orgs, err
 }
 
+// Iter returns an iterator over pages of the orgs the user belongs to. Each
+// page is a []envelope.Org.
+func (o *OrgsClient) Iter() *Pages {
+	return o.client.Pages("/orgs", nil, true)
+}
+
 // RemoveMember removes a user from an org
 func (o *OrgsClient) RemoveMember(ctx context.Context, orgID identity.ID,
 	userID identity.ID) error {
@@ -100,6 +101,22 @@ func (o *OrgsClient) RemoveMember(ctx context.Context, orgID identity.ID,
 	return err
 }
 
+// Delete deletes an org, along with everything in it. The daemon forgets the
+// keys and secrets it has cached for the org.
+func (o *OrgsClient) Delete(ctx context.Context, orgID identity.ID) error {
+	if err := o.client.require(ctx, FeatureOrgDelete); err != nil {
+		return err
+	}
+
+	req, _, err := o.client.NewRequest("DELETE", "/orgs/"+orgID.String(), nil, nil, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = o.client.Do(ctx, req, nil, nil, nil)
+	return err
+}
+
 // GetTree returns an org tree
 func (o *OrgsClient) GetTree(ctx context.Context, orgID identity.ID) ([]OrgTreeSegment, error) {
 	v := &url.Values{}
@@ -113,3 +130,46 @@ func (o *OrgsClient) GetTree(ctx context.Context, orgID identity.ID) ([]OrgTreeS
 	_, err = o.client.Do(ctx, req, &segments, nil, nil)
 	return segments, err
 }
+
+// GetSettings returns the settings of an org. Registries without org settings
+// have the defaults for every org.
+func (o *OrgsClient) GetSettings(ctx context.Context, orgID identity.ID) (*apitypes.OrgSettings, error) {
+	ok, err := o.client.Supports(ctx, FeatureOrgSettings)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &apitypes.OrgSettings{}, nil
+	}
+
+	req, _, err := o.client.NewRequest("GET", "/orgs/"+orgID.String()+"/settings", nil, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := apitypes.OrgSettings{}
+	_, err = o.client.Do(ctx, req, &settings, nil, nil)
+	if apitypes.IsNotFoundError(err) {
+		return &settings, nil // no settings have been saved yet
+	}
+
+	return &settings, err
+}
+
+// UpdateSettings replaces the settings of an org.
+func (o *OrgsClient) UpdateSettings(ctx context.Context, orgID identity.ID,
+	settings *apitypes.OrgSettings) (*apitypes.OrgSettings, error) {
+
+	if err := o.client.require(ctx, FeatureOrgSettings); err != nil {
+		return nil, err
+	}
+
+	req, _, err := o.client.NewRequest("PUT", "/orgs/"+orgID.String()+"/settings", nil, settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	res := apitypes.OrgSettings{}
+	_, err = o.client.Do(ctx, req, &res, nil, nil)
+	return &res, err
+}
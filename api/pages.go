@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// cursorParam is the query parameter used to request a page after the first.
+const cursorParam = "cursor"
+
+// Pages iterates over the pages of a list endpoint.
+//
+// Endpoints that paginate their results return a Link header with a "next"
+// relation pointing at the following page. The cursor query parameter from
+// that link is sent with the next request. Endpoints that don't paginate are
+// read as a single page.
+type Pages struct {
+	client  *Client
+	path    string
+	query   url.Values
+	proxied bool
+
+	cursor string
+	done   bool
+	err    error
+}
+
+// Pages returns a Pages for the GET endpoint at path.
+func (c *Client) Pages(path string, query *url.Values, proxied bool) *Pages {
+	q := url.Values{}
+	if query != nil {
+		for k, v := range *query {
+			q[k] = v
+		}
+	}
+
+	return &Pages{client: c, path: path, query: q, proxied: proxied}
+}
+
+// Next fetches the next page, decoding it into v. It returns false once
+// every page has been read, or if an error occurred, which is returned by
+// Err.
+func (p *Pages) Next(ctx context.Context, v interface{}) bool {
+	if p.done {
+		return false
+	}
+
+	if p.cursor != "" {
+		p.query.Set(cursorParam, p.cursor)
+	}
+
+	req, _, err := p.client.NewRequest("GET", p.path, &p.query, nil, p.proxied)
+	if err != nil {
+		p.err = err
+		p.done = true
+		return false
+	}
+
+	resp, err := p.client.Do(ctx, req, v, nil, nil)
+	if err != nil {
+		p.err = err
+		p.done = true
+		return false
+	}
+
+	p.cursor = nextCursor(resp.Header.Get("Link"))
+	p.done = p.cursor == ""
+	return true
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (p *Pages) Err() error {
+	return p.err
+}
+
+// All reads every remaining page into v, which must be a pointer to a slice.
+func (p *Pages) All(ctx context.Context, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("All requires a pointer to a slice")
+	}
+
+	all := rv.Elem()
+	for {
+		page := reflect.New(all.Type())
+		if !p.Next(ctx, page.Interface()) {
+			break
+		}
+		all = reflect.AppendSlice(all, page.Elem())
+	}
+
+	if p.err != nil {
+		return p.err
+	}
+
+	if all.IsNil() {
+		all = reflect.MakeSlice(all.Type(), 0, 0)
+	}
+	rv.Elem().Set(all)
+	return nil
+}
+
+// nextCursor returns the cursor for the "next" relation of a Link header, or
+// an empty string if there is none.
+func nextCursor(link string) string {
+	for _, l := range strings.Split(link, ",") {
+		parts := strings.Split(l, ";")
+		target := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+
+		next := false
+		for _, param := range parts[1:] {
+			param = strings.Replace(strings.TrimSpace(param), " ", "", -1)
+			if param == `rel="next"` || param == "rel=next" {
+				next = true
+			}
+		}
+		if !next {
+			continue
+		}
+
+		u, err := url.Parse(target[1 : len(target)-1])
+		if err != nil {
+			return ""
+		}
+		return u.Query().Get(cursorParam)
+	}
+
+	return ""
+}
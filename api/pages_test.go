@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestNextCursor(t *testing.T) {
+	tcs := []struct {
+		link, cursor string
+	}{
+		{"", ""},
+		{`</orgs?cursor=abc>; rel="next"`, "abc"},
+		{`</orgs?cursor=a>; rel="prev", </orgs?cursor=c&limit=5>; rel="next"`, "c"},
+		{`</orgs?cursor=a>; rel="prev"`, ""},
+		{`</orgs?page=2>; rel=next`, ""},
+	}
+
+	for _, tc := range tcs {
+		if got := nextCursor(tc.link); got != tc.cursor {
+			t.Errorf("%q: got %q want %q", tc.link, got, tc.cursor)
+		}
+	}
+}
+
+type pagesTransport struct {
+	pages map[string]string
+	links map[string]string
+}
+
+func (p *pagesTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	cursor := r.URL.Query().Get(cursorParam)
+
+	rec := httptest.NewRecorder()
+	if link, ok := p.links[cursor]; ok {
+		rec.Header().Set("Link", link)
+	}
+	rec.WriteString(p.pages[cursor])
+
+	return rec.Result(), nil
+}
+
+func TestPagesAll(t *testing.T) {
+	rt := &pagesTransport{
+		pages: map[string]string{"": `["a","b"]`, "2": `["c"]`, "3": `[]`},
+		links: map[string]string{
+			"":  `</proxy/things?cursor=2>; rel="next"`,
+			"2": `</proxy/things?cursor=3>; rel="next"`,
+		},
+	}
+	c := NewClientWithTransport(rt)
+
+	var all []string
+	err := c.Pages("/things", &url.Values{}, true).All(context.Background(), &all)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(all, want) {
+		t.Errorf("got %v want %v", all, want)
+	}
+
+	pages := 0
+	p := c.Pages("/things", nil, true)
+	var page []string
+	for p.Next(context.Background(), &page) {
+		pages++
+	}
+	if p.Err() != nil || pages != 3 {
+		t.Errorf("got %d pages, err %v", pages, p.Err())
+	}
+}
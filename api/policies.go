@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/url"
 
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/primitive"
@@ -14,8 +15,23 @@ type PoliciesClient struct {
 	client *Client
 }
 
-// Create creates a new policy
+// Create creates a new policy. If the registry supports it, the daemon signs
+// the policy with the user's signing key for its org first.
 func (p *PoliciesClient) Create(ctx context.Context, policy *primitive.Policy) (*envelope.Policy, error) {
+	signed, err := p.client.Supports(ctx, FeatureSignedPolicy)
+	if err != nil {
+		return nil, err
+	}
+	if signed {
+		req, _, err := p.client.NewRequest("POST", "/policies", nil, policy, false)
+		if err != nil {
+			return nil, err
+		}
+
+		res := envelope.Policy{}
+		_, err = p.client.Do(ctx, req, &res, nil, nil)
+		return &res, err
+	}
 
 	ID, err := identity.NewMutable(policy)
 	if err != nil {
@@ -58,6 +74,38 @@ func (p *PoliciesClient) List(ctx context.Context, orgID *identity.ID, name stri
 	return policies, err
 }
 
+// Signatures returns the outcome of verifying the signature of each policy in
+// the org, as checked by the daemon.
+func (p *PoliciesClient) Signatures(ctx context.Context, orgID *identity.ID) ([]apitypes.PolicySignature, error) {
+	v := &url.Values{}
+	v.Set("org_id", orgID.String())
+
+	req, _, err := p.client.NewRequest("GET", "/policies/signatures", v, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	signatures := []apitypes.PolicySignature{}
+	_, err = p.client.Do(ctx, req, &signatures, nil, nil)
+	return signatures, err
+}
+
+// Sign signs an existing policy with the user's signing key for its org. It
+// is used for policies created before the registry supported signing them.
+func (p *PoliciesClient) Sign(ctx context.Context, orgID, policyID *identity.ID) (*envelope.Policy, error) {
+	v := &url.Values{}
+	v.Set("org_id", orgID.String())
+
+	req, _, err := p.client.NewRequest("POST", "/policies/"+policyID.String()+"/sign", v, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	res := envelope.Policy{}
+	_, err = p.client.Do(ctx, req, &res, nil, nil)
+	return &res, err
+}
+
 // Attach attaches a policy to a team
 func (p *PoliciesClient) Attach(ctx context.Context, org, policy, team *identity.ID) error {
 	attachment := primitive.PolicyAttachment{
@@ -117,3 +165,33 @@ func (p *PoliciesClient) AttachmentsList(ctx context.Context, orgID, ownerID, po
 	_, err = p.client.Do(ctx, req, &attachments, nil, nil)
 	return attachments, err
 }
+
+// AttachedTeams retrieves the attachments of a policy, along with the teams
+// and machine roles they attach it to. Attachments whose team can no longer be
+// found are returned with a nil Team.
+func (p *PoliciesClient) AttachedTeams(ctx context.Context, orgID, policyID *identity.ID) ([]apitypes.PolicyAttachmentSegment, error) {
+	attachments, err := p.AttachmentsList(ctx, orgID, nil, policyID)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := p.client.Teams.GetByOrg(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	teamsByID := make(map[identity.ID]*envelope.Team, len(teams))
+	for i := range teams {
+		teamsByID[*teams[i].ID] = &teams[i]
+	}
+
+	segments := make([]apitypes.PolicyAttachmentSegment, len(attachments))
+	for i := range attachments {
+		segments[i] = apitypes.PolicyAttachmentSegment{
+			Attachment: &attachments[i],
+			Team:       teamsByID[*attachments[i].Body.OwnerID],
+		}
+	}
+
+	return segments, nil
+}
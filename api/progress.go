@@ -1,5 +1,10 @@
 package api
 
+import (
+	"sync"
+	"time"
+)
+
 // ProgressFunc is used to output events
 type ProgressFunc func(event *Event, err error)
 
@@ -7,7 +12,176 @@ type ProgressFunc func(event *Event, err error)
 type Event struct {
 	ID          string `json:"id"`
 	MessageType string
+	Step        string `json:"step"`
 	Message     string `json:"message"`
 	Completed   int    `json:"completed"`
 	Total       int    `json:"total"`
+
+	// Elapsed is the number of milliseconds since the request started.
+	Elapsed int64 `json:"elapsed"`
+}
+
+// ElapsedTime returns the time since the request started.
+func (e *Event) ElapsedTime() time.Duration {
+	return time.Duration(e.Elapsed) * time.Millisecond
+}
+
+// ETA estimates the time left until the request completes, assuming the
+// remaining steps take as long as the completed ones did on average. It
+// returns -1 when no steps have completed yet.
+func (e *Event) ETA() time.Duration {
+	if e.Completed <= 0 {
+		return -1
+	}
+	if e.Completed >= e.Total {
+		return 0
+	}
+
+	perStep := e.ElapsedTime() / time.Duration(e.Completed)
+	return perStep * time.Duration(e.Total-e.Completed)
+}
+
+// Progress is a structured update on a long running request, such as
+// generating keypairs.
+type Progress struct {
+	// Stage is the step the request is on, such as derive, claim, or upload.
+	Stage   string
+	Message string
+
+	// Percent is how much of the request is done, from 0 to 100, counting
+	// Completed of Total steps.
+	Percent   int
+	Completed int
+	Total     int
+
+	// Elapsed is the time since the request started, and ETA the estimated
+	// time left, or -1 when it's unknown.
+	Elapsed time.Duration
+	ETA     time.Duration
+}
+
+// newProgress returns the Progress reported by evt.
+func newProgress(evt *Event) Progress {
+	p := Progress{
+		Stage:     evt.Step,
+		Message:   evt.Message,
+		Completed: evt.Completed,
+		Total:     evt.Total,
+		Elapsed:   evt.ElapsedTime(),
+		ETA:       evt.ETA(),
+	}
+
+	if evt.Total > 0 {
+		p.Percent = 100 * evt.Completed / evt.Total
+		if p.Percent > 100 {
+			p.Percent = 100
+		}
+	}
+
+	return p
+}
+
+// progressSender returns a ProgressFunc sending each event to progress, or
+// nil if progress is nil. Errors reading the events are dropped; they don't
+// affect the request.
+func progressSender(progress chan<- Progress) *ProgressFunc {
+	if progress == nil {
+		return nil
+	}
+
+	fn := ProgressFunc(func(evt *Event, err error) {
+		if evt != nil {
+			progress <- newProgress(evt)
+		}
+	})
+	return &fn
+}
+
+// closeProgress closes progress, if given, once the request is done.
+func closeProgress(progress chan<- Progress) {
+	if progress != nil {
+		close(progress)
+	}
+}
+
+// progressAggregate combines the progress of several concurrent requests into
+// a single stream of events, counting the steps of all of them.
+type progressAggregate struct {
+	mutex     sync.Mutex
+	started   time.Time
+	completed []int
+	total     []int
+	output    *ProgressFunc
+}
+
+func newProgressAggregate(n int, output *ProgressFunc) *progressAggregate {
+	return &progressAggregate{
+		started:   time.Now(),
+		completed: make([]int, n),
+		total:     make([]int, n),
+		output:    output,
+	}
+}
+
+// progressFunc returns the ProgressFunc for the i'th request, or nil if there
+// is no output.
+func (a *progressAggregate) progressFunc(i int) *ProgressFunc {
+	if a.output == nil {
+		return nil
+	}
+
+	fn := ProgressFunc(func(evt *Event, err error) {
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+
+		if evt == nil {
+			(*a.output)(nil, err)
+			return
+		}
+
+		a.completed[i] = evt.Completed
+		a.total[i] = evt.Total
+		a.emit(evt.Step, evt.Message)
+	})
+	return &fn
+}
+
+// done marks the i'th request as finished, whether or not it reported all of
+// its steps.
+func (a *progressAggregate) done(i int) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.total[i] == 0 {
+		a.total[i] = 1
+	}
+	a.completed[i] = a.total[i]
+}
+
+// emit sends an event with the combined counts. Requests that haven't
+// reported yet are assumed to have as many steps as the longest one that has.
+// The mutex must be held.
+func (a *progressAggregate) emit(step, message string) {
+	evt := &Event{
+		Step:    step,
+		Message: message,
+		Elapsed: int64(time.Since(a.started) / time.Millisecond),
+	}
+
+	steps := 0
+	for _, t := range a.total {
+		if t > steps {
+			steps = t
+		}
+	}
+
+	for i, t := range a.total {
+		if t == 0 {
+			t = steps
+		}
+		evt.Completed += a.completed[i]
+		evt.Total += t
+	}
+
+	(*a.output)(evt, nil)
 }
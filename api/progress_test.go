@@ -0,0 +1,82 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventETA(t *testing.T) {
+	tcs := []struct {
+		name  string
+		event Event
+		eta   time.Duration
+	}{
+		{"nothing completed", Event{Completed: 0, Total: 4, Elapsed: 100}, -1},
+		{"half completed", Event{Completed: 2, Total: 4, Elapsed: 3000}, 3 * time.Second},
+		{"all completed", Event{Completed: 4, Total: 4, Elapsed: 3000}, 0},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			eta := tc.event.ETA()
+			if eta != tc.eta {
+				t.Errorf("wrong eta. got %s want %s", eta, tc.eta)
+			}
+		})
+	}
+}
+
+func TestProgressAggregate(t *testing.T) {
+	var events []Event
+	output := ProgressFunc(func(evt *Event, err error) {
+		events = append(events, *evt)
+	})
+
+	agg := newProgressAggregate(3, &output)
+	first := agg.progressFunc(0)
+	second := agg.progressFunc(1)
+
+	(*first)(&Event{Message: "a", Completed: 1, Total: 4}, nil)
+	(*second)(&Event{Message: "b", Completed: 2, Total: 4}, nil)
+	agg.done(0)
+	(*second)(&Event{Message: "b", Completed: 3, Total: 4}, nil)
+
+	want := []struct{ completed, total int }{{1, 12}, {3, 12}, {7, 12}}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, w := range want {
+		if events[i].Completed != w.completed || events[i].Total != w.total {
+			t.Errorf("event %d: got %d/%d, want %d/%d", i,
+				events[i].Completed, events[i].Total, w.completed, w.total)
+		}
+	}
+}
+
+func TestProgressSender(t *testing.T) {
+	if progressSender(nil) != nil {
+		t.Fatal("expected no ProgressFunc without a channel")
+	}
+
+	progress := make(chan Progress, 2)
+	send := *progressSender(progress)
+	send(nil, nil)
+	send(&Event{Step: "claim", Message: "signed", Completed: 1, Total: 4, Elapsed: 500}, nil)
+	closeProgress(progress)
+
+	var got []Progress
+	for p := range progress {
+		got = append(got, p)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("got %d updates, want 1", len(got))
+	}
+	p := got[0]
+	if p.Stage != "claim" || p.Message != "signed" || p.Percent != 25 {
+		t.Errorf("wrong update: %+v", p)
+	}
+	if p.Elapsed != 500*time.Millisecond || p.ETA != 1500*time.Millisecond {
+		t.Errorf("wrong times: elapsed %s, eta %s", p.Elapsed, p.ETA)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
 )
 
 // ProjectsClient makes proxied requests to the registry's projects endpoints
@@ -70,6 +71,39 @@ func (p *ProjectsClient) List(ctx context.Context, orgIDs *[]*identity.ID, names
 	return projects, err
 }
 
+type projectStateRequest struct {
+	State string `json:"state"`
+}
+
+// Archive archives the project with the given ID, hiding it from listings
+// and blocking new secrets from being set in it. Its keyrings are kept.
+func (p *ProjectsClient) Archive(ctx context.Context, projectID *identity.ID) (*envelope.Project, error) {
+	return p.setState(ctx, projectID, primitive.ProjectArchivedState)
+}
+
+// Restore makes the archived project with the given ID active again.
+func (p *ProjectsClient) Restore(ctx context.Context, projectID *identity.ID) (*envelope.Project, error) {
+	return p.setState(ctx, projectID, primitive.ProjectActiveState)
+}
+
+func (p *ProjectsClient) setState(ctx context.Context, projectID *identity.ID,
+	state string) (*envelope.Project, error) {
+
+	if err := p.client.require(ctx, FeatureArchive); err != nil {
+		return nil, err
+	}
+
+	body := projectStateRequest{State: state}
+	req, _, err := p.client.NewRequest("PATCH", "/projects/"+projectID.String(), nil, &body, true)
+	if err != nil {
+		return nil, err
+	}
+
+	res := envelope.Project{}
+	_, err = p.client.Do(ctx, req, &res, nil, nil)
+	return &res, err
+}
+
 // GetTree returns a project tree
 func (p *ProjectsClient) GetTree(ctx context.Context, orgID *identity.ID) ([]ProjectTreeSegment, error) {
 	v := &url.Values{}
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// RecorderMode selects whether a Recorder captures or replays interactions.
+type RecorderMode int
+
+// Recorder modes
+const (
+	// RecordMode passes requests through to a real transport, capturing each
+	// request and response.
+	RecordMode RecorderMode = iota
+
+	// ReplayMode answers requests from previously captured interactions,
+	// without making any network calls.
+	ReplayMode
+)
+
+const redacted = "REDACTED"
+
+// DefaultRedactFields are the JSON object keys whose values are replaced
+// before interactions are written to a fixture. The plaintext of credential
+// values is always replaced.
+var DefaultRedactFields = []string{
+	"password", "passphrase", "secret", "auth_token", "login_token",
+	"login_token_hmac", "login_token_sig", "token_secret",
+}
+
+// redactHeaders are never written to fixtures. X-Request-ID changes on every
+// request, and the others may carry credentials.
+var redactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Request-ID"}
+
+// Interaction is a single captured request and its response.
+type Interaction struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	RequestBody  string      `json:"request_body,omitempty"`
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header,omitempty"`
+	ResponseBody string      `json:"response_body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records interactions with the daemon
+// and registry into a fixture file, and replays them deterministically.
+//
+// Use it with NewClientWithTransport to write hermetic tests for code built on
+// top of a Client:
+//
+//	rec, err := api.NewRecorder("fixtures/keypairs.json", api.ReplayMode, nil)
+//	client := api.NewClientWithTransport(rec)
+//
+// Fixtures are captured by running the same code once in RecordMode against a
+// real daemon, then calling Save. Before being saved, request and response
+// bodies are sanitized by replacing the values of any JSON keys listed in
+// RedactFields, and the plaintext of any credentials.
+//
+// Progress events are not recorded; requests made with a ProgressFunc replay
+// without emitting any events.
+type Recorder struct {
+	// RedactFields lists the JSON keys to sanitize. It defaults to
+	// DefaultRedactFields.
+	RedactFields []string
+
+	// MatchBody requires a replayed request's sanitized body to match the
+	// recorded body, in addition to its method and URL. It is off by default,
+	// as many request bodies contain freshly generated ids and nonces.
+	MatchBody bool
+
+	mode         RecorderMode
+	path         string
+	transport    http.RoundTripper
+	mutex        sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder returns a Recorder backed by the fixture file at path.
+//
+// In RecordMode, requests are sent via transport, which must not be nil; see
+// NewTransport. In ReplayMode, the fixture is loaded from path, and transport
+// is ignored.
+func NewRecorder(path string, mode RecorderMode, transport http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{
+		RedactFields: DefaultRedactFields,
+		mode:         mode,
+		path:         path,
+		transport:    transport,
+	}
+
+	switch mode {
+	case RecordMode:
+		if transport == nil {
+			return nil, errors.New("a transport is required to record")
+		}
+	case ReplayMode:
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(b, &r.interactions)
+		if err != nil {
+			return nil, err
+		}
+		r.used = make([]bool, len(r.interactions))
+	default:
+		return nil, errors.New("unknown recorder mode")
+	}
+
+	return r, nil
+}
+
+// Interactions returns the interactions recorded or loaded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]Interaction{}, r.interactions...)
+}
+
+// Save writes the recorded interactions to the Recorder's fixture file.
+func (r *Recorder) Save() error {
+	if r.mode != RecordMode {
+		return errors.New("only a recording Recorder can be saved")
+	}
+
+	r.mutex.Lock()
+	b, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.path, append(b, '\n'), 0644)
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isObserveRequest(req) {
+		return r.observe(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.mode == ReplayMode {
+		return r.replay(req, body)
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	header := http.Header{}
+	for k, v := range resp.Header {
+		header[k] = v
+	}
+	for _, h := range redactHeaders {
+		header.Del(h)
+	}
+
+	i := Interaction{
+		Method:       req.Method,
+		URL:          req.URL.RequestURI(),
+		RequestBody:  r.sanitize(body),
+		StatusCode:   resp.StatusCode,
+		Header:       header,
+		ResponseBody: r.sanitize(respBody),
+	}
+
+	r.mutex.Lock()
+	r.interactions = append(r.interactions, i)
+	r.mutex.Unlock()
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	uri := req.URL.RequestURI()
+	for idx, i := range r.interactions {
+		if r.used[idx] || i.Method != req.Method || i.URL != uri {
+			continue
+		}
+
+		if r.MatchBody && i.RequestBody != r.sanitize(body) {
+			continue
+		}
+
+		r.used[idx] = true
+
+		header := http.Header{}
+		for k, v := range i.Header {
+			header[k] = v
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.StatusCode, http.StatusText(i.StatusCode)),
+			StatusCode:    i.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(i.ResponseBody)),
+			ContentLength: int64(len(i.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded interaction for %s %s", req.Method, uri)
+}
+
+// observe handles requests for the progress event stream. When recording,
+// they pass straight through; when replaying, the stream is empty.
+func (r *Recorder) observe(req *http.Request) (*http.Response, error) {
+	if r.mode == RecordMode {
+		return r.transport.RoundTrip(req)
+	}
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func isObserveRequest(req *http.Request) bool {
+	return req.URL.Path == "/v1/observe"
+}
+
+// sanitize replaces the values of any redacted keys within a JSON body.
+// Bodies that are not JSON are returned unchanged.
+func (r *Recorder) sanitize(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var v interface{}
+	err := json.Unmarshal(body, &v)
+	if err != nil {
+		return string(body)
+	}
+
+	fields := make(map[string]bool, len(r.RedactFields))
+	for _, f := range r.RedactFields {
+		fields[f] = true
+	}
+
+	b, err := json.Marshal(redact(v, fields))
+	if err != nil {
+		return string(body)
+	}
+
+	return string(b)
+}
+
+func redact(v interface{}, fields map[string]bool) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if fields[k] {
+				t[k] = redacted
+				continue
+			}
+			if s, ok := child.(string); ok && k == "value" {
+				if r, ok := redactCredentialValue(s); ok {
+					t[k] = r
+					continue
+				}
+			}
+			t[k] = redact(child, fields)
+		}
+	case []interface{}:
+		for i, child := range t {
+			t[i] = redact(child, fields)
+		}
+	}
+
+	return v
+}
+
+// redactCredentialValue replaces the plaintext within a credential's value,
+// which is a JSON encoded string holding its type and value. The placeholder
+// has the same type, so the credential still decodes when replayed. Values of
+// types not known to be safe to redact this way are replaced outright. The
+// bool return is false if s is not a credential value.
+func redactCredentialValue(s string) (string, bool) {
+	cv := struct {
+		Version int `json:"version"`
+		Body    struct {
+			Type  string          `json:"type"`
+			Value json.RawMessage `json:"value"`
+		} `json:"body"`
+	}{}
+	err := json.Unmarshal([]byte(s), &cv)
+	if err != nil || cv.Body.Type == "" {
+		return "", false
+	}
+
+	var placeholder interface{}
+	switch cv.Body.Type {
+	case "string":
+		placeholder = redacted
+	case "number":
+		placeholder = 0
+	case "binary":
+		placeholder = []byte(redacted)
+	case "undefined":
+		// Unset credentials have no plaintext to redact.
+		return s, true
+	default:
+		return redacted, true
+	}
+
+	cv.Body.Value, err = json.Marshal(placeholder)
+	if err != nil {
+		return "", false
+	}
+
+	b, err := json.Marshal(&cv)
+	if err != nil {
+		return "", false
+	}
+
+	return string(b), true
+}
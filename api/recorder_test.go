@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+type stubTransport struct {
+	calls int
+	body  string
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.calls++
+	body := s.body
+	if body == "" {
+		body = `{"version":"0.22.0","password":"hunter2"}`
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"X-Request-Id": []string{"abc"}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "fixture.json")
+	stub := &stubTransport{}
+
+	rec, err := NewRecorder(path, RecordMode, stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	v, err := NewClientWithTransport(rec).Version.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != "0.22.0" {
+		t.Errorf("unexpected version while recording: %s", v.Version)
+	}
+
+	err = rec.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "hunter2") || strings.Contains(string(b), "abc") {
+		t.Error("fixture was not sanitized")
+	}
+
+	rep, err := NewRecorder(path, ReplayMode, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClientWithTransport(rep)
+	v, err = client.Version.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != "0.22.0" {
+		t.Errorf("unexpected version while replaying: %s", v.Version)
+	}
+	if stub.calls != 1 {
+		t.Errorf("replay made %d real calls", stub.calls-1)
+	}
+
+	_, err = client.Version.Get(ctx)
+	if err == nil {
+		t.Error("expected error once recorded interactions are exhausted")
+	}
+}
+
+func TestRecorderRedactsCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	values := []*apitypes.CredentialValue{
+		apitypes.NewStringCredentialValue("hunter2"),
+		apitypes.NewIntCredentialValue(8675309),
+		apitypes.NewFileCredentialValue([]byte("hunter2")),
+	}
+
+	creds := make([]apitypes.CredentialEnvelope, len(values))
+	for i, v := range values {
+		var body apitypes.Credential = &apitypes.BaseCredential{Name: "password", Value: v}
+		creds[i] = apitypes.CredentialEnvelope{Version: 1, Body: &body}
+	}
+
+	b, err := json.Marshal(creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "fixture.json")
+	stub := &stubTransport{body: string(b)}
+
+	rec, err := NewRecorder(path, RecordMode, stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	_, err = NewClientWithTransport(rec).Credentials.Get(ctx, "/acme/app/dev/*/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rec.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte("hunter2"))
+	for _, secret := range []string{"hunter2", "8675309", encoded} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("fixture contains the secret %s", secret)
+		}
+	}
+
+	rep, err := NewRecorder(path, ReplayMode, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, err := NewClientWithTransport(rep).Credentials.Get(ctx, "/acme/app/dev/*/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != len(values) {
+		t.Fatalf("got %d credentials, want %d", len(replayed), len(values))
+	}
+
+	for i, want := range []string{"REDACTED", "0", "REDACTED"} {
+		if got := (*replayed[i].Body).GetValue().String(); got != want {
+			t.Errorf("credential %d: got value %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestRedactCredentialValue(t *testing.T) {
+	tcs := []struct {
+		name  string
+		value string
+		want  string
+		ok    bool
+	}{
+		{
+			name:  "string",
+			value: `{"version":1,"body":{"type":"string","value":"hunter2"}}`,
+			want:  `{"version":1,"body":{"type":"string","value":"REDACTED"}}`,
+			ok:    true,
+		},
+		{
+			name:  "unset",
+			value: `{"version":1,"body":{"type":"undefined"}}`,
+			want:  `{"version":1,"body":{"type":"undefined"}}`,
+			ok:    true,
+		},
+		{
+			name:  "unknown type",
+			value: `{"version":1,"body":{"type":"json","value":{"password":"hunter2"}}}`,
+			want:  "REDACTED",
+			ok:    true,
+		},
+		{name: "not a credential value", value: "hunter2"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := redactCredentialValue(tc.value)
+			if ok != tc.ok {
+				t.Fatalf("expected ok %t, got %t", tc.ok, ok)
+			}
+			if ok && got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// retryBaseDelay is the delay before the first retry. It doubles for
+	// every attempt after that, up to retryMaxDelay.
+	retryBaseDelay = 200 * time.Millisecond
+	retryMaxDelay  = 5 * time.Second
+
+	// breakerThreshold is the number of consecutive failures to reach the
+	// registry after which the circuit breaker opens.
+	breakerThreshold = 5
+
+	// breakerCooldown is how long the circuit breaker stays open before
+	// letting a request through to check whether the registry is back.
+	breakerCooldown = 30 * time.Second
+)
+
+// ErrRegistryUnavailable is returned without making a request while the
+// circuit breaker is open, after repeated failures to reach the registry.
+var ErrRegistryUnavailable = errors.New("the registry is unavailable; try again shortly")
+
+// do sends r, retrying GET requests that fail because the daemon or registry
+// could not be reached, waiting longer before each retry.
+func (c *Client) do(ctx context.Context, r *http.Request) (*http.Response, error) {
+	if c.breaker != nil && !c.breaker.allow() {
+		return nil, ErrRegistryUnavailable
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.client.Do(r)
+
+		failed := unavailable(resp, err)
+		if c.breaker != nil {
+			c.breaker.record(!failed)
+		}
+
+		if !failed || r.Method != "GET" || attempt >= c.retries {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff(attempt)):
+		}
+	}
+}
+
+// unavailable returns whether a request failed because the daemon or the
+// registry behind it could not be reached or did not respond in time.
+func unavailable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff returns how long to wait before retrying after the given attempt,
+// with jitter so many clients don't retry in lockstep. The delay is between
+// half and all of the exponential backoff.
+func backoff(attempt int) time.Duration {
+	d := retryMaxDelay
+	if attempt < 16 {
+		if exp := retryBaseDelay << uint(attempt); exp < d {
+			d = exp
+		}
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// circuitBreaker fails requests fast once the registry has been unreachable
+// for several requests in a row, rather than having each wait on its own
+// timeouts and retries. After a cooldown, one request at a time is let
+// through until one succeeds.
+type circuitBreaker struct {
+	mutex    sync.Mutex
+	failures int
+	openedAt time.Time
+
+	now func() time.Time
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{now: time.Now}
+}
+
+// allow returns whether a request may be made.
+func (b *circuitBreaker) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.failures < breakerThreshold {
+		return true
+	}
+
+	if b.now().Sub(b.openedAt) < breakerCooldown {
+		return false
+	}
+
+	// Let this request through to probe the registry, and keep the breaker
+	// open for everyone else until it reports back.
+	b.openedAt = b.now()
+	return true
+}
+
+// record notes the outcome of a request.
+func (b *circuitBreaker) record(ok bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if ok {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= breakerThreshold {
+		b.openedAt = b.now()
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type statusTransport struct {
+	statuses []int
+	calls    int
+}
+
+func (t *statusTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	status := t.statuses[t.calls]
+	t.calls++
+	if status == 0 {
+		return nil, errors.New("connection refused")
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    r,
+	}, nil
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 40; attempt++ {
+		d := backoff(attempt)
+		max := retryBaseDelay << uint(attempt)
+		if attempt >= 16 || max > retryMaxDelay {
+			max = retryMaxDelay
+		}
+
+		if d < max/2 || d > max {
+			t.Errorf("attempt %d: backoff %s not within [%s, %s]", attempt, d, max/2, max)
+		}
+	}
+}
+
+func TestDoRetries(t *testing.T) {
+	tcs := []struct {
+		name     string
+		method   string
+		statuses []int
+		calls    int
+		status   int
+	}{
+		{"success", "GET", []int{200}, 1, 200},
+		{"recovers", "GET", []int{0, 503, 200}, 3, 200},
+		{"gives up", "GET", []int{502, 502, 502}, 3, 502},
+		{"not found", "GET", []int{404}, 1, 404},
+		{"not idempotent", "POST", []int{503}, 1, 503},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := &statusTransport{statuses: tc.statuses}
+			c := NewClientWithTransport(rt)
+			c.retries = 2
+
+			r, err := http.NewRequest(tc.method, "http://localhost/v1/orgs", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := c.do(context.Background(), r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tc.status)
+			}
+			if rt.calls != tc.calls {
+				t.Errorf("made %d requests, want %d", rt.calls, tc.calls)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker()
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < breakerThreshold; i++ {
+		if !b.allow() {
+			t.Fatalf("request %d refused before the threshold", i)
+		}
+		b.record(false)
+	}
+
+	if b.allow() {
+		t.Error("breaker should be open after repeated failures")
+	}
+
+	now = now.Add(breakerCooldown)
+	if !b.allow() {
+		t.Error("breaker should let a probe through after the cooldown")
+	}
+	if b.allow() {
+		t.Error("breaker should only let one probe through")
+	}
+
+	b.record(true)
+	if !b.allow() {
+		t.Error("breaker should close once a request succeeds")
+	}
+}
@@ -167,6 +167,7 @@ func (s *SessionClient) UserLogin(ctx context.Context, email, passphrase string)
 	login := apitypes.UserLogin{
 		Email:    email,
 		Password: passphrase,
+		MFACode:  mfaCode(ctx),
 	}
 
 	rawLogin, err := json.Marshal(login)
@@ -202,6 +203,18 @@ func (s *SessionClient) MachineLogin(ctx context.Context, tokenID, tokenSecret s
 	return performLogin(ctx, s, "machine", rawLogin)
 }
 
+// SaveToKeychain has the daemon store the login of the current session in the
+// operating system's keychain, so it logs in again on its own when restarted.
+func (s *SessionClient) SaveToKeychain(ctx context.Context) error {
+	req, _, err := s.client.NewRequest("POST", "/session/keychain", nil, nil, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Do(ctx, req, nil, nil, nil)
+	return err
+}
+
 func performLogin(ctx context.Context, s *SessionClient, loginType apitypes.SessionType, rawLogin json.RawMessage) error {
 	wrapper := apitypes.Login{
 		Type:        loginType,
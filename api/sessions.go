@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+// SessionsClient makes proxied requests to the registry's sessions endpoints,
+// for managing the active sessions of the logged in user.
+type SessionsClient struct {
+	client *Client
+}
+
+// List returns all of the user's active sessions.
+func (s *SessionsClient) List(ctx context.Context) ([]apitypes.ActiveSession, error) {
+	if err := s.client.require(ctx, FeatureSessions); err != nil {
+		return nil, err
+	}
+
+	req, _, err := s.client.NewRequest("GET", "/sessions", nil, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []apitypes.ActiveSession{}
+	_, err = s.client.Do(ctx, req, &sessions, nil, nil)
+	return sessions, err
+}
+
+// Revoke destroys the session with the given id, logging out the device
+// it belongs to.
+func (s *SessionsClient) Revoke(ctx context.Context, id string) error {
+	if err := s.client.require(ctx, FeatureSessions); err != nil {
+		return err
+	}
+
+	req, _, err := s.client.NewRequest("DELETE", "/sessions/"+id, nil, nil, true)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Do(ctx, req, nil, nil, nil)
+	return err
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/nacl/secretbox"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	b64 "github.com/manifoldco/torus-cli/base64"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// ErrInvalidShareToken is returned when redeeming a token that was not
+// printed by `torus share`.
+var ErrInvalidShareToken = errors.New("invalid share token")
+
+// shareIDPattern matches the ids the registry gives shares, so a token can't
+// point a redemption at any other endpoint.
+var shareIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// SharesClient makes proxied requests to the registry's shares endpoints,
+// for sharing a secret through a one-time link.
+//
+// Secrets are encrypted with a new random key for every share. The registry
+// is only sent the ciphertext; the key is part of the token returned by
+// Create, so only those given the token can read the secret.
+type SharesClient struct {
+	client *Client
+}
+
+// Create shares the secret with the given name and value. The share can be
+// redeemed up to maxReads times before it expires. It returns the token to
+// redeem it with.
+func (s *SharesClient) Create(ctx context.Context, orgID *identity.ID, name string,
+	value *apitypes.CredentialValue, expires time.Time, maxReads int) (string, *apitypes.Share, error) {
+
+	if err := s.client.require(ctx, FeatureShares); err != nil {
+		return "", nil, err
+	}
+
+	pt, err := json.Marshal(&apitypes.SharedSecret{Name: name, Value: value})
+	if err != nil {
+		return "", nil, err
+	}
+
+	var key [32]byte
+	var nonce [24]byte
+	_, err = rand.Read(key[:])
+	if err != nil {
+		return "", nil, err
+	}
+	_, err = rand.Read(nonce[:])
+	if err != nil {
+		return "", nil, err
+	}
+
+	share := &apitypes.Share{
+		OrgID:      orgID,
+		Ciphertext: b64.NewValue(secretbox.Seal(nil, pt, &nonce, &key)),
+		Nonce:      b64.NewValue(nonce[:]),
+		Expires:    expires.UTC(),
+		MaxReads:   maxReads,
+	}
+
+	req, _, err := s.client.NewRequest("POST", "/shares", nil, share, true)
+	if err != nil {
+		return "", nil, err
+	}
+
+	created := &apitypes.Share{}
+	_, err = s.client.Do(ctx, req, created, nil, nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return shareToken(created.ID, key[:]), created, nil
+}
+
+// Redeem fetches and decrypts the secret shared with token, using up one of
+// the share's reads.
+func (s *SharesClient) Redeem(ctx context.Context, token string) (*apitypes.SharedSecret, *apitypes.Share, error) {
+	id, key, err := parseShareToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.client.require(ctx, FeatureShares); err != nil {
+		return nil, nil, err
+	}
+
+	req, _, err := s.client.NewRequest("POST", "/shares/"+id+"/redeem", nil, nil, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	share := &apitypes.Share{}
+	_, err = s.client.Do(ctx, req, share, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secret, err := openShare(share, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return secret, share, nil
+}
+
+// shareToken encodes the id of a share and the key its secret is encrypted
+// with, as id.key.
+func shareToken(id string, key []byte) string {
+	return id + "." + base64.RawURLEncoding.EncodeToString(key)
+}
+
+func parseShareToken(token string) (string, *[32]byte, error) {
+	token = strings.TrimSpace(token)
+	idx := strings.LastIndex(token, ".")
+	if idx <= 0 || !shareIDPattern.MatchString(token[:idx]) {
+		return "", nil, ErrInvalidShareToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token[idx+1:])
+	if err != nil || len(raw) != 32 {
+		return "", nil, ErrInvalidShareToken
+	}
+
+	var key [32]byte
+	copy(key[:], raw)
+	return token[:idx], &key, nil
+}
+
+func openShare(share *apitypes.Share, key *[32]byte) (*apitypes.SharedSecret, error) {
+	if share.Ciphertext == nil || share.Nonce == nil || len(*share.Nonce) != 24 {
+		return nil, errors.New("shared secret is malformed")
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], *share.Nonce)
+
+	pt, ok := secretbox.Open(nil, *share.Ciphertext, &nonce, key)
+	if !ok {
+		return nil, errors.New("could not decrypt shared secret; check the token")
+	}
+
+	secret := &apitypes.SharedSecret{}
+	err := json.Unmarshal(pt, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
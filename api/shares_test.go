@@ -0,0 +1,70 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"golang.org/x/crypto/nacl/secretbox"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/base64"
+)
+
+func TestShareToken(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	token := shareToken("0a1b-C_2", key)
+
+	id, parsed, err := parseShareToken(" " + token + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "0a1b-C_2" || !bytes.Equal(parsed[:], key) {
+		t.Errorf("got %s, %x", id, parsed[:])
+	}
+
+	bad := []string{
+		"",
+		"nokey",
+		".AAAA",
+		"id.not-base64!",
+		"id." + token[len(token)-10:],
+		"../orgs." + token[len("0a1b-C_2."):],
+	}
+	for _, b := range bad {
+		if _, _, err := parseShareToken(b); err != ErrInvalidShareToken {
+			t.Errorf("parseShareToken(%q) = %v, want ErrInvalidShareToken", b, err)
+		}
+	}
+}
+
+func TestOpenShare(t *testing.T) {
+	var key, wrong [32]byte
+	key[0] = 1
+	var nonce [24]byte
+
+	pt, err := json.Marshal(&apitypes.SharedSecret{
+		Name:  "db_password",
+		Value: apitypes.NewStringCredentialValue("hunter2"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	share := &apitypes.Share{
+		Ciphertext: base64.NewValue(secretbox.Seal(nil, pt, &nonce, &key)),
+		Nonce:      base64.NewValue(nonce[:]),
+	}
+
+	secret, err := openShare(share, &key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Name != "db_password" || secret.Value.String() != "hunter2" {
+		t.Errorf("got %s=%s", secret.Name, secret.Value.String())
+	}
+
+	if _, err := openShare(share, &wrong); err == nil {
+		t.Error("opened share with the wrong key")
+	}
+}
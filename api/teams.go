@@ -17,6 +17,14 @@ type TeamsClient struct {
 
 // List retrieves all teams for an org based on the filtered values
 func (t *TeamsClient) List(ctx context.Context, orgID *identity.ID, name string, teamType primitive.TeamType) ([]envelope.Team, error) {
+	teams := []envelope.Team{}
+	err := t.Iter(orgID, name, teamType).All(ctx, &teams)
+	return teams, err
+}
+
+// Iter returns an iterator over pages of teams, filtered like List. Each page
+// is a []envelope.Team.
+func (t *TeamsClient) Iter(orgID *identity.ID, name string, teamType primitive.TeamType) *Pages {
 	v := &url.Values{}
 
 	if orgID != nil {
@@ -29,45 +37,17 @@ func (t *TeamsClient) List(ctx context.Context, orgID *identity.ID, name string,
 		v.Set("type", string(teamType))
 	}
 
-	req, _, err := t.client.NewRequest("GET", "/teams", v, nil, true)
-	if err != nil {
-		return nil, err
-	}
-
-	teams := []envelope.Team{}
-	_, err = t.client.Do(ctx, req, &teams, nil, nil)
-	return teams, err
+	return t.client.Pages("/teams", v, true)
 }
 
 // GetByOrg retrieves all teams for an org id
 func (t *TeamsClient) GetByOrg(ctx context.Context, orgID *identity.ID) ([]envelope.Team, error) {
-	v := &url.Values{}
-	v.Set("org_id", orgID.String())
-
-	req, _, err := t.client.NewRequest("GET", "/teams", v, nil, true)
-	if err != nil {
-		return nil, err
-	}
-
-	teams := []envelope.Team{}
-	_, err = t.client.Do(ctx, req, &teams, nil, nil)
-	return teams, err
+	return t.List(ctx, orgID, "", primitive.AnyTeamType)
 }
 
 // GetByName retrieves the team with the specified name
 func (t *TeamsClient) GetByName(ctx context.Context, orgID *identity.ID, name string) ([]envelope.Team, error) {
-	v := &url.Values{}
-	v.Set("org_id", orgID.String())
-	v.Set("name", name)
-
-	req, _, err := t.client.NewRequest("GET", "/teams", v, nil, true)
-	if err != nil {
-		return nil, err
-	}
-
-	teams := []envelope.Team{}
-	_, err = t.client.Do(ctx, req, &teams, nil, nil)
-	return teams, err
+	return t.List(ctx, orgID, name, primitive.AnyTeamType)
 }
 
 // Create performs a request to create a new team object
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Trace is the metadata of a request made to the daemon. Request and
+// response bodies are never traced, as they may hold secrets.
+type Trace struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+
+	// Proxied is whether the daemon passed the request straight through to
+	// the registry, rather than handling it itself.
+	Proxied bool `json:"proxied"`
+
+	Status    int    `json:"status,omitempty"`
+	Duration  int64  `json:"duration_ms"`
+	RequestID string `json:"request_id"`
+	Error     string `json:"error,omitempty"`
+}
+
+// String returns the trace as a line of a transcript.
+func (t *Trace) String() string {
+	route := "daemon"
+	if t.Proxied {
+		route = "registry"
+	}
+
+	result := fmt.Sprintf("%d", t.Status)
+	if t.Error != "" {
+		result = "error: " + t.Error
+	}
+
+	return fmt.Sprintf("%s %s %s via %s %s %dms", t.RequestID, t.Method, t.Path,
+		route, result, t.Duration)
+}
+
+// Tracer is an http.RoundTripper that writes a Trace of every request made
+// through it, either as a transcript for people to read, or as JSON lines.
+type Tracer struct {
+	rt     http.RoundTripper
+	w      io.Writer
+	asJSON bool
+
+	mutex sync.Mutex
+}
+
+// NewTracer returns a Tracer that makes requests with rt, and writes traces
+// to w.
+func NewTracer(rt http.RoundTripper, w io.Writer, asJSON bool) *Tracer {
+	return &Tracer{rt: rt, w: w, asJSON: asJSON}
+}
+
+// RoundTrip implements http.RoundTripper. Requests are timed until their
+// response headers are received.
+func (t *Tracer) RoundTrip(r *http.Request) (*http.Response, error) {
+	trace := &Trace{
+		Time:      time.Now().UTC(),
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		RequestID: r.Header.Get("X-Request-ID"),
+	}
+
+	switch {
+	case strings.HasPrefix(trace.Path, "/proxy/"):
+		trace.Proxied = true
+		trace.Path = strings.TrimPrefix(trace.Path, "/proxy")
+	case strings.HasPrefix(trace.Path, "/v1/"):
+		trace.Path = strings.TrimPrefix(trace.Path, "/v1")
+	}
+
+	resp, err := t.rt.RoundTrip(r)
+	trace.Duration = int64(time.Since(trace.Time) / time.Millisecond)
+	if err != nil {
+		trace.Error = err.Error()
+	} else {
+		trace.Status = resp.StatusCode
+	}
+
+	t.write(trace)
+	return resp, err
+}
+
+func (t *Tracer) write(trace *Trace) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.asJSON {
+		json.NewEncoder(t.w).Encode(trace)
+		return
+	}
+
+	fmt.Fprintln(t.w, "trace:", trace)
+}
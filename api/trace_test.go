@@ -0,0 +1,53 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTracer(t *testing.T) {
+	tcs := []struct {
+		name     string
+		path     string
+		statuses []int
+		proxied  bool
+		result   string
+	}{
+		{"daemon", "/v1/credentials", []int{200}, false, "GET /credentials via daemon 200"},
+		{"proxied", "/proxy/orgs", []int{404}, true, "GET /orgs via registry 404"},
+		{"error", "/v1/self", []int{0}, false, "GET /self via daemon error: connection refused"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, asJSON := range []bool{false, true} {
+				buf := &bytes.Buffer{}
+				tracer := NewTracer(&statusTransport{statuses: tc.statuses}, buf, asJSON)
+
+				r, _ := http.NewRequest("GET", "http://localhost"+tc.path, nil)
+				r.Header.Set("X-Request-ID", "abc")
+				tracer.RoundTrip(r)
+
+				if !asJSON {
+					line := buf.String()
+					if !strings.HasPrefix(line, "trace: abc "+tc.result+" ") {
+						t.Errorf("unexpected transcript line %q", line)
+					}
+					continue
+				}
+
+				trace := Trace{}
+				err := json.Unmarshal(buf.Bytes(), &trace)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if trace.RequestID != "abc" || trace.Proxied != tc.proxied || trace.Status != tc.statuses[0] {
+					t.Errorf("unexpected trace %+v", trace)
+				}
+			}
+		})
+	}
+}
@@ -49,3 +49,51 @@ func (u *UsersClient) Update(ctx context.Context, delta apitypes.ProfileUpdate)
 	_, err = u.client.Do(ctx, req, &user, nil, nil)
 	return &user, err
 }
+
+// ChangePassword changes the current user's password, without ending the
+// session.
+func (u *UsersClient) ChangePassword(ctx context.Context, current, password string) (*envelope.User, error) {
+	change := apitypes.PasswordChange{Current: current, Password: password}
+	req, _, err := u.client.NewRequest("POST", "/self/password", nil, &change, false)
+	if err != nil {
+		return nil, err
+	}
+
+	user := envelope.User{}
+	_, err = u.client.Do(ctx, req, &user, nil, nil)
+	return &user, err
+}
+
+// GenerateRecoveryCodes creates a new set of recovery codes for the current
+// user, replacing any they had before.
+func (u *UsersClient) GenerateRecoveryCodes(ctx context.Context) (*apitypes.RecoveryCodes, error) {
+	if err := u.client.require(ctx, FeatureRecovery); err != nil {
+		return nil, err
+	}
+
+	req, _, err := u.client.NewRequest("POST", "/self/recovery-codes", nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := apitypes.RecoveryCodes{}
+	_, err = u.client.Do(ctx, req, &codes, nil, nil)
+	return &codes, err
+}
+
+// Recover replaces the forgotten password of the account with the given
+// email, using one of its recovery codes.
+func (u *UsersClient) Recover(ctx context.Context, email, code, password string) error {
+	if err := u.client.require(ctx, FeatureRecovery); err != nil {
+		return err
+	}
+
+	recovery := apitypes.RecoveryRequest{Email: email, Code: code, Password: password}
+	req, _, err := u.client.NewRequest("POST", "/recovery", nil, &recovery, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = u.client.Do(ctx, req, nil, nil, nil)
+	return err
+}
@@ -13,13 +13,24 @@ type WorklogClient struct {
 	client *Client
 }
 
-// List returns the list of all worklog items in the given org.
-func (w *WorklogClient) List(ctx context.Context, orgID *identity.ID) ([]apitypes.WorklogItem, error) {
+// List returns the list of worklog items of the given types in the given org.
+// Use apitypes.AnyWorklogType to list every item.
+func (w *WorklogClient) List(ctx context.Context, orgID *identity.ID,
+	itemType apitypes.WorklogType) ([]apitypes.WorklogItem, error) {
+
 	v := &url.Values{}
 	if orgID != nil {
 		v.Set("org_id", orgID.String())
 	}
 
+	if itemType != apitypes.AnyWorklogType {
+		for t := apitypes.SecretRotateWorklogType; t != 0 && t < apitypes.AnyWorklogType; t <<= 1 {
+			if itemType&t != 0 {
+				v.Add("type", t.String())
+			}
+		}
+	}
+
 	req, _, err := w.client.NewRequest("GET", "/worklog", v, nil, false)
 	if err != nil {
 		return nil, err
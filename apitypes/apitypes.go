@@ -4,6 +4,7 @@ package apitypes
 import (
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/manifoldco/torus-cli/base64"
 	"github.com/manifoldco/torus-cli/envelope"
@@ -19,8 +20,16 @@ const (
 	BadRequestError     = "bad_request"
 	UnauthorizedError   = "unauthorized"
 	NotFoundError       = "not_found"
+	ConflictError       = "conflict"
 	InternalServerError = "internal_server"
 	NotImplementedError = "not_implemented"
+
+	ConfirmationRequiredError = "confirmation_required"
+	MFARequiredError          = "mfa_required"
+
+	// ReadOnlyError is returned by a daemon started in read-only mode for
+	// requests that would change anything in the registry.
+	ReadOnlyError = "read_only"
 )
 
 // Error represents standard formatted API errors from the daemon or registry.
@@ -100,6 +109,49 @@ func IsUnauthorizedError(err error) bool {
 	return false
 }
 
+// IsConflictError returns whether or not an error is a 409 result from the api.
+func IsConflictError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if apiErr, ok := err.(*Error); ok {
+		return apiErr.Type == ConflictError
+	}
+
+	return false
+}
+
+// IsMFARequiredError returns whether or not an error is a result from the api
+// asking for a code from the user's authenticator app, because they have
+// multi-factor authentication enabled.
+func IsMFARequiredError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if apiErr, ok := err.(*Error); ok {
+		return apiErr.Type == MFARequiredError
+	}
+
+	return false
+}
+
+// IsConfirmationRequiredError returns whether or not an error is a 428 result
+// from the api, returned when writing to a protected environment without
+// confirming it.
+func IsConfirmationRequiredError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if apiErr, ok := err.(*Error); ok {
+		return apiErr.Type == ConfirmationRequiredError
+	}
+
+	return false
+}
+
 // SessionType is the enumerated string type of sessions.
 type SessionType string
 
@@ -124,6 +176,22 @@ type Version struct {
 	Version string `json:"version"`
 }
 
+// Capabilities lists the optional features a registry supports. Registries
+// that predate capabilities negotiation support none of them.
+type Capabilities struct {
+	Features []string `json:"features"`
+}
+
+// Supports returns whether feature is one of the registry's features.
+func (c *Capabilities) Supports(feature string) bool {
+	for _, f := range c.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
 // SessionStatus contains details about the user's daemon session.
 type SessionStatus struct {
 	Token      bool `json:"token"`
@@ -149,6 +217,10 @@ type LoginCredential interface {
 type UserLogin struct {
 	Email    string `json:"email"`
 	Password string `json:"passphrase"`
+
+	// MFACode is a code from the user's authenticator app, required when
+	// they have multi-factor authentication enabled.
+	MFACode string `json:"mfa_code,omitempty"`
 }
 
 // Type returns the type of login request
@@ -218,10 +290,18 @@ type Signup struct {
 	OrgInvite  bool
 }
 
-// ProfileUpdate contains the fields a user can change on their user object
+// ProfileUpdate contains the fields a user can change on their user object.
+// Password is refused; passwords are changed with a PasswordChange, which
+// requires the current one.
 type ProfileUpdate struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
+	Password string `json:"password,omitempty"`
+}
+
+// PasswordChange contains the fields needed to change a user's password
+type PasswordChange struct {
+	Current  string `json:"current"`
 	Password string `json:"password"`
 }
 
@@ -232,7 +312,52 @@ type InviteAccept struct {
 	Code  string `json:"code"`
 }
 
+// InvitesApproveRequest asks the daemon to approve every accepted invite to
+// an org.
+type InvitesApproveRequest struct {
+	OrgID *identity.ID `json:"org_id"`
+}
+
+// InviteApproval is the outcome of approving one invite, as part of
+// approving all of an org's accepted invites.
+type InviteApproval struct {
+	InviteID *identity.ID `json:"invite_id"`
+	Email    string       `json:"email"`
+	Approved bool         `json:"approved"`
+	Error    string       `json:"error,omitempty"`
+}
+
 // VerifyEmail contains email verification code
 type VerifyEmail struct {
 	Code string `json:"code"`
 }
+
+// PrefetchStats describes the daemon's credential prefetch cache, and how
+// often credential requests were served from it.
+type PrefetchStats struct {
+	Enabled     bool       `json:"enabled"`
+	Paths       []string   `json:"paths"`
+	Hits        uint64     `json:"hits"`
+	Misses      uint64     `json:"misses"`
+	LastRefresh *time.Time `json:"last_refresh"`
+}
+
+// CredentialsRevision identifies the state of the credentials at a path. It
+// changes whenever any of them are set, unset, or rotated.
+type CredentialsRevision struct {
+	Path     string `json:"path"`
+	Revision string `json:"revision"`
+}
+
+// CachedAtHeader is set on credentials served from the daemon's offline cache,
+// to the time they were fetched from the registry, in RFC 3339 format.
+const CachedAtHeader = "X-Torus-Cached-At"
+
+// BreakGlassHeader carries the reason given for reading secrets from a frozen
+// keyring.
+const BreakGlassHeader = "X-Torus-Break-Glass"
+
+// PrefetchRequest registers a path for the daemon to prefetch credentials for.
+type PrefetchRequest struct {
+	Path string `json:"path"`
+}
@@ -0,0 +1,62 @@
+package apitypes
+
+import (
+	"time"
+
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// KeyringAudit is the result of checking the integrity of every keyring the
+// current user or machine can see in an org.
+type KeyringAudit struct {
+	Keyrings    int                 `json:"keyrings"`
+	Members     int                 `json:"members"`
+	Credentials int                 `json:"credentials"`
+	Issues      []KeyringAuditIssue `json:"issues"`
+}
+
+// KeyringAuditIssue describes a single problem found during a keyring audit.
+type KeyringAuditIssue struct {
+	KeyringID *identity.ID `json:"keyring_id"`
+	PathExp   string       `json:"pathexp"`
+
+	// Subject is the ID of the keyring, member, or credential at fault.
+	Subject string `json:"subject"`
+	Problem string `json:"problem"`
+}
+
+// AuditEvent records an action someone took on an org's secrets or access
+// controls.
+type AuditEvent struct {
+	Time    time.Time    `json:"time"`
+	OrgID   *identity.ID `json:"org_id,omitempty"`
+	ActorID *identity.ID `json:"actor_id"`
+
+	// Actor is the username or machine name of the actor, when known.
+	Actor string `json:"actor,omitempty"`
+
+	// Action is one of the AuditEvent actions, or another action recorded by
+	// the registry.
+	Action string `json:"action"`
+
+	// Subject is the path of the secret, or the name of the policy, that the
+	// action was taken on.
+	Subject string `json:"subject"`
+
+	// Reason is the reason given for breaking the glass on a frozen keyring.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Actions recorded in AuditEvents.
+const (
+	AuditCredentialRead  = "credential.read"
+	AuditCredentialSet   = "credential.set"
+	AuditCredentialUnset = "credential.unset"
+	AuditPolicyCreate    = "policy.create"
+	AuditPolicyAttach    = "policy.attach"
+	AuditPolicyDetach    = "policy.detach"
+	AuditKeyringFreeze   = "keyring.freeze"
+	AuditKeyringUnfreeze = "keyring.unfreeze"
+	AuditBreakGlass      = "keyring.break_glass"
+	AuditHoneytokenRead  = "credential.honeytoken_read"
+)
@@ -0,0 +1,50 @@
+package apitypes
+
+import (
+	"time"
+
+	"github.com/manifoldco/torus-cli/base64"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// BootstrapProvider is the cloud provider a new instance uses to prove its
+// identity to a gatekeeper.
+type BootstrapProvider string
+
+// These are the supported bootstrap providers.
+const (
+	AWSBootstrapProvider BootstrapProvider = "aws"
+	GCPBootstrapProvider BootstrapProvider = "gcp"
+)
+
+// BootstrapRequest is sent by a new instance to a gatekeeper, exchanging a
+// signed instance identity document for machine credentials.
+//
+// For AWS, Identity holds the instance identity document, and Signature its
+// base64 encoded RSA-SHA256 signature. For GCP, Identity holds the instance
+// identity token, which carries its own signature.
+//
+// Challenge is one issued by the gatekeeper, and can only be used once. GCP
+// identity tokens must be issued for an audience including it.
+type BootstrapRequest struct {
+	Provider  BootstrapProvider `json:"provider"`
+	Identity  string            `json:"identity"`
+	Signature string            `json:"signature,omitempty"`
+	Role      string            `json:"role"`
+	Challenge string            `json:"challenge"`
+}
+
+// BootstrapChallenge is issued by a gatekeeper to an instance about to
+// bootstrap.
+type BootstrapChallenge struct {
+	Challenge string    `json:"challenge"`
+	Expires   time.Time `json:"expires_at"`
+}
+
+// BootstrapResponse holds the credentials of the machine created for a
+// bootstrapped instance.
+type BootstrapResponse struct {
+	Name    string        `json:"name"`
+	TokenID *identity.ID  `json:"token_id"`
+	Secret  *base64.Value `json:"secret"`
+}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"reflect"
 	"strconv"
+	"unicode/utf8"
 
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
 )
 
 var errMistmatchedType = errors.New("Mismatched type and value in credential")
@@ -17,6 +19,7 @@ const (
 	stringCV
 	intCV
 	floatCV
+	binaryCV
 )
 
 // CredentialEnvelope is an unencrypted credential object with a
@@ -41,6 +44,8 @@ type Credential interface {
 	GetPathExp() *pathexp.PathExp
 	GetProjectID() *identity.ID
 	GetValue() *CredentialValue
+	GetCredentialVersion() int
+	GetMeta() *primitive.CredentialMeta
 }
 
 // BaseCredential is the body of an unencrypted Credential
@@ -50,6 +55,13 @@ type BaseCredential struct {
 	PathExp   *pathexp.PathExp `json:"pathexp"`
 	ProjectID *identity.ID     `json:"project_id"`
 	Value     *CredentialValue `json:"value"`
+
+	// Previous and CredentialVersion describe the credential's place in its
+	// version history. They are set on credentials read from the daemon. On
+	// credentials written to it, they may give the version the caller last
+	// saw, so the write fails with a conflict if it has since changed.
+	Previous          *identity.ID `json:"previous,omitempty"`
+	CredentialVersion int          `json:"credential_version,omitempty"`
 }
 
 // GetName returns the name
@@ -72,6 +84,16 @@ func (c *BaseCredential) GetProjectID() *identity.ID {
 	return c.ProjectID
 }
 
+// GetCredentialVersion returns the credential's position in its history
+func (c *BaseCredential) GetCredentialVersion() int {
+	return c.CredentialVersion
+}
+
+// GetMeta returns the credential's metadata. v1 credentials have none.
+func (c *BaseCredential) GetMeta() *primitive.CredentialMeta {
+	return &primitive.CredentialMeta{}
+}
+
 // GetValue returns the value object, unless unset then returns nil
 func (c *BaseCredential) GetValue() *CredentialValue {
 	if c.Value.cvtype == unsetCV {
@@ -80,6 +102,49 @@ func (c *BaseCredential) GetValue() *CredentialValue {
 	return c.Value
 }
 
+// CredentialLocation is where a secret, found by searching the names of
+// secrets, is set. It never includes the secret's value.
+type CredentialLocation struct {
+	OrgID   *identity.ID     `json:"org_id"`
+	PathExp *pathexp.PathExp `json:"pathexp"`
+	Name    string           `json:"name"`
+
+	// ID and CredentialVersion identify the secret's latest version.
+	ID                *identity.ID `json:"id,omitempty"`
+	CredentialVersion int          `json:"credential_version,omitempty"`
+}
+
+// EnvironmentCloneRequest asks the daemon to copy the secrets set in one
+// environment of a project to another, optionally only those for a service.
+type EnvironmentCloneRequest struct {
+	Org     string `json:"org"`
+	Project string `json:"project"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Service string `json:"service,omitempty"`
+
+	// Overwrite replaces secrets already set at their destination, rather
+	// than skipping them.
+	Overwrite bool `json:"overwrite"`
+
+	// DryRun returns what would be copied, without copying it.
+	DryRun bool `json:"dry_run"`
+}
+
+// EnvironmentClone is the result of cloning an environment.
+type EnvironmentClone struct {
+	// Cloned are the secrets copied, at their new locations.
+	Cloned []CredentialLocation `json:"cloned"`
+
+	// Skipped are the secrets that were not copied, as they are already set
+	// at their new locations.
+	Skipped []CredentialLocation `json:"skipped"`
+
+	// Shared is the number of secrets that were not copied, as they are set
+	// for both environments already.
+	Shared int `json:"shared"`
+}
+
 // CredentialV2 is the body of an unencrypted Credential
 type CredentialV2 struct {
 	BaseCredential
@@ -100,6 +165,17 @@ func (c *CredentialV2) GetValue() *CredentialValue {
 	return c.Value
 }
 
+// CredentialV3 is the body of an unencrypted Credential, with its metadata
+type CredentialV3 struct {
+	CredentialV2
+	primitive.CredentialMeta
+}
+
+// GetMeta returns the credential's metadata
+func (c *CredentialV3) GetMeta() *primitive.CredentialMeta {
+	return &c.CredentialMeta
+}
+
 // CredentialValue is the raw value of a credential.
 type CredentialValue struct {
 	cvtype int
@@ -112,8 +188,15 @@ func (c *CredentialValue) IsUnset() bool {
 	return c.cvtype == unsetCV
 }
 
+// IsBinary returns if this credential holds binary data, which can't be
+// stored as a string.
+func (c *CredentialValue) IsBinary() bool {
+	return c.cvtype == binaryCV
+}
+
 // String returns the string representation of this credential. It panics
-// if the credential was deleted.
+// if the credential was deleted. The bytes of binary values are returned
+// as is.
 func (c *CredentialValue) String() string {
 	if c.cvtype == unsetCV {
 		panic("CredentialValue has been unset")
@@ -130,7 +213,8 @@ type credentialImpl struct {
 	} `json:"body"`
 }
 
-// Raw returns the underlying typed value for this Credential.
+// Raw returns the underlying typed value for this Credential. Binary values
+// are returned as a []byte.
 func (c *CredentialValue) Raw() (interface{}, error) {
 	if c.IsUnset() {
 		return nil, errors.New("Cannot return raw value of an unset Credential")
@@ -150,6 +234,8 @@ func (c *CredentialValue) MarshalJSON() ([]byte, error) {
 		impl.Body.Type = "number"
 	case floatCV:
 		impl.Body.Type = "number"
+	case binaryCV:
+		impl.Body.Type = "binary"
 	case unsetCV:
 		impl.Body.Type = "undefined"
 	}
@@ -223,6 +309,16 @@ func (c *CredentialValue) UnmarshalJSON(b []byte) error {
 		}
 
 		c.value = v.String()
+	case "binary":
+		c.cvtype = binaryCV
+		var v []byte
+		err := json.Unmarshal(impl.Body.Value, &v)
+		if err != nil {
+			return errMistmatchedType
+		}
+
+		c.raw = v
+		c.value = string(v)
 	default:
 		return errors.New("Decoding type " + impl.Body.Type + " is not supported")
 	}
@@ -260,3 +356,18 @@ func NewFloatCredentialValue(f float64) *CredentialValue {
 		raw:    f,
 	}
 }
+
+// NewFileCredentialValue creates a CredentialValue holding the contents of a
+// file exactly. Contents that are valid UTF-8 are stored as a string, which
+// every version of torus can read, and anything else as binary.
+func NewFileCredentialValue(b []byte) *CredentialValue {
+	if utf8.Valid(b) {
+		return NewStringCredentialValue(string(b))
+	}
+
+	return &CredentialValue{
+		cvtype: binaryCV,
+		value:  string(b),
+		raw:    b,
+	}
+}
@@ -116,3 +116,49 @@ func TestCredentialValueUnmarshalJSON(t *testing.T) {
 
 	})
 }
+
+func TestFileCredentialValue(t *testing.T) {
+	t.Run("text keeps its newlines", func(t *testing.T) {
+		pem := "-----BEGIN CERTIFICATE-----\nMIIB\r\n-----END CERTIFICATE-----\n"
+		c := NewFileCredentialValue([]byte(pem))
+		if c.IsBinary() {
+			t.Error("text was stored as binary")
+		}
+
+		b, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := CredentialValue{}
+		err = json.Unmarshal(b, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != pem {
+			t.Errorf("wrong value! had: %q wanted: %q", out.String(), pem)
+		}
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		data := []byte{0x00, 0xff, 0xfe, '\n', 0x80}
+		c := NewFileCredentialValue(data)
+		if !c.IsBinary() {
+			t.Error("invalid utf-8 was not stored as binary")
+		}
+
+		b, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := CredentialValue{}
+		err = json.Unmarshal(b, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !out.IsBinary() || out.String() != string(data) {
+			t.Errorf("wrong value! had: %q wanted: %q", out.String(), data)
+		}
+	})
+}
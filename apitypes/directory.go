@@ -0,0 +1,19 @@
+package apitypes
+
+import "github.com/manifoldco/torus-cli/identity"
+
+// DirectoryEntry is the result of looking up an email address in the
+// registry's user directory. Users are only found if they have chosen to be
+// discoverable; otherwise Found is false, whether or not they have an account.
+type DirectoryEntry struct {
+	Email    string       `json:"email"`
+	Found    bool         `json:"found"`
+	UserID   *identity.ID `json:"user_id,omitempty"`
+	Username string       `json:"username,omitempty"`
+}
+
+// DirectorySettings holds whether the current user can be found in the
+// directory by their email address.
+type DirectorySettings struct {
+	Discoverable bool `json:"discoverable"`
+}
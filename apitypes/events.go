@@ -0,0 +1,36 @@
+package apitypes
+
+import (
+	"time"
+
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// OrgEvent is a notification of something happening in an org, streamed to
+// subscribers as it occurs.
+type OrgEvent struct {
+	// ID increases with each event in an org, and is used to ask the
+	// registry for the events after it.
+	ID    string       `json:"id"`
+	Time  time.Time    `json:"time"`
+	OrgID *identity.ID `json:"org_id"`
+
+	// Type is one of the OrgEvent types, or another type sent by the
+	// registry.
+	Type string `json:"type"`
+
+	// Actor is the username or machine name of who caused the event.
+	Actor string `json:"actor,omitempty"`
+
+	// Subject is the email address, username, or secret path that the event
+	// is about.
+	Subject string `json:"subject"`
+}
+
+// Types of OrgEvents.
+const (
+	EventInviteApproved    = "invite.approved"
+	EventCredentialChanged = "credential.changed"
+	EventMemberAdded       = "member.added"
+	EventHoneytokenRead    = "credential.honeytoken_read"
+)
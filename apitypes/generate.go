@@ -0,0 +1,123 @@
+package apitypes
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Generators the daemon can create the value of a secret with.
+const (
+	GeneratePassword = "password"
+	GenerateHex      = "hex"
+	GenerateBase64   = "base64"
+	GenerateUUID     = "uuid"
+)
+
+// Charsets that generated passwords can be drawn from.
+const (
+	CharsetAlnum   = "alnum"
+	CharsetAlpha   = "alpha"
+	CharsetNumeric = "numeric"
+	CharsetSymbols = "symbols"
+)
+
+const (
+	defaultGenerateLength = 32
+	minGenerateLength     = 8
+	maxGenerateLength     = 1024
+)
+
+// GeneratorSpec describes how the daemon should generate the value of a
+// secret. Length is the number of characters in a password, or the number of
+// random bytes encoded for hex and base64 values. Charset only applies to
+// passwords.
+type GeneratorSpec struct {
+	Type    string
+	Length  int
+	Charset string
+}
+
+// ParseGeneratorSpec parses a comma separated generator description, such as
+// "password,length=32,charset=alnum" or "uuid". The generator may be given
+// on its own, or as type=NAME, and defaults to a password.
+func ParseGeneratorSpec(s string) (*GeneratorSpec, error) {
+	g := &GeneratorSpec{Type: GeneratePassword}
+	lengthSet := false
+
+	for _, opt := range strings.Split(s, ",") {
+		opt = strings.TrimSpace(opt)
+		if opt == "" {
+			continue
+		}
+
+		parts := strings.SplitN(opt, "=", 2)
+		if len(parts) == 1 {
+			parts = []string{"type", parts[0]}
+		}
+
+		key, value := parts[0], parts[1]
+		switch key {
+		case "type":
+			g.Type = value
+		case "length":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, errors.New("length must be a whole number")
+			}
+			g.Length = n
+			lengthSet = true
+		case "charset":
+			g.Charset = value
+		default:
+			return nil, errors.New("unknown generator option " + key)
+		}
+	}
+
+	switch g.Type {
+	case GeneratePassword:
+		switch g.Charset {
+		case "":
+			g.Charset = CharsetAlnum
+		case CharsetAlnum, CharsetAlpha, CharsetNumeric, CharsetSymbols:
+		default:
+			return nil, errors.New("unknown charset " + g.Charset +
+				", expected alnum, alpha, numeric, or symbols")
+		}
+	case GenerateHex, GenerateBase64:
+		if g.Charset != "" {
+			return nil, errors.New("charset can only be given for passwords")
+		}
+	case GenerateUUID:
+		if lengthSet || g.Charset != "" {
+			return nil, errors.New("uuids do not take a length or charset")
+		}
+		return g, nil
+	default:
+		return nil, errors.New("unknown generator " + g.Type +
+			", expected password, hex, base64, or uuid")
+	}
+
+	if !lengthSet {
+		g.Length = defaultGenerateLength
+	}
+	if g.Length < minGenerateLength || g.Length > maxGenerateLength {
+		return nil, errors.New("length must be between " + strconv.Itoa(minGenerateLength) +
+			" and " + strconv.Itoa(maxGenerateLength))
+	}
+
+	return g, nil
+}
+
+// String returns the spec in the form read by ParseGeneratorSpec.
+func (g *GeneratorSpec) String() string {
+	opts := []string{g.Type}
+	if g.Length > 0 {
+		opts = append(opts, "length="+strconv.Itoa(g.Length))
+	}
+	if g.Charset != "" {
+		opts = append(opts, "charset="+g.Charset)
+	}
+
+	return strings.Join(opts, ",")
+}
@@ -0,0 +1,43 @@
+package apitypes
+
+import "testing"
+
+func TestParseGeneratorSpec(t *testing.T) {
+	tcs := []struct {
+		in   string
+		want GeneratorSpec
+	}{
+		{"length=32,charset=alnum", GeneratorSpec{GeneratePassword, 32, CharsetAlnum}},
+		{"password", GeneratorSpec{GeneratePassword, 32, CharsetAlnum}},
+		{"charset=symbols,length=64", GeneratorSpec{GeneratePassword, 64, CharsetSymbols}},
+		{"hex,length=16", GeneratorSpec{GenerateHex, 16, ""}},
+		{"type=base64", GeneratorSpec{GenerateBase64, 32, ""}},
+		{"uuid", GeneratorSpec{GenerateUUID, 0, ""}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseGeneratorSpec(tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tc.want {
+				t.Errorf("expected %+v, got %+v", tc.want, *got)
+			}
+
+			again, err := ParseGeneratorSpec(got.String())
+			if err != nil || *again != *got {
+				t.Errorf("%q did not round trip: %+v, %v", got.String(), again, err)
+			}
+		})
+	}
+
+	for _, in := range []string{"length=4", "length=x", "charset=emoji", "hex,charset=alnum",
+		"uuid,length=32", "rot13", "size=32"} {
+		t.Run(in, func(t *testing.T) {
+			if _, err := ParseGeneratorSpec(in); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
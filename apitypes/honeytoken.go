@@ -0,0 +1,23 @@
+package apitypes
+
+import (
+	"time"
+
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// HoneytokenRead reports that a secret marked as a honeytoken was decrypted.
+// Honeytokens are never used by real workloads, so any read of one is a sign
+// that someone is looking where they shouldn't be.
+type HoneytokenRead struct {
+	Time   time.Time    `json:"time"`
+	OrgID  *identity.ID `json:"org_id"`
+	Path   string       `json:"path"`
+	Reader *identity.ID `json:"reader_id"`
+
+	// AuthID is the user, or machine token, that the reader logged in with.
+	AuthID *identity.ID `json:"auth_id"`
+
+	// Hostname is the name of the machine the secret was read on.
+	Hostname string `json:"hostname"`
+}
@@ -0,0 +1,13 @@
+package apitypes
+
+// Steps of keypair generation, identified in its progress events.
+const (
+	// KeypairStepDerive is the generation of the keypairs themselves.
+	KeypairStepDerive = "derive"
+
+	// KeypairStepClaim is the signing of the claims vouching for the keys.
+	KeypairStepClaim = "claim"
+
+	// KeypairStepUpload is the upload of the keys and claims to the registry.
+	KeypairStepUpload = "upload"
+)
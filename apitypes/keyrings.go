@@ -0,0 +1,78 @@
+package apitypes
+
+import (
+	"time"
+
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// KeyringRotationRequest asks the daemon to rotate the keyring for a path
+// expression.
+type KeyringRotationRequest struct {
+	PathExp string `json:"pathexp"`
+}
+
+// KeyringRotation is the result of rotating a keyring's master encryption key.
+type KeyringRotation struct {
+	KeyringID      *identity.ID `json:"keyring_id"`
+	PathExp        string       `json:"pathexp"`
+	KeyringVersion int          `json:"keyring_version"`
+
+	// Members is the number of users and machines the new key is shared with.
+	Members int `json:"members"`
+
+	// Credentials is the number of secrets encrypted again with the new key.
+	Credentials int `json:"credentials"`
+}
+
+// KeyringFreeze describes a keyring frozen during a security incident. While
+// frozen, its secrets can not be changed, and can only be read by an org owner
+// who gives a reason for breaking the glass.
+type KeyringFreeze struct {
+	Frozen   time.Time    `json:"frozen_at"`
+	FrozenBy *identity.ID `json:"frozen_by"`
+	Reason   string       `json:"reason"`
+}
+
+// KeyringFreezeRequest asks for every keyring matching a path expression to be
+// frozen or unfrozen.
+type KeyringFreezeRequest struct {
+	OrgID   *identity.ID `json:"org_id"`
+	PathExp string       `json:"pathexp"`
+
+	// Reason is required when freezing.
+	Reason string `json:"reason,omitempty"`
+}
+
+// KeyringFreezeResult is the result of freezing or unfreezing keyrings.
+type KeyringFreezeResult struct {
+	// Keyrings is the number of keyrings frozen or unfrozen.
+	Keyrings int `json:"keyrings"`
+}
+
+// KeyringMembers describes the current version of a keyring, and the users
+// and machines it is shared with.
+type KeyringMembers struct {
+	KeyringID      *identity.ID    `json:"keyring_id"`
+	PathExp        string          `json:"pathexp"`
+	KeyringVersion int             `json:"keyring_version"`
+	Frozen         bool            `json:"frozen"`
+	Members        []KeyringMember `json:"members"`
+}
+
+// KeyringMember is a share of a keyring's master encryption key with a user,
+// or a machine's token.
+type KeyringMember struct {
+	ID      *identity.ID `json:"id"`
+	OwnerID *identity.ID `json:"owner_id"`
+	Created time.Time    `json:"created_at"`
+
+	// PublicKeyID is the owner's encryption key the share is encrypted for,
+	// and EncryptingKeyID the encryption key of whoever shared it.
+	PublicKeyID     *identity.ID `json:"public_key_id"`
+	EncryptingKeyID *identity.ID `json:"encrypting_key_id"`
+
+	// Revoked is true if the share has been revoked, so the owner can no
+	// longer decrypt the keyring with it.
+	Revoked bool `json:"revoked"`
+}
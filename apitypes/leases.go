@@ -0,0 +1,29 @@
+package apitypes
+
+import "time"
+
+// LeaseTokenHeader is the header holding the token of the lease being read
+// or revoked.
+const LeaseTokenHeader = "X-Torus-Lease-Token"
+
+// LeaseVar is an environment variable held by a lease.
+type LeaseVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// LeaseRequest asks the daemon to hold variables for a process run by torus
+// run, until the lease expires or is revoked.
+type LeaseRequest struct {
+	// TTL is how long the lease lasts, in seconds.
+	TTL  int64      `json:"ttl"`
+	Vars []LeaseVar `json:"vars"`
+}
+
+// Lease is a lease created by the daemon. Its variables can be read with its
+// ID and Token, until it expires.
+type Lease struct {
+	ID        string    `json:"id"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
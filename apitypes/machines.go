@@ -24,3 +24,9 @@ type MachinesCreateRequest struct {
 	TeamID *identity.ID  `json:"team_id"`
 	Secret *base64.Value `json:"secret"`
 }
+
+// MachineTokenRestriction limits where the tokens for a machine can be used
+// from. An empty list of CIDRs lifts the restriction.
+type MachineTokenRestriction struct {
+	AllowedCIDRs []string `json:"allowed_cidrs"`
+}
@@ -0,0 +1,28 @@
+package apitypes
+
+import "time"
+
+// MFACodeHeader carries a code from the user's authenticator app, for
+// operations the registry requires multi-factor authentication for.
+const MFACodeHeader = "X-Torus-MFA-Code"
+
+// MFAStatus is whether the user has multi-factor authentication enabled.
+type MFAStatus struct {
+	Enabled   bool       `json:"enabled"`
+	EnabledAt *time.Time `json:"enabled_at,omitempty"`
+}
+
+// MFAEnrollment holds the TOTP secret for a user enabling multi-factor
+// authentication. It isn't enabled until a code generated from it is
+// confirmed.
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+
+	// URI is the otpauth:// URI of the secret, for authenticator apps.
+	URI string `json:"uri"`
+}
+
+// MFACode is a code from the user's authenticator app.
+type MFACode struct {
+	Code string `json:"code"`
+}
@@ -0,0 +1,29 @@
+package apitypes
+
+// OrgSettings are the conventions shared by everyone in an org.
+type OrgSettings struct {
+	// Environments are the org's canonical environment names. When any are
+	// defined, new environments must use one of them.
+	Environments []EnvironmentSetting `json:"environments"`
+}
+
+// EnvironmentSetting describes a canonical environment name.
+type EnvironmentSetting struct {
+	Name string `json:"name"`
+
+	// Protected environments can not be written to with a wildcard, and
+	// writes to them must be confirmed.
+	Protected bool `json:"protected"`
+}
+
+// Environment returns the setting for the named environment, or nil if it is
+// not defined.
+func (s *OrgSettings) Environment(name string) *EnvironmentSetting {
+	for i, env := range s.Environments {
+		if env.Name == name {
+			return &s.Environments[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package apitypes
+
+import (
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// PolicyAttachmentSegment represents a policy attachment along with the team
+// or machine role it attaches the policy to.
+type PolicyAttachmentSegment struct {
+	Attachment *envelope.PolicyAttachment `json:"attachment"`
+	Team       *envelope.Team             `json:"team"`
+}
+
+// The outcomes of verifying a policy's signature.
+const (
+	PolicyUnsigned = "unsigned"
+	PolicySigned   = "signed"
+	PolicyInvalid  = "invalid"
+)
+
+// PolicySignature is the outcome of verifying the signature of a policy.
+// SignerID is the user or machine that signed a valid signature, and Problem
+// says why an invalid one can't be trusted.
+type PolicySignature struct {
+	PolicyID *identity.ID `json:"policy_id"`
+	Status   string       `json:"status"`
+	SignerID *identity.ID `json:"signer_id,omitempty"`
+	Problem  string       `json:"problem,omitempty"`
+}
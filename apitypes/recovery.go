@@ -0,0 +1,16 @@
+package apitypes
+
+// RecoveryCodes contains a user's newly generated recovery codes. They are
+// only ever shown once; the registry holds a copy of the master key wrapped
+// with each code, but never the codes themselves.
+type RecoveryCodes struct {
+	Codes []string `json:"codes"`
+}
+
+// RecoveryRequest contains the fields needed to replace a forgotten password
+// using a recovery code.
+type RecoveryRequest struct {
+	Email    string `json:"email"`
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,18 @@
+package apitypes
+
+import "time"
+
+// ActiveSession represents an auth token that is still live for the current
+// user, along with where and when it was issued.
+type ActiveSession struct {
+	ID       string      `json:"id"`
+	Type     SessionType `json:"type"`
+	Created  time.Time   `json:"created_at"`
+	LastUsed *time.Time  `json:"last_used_at"`
+	Device   string      `json:"device"`
+	IP       string      `json:"ip"`
+	Version  string      `json:"version"`
+
+	// Current is set for the session the request was made with.
+	Current bool `json:"current"`
+}
@@ -0,0 +1,31 @@
+package apitypes
+
+import (
+	"time"
+
+	"github.com/manifoldco/torus-cli/base64"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// Share is a secret shared through a one-time link. The registry only holds
+// the secret encrypted with a key that never leaves the sharer's machine,
+// except as part of the token given to whoever redeems it.
+type Share struct {
+	ID         string        `json:"id,omitempty"`
+	OrgID      *identity.ID  `json:"org_id"`
+	Ciphertext *base64.Value `json:"ciphertext"`
+	Nonce      *base64.Value `json:"nonce"`
+	Expires    time.Time     `json:"expires_at"`
+	MaxReads   int           `json:"max_reads"`
+
+	// Reads is how many times the share has been redeemed, including the
+	// current redemption.
+	Reads int `json:"reads"`
+}
+
+// SharedSecret is the name and value of a shared secret, as encrypted in a
+// Share.
+type SharedSecret struct {
+	Name  string           `json:"name"`
+	Value *CredentialValue `json:"value"`
+}
@@ -18,6 +18,9 @@ const (
 	MissingKeypairsWorklogType
 	InviteApproveWorklogType
 	KeyringMembersWorklogType
+	KeyTrustWorklogType
+	StaleClaimsWorklogType
+	RevokedKeysWorklogType
 
 	AnyWorklogType WorklogType = 0xff
 )
@@ -96,11 +99,29 @@ func (t WorklogType) String() string {
 		return "invite"
 	case KeyringMembersWorklogType:
 		return "keyring"
+	case KeyTrustWorklogType:
+		return "trust"
+	case StaleClaimsWorklogType:
+		return "claims"
+	case RevokedKeysWorklogType:
+		return "revoked"
 	default:
 		return "n/a"
 	}
 }
 
+// ParseWorklogType returns the worklog type with the given name, as returned
+// by String.
+func ParseWorklogType(name string) (WorklogType, error) {
+	for t := SecretRotateWorklogType; t != 0 && t < AnyWorklogType; t <<= 1 {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+
+	return 0, errors.New("unknown worklog type " + name)
+}
+
 // CreateID creates and populates a WorklogID for the WorklogItem based on the
 // given type and its subject.
 func (w *WorklogItem) CreateID(worklogType WorklogType) {
@@ -0,0 +1,167 @@
+// Package aws syncs secrets into AWS Secrets Manager and SSM Parameter Store,
+// so services deployed on AWS can read managed values without running the
+// torus daemon.
+package aws
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-ini/ini"
+)
+
+// Credentials are the AWS access keys requests are signed with.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Config holds the credentials and region to use when talking to AWS.
+type Config struct {
+	Credentials Credentials
+	Region      string
+}
+
+// LoadConfig returns the credentials and region the AWS CLI would use for the
+// given profile: those set in the environment, or else those in the shared
+// credentials and config files. An empty profile uses $AWS_PROFILE, or the
+// default profile.
+func LoadConfig(profile string) (*Config, error) {
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+
+	cfg := &Config{
+		Credentials: Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		Region: os.Getenv("AWS_REGION"),
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+
+	if cfg.Credentials.AccessKeyID == "" {
+		section, err := profileSection(credentialsFile(), profile)
+		if err != nil {
+			return nil, err
+		}
+		if section != nil {
+			cfg.Credentials = Credentials{
+				AccessKeyID:     section.Key("aws_access_key_id").String(),
+				SecretAccessKey: section.Key("aws_secret_access_key").String(),
+				SessionToken:    section.Key("aws_session_token").String(),
+			}
+		}
+	}
+
+	if cfg.Region == "" {
+		name := "profile " + profile
+		if profile == "default" {
+			name = profile
+		}
+
+		section, err := profileSection(configFile(), name)
+		if err != nil {
+			return nil, err
+		}
+		if section != nil {
+			cfg.Region = section.Key("region").String()
+		}
+	}
+
+	if cfg.Credentials.AccessKeyID == "" || cfg.Credentials.SecretAccessKey == "" {
+		return nil, errors.New("no AWS credentials found for profile " + profile)
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("no AWS region set for profile " + profile)
+	}
+
+	return cfg, nil
+}
+
+func credentialsFile() string {
+	if f := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); f != "" {
+		return f
+	}
+	return filepath.Join(os.Getenv("HOME"), ".aws", "credentials")
+}
+
+func configFile() string {
+	if f := os.Getenv("AWS_CONFIG_FILE"); f != "" {
+		return f
+	}
+	return filepath.Join(os.Getenv("HOME"), ".aws", "config")
+}
+
+// profileSection returns the named section of the ini file at path, or nil if
+// the file or section don't exist.
+func profileSection(path, name string) (*ini.Section, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	f, err := ini.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	section, err := f.GetSection(name)
+	if err != nil {
+		return nil, nil
+	}
+	return section, nil
+}
+
+// Secrets and parameters written by torus are tagged, so that later syncs can
+// tell them apart from those managed by other tools.
+const (
+	ManagedByTag = "managed-by"
+
+	managedBy = "torus"
+)
+
+// tag is an AWS resource tag.
+type tag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+var managedTags = []tag{{Key: ManagedByTag, Value: managedBy}}
+
+func isManaged(tags []tag) bool {
+	for _, t := range tags {
+		if t.Key == ManagedByTag && t.Value == managedBy {
+			return true
+		}
+	}
+	return false
+}
+
+// fullName returns the name of the secret or parameter for name under prefix.
+func fullName(prefix, name string) string {
+	return strings.TrimSuffix(prefix, "/") + "/" + name
+}
+
+// shortName returns the name under prefix of the secret or parameter full, or
+// false if it's not directly under prefix.
+func shortName(prefix, full string) (string, bool) {
+	dir := strings.TrimSuffix(prefix, "/") + "/"
+	if !strings.HasPrefix(full, dir) {
+		return "", false
+	}
+
+	name := full[len(dir):]
+	if name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,96 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// The get-vanilla case of the AWS Signature Version 4 test suite.
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds := &Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	sign(req, nil, creds, "us-east-1", "service", now)
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("wrong Authorization header.\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestShortName(t *testing.T) {
+	tcs := []struct {
+		prefix, full, name string
+		ok                 bool
+	}{
+		{"/app/prod", "/app/prod/TOKEN", "TOKEN", true},
+		{"/app/prod/", "/app/prod/TOKEN", "TOKEN", true},
+		{"/app/prod", "/app/prod/db/TOKEN", "", false},
+		{"/app/prod", "/app/production/TOKEN", "", false},
+		{"app", "app/TOKEN", "TOKEN", true},
+	}
+
+	for _, tc := range tcs {
+		name, ok := shortName(tc.prefix, tc.full)
+		if name != tc.name || ok != tc.ok {
+			t.Errorf("shortName(%q, %q) = %q, %t; want %q, %t",
+				tc.prefix, tc.full, name, ok, tc.name, tc.ok)
+		}
+	}
+}
+
+func TestParameterStoreList(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-Amz-Target") {
+		case "AmazonSSM.DescribeParameters":
+			w.Write([]byte(`{"Parameters": [{"Name": "/app/prod/TOKEN"}]}`))
+		case "AmazonSSM.GetParameters":
+			req := struct {
+				Names          []string
+				WithDecryption bool
+			}{}
+			json.NewDecoder(r.Body).Decode(&req)
+			if !req.WithDecryption || len(req.Names) != 1 {
+				t.Errorf("unexpected GetParameters request: %+v", req)
+			}
+			w.Write([]byte(`{"Parameters": [{"Name": "/app/prod/TOKEN", "Value": "abc"}]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type": "com.amazonaws.ssm#InvalidAction", "message": "bad action"}`))
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &Config{Credentials: Credentials{AccessKeyID: "id", SecretAccessKey: "key"}, Region: "us-east-1"}
+	p := NewParameterStore(cfg, "/app/prod", "")
+	p.endpoint = srv.URL
+
+	params, err := p.List(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	value := "abc"
+	if want := map[string]*string{"TOKEN": &value}; !reflect.DeepEqual(params, want) {
+		t.Errorf("wrong parameters. got %v want %v", params, want)
+	}
+
+	err = p.Delete(context.Background(), "TOKEN")
+	if apiErr, ok := err.(*Error); !ok || apiErr.Type != "InvalidAction" {
+		t.Errorf("expected an InvalidAction error, got %v", err)
+	}
+}
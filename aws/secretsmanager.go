@@ -0,0 +1,134 @@
+package aws
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// SecretsManager is the set of secrets under a name prefix in AWS Secrets
+// Manager. Only secrets tagged as managed by torus are listed, so that secrets
+// written by other tools are never changed or deleted.
+type SecretsManager struct {
+	client
+	prefix string
+	kmsKey string
+}
+
+// NewSecretsManager returns the secrets named prefix/NAME, in the region
+// given by cfg. New secrets are encrypted with kmsKey, or the account's
+// default key if it's empty.
+func NewSecretsManager(cfg *Config, prefix, kmsKey string) *SecretsManager {
+	return &SecretsManager{
+		client: newClient(cfg, "secretsmanager", "secretsmanager"),
+		prefix: prefix,
+		kmsKey: kmsKey,
+	}
+}
+
+// List returns the names and current values of every managed secret under
+// the prefix.
+func (s *SecretsManager) List(ctx context.Context) (map[string]*string, error) {
+	var names []string
+	req := map[string]interface{}{
+		"Filters": []map[string]interface{}{
+			{"Key": "name", "Values": []string{strings.TrimSuffix(s.prefix, "/") + "/"}},
+		},
+		"MaxResults": 100,
+	}
+
+	for {
+		resp := struct {
+			SecretList []struct {
+				Name string `json:"Name"`
+				Tags []tag  `json:"Tags"`
+			} `json:"SecretList"`
+			NextToken string `json:"NextToken"`
+		}{}
+
+		err := s.do(ctx, "ListSecrets", req, &resp)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, secret := range resp.SecretList {
+			// The name filter isn't case sensitive, so check it again.
+			if _, ok := shortName(s.prefix, secret.Name); ok && isManaged(secret.Tags) {
+				names = append(names, secret.Name)
+			}
+		}
+
+		if resp.NextToken == "" {
+			break
+		}
+		req["NextToken"] = resp.NextToken
+	}
+
+	secrets := make(map[string]*string, len(names))
+	for _, full := range names {
+		resp := struct {
+			SecretString string `json:"SecretString"`
+		}{}
+
+		err := s.do(ctx, "GetSecretValue", map[string]string{"SecretId": full}, &resp)
+		if err != nil {
+			return nil, err
+		}
+
+		name, _ := shortName(s.prefix, full)
+		value := resp.SecretString
+		secrets[name] = &value
+	}
+
+	return secrets, nil
+}
+
+// Create creates a secret, tagged as managed by torus.
+func (s *SecretsManager) Create(ctx context.Context, name, value string) error {
+	token, err := requestToken()
+	if err != nil {
+		return err
+	}
+
+	req := map[string]interface{}{
+		"Name":               fullName(s.prefix, name),
+		"SecretString":       value,
+		"ClientRequestToken": token,
+		"Tags":               managedTags,
+	}
+	if s.kmsKey != "" {
+		req["KmsKeyId"] = s.kmsKey
+	}
+
+	return s.do(ctx, "CreateSecret", req, nil)
+}
+
+// Update stores value as the current version of a secret.
+func (s *SecretsManager) Update(ctx context.Context, name, value string) error {
+	token, err := requestToken()
+	if err != nil {
+		return err
+	}
+
+	return s.do(ctx, "PutSecretValue", map[string]string{
+		"SecretId":           fullName(s.prefix, name),
+		"SecretString":       value,
+		"ClientRequestToken": token,
+	}, nil)
+}
+
+// Delete schedules a secret for deletion, after the default recovery window.
+func (s *SecretsManager) Delete(ctx context.Context, name string) error {
+	return s.do(ctx, "DeleteSecret", map[string]string{
+		"SecretId": fullName(s.prefix, name),
+	}, nil)
+}
+
+// requestToken returns a random token identifying a new version of a secret.
+// The SDKs generate these; requests made without one are refused.
+func requestToken() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	return hex.EncodeToString(b), err
+}
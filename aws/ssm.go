@@ -0,0 +1,138 @@
+package aws
+
+import (
+	"context"
+	"strings"
+)
+
+// ssmBatchSize is the most parameters that can be read in one request.
+const ssmBatchSize = 10
+
+// ParameterStore is the set of parameters under a path in AWS SSM Parameter
+// Store. Only parameters tagged as managed by torus are listed, so that
+// parameters written by other tools are never changed or deleted. Values are
+// stored as SecureStrings.
+type ParameterStore struct {
+	client
+	prefix string
+	kmsKey string
+}
+
+// NewParameterStore returns the parameters named prefix/NAME, in the region
+// given by cfg. prefix must begin with a /. Values are encrypted with kmsKey,
+// or the account's default key for SSM if it's empty.
+func NewParameterStore(cfg *Config, prefix, kmsKey string) *ParameterStore {
+	return &ParameterStore{
+		client: newClient(cfg, "ssm", "AmazonSSM"),
+		prefix: prefix,
+		kmsKey: kmsKey,
+	}
+}
+
+// List returns the names and current values of every managed parameter
+// under the prefix.
+func (p *ParameterStore) List(ctx context.Context) (map[string]*string, error) {
+	path := strings.TrimSuffix(p.prefix, "/")
+	if path == "" {
+		path = "/"
+	}
+
+	var names []string
+	req := map[string]interface{}{
+		"ParameterFilters": []map[string]interface{}{
+			{"Key": "Path", "Option": "OneLevel", "Values": []string{path}},
+			{"Key": "tag:" + ManagedByTag, "Values": []string{managedBy}},
+		},
+		"MaxResults": 50,
+	}
+
+	for {
+		resp := struct {
+			Parameters []struct {
+				Name string `json:"Name"`
+			} `json:"Parameters"`
+			NextToken string `json:"NextToken"`
+		}{}
+
+		err := p.do(ctx, "DescribeParameters", req, &resp)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, param := range resp.Parameters {
+			names = append(names, param.Name)
+		}
+
+		if resp.NextToken == "" {
+			break
+		}
+		req["NextToken"] = resp.NextToken
+	}
+
+	params := make(map[string]*string, len(names))
+	for len(names) > 0 {
+		n := len(names)
+		if n > ssmBatchSize {
+			n = ssmBatchSize
+		}
+
+		resp := struct {
+			Parameters []struct {
+				Name  string `json:"Name"`
+				Value string `json:"Value"`
+			} `json:"Parameters"`
+		}{}
+
+		err := p.do(ctx, "GetParameters", map[string]interface{}{
+			"Names":          names[:n],
+			"WithDecryption": true,
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, param := range resp.Parameters {
+			if name, ok := shortName(p.prefix, param.Name); ok {
+				value := param.Value
+				params[name] = &value
+			}
+		}
+
+		names = names[n:]
+	}
+
+	return params, nil
+}
+
+// Create creates a parameter, tagged as managed by torus.
+func (p *ParameterStore) Create(ctx context.Context, name, value string) error {
+	req := p.putRequest(name, value)
+	req["Tags"] = managedTags
+	return p.do(ctx, "PutParameter", req, nil)
+}
+
+// Update stores value as the next version of a parameter.
+func (p *ParameterStore) Update(ctx context.Context, name, value string) error {
+	req := p.putRequest(name, value)
+	req["Overwrite"] = true
+	return p.do(ctx, "PutParameter", req, nil)
+}
+
+// Delete deletes a parameter.
+func (p *ParameterStore) Delete(ctx context.Context, name string) error {
+	return p.do(ctx, "DeleteParameter", map[string]string{
+		"Name": fullName(p.prefix, name),
+	}, nil)
+}
+
+func (p *ParameterStore) putRequest(name, value string) map[string]interface{} {
+	req := map[string]interface{}{
+		"Name":  fullName(p.prefix, name),
+		"Value": value,
+		"Type":  "SecureString",
+	}
+	if p.kmsKey != "" {
+		req["KeyId"] = p.kmsKey
+	}
+	return req
+}
@@ -0,0 +1,267 @@
+// Package bundle reads and writes credential bundles: archives of secrets,
+// encrypted with a passphrase and signed, for hosts that can't reach the
+// registry, such as air-gapped deploy targets.
+//
+// Secrets are encrypted with secretbox, using a key derived from the
+// passphrase with scrypt. The encrypted archive is signed with an ed25519 key
+// belonging to whoever created it. Bundles are only opened given the key they
+// must be signed by, so the host opening one knows who it came from, as well
+// as that it hasn't been changed; anyone can sign a bundle with a key of
+// their own.
+package bundle
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/manifoldco/torus-cli/base64"
+)
+
+// Version is the version of the bundle format written by Create.
+const Version = 1
+
+// The algorithms used by bundles of the current version.
+const (
+	Encryption = "scrypt+secretbox"
+	Signature  = "eddsa"
+)
+
+// scrypt parameters, matching those used for passwords.
+const (
+	scryptN   = 32768
+	scryptR   = 8
+	scryptP   = 1
+	keyBytes  = 32
+	saltBytes = 16
+)
+
+// ErrPassphrase is returned by Open when the passphrase is wrong.
+var ErrPassphrase = errors.New("wrong passphrase, or the bundle is damaged")
+
+// Secret is a secret held in a bundle. Value is a string, number, or bool.
+// Numbers are read back as json.Numbers.
+type Secret struct {
+	Path  string      `json:"path"`
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// Contents are what a bundle holds, once opened.
+type Contents struct {
+	Created time.Time `json:"created_at"`
+	Creator string    `json:"creator"`
+	Secrets []Secret  `json:"secrets"`
+}
+
+// file is a bundle as it's written.
+type file struct {
+	Version    int           `json:"version"`
+	Encryption string        `json:"encryption"`
+	Salt       *base64.Value `json:"salt"`
+	Nonce      *base64.Value `json:"nonce"`
+	Ciphertext *base64.Value `json:"ciphertext"`
+
+	Signature *Sig `json:"signature"`
+}
+
+// Sig is the signature of a bundle.
+type Sig struct {
+	Algorithm string        `json:"alg"`
+	PublicKey *base64.Value `json:"public_key"`
+	Value     *base64.Value `json:"value"`
+}
+
+// signed returns the bytes of f covered by its signature.
+func (f *file) signed() []byte {
+	b, _ := json.Marshal(struct {
+		Version    int           `json:"version"`
+		Encryption string        `json:"encryption"`
+		Salt       *base64.Value `json:"salt"`
+		Nonce      *base64.Value `json:"nonce"`
+		Ciphertext *base64.Value `json:"ciphertext"`
+	}{f.Version, f.Encryption, f.Salt, f.Nonce, f.Ciphertext})
+	return b
+}
+
+func deriveKey(passphrase, salt []byte) (*[keyBytes]byte, error) {
+	k, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, keyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key := [keyBytes]byte{}
+	copy(key[:], k)
+	return &key, nil
+}
+
+// Create writes a bundle holding c to w, encrypted with passphrase, and
+// signed with key.
+func Create(w io.Writer, c *Contents, passphrase []byte, key ed25519.PrivateKey) error {
+	pt, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	random := make([]byte, saltBytes+24)
+	_, err = rand.Read(random)
+	if err != nil {
+		return err
+	}
+	salt, nonce := random[:saltBytes], [24]byte{}
+	copy(nonce[:], random[saltBytes:])
+
+	k, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	f := &file{
+		Version:    Version,
+		Encryption: Encryption,
+		Salt:       base64.NewValue(salt),
+		Nonce:      base64.NewValue(nonce[:]),
+		Ciphertext: base64.NewValue(secretbox.Seal(nil, pt, &nonce, k)),
+	}
+
+	pub := key.Public().(ed25519.PublicKey)
+	f.Signature = &Sig{
+		Algorithm: Signature,
+		PublicKey: base64.NewValue(pub),
+		Value:     base64.NewValue(ed25519.Sign(key, f.signed())),
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f)
+}
+
+// Open reads a bundle from r, checks that it was signed by signer, and
+// decrypts it with passphrase. The key the bundle says it was signed with is
+// only used to report a mismatch; the signature is checked against signer.
+func Open(r io.Reader, passphrase []byte, signer ed25519.PublicKey) (*Contents, error) {
+	if len(signer) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid signer key")
+	}
+
+	f := file{}
+	err := json.NewDecoder(r).Decode(&f)
+	if err != nil {
+		return nil, errors.New("not a bundle: " + err.Error())
+	}
+
+	if f.Version != Version || f.Encryption != Encryption {
+		return nil, errors.New("unsupported bundle version")
+	}
+	if f.Salt == nil || f.Nonce == nil || f.Ciphertext == nil || len(*f.Nonce) != 24 {
+		return nil, errors.New("incomplete bundle")
+	}
+
+	sig := f.Signature
+	if sig == nil || sig.Algorithm != Signature || sig.Value == nil {
+		return nil, errors.New("bundle is not signed")
+	}
+	if sig.PublicKey != nil && !bytes.Equal(*sig.PublicKey, signer) {
+		return nil, &SignerError{Signer: ed25519.PublicKey(*sig.PublicKey)}
+	}
+	if !ed25519.Verify(signer, f.signed(), *sig.Value) {
+		return nil, errors.New("bundle signature is invalid")
+	}
+
+	k, err := deriveKey(passphrase, *f.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := [24]byte{}
+	copy(nonce[:], *f.Nonce)
+	pt, ok := secretbox.Open(nil, *f.Ciphertext, &nonce, k)
+	if !ok {
+		return nil, ErrPassphrase
+	}
+
+	// Numbers are kept as they were written, so large integers aren't
+	// turned into floats.
+	c := &Contents{}
+	dec := json.NewDecoder(bytes.NewReader(pt))
+	dec.UseNumber()
+	err = dec.Decode(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// SignerError is returned by Open when a bundle says it was signed by a
+// different key than the one it must be signed by.
+type SignerError struct {
+	Signer ed25519.PublicKey
+}
+
+func (e *SignerError) Error() string {
+	return "bundle was signed by " + KeyID(e.Signer)
+}
+
+// KeyID returns the printable form of a signing public key, as shown to
+// those opening bundles, and given to check who signed one.
+func KeyID(pub ed25519.PublicKey) string {
+	return base64.NewValue(pub).String()
+}
+
+// ParseKeyID returns the signing public key printed as id by KeyID.
+func ParseKeyID(id string) (ed25519.PublicKey, error) {
+	v, err := base64.NewValueFromString(id)
+	if err != nil || len(*v) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid bundle signing key: " + id)
+	}
+
+	return ed25519.PublicKey(*v), nil
+}
+
+// LoadKey returns the signing key stored at path, generating and storing a
+// new one, readable only by the current user, if there is none yet.
+//
+// The key is stored unencrypted, like an ssh key without a passphrase.
+// Anyone who can read the file can sign bundles as its owner, so it is only
+// as safe as the account that holds it.
+func LoadKey(path string) (ed25519.PrivateKey, error) {
+	b, err := ioutil.ReadFile(path)
+	if err == nil {
+		v, err := base64.NewValueFromString(string(b))
+		if err != nil || len(*v) != ed25519.PrivateKeySize {
+			return nil, errors.New("invalid bundle signing key in " + path)
+		}
+		return ed25519.PrivateKey(*v), nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ioutil.WriteFile(path, []byte(base64.NewValue(key).String()), 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
@@ -0,0 +1,127 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/manifoldco/torus-cli/base64"
+)
+
+func TestBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "torus-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyPath := filepath.Join(dir, "bundle.key")
+	key, err := LoadKey(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Contents{
+		Created: time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC),
+		Creator: "jeff",
+		Secrets: []Secret{
+			{Path: "/acme/api/prod/web/*/1", Name: "port", Value: json.Number("8080")},
+			{Path: "/acme/api/prod/web/*/1", Name: "token", Value: "s3cr3t"},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := Create(buf, c, []byte("correct horse"), key); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("s3cr3t")) {
+		t.Fatal("bundle holds a plaintext secret")
+	}
+
+	again, err := LoadKey(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ParseKeyID(KeyID(again.Public().(ed25519.PublicKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("open", func(t *testing.T) {
+		opened, err := Open(bytes.NewReader(buf.Bytes()), []byte("correct horse"), signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(opened, c) {
+			t.Errorf("got %+v, want %+v", opened, c)
+		}
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		_, err := Open(bytes.NewReader(buf.Bytes()), []byte("battery staple"), signer)
+		if err != ErrPassphrase {
+			t.Errorf("got %v, want ErrPassphrase", err)
+		}
+	})
+
+	t.Run("other signer", func(t *testing.T) {
+		other, forged, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = Open(bytes.NewReader(buf.Bytes()), []byte("correct horse"), other)
+		if _, ok := err.(*SignerError); !ok {
+			t.Errorf("got %v, want a SignerError", err)
+		}
+
+		// A bundle re-signed by someone else, claiming the trusted key,
+		// still fails.
+		f := file{}
+		if err := json.Unmarshal(buf.Bytes(), &f); err != nil {
+			t.Fatal(err)
+		}
+		f.Signature.Value = base64.NewValue(ed25519.Sign(forged, f.signed()))
+
+		b, err := json.Marshal(&f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(bytes.NewReader(b), []byte("correct horse"), signer); err == nil {
+			t.Error("expected a bundle signed by another key to fail its signature check")
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		f := file{}
+		if err := json.Unmarshal(buf.Bytes(), &f); err != nil {
+			t.Fatal(err)
+		}
+		(*f.Ciphertext)[0] ^= 1
+
+		b, err := json.Marshal(&f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(bytes.NewReader(b), []byte("correct horse"), signer); err == nil {
+			t.Error("expected a tampered bundle to fail its signature check")
+		}
+	})
+
+	t.Run("key is private", func(t *testing.T) {
+		info, err := os.Stat(keyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("got mode %s, want 0600", info.Mode().Perm())
+		}
+	})
+}
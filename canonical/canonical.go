@@ -0,0 +1,117 @@
+// Package canonical provides the deterministic JSON serialization used by
+// Torus when deriving the IDs of immutable objects, and when signing and
+// verifying them.
+//
+// The canonical encoding of an object body is the output of encoding/json for
+// the body's Go type, from the primitive package: object keys appear in struct
+// field order, there is no insignificant whitespace, and the characters <, >
+// and & are escaped as \u003c, \u003e and \u0026. Clients in other languages
+// must reproduce these bytes exactly, or signatures will not verify.
+//
+// Canonicalize can be used to bring JSON received over the wire, which may
+// have been re-indented or re-escaped, back into canonical form without
+// knowing its schema.
+package canonical
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// Marshal returns the canonical JSON encoding of v.
+func Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// SigningBytes returns the bytes that are hashed or signed for an object body
+// with the given schema version: the version in decimal, followed by the
+// canonical encoding of the body.
+func SigningBytes(version int, body interface{}) ([]byte, error) {
+	b, err := Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(strconv.Itoa(version)), b...), nil
+}
+
+// errTrailingData is returned by Canonicalize when the input holds more than
+// a single JSON value.
+var errTrailingData = errors.New("canonical: unexpected data after JSON value")
+
+type frame struct {
+	object bool
+	count  int
+}
+
+// Canonicalize re-encodes the single JSON value in raw into canonical form.
+//
+// Whitespace is removed and strings are re-escaped as encoding/json would
+// escape them. The order of object keys is preserved, as it is significant;
+// number literals are preserved as written.
+func Canonicalize(raw []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	out := &bytes.Buffer{}
+	var stack []frame
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(stack) == 0 && out.Len() > 0 {
+			return nil, errTrailingData
+		}
+
+		// Closing delimiters end the current frame, and never need a
+		// separator before them.
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			out.WriteByte(byte(d))
+			continue
+		}
+
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if top.object && top.count%2 == 1 {
+				out.WriteByte(':')
+			} else if top.count > 0 {
+				out.WriteByte(',')
+			}
+			top.count++
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			out.WriteByte(byte(t))
+			stack = append(stack, frame{object: t == '{'})
+		case string:
+			b, err := json.Marshal(t)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(b)
+		case json.Number:
+			out.WriteString(t.String())
+		case bool:
+			out.WriteString(strconv.FormatBool(t))
+		case nil:
+			out.WriteString("null")
+		}
+	}
+
+	if out.Len() == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return out.Bytes(), nil
+}
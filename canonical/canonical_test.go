@@ -0,0 +1,123 @@
+package canonical
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+type body struct {
+	Name    string            `json:"name"`
+	Created time.Time         `json:"created_at"`
+	Count   int               `json:"count"`
+	Ratio   float64           `json:"ratio"`
+	Tags    []string          `json:"tags"`
+	Extra   map[string]string `json:"extra"`
+	Enabled bool              `json:"enabled"`
+	Parent  *body             `json:"parent"`
+}
+
+func TestSigningBytes(t *testing.T) {
+	b := body{Name: "<a&b>", Count: 2}
+
+	out, err := SigningBytes(1, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := json.Marshal(&b)
+	if !bytes.Equal(out, append([]byte("1"), raw...)) {
+		t.Errorf("signing bytes differ from the original encoding: %s", out)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	tcs := []struct {
+		in, out string
+	}{
+		{`{ "b": 1, "a": [ true, null, "x" ] }`, `{"b":1,"a":[true,null,"x"]}`},
+		{`"<A>"`, `"\u003cA\u003e"`},
+		{`[]`, `[]`},
+		{`{"a":{},"b":[{}]}`, `{"a":{},"b":[{}]}`},
+		{"1.50", "1.50"},
+	}
+
+	for _, tc := range tcs {
+		out, err := Canonicalize([]byte(tc.in))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.in, err)
+			continue
+		}
+		if string(out) != tc.out {
+			t.Errorf("%s: got %s, want %s", tc.in, out, tc.out)
+		}
+	}
+
+	for _, in := range []string{``, `{"a":1} {}`, `{"a":}`, `[1,]`} {
+		if _, err := Canonicalize([]byte(in)); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+}
+
+// Anything encoding/json produces is already canonical.
+func TestCanonicalizeMarshalFixedPoint(t *testing.T) {
+	f := func(name string, count int, ratio float64, tags []string, extra map[string]string, enabled bool) bool {
+		b := body{
+			Name: name, Count: count, Ratio: ratio, Tags: tags,
+			Extra: extra, Enabled: enabled, Created: time.Unix(int64(count), 0).UTC(),
+			Parent: &body{Name: name + strconv.Itoa(count)},
+		}
+
+		raw, err := Marshal(&b)
+		if err != nil {
+			return true // NaN and Inf cannot be encoded at all
+		}
+
+		out, err := Canonicalize(raw)
+		return err == nil && bytes.Equal(raw, out)
+	}
+
+	if err := quick.Check(f, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+// Canonicalizing is idempotent, and indenting does not change the result.
+func TestCanonicalizeIdempotent(t *testing.T) {
+	f := func(keys []string, values []string) bool {
+		m := make(map[string]interface{})
+		for i, k := range keys {
+			if i < len(values) {
+				m[k] = values[i]
+			} else {
+				m[k] = []interface{}{float64(i), nil, i%2 == 0}
+			}
+		}
+
+		indented, err := json.MarshalIndent(m, "", "\t")
+		if err != nil {
+			return false
+		}
+
+		once, err := Canonicalize(indented)
+		if err != nil {
+			return false
+		}
+
+		twice, err := Canonicalize(once)
+		if err != nil {
+			return false
+		}
+
+		compact, _ := json.Marshal(m)
+		return bytes.Equal(once, twice) && bytes.Equal(once, compact)
+	}
+
+	if err := quick.Check(f, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build gofuzz
+// +build gofuzz
+
+package canonical
+
+import "bytes"
+
+// Fuzz is the entry point for go-fuzz. Any input that canonicalizes must
+// canonicalize to a fixed point.
+func Fuzz(data []byte) int {
+	once, err := Canonicalize(data)
+	if err != nil {
+		return 0
+	}
+
+	twice, err := Canonicalize(once)
+	if err != nil {
+		panic("canonical output failed to canonicalize: " + err.Error())
+	}
+
+	if !bytes.Equal(once, twice) {
+		panic("canonicalization is not idempotent")
+	}
+
+	return 1
+}
@@ -0,0 +1,149 @@
+// Package ci syncs secrets into the variable stores of hosted CI providers, so
+// pipelines that cannot run the torus daemon still receive managed values.
+package ci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+)
+
+// Store is the variable store of a CI provider.
+type Store interface {
+	// List returns every variable in the store. Values are nil if the
+	// provider does not expose them.
+	List(ctx context.Context) (map[string]*string, error)
+
+	Create(ctx context.Context, name, value string) error
+	Update(ctx context.Context, name, value string) error
+	Delete(ctx context.Context, name string) error
+}
+
+// Plan holds the changes needed to make a Store match a set of secrets.
+type Plan struct {
+	Create []string
+	Update []string
+	Delete []string
+}
+
+// NewPlan returns the changes needed to turn existing variables into the
+// desired ones. Variables whose values are not known are always updated.
+func NewPlan(existing map[string]*string, desired map[string]string) *Plan {
+	p := &Plan{
+		Create: []string{},
+		Update: []string{},
+		Delete: []string{},
+	}
+
+	for name, value := range desired {
+		current, ok := existing[name]
+		switch {
+		case !ok:
+			p.Create = append(p.Create, name)
+		case current == nil || *current != value:
+			p.Update = append(p.Update, name)
+		}
+	}
+
+	for name := range existing {
+		if _, ok := desired[name]; !ok {
+			p.Delete = append(p.Delete, name)
+		}
+	}
+
+	sort.Strings(p.Create)
+	sort.Strings(p.Update)
+	sort.Strings(p.Delete)
+	return p
+}
+
+// Empty returns whether the plan makes no changes.
+func (p *Plan) Empty() bool {
+	return len(p.Create) == 0 && len(p.Update) == 0 && len(p.Delete) == 0
+}
+
+// Apply makes the planned changes to s, using the values in desired.
+func (p *Plan) Apply(ctx context.Context, s Store, desired map[string]string) error {
+	for _, name := range p.Create {
+		if err := s.Create(ctx, name, desired[name]); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range p.Update {
+		if err := s.Update(ctx, name, desired[name]); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range p.Delete {
+		if err := s.Delete(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Error is an error returned by a CI provider's API.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// client performs JSON requests against a CI provider's API.
+type client struct {
+	http    *http.Client
+	baseURL string
+	header  http.Header
+}
+
+func (c *client) do(ctx context.Context, method, path string, body, v interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, r)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &Error{StatusCode: resp.StatusCode, Message: string(bytes.TrimSpace(msg))}
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,102 @@
+package ci
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+func TestNewPlan(t *testing.T) {
+	same, old := "same", "old"
+	existing := map[string]*string{
+		"SAME":    &same,
+		"CHANGED": &old,
+		"UNKNOWN": nil,
+		"REMOVED": &old,
+	}
+	desired := map[string]string{
+		"SAME":    "same",
+		"CHANGED": "new",
+		"UNKNOWN": "value",
+		"NEW":     "value",
+	}
+
+	p := NewPlan(existing, desired)
+	want := &Plan{
+		Create: []string{"NEW"},
+		Update: []string{"CHANGED", "UNKNOWN"},
+		Delete: []string{"REMOVED"},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("wrong plan. got %+v want %+v", p, want)
+	}
+
+	if !NewPlan(map[string]*string{"SAME": &same}, map[string]string{"SAME": "same"}).Empty() {
+		t.Error("expected an empty plan")
+	}
+}
+
+func TestSealBox(t *testing.T) {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct, err := sealBox([]byte("secret"), pub)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	var ephemeral [32]byte
+	copy(ephemeral[:], ct[:32])
+	nonce, err := sealNonce(&ephemeral, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pt, ok := box.Open(nil, ct[32:], nonce, &ephemeral, priv)
+	if !ok || string(pt) != "secret" {
+		t.Errorf("could not open sealed box. got %q", pt)
+	}
+}
+
+func TestGitLabEscapesProject(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+		if r.Header.Get("Private-Token") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[{"key": "NAME", "value": "value"}]`))
+	}))
+	defer srv.Close()
+
+	g := NewGitLab(srv.URL+"/", "group/project", "token")
+
+	vars, err := g.List(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if len(vars) != 1 || *vars["NAME"] != "value" {
+		t.Errorf("wrong variables: %v", vars)
+	}
+
+	err = g.Delete(context.Background(), "NAME")
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	want := []string{
+		"GET /api/v4/projects/group%2Fproject/variables",
+		"DELETE /api/v4/projects/group%2Fproject/variables/NAME",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("wrong requests. got %v want %v", paths, want)
+	}
+}
@@ -0,0 +1,152 @@
+package ci
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dchest/blake2b"
+	"golang.org/x/crypto/nacl/box"
+)
+
+const gitHubURL = "https://api.github.com"
+
+// gitHubPageSize is the number of secrets listed per request.
+const gitHubPageSize = 100
+
+// GitHub is the Actions secret store of a GitHub repository. GitHub does not
+// expose the values of secrets once they are set.
+type GitHub struct {
+	client
+	repo string
+	key  *gitHubPublicKey
+}
+
+type gitHubPublicKey struct {
+	ID  string `json:"key_id"`
+	Key string `json:"key"`
+}
+
+// NewGitHub returns the secret store of the given owner/repo, authenticated
+// with token.
+func NewGitHub(repo, token string) *GitHub {
+	return &GitHub{
+		client: client{
+			http:    http.DefaultClient,
+			baseURL: gitHubURL,
+			header: http.Header{
+				"Authorization": {"token " + token},
+				"Accept":        {"application/vnd.github+json"},
+			},
+		},
+		repo: repo,
+	}
+}
+
+// List returns the names of every Actions secret in the repository.
+func (g *GitHub) List(ctx context.Context) (map[string]*string, error) {
+	secrets := make(map[string]*string)
+	for page := 1; ; page++ {
+		resp := struct {
+			Secrets []struct {
+				Name string `json:"name"`
+			} `json:"secrets"`
+		}{}
+
+		path := fmt.Sprintf("/repos/%s/actions/secrets?per_page=%d&page=%d",
+			g.repo, gitHubPageSize, page)
+		err := g.do(ctx, "GET", path, nil, &resp)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range resp.Secrets {
+			secrets[s.Name] = nil
+		}
+
+		if len(resp.Secrets) < gitHubPageSize {
+			return secrets, nil
+		}
+	}
+}
+
+// Create creates an Actions secret.
+func (g *GitHub) Create(ctx context.Context, name, value string) error {
+	return g.Update(ctx, name, value)
+}
+
+// Update sets the value of an Actions secret, encrypted with the
+// repository's public key.
+func (g *GitHub) Update(ctx context.Context, name, value string) error {
+	if g.key == nil {
+		key := &gitHubPublicKey{}
+		err := g.do(ctx, "GET", "/repos/"+g.repo+"/actions/secrets/public-key", nil, key)
+		if err != nil {
+			return err
+		}
+		g.key = key
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(g.key.Key)
+	if err != nil || len(raw) != 32 {
+		return errors.New("invalid repository public key")
+	}
+
+	var recipient [32]byte
+	copy(recipient[:], raw)
+
+	ct, err := sealBox([]byte(value), &recipient)
+	if err != nil {
+		return err
+	}
+
+	body := struct {
+		EncryptedValue string `json:"encrypted_value"`
+		KeyID          string `json:"key_id"`
+	}{
+		EncryptedValue: base64.StdEncoding.EncodeToString(ct),
+		KeyID:          g.key.ID,
+	}
+
+	return g.do(ctx, "PUT", "/repos/"+g.repo+"/actions/secrets/"+name, &body, nil)
+}
+
+// Delete deletes an Actions secret.
+func (g *GitHub) Delete(ctx context.Context, name string) error {
+	return g.do(ctx, "DELETE", "/repos/"+g.repo+"/actions/secrets/"+name, nil, nil)
+}
+
+// sealBox anonymously encrypts msg for recipient, compatible with libsodium's
+// crypto_box_seal, as required by GitHub.
+func sealBox(msg []byte, recipient *[32]byte) ([]byte, error) {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := sealNonce(pub, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	return box.Seal(pub[:], msg, nonce, recipient, priv), nil
+}
+
+// sealNonce derives the nonce of a sealed box from the ephemeral and
+// recipient public keys.
+func sealNonce(ephemeral, recipient *[32]byte) (*[24]byte, error) {
+	h, err := blake2b.New(&blake2b.Config{Size: 24})
+	if err != nil {
+		return nil, err
+	}
+
+	h.Write(ephemeral[:])
+	h.Write(recipient[:])
+
+	var nonce [24]byte
+	copy(nonce[:], h.Sum(nil))
+	return &nonce, nil
+}
@@ -0,0 +1,82 @@
+package ci
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitLabURL is the address of the hosted GitLab service.
+const GitLabURL = "https://gitlab.com"
+
+// gitLabPageSize is the number of variables listed per request.
+const gitLabPageSize = 100
+
+// GitLab is the CI/CD variable store of a GitLab project.
+type GitLab struct {
+	client
+	project string
+}
+
+// NewGitLab returns the variable store of the given group/project on the
+// GitLab instance at baseURL, authenticated with token.
+func NewGitLab(baseURL, project, token string) *GitLab {
+	return &GitLab{
+		client: client{
+			http:    http.DefaultClient,
+			baseURL: strings.TrimRight(baseURL, "/") + "/api/v4",
+			header: http.Header{
+				"Private-Token": {token},
+			},
+		},
+		project: url.QueryEscape(project),
+	}
+}
+
+type gitLabVariable struct {
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value"`
+}
+
+// List returns every CI/CD variable of the project, with its value.
+func (g *GitLab) List(ctx context.Context) (map[string]*string, error) {
+	vars := make(map[string]*string)
+	for page := 1; ; page++ {
+		resp := []gitLabVariable{}
+
+		path := fmt.Sprintf("/projects/%s/variables?per_page=%d&page=%d",
+			g.project, gitLabPageSize, page)
+		err := g.do(ctx, "GET", path, nil, &resp)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range resp {
+			value := v.Value
+			vars[v.Key] = &value
+		}
+
+		if len(resp) < gitLabPageSize {
+			return vars, nil
+		}
+	}
+}
+
+// Create creates a CI/CD variable.
+func (g *GitLab) Create(ctx context.Context, name, value string) error {
+	body := gitLabVariable{Key: name, Value: value}
+	return g.do(ctx, "POST", "/projects/"+g.project+"/variables", &body, nil)
+}
+
+// Update sets the value of a CI/CD variable.
+func (g *GitLab) Update(ctx context.Context, name, value string) error {
+	body := gitLabVariable{Value: value}
+	return g.do(ctx, "PUT", "/projects/"+g.project+"/variables/"+name, &body, nil)
+}
+
+// Delete deletes a CI/CD variable.
+func (g *GitLab) Delete(ctx context.Context, name string) error {
+	return g.do(ctx, "DELETE", "/projects/"+g.project+"/variables/"+name, nil, nil)
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+func init() {
+	account := cli.Command{
+		Name:     "account",
+		Usage:    "Manage your Torus account credentials",
+		Category: "ACCOUNT",
+		Subcommands: []cli.Command{
+			{
+				Name:   "password",
+				Usage:  "Change your password",
+				Action: chain(ensureDaemon, ensureSession, accountPassword),
+			},
+			{
+				Name:   "recovery-codes",
+				Usage:  "Generate one-time codes for resetting a forgotten password",
+				Flags:  []cli.Flag{stdAutoAcceptFlag},
+				Action: chain(ensureDaemon, ensureSession, accountRecoveryCodes),
+			},
+			{
+				Name:   "recover",
+				Usage:  "Reset a forgotten password using a recovery code",
+				Action: chain(ensureDaemon, accountRecover),
+			},
+		},
+	}
+	Cmds = append(Cmds, account)
+}
+
+func accountPassword(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return errs.NewErrorExitError("Error fetching user details", err)
+	}
+	if session.Type() == apitypes.MachineSession {
+		return errs.NewExitError("Machines cannot change passwords")
+	}
+
+	current, password, err := changePasswordPrompt()
+	if err != nil {
+		return err
+	}
+
+	if password == current {
+		return errs.NewExitError("New password must differ from your current password.")
+	}
+
+	_, err = client.Users.ChangePassword(c, current, password)
+	if apitypes.IsUnauthorizedError(err) {
+		return errs.NewExitError("Invalid password.")
+	}
+	if err != nil {
+		return errs.NewErrorExitError("Could not change password.", err)
+	}
+
+	fmt.Println("\nYour password has been changed. You remain logged in on all of your devices.")
+	return nil
+}
+
+func accountRecoveryCodes(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return errs.NewErrorExitError("Error fetching user details", err)
+	}
+	if session.Type() == apitypes.MachineSession {
+		return errs.NewExitError("Machines cannot have recovery codes")
+	}
+
+	preamble := "You are about to generate new recovery codes. Any recovery codes " +
+		"you already have will stop working."
+	abortErr := ConfirmDialogue(ctx, nil, &preamble, "", true)
+	if abortErr != nil {
+		return abortErr
+	}
+
+	codes, err := client.Users.GenerateRecoveryCodes(c)
+	if err != nil {
+		return errs.NewErrorExitError("Could not generate recovery codes.", err)
+	}
+
+	printRecoveryCodes(codes)
+	return nil
+}
+
+// printRecoveryCodes displays a user's new recovery codes, which can't be
+// shown again.
+func printRecoveryCodes(codes *apitypes.RecoveryCodes) {
+	fmt.Println("")
+	fmt.Println("Your recovery codes are:")
+	fmt.Println("")
+	for _, code := range codes.Codes {
+		fmt.Printf("    %s\n", code)
+	}
+	fmt.Println("")
+	fmt.Println("Store them somewhere safe. Each can be used once with `torus account recover`")
+	fmt.Println("to reset a forgotten password. Torus can't see them, and they won't be shown again.")
+}
+
+func accountRecover(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments", ctx)
+	}
+
+	email, err := EmailPrompt("")
+	if err != nil {
+		return err
+	}
+
+	code, err := RecoveryCodePrompt()
+	if err != nil {
+		return err
+	}
+
+	label := newPasswordLabel
+	password, err := PasswordPrompt(true, &label)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	err = client.Users.Recover(c, email, code, password)
+	if apitypes.IsUnauthorizedError(err) || apitypes.IsNotFoundError(err) {
+		return errs.NewExitError("Invalid email or recovery code.")
+	}
+	if err != nil {
+		return errs.NewErrorExitError("Could not reset password.", err)
+	}
+
+	fmt.Println("\nYour password has been reset. The recovery code you used can't be used again.")
+	return performLogin(c, client, email, password, true)
+}
@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/prefs"
+)
+
+var aliasNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// aliasParamRegex matches the parameters that can be used in an alias' command:
+// $1, $2, ... for a single argument, and $@ for all of them.
+var aliasParamRegex = regexp.MustCompile(`\$(@|[1-9][0-9]*)`)
+
+func init() {
+	alias := cli.Command{
+		Name:     "alias",
+		Usage:    "Manage shortcuts for frequently used commands",
+		Category: "SYSTEM",
+		Subcommands: []cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List your aliases",
+				Action: aliasList,
+			},
+			{
+				Name:      "add",
+				Usage:     "Add an alias, or replace an existing one",
+				ArgsUsage: "<name> <command> [arguments...]",
+				Action:    aliasAdd,
+			},
+			{
+				Name:      "remove",
+				Usage:     "Remove an alias",
+				ArgsUsage: "<name>",
+				Action:    aliasRemove,
+			},
+		},
+	}
+	Cmds = append(Cmds, alias)
+}
+
+// ExpandAlias replaces the command in args with the arguments it is aliased
+// to in the user's preferences. args is expected to include the program name,
+// as in os.Args. Aliases may not replace any of the given commands.
+func ExpandAlias(args []string, cmds []cli.Command) ([]string, error) {
+	if len(args) < 2 {
+		return args, nil
+	}
+
+	for _, c := range cmds {
+		if c.HasName(args[1]) {
+			return args, nil
+		}
+	}
+
+	preferences, err := prefs.NewPreferences()
+	if err != nil {
+		return args, nil
+	}
+
+	command, ok := preferences.Aliases[args[1]]
+	if !ok {
+		return args, nil
+	}
+
+	expanded, err := expandAlias(command, args[2:])
+	if err != nil {
+		return nil, errs.NewErrorExitError("Could not expand alias "+args[1]+".", err)
+	}
+
+	return append([]string{args[0]}, expanded...), nil
+}
+
+// expandAlias splits command into arguments, and substitutes args into its
+// parameters. If command has no parameters, args are appended to it instead.
+func expandAlias(command string, args []string) ([]string, error) {
+	words, err := splitArgs(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("alias has no command")
+	}
+
+	var missing int
+	substitute := func(param string) string {
+		if param == "$@" {
+			return strings.Join(args, " ")
+		}
+
+		n, _ := strconv.Atoi(param[1:])
+		if n > len(args) {
+			if n > missing {
+				missing = n
+			}
+			return ""
+		}
+		return args[n-1]
+	}
+
+	var out []string
+	params := false
+	for _, word := range words {
+		if !aliasParamRegex.MatchString(word) {
+			out = append(out, word)
+			continue
+		}
+
+		params = true
+		if word == "$@" {
+			out = append(out, args...)
+			continue
+		}
+		out = append(out, aliasParamRegex.ReplaceAllStringFunc(word, substitute))
+	}
+
+	if missing > 0 {
+		return nil, fmt.Errorf("alias requires %d arguments, %d given", missing, len(args))
+	}
+
+	if !params {
+		out = append(out, args...)
+	}
+
+	return out, nil
+}
+
+// splitArgs splits s into arguments on whitespace, in the same way as a
+// shell. Single and double quotes group words, and a backslash escapes the
+// character following it, except within single quotes.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var cur []rune
+	var quote rune
+	inWord := false
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur = append(cur, r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur = append(cur, r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				args = append(args, string(cur))
+				cur = cur[:0]
+				inWord = false
+			}
+		default:
+			cur = append(cur, r)
+			inWord = true
+		}
+	}
+
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf("unterminated quoted string in %s", s)
+	}
+	if inWord {
+		args = append(args, string(cur))
+	}
+
+	return args, nil
+}
+
+// joinArgs is the inverse of splitArgs, quoting any argument that would
+// otherwise be split or unescaped.
+func joinArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\") {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+func aliasList(ctx *cli.Context) error {
+	preferences, err := prefs.NewPreferences()
+	if err != nil {
+		return errs.NewErrorExitError("Failed to load prefs.", err)
+	}
+
+	if len(preferences.Aliases) == 0 {
+		fmt.Println("No aliases set. Use 'torus alias add' to create one.")
+		return nil
+	}
+
+	names := make([]string, 0, len(preferences.Aliases))
+	for name := range preferences.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, tableHeader("ALIAS", "COMMAND"))
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, preferences.Aliases[name])
+	}
+
+	w.Flush()
+	return nil
+}
+
+func aliasAdd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) < 2 {
+		return errs.NewUsageExitError("A name and command are required", ctx)
+	}
+
+	name := args[0]
+	if !aliasNameRegex.MatchString(name) {
+		return errs.NewExitError("Alias names must be lowercase letters, numbers and dashes, starting with a letter.")
+	}
+
+	for _, c := range Cmds {
+		if c.HasName(name) {
+			return errs.NewExitError("Alias " + name + " would replace the " + c.Name + " command.")
+		}
+	}
+
+	// A single argument is the command as it would be written in the
+	// torusrc, otherwise quote each argument so it survives expansion.
+	command := args[1]
+	if len(args) > 2 {
+		command = joinArgs(args[1:])
+	}
+
+	words, err := splitArgs(command)
+	if err != nil {
+		return errs.NewErrorExitError("Invalid command.", err)
+	}
+	if len(words) == 0 {
+		return errs.NewUsageExitError("A name and command are required", ctx)
+	}
+
+	preferences, err := prefs.LoadFile()
+	if err != nil {
+		return errs.NewErrorExitError("Failed to load prefs.", err)
+	}
+
+	if preferences.Aliases == nil {
+		preferences.Aliases = make(map[string]string)
+	}
+	preferences.Aliases[name] = command
+
+	err = preferences.Save()
+	if err != nil {
+		return errs.NewErrorExitError("Failed to save preferences.", err)
+	}
+
+	fmt.Printf("Alias %s added.\n", name)
+	return nil
+}
+
+func aliasRemove(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "Name is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	preferences, err := prefs.LoadFile()
+	if err != nil {
+		return errs.NewErrorExitError("Failed to load prefs.", err)
+	}
+
+	if _, ok := preferences.Aliases[args[0]]; !ok {
+		return errs.NewExitError("Alias " + args[0] + " not found.")
+	}
+	delete(preferences.Aliases, args[0])
+
+	err = preferences.Save()
+	if err != nil {
+		return errs.NewErrorExitError("Failed to save preferences.", err)
+	}
+
+	fmt.Printf("Alias %s removed.\n", args[0])
+	return nil
+}
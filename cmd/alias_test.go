@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tcs := []struct {
+		in   string
+		want []string
+	}{
+		{in: "view -e production  -s api", want: []string{"view", "-e", "production", "-s", "api"}},
+		{in: `set msg "hello world"`, want: []string{"set", "msg", "hello world"}},
+		{in: `set msg 'it''s' \"x\"`, want: []string{"set", "msg", "its", `"x"`}},
+		{in: `set empty ''`, want: []string{"set", "empty", ""}},
+	}
+
+	for _, tc := range tcs {
+		got, err := splitArgs(tc.in)
+		if err != nil {
+			t.Errorf("%s: %s", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q want %q", tc.in, got, tc.want)
+		}
+
+		round, err := splitArgs(joinArgs(got))
+		if err != nil || !reflect.DeepEqual(round, got) {
+			t.Errorf("%s: joinArgs did not round trip, got %q", tc.in, round)
+		}
+	}
+
+	for _, bad := range []string{`set msg "hello`, `set msg 'hello`, `set msg \`} {
+		if _, err := splitArgs(bad); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
+
+func TestExpandAlias(t *testing.T) {
+	tcs := []struct {
+		command string
+		args    []string
+		want    []string
+	}{
+		{command: "view -e production", args: []string{"-s", "api"},
+			want: []string{"view", "-e", "production", "-s", "api"}},
+		{command: "view -e $1 -s $2", args: []string{"staging", "www"},
+			want: []string{"view", "-e", "staging", "-s", "www"}},
+		{command: "ls /org/project/$1/**", args: []string{"dev-*"},
+			want: []string{"ls", "/org/project/dev-*/**"}},
+		{command: "run -e $1 -- $@", args: []string{"prod", "make"},
+			want: []string{"run", "-e", "prod", "--", "prod", "make"}},
+	}
+
+	for _, tc := range tcs {
+		got, err := expandAlias(tc.command, tc.args)
+		if err != nil {
+			t.Errorf("%s: %s", tc.command, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q want %q", tc.command, got, tc.want)
+		}
+	}
+
+	if _, err := expandAlias("view -e $2", []string{"prod"}); err == nil {
+		t.Error("expected an error for a missing argument")
+	}
+}
@@ -12,8 +12,10 @@ import (
 
 	"github.com/manifoldco/torus-cli/api"
 	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/policyeval"
 	"github.com/manifoldco/torus-cli/primitive"
 )
 
@@ -23,7 +25,21 @@ func init() {
 		Usage:     "Increase access given to a team or role by creating and attaching a new policy",
 		ArgsUsage: "<crudl> <path> <team|machine-role>",
 		Category:  "ACCESS CONTROL",
-		Action:    chain(ensureDaemon, ensureSession, allowCmd),
+		Flags: []cli.Flag{
+			newPlaceholder("hours", "HH:MM-HH:MM",
+				"Only allow access during these hours of the day", "", "", false),
+			newPlaceholder("days", "DAYS",
+				"Only allow access on these days of the week (e.g. mon-fri)", "", "", false),
+			newPlaceholder("time-zone", "ZONE",
+				"Time zone of --hours and --days (default: UTC)", "", "", false),
+			newPlaceholder("machine-team", "TEAM",
+				"Only allow access to machines in this team", "", "", false),
+			cli.DurationFlag{
+				Name:  "recent-auth",
+				Usage: "Only allow access to those who logged in within this long (e.g. 15m)",
+			},
+		},
+		Action: chain(ensureDaemon, ensureSession, allowCmd),
 	}
 
 	Cmds = append(Cmds, allow)
@@ -80,7 +96,10 @@ func doCrudl(ctx *cli.Context, effect primitive.PolicyEffect, extra primitive.Po
 		return err
 	}
 
-	stmtAction |= extra
+	conditions, err := policyConditionsFlags(ctx)
+	if err != nil {
+		return err
+	}
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -112,18 +131,44 @@ func doCrudl(ctx *cli.Context, effect primitive.PolicyEffect, extra primitive.Po
 		OrgID:      org.ID,
 	}
 	policy.Policy.Name = fmt.Sprintf("generated-%s-%d", effect.String(), time.Now().Unix())
-	policy.Policy.Statements = []primitive.PolicyStatement{{
-		Effect:   effect,
-		Action:   stmtAction,
-		Resource: pe.String() + "/" + name,
-	}}
+	resource := pe.String() + "/" + name
+	if conditions == nil {
+		policy.Policy.Statements = []primitive.PolicyStatement{{
+			Effect:   effect,
+			Action:   stmtAction | extra,
+			Resource: resource,
+		}}
+	} else {
+		// The extra actions are granted without conditions, so that
+		// conditions on writing don't also limit reading.
+		policy.Policy.Statements = []primitive.PolicyStatement{{
+			Effect:     effect,
+			Action:     stmtAction,
+			Resource:   resource,
+			Conditions: conditions,
+		}}
+		if extra != 0 {
+			policy.Policy.Statements = append(policy.Policy.Statements, primitive.PolicyStatement{
+				Effect:   effect,
+				Action:   extra,
+				Resource: resource,
+			})
+		}
+	}
 
-	res, err := client.Policies.Create(c, &policy)
+	var res *envelope.Policy
+	err = withMFA(c, func(c context.Context) error {
+		var err error
+		res, err = client.Policies.Create(c, &policy)
+		return err
+	})
 	if err != nil {
 		return errs.NewErrorExitError("Failed to create policy", err)
 	}
 
-	err = client.Policies.Attach(c, org.ID, res.ID, team.ID)
+	err = withMFA(c, func(c context.Context) error {
+		return client.Policies.Attach(c, org.ID, res.ID, team.ID)
+	})
 	if err != nil {
 		return errs.NewErrorExitError("Could not attach policy.", err)
 	}
@@ -136,12 +181,53 @@ func doCrudl(ctx *cli.Context, effect primitive.PolicyEffect, extra primitive.Po
 		fmt.Fprintf(w, "Effect:\t%s\n", s.Effect.String())
 		fmt.Fprintf(w, "Action(s):\t%s\n", s.Action.String())
 		fmt.Fprintf(w, "Resource:\t%s\n", s.Resource)
+		if s.Conditions != nil {
+			fmt.Fprintf(w, "Conditions:\t%s\n", s.Conditions.String())
+		}
 	}
 	w.Flush()
 
 	return nil
 }
 
+// policyConditionsFlags returns the conditions given by the flags to allow,
+// or nil if there are none.
+func policyConditionsFlags(ctx *cli.Context) (*primitive.PolicyConditions, error) {
+	conditions := &primitive.PolicyConditions{
+		Hours:       ctx.String("hours"),
+		TimeZone:    ctx.String("time-zone"),
+		MachineTeam: ctx.String("machine-team"),
+	}
+
+	if raw := ctx.String("days"); raw != "" {
+		days, err := policyeval.ParseDays(raw)
+		if err != nil {
+			return nil, errs.NewErrorExitError("Invalid --days.", err)
+		}
+		conditions.Days = days
+	}
+
+	if d := ctx.Duration("recent-auth"); d > 0 {
+		conditions.RecentAuth = d.String()
+	}
+
+	if conditions.Hours == "" && len(conditions.Days) == 0 &&
+		conditions.MachineTeam == "" && conditions.RecentAuth == "" {
+
+		if conditions.TimeZone != "" {
+			return nil, errs.NewUsageExitError("--time-zone requires --hours or --days", ctx)
+		}
+		return nil, nil
+	}
+
+	err := policyeval.ValidateConditions(conditions)
+	if err != nil {
+		return nil, errs.NewErrorExitError("Invalid conditions.", err)
+	}
+
+	return conditions, nil
+}
+
 func parseAction(raw string) (primitive.PolicyAction, error) {
 	var action primitive.PolicyAction
 	for _, c := range raw {
@@ -0,0 +1,376 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/chzyer/readline"
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/prefs"
+	"github.com/manifoldco/torus-cli/promptui"
+
+	"github.com/manifoldco/torus-cli/cmd/output"
+)
+
+func init() {
+	approvals := cli.Command{
+		Name:     "approvals",
+		Usage:    "Approve or reject the invites waiting for approval in an org, many at a time",
+		Category: "ORGANIZATIONS",
+		Flags: []cli.Flag{
+			orgFlag("org to approve invites for", true),
+			newPlaceholder("team, t", "TEAM", "Only show invites to TEAM", "", "", false),
+			newPlaceholder("older-than", "AGE",
+				"Only show invites waiting longer than AGE (e.g. 24h, 7d)", "", "", false),
+			newPlaceholder("newer-than", "AGE",
+				"Only show invites waiting less than AGE (e.g. 24h, 7d)", "", "", false),
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setUserEnv, checkRequiredFlags, approvalsCmd,
+		),
+	}
+
+	Cmds = append(Cmds, approvals)
+}
+
+// approval is an invite waiting for an org admin to approve it.
+type approval struct {
+	invite   envelope.OrgInvite
+	username string
+	teams    []string
+	waiting  time.Time
+}
+
+// approvalFilter limits the approvals shown to those for a team, or that have
+// been waiting within a range of times.
+type approvalFilter struct {
+	team   *identity.ID
+	before time.Time
+	after  time.Time
+}
+
+func (f *approvalFilter) match(a *approval) bool {
+	if !f.before.IsZero() && !a.waiting.Before(f.before) {
+		return false
+	}
+	if !f.after.IsZero() && a.waiting.Before(f.after) {
+		return false
+	}
+	if f.team == nil {
+		return true
+	}
+
+	for _, id := range a.invite.Body.PendingTeams {
+		if id == *f.team {
+			return true
+		}
+	}
+	return false
+}
+
+// filterApprovals returns the approvals matching f, waiting longest first.
+func filterApprovals(approvals []approval, f *approvalFilter) []approval {
+	matched := []approval{}
+	for _, a := range approvals {
+		if f.match(&a) {
+			matched = append(matched, a)
+		}
+	}
+
+	sort.Stable(approvalsByAge(matched))
+	return matched
+}
+
+type approvalsByAge []approval
+
+func (a approvalsByAge) Len() int           { return len(a) }
+func (a approvalsByAge) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a approvalsByAge) Less(i, j int) bool { return a[i].waiting.Before(a[j].waiting) }
+
+func approvalsCmd(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	filter, err := approvalsFilter(c, ctx, client, org.ID)
+	if err != nil {
+		return err
+	}
+
+	all, err := listApprovals(c, client, org.ID)
+	if err != nil {
+		return errs.NewErrorExitError("Failed to retrieve invites, please try again.", err)
+	}
+	approvals := filterApprovals(all, filter)
+
+	if output.IsJSON(ctx) {
+		records := make([]output.Invite, len(approvals))
+		for i, a := range approvals {
+			records[i] = output.Invite{
+				ID:       a.invite.ID,
+				Email:    a.invite.Body.Email,
+				Username: a.username,
+				State:    a.invite.Body.State,
+				Created:  a.invite.Body.Created,
+			}
+		}
+		return output.Write(os.Stdout, records)
+	}
+
+	if len(approvals) == 0 {
+		fmt.Println("No invites are waiting for approval.")
+		return nil
+	}
+
+	if promptsDisabled() || !readline.IsTerminal(int(os.Stdin.Fd())) ||
+		!readline.IsTerminal(int(os.Stdout.Fd())) {
+		printApprovals(approvals)
+		return nil
+	}
+
+	canReject, err := client.Supports(c, api.FeatureInviteReject)
+	if err != nil {
+		return err
+	}
+
+	return approvalsQueue(c, client, approvals, canReject)
+}
+
+// approvalsFilter returns the filter given by the --team, --older-than and
+// --newer-than flags.
+func approvalsFilter(c context.Context, ctx *cli.Context, client *api.Client,
+	orgID *identity.ID) (*approvalFilter, error) {
+
+	f := &approvalFilter{}
+	now := time.Now()
+
+	if age := ctx.String("older-than"); age != "" {
+		t, err := parseRelativeTime(age, now, -1)
+		if err != nil {
+			return nil, errs.NewUsageExitError(err.Error(), ctx)
+		}
+		f.before = t
+	}
+
+	if age := ctx.String("newer-than"); age != "" {
+		t, err := parseRelativeTime(age, now, -1)
+		if err != nil {
+			return nil, errs.NewUsageExitError(err.Error(), ctx)
+		}
+		f.after = t
+	}
+
+	if name := ctx.String("team"); name != "" {
+		teams, err := client.Teams.GetByName(c, orgID, name)
+		if err != nil {
+			return nil, errs.NewErrorExitError("Could not look up team.", err)
+		}
+		if len(teams) != 1 {
+			return nil, errs.NewExitError("Team not found.")
+		}
+		f.team = teams[0].ID
+	}
+
+	return f, nil
+}
+
+// listApprovals returns every invite in the org that has been accepted, and
+// is waiting for approval, along with the invitee's username and the names of
+// the teams they will join.
+func listApprovals(c context.Context, client *api.Client, orgID *identity.ID) ([]approval, error) {
+	invites, err := client.Invites.List(c, orgID, []string{"accepted"})
+	if err != nil {
+		return nil, err
+	}
+	if len(invites) == 0 {
+		return nil, nil
+	}
+
+	teams, err := client.Teams.GetByOrg(c, orgID)
+	if err != nil {
+		return nil, err
+	}
+	teamNames := make(map[identity.ID]string, len(teams))
+	for _, t := range teams {
+		teamNames[*t.ID] = t.Body.Name
+	}
+
+	var inviteeIDs []identity.ID
+	for _, invite := range invites {
+		if invite.Body.InviteeID != nil {
+			inviteeIDs = append(inviteeIDs, *invite.Body.InviteeID)
+		}
+	}
+
+	usernames := make(map[identity.ID]string)
+	if len(inviteeIDs) > 0 {
+		profiles, err := client.Profiles.ListByID(c, inviteeIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range *profiles {
+			usernames[*p.ID] = p.Body.Username
+		}
+	}
+
+	approvals := make([]approval, len(invites))
+	for i, invite := range invites {
+		a := approval{invite: invite}
+		if invite.Body.InviteeID != nil {
+			a.username = usernames[*invite.Body.InviteeID]
+		}
+		for _, id := range invite.Body.PendingTeams {
+			a.teams = append(a.teams, teamNames[id])
+		}
+		switch {
+		case invite.Body.Accepted != nil:
+			a.waiting = *invite.Body.Accepted
+		case invite.Body.Created != nil:
+			a.waiting = *invite.Body.Created
+		}
+		approvals[i] = a
+	}
+
+	return approvals, nil
+}
+
+// describe returns a one line summary of an approval, for listing.
+func (a *approval) describe(now time.Time) string {
+	who := a.invite.Body.Email
+	if a.username != "" {
+		who += " (" + a.username + ")"
+	}
+
+	teams := "no teams"
+	if len(a.teams) > 0 {
+		teams = strings.Join(a.teams, ", ")
+	}
+
+	return fmt.Sprintf("%s  %s  waiting %s", who, teams, formatAge(now.Sub(a.waiting)))
+}
+
+func printApprovals(approvals []approval) {
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, tableHeader("EMAIL", "USERNAME", "TEAMS", "WAITING"))
+	for _, a := range approvals {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.invite.Body.Email, a.username,
+			strings.Join(a.teams, ", "), formatAge(now.Sub(a.waiting)))
+	}
+	w.Flush()
+}
+
+// approvalsQueue shows the approvals in an interactive list, where they can be
+// marked and approved or rejected together, until the list is empty or the
+// user quits.
+func approvalsQueue(c context.Context, client *api.Client, approvals []approval, canReject bool) error {
+	preferences, err := prefs.NewPreferences()
+	if err != nil {
+		return err
+	}
+
+	actions := []promptui.QueueAction{{Key: 'a', Label: "approve"}}
+	if canReject {
+		actions = append(actions, promptui.QueueAction{Key: 'r', Label: "reject"})
+	}
+
+	var failed int
+	for len(approvals) > 0 {
+		now := time.Now()
+		items := make([]string, len(approvals))
+		for i, a := range approvals {
+			items[i] = a.describe(now)
+		}
+
+		q := promptui.Queue{
+			Label:     fmt.Sprintf("%d invites waiting for approval", len(approvals)),
+			Items:     items,
+			Actions:   actions,
+			IsVimMode: preferences.Core.Vim,
+		}
+
+		action, chosen, err := q.Run()
+		if err != nil {
+			return err
+		}
+		if action == nil {
+			break
+		}
+
+		done := runApprovals(c, client, approvals, chosen, action.Key == 'r')
+		failed += len(chosen) - len(done)
+
+		remaining := approvals[:0]
+		for i, a := range approvals {
+			if !done[i] {
+				remaining = append(remaining, a)
+			}
+		}
+		approvals = remaining
+	}
+
+	if failed > 0 {
+		return errs.NewExitError(fmt.Sprintf("%d invites could not be processed.", failed))
+	}
+	return nil
+}
+
+// runApprovals approves or rejects the chosen approvals one after another,
+// reporting each as it finishes. It returns the indices of those that
+// succeeded.
+func runApprovals(c context.Context, client *api.Client, approvals []approval,
+	chosen []int, reject bool) map[int]bool {
+
+	verb, done := "Approved", "approve"
+	if reject {
+		verb, done = "Rejected", "reject"
+	}
+
+	succeeded := make(map[int]bool, len(chosen))
+	for n, i := range chosen {
+		invite := approvals[i].invite
+
+		var err error
+		if reject {
+			err = client.Invites.Reject(c, *invite.ID)
+		} else {
+			err = client.Invites.Approve(c, *invite.ID, nil)
+		}
+
+		prefix := fmt.Sprintf("[%d/%d]", n+1, len(chosen))
+		if err != nil {
+			fmt.Println(promptui.FailedValue(prefix+" Could not "+done, invite.Body.Email+": "+err.Error()))
+			continue
+		}
+
+		fmt.Println(promptui.SuccessfulValue(prefix+" "+verb, invite.Body.Email))
+		succeeded[i] = true
+	}
+
+	return succeeded
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestFilterApprovals(t *testing.T) {
+	team, err := identity.NewMutable(&primitive.Team{Name: "ops"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	approvalFor := func(email string, age time.Duration, teams ...identity.ID) approval {
+		return approval{
+			invite: envelope.OrgInvite{
+				Body: &primitive.OrgInvite{Email: email, PendingTeams: teams},
+			},
+			waiting: now.Add(-age),
+		}
+	}
+
+	approvals := []approval{
+		approvalFor("new@example.com", time.Hour),
+		approvalFor("old@example.com", 10*24*time.Hour, team),
+		approvalFor("mid@example.com", 3*24*time.Hour, team),
+	}
+
+	tcs := []struct {
+		name   string
+		filter approvalFilter
+		emails []string
+	}{
+		{"all, oldest first", approvalFilter{},
+			[]string{"old@example.com", "mid@example.com", "new@example.com"}},
+		{"by team", approvalFilter{team: &team},
+			[]string{"old@example.com", "mid@example.com"}},
+		{"older than", approvalFilter{before: now.Add(-2 * 24 * time.Hour)},
+			[]string{"old@example.com", "mid@example.com"}},
+		{"newer than", approvalFilter{after: now.Add(-5 * 24 * time.Hour)},
+			[]string{"mid@example.com", "new@example.com"}},
+		{"by team and age", approvalFilter{team: &team, after: now.Add(-5 * 24 * time.Hour)},
+			[]string{"mid@example.com"}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			matched := filterApprovals(approvals, &tc.filter)
+			if len(matched) != len(tc.emails) {
+				t.Fatalf("expected %d approvals, got %d", len(tc.emails), len(matched))
+			}
+			for i, a := range matched {
+				if a.invite.Body.Email != tc.emails[i] {
+					t.Errorf("%d: expected %s, got %s", i, tc.emails[i], a.invite.Body.Email)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+func init() {
+	audit := cli.Command{
+		Name:     "audit",
+		Usage:    "Show who accessed an organization's secrets, and check their integrity",
+		Category: "ORGANIZATIONS",
+		Flags: []cli.Flag{
+			orgFlag("org to show audit events for", true),
+			newPlaceholder("since", "TIME", "Show events since this long ago (e.g. 24h, 7d), or this date",
+				"24h", "", false),
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			checkRequiredFlags, auditEventsCmd,
+		),
+		Subcommands: []cli.Command{
+			{
+				Name:  "keyrings",
+				Usage: "Verify every keyring, keyring member, and secret you can see in an org",
+				Flags: []cli.Flag{
+					orgFlag("org to audit keyrings for", true),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, auditKeyringsCmd,
+				),
+			},
+		},
+	}
+
+	Cmds = append(Cmds, audit)
+}
+
+const auditEventsFailed = "Could not retrieve audit events, please try again."
+
+func auditEventsCmd(ctx *cli.Context) error {
+	since, err := parseRelativeTime(ctx.String("since"), time.Now(), -1)
+	if err != nil {
+		return errs.NewUsageExitError("Invalid --since: "+ctx.String("since"), ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	events, err := client.Audit.List(c, org.ID, since)
+	if err != nil {
+		return errs.NewErrorExitError(auditEventsFailed, err)
+	}
+
+	if len(events) == 0 {
+		fmt.Printf("No events in %s since %s.\n", org.Body.Name, since.Format(time.RFC3339))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, tableHeader("TIME", "ACTOR", "ACTION", "SUBJECT"))
+	fmt.Fprintln(w, " \t \t \t ")
+	for _, e := range events {
+		actor := e.Actor
+		if actor == "" && e.ActorID != nil {
+			actor = e.ActorID.String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Time.Local().Format(time.RFC3339),
+			actor, e.Action, e.Subject)
+	}
+	w.Flush()
+
+	return nil
+}
+
+const auditKeyringsFailed = "Could not audit keyrings, please try again."
+
+func auditKeyringsCmd(ctx *cli.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	audit, err := client.Keyrings.Audit(c, org.ID, &progress)
+	if err != nil {
+		return errs.NewErrorExitError(auditKeyringsFailed, err)
+	}
+
+	fmt.Printf("\nChecked %d keyrings, %d keyring members, and %d secrets in %s.\n",
+		audit.Keyrings, audit.Members, audit.Credentials, org.Body.Name)
+
+	if len(audit.Issues) == 0 {
+		fmt.Println("No problems found.")
+		return nil
+	}
+
+	fmt.Println("")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, tableHeader("PATH", "ID", "PROBLEM"))
+	fmt.Fprintln(w, " \t \t ")
+	for _, issue := range audit.Issues {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", issue.PathExp, issue.Subject, issue.Problem)
+	}
+	w.Flush()
+
+	msg := fmt.Sprintf("\n%d problems found. Contact an org administrator "+
+		"before sharing secrets in the affected paths.", len(audit.Issues))
+	return errs.NewExitError(msg)
+}
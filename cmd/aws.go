@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/aws"
+	"github.com/manifoldco/torus-cli/ci"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+func init() {
+	awsCmd := cli.Command{
+		Name:     "aws",
+		Usage:    "Sync secrets into AWS",
+		Category: "SECRETS",
+		Subcommands: []cli.Command{
+			{
+				Name:  "sync",
+				Usage: "Sync secrets into AWS Secrets Manager or SSM Parameter Store",
+				Flags: append(exportContextFlags,
+					newPlaceholder("service-type", "TYPE",
+						"AWS service to write to (secretsmanager, ssm)",
+						"secretsmanager", "", false),
+					newPlaceholder("prefix", "PREFIX",
+						"Write each secret to PREFIX/NAME", "", "", true),
+					newPlaceholder("kms-key", "KEY",
+						"KMS key to encrypt secrets with (default: the account's default key)",
+						"", "", false),
+					newPlaceholder("profile", "PROFILE",
+						"AWS profile to use (default: $AWS_PROFILE, or default)", "", "", false),
+					newPlaceholder("region", "REGION",
+						"AWS region to use (default: from the environment or profile)", "", "", false),
+					cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show the changes that would be made, without making them",
+					},
+					stdAutoAcceptFlag,
+				),
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					setUserEnv, checkRequiredFlags, awsSyncCmd,
+				),
+			},
+		},
+	}
+
+	Cmds = append(Cmds, awsCmd)
+}
+
+// awsSecretName matches the names both Secrets Manager and SSM Parameter
+// Store allow for the last part of a secret's name.
+var awsSecretName = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+func awsSyncCmd(ctx *cli.Context) error {
+	prefix := ctx.String("prefix")
+
+	var target string
+	switch ctx.String("service-type") {
+	case "secretsmanager":
+		target = "Secrets Manager secrets under " + prefix
+	case "ssm":
+		if !strings.HasPrefix(prefix, "/") {
+			return errs.NewUsageExitError("--prefix must begin with a / for ssm", ctx)
+		}
+		target = "SSM parameters under " + prefix
+	default:
+		return errs.NewUsageExitError("Unknown --service-type "+ctx.String("service-type"), ctx)
+	}
+
+	secrets, _, err := getSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	desired, err := awsSecretValues(secrets)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := aws.LoadConfig(ctx.String("profile"))
+	if err != nil {
+		return errs.NewErrorExitError("Could not load AWS configuration.", err)
+	}
+	if region := ctx.String("region"); region != "" {
+		cfg.Region = region
+	}
+
+	var store ci.Store
+	if ctx.String("service-type") == "ssm" {
+		store = aws.NewParameterStore(cfg, prefix, ctx.String("kms-key"))
+	} else {
+		store = aws.NewSecretsManager(cfg, prefix, ctx.String("kms-key"))
+	}
+
+	c := context.Background()
+	existing, err := store.List(c)
+	if err != nil {
+		return errs.NewErrorExitError("Could not read "+target+".", err)
+	}
+
+	plan := ci.NewPlan(existing, desired)
+	if plan.Empty() {
+		fmt.Printf("%s are up to date.\n", target)
+		return nil
+	}
+
+	fmt.Println("")
+	printCIChanges("create", plan.Create)
+	printCIChanges("update", plan.Update)
+	printCIChanges("delete", plan.Delete)
+	fmt.Println("")
+
+	if ctx.Bool("dry-run") {
+		fmt.Printf("Dry run, %s were not changed.\n", target)
+		return nil
+	}
+
+	if len(plan.Delete) > 0 {
+		preamble := fmt.Sprintf("%d secrets under %s are not set in Torus, and will be deleted.",
+			len(plan.Delete), prefix)
+		abortErr := ConfirmDialogue(ctx, nil, &preamble, "", true)
+		if abortErr != nil {
+			return abortErr
+		}
+	}
+
+	err = plan.Apply(c, store, desired)
+	if err != nil {
+		return errs.NewErrorExitError("Could not sync "+target+".", err)
+	}
+
+	fmt.Printf("%s are now in sync with Torus.\n", target)
+	return nil
+}
+
+// awsSecretValues returns the AWS names and values of secrets. Names are
+// upper cased, as they are for env files.
+func awsSecretValues(secrets []apitypes.CredentialEnvelope) (map[string]string, error) {
+	exported, err := exportSecrets(secrets)
+	if err != nil {
+		return nil, errs.NewErrorExitError("Could not export secrets.", err)
+	}
+
+	values := make(map[string]string, len(exported))
+	for _, s := range exported {
+		name := strings.ToUpper(s.name)
+		if !awsSecretName.MatchString(name) {
+			return nil, errs.NewExitError("Secret " + s.name + " cannot be used as an AWS secret name.")
+		}
+		values[name] = fmt.Sprint(s.value)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/gatekeeper"
+)
+
+func init() {
+	bootstrap := cli.Command{
+		Name:     "bootstrap",
+		Usage:    "Obtain machine credentials for this cloud instance from a gatekeeper",
+		Category: "ORGANIZATIONS",
+		Flags: []cli.Flag{
+			newPlaceholder("provider", "PROVIDER",
+				"Cloud provider to prove this instance's identity with (aws or gcp)",
+				"", "TORUS_BOOTSTRAP_PROVIDER", true),
+			newPlaceholder("url", "URL", "URL of the gatekeeper", "",
+				"TORUS_GATEKEEPER_URL", true),
+			roleFlag("Machine role to join", true),
+		},
+		Action: chain(checkRequiredFlags, bootstrapCmd),
+	}
+
+	Cmds = append(Cmds, bootstrap)
+}
+
+func bootstrapCmd(ctx *cli.Context) error {
+	provider := apitypes.BootstrapProvider(ctx.String("provider"))
+	switch provider {
+	case apitypes.AWSBootstrapProvider, apitypes.GCPBootstrapProvider:
+	default:
+		return errs.NewUsageExitError("Unknown provider: "+string(provider), ctx)
+	}
+
+	resp, err := gatekeeper.Bootstrap(context.Background(), ctx.String("url"),
+		provider, ctx.String("role"))
+	if err != nil {
+		return errs.NewErrorExitError("Could not bootstrap machine.", err)
+	}
+
+	// Written in env file format, so the output can be redirected and loaded
+	// by the daemon's service manager.
+	fmt.Printf("TORUS_TOKEN_ID=%s\n", resp.TokenID)
+	fmt.Printf("TORUS_TOKEN_SECRET=%s\n", resp.Secret)
+	return nil
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/bundle"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+func init() {
+	bundles := cli.Command{
+		Name:     "bundle",
+		Usage:    "Carry secrets to hosts that can't reach Torus in encrypted, signed bundles",
+		Category: "SECRETS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "create",
+				Usage:     "Write the secrets for a service and environment to a bundle",
+				ArgsUsage: "[name...]",
+				Flags: append(exportContextFlags,
+					newPlaceholder("out", "FILE", "Write the bundle to FILE", "", "", true),
+				),
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					setUserEnv, checkRequiredFlags, bundleCreateCmd,
+				),
+			},
+			{
+				Name:      "open",
+				Usage:     "Decrypt a bundle, writing out its secrets",
+				ArgsUsage: "<file>",
+				Flags: []cli.Flag{
+					formatFlag("env", "Format used to write secrets (env, json, yaml, toml)"),
+					newPlaceholder("signer", "KEY", "Key the bundle must be signed by", "", "TORUS_BUNDLE_SIGNER", true),
+				},
+				Action: chain(checkRequiredFlags, bundleOpenCmd),
+			},
+		},
+	}
+
+	Cmds = append(Cmds, bundles)
+}
+
+// bundleKeyFile is the name of the file, in the Torus root, holding the key
+// bundles are signed with.
+const bundleKeyFile = "bundle.key"
+
+func bundleCreateCmd(ctx *cli.Context) error {
+	secrets, path, err := getSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]bool)
+	for _, name := range ctx.Args() {
+		names[name] = true
+	}
+
+	contents := &bundle.Contents{Created: time.Now().UTC()}
+	for _, secret := range secrets {
+		body := *secret.Body
+		if len(names) > 0 && !names[body.GetName()] {
+			continue
+		}
+		delete(names, body.GetName())
+
+		cv := body.GetValue()
+		if cv.IsBinary() {
+			return errs.NewExitError("Secret " + body.GetName() + " holds binary data, and can't be bundled.")
+		}
+
+		value, err := cv.Raw()
+		if err != nil {
+			return errs.NewErrorExitError("Could not read secrets.", err)
+		}
+
+		contents.Secrets = append(contents.Secrets, bundle.Secret{
+			Path:  body.GetPathExp().String(),
+			Name:  body.GetName(),
+			Value: value,
+		})
+	}
+
+	if len(names) > 0 {
+		var missing []string
+		for name := range names {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return errs.NewExitError("Secrets not set for " + path + ": " + strings.Join(missing, ", "))
+	}
+	if len(contents.Secrets) == 0 {
+		return errs.NewExitError("No secrets are set for " + path + ".")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	session, err := client.Session.Who(context.Background())
+	if err != nil {
+		return errs.NewErrorExitError("Could not retrieve session.", err)
+	}
+	contents.Creator = session.Username()
+
+	key, err := bundle.LoadKey(filepath.Join(cfg.TorusRoot, bundleKeyFile))
+	if err != nil {
+		return errs.NewErrorExitError("Could not load bundle signing key.", err)
+	}
+
+	label := bundlePassphraseLabel
+	passphrase, err := PasswordPrompt(true, &label)
+	if err != nil {
+		return err
+	}
+
+	out := ctx.String("out")
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errs.NewErrorExitError("Could not create bundle file.", err)
+	}
+	defer f.Close()
+
+	err = bundle.Create(f, contents, []byte(passphrase), key)
+	if err != nil {
+		return errs.NewErrorExitError("Could not create bundle.", err)
+	}
+
+	fmt.Printf("Bundled %d secrets from %s into %s.\n", len(contents.Secrets), path, out)
+	fmt.Printf("\nThe bundle is signed with the key %s.\n", bundle.KeyID(key.Public().(ed25519.PublicKey)))
+	fmt.Println("It can only be opened with --signer set to this key.")
+	return nil
+}
+
+func bundleOpenCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) < 1 {
+		return errs.NewUsageExitError("Missing bundle file", ctx)
+	}
+	if len(args) > 1 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	format := ctx.String("format")
+	writer, ok := exportWriters[format]
+	if !ok {
+		return errs.NewUsageExitError("Unknown format: "+format, ctx)
+	}
+
+	signer, err := bundle.ParseKeyID(ctx.String("signer"))
+	if err != nil {
+		return errs.NewUsageExitError("Invalid --signer key", ctx)
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return errs.NewErrorExitError("Could not open bundle file.", err)
+	}
+	defer f.Close()
+
+	label := bundlePassphraseLabel
+	passphrase, err := PasswordPrompt(false, &label)
+	if err != nil {
+		return err
+	}
+
+	contents, err := bundle.Open(f, []byte(passphrase), signer)
+	if sErr, ok := err.(*bundle.SignerError); ok {
+		return errs.NewExitError("The bundle was signed by " + bundle.KeyID(sErr.Signer) +
+			", not " + bundle.KeyID(signer) + ".")
+	}
+	if err != nil {
+		return errs.NewErrorExitError("Could not open bundle.", err)
+	}
+
+	exported := make([]exportedSecret, len(contents.Secrets))
+	for i, secret := range contents.Secrets {
+		exported[i] = exportedSecret{name: secret.Name, value: secret.Value}
+	}
+	sort.Sort(exportedSecrets(exported))
+
+	fmt.Fprintf(os.Stderr, "Bundle of %d secrets created by %s on %s, signed by %s.\n",
+		len(exported), contents.Creator, contents.Created.Local().Format(time.RFC1123), bundle.KeyID(signer))
+
+	return writer(os.Stdout, exported)
+}
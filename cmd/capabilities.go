@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+)
+
+const (
+	capabilitiesFile    = "capabilities.json"
+	capabilitiesTTL     = time.Hour
+	capabilitiesTimeout = time.Second
+)
+
+// featureCommands maps commands, by their full name, to the registry feature
+// they need. They are hidden when the registry does not support it.
+var featureCommands = map[string]string{
+	"sessions":               api.FeatureSessions,
+	"invites resend":         api.FeatureInviteResend,
+	"envs define":            api.FeatureOrgSettings,
+	"envs undefine":          api.FeatureOrgSettings,
+	"share":                  api.FeatureShares,
+	"redeem":                 api.FeatureShares,
+	"freeze":                 api.FeatureFreeze,
+	"unfreeze":               api.FeatureFreeze,
+	"profile discoverable":   api.FeatureDirectory,
+	"events":                 api.FeatureEvents,
+	"account recovery-codes": api.FeatureRecovery,
+	"account recover":        api.FeatureRecovery,
+	"mfa":                    api.FeatureMFA,
+	"projects archive":       api.FeatureArchive,
+	"projects restore":       api.FeatureArchive,
+	"orgs destroy":           api.FeatureOrgDelete,
+}
+
+// cachedCapabilities is the registry's capabilities, as saved in the torus
+// root so they can be checked without a request every time the CLI starts.
+type cachedCapabilities struct {
+	RegistryURI string    `json:"registry_uri"`
+	Fetched     time.Time `json:"fetched"`
+	Features    []string  `json:"features"`
+}
+
+// HideUnsupported hides the commands that need features the registry does not
+// support. The registry's capabilities are read from a cache, refreshed
+// through the daemon if it is running. Nothing is hidden if they aren't known.
+func HideUnsupported(cmds []cli.Command) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return
+	}
+
+	caps := registryCapabilities(cfg)
+	if caps != nil {
+		hideUnsupported(cmds, "", caps)
+	}
+}
+
+func hideUnsupported(cmds []cli.Command, parent string, caps *apitypes.Capabilities) {
+	for i := range cmds {
+		name := strings.TrimSpace(parent + " " + cmds[i].Name)
+		if feature, ok := featureCommands[name]; ok && !caps.Supports(feature) {
+			cmds[i].Hidden = true
+		}
+
+		hideUnsupported(cmds[i].Subcommands, name, caps)
+	}
+}
+
+// registryCapabilities returns the cached capabilities of the configured
+// registry, fetching them again once they are stale.
+func registryCapabilities(cfg *config.Config) *apitypes.Capabilities {
+	path := filepath.Join(cfg.TorusRoot, capabilitiesFile)
+	registry := cfg.RegistryURI.String()
+
+	var cached *cachedCapabilities
+	if raw, err := ioutil.ReadFile(path); err == nil {
+		c := &cachedCapabilities{}
+		if json.Unmarshal(raw, c) == nil && c.RegistryURI == registry {
+			cached = c
+		}
+	}
+
+	if cached == nil || time.Since(cached.Fetched) > capabilitiesTTL {
+		if fresh := fetchCapabilities(cfg); fresh != nil {
+			cached = &cachedCapabilities{
+				RegistryURI: registry,
+				Fetched:     time.Now(),
+				Features:    fresh.Features,
+			}
+
+			if raw, err := json.Marshal(cached); err == nil {
+				ioutil.WriteFile(path, raw, 0600)
+			}
+		}
+	}
+
+	if cached == nil {
+		return nil
+	}
+	return &apitypes.Capabilities{Features: cached.Features}
+}
+
+// fetchCapabilities asks the registry for its capabilities through the
+// daemon, if it is already running.
+func fetchCapabilities(cfg *config.Config) *apitypes.Capabilities {
+	proc, err := findDaemon(cfg)
+	if err != nil || proc == nil {
+		return nil
+	}
+
+	c, cancel := context.WithTimeout(context.Background(), capabilitiesTimeout)
+	defer cancel()
+
+	caps, err := api.NewClient(cfg).Capabilities(c)
+	if err != nil {
+		return nil
+	}
+	return caps
+}
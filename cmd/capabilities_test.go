@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+func TestHideUnsupported(t *testing.T) {
+	cmds := []cli.Command{
+		{Name: "sessions"},
+		{
+			Name: "invites",
+			Subcommands: []cli.Command{
+				{Name: "send"},
+				{Name: "resend"},
+			},
+		},
+		{Name: "resend"},
+	}
+
+	caps := &apitypes.Capabilities{Features: []string{api.FeatureSessions}}
+	hideUnsupported(cmds, "", caps)
+
+	hidden := map[string]bool{
+		"sessions":       cmds[0].Hidden,
+		"invites send":   cmds[1].Subcommands[0].Hidden,
+		"invites resend": cmds[1].Subcommands[1].Hidden,
+		"resend":         cmds[2].Hidden,
+	}
+	want := map[string]bool{
+		"sessions":       false,
+		"invites send":   false,
+		"invites resend": true,
+		"resend":         false,
+	}
+
+	for name, h := range hidden {
+		if h != want[name] {
+			t.Errorf("%s: hidden = %t, want %t", name, h, want[name])
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/ci"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+func init() {
+	ciCmd := cli.Command{
+		Name:     "ci",
+		Usage:    "Sync secrets into hosted CI providers",
+		Category: "SECRETS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Sync secrets into GitHub Actions secrets or GitLab CI/CD variables",
+				ArgsUsage: "[name...]",
+				Flags: append(exportContextFlags,
+					newPlaceholder("provider", "PROVIDER", "CI provider to sync into (github, gitlab)",
+						"", "", true),
+					newPlaceholder("repo", "REPO",
+						"Sync secrets into this GitHub OWNER/REPO or GitLab GROUP/PROJECT.",
+						"", "", true),
+					newPlaceholder("gitlab-url", "URL", "Address of the GitLab instance.",
+						ci.GitLabURL, "GITLAB_URL", false),
+					stdAutoAcceptFlag,
+				),
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					setUserEnv, checkRequiredFlags, ciExportCmd,
+				),
+			},
+		},
+	}
+
+	Cmds = append(Cmds, ciCmd)
+}
+
+// ciExportCmd syncs secrets into the provider named by --provider, the same
+// way as the export subcommand for that provider.
+func ciExportCmd(ctx *cli.Context) error {
+	switch ctx.String("provider") {
+	case "github":
+		return exportGitHubCmd(ctx)
+	case "gitlab":
+		return exportGitLabCmd(ctx)
+	default:
+		return errs.NewUsageExitError("Unknown provider "+ctx.String("provider")+", use github or gitlab", ctx)
+	}
+}
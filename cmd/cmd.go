@@ -3,11 +3,19 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/urfave/cli"
 
 	"github.com/manifoldco/torus-cli/api"
 	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/i18n"
 	"github.com/manifoldco/torus-cli/ui"
 )
 
@@ -20,6 +28,100 @@ var progress api.ProgressFunc = func(evt *api.Event, err error) {
 	}
 }
 
+// progressBar renders events as a progress bar, with the estimated time left.
+// ui.ProgressDone must be called once the request completes.
+var progressBar api.ProgressFunc = func(evt *api.Event, err error) {
+	if evt != nil {
+		ui.ProgressBar(evt.Message, evt.Completed, evt.Total, evt.ETA())
+	}
+}
+
+// progressEvent is the JSON representation of a progress event.
+type progressEvent struct {
+	Org       string `json:"org,omitempty"`
+	Step      string `json:"step,omitempty"`
+	Message   string `json:"message"`
+	Percent   int    `json:"percent"`
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+
+	// Elapsed and ETA are in milliseconds. ETA is -1 when it is unknown.
+	Elapsed int64 `json:"elapsed"`
+	ETA     int64 `json:"eta"`
+}
+
+// jsonProgress returns a function writing each update to stdout as a line of
+// JSON, regardless of the progress preference. org is included in the events,
+// when given.
+func jsonProgress(org string) func(api.Progress) {
+	enc := json.NewEncoder(os.Stdout)
+	return func(p api.Progress) {
+		eta := p.ETA
+		if eta > 0 {
+			eta /= time.Millisecond
+		}
+
+		enc.Encode(&progressEvent{
+			Org:       org,
+			Step:      p.Stage,
+			Message:   p.Message,
+			Percent:   p.Percent,
+			Completed: p.Completed,
+			Total:     p.Total,
+			Elapsed:   int64(p.Elapsed / time.Millisecond),
+			ETA:       int64(eta),
+		})
+	}
+}
+
+// showProgress renders the updates sent on the returned channel, which is
+// given to an api call that closes it when done: as a progress bar, or as
+// lines of JSON when format is json, or not at all when quiet. The returned
+// function waits for the channel to be closed, and ends the progress bar.
+func showProgress(format, org string, quiet bool) (chan api.Progress, func()) {
+	render := func(p api.Progress) {
+		ui.ProgressBar(p.Message, p.Completed, p.Total, p.ETA)
+	}
+	switch {
+	case quiet:
+		render = func(api.Progress) {}
+	case format == "json":
+		render = jsonProgress(org)
+	}
+
+	progress := make(chan api.Progress)
+	done := make(chan bool)
+	go func() {
+		for p := range progress {
+			render(p)
+		}
+		close(done)
+	}()
+
+	return progress, func() {
+		<-done
+		ui.ProgressDone()
+	}
+}
+
+// progressFlags are the flags of commands showing progress with showProgress.
+var progressFlags = []cli.Flag{
+	formatFlag("text", "Format used to display progress (text, json)"),
+	cli.BoolFlag{
+		Name:  "quiet, q",
+		Usage: "Don't display progress",
+	},
+}
+
+// progressFormat returns the progress format set by progressFlags.
+func progressFormat(ctx *cli.Context) (string, error) {
+	format := ctx.String("format")
+	if format != "text" && format != "json" {
+		return "", errs.NewUsageExitError("Unknown format: "+format, ctx)
+	}
+	return format, nil
+}
+
 // NewAPIClient loads config and creates a new api client
 func NewAPIClient(ctx *context.Context, client *api.Client) (context.Context, *api.Client, error) {
 	if client == nil {
@@ -37,3 +139,41 @@ func NewAPIClient(ctx *context.Context, client *api.Client) (context.Context, *a
 	}
 	return c, client, nil
 }
+
+// parseRelativeTime returns the time described by s. It may be a duration,
+// such as 24h, or a number of days, such as 7d, which is added to now
+// (or subtracted, if dir is negative), or a date in YYYY-MM-DD or RFC 3339
+// format.
+func parseRelativeTime(s string, now time.Time, dir int) (time.Time, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err == nil && days >= 0 {
+			return now.AddDate(0, 0, dir*days), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(time.Duration(dir) * d), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid time: %s", s)
+}
+
+// tableHeader returns the header row of a table, with each column name
+// translated into the user's language and separated by tabs.
+func tableHeader(columns ...string) string {
+	translated := make([]string, len(columns))
+	for i, c := range columns {
+		translated[i] = i18n.T(c)
+	}
+
+	return strings.Join(translated, "\t")
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/i18n"
+)
+
+func TestParseRelativeTime(t *testing.T) {
+	now := time.Date(2017, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		s    string
+		dir  int
+		want time.Time
+	}{
+		{"24h", -1, now.Add(-24 * time.Hour)},
+		{"90m", -1, now.Add(-90 * time.Minute)},
+		{"7d", -1, now.AddDate(0, 0, -7)},
+		{"7d", 1, now.AddDate(0, 0, 7)},
+		{"1h", 1, now.Add(time.Hour)},
+		{"2017-03-01T00:00:00Z", -1, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"2017-03-01T00:00:00Z", 1, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tcs {
+		got, err := parseRelativeTime(tc.s, now, tc.dir)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.s, err)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("%s: got %s want %s", tc.s, got, tc.want)
+		}
+	}
+
+	got, err := parseRelativeTime("2017-03-01", now, -1)
+	if err != nil || got.Day() != 1 || got.Hour() != 0 {
+		t.Errorf("wrong date: %s %s", got, err)
+	}
+
+	for _, bad := range []string{"", "yesterday", "-3d", "-1h"} {
+		if _, err := parseRelativeTime(bad, now, -1); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestTableHeader(t *testing.T) {
+	defer i18n.Init(i18n.DefaultLanguage)
+
+	tcs := []struct {
+		lang string
+		want string
+	}{
+		{i18n.DefaultLanguage, "PATH\tVERSION\tMEMBERS"},
+		{"de", "PFAD\tVERSION\tMITGLIEDER"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.lang, func(t *testing.T) {
+			i18n.Init(tc.lang)
+			if got := tableHeader("PATH", "VERSION", "MEMBERS"); got != tc.want {
+				t.Errorf("wrong header: %q != %q", got, tc.want)
+			}
+		})
+	}
+}
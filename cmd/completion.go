@@ -0,0 +1,480 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/dirprefs"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/prefs"
+)
+
+// completionTimeout bounds the time spent asking the daemon for completions,
+// so a slow registry never hangs the shell.
+const completionTimeout = 2 * time.Second
+
+func init() {
+	completion := cli.Command{
+		Name:     "completion",
+		Usage:    "Output a shell completion script",
+		Category: "SYSTEM",
+		Subcommands: []cli.Command{
+			{
+				Name:   "bash",
+				Usage:  "Output a completion script for bash",
+				Action: completionScript(bashCompletion),
+			},
+			{
+				Name:   "zsh",
+				Usage:  "Output a completion script for zsh",
+				Action: completionScript(zshCompletion),
+			},
+			{
+				Name:   "fish",
+				Usage:  "Output a completion script for fish",
+				Action: completionScript(fishCompletion),
+			},
+		},
+	}
+
+	complete := cli.Command{
+		Name:            "__complete",
+		Usage:           "Print completions for the given words",
+		Hidden:          true, // called by the completion scripts
+		SkipFlagParsing: true,
+		Action:          completeCmd,
+	}
+
+	Cmds = append(Cmds, completion, complete)
+}
+
+const bashCompletion = `_torus_complete() {
+    local IFS=$'\n'
+    COMPREPLY=($(torus __complete "${COMP_WORDS[@]:1:$COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _torus_complete torus
+`
+
+const zshCompletion = `#compdef torus
+_torus() {
+    local -a candidates
+    candidates=("${(@f)$(torus __complete "${(@)words[2,$CURRENT]}" 2>/dev/null)}")
+    compadd -a candidates
+}
+compdef _torus torus
+`
+
+const fishCompletion = `function __torus_complete
+    set -l words (commandline -opc)
+    set -e words[1]
+    set -l current (commandline -ct)
+    torus __complete $words "$current" 2>/dev/null
+end
+complete -c torus -f -a '(__torus_complete)'
+`
+
+func completionScript(script string) func(*cli.Context) error {
+	return func(ctx *cli.Context) error {
+		if len(ctx.Args()) > 0 {
+			return errs.NewUsageExitError("Too many arguments", ctx)
+		}
+
+		fmt.Print(script)
+		return nil
+	}
+}
+
+// completionLine is the command line being completed, parsed as far as the
+// word under the cursor.
+type completionLine struct {
+	command *cli.Command
+	subcmds []cli.Command
+
+	// flags holds the values of the flags given so far, by their long name.
+	flags map[string]string
+	args  []string
+
+	// valueOf is the long name of the flag whose value is being completed.
+	valueOf string
+	current string
+}
+
+// parseCompletionLine walks words, the arguments after the program name, down
+// through cmds. The last word is the one being completed.
+func parseCompletionLine(cmds []cli.Command, words []string) *completionLine {
+	l := &completionLine{subcmds: cmds, flags: make(map[string]string)}
+	if len(words) == 0 {
+		return l
+	}
+
+	l.current = words[len(words)-1]
+	words = words[:len(words)-1]
+
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if strings.HasPrefix(w, "-") {
+			if l.command == nil {
+				continue
+			}
+
+			name, value, hasValue := splitFlagWord(w)
+			flag := findFlag(l.command.Flags, name)
+			if flag == nil || !takesValue(flag) {
+				continue
+			}
+
+			long := flagNames(flag)[0]
+			switch {
+			case hasValue:
+				l.flags[long] = value
+			case i+1 < len(words):
+				i++
+				l.flags[long] = words[i]
+			default:
+				l.valueOf = long
+			}
+			continue
+		}
+
+		if len(l.args) == 0 {
+			if sub := findCommand(l.subcmds, w); sub != nil {
+				l.command = sub
+				l.subcmds = sub.Subcommands
+				continue
+			}
+		}
+
+		l.args = append(l.args, w)
+	}
+
+	return l
+}
+
+// static returns the completions that can be found without the daemon, and
+// whether the word being completed was a subcommand or flag name.
+func (l *completionLine) static() ([]string, bool) {
+	if l.valueOf != "" {
+		return nil, false
+	}
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(l.current, "-"):
+		if l.command == nil {
+			return nil, true
+		}
+		for _, f := range l.command.Flags {
+			for _, name := range flagNames(f) {
+				if len(name) == 1 {
+					candidates = append(candidates, "-"+name)
+				} else {
+					candidates = append(candidates, "--"+name)
+				}
+			}
+		}
+	case len(l.subcmds) > 0 && len(l.args) == 0:
+		for _, c := range l.subcmds {
+			if !c.Hidden {
+				candidates = append(candidates, c.Name)
+			}
+		}
+	default:
+		return nil, false
+	}
+
+	return filterCompletions(candidates, l.current), true
+}
+
+// completesCredentialName returns whether the word being completed is the
+// name of a secret, as with `torus set <name|path> <value>`.
+func (l *completionLine) completesCredentialName() bool {
+	return l.command != nil && len(l.args) == 0 && l.valueOf == "" &&
+		!strings.HasPrefix(l.current, "-") &&
+		strings.HasPrefix(l.command.ArgsUsage, "<name")
+}
+
+func completeCmd(ctx *cli.Context) error {
+	line := parseCompletionLine(ctx.App.Commands, ctx.Args())
+
+	candidates, ok := line.static()
+	if !ok {
+		candidates = dynamicCompletions(line)
+	}
+
+	for _, c := range candidates {
+		fmt.Println(c)
+	}
+	return nil
+}
+
+// dynamicCompletions asks the daemon for the names of orgs, projects,
+// environments, services, or secrets. Nothing is returned if the daemon is
+// not running or not logged in; completion never starts the daemon.
+func dynamicCompletions(line *completionLine) []string {
+	var kind string
+	switch {
+	case line.valueOf != "":
+		kind = line.valueOf
+	case line.completesCredentialName():
+		kind = "name"
+	default:
+		return nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil
+	}
+
+	proc, err := findDaemon(cfg)
+	if err != nil || proc == nil {
+		return nil
+	}
+
+	client := api.NewClient(cfg)
+	c, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	names, err := completionNames(c, client, kind, completionScope(line))
+	if err != nil {
+		return nil
+	}
+
+	return filterCompletions(names, line.current)
+}
+
+// completionScope returns the org, project, environment and service to
+// complete within, from the flags on the line, falling back on the
+// environment, linked directory and preferences as commands do.
+func completionScope(line *completionLine) map[string]string {
+	scope := make(map[string]string)
+	for k, v := range line.flags {
+		scope[k] = v
+	}
+
+	envVars := map[string]string{
+		"org":         "TORUS_ORG",
+		"project":     "TORUS_PROJECT",
+		"environment": "TORUS_ENVIRONMENT",
+		"service":     "TORUS_SERVICE",
+	}
+	for k, name := range envVars {
+		if scope[k] == "" {
+			scope[k] = os.Getenv(name)
+		}
+	}
+
+	p, err := prefs.NewPreferences()
+	if err != nil || !p.Core.Context {
+		return scope
+	}
+
+	defaults := map[string]string{
+		"org":         p.Defaults.Organization,
+		"project":     p.Defaults.Project,
+		"environment": p.Defaults.Environment,
+		"service":     p.Defaults.Service,
+	}
+
+	if d, err := dirprefs.Load(true); err == nil {
+		if dc, err := d.Resolve(scope["environment"], scope["service"]); err == nil {
+			for k, v := range map[string]string{
+				"org":         dc.Organization,
+				"project":     dc.Project,
+				"environment": dc.Environment,
+				"service":     dc.Service,
+			} {
+				if v != "" {
+					defaults[k] = v
+				}
+			}
+		}
+	}
+
+	for k, v := range defaults {
+		if scope[k] == "" && v != "" {
+			scope[k] = v
+		}
+	}
+
+	return scope
+}
+
+func completionNames(c context.Context, client *api.Client, kind string,
+	scope map[string]string) ([]string, error) {
+
+	if kind == "org" {
+		orgs, err := client.Orgs.List(c)
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for _, o := range orgs {
+			names = append(names, o.Body.Name)
+		}
+		return names, nil
+	}
+
+	if scope["org"] == "" {
+		return nil, nil
+	}
+
+	org, err := client.Orgs.GetByName(c, scope["org"])
+	if err != nil || org == nil {
+		return nil, err
+	}
+	orgIDs := []*identity.ID{org.ID}
+
+	if kind == "project" {
+		projects, err := client.Projects.List(c, &orgIDs, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for _, p := range activeProjects(projects) {
+			names = append(names, p.Body.Name)
+		}
+		return names, nil
+	}
+
+	if scope["project"] == "" {
+		return nil, nil
+	}
+
+	projectNames := []string{scope["project"]}
+	projects, err := client.Projects.List(c, &orgIDs, &projectNames)
+	if err != nil || len(projects) != 1 {
+		return nil, err
+	}
+	projectIDs := []*identity.ID{projects[0].ID}
+
+	switch kind {
+	case "environment":
+		envs, err := client.Environments.List(c, &orgIDs, &projectIDs, nil)
+		if err != nil {
+			return nil, err
+		}
+		return envNames(envs), nil
+	case "service":
+		services, err := client.Services.List(c, &orgIDs, &projectIDs, nil)
+		if err != nil {
+			return nil, err
+		}
+		return serviceNames(services), nil
+	case "name":
+		return credentialNames(c, client, scope)
+	default:
+		return nil, nil
+	}
+}
+
+func credentialNames(c context.Context, client *api.Client, scope map[string]string) ([]string, error) {
+	segments := []string{scope["org"], scope["project"], scope["environment"], scope["service"], "*", "*"}
+	for i, s := range segments {
+		if s == "" {
+			segments[i] = "*"
+		}
+	}
+
+	creds, err := client.Credentials.Search(c, "/"+strings.Join(segments, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, cred := range creds {
+		names = append(names, (*cred.Body).GetName())
+	}
+	return names, nil
+}
+
+func envNames(envs []envelope.Environment) []string {
+	var names []string
+	for _, e := range envs {
+		names = append(names, e.Body.Name)
+	}
+	return names
+}
+
+func serviceNames(services []envelope.Service) []string {
+	var names []string
+	for _, s := range services {
+		names = append(names, s.Body.Name)
+	}
+	return names
+}
+
+// filterCompletions returns the unique candidates starting with prefix, in
+// sorted order.
+func filterCompletions(candidates []string, prefix string) []string {
+	seen := make(map[string]bool, len(candidates))
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) && !seen[c] {
+			seen[c] = true
+			matches = append(matches, c)
+		}
+	}
+
+	sort.Strings(matches)
+	return matches
+}
+
+func findCommand(cmds []cli.Command, name string) *cli.Command {
+	for i, c := range cmds {
+		if c.HasName(name) {
+			return &cmds[i]
+		}
+	}
+	return nil
+}
+
+func findFlag(flags []cli.Flag, name string) cli.Flag {
+	for _, f := range flags {
+		for _, n := range flagNames(f) {
+			if n == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// flagNames returns the names of f, long name first, as in "org, o".
+func flagNames(f cli.Flag) []string {
+	var names []string
+	for _, n := range strings.Split(f.GetName(), ",") {
+		names = append(names, strings.TrimSpace(n))
+	}
+	return names
+}
+
+func takesValue(f cli.Flag) bool {
+	switch f.(type) {
+	case cli.BoolFlag, cli.BoolTFlag:
+		return false
+	default:
+		return true
+	}
+}
+
+// splitFlagWord splits a word like --org=acme into its flag name and value.
+func splitFlagWord(w string) (string, string, bool) {
+	w = strings.TrimLeft(w, "-")
+	if i := strings.Index(w, "="); i >= 0 {
+		return w[:i], w[i+1:], true
+	}
+	return w, "", false
+}
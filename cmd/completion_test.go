@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestCompletionLine(t *testing.T) {
+	cmds := []cli.Command{
+		{
+			Name:      "set",
+			ArgsUsage: "<name|path> <value>",
+			Flags: []cli.Flag{
+				stdOrgFlag,
+				stdEnvFlag,
+				cli.BoolFlag{Name: "force"},
+			},
+		},
+		{
+			Name: "orgs",
+			Subcommands: []cli.Command{
+				{Name: "list"},
+				{Name: "remove"},
+				{Name: "secret", Hidden: true},
+			},
+		},
+	}
+
+	tcs := []struct {
+		name    string
+		words   []string
+		static  []string
+		ok      bool
+		valueOf string
+		creds   bool
+	}{
+		{"commands", []string{"s"}, []string{"set"}, true, "", false},
+		{"subcommands", []string{"orgs", ""}, []string{"list", "remove"}, true, "", false},
+		{"flags", []string{"set", "--e"}, []string{"--environment"}, true, "", false},
+		{"short flags", []string{"set", "-"}, []string{"--environment", "--force", "--org", "-e", "-o"}, true, "", false},
+		{"flag value", []string{"set", "-e", "pr"}, nil, false, "environment", false},
+		{"name", []string{"set", "-o", "acme", "--force", ""}, nil, false, "", true},
+		{"value after flag", []string{"set", "--org=acme", "-e", "dev", "A"}, nil, false, "", true},
+		{"value", []string{"set", "NAME", ""}, nil, false, "", false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			l := parseCompletionLine(cmds, tc.words)
+			static, ok := l.static()
+			if ok != tc.ok || !reflect.DeepEqual(static, tc.static) {
+				t.Errorf("static() = %v, %t; want %v, %t", static, ok, tc.static, tc.ok)
+			}
+			if l.valueOf != tc.valueOf {
+				t.Errorf("completing value of %q, want %q", l.valueOf, tc.valueOf)
+			}
+			if got := l.completesCredentialName(); got != tc.creds {
+				t.Errorf("completesCredentialName() = %t", got)
+			}
+		})
+	}
+}
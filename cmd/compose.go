@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/compose"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+const (
+	defaultComposeFile     = "docker-compose.yml"
+	defaultComposeOverride = "docker-compose.override.yml"
+)
+
+func init() {
+	overrideFlag := newPlaceholder("override", "FILE",
+		"Path of the generated override file", defaultComposeOverride, "", false)
+
+	composeCmd := cli.Command{
+		Name:     "compose",
+		Usage:    "Give docker-compose services their secrets for local development",
+		Category: "SECRETS",
+		Subcommands: []cli.Command{
+			{
+				Name:  "generate",
+				Usage: "Write a docker-compose override file giving each service its secrets",
+				Flags: []cli.Flag{
+					newPlaceholder("file, f", "FILE", "Path of the docker-compose file",
+						defaultComposeFile, "", false),
+					overrideFlag,
+					newSlicePlaceholder("map", "SERVICE=TORUS",
+						"Give compose SERVICE the secrets of TORUS service. Can be given more than once",
+						"", "", false),
+					stdOrgFlag,
+					stdProjectFlag,
+					stdEnvFlag,
+					userFlag("Use this user.", false),
+					machineFlag("Use this machine.", false),
+					stdInstanceFlag,
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					setUserEnv, checkRequiredFlags, composeGenerateCmd,
+				),
+			},
+			{
+				Name:   "clean",
+				Usage:  "Remove a generated override file, and the env files it uses",
+				Flags:  []cli.Flag{overrideFlag},
+				Action: composeCleanCmd,
+			},
+		},
+	}
+
+	Cmds = append(Cmds, composeCmd)
+}
+
+func composeGenerateCmd(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	mapping, err := parseComposeMap(ctx.StringSlice("map"))
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	file, err := compose.Load(ctx.String("file"))
+	if err != nil {
+		return errs.NewErrorExitError("Could not read "+ctx.String("file")+".", err)
+	}
+
+	overridePath := ctx.String("override")
+	oldEnvDir, err := generatedOverride(overridePath)
+	if err != nil {
+		return err
+	}
+
+	envDir, err := compose.NewEnvDir()
+	if err != nil {
+		return errs.NewErrorExitError("Could not create directory for env files.", err)
+	}
+
+	envFiles, err := writeComposeEnvFiles(ctx, file, mapping, envDir)
+	if err != nil {
+		compose.RemoveEnvDir(envDir)
+		return err
+	}
+
+	b, err := compose.NewOverride(file.Version, envFiles).Marshal(envDir)
+	if err == nil {
+		err = ioutil.WriteFile(overridePath, b, 0644)
+	}
+	if err != nil {
+		compose.RemoveEnvDir(envDir)
+		return errs.NewErrorExitError("Could not write "+overridePath+".", err)
+	}
+
+	if oldEnvDir != "" {
+		compose.RemoveEnvDir(oldEnvDir)
+	}
+
+	fmt.Printf("Wrote %s with secrets for %d services.\n", overridePath, len(envFiles))
+	fmt.Println("Run 'torus compose clean' to remove it, and the env files it uses, when you're done.")
+	return nil
+}
+
+// writeComposeEnvFiles writes an env file to envDir for each service in file
+// with secrets, returning their paths by service name.
+func writeComposeEnvFiles(ctx *cli.Context, file *compose.File, mapping map[string]string,
+	envDir string) (map[string]string, error) {
+
+	services := file.TorusServices(mapping)
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	envFiles := make(map[string]string, len(names))
+	for _, name := range names {
+		secrets, _, err := getServiceSecrets(ctx, services[name])
+		if err != nil {
+			return nil, err
+		}
+		if len(secrets) == 0 {
+			fmt.Fprintf(os.Stderr, "Skipping %s: service %s has no secrets.\n", name, services[name])
+			continue
+		}
+
+		values, err := composeEnvValues(secrets)
+		if err == nil {
+			envFiles[name], err = compose.WriteEnvFile(envDir, name, values)
+		}
+		if err != nil {
+			return nil, errs.NewErrorExitError("Could not write env file for "+name+".", err)
+		}
+	}
+
+	return envFiles, nil
+}
+
+func composeCleanCmd(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	overridePath := ctx.String("override")
+	if _, err := os.Stat(overridePath); os.IsNotExist(err) {
+		fmt.Printf("%s does not exist, nothing to clean.\n", overridePath)
+		return nil
+	}
+
+	envDir, err := generatedOverride(overridePath)
+	if err != nil {
+		return err
+	}
+
+	if envDir != "" {
+		err = compose.RemoveEnvDir(envDir)
+		if err != nil {
+			return errs.NewErrorExitError("Could not remove env files.", err)
+		}
+	}
+
+	err = os.Remove(overridePath)
+	if err != nil {
+		return errs.NewErrorExitError("Could not remove "+overridePath+".", err)
+	}
+
+	fmt.Printf("Removed %s and its env files.\n", overridePath)
+	return nil
+}
+
+// generatedOverride returns the env file directory of the override file at
+// path. It is an error for the file to exist without having been generated by
+// torus, so that hand written overrides are never replaced.
+func generatedOverride(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errs.NewErrorExitError("Could not read "+path+".", err)
+	}
+
+	generated, envDir := compose.Generated(b)
+	if !generated {
+		return "", errs.NewExitError(path + " exists, and was not generated by Torus.")
+	}
+
+	return envDir, nil
+}
+
+// parseComposeMap reads --map values of the form SERVICE=TORUS.
+func parseComposeMap(values []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(values))
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid --map %q, expected SERVICE=TORUS", v)
+		}
+		mapping[parts[0]] = parts[1]
+	}
+
+	return mapping, nil
+}
+
+// composeEnvValues returns the env file names and values of secrets. Names
+// are upper cased, as they are by torus run.
+func composeEnvValues(secrets []apitypes.CredentialEnvelope) (map[string]string, error) {
+	exported, err := exportSecrets(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(exported))
+	for _, s := range exported {
+		values[strings.ToUpper(s.name)] = fmt.Sprint(s.value)
+	}
+
+	return values, nil
+}
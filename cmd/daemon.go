@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -20,10 +21,17 @@ import (
 	"github.com/manifoldco/torus-cli/api"
 	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
 
 	"github.com/manifoldco/torus-cli/daemon"
+	"github.com/manifoldco/torus-cli/daemon/resolver"
+	"github.com/manifoldco/torus-cli/daemon/sentinel"
 )
 
+// sentinelExitStatus is the exit status of a daemon stopped by the sentinel
+// finding an anomaly.
+const sentinelExitStatus = 3
+
 func init() {
 	daemon := cli.Command{
 		Name:     "daemon",
@@ -53,12 +61,58 @@ func init() {
 						Usage:  "Skip Torus root dir permission checks",
 						Hidden: true, // Just for system daemon use
 					},
+					newPlaceholder("cache-proxy", "ADDR",
+						"Also serve a caching proxy of the registry for other daemons on ADDR (e.g. :4443)",
+						"", "TORUS_CACHE_PROXY", false),
+					newPlaceholder("cache-proxy-cert", "FILE",
+						"TLS certificate for the caching proxy", "", "TORUS_CACHE_PROXY_CERT", false),
+					newPlaceholder("cache-proxy-key", "FILE",
+						"TLS private key for the caching proxy", "", "TORUS_CACHE_PROXY_KEY", false),
+					cli.DurationFlag{
+						Name:  "cache-ttl",
+						Usage: "How long the caching proxy keeps registry responses",
+						Value: time.Minute,
+					},
+					newPlaceholder("resolve-api", "ADDR",
+						"Also serve the resolve API, for tools like Terraform, on loopback ADDR (e.g. 127.0.0.1:4444)",
+						"", "TORUS_RESOLVE_API", false),
+					cli.Float64Flag{
+						Name:  "resolve-rate",
+						Usage: "Most requests per second the resolve API serves",
+						Value: resolver.DefaultRate,
+					},
+					cli.BoolFlag{
+						Name:   "read-only",
+						Usage:  "Reject every request that would change anything in the registry, allowing only reads of secrets",
+						EnvVar: "TORUS_READ_ONLY",
+					},
+					cli.BoolFlag{
+						Name:  "sentinel",
+						Usage: "Continuously verify claims, keyring members, and the offline cache, alerting on anomalies",
+					},
+					cli.DurationFlag{
+						Name:  "sentinel-interval",
+						Usage: "How often the sentinel runs its checks",
+						Value: sentinel.DefaultInterval,
+					},
+					newSlicePlaceholder("sentinel-path", "PATH",
+						"Keyring path to watch for unexpected members (can be repeated)", "", "", false),
+					newPlaceholder("sentinel-webhook", "URL",
+						"POST sentinel anomalies to URL as JSON", "", "TORUS_SENTINEL_WEBHOOK", false),
+					cli.BoolFlag{
+						Name:  "sentinel-syslog",
+						Usage: "Send sentinel anomalies to syslog",
+					},
+					cli.BoolFlag{
+						Name:  "sentinel-exit",
+						Usage: fmt.Sprintf("Stop the daemon with exit status %d when the sentinel finds an anomaly", sentinelExitStatus),
+					},
 				},
 				Action: func(ctx *cli.Context) error {
 					if ctx.Bool("foreground") {
 						return startDaemon(ctx)
 					}
-					return spawnDaemonCmd()
+					return spawnDaemonCmd(ctx)
 				},
 			},
 			{
@@ -95,10 +149,34 @@ func daemonStatus(ctx *cli.Context) error {
 
 	fmt.Printf("Daemon is running. pid: %d version: v%s\n", proc.Pid, v.Version)
 
+	stats, err := client.Credentials.PrefetchStats(context.Background())
+	if err != nil {
+		return errs.NewErrorExitError("Error communicating with the daemon", err)
+	}
+
+	if !stats.Enabled {
+		fmt.Println("Credential prefetch is disabled.")
+		return nil
+	}
+
+	rate := 0.0
+	if total := stats.Hits + stats.Misses; total > 0 {
+		rate = float64(stats.Hits) / float64(total) * 100
+	}
+	fmt.Printf("Credential prefetch: %d path(s) watched, %.0f%% hit rate (%d/%d)\n",
+		len(stats.Paths), rate, stats.Hits, stats.Hits+stats.Misses)
+
 	return nil
 }
 
-func spawnDaemonCmd() error {
+func spawnDaemonCmd(ctx *cli.Context) error {
+	if _, err := cacheProxyOptions(ctx); err != nil {
+		return err
+	}
+	if _, err := sentinelConfig(ctx); err != nil {
+		return err
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return err
@@ -114,7 +192,34 @@ func spawnDaemonCmd() error {
 		return nil
 	}
 
-	err = spawnDaemon()
+	var args []string
+	for _, name := range []string{"cache-proxy", "cache-proxy-cert", "cache-proxy-key", "resolve-api"} {
+		if v := ctx.String(name); v != "" {
+			args = append(args, "--"+name, v)
+		}
+	}
+	if ctx.IsSet("cache-ttl") {
+		args = append(args, "--cache-ttl", ctx.Duration("cache-ttl").String())
+	}
+	if ctx.IsSet("resolve-rate") {
+		args = append(args, "--resolve-rate", fmt.Sprint(ctx.Float64("resolve-rate")))
+	}
+	for _, name := range []string{"read-only", "sentinel", "sentinel-syslog", "sentinel-exit"} {
+		if ctx.Bool(name) {
+			args = append(args, "--"+name)
+		}
+	}
+	if ctx.IsSet("sentinel-interval") {
+		args = append(args, "--sentinel-interval", ctx.Duration("sentinel-interval").String())
+	}
+	for _, p := range ctx.StringSlice("sentinel-path") {
+		args = append(args, "--sentinel-path", p)
+	}
+	if v := ctx.String("sentinel-webhook"); v != "" {
+		args = append(args, "--sentinel-webhook", v)
+	}
+
+	err = spawnDaemon(args...)
 	if err != nil {
 		return err
 	}
@@ -123,20 +228,29 @@ func spawnDaemonCmd() error {
 	return nil
 }
 
-func spawnDaemon() error {
+// spawnDaemon starts the daemon in the background, passing it any extra
+// args for `daemon start`.
+func spawnDaemon(args ...string) error {
+	// Clone the current env, removing email and password if they exist.
+	// no need to keep those hanging around in a long lived-process!
+	return spawnDaemonEnv(filterEnv(), args...)
+}
+
+// spawnDaemonEnv starts a daemon in the background with the given
+// environment.
+func spawnDaemonEnv(env []string, args ...string) error {
 	executable, err := osext.Executable()
 	if err != nil {
 		return errs.NewErrorExitError("Unable to find executable.", err)
 	}
 
-	cmd := exec.Command(executable, "daemon", "start", "--foreground", "--daemonize")
+	args = append([]string{"daemon", "start", "--foreground", "--daemonize"}, args...)
+	cmd := exec.Command(executable, args...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		Setsid: true, // start a new session group, ie detach
 	}
 
-	// Clone the current env, removing email and password if they exist.
-	// no need to keep those hanging around in a long lived-process!
-	cmd.Env = filterEnv()
+	cmd.Env = env
 
 	err = cmd.Start()
 	if err != nil {
@@ -166,17 +280,57 @@ func startDaemon(ctx *cli.Context) error {
 	if err != nil {
 		return errs.NewErrorExitError("Failed to load config.", err)
 	}
+	if ctx.Bool("read-only") {
+		cfg.ReadOnly = true
+	}
+
+	cacheProxy, err := cacheProxyOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	sentinelCfg, err := sentinelConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	resolveAPI := resolveAPIOptions(ctx)
 
 	daemon, err := daemon.New(cfg, noPermissionCheck)
 	if err != nil {
 		return errs.NewErrorExitError("Failed to create daemon.", err)
 	}
 
+	if cacheProxy != nil {
+		daemon.EnableCacheProxy(*cacheProxy)
+	}
+
+	if resolveAPI != nil {
+		err = daemon.EnableResolveAPI(*resolveAPI)
+		if err != nil {
+			return errs.NewErrorExitError("Failed to start resolve API.", err)
+		}
+	}
+
+	if sentinelCfg != nil {
+		err = daemon.EnableSentinel(*sentinelCfg)
+		if err != nil {
+			return errs.NewErrorExitError("Failed to start sentinel.", err)
+		}
+	}
+
 	go watch(daemon)
 	defer daemon.Shutdown()
 
 	log.Printf("v%s of the Daemon is now listening on %s", cfg.Version, daemon.Addr())
+	if cfg.ReadOnly {
+		log.Printf("The Daemon is read-only; requests that would change the registry are rejected")
+	}
 	err = daemon.Run()
+	if err == sentinel.ErrAnomaly {
+		log.Printf("Stopping daemon: %s", err)
+		return cli.NewExitError("Sentinel found anomalies; see the daemon log.", sentinelExitStatus)
+	}
 	if err != nil {
 		log.Printf("Error while running daemon.\n%s", err)
 	}
@@ -184,6 +338,75 @@ func startDaemon(ctx *cli.Context) error {
 	return err
 }
 
+// sentinelConfig returns the sentinel configured by the flags to
+// `daemon start`, or nil if it is not enabled.
+func sentinelConfig(ctx *cli.Context) (*sentinel.Config, error) {
+	if !ctx.Bool("sentinel") {
+		return nil, nil
+	}
+
+	cfg := &sentinel.Config{
+		Interval: ctx.Duration("sentinel-interval"),
+		Webhook:  ctx.String("sentinel-webhook"),
+		Syslog:   ctx.Bool("sentinel-syslog"),
+		Exit:     ctx.Bool("sentinel-exit"),
+	}
+
+	for _, raw := range ctx.StringSlice("sentinel-path") {
+		pe, err := pathexp.Parse(raw)
+		if err != nil {
+			return nil, errs.NewErrorExitError("Invalid --sentinel-path "+raw+".", err)
+		}
+		cfg.Paths = append(cfg.Paths, pe)
+	}
+
+	if cfg.Webhook != "" {
+		u, err := url.Parse(cfg.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, errs.NewUsageExitError("--sentinel-webhook must be an http or https URL", ctx)
+		}
+	}
+
+	return cfg, nil
+}
+
+// cacheProxyOptions returns the caching registry proxy configured by the
+// flags to `daemon start`, or nil if it is not enabled.
+func cacheProxyOptions(ctx *cli.Context) (*daemon.CacheProxyOptions, error) {
+	addr := ctx.String("cache-proxy")
+	if addr == "" {
+		return nil, nil
+	}
+
+	certFile := ctx.String("cache-proxy-cert")
+	keyFile := ctx.String("cache-proxy-key")
+	if (certFile == "") != (keyFile == "") {
+		return nil, errs.NewUsageExitError(
+			"--cache-proxy-cert and --cache-proxy-key must be used together", ctx)
+	}
+
+	return &daemon.CacheProxyOptions{
+		Addr:     addr,
+		CertFile: certFile,
+		KeyFile:  keyFile,
+		TTL:      ctx.Duration("cache-ttl"),
+	}, nil
+}
+
+// resolveAPIOptions returns the resolve API configured by the flags to
+// `daemon start`, or nil if it is not enabled.
+func resolveAPIOptions(ctx *cli.Context) *daemon.ResolveAPIOptions {
+	addr := ctx.String("resolve-api")
+	if addr == "" {
+		return nil
+	}
+
+	return &daemon.ResolveAPIOptions{
+		Addr: addr,
+		Rate: ctx.Float64("resolve-rate"),
+	}
+}
+
 func watch(daemon *daemon.Daemon) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
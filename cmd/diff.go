@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func init() {
+	diff := cli.Command{
+		Name:      "diff",
+		Usage:     "Compare the secrets of two environments or paths",
+		ArgsUsage: "<environment|path> <environment|path>",
+		Category:  "SECRETS",
+		Flags: []cli.Flag{
+			orgFlag("Use this organization.", false),
+			projectFlag("Use this project.", false),
+			serviceFlag("Use this service.", "default", false),
+			userFlag("Use this user.", false),
+			machineFlag("Use this machine.", false),
+			instanceFlag("Use this instance.", false),
+			cli.BoolFlag{
+				Name:  "values",
+				Usage: "Show the values of secrets that differ",
+			},
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setUserEnv, checkRequiredFlags, diffCmd,
+		),
+	}
+
+	Cmds = append(Cmds, diff)
+}
+
+// secretDiff compares a secret between two sets of secrets. Either value is
+// nil if the secret is not set there.
+type secretDiff struct {
+	name        string
+	left, right *apitypes.CredentialValue
+}
+
+// Same returns whether the secret has the same value in both sets.
+func (d *secretDiff) Same() bool {
+	return d.left != nil && d.right != nil && d.left.String() == d.right.String()
+}
+
+func diffCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 2 {
+		msg := "Two environments or paths are required."
+		if len(args) > 2 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return err
+	}
+
+	identity, err := deriveIdentity(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, len(args))
+	for i, arg := range args {
+		paths[i], err = diffPath(ctx, arg, identity)
+		if err != nil {
+			return err
+		}
+	}
+
+	creds, err := client.Credentials.GetMany(c, paths)
+	if err != nil {
+		return errs.NewErrorExitError("Error fetching secrets", err)
+	}
+
+	diffs := diffSecrets(creds[0], creds[1])
+	if len(diffs) == 0 {
+		fmt.Println("No secrets found.")
+		return nil
+	}
+
+	fmt.Printf("Comparing %s (-) with %s (+)\n\n", paths[0], paths[1])
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	for _, d := range diffs {
+		key := strings.ToUpper(d.name)
+		switch {
+		case d.right == nil:
+			fmt.Fprintf(w, "- %s\tonly in %s\t", key, args[0])
+		case d.left == nil:
+			fmt.Fprintf(w, "+ %s\tonly in %s\t", key, args[1])
+		case d.Same():
+			fmt.Fprintf(w, "  %s\tsame\t", key)
+		default:
+			fmt.Fprintf(w, "~ %s\tdiffers\t", key)
+		}
+
+		if ctx.Bool("values") && !d.Same() {
+			fmt.Fprintf(w, "%s -> %s", diffValue(d.left), diffValue(d.right))
+		}
+		fmt.Fprintln(w)
+	}
+
+	w.Flush()
+	return nil
+}
+
+// diffPath returns the path of the secrets to compare for arg. arg is either
+// the name of an environment in the current context, or a full path.
+func diffPath(ctx *cli.Context, arg, identity string) (string, error) {
+	if strings.HasPrefix(arg, "/") {
+		_, err := pathexp.Parse(arg)
+		if err != nil {
+			return "", errs.NewErrorExitError("Invalid path "+arg, err)
+		}
+		return arg, nil
+	}
+
+	if ctx.String("org") == "" || ctx.String("project") == "" {
+		return "", errs.NewUsageExitError(
+			"--org and --project are required to compare environments", ctx)
+	}
+
+	parts := []string{
+		"", ctx.String("org"), ctx.String("project"), arg,
+		ctx.String("service"), identity, ctx.String("instance"),
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// diffSecrets compares two sets of credentials by name, after resolving each
+// set the same way as `torus view`. The result is sorted by name.
+func diffSecrets(left, right []apitypes.CredentialEnvelope) []secretDiff {
+	lset := credentialSet{}
+	for _, c := range left {
+		lset.Add(c)
+	}
+	rset := credentialSet{}
+	for _, c := range right {
+		rset.Add(c)
+	}
+
+	var diffs []secretDiff
+	for _, c := range lset.ToSlice() {
+		name := (*c.Body).GetName()
+		d := secretDiff{name: name, left: (*c.Body).GetValue()}
+		if rc, ok := rset[name]; ok {
+			d.right = (*rc.Body).GetValue()
+		}
+		diffs = append(diffs, d)
+	}
+	for _, c := range rset.ToSlice() {
+		name := (*c.Body).GetName()
+		if _, ok := lset[name]; !ok {
+			diffs = append(diffs, secretDiff{name: name, right: (*c.Body).GetValue()})
+		}
+	}
+
+	sort.Sort(secretDiffSorter(diffs))
+	return diffs
+}
+
+func diffValue(v *apitypes.CredentialValue) string {
+	if v == nil {
+		return "(not set)"
+	}
+	return v.String()
+}
+
+// secretDiffSorter implements sort.Interface, for sorting secretDiffs by
+// name.
+type secretDiffSorter []secretDiff
+
+func (s secretDiffSorter) Len() int           { return len(s) }
+func (s secretDiffSorter) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s secretDiffSorter) Less(i, j int) bool { return s[i].name < s[j].name }
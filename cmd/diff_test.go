@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func TestDiffSecrets(t *testing.T) {
+	cred := func(env, name, value string) apitypes.CredentialEnvelope {
+		pe, err := pathexp.Parse("/org/project/" + env + "/default/*/1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var body apitypes.Credential = &apitypes.CredentialV2{
+			BaseCredential: apitypes.BaseCredential{
+				Name:    name,
+				PathExp: pe,
+				Value:   apitypes.NewStringCredentialValue(value),
+			},
+			State: "set",
+		}
+		return apitypes.CredentialEnvelope{Version: 2, Body: &body}
+	}
+
+	staging := []apitypes.CredentialEnvelope{
+		cred("staging", "port", "8080"),
+		cred("staging", "debug", "true"),
+		cred("staging", "url", "https://staging.example.com"),
+	}
+	production := []apitypes.CredentialEnvelope{
+		cred("production", "url", "https://example.com"),
+		cred("production", "port", "8080"),
+		cred("production", "token", "secret"),
+	}
+
+	diffs := diffSecrets(staging, production)
+
+	want := []struct {
+		name        string
+		left, right bool
+		same        bool
+	}{
+		{name: "debug", left: true},
+		{name: "port", left: true, right: true, same: true},
+		{name: "token", right: true},
+		{name: "url", left: true, right: true},
+	}
+
+	if len(diffs) != len(want) {
+		t.Fatalf("got %d diffs want %d", len(diffs), len(want))
+	}
+
+	for i, w := range want {
+		d := diffs[i]
+		if d.name != w.name || (d.left != nil) != w.left || (d.right != nil) != w.right || d.Same() != w.same {
+			t.Errorf("%d: got %s (%v, %v, same: %t), want %+v",
+				i, d.name, d.left, d.right, d.Same(), w)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/compose"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+// dockerPollInterval is how often torus docker run checks whether the
+// container has been created.
+const dockerPollInterval = 100 * time.Millisecond
+
+func init() {
+	dockerCmd := cli.Command{
+		Name:     "docker",
+		Usage:    "Run docker containers with secrets, without writing them to disk",
+		Category: "SECRETS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "run",
+				Usage:     "Run docker run, giving the container its secrets through an env file",
+				ArgsUsage: "[--] <docker run arguments>...",
+				Flags:     exportContextFlags,
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					setUserEnv, checkRequiredFlags, dockerRunCmd,
+				),
+			},
+			{
+				Name:      "compose",
+				Usage:     "Run docker compose, giving each service its secrets through an env file",
+				ArgsUsage: "[--] <docker compose arguments>...",
+				Flags: []cli.Flag{
+					newPlaceholder("file, f", "FILE", "Path of the docker-compose file",
+						defaultComposeFile, "", false),
+					newSlicePlaceholder("map", "SERVICE=TORUS",
+						"Give compose SERVICE the secrets of TORUS service. Can be given more than once",
+						"", "", false),
+					stdOrgFlag,
+					stdProjectFlag,
+					stdEnvFlag,
+					userFlag("Use this user.", false),
+					machineFlag("Use this machine.", false),
+					stdInstanceFlag,
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					setUserEnv, checkRequiredFlags, dockerComposeCmd,
+				),
+			},
+		},
+	}
+
+	Cmds = append(Cmds, dockerCmd)
+}
+
+func dockerRunCmd(ctx *cli.Context) error {
+	args := []string(ctx.Args())
+	if len(args) == 0 {
+		return errs.NewUsageExitError("An image is required", ctx)
+	}
+	for _, arg := range args {
+		if arg == "--cidfile" || strings.HasPrefix(arg, "--cidfile=") {
+			return errs.NewUsageExitError("--cidfile cannot be used with torus docker run", ctx)
+		}
+	}
+
+	secrets, _, err := getSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	values, err := composeEnvValues(secrets)
+	if err != nil {
+		return errs.NewErrorExitError("Could not export secrets.", err)
+	}
+
+	envDir, err := compose.NewEnvDir()
+	if err != nil {
+		return errs.NewErrorExitError("Could not create directory for env file.", err)
+	}
+
+	envFile, err := compose.WriteEnvFile(envDir, "docker", values)
+	if err != nil {
+		compose.RemoveEnvDir(envDir)
+		return errs.NewErrorExitError("Could not write env file.", err)
+	}
+
+	// docker writes the container's ID to the cidfile once the container
+	// has been created, by which point it has read the env file.
+	cidFile := filepath.Join(envDir, "cid")
+	dockerArgs := append([]string{"run", "--env-file", envFile, "--cidfile", cidFile}, args...)
+
+	done := make(chan bool)
+	go removeOnCreate(cidFile, envFile, done)
+
+	err = relayProcess(dockerCommand(dockerArgs), "Failed to run docker.")
+	close(done)
+	compose.RemoveEnvDir(envDir)
+
+	return exitWithStatus(err)
+}
+
+// removeOnCreate removes envFile once docker has written cidFile, or done is
+// closed.
+func removeOnCreate(cidFile, envFile string, done chan bool) {
+	ticker := time.NewTicker(dockerPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if fi, err := os.Stat(cidFile); err == nil && fi.Size() > 0 {
+				os.Remove(envFile)
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+func dockerComposeCmd(ctx *cli.Context) error {
+	args := []string(ctx.Args())
+	if len(args) == 0 {
+		return errs.NewUsageExitError("A docker compose command is required", ctx)
+	}
+
+	mapping, err := parseComposeMap(ctx.StringSlice("map"))
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	file, err := compose.Load(ctx.String("file"))
+	if err != nil {
+		return errs.NewErrorExitError("Could not read "+ctx.String("file")+".", err)
+	}
+
+	envDir, err := compose.NewEnvDir()
+	if err != nil {
+		return errs.NewErrorExitError("Could not create directory for env files.", err)
+	}
+
+	envFiles, err := writeComposeEnvFiles(ctx, file, mapping, envDir)
+	if err != nil {
+		compose.RemoveEnvDir(envDir)
+		return err
+	}
+
+	overridePath := filepath.Join(envDir, defaultComposeOverride)
+	b, err := compose.NewOverride(file.Version, envFiles).Marshal(envDir)
+	if err == nil {
+		err = ioutil.WriteFile(overridePath, b, 0600)
+	}
+	if err != nil {
+		compose.RemoveEnvDir(envDir)
+		return errs.NewErrorExitError("Could not write override file.", err)
+	}
+
+	dockerArgs := append([]string{"compose", "-f", ctx.String("file"), "-f", overridePath}, args...)
+	err = relayProcess(dockerCommand(dockerArgs), "Failed to run docker compose.")
+	compose.RemoveEnvDir(envDir)
+
+	return exitWithStatus(err)
+}
+
+// dockerCommand returns the docker command with the given arguments, run
+// with our stdio, but without the credentials torus was given.
+func dockerCommand(args []string) *exec.Cmd {
+	cmd := exec.Command("docker", args...)
+	cmd.Env = filterEnv()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+func init() {
+	env := cli.Command{
+		Name:     "env",
+		Usage:    "Print shell commands that set secrets in the environment, for use with eval",
+		Category: "SECRETS",
+		Flags: append(exportContextFlags,
+			newPlaceholder("shell", "SHELL",
+				"Shell to print commands for (sh, bash, zsh, fish, powershell) (default: from $SHELL)", "", "", false),
+			cli.BoolFlag{
+				Name:  "unset",
+				Usage: "Print commands that remove the secrets from the environment instead",
+			},
+			stdOfflineFlag,
+		),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setUserEnv, checkRequiredFlags, envCmd,
+		),
+	}
+
+	Cmds = append(Cmds, env)
+}
+
+// shellSyntax is how to set and unset environment variables in a shell.
+type shellSyntax struct {
+	set   func(name, value string) string
+	unset func(name string) string
+}
+
+var shells = map[string]shellSyntax{
+	"sh": {
+		set:   func(n, v string) string { return "export " + n + "=" + quoteSingle(v, `'\''`) },
+		unset: func(n string) string { return "unset " + n },
+	},
+	"fish": {
+		set: func(n, v string) string {
+			v = strings.Replace(v, `\`, `\\`, -1)
+			return "set -gx " + n + " " + quoteSingle(v, `\'`)
+		},
+		unset: func(n string) string { return "set -e " + n },
+	},
+	"powershell": {
+		set: func(n, v string) string { return "$env:" + n + " = " + quoteSingle(v, `''`) },
+		unset: func(n string) string {
+			return "Remove-Item Env:" + n + " -ErrorAction SilentlyContinue"
+		},
+	},
+}
+
+// shellAliases maps the names shells are known by to their syntax.
+var shellAliases = map[string]string{
+	"sh":         "sh",
+	"bash":       "sh",
+	"zsh":        "sh",
+	"ksh":        "sh",
+	"dash":       "sh",
+	"fish":       "fish",
+	"powershell": "powershell",
+	"pwsh":       "powershell",
+}
+
+// envVarName matches the names that can be used as environment variables in
+// every supported shell.
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func envCmd(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	shell := ctx.String("shell")
+	if shell == "" {
+		shell = filepath.Base(os.Getenv("SHELL"))
+	}
+	syntax, ok := shells[shellAliases[strings.ToLower(shell)]]
+	if !ok {
+		if ctx.String("shell") != "" {
+			return errs.NewUsageExitError("Unknown shell: "+shell, ctx)
+		}
+		syntax = shells["sh"]
+	}
+
+	secrets, _, err := getSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	vars, err := runEnv(secrets, nil, defaultEnvNamer)
+	if err != nil {
+		return errs.NewErrorExitError("Could not name variables.", err)
+	}
+
+	return writeShellEnv(os.Stdout, os.Stderr, syntax, vars, ctx.Bool("unset"))
+}
+
+// writeShellEnv writes a command to set, or unset, each variable. Secrets
+// whose value is unset are always unset. Secrets whose names can't be used as
+// environment variables are skipped, with a warning written to errOut.
+func writeShellEnv(out, errOut io.Writer, syntax shellSyntax, vars []runVar, unset bool) error {
+	for _, v := range vars {
+		if !envVarName.MatchString(v.name) {
+			fmt.Fprintf(errOut, "Skipping %s, which is not a valid environment variable name.\n", v.name)
+			continue
+		}
+
+		line := syntax.unset(v.name)
+		if !unset && !v.unset {
+			line = syntax.set(v.name, v.value)
+		}
+
+		_, err := fmt.Fprintln(out, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// quoteSingle wraps v in single quotes, replacing any single quotes within it
+// with escaped.
+func quoteSingle(v, escaped string) string {
+	return "'" + strings.Replace(v, "'", escaped, -1) + "'"
+}
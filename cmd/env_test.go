@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteShellEnv(t *testing.T) {
+	vars := []runVar{
+		{name: "DATABASE_URL", value: "postgres://db:5432/app"},
+		{name: "MOTD", value: `it's $HOME \o/`},
+		{name: "OLD", unset: true},
+		{name: "BAD-NAME", value: "x"},
+	}
+
+	tcs := []struct {
+		shell string
+		unset bool
+		out   string
+	}{
+		{"sh", false, "export DATABASE_URL='postgres://db:5432/app'\n" +
+			`export MOTD='it'\''s $HOME \o/'` + "\n" +
+			"unset OLD\n"},
+		{"fish", false, "set -gx DATABASE_URL 'postgres://db:5432/app'\n" +
+			`set -gx MOTD 'it\'s $HOME \\o/'` + "\n" +
+			"set -e OLD\n"},
+		{"powershell", false, "$env:DATABASE_URL = 'postgres://db:5432/app'\n" +
+			`$env:MOTD = 'it''s $HOME \o/'` + "\n" +
+			"Remove-Item Env:OLD -ErrorAction SilentlyContinue\n"},
+		{"sh", true, "unset DATABASE_URL\nunset MOTD\nunset OLD\n"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.shell, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			errOut := &bytes.Buffer{}
+			err := writeShellEnv(out, errOut, shells[tc.shell], vars, tc.unset)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			if out.String() != tc.out {
+				t.Errorf("wrong output. got:\n%s\nwant:\n%s", out.String(), tc.out)
+			}
+			if errOut.Len() == 0 {
+				t.Error("expected a warning about BAD-NAME")
+			}
+		})
+	}
+}
@@ -10,10 +10,12 @@ import (
 	"github.com/urfave/cli"
 
 	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
 )
 
 func init() {
@@ -50,6 +52,57 @@ func init() {
 					checkRequiredFlags, listEnvsCmd,
 				),
 			},
+			{
+				Name:      "define",
+				Usage:     "Define a canonical environment name for an organization",
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					orgFlag("org to define the environment for", true),
+					cli.BoolFlag{
+						Name:  "protected",
+						Usage: "Deny wildcard writes to the environment, and confirm all others",
+					},
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, defineEnvCmd,
+				),
+			},
+			{
+				Name:      "undefine",
+				Usage:     "Remove a canonical environment name from an organization",
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					orgFlag("org to remove the environment from", true),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, undefineEnvCmd,
+				),
+			},
+			{
+				Name:      "clone",
+				Usage:     "Copy the secrets in one environment of a project to another",
+				ArgsUsage: "<from> <to>",
+				Flags: []cli.Flag{
+					orgFlag("org the project belongs to", true),
+					projectFlag("project to clone the environment in", true),
+					serviceFlag("Only copy the secrets for this service", "", false),
+					cli.BoolFlag{
+						Name:  "overwrite",
+						Usage: "Replace secrets already set in the destination, rather than skipping them",
+					},
+					cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show the secrets that would be copied, without copying them",
+					},
+					stdAutoAcceptFlag,
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, cloneEnvCmd,
+				),
+			},
 		},
 	}
 	Cmds = append(Cmds, envs)
@@ -112,6 +165,17 @@ func createEnv(ctx *cli.Context) error {
 		return handleSelectError(err, envCreateFailed)
 	}
 
+	if org != nil {
+		settings, err := client.Orgs.GetSettings(c, *org.ID)
+		if err != nil {
+			return errs.NewErrorExitError(envCreateFailed, err)
+		}
+		if len(settings.Environments) > 0 && settings.Environment(environmentName) == nil {
+			return errs.NewExitError("Environment names in " + org.Body.Name +
+				" must be one of: " + strings.Join(definedEnvNames(settings), ", "))
+		}
+	}
+
 	// Create the org now if needed
 	if org == nil && newOrg {
 		org, err = createOrgByName(c, ctx, client, oName)
@@ -182,6 +246,7 @@ func listEnvsCmd(ctx *cli.Context) error {
 		if err != nil {
 			return errs.NewExitError(envListFailed)
 		}
+		projects = activeProjects(projects)
 
 	} else {
 		// Retrieve only a single project by name
@@ -214,6 +279,11 @@ func listEnvsCmd(ctx *cli.Context) error {
 		eMap[ID] = append(eMap[ID], env)
 	}
 
+	settings, err := client.Orgs.GetSettings(c, *org.ID)
+	if err != nil {
+		return errs.NewErrorExitError(envListFailed, err)
+	}
+
 	// Build output of projects/envs
 	fmt.Println("")
 	for projectID, project := range pMap {
@@ -222,7 +292,12 @@ func listEnvsCmd(ctx *cli.Context) error {
 		fmt.Println(title)
 		fmt.Println(strings.Repeat("-", utf8.RuneCountInString(title)))
 		for _, env := range eMap[projectID] {
-			fmt.Println(env.Body.Name)
+			setting := settings.Environment(env.Body.Name)
+			if setting != nil && setting.Protected {
+				fmt.Println(env.Body.Name + " (protected)")
+			} else {
+				fmt.Println(env.Body.Name)
+			}
 		}
 		fmt.Println("")
 	}
@@ -253,3 +328,233 @@ func listEnvs(ctx *context.Context, client *api.Client, orgID, projID *identity.
 
 	return client.Environments.List(c, &orgIDs, &projectIDs, &names)
 }
+
+const envCloneFailed = "Could not clone environment, please try again."
+
+func cloneEnvCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 2 {
+		return errs.NewUsageExitError("The environments to clone from and to are required.", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := client.Orgs.GetByName(c, ctx.String("org"))
+	if err != nil {
+		return errs.NewErrorExitError(envCloneFailed, err)
+	}
+	if org == nil {
+		return errs.NewExitError("Org not found.")
+	}
+
+	projectName := ctx.String("project")
+	projects, err := listProjects(&c, client, org.ID, &projectName)
+	if err != nil {
+		return errs.NewErrorExitError(envCloneFailed, err)
+	}
+	if len(projects) != 1 {
+		return errs.NewExitError("Project not found.")
+	}
+	if projects[0].Body.Archived() {
+		return archivedProjectError(projectName)
+	}
+
+	to := args[1]
+	envs, err := listEnvs(&c, client, org.ID, projects[0].ID, &to)
+	if err != nil {
+		return errs.NewErrorExitError(envCloneFailed, err)
+	}
+	if len(envs) == 0 {
+		return errs.NewExitError("Environment " + to + " not found. Use '" +
+			ctx.App.Name + " envs create " + to + "' to create it.")
+	}
+
+	req := apitypes.EnvironmentCloneRequest{
+		Org:       ctx.String("org"),
+		Project:   projectName,
+		From:      args[0],
+		To:        to,
+		Service:   ctx.String("service"),
+		Overwrite: ctx.Bool("overwrite"),
+		DryRun:    true,
+	}
+
+	plan, err := client.Credentials.Clone(c, &req, false, nil)
+	if err != nil {
+		return errs.NewErrorExitError(envCloneFailed, err)
+	}
+
+	fmt.Println("")
+	printCloneLocations("copy", plan.Cloned)
+	printCloneLocations("skip", plan.Skipped)
+	if plan.Shared > 0 {
+		fmt.Printf("  %d secret(s) are already shared by %s and %s\n", plan.Shared, req.From, req.To)
+	}
+	fmt.Println("")
+
+	if len(plan.Cloned) == 0 {
+		fmt.Printf("There are no secrets to copy from %s to %s.\n", req.From, req.To)
+		if len(plan.Skipped) > 0 {
+			fmt.Println("Use --overwrite to replace the secrets already set.")
+		}
+		return nil
+	}
+
+	if ctx.Bool("dry-run") {
+		fmt.Printf("Dry run, %d secret(s) were not copied.\n", len(plan.Cloned))
+		return nil
+	}
+
+	preamble := fmt.Sprintf("You are about to copy %d secret(s) from %s to %s.",
+		len(plan.Cloned), req.From, req.To)
+	abortErr := ConfirmDialogue(ctx, nil, &preamble, "", true)
+	if abortErr != nil {
+		return abortErr
+	}
+
+	req.DryRun = false
+	clone, err := client.Credentials.Clone(c, &req, false, &progress)
+	if apitypes.IsConfirmationRequiredError(err) {
+		err = confirmProtectedWrite(ctx, err)
+		if err != nil {
+			return err
+		}
+		clone, err = client.Credentials.Clone(c, &req, true, &progress)
+	}
+	if apitypes.IsConflictError(err) {
+		return errs.NewExitError(err.Error() +
+			"\nCheck their new values, and run the command again.")
+	}
+	if err != nil {
+		return errs.NewErrorExitError(envCloneFailed, err)
+	}
+
+	fmt.Printf("\n%d secret(s) have been copied from %s to %s.\n",
+		len(clone.Cloned), req.From, req.To)
+	return nil
+}
+
+// printCloneLocations prints each secret an environment clone acts on.
+func printCloneLocations(action string, locations []apitypes.CredentialLocation) {
+	for _, l := range locations {
+		fmt.Printf("  %s %s/%s\n", action, l.PathExp, l.Name)
+	}
+}
+
+const envDefineFailed = "Could not update environment names, please try again."
+
+func defineEnvCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		return errs.NewUsageExitError("An environment name is required.", ctx)
+	}
+
+	name := args[0]
+	if !pathexp.ValidSlug(name) {
+		return errs.NewExitError("Environment names can only contain lowercase " +
+			"letters, numbers, dashes, and underscores.")
+	}
+
+	return updateEnvSettings(ctx, func(settings *apitypes.OrgSettings) error {
+		setting := settings.Environment(name)
+		if setting == nil {
+			settings.Environments = append(settings.Environments,
+				apitypes.EnvironmentSetting{Name: name})
+			setting = &settings.Environments[len(settings.Environments)-1]
+		}
+
+		setting.Protected = ctx.Bool("protected")
+		return nil
+	})
+}
+
+func undefineEnvCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		return errs.NewUsageExitError("An environment name is required.", ctx)
+	}
+
+	return updateEnvSettings(ctx, func(settings *apitypes.OrgSettings) error {
+		envs := settings.Environments[:0]
+		for _, env := range settings.Environments {
+			if env.Name != args[0] {
+				envs = append(envs, env)
+			}
+		}
+
+		if len(envs) == len(settings.Environments) {
+			return errs.NewExitError("Environment " + args[0] + " is not defined.")
+		}
+
+		settings.Environments = envs
+		return nil
+	})
+}
+
+// updateEnvSettings applies fn to the settings of the org given by the --org
+// flag, saves them, and prints the org's environment names.
+func updateEnvSettings(ctx *cli.Context, fn func(*apitypes.OrgSettings) error) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := client.Orgs.GetByName(c, ctx.String("org"))
+	if err != nil {
+		return errs.NewErrorExitError(envDefineFailed, err)
+	}
+	if org == nil {
+		return errs.NewExitError("Org not found.")
+	}
+
+	settings, err := client.Orgs.GetSettings(c, *org.ID)
+	if err != nil {
+		return errs.NewErrorExitError(envDefineFailed, err)
+	}
+
+	err = fn(settings)
+	if err != nil {
+		return err
+	}
+
+	settings, err = client.Orgs.UpdateSettings(c, *org.ID, settings)
+	if err != nil {
+		return errs.NewErrorExitError(envDefineFailed, err)
+	}
+
+	fmt.Println("")
+	if len(settings.Environments) == 0 {
+		fmt.Printf("Environments in %s may have any name.\n", org.Body.Name)
+		return nil
+	}
+
+	fmt.Printf("Environments in %s must be named one of:\n", org.Body.Name)
+	for _, env := range settings.Environments {
+		if env.Protected {
+			fmt.Printf("  %s (protected)\n", env.Name)
+		} else {
+			fmt.Printf("  %s\n", env.Name)
+		}
+	}
+
+	return nil
+}
+
+// definedEnvNames returns the canonical environment names in settings.
+func definedEnvNames(settings *apitypes.OrgSettings) []string {
+	names := make([]string, len(settings.Environments))
+	for i, env := range settings.Environments {
+		names[i] = env.Name
+	}
+
+	return names
+}
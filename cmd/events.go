@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+
+	"github.com/manifoldco/torus-cli/cmd/output"
+)
+
+func init() {
+	events := cli.Command{
+		Name:     "events",
+		Usage:    "Follow the activity in an organization as it happens",
+		Category: "ORGANIZATIONS",
+		Flags: []cli.Flag{
+			orgFlag("org to follow events for", true),
+			newSlicePlaceholder("type, t", "TYPE",
+				"Only show events of this type, such as invite.approved, credential.changed, or member.added",
+				"", "", false),
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			checkRequiredFlags, eventsCmd,
+		),
+	}
+
+	Cmds = append(Cmds, events)
+}
+
+func eventsCmd(ctx *cli.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	types := make(map[string]bool)
+	for _, t := range ctx.StringSlice("type") {
+		types[t] = true
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	asJSON := output.IsJSON(ctx)
+	enc := json.NewEncoder(os.Stdout)
+	if !asJSON {
+		fmt.Fprintf(os.Stderr, "Following events in %s. Press Ctrl-C to stop.\n", org.Body.Name)
+	}
+
+	err = client.Events.Follow(c, org.ID, func(evt *apitypes.OrgEvent, err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Lost connection to the daemon, reconnecting: %s\n", err)
+			return
+		}
+		if len(types) > 0 && !types[evt.Type] {
+			return
+		}
+
+		if asJSON {
+			enc.Encode(output.NewEvent(evt))
+			return
+		}
+
+		fmt.Printf("%s  %-20s %-16s %s\n", evt.Time.Local().Format(time.RFC3339),
+			evt.Type, evt.Actor, evt.Subject)
+	})
+	if err != nil {
+		return errs.NewErrorExitError("Could not follow org events.", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+func init() {
+	export := cli.Command{
+		Name:      "export",
+		Usage:     "Export secrets for the current service and environment",
+		ArgsUsage: "[file]",
+		Category:  "SECRETS",
+		Flags: append(exportContextFlags,
+			formatFlag("env", "Format used to export data (env, json, yaml, toml)"),
+		),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setUserEnv, checkRequiredFlags, exportCmd,
+		),
+		Subcommands: exportCISubcommands,
+	}
+
+	Cmds = append(Cmds, export)
+}
+
+// exportContextFlags identify the secrets to export.
+var exportContextFlags = []cli.Flag{
+	stdOrgFlag,
+	stdProjectFlag,
+	stdEnvFlag,
+	serviceFlag("Use this service.", "default", true),
+	userFlag("Use this user.", false),
+	machineFlag("Use this machine.", false),
+	stdInstanceFlag,
+}
+
+// exportedSecret is a single secret ready to be written out.
+type exportedSecret struct {
+	name  string
+	value interface{}
+}
+
+type exportWriter func(io.Writer, []exportedSecret) error
+
+var exportWriters = map[string]exportWriter{
+	"env":  writeEnvExport,
+	"json": writeJSONExport,
+	"yaml": writeYAMLExport,
+	"toml": writeTOMLExport,
+}
+
+func exportCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) > 1 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	format := ctx.String("format")
+	writer, ok := exportWriters[format]
+	if !ok {
+		return errs.NewUsageExitError("Unknown format: "+format, ctx)
+	}
+
+	secrets, _, err := getSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	exported, err := exportSecrets(secrets)
+	if err != nil {
+		return errs.NewErrorExitError("Could not export secrets.", err)
+	}
+
+	out := io.Writer(os.Stdout)
+	if len(args) == 1 {
+		f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return errs.NewErrorExitError("Could not create export file.", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	err = writer(out, exported)
+	if err != nil {
+		return errs.NewErrorExitError("Could not export secrets.", err)
+	}
+
+	if len(args) == 1 {
+		fmt.Fprintf(os.Stderr, "Exported %d secrets to %s\n", len(exported), args[0])
+	}
+
+	return nil
+}
+
+// exportSecrets returns the raw values of the given secrets, sorted by name.
+func exportSecrets(secrets []apitypes.CredentialEnvelope) ([]exportedSecret, error) {
+	exported := make([]exportedSecret, 0, len(secrets))
+	for _, secret := range secrets {
+		cv := (*secret.Body).GetValue()
+		if cv.IsBinary() {
+			name := (*secret.Body).GetName()
+			return nil, fmt.Errorf("secret %s holds binary data, and can't be exported; "+
+				"use 'torus view %s --out FILE' to write it to a file", name, name)
+		}
+
+		value, err := cv.Raw()
+		if err != nil {
+			return nil, err
+		}
+
+		exported = append(exported, exportedSecret{
+			name:  (*secret.Body).GetName(),
+			value: value,
+		})
+	}
+
+	sort.Sort(exportedSecrets(exported))
+	return exported, nil
+}
+
+type exportedSecrets []exportedSecret
+
+func (e exportedSecrets) Len() int           { return len(e) }
+func (e exportedSecrets) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e exportedSecrets) Less(i, j int) bool { return e[i].name < e[j].name }
+
+// quoteValue returns the JSON representation of a raw secret value. Strings
+// are double quoted and escaped in a way that is also valid in YAML and TOML.
+func quoteValue(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+var bareEnvValue = regexp.MustCompile(`^[^\s"'\\#$` + "`" + `]*$`)
+
+// writeEnvExport writes secrets as an env file. Names are upper cased, and
+// values are only quoted when they need to be.
+func writeEnvExport(w io.Writer, secrets []exportedSecret) error {
+	for _, s := range secrets {
+		value := fmt.Sprint(s.value)
+		if !bareEnvValue.MatchString(value) {
+			value = strconv.Quote(value)
+		}
+
+		_, err := fmt.Fprintf(w, "%s=%s\n", strings.ToUpper(s.name), value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeJSONExport(w io.Writer, secrets []exportedSecret) error {
+	keyMap := make(map[string]interface{}, len(secrets))
+	for _, s := range secrets {
+		keyMap[s.name] = s.value
+	}
+
+	b, err := json.MarshalIndent(keyMap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
+
+var bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// writeYAMLExport writes secrets as a YAML mapping. Keys are always quoted, so
+// names like "yes" or "null" are not read back as other types.
+func writeYAMLExport(w io.Writer, secrets []exportedSecret) error {
+	return writeKeyValues(w, secrets, "%s: %s\n", func(string) bool { return false })
+}
+
+func writeTOMLExport(w io.Writer, secrets []exportedSecret) error {
+	return writeKeyValues(w, secrets, "%s = %s\n", bareTOMLKey.MatchString)
+}
+
+// writeKeyValues writes each secret on its own line using the given format.
+// Keys are quoted unless bare returns true for them.
+func writeKeyValues(w io.Writer, secrets []exportedSecret, format string,
+	bare func(string) bool) error {
+
+	for _, s := range secrets {
+		key := s.name
+		if !bare(key) {
+			var err error
+			key, err = quoteValue(key)
+			if err != nil {
+				return err
+			}
+		}
+
+		value, err := quoteValue(s.value)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, format, key, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
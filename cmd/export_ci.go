@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/ci"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+// exportCISubcommands sync secrets into the variable stores of CI providers.
+var exportCISubcommands = []cli.Command{
+	{
+		Name:      "gh-actions",
+		Usage:     "Sync secrets into a GitHub repository's Actions secrets",
+		ArgsUsage: "[name...]",
+		Flags: append(exportContextFlags,
+			newPlaceholder("repo", "OWNER/REPO", "Sync secrets into this repository.",
+				"", "", true),
+			stdAutoAcceptFlag,
+		),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setUserEnv, checkRequiredFlags, exportGitHubCmd,
+		),
+	},
+	{
+		Name:      "gitlab",
+		Usage:     "Sync secrets into a GitLab project's CI/CD variables",
+		ArgsUsage: "[name...]",
+		Flags: append(exportContextFlags,
+			newPlaceholder("repo", "GROUP/PROJECT", "Sync secrets into this GitLab project.",
+				"", "", true),
+			newPlaceholder("gitlab-url", "URL", "Address of the GitLab instance.",
+				ci.GitLabURL, "GITLAB_URL", false),
+			stdAutoAcceptFlag,
+		),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setUserEnv, checkRequiredFlags, exportGitLabCmd,
+		),
+	},
+}
+
+func exportGitHubCmd(ctx *cli.Context) error {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return errs.NewExitError("GITHUB_TOKEN must be set to a token that can manage the repository's secrets.")
+	}
+
+	return syncCISecrets(ctx, ci.NewGitHub(ctx.String("repo"), token), isGitHubSecretName)
+}
+
+func exportGitLabCmd(ctx *cli.Context) error {
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		return errs.NewExitError("GITLAB_TOKEN must be set to a token that can manage the project's variables.")
+	}
+
+	store := ci.NewGitLab(ctx.String("gitlab-url"), ctx.String("repo"), token)
+	return syncCISecrets(ctx, store, ciVariableName.MatchString)
+}
+
+var ciVariableName = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// isGitHubSecretName returns whether name may be used for an Actions secret.
+func isGitHubSecretName(name string) bool {
+	return ciVariableName.MatchString(name) && !strings.HasPrefix(name, "GITHUB_")
+}
+
+// syncCISecrets makes the variables in store match the secrets in the current
+// context, or only the secrets named in the command's arguments if any are
+// given. Secret names are upper cased, as they are for env files.
+func syncCISecrets(ctx *cli.Context, store ci.Store, validName func(string) bool) error {
+	secrets, path, err := getSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	selected := len(ctx.Args()) > 0
+	if selected {
+		secrets, err = selectCISecrets(secrets, ctx.Args(), path)
+		if err != nil {
+			return err
+		}
+	}
+
+	desired, err := ciVariables(secrets, validName)
+	if err != nil {
+		return err
+	}
+
+	c := context.Background()
+	existing, err := store.List(c)
+	if err != nil {
+		return errs.NewErrorExitError("Could not list CI variables.", err)
+	}
+
+	// Variables for secrets that weren't selected are left alone.
+	if selected {
+		for name := range existing {
+			if _, ok := desired[name]; !ok {
+				delete(existing, name)
+			}
+		}
+	}
+
+	plan := ci.NewPlan(existing, desired)
+	if plan.Empty() {
+		fmt.Printf("%s is up to date.\n", ctx.String("repo"))
+		return nil
+	}
+
+	fmt.Println("")
+	printCIChanges("create", plan.Create)
+	printCIChanges("update", plan.Update)
+	printCIChanges("delete", plan.Delete)
+	fmt.Println("")
+
+	if len(plan.Delete) > 0 {
+		preamble := fmt.Sprintf("%d variables in %s are not set in Torus, and will be deleted.",
+			len(plan.Delete), ctx.String("repo"))
+		abortErr := ConfirmDialogue(ctx, nil, &preamble, "", true)
+		if abortErr != nil {
+			return abortErr
+		}
+	}
+
+	err = plan.Apply(c, store, desired)
+	if err != nil {
+		return errs.NewErrorExitError("Could not sync CI variables.", err)
+	}
+
+	fmt.Printf("%s is now in sync with Torus.\n", ctx.String("repo"))
+	return nil
+}
+
+// selectCISecrets returns the secrets with the given names. It's an error for
+// any of them not to be set at path.
+func selectCISecrets(secrets []apitypes.CredentialEnvelope, names []string,
+	path string) ([]apitypes.CredentialEnvelope, error) {
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	selected := make([]apitypes.CredentialEnvelope, 0, len(names))
+	for _, secret := range secrets {
+		name := (*secret.Body).GetName()
+		if wanted[name] {
+			selected = append(selected, secret)
+			delete(wanted, name)
+		}
+	}
+
+	if len(wanted) > 0 {
+		missing := make([]string, 0, len(wanted))
+		for name := range wanted {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return nil, errs.NewExitError("Secrets not set for " + path + ": " + strings.Join(missing, ", "))
+	}
+
+	return selected, nil
+}
+
+// ciVariables returns the CI variable names and values of secrets.
+func ciVariables(secrets []apitypes.CredentialEnvelope, validName func(string) bool) (map[string]string, error) {
+	exported, err := exportSecrets(secrets)
+	if err != nil {
+		return nil, errs.NewErrorExitError("Could not export secrets.", err)
+	}
+
+	vars := make(map[string]string, len(exported))
+	for _, s := range exported {
+		name := strings.ToUpper(s.name)
+		if !validName(name) {
+			return nil, errs.NewExitError("Secret " + s.name + " cannot be used as a CI variable name.")
+		}
+		vars[name] = fmt.Sprint(s.value)
+	}
+
+	return vars, nil
+}
+
+func printCIChanges(action string, names []string) {
+	for _, name := range names {
+		fmt.Printf("  %s %s\n", action, name)
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestExportWriters(t *testing.T) {
+	secrets := []exportedSecret{
+		{name: "count", value: 3},
+		{name: "database_url", value: "postgres://db:5432/app"},
+		{name: "motd", value: "hello \"world\"\n"},
+		{name: "yes", value: 1.5},
+	}
+
+	tcs := []struct {
+		format string
+		out    string
+	}{
+		{"env", "COUNT=3\n" +
+			"DATABASE_URL=postgres://db:5432/app\n" +
+			"MOTD=\"hello \\\"world\\\"\\n\"\n" +
+			"YES=1.5\n"},
+		{"json", "{\n" +
+			"  \"count\": 3,\n" +
+			"  \"database_url\": \"postgres://db:5432/app\",\n" +
+			"  \"motd\": \"hello \\\"world\\\"\\n\",\n" +
+			"  \"yes\": 1.5\n" +
+			"}\n"},
+		{"yaml", "\"count\": 3\n" +
+			"\"database_url\": \"postgres://db:5432/app\"\n" +
+			"\"motd\": \"hello \\\"world\\\"\\n\"\n" +
+			"\"yes\": 1.5\n"},
+		{"toml", "count = 3\n" +
+			"database_url = \"postgres://db:5432/app\"\n" +
+			"motd = \"hello \\\"world\\\"\\n\"\n" +
+			"yes = 1.5\n"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := exportWriters[tc.format](buf, secrets)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			if buf.String() != tc.out {
+				t.Errorf("wrong output. got:\n%s\nwant:\n%s", buf.String(), tc.out)
+			}
+		})
+	}
+}
@@ -23,6 +23,11 @@ var (
 		Name:  "yes, y",
 		Usage: "Automatically accept confirmation dialogues.",
 	}
+
+	stdOfflineFlag = cli.BoolFlag{
+		Name:  "offline",
+		Usage: "Use the secrets cached by the daemon, without contacting the registry.",
+	}
 )
 
 func formatFlag(defaultValue, description string) cli.Flag {
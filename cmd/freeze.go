@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func init() {
+	freeze := cli.Command{
+		Name:      "freeze",
+		Usage:     "Block changes to, and reads of, the secrets in matching keyrings during an incident",
+		ArgsUsage: "<path>",
+		Category:  "ACCESS CONTROL",
+		Flags: []cli.Flag{
+			newPlaceholder("reason", "REASON", "Why the keyrings are being frozen", "", "", true),
+			stdAutoAcceptFlag,
+		},
+		Action: chain(ensureDaemon, ensureSession, checkRequiredFlags, freezeCmd),
+	}
+
+	unfreeze := cli.Command{
+		Name:      "unfreeze",
+		Usage:     "Lift the freeze on matching keyrings",
+		ArgsUsage: "<path>",
+		Category:  "ACCESS CONTROL",
+		Flags: []cli.Flag{
+			stdAutoAcceptFlag,
+		},
+		Action: chain(ensureDaemon, ensureSession, unfreezeCmd),
+	}
+
+	Cmds = append(Cmds, freeze, unfreeze)
+}
+
+func freezeCmd(ctx *cli.Context) error {
+	pe, err := freezePathArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	preamble := fmt.Sprintf("You are about to freeze every keyring matching %s. Their "+
+		"secrets can't be changed until they are unfrozen, and can only be read by org "+
+		"owners using --break-glass.", pe)
+	abortErr := ConfirmDialogue(ctx, nil, &preamble, "", true)
+	if abortErr != nil {
+		return abortErr
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, pe.Org.String())
+	if err != nil {
+		return err
+	}
+
+	result, err := client.Keyrings.Freeze(c, org.ID, pe.String(), ctx.String("reason"))
+	if err != nil {
+		return errs.NewErrorExitError("Could not freeze keyrings.", err)
+	}
+
+	fmt.Printf("\n%d keyrings matching %s frozen. Lift the freeze with 'torus unfreeze %s'.\n",
+		result.Keyrings, pe, pe)
+	return nil
+}
+
+func unfreezeCmd(ctx *cli.Context) error {
+	pe, err := freezePathArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	preamble := fmt.Sprintf("You are about to unfreeze every keyring matching %s.", pe)
+	abortErr := ConfirmDialogue(ctx, nil, &preamble, "", true)
+	if abortErr != nil {
+		return abortErr
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, pe.Org.String())
+	if err != nil {
+		return err
+	}
+
+	result, err := client.Keyrings.Unfreeze(c, org.ID, pe.String())
+	if err != nil {
+		return errs.NewErrorExitError("Could not unfreeze keyrings.", err)
+	}
+
+	fmt.Printf("\n%d keyrings matching %s unfrozen.\n", result.Keyrings, pe)
+	return nil
+}
+
+// freezePathArg returns the path expression given to freeze or unfreeze.
+func freezePathArg(ctx *cli.Context) (*pathexp.PathExp, error) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "A path is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return nil, errs.NewUsageExitError(msg, ctx)
+	}
+
+	pe, err := pathexp.Parse(args[0])
+	if err != nil {
+		return nil, errs.NewUsageExitError("Invalid path: "+err.Error(), ctx)
+	}
+
+	return pe, nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/gatekeeper"
+)
+
+func init() {
+	gk := cli.Command{
+		Name:     "gatekeeper",
+		Usage:    "Issue machine credentials to cloud instances that prove their identity",
+		Category: "ORGANIZATIONS",
+		Flags: []cli.Flag{
+			orgFlag("Org to create machines in", true),
+			newPlaceholder("constraints", "FILE",
+				"JSON file of accounts, regions and roles instances may bootstrap into",
+				"", "TORUS_GATEKEEPER_CONSTRAINTS", true),
+			newPlaceholder("url", "URL",
+				"URL instances reach this gatekeeper on", "",
+				"TORUS_GATEKEEPER_URL", true),
+			newPlaceholder("listen", "ADDR", "Address to listen on", ":4500",
+				"TORUS_GATEKEEPER_LISTEN", false),
+			newPlaceholder("tls-cert", "FILE", "PEM encoded TLS certificate to serve with", "",
+				"TORUS_GATEKEEPER_TLS_CERT", false),
+			newPlaceholder("tls-key", "FILE", "PEM encoded private key of the TLS certificate", "",
+				"TORUS_GATEKEEPER_TLS_KEY", false),
+			cli.BoolFlag{
+				Name:  "insecure",
+				Usage: "Serve machine credentials over plain HTTP, without TLS",
+			},
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			checkRequiredFlags, gatekeeperCmd,
+		),
+	}
+
+	Cmds = append(Cmds, gk)
+}
+
+func gatekeeperCmd(ctx *cli.Context) error {
+	certFile := ctx.String("tls-cert")
+	keyFile := ctx.String("tls-key")
+	if (certFile == "") != (keyFile == "") {
+		return errs.NewUsageExitError("--tls-cert and --tls-key must be set together", ctx)
+	}
+	if certFile == "" && !ctx.Bool("insecure") {
+		return errs.NewUsageExitError("The gatekeeper returns machine token secrets, "+
+			"so it must be served with --tls-cert and --tls-key, unless --insecure is set", ctx)
+	}
+
+	constraints, err := gatekeeper.LoadConstraints(ctx.String("constraints"))
+	if err != nil {
+		return errs.NewErrorExitError("Could not load constraints.", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	g, err := gatekeeper.New(client, org, ctx.String("url"), constraints)
+	if err != nil {
+		return errs.NewErrorExitError("Could not start gatekeeper.", err)
+	}
+
+	fmt.Printf("Gatekeeper for the %s org listening on %s\n", org.Body.Name,
+		ctx.String("listen"))
+
+	if certFile != "" {
+		err = http.ListenAndServeTLS(ctx.String("listen"), certFile, keyFile, g)
+	} else {
+		fmt.Println("Warning: serving machine credentials without TLS.")
+		err = http.ListenAndServe(ctx.String("listen"), g)
+	}
+	if err != nil {
+		return errs.NewErrorExitError("Gatekeeper stopped.", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,323 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/hints"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func init() {
+	guests := cli.Command{
+		Name:     "guests",
+		Usage:    "Give outside collaborators read access to a single environment",
+		Category: "ACCESS CONTROL",
+		Subcommands: []cli.Command{
+			{
+				Name:      "add",
+				Usage:     "Invite a guest with read-only access to one project environment",
+				ArgsUsage: "<email>",
+				Flags: []cli.Flag{
+					stdOrgFlag,
+					stdProjectFlag,
+					envFlag("Environment the guest can read", true),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, guestsAddCmd,
+				),
+			},
+			{
+				Name:      "remove",
+				Usage:     "Remove a guest's access to a project environment",
+				ArgsUsage: "<username>",
+				Flags: []cli.Flag{
+					stdOrgFlag,
+					stdProjectFlag,
+					envFlag("Environment the guest can read", true),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, guestsRemoveCmd,
+				),
+			},
+		},
+	}
+
+	Cmds = append(Cmds, guests)
+}
+
+const guestAddFailed = "Could not add guest, please try again."
+const guestRemoveFailed = "Could not remove guest, please try again."
+
+// guestTeamName returns the name of the team, and its policy, that holds the
+// guests of the given project environment.
+func guestTeamName(project, env string) (string, error) {
+	name := primitive.GuestTeamPrefix + project + "-" + env
+	if !pathexp.ValidSlug(name) {
+		return "", errs.NewExitError(
+			"Project and environment names are too long to create a guest team.")
+	}
+
+	return name, nil
+}
+
+func guestsAddCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) < 1 || args[0] == "" {
+		return errs.NewUsageExitError("Missing email", ctx)
+	}
+	if len(args) > 1 {
+		return errs.NewUsageExitError("Too many arguments", ctx)
+	}
+	email := args[0]
+
+	orgName := ctx.String("org")
+	projectName := ctx.String("project")
+	envName := ctx.String("environment")
+
+	name, err := guestTeamName(projectName, envName)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return errs.NewErrorExitError(guestAddFailed, err)
+	}
+
+	org, err := client.Orgs.GetByName(c, orgName)
+	if err != nil {
+		return errs.NewErrorExitError(guestAddFailed, err)
+	}
+	if org == nil {
+		return errs.NewExitError("Org not found.")
+	}
+
+	projects, err := client.Projects.List(c, &[]*identity.ID{org.ID}, &[]string{projectName})
+	if err != nil {
+		return errs.NewErrorExitError(guestAddFailed, err)
+	}
+	if len(projects) < 1 {
+		return errs.NewExitError("Project not found.")
+	}
+
+	envs, err := client.Environments.List(c, &[]*identity.ID{org.ID},
+		&[]*identity.ID{projects[0].ID}, &[]string{envName})
+	if err != nil {
+		return errs.NewErrorExitError(guestAddFailed, err)
+	}
+	if len(envs) < 1 {
+		return errs.NewExitError("Environment not found.")
+	}
+
+	team, err := ensureGuestTeam(c, client, org, name)
+	if err != nil {
+		return err
+	}
+
+	err = ensureGuestPolicy(c, client, org, team, name, projectName, envName)
+	if err != nil {
+		return err
+	}
+
+	err = client.Invites.Send(c, email, *org.ID, *session.ID(), nil, []identity.ID{*team.ID})
+	if err != nil {
+		if strings.Contains(err.Error(), "resource exists") {
+			return errs.NewExitError(email + " has already been invited to the " + org.Body.Name + " org")
+		}
+		return errs.NewErrorExitError(guestAddFailed, err)
+	}
+
+	fmt.Printf("Invitation to join the %s organization as a guest has been sent to %s.\n",
+		org.Body.Name, email)
+	fmt.Printf("\nOnce approved, they will only be able to read secrets in %s/%s.\n",
+		projectName, envName)
+
+	hints.Display([]string{"invites approve", "guests remove"})
+	return nil
+}
+
+// ensureGuestTeam returns the guest team with the given name, creating it if
+// it does not exist.
+func ensureGuestTeam(c context.Context, client *api.Client, org *envelope.Org,
+	name string) (*envelope.Team, error) {
+
+	teams, err := client.Teams.GetByName(c, org.ID, name)
+	if err != nil {
+		return nil, errs.NewErrorExitError(guestAddFailed, err)
+	}
+	if len(teams) > 0 {
+		return &teams[0], nil
+	}
+
+	team, err := client.Teams.Create(c, org.ID, name, primitive.UserTeamType)
+	if err != nil {
+		return nil, errs.NewErrorExitError("Could not create guest team.", err)
+	}
+
+	return team, nil
+}
+
+// ensureGuestPolicy makes sure the guest team has a policy attached that
+// denies access everywhere in the org, except for reading the secrets of the
+// given project environment.
+func ensureGuestPolicy(c context.Context, client *api.Client, org *envelope.Org,
+	team *envelope.Team, name, project, env string) error {
+
+	policies, err := client.Policies.List(c, org.ID, name)
+	if err != nil {
+		return errs.NewErrorExitError(guestAddFailed, err)
+	}
+
+	var policyID *identity.ID
+	if len(policies) > 0 {
+		policyID = policies[0].ID
+	} else {
+		allActions := primitive.PolicyAction(primitive.PolicyActionCreate |
+			primitive.PolicyActionRead | primitive.PolicyActionUpdate |
+			primitive.PolicyActionDelete | primitive.PolicyActionList)
+
+		policy := primitive.Policy{
+			PolicyType: "user",
+			OrgID:      org.ID,
+		}
+		policy.Policy.Name = name
+		policy.Policy.Description = "Guest read access to " + project + "/" + env
+		policy.Policy.Statements = []primitive.PolicyStatement{
+			{
+				Effect:   primitive.PolicyEffectDeny,
+				Action:   allActions,
+				Resource: "/" + org.Body.Name + "/*/*/*/*/*/*",
+			},
+			{
+				Effect:   primitive.PolicyEffectAllow,
+				Action:   primitive.PolicyActionRead | primitive.PolicyActionList,
+				Resource: "/" + org.Body.Name + "/" + project + "/" + env + "/*/*/*/*",
+			},
+		}
+
+		var res *envelope.Policy
+		err := withMFA(c, func(c context.Context) error {
+			var err error
+			res, err = client.Policies.Create(c, &policy)
+			return err
+		})
+		if err != nil {
+			return errs.NewErrorExitError("Could not create guest policy.", err)
+		}
+		policyID = res.ID
+	}
+
+	attachments, err := client.Policies.AttachmentsList(c, org.ID, team.ID, policyID)
+	if err != nil {
+		return errs.NewErrorExitError(guestAddFailed, err)
+	}
+	if len(attachments) > 0 {
+		return nil
+	}
+
+	err = withMFA(c, func(c context.Context) error {
+		return client.Policies.Attach(c, org.ID, policyID, team.ID)
+	})
+	if err != nil {
+		return errs.NewErrorExitError("Could not attach guest policy.", err)
+	}
+
+	return nil
+}
+
+func guestsRemoveCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) < 1 || args[0] == "" {
+		return errs.NewUsageExitError("Missing username", ctx)
+	}
+	if len(args) > 1 {
+		return errs.NewUsageExitError("Too many arguments", ctx)
+	}
+	username := args[0]
+
+	name, err := guestTeamName(ctx.String("project"), ctx.String("environment"))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := client.Orgs.GetByName(c, ctx.String("org"))
+	if err != nil {
+		return errs.NewErrorExitError(guestRemoveFailed, err)
+	}
+	if org == nil {
+		return errs.NewExitError("Org not found.")
+	}
+
+	user, err := client.Profiles.ListByName(c, username)
+	if err != nil || user == nil {
+		return errs.NewExitError("User not found.")
+	}
+
+	teams, err := client.Teams.GetByName(c, org.ID, name)
+	if err != nil {
+		return errs.NewErrorExitError(guestRemoveFailed, err)
+	}
+	if len(teams) < 1 {
+		return errs.NewExitError("No guests found for this environment.")
+	}
+
+	memberships, err := client.Memberships.List(c, org.ID, user.ID, teams[0].ID)
+	if err != nil {
+		return errs.NewErrorExitError(guestRemoveFailed, err)
+	}
+	if len(memberships) < 1 {
+		return errs.NewExitError(username + " is not a guest of this environment.")
+	}
+
+	err = client.Memberships.Delete(c, memberships[0].ID)
+	if err != nil {
+		return errs.NewErrorExitError(guestRemoveFailed, err)
+	}
+
+	// If this was the guest's last team, they no longer have a reason to be
+	// in the org at all.
+	remaining, err := client.Memberships.List(c, org.ID, user.ID, nil)
+	if err != nil {
+		return errs.NewErrorExitError(guestRemoveFailed, err)
+	}
+	if len(remaining) == 0 {
+		err = client.Orgs.RemoveMember(c, *org.ID, *user.ID)
+		if err != nil {
+			return errs.NewErrorExitError("Guest removed from team, but could not be removed from org.", err)
+		}
+	}
+
+	fmt.Printf("%s no longer has guest access to %s/%s.\n", username,
+		ctx.String("project"), ctx.String("environment"))
+
+	hints.Display([]string{"worklog"})
+	return nil
+}
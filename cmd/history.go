@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/hints"
+
+	"github.com/manifoldco/torus-cli/cmd/output"
+)
+
+func init() {
+	history := cli.Command{
+		Name:      "history",
+		Usage:     "List the previous values of a secret",
+		ArgsUsage: "<name|path>",
+		Category:  "SECRETS",
+		Flags:     credentialPathFlags,
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setSliceDefaults, historyCmd,
+		),
+	}
+
+	rollback := cli.Command{
+		Name:      "rollback",
+		Usage:     "Restore a secret to one of its previous values",
+		ArgsUsage: "<name|path>",
+		Category:  "SECRETS",
+		Flags: append(credentialPathFlags,
+			cli.IntFlag{
+				Name:  "version",
+				Usage: "Restore this version of the secret, as listed by history.",
+			},
+			forceSetFlag,
+			stdAutoAcceptFlag,
+		),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setSliceDefaults, rollbackCmd,
+		),
+	}
+
+	Cmds = append(Cmds, history, rollback)
+}
+
+const credentialHistoryFailed = "Could not retrieve secret history."
+
+func historyCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "Name or path is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	pe, name, creds, err := credentialHistory(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	if output.IsJSON(ctx) {
+		records, err := output.NewCredentials(creds)
+		if err != nil {
+			return err
+		}
+		return output.Write(os.Stdout, records)
+	}
+
+	if len(creds) == 0 {
+		fmt.Printf("No history found for %s/%s.\n", pe, name)
+		return nil
+	}
+
+	fmt.Println("")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, tableHeader("VERSION", "VALUE"))
+	fmt.Fprintln(w, " \t ")
+	for i, cred := range creds {
+		body := *cred.Body
+
+		version := fmt.Sprintf("%d", body.GetCredentialVersion())
+		if i == 0 {
+			version += "*"
+		}
+
+		value := "unset"
+		if v := body.GetValue(); v != nil {
+			value = v.String()
+		}
+
+		fmt.Fprintf(w, "%s\t%s\n", version, value)
+	}
+	w.Flush()
+
+	fmt.Printf("\nHistory of %s/%s. The current version is marked with *.\n", pe, name)
+
+	hints.Display([]string{"rollback"})
+	return nil
+}
+
+func rollbackCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "Name or path is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	version := ctx.Int("version")
+	if version <= 0 {
+		return errs.NewUsageExitError("A --version to restore is required.", ctx)
+	}
+
+	pe, name, creds, err := credentialHistory(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	value, err := findCredentialVersion(creds, version)
+	if err != nil {
+		return err
+	}
+
+	preamble := fmt.Sprintf("You are about to set \"%s/%s\" back to version %d.",
+		pe, name, version)
+	abortErr := ConfirmDialogue(ctx, nil, &preamble, "", true)
+	if abortErr != nil {
+		return abortErr
+	}
+
+	cred, err := setCredential(ctx, args[0], func() *apitypes.CredentialValue {
+		return value
+	}, nil)
+	if err != nil {
+		return errs.NewErrorExitError("Could not roll back credential.", err)
+	}
+
+	body := *cred.Body
+	fmt.Printf("\nCredential %s has been rolled back to version %d at %s/%s\n",
+		body.GetName(), version, body.GetPathExp(), body.GetName())
+
+	hints.Display([]string{"history", "view"})
+	return nil
+}
+
+// credentialHistory returns the history of the secret identified by
+// nameOrPath, newest first.
+func credentialHistory(ctx *cli.Context, nameOrPath string) (string, string, []apitypes.CredentialEnvelope, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	pe, credName, err := determineCredential(ctx, nameOrPath)
+	if err != nil {
+		return "", "", nil, err
+	}
+	name := strings.ToLower(*credName)
+
+	client := api.NewClient(cfg)
+	creds, err := client.Credentials.History(context.Background(), pe.String(), name)
+	if err != nil {
+		return "", "", nil, errs.NewErrorExitError(credentialHistoryFailed, err)
+	}
+
+	return pe.String(), name, creds, nil
+}
+
+// findCredentialVersion returns the value of the given version of a secret,
+// from its history. It errors if that value can't be restored.
+func findCredentialVersion(creds []apitypes.CredentialEnvelope, version int) (*apitypes.CredentialValue, error) {
+	for i, cred := range creds {
+		body := *cred.Body
+		if body.GetCredentialVersion() != version {
+			continue
+		}
+
+		if i == 0 {
+			msg := fmt.Sprintf("Version %d is already the current version.", version)
+			return nil, errs.NewExitError(msg)
+		}
+
+		value := body.GetValue()
+		if value == nil {
+			msg := fmt.Sprintf("Version %d was unset. Use `torus unset` instead.", version)
+			return nil, errs.NewExitError(msg)
+		}
+
+		return value, nil
+	}
+
+	msg := fmt.Sprintf("Version %d not found. Use `torus history` to list versions.", version)
+	return nil, errs.NewExitError(msg)
+}
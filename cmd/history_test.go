@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+func TestFindCredentialVersion(t *testing.T) {
+	cred := func(version int, value *apitypes.CredentialValue) apitypes.CredentialEnvelope {
+		state := "set"
+		if value.IsUnset() {
+			state = "unset"
+		}
+
+		var body apitypes.Credential = &apitypes.CredentialV2{
+			BaseCredential: apitypes.BaseCredential{
+				Name:              "password",
+				Value:             value,
+				CredentialVersion: version,
+			},
+			State: state,
+		}
+		return apitypes.CredentialEnvelope{Version: 2, Body: &body}
+	}
+
+	creds := []apitypes.CredentialEnvelope{
+		cred(3, apitypes.NewStringCredentialValue("new")),
+		cred(2, apitypes.NewUnsetCredentialValue()),
+		cred(1, apitypes.NewStringCredentialValue("old")),
+	}
+
+	t.Run("previous version", func(t *testing.T) {
+		value, err := findCredentialVersion(creds, 1)
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+		if value.String() != "old" {
+			t.Errorf("wrong value. got %q want %q", value.String(), "old")
+		}
+	})
+
+	for _, version := range []int{2, 3, 4} {
+		if _, err := findCredentialVersion(creds, version); err == nil {
+			t.Errorf("expected an error restoring version %d", version)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/hints"
+)
+
+func init() {
+	imp := cli.Command{
+		Name:      "import",
+		Usage:     "Set many secrets at once from an env, json, or yaml file",
+		ArgsUsage: "<file>",
+		Category:  "SECRETS",
+		Flags: append(credentialPathFlags,
+			newPlaceholder("format", "FORMAT",
+				"Format of the file (env, json, yaml). Detected from the file extension by default.",
+				"", "", false),
+			forceSetFlag,
+		),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setSliceDefaults, importCmd,
+		),
+	}
+
+	Cmds = append(Cmds, imp)
+}
+
+// importedSecret is a single secret read from an import file.
+type importedSecret struct {
+	name  string
+	value *apitypes.CredentialValue
+}
+
+type importReader func(io.Reader) ([]importedSecret, error)
+
+var importReaders = map[string]importReader{
+	"env":  readEnvImport,
+	"json": readJSONImport,
+	"yaml": readYAMLImport,
+}
+
+var importExtensions = map[string]string{
+	".env":  "env",
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+}
+
+const importFailed = "Could not import secrets."
+
+func importCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "A file to import is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	format := ctx.String("format")
+	if format == "" {
+		format = importExtensions[strings.ToLower(filepath.Ext(args[0]))]
+		if format == "" {
+			return errs.NewUsageExitError("Could not detect the file format. Use --format.", ctx)
+		}
+	}
+
+	reader, ok := importReaders[format]
+	if !ok {
+		return errs.NewUsageExitError("Unknown format: "+format, ctx)
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return errs.NewErrorExitError("Could not open import file.", err)
+	}
+	defer f.Close()
+
+	secrets, err := readImport(reader, f)
+	if err != nil {
+		return errs.NewErrorExitError("Could not read "+args[0]+".", err)
+	}
+
+	if len(secrets) == 0 {
+		return errs.NewExitError("No secrets found in " + args[0] + ".")
+	}
+
+	rules, err := loadRules()
+	if err != nil {
+		return errs.NewErrorExitError("Could not load rules.", err)
+	}
+
+	var broken []string
+	for _, s := range secrets {
+		if problems := rules.Check(s.name, s.value.String()); len(problems) > 0 {
+			broken = append(broken, s.name+": "+describeRuleProblems(problems))
+		}
+	}
+	if len(broken) > 0 {
+		return errs.NewExitError("Some secrets in " + args[0] + " break the rules in .torus.json:\n  " +
+			strings.Join(broken, "\n  "))
+	}
+
+	pe, err := determinePathExp(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, project, err := credentialOwners(c, client, pe)
+	if err != nil {
+		return err
+	}
+
+	creds := make([]apitypes.Credential, len(secrets))
+	for i, s := range secrets {
+		creds[i] = newCredential(org.ID, project.ID, pe, s.name, s.value)
+	}
+	if !ctx.Bool("force") {
+		locations, err := client.Credentials.SearchNames(c, org.ID, "*", false, pe.String(), true)
+		if err != nil {
+			return errs.NewErrorExitError("Could not find the secrets already set.", err)
+		}
+		expectVersions(creds, locations)
+	}
+
+	_, err = client.Credentials.CreateBatch(c, creds, ctx.Bool("force"), false, &progress)
+	if apitypes.IsConfirmationRequiredError(err) {
+		err = confirmProtectedWrite(ctx, err)
+		if err != nil {
+			return err
+		}
+		_, err = client.Credentials.CreateBatch(c, creds, ctx.Bool("force"), true, &progress)
+	}
+	if apitypes.IsConflictError(err) {
+		return errs.NewExitError(err.Error() +
+			"\nCheck their new values, and run the command again, or use --force to overwrite them.")
+	}
+	if err != nil {
+		return errs.NewErrorExitError(importFailed, err)
+	}
+
+	fmt.Printf("\n%d secrets have been set at %s\n", len(secrets), pe)
+
+	hints.Display([]string{"view", "run"})
+	return nil
+}
+
+// readImport reads secrets using the given reader, and checks that their
+// names are usable. Names are lower cased, and the result is sorted by name.
+func readImport(reader importReader, r io.Reader) ([]importedSecret, error) {
+	secrets, err := reader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(secrets))
+	for i, s := range secrets {
+		name := strings.ToLower(s.name)
+		if name == "" || strings.ContainsAny(name, "/* \t") {
+			return nil, fmt.Errorf("invalid secret name %q", s.name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("secret %s is set more than once", name)
+		}
+
+		seen[name] = true
+		secrets[i].name = name
+	}
+
+	sort.Sort(importedSecrets(secrets))
+	return secrets, nil
+}
+
+type importedSecrets []importedSecret
+
+func (s importedSecrets) Len() int           { return len(s) }
+func (s importedSecrets) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s importedSecrets) Less(i, j int) bool { return s[i].name < s[j].name }
+
+// readEnvImport reads an env file of NAME=value lines, as written by
+// `torus export`. Values are always strings.
+func readEnvImport(r io.Reader) ([]importedSecret, error) {
+	secrets := []importedSecret{}
+	err := scanLines(r, func(line string) error {
+		line = strings.TrimPrefix(line, "export ")
+
+		idx := strings.Index(line, "=")
+		if idx == -1 {
+			return fmt.Errorf("expected NAME=value, got %q", line)
+		}
+
+		value := strings.TrimSpace(line[idx+1:])
+		switch {
+		case strings.HasPrefix(value, `"`):
+			var err error
+			value, err = strconv.Unquote(value)
+			if err != nil {
+				return fmt.Errorf("invalid quoted value %s", value)
+			}
+		case len(value) > 1 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+			value = value[1 : len(value)-1]
+		}
+
+		secrets = append(secrets, importedSecret{
+			name:  strings.TrimSpace(line[:idx]),
+			value: apitypes.NewStringCredentialValue(value),
+		})
+		return nil
+	})
+
+	return secrets, err
+}
+
+// readJSONImport reads a flat JSON object of names to values.
+func readJSONImport(r io.Reader) ([]importedSecret, error) {
+	raw := map[string]interface{}{}
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	err := dec.Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make([]importedSecret, 0, len(raw))
+	for name, v := range raw {
+		var value *apitypes.CredentialValue
+		switch t := v.(type) {
+		case string:
+			value = apitypes.NewStringCredentialValue(t)
+		case bool:
+			value = apitypes.NewStringCredentialValue(strconv.FormatBool(t))
+		case json.Number:
+			value, err = numberValue(t.String())
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("value of %s must be a string or number", name)
+		}
+
+		secrets = append(secrets, importedSecret{name: name, value: value})
+	}
+
+	return secrets, nil
+}
+
+// readYAMLImport reads a flat YAML mapping of names to values, as written by
+// `torus export`. Nested mappings and lists are not supported.
+func readYAMLImport(r io.Reader) ([]importedSecret, error) {
+	secrets := []importedSecret{}
+	err := scanLines(r, func(line string) error {
+		if line == "---" {
+			return nil
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") ||
+			strings.HasPrefix(line, "-") {
+			return errors.New("only a flat mapping of names to values is supported")
+		}
+
+		name, rest, err := yamlScalar(line, ":")
+		if err != nil {
+			return err
+		}
+
+		if !strings.HasPrefix(rest, ":") {
+			return fmt.Errorf("expected name: value, got %q", line)
+		}
+
+		raw := strings.TrimSpace(rest[1:])
+		if raw == "" {
+			return fmt.Errorf("value of %s must be a string or number", name)
+		}
+
+		var value *apitypes.CredentialValue
+		if raw[0] == '"' || raw[0] == '\'' {
+			s, rest, err := yamlScalar(raw, " #")
+			if err != nil {
+				return err
+			}
+			if rest != "" && !strings.HasPrefix(rest, " #") {
+				return fmt.Errorf("unexpected %q after value of %s", rest, name)
+			}
+			value = apitypes.NewStringCredentialValue(s)
+		} else {
+			if idx := strings.Index(raw, " #"); idx != -1 {
+				raw = strings.TrimSpace(raw[:idx])
+			}
+
+			value, err = numberValue(raw)
+			if err != nil {
+				value = apitypes.NewStringCredentialValue(raw)
+			}
+		}
+
+		secrets = append(secrets, importedSecret{name: name, value: value})
+		return nil
+	})
+
+	return secrets, err
+}
+
+// yamlScalar reads a single, possibly quoted, scalar from the start of s. It
+// returns the scalar, and whatever follows it. Unquoted scalars end at sep.
+func yamlScalar(s, sep string) (string, string, error) {
+	switch s[0] {
+	case '"':
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] == '"' {
+				v, err := strconv.Unquote(s[:i+1])
+				return v, strings.TrimLeft(s[i+1:], " \t"), err
+			}
+		}
+	case '\'':
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+
+			v := strings.Replace(s[1:i], "''", "'", -1)
+			return v, strings.TrimLeft(s[i+1:], " \t"), nil
+		}
+	default:
+		idx := strings.Index(s, sep)
+		if idx == -1 {
+			return strings.TrimSpace(s), "", nil
+		}
+		return strings.TrimSpace(s[:idx]), s[idx:], nil
+	}
+
+	return "", "", fmt.Errorf("unterminated quoted string %s", s)
+}
+
+// numberValue returns a number credential value for s, if it is one.
+func numberValue(s string) (*apitypes.CredentialValue, error) {
+	if i, err := strconv.Atoi(s); err == nil {
+		return apitypes.NewIntCredentialValue(i), nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return apitypes.NewFloatCredentialValue(f), nil
+}
+
+// scanLines calls fn for every line of r that is not blank or a comment.
+func scanLines(r io.Reader, fn func(string) error) error {
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		err := fn(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %s", lineNo, err)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestImportReadersRoundTrip(t *testing.T) {
+	secrets := []exportedSecret{
+		{name: "count", value: 3},
+		{name: "database_url", value: "postgres://db:5432/app"},
+		{name: "motd", value: "it's \"hello\" # world\n"},
+		{name: "ratio", value: 1.5},
+	}
+
+	for _, format := range []string{"env", "json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := exportWriters[format](buf, secrets)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			imported, err := readImport(importReaders[format], buf)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			if len(imported) != len(secrets) {
+				t.Fatalf("wrong number of secrets. got %d want %d", len(imported), len(secrets))
+			}
+
+			for i, s := range imported {
+				raw, err := s.value.Raw()
+				if err != nil {
+					t.Fatal("unexpected error:", err)
+				}
+
+				// env files have no types, so every value is read as a string.
+				want := secrets[i].value
+				if format == "env" {
+					want = fmt.Sprint(want)
+				}
+
+				if s.name != secrets[i].name || raw != want {
+					t.Errorf("wrong secret. got %s=%#v want %s=%#v",
+						s.name, raw, secrets[i].name, want)
+				}
+			}
+		})
+	}
+}
+
+func TestImportReaders(t *testing.T) {
+	tcs := []struct {
+		format string
+		in     string
+		out    map[string]string
+		err    bool
+	}{
+		{format: "env", in: "# comment\n\nexport NAME=value\nOTHER='quoted # value'\n",
+			out: map[string]string{"name": "value", "other": "quoted # value"}},
+		{format: "env", in: "NAME\n", err: true},
+		{format: "env", in: "NAME=a\nname=b\n", err: true},
+		{format: "yaml", in: "---\nname: plain value # comment\n'other': 'it''s'\n",
+			out: map[string]string{"name": "plain value", "other": "it's"}},
+		{format: "yaml", in: "parent:\n  child: value\n", err: true},
+		{format: "yaml", in: "list:\n- a\n", err: true},
+		{format: "json", in: `{"name": "value", "enabled": true}`,
+			out: map[string]string{"name": "value", "enabled": "true"}},
+		{format: "json", in: `{"nested": {"a": "b"}}`, err: true},
+	}
+
+	for _, tc := range tcs {
+		secrets, err := readImport(importReaders[tc.format], strings.NewReader(tc.in))
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s %q: expected an error", tc.format, tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: unexpected error: %s", tc.format, tc.in, err)
+			continue
+		}
+
+		if len(secrets) != len(tc.out) {
+			t.Errorf("%s %q: wrong number of secrets: %d", tc.format, tc.in, len(secrets))
+		}
+		for _, s := range secrets {
+			if s.value.String() != tc.out[s.name] {
+				t.Errorf("%s %q: wrong value for %s: %q", tc.format, tc.in, s.name, s.value.String())
+			}
+		}
+	}
+}
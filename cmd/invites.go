@@ -10,11 +10,12 @@ func init() {
 		Subcommands: []cli.Command{
 			{
 				Name:      "send",
-				Usage:     "Send an invitation to join an organization to an email address",
-				ArgsUsage: "<email>",
+				Usage:     "Send invitations to join an organization to one or more email addresses",
+				ArgsUsage: "[email...]",
 				Flags: []cli.Flag{
 					orgFlag("org to invite user to", true),
 					newSlicePlaceholder("team, t", "TEAM", "team to add user to", "member", "", true),
+					newPlaceholder("file, f", "FILE", "CSV file of emails, and teams separated by ; to invite (- for stdin)", "", "", false),
 				},
 				Action: chain(
 					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
@@ -39,15 +40,31 @@ func init() {
 			{
 				Name:      "approve",
 				Usage:     "Approve an invitation previously sent to an email address to join an organization",
-				ArgsUsage: "<email>",
+				ArgsUsage: "<email> | --all",
 				Flags: []cli.Flag{
 					orgFlag("org to approve invite for", true),
+					cli.BoolFlag{
+						Name:  "all",
+						Usage: "Approve every accepted invite",
+					},
 				},
 				Action: chain(
 					ensureDaemon, ensureSession, loadDirPrefs,
 					loadPrefDefaults, setUserEnv, checkRequiredFlags, invitesApprove,
 				),
 			},
+			{
+				Name:      "resend",
+				Usage:     "Resend the email for an invitation that has not yet been accepted",
+				ArgsUsage: "<email>",
+				Flags: []cli.Flag{
+					orgFlag("org the invite is for", true),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs,
+					loadPrefDefaults, setUserEnv, checkRequiredFlags, invitesResend,
+				),
+			},
 			{
 				Name:      "accept",
 				Usage:     "Accept an invitation to join an organization",
@@ -38,7 +38,7 @@ func invitesAccept(ctx *cli.Context) error {
 	if err != nil {
 		_, value, err := SelectAcceptAction()
 		if err != nil {
-			if err == promptui.ErrEOF || err == promptui.ErrInterrupt {
+			if err == promptui.ErrEOF || err == promptui.ErrInterrupt || errs.IsPromptError(err) {
 				return err
 			}
 			return errs.NewExitError(acceptInviteFailed)
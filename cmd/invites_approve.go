@@ -3,19 +3,29 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"text/tabwriter"
 
 	"github.com/urfave/cli"
 
 	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/cmd/output"
 	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/ui"
 )
 
 const approveInviteFailed = "Could not approve invitation to org, please try again."
 
 func invitesApprove(ctx *cli.Context) error {
 	args := ctx.Args()
+	if ctx.Bool("all") {
+		if len(args) > 0 {
+			return errs.NewUsageExitError("An email can't be given with --all", ctx)
+		}
+		return invitesApproveAll(ctx)
+	}
 	if len(args) < 1 {
 		return errs.NewUsageExitError("Missing email", ctx)
 	}
@@ -65,3 +75,72 @@ func invitesApprove(ctx *cli.Context) error {
 
 	return nil
 }
+
+// invitesApproveAll approves every accepted invite to the org in one request,
+// so the daemon can share the org's keyrings with all of the invitees at once.
+func invitesApproveAll(ctx *cli.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := client.Orgs.GetByName(c, ctx.String("org"))
+	if err != nil {
+		return errs.NewExitError(approveInviteFailed)
+	}
+	if org == nil {
+		return errs.NewExitError("Org not found.")
+	}
+
+	approvals, err := client.Invites.ApproveAll(c, *org.ID, &progressBar)
+	ui.ProgressDone()
+	if err != nil {
+		return errs.NewErrorExitError(approveInviteFailed, err)
+	}
+
+	failed := 0
+	records := make([]output.InviteApproval, len(approvals))
+	for i, approval := range approvals {
+		records[i] = output.InviteApproval{
+			Email:    approval.Email,
+			Approved: approval.Approved,
+			Error:    approval.Error,
+		}
+		if !approval.Approved {
+			failed++
+		}
+	}
+
+	if output.IsJSON(ctx) {
+		err = output.Write(os.Stdout, records)
+		if err != nil {
+			return err
+		}
+	} else if len(records) == 0 {
+		fmt.Println("There are no accepted invites waiting for approval.")
+		return nil
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+		fmt.Fprintln(w, tableHeader("EMAIL", "RESULT"))
+		for _, record := range records {
+			result := "approved"
+			if !record.Approved {
+				result = "failed: " + record.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\n", record.Email, result)
+		}
+		w.Flush()
+
+		fmt.Printf("\n%d of %d invitations to the %s org approved.\n",
+			len(records)-failed, len(records), org.Body.Name)
+	}
+
+	if failed > 0 {
+		return errs.NewExitError(fmt.Sprintf("%d invitations could not be approved.", failed))
+	}
+
+	return nil
+}
@@ -14,6 +14,9 @@ import (
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/hints"
 	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/cmd/output"
 )
 
 func invitesList(ctx *cli.Context) error {
@@ -45,6 +48,10 @@ func invitesList(ctx *cli.Context) error {
 	}
 
 	if len(invites) < 1 {
+		if output.IsJSON(ctx) {
+			return output.Write(os.Stdout, []output.Invite{})
+		}
+
 		fmt.Println("No invites found.")
 		return nil
 	}
@@ -76,6 +83,32 @@ func invitesList(ctx *cli.Context) error {
 		usernameByID[profile.ID.String()] = profile.Body.Username
 	}
 
+	if output.IsJSON(ctx) {
+		records := []output.Invite{}
+		for _, invite := range invites {
+			inviter := usernameByID[invite.Body.InviterID.String()]
+			if inviter == "" {
+				continue
+			}
+
+			record := output.Invite{
+				ID:        invite.ID,
+				Email:     invite.Body.Email,
+				State:     invite.Body.State,
+				InvitedBy: inviter,
+				Created:   invite.Body.Created,
+			}
+			if invite.Body.InviteeID != nil {
+				record.Username = usernameByID[invite.Body.InviteeID.String()]
+			}
+			if invite.Body.Delivery != nil {
+				record.Delivery = invite.Body.Delivery.State
+			}
+			records = append(records, record)
+		}
+		return output.Write(os.Stdout, records)
+	}
+
 	fmt.Println("")
 	if ctx.Bool("approved") {
 		fmt.Println("Listing approved invitations for the " + ctx.String("org") + " org")
@@ -85,8 +118,9 @@ func invitesList(ctx *cli.Context) error {
 	fmt.Println("")
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 8, ' ', 0)
-	fmt.Fprintln(w, "EMAIL\tUSERNAME\tSTATE\tINVITED BY\tCREATION DATE")
-	fmt.Fprintln(w, " \t \t \t ")
+	fmt.Fprintln(w, tableHeader("EMAIL", "USERNAME", "STATE", "DELIVERY", "INVITED BY", "CREATION DATE"))
+	fmt.Fprintln(w, " \t \t \t \t ")
+	bounced := 0
 	for _, invite := range invites {
 		inviter := usernameByID[invite.Body.InviterID.String()]
 		if inviter == "" {
@@ -97,11 +131,22 @@ func invitesList(ctx *cli.Context) error {
 		if invite.Body.InviteeID != nil {
 			invitee = usernameByID[invite.Body.InviteeID.String()]
 		}
-		fmt.Fprintln(w, identity+"\t"+invitee+"\t"+invite.Body.State+"\t"+inviter+"\t"+invite.Body.Created.Format(time.RFC3339))
+		delivery := "-"
+		if invite.Body.Delivery != nil {
+			delivery = invite.Body.Delivery.State
+			if delivery == primitive.OrgInviteDeliveryBounced {
+				bounced++
+			}
+		}
+		fmt.Fprintln(w, identity+"\t"+invitee+"\t"+invite.Body.State+"\t"+delivery+"\t"+inviter+"\t"+invite.Body.Created.Format(time.RFC3339))
 	}
 	w.Flush()
 	fmt.Println("")
 
-	hints.Display([]string{"invites approve", "teams members"})
+	if bounced > 0 {
+		fmt.Printf("%d invitation email(s) bounced. Check the address, then resend or send a new invite.\n\n", bounced)
+	}
+
+	hints.Display([]string{"invites approve", "invites resend", "teams members"})
 	return nil
 }
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+const resendInviteFailed = "Could not resend invitation to org, please try again."
+
+func invitesResend(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) < 1 {
+		return errs.NewUsageExitError("Missing email", ctx)
+	}
+	email := ctx.Args()[0]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := client.Orgs.GetByName(c, ctx.String("org"))
+	if err != nil {
+		return errs.NewExitError(resendInviteFailed)
+	}
+	if org == nil {
+		return errs.NewExitError("Org not found.")
+	}
+
+	// Only invites that have not been accepted yet still rely on the email.
+	states := []string{"pending", "associated"}
+	invites, err := client.Invites.List(c, org.ID, states)
+	if err != nil {
+		return errs.NewExitError("Failed to retrieve invites, please try again.")
+	}
+
+	var targetInvite *identity.ID
+	for _, invite := range invites {
+		if invite.Body.Email == email {
+			targetInvite = invite.ID
+		}
+	}
+	if targetInvite == nil {
+		return errs.NewExitError("Invite not found.")
+	}
+
+	_, err = client.Invites.Resend(c, *targetInvite)
+	if err != nil {
+		return errs.NewErrorExitError(resendInviteFailed, err)
+	}
+
+	fmt.Println("Invitation email to " + email + " has been queued for delivery.")
+	return nil
+}
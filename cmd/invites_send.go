@@ -2,13 +2,20 @@ package cmd
 
 import (
 	"context"
+	"encoding/csv"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"sync"
+	"text/tabwriter"
 
 	"github.com/urfave/cli"
 
 	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/cmd/output"
 	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/hints"
 	"github.com/manifoldco/torus-cli/identity"
@@ -16,15 +23,43 @@ import (
 
 const orgInviteFailed = "Could not send invitation to org, please try again."
 
+// memberTeam is the team everyone invited to an org joins.
+const memberTeam = "member"
+
+// inviteRow is an email address to invite, along with the teams given for it
+// in an invites file.
+type inviteRow struct {
+	Email string
+	Teams []string
+}
+
 func invitesSend(ctx *cli.Context) error {
 	args := ctx.Args()
-	if len(args) < 1 || args[0] == "" {
+	file := ctx.String("file")
+	if len(args) < 1 && file == "" {
 		return errs.NewUsageExitError("Missing email", ctx)
 	}
-	if len(args) > 1 {
-		return errs.NewUsageExitError("Too many arguments", ctx)
+
+	var rows []inviteRow
+	for _, email := range args {
+		if email == "" {
+			return errs.NewUsageExitError("Missing email", ctx)
+		}
+		rows = append(rows, inviteRow{Email: email})
+	}
+
+	if file != "" {
+		fileRows, err := readInviteFile(file)
+		if err != nil {
+			return errs.NewErrorExitError("Could not read "+file+".", err)
+		}
+		rows = append(rows, fileRows...)
+	}
+
+	rows = mergeInviteRows(rows)
+	if len(rows) < 1 {
+		return errs.NewExitError("No email addresses found in " + file + ".")
 	}
-	email := args[0]
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -53,36 +88,23 @@ func invitesSend(ctx *cli.Context) error {
 		return errs.NewExitError(orgInviteFailed)
 	}
 
-	matchTeams := ctx.StringSlice("team")
+	if len(rows) == 1 && file == "" {
+		return inviteOne(client, org, *session.ID(), teams, rows[0].Email, ctx.StringSlice("team"))
+	}
+
+	return inviteMany(ctx, client, org, *session.ID(), teams, rows)
+}
+
+// inviteOne sends a single invite, explaining what happens next.
+func inviteOne(client *api.Client, org *envelope.Org, inviterID identity.ID,
+	teams []envelope.Team, email string, matchTeams []string) error {
 
 	// ensure that even with custom teams, users are always invited to the
 	// member team
-	const memberTeam = "member"
-	memberFound := false
-	for _, team := range matchTeams {
-		if team == memberTeam {
-			memberFound = true
-			break
-		}
-	}
-	if !memberFound {
-		matchTeams = append(matchTeams, memberTeam)
-	}
+	matchTeams = inviteTeams(matchTeams, nil)
 
 	// Verify all team names supplied exist for this org
-	teamIDs := make([]identity.ID, len(matchTeams))
-	var missingTeams []string
-
-TeamSearch:
-	for i, teamName := range matchTeams {
-		for _, team := range teams {
-			if team.Body.Name == teamName {
-				teamIDs[i] = *team.ID
-				continue TeamSearch
-			}
-		}
-		missingTeams = append(missingTeams, teamName)
-	}
+	teamIDs, missingTeams := matchInviteTeams(teams, matchTeams)
 
 	// One of the supplied teams is not known to this org
 	if len(missingTeams) > 0 {
@@ -93,7 +115,9 @@ TeamSearch:
 		return errs.NewExitError(orgInviteFailed)
 	}
 
-	err = client.Invites.Send(context.Background(), email, *org.ID, *session.ID(), teamIDs)
+	inviteeID := lookupInvitee(client, org.ID, email)
+
+	err := client.Invites.Send(context.Background(), email, *org.ID, inviterID, inviteeID, teamIDs)
 	if err != nil {
 		if strings.Contains(err.Error(), "resource exists") {
 			return errs.NewExitError(email + " has already been invited to the " + org.Body.Name + " org")
@@ -109,3 +133,282 @@ TeamSearch:
 	hints.Display([]string{"invites approve", "teams members"})
 	return nil
 }
+
+// inviteMany sends an invite to each row at once, and prints whether each
+// was sent. Rows naming teams the org doesn't have are not sent.
+func inviteMany(ctx *cli.Context, client *api.Client, org *envelope.Org, inviterID identity.ID,
+	teams []envelope.Team, rows []inviteRow) error {
+
+	results := make([]output.InviteResult, len(rows))
+	var invites []api.Invitation
+	var sent []int
+
+	for i, row := range rows {
+		names := inviteTeams(ctx.StringSlice("team"), row.Teams)
+		results[i] = output.InviteResult{Email: row.Email, Teams: names}
+
+		teamIDs, missingTeams := matchInviteTeams(teams, names)
+		if len(missingTeams) > 0 {
+			results[i].Error = "unknown team(s): " + strings.Join(missingTeams, ", ")
+			continue
+		}
+
+		invites = append(invites, api.Invitation{Email: row.Email, TeamIDs: teamIDs})
+		sent = append(sent, i)
+	}
+
+	emails := make([]string, len(invites))
+	for i, invite := range invites {
+		emails[i] = invite.Email
+	}
+	for i, inviteeID := range lookupInvitees(client, org.ID, emails) {
+		invites[i].InviteeID = inviteeID
+	}
+
+	for n, err := range client.Invites.SendMany(context.Background(), *org.ID, inviterID, invites) {
+		result := &results[sent[n]]
+		switch {
+		case err == nil:
+			result.Sent = true
+		case strings.Contains(err.Error(), "resource exists"):
+			result.Error = "already invited"
+		default:
+			result.Error = "could not send invitation"
+		}
+	}
+
+	failed := 0
+	for _, result := range results {
+		if !result.Sent {
+			failed++
+		}
+	}
+
+	if output.IsJSON(ctx) {
+		err := output.Write(os.Stdout, results)
+		if err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+		fmt.Fprintln(w, tableHeader("EMAIL", "TEAMS", "RESULT"))
+		for _, result := range results {
+			status := "sent"
+			if !result.Sent {
+				status = "failed: " + result.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", result.Email, strings.Join(result.Teams, ", "), status)
+		}
+		w.Flush()
+
+		fmt.Printf("\n%d of %d invitations to the %s org sent.\n", len(rows)-failed, len(rows), org.Body.Name)
+	}
+
+	if failed > 0 {
+		return errs.NewExitError(fmt.Sprintf("%d invitations could not be sent.", failed))
+	}
+
+	hints.Display([]string{"invites approve", "teams members"})
+	return nil
+}
+
+// inviteTeams returns the teams to invite someone to, given the teams from
+// the command line and their row of an invites file. The member team is
+// always included.
+func inviteTeams(flagTeams, rowTeams []string) []string {
+	names := appendTeams(nil, flagTeams...)
+	names = appendTeams(names, rowTeams...)
+	return appendTeams(names, memberTeam)
+}
+
+// appendTeams appends the team names not already in names.
+func appendTeams(names []string, more ...string) []string {
+Next:
+	for _, name := range more {
+		if name == "" {
+			continue
+		}
+		for _, existing := range names {
+			if existing == name {
+				continue Next
+			}
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// matchInviteTeams returns the IDs of the named teams, and the names of any
+// teams the org doesn't have.
+func matchInviteTeams(teams []envelope.Team, names []string) ([]identity.ID, []string) {
+	var teamIDs []identity.ID
+	var missingTeams []string
+
+TeamSearch:
+	for _, teamName := range names {
+		for _, team := range teams {
+			if team.Body.Name == teamName {
+				teamIDs = append(teamIDs, *team.ID)
+				continue TeamSearch
+			}
+		}
+		missingTeams = append(missingTeams, teamName)
+	}
+
+	return teamIDs, missingTeams
+}
+
+// readInviteFile reads the invites file at path, or from stdin if path is -.
+func readInviteFile(path string) ([]inviteRow, error) {
+	if path == "-" {
+		return parseInviteCSV(os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseInviteCSV(f)
+}
+
+// parseInviteCSV reads rows of email addresses, and optionally the teams to
+// invite them to, separated by ; or |. The first line may be a header naming
+// the email and teams columns, in which case other columns are ignored.
+func parseInviteCSV(r io.Reader) ([]inviteRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	emailCol, teamsCol := 0, 1
+	if len(records) > 0 && isInviteHeader(records[0]) {
+		emailCol, teamsCol = -1, -1
+		for i, name := range records[0] {
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "email":
+				emailCol = i
+			case "teams", "team":
+				teamsCol = i
+			}
+		}
+		records = records[1:]
+	}
+
+	var rows []inviteRow
+	for i, record := range records {
+		if emailCol >= len(record) {
+			continue
+		}
+
+		email := strings.TrimSpace(record[emailCol])
+		if email == "" {
+			continue
+		}
+		if !strings.Contains(email, "@") {
+			return nil, fmt.Errorf("record %d: %q is not an email address", i+1, email)
+		}
+
+		row := inviteRow{Email: email}
+		if teamsCol >= 0 && teamsCol < len(record) {
+			fields := strings.FieldsFunc(record[teamsCol], func(r rune) bool {
+				return r == ';' || r == '|'
+			})
+			for _, team := range fields {
+				if team = strings.TrimSpace(team); team != "" {
+					row.Teams = append(row.Teams, team)
+				}
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func isInviteHeader(record []string) bool {
+	for _, name := range record {
+		if strings.ToLower(strings.TrimSpace(name)) == "email" {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeInviteRows combines rows for the same email address, so each address
+// is only invited once, to all of the teams given for it.
+func mergeInviteRows(rows []inviteRow) []inviteRow {
+	var merged []inviteRow
+	index := make(map[string]int)
+	for _, row := range rows {
+		key := strings.ToLower(row.Email)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, inviteRow{Email: row.Email})
+			i = len(merged) - 1
+		}
+		merged[i].Teams = appendTeams(merged[i].Teams, row.Teams...)
+	}
+
+	return merged
+}
+
+// lookupInvitee tells the inviter whether email belongs to a discoverable
+// Torus account, returning its ID so the invite can be associated with it. It
+// returns nil if the registry has no directory, or the lookup fails.
+func lookupInvitee(client *api.Client, orgID *identity.ID, email string) *identity.ID {
+	c := context.Background()
+	if ok, err := client.Supports(c, api.FeatureDirectory); err != nil || !ok {
+		return nil
+	}
+
+	entry, err := client.Directory.Lookup(c, orgID, email)
+	if err != nil {
+		return nil
+	}
+
+	if !entry.Found {
+		fmt.Println(email + " does not belong to a discoverable Torus account. " +
+			"They will need to sign up, or log in, with this address to accept.\n")
+		return nil
+	}
+
+	fmt.Println(email + " belongs to the Torus user " + entry.Username + ". " +
+		"The invitation will be linked to their account.\n")
+	return entry.UserID
+}
+
+// lookupInvitees looks up each of emails in the directory at once, like
+// lookupInvitee, without printing the results. The ID of the account for
+// emails[i] is returned in the i'th element of the result, or nil.
+func lookupInvitees(client *api.Client, orgID *identity.ID, emails []string) []*identity.ID {
+	ids := make([]*identity.ID, len(emails))
+
+	c := context.Background()
+	if ok, err := client.Supports(c, api.FeatureDirectory); err != nil || !ok {
+		return ids
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(emails))
+	for i, email := range emails {
+		go func(i int, email string) {
+			entry, err := client.Directory.Lookup(c, orgID, email)
+			if err == nil && entry.Found {
+				ids[i] = entry.UserID
+			}
+			wg.Done()
+		}(i, email)
+	}
+	wg.Wait()
+
+	return ids
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseInviteCSV(t *testing.T) {
+	t.Run("without a header", func(t *testing.T) {
+		in := "jeff@example.com,ops;dev\nsam@example.com\n\n"
+		rows, err := parseInviteCSV(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []inviteRow{
+			{Email: "jeff@example.com", Teams: []string{"ops", "dev"}},
+			{Email: "sam@example.com"},
+		}
+		if !reflect.DeepEqual(rows, want) {
+			t.Errorf("got %v, want %v", rows, want)
+		}
+	})
+
+	t.Run("with a header", func(t *testing.T) {
+		in := "name,Email,teams\nJeff,jeff@example.com,ops | dev\nSam,sam@example.com,\n"
+		rows, err := parseInviteCSV(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []inviteRow{
+			{Email: "jeff@example.com", Teams: []string{"ops", "dev"}},
+			{Email: "sam@example.com"},
+		}
+		if !reflect.DeepEqual(rows, want) {
+			t.Errorf("got %v, want %v", rows, want)
+		}
+	})
+
+	t.Run("bad email", func(t *testing.T) {
+		_, err := parseInviteCSV(strings.NewReader("jeff,ops\n"))
+		if err == nil {
+			t.Error("expected an error for a row without an email address")
+		}
+	})
+}
+
+func TestMergeInviteRows(t *testing.T) {
+	rows := mergeInviteRows([]inviteRow{
+		{Email: "jeff@example.com", Teams: []string{"ops"}},
+		{Email: "sam@example.com"},
+		{Email: "Jeff@example.com", Teams: []string{"ops", "dev"}},
+	})
+
+	want := []inviteRow{
+		{Email: "jeff@example.com", Teams: []string{"ops", "dev"}},
+		{Email: "sam@example.com"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %v, want %v", rows, want)
+	}
+
+	teams := inviteTeams([]string{"member", "ops"}, []string{"dev", "ops"})
+	if !reflect.DeepEqual(teams, []string{"member", "ops", "dev"}) {
+		t.Errorf("unexpected teams %v", teams)
+	}
+}
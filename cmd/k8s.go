@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/ci"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/k8s"
+)
+
+func init() {
+	kube := cli.Command{
+		Name:     "k8s",
+		Usage:    "Sync secrets into Kubernetes",
+		Category: "SECRETS",
+		Subcommands: []cli.Command{
+			{
+				Name:  "sync",
+				Usage: "Sync secrets into a Kubernetes Secret",
+				Flags: append(exportContextFlags,
+					newPlaceholder("secret-name", "NAME", "Name of the Kubernetes Secret to write.",
+						"", "", true),
+					newPlaceholder("namespace", "NAMESPACE",
+						"Namespace of the Secret (default: the kubeconfig context's namespace)",
+						"", "", false),
+					newPlaceholder("kubeconfig", "FILE", "Path to the kubeconfig file to use.",
+						"", "", false),
+					newPlaceholder("context", "CONTEXT",
+						"Use this kubeconfig context instead of the current one.",
+						"", "", false),
+					cli.BoolFlag{
+						Name:  "yaml",
+						Usage: "Write the Secret as YAML instead of applying it to the cluster",
+					},
+					cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show the changes that would be made, without making them",
+					},
+				),
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					setUserEnv, checkRequiredFlags, k8sSyncCmd,
+				),
+			},
+		},
+	}
+
+	Cmds = append(Cmds, kube)
+}
+
+// k8sKeyName matches the names Kubernetes allows for the keys of a Secret.
+var k8sKeyName = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+func k8sSyncCmd(ctx *cli.Context) error {
+	secrets, path, err := getSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	values, err := k8sSecretValues(secrets)
+	if err != nil {
+		return err
+	}
+
+	name := ctx.String("secret-name")
+	namespace := ctx.String("namespace")
+
+	if ctx.Bool("yaml") {
+		if namespace == "" {
+			namespace = k8s.DefaultNamespace
+		}
+
+		b, err := k8s.NewSecret(namespace, name, path, values).YAML()
+		if err != nil {
+			return errs.NewErrorExitError("Could not write Secret.", err)
+		}
+
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+
+	kubeconfig := ctx.String("kubeconfig")
+	if kubeconfig == "" {
+		kubeconfig = k8s.KubeconfigPath()
+	}
+
+	client, err := k8s.NewClient(kubeconfig, ctx.String("context"))
+	if err != nil {
+		return errs.NewErrorExitError("Could not load kubeconfig.", err)
+	}
+
+	if namespace == "" {
+		namespace = client.Namespace()
+	}
+
+	c := context.Background()
+	existing, err := client.GetSecret(c, namespace, name)
+	if err != nil {
+		return errs.NewErrorExitError("Could not read Secret "+name+".", err)
+	}
+
+	current := map[string]*string{}
+	if existing != nil {
+		if !existing.Managed() {
+			return errs.NewExitError(fmt.Sprintf(
+				"Secret %s in namespace %s exists, and is not managed by Torus.",
+				name, namespace))
+		}
+
+		current, err = existing.Values()
+		if err != nil {
+			return errs.NewErrorExitError("Could not read Secret "+name+".", err)
+		}
+	}
+
+	target := fmt.Sprintf("Secret %s in namespace %s", name, namespace)
+	plan := ci.NewPlan(current, values)
+	if existing != nil && plan.Empty() &&
+		existing.Metadata.Annotations[k8s.PathAnnotation] == path {
+
+		fmt.Printf("%s is up to date.\n", target)
+		return nil
+	}
+
+	fmt.Println("")
+	if existing == nil {
+		fmt.Printf("  create secret %s\n", name)
+	}
+	printCIChanges("create", plan.Create)
+	printCIChanges("update", plan.Update)
+	printCIChanges("delete", plan.Delete)
+	fmt.Println("")
+
+	if ctx.Bool("dry-run") {
+		fmt.Printf("Dry run, %s was not changed.\n", target)
+		return nil
+	}
+
+	secret := k8s.NewSecret(namespace, name, path, values)
+	if existing == nil {
+		err = client.CreateSecret(c, secret)
+	} else {
+		secret.Metadata.ResourceVersion = existing.Metadata.ResourceVersion
+		err = client.UpdateSecret(c, secret)
+	}
+	if err != nil {
+		return errs.NewErrorExitError("Could not write Secret "+name+".", err)
+	}
+
+	fmt.Printf("%s is now in sync with Torus.\n", target)
+	return nil
+}
+
+// k8sSecretValues returns the Secret keys and values of secrets. Names are
+// upper cased, as they are for env files, so the Secret can be used with
+// envFrom.
+func k8sSecretValues(secrets []apitypes.CredentialEnvelope) (map[string]string, error) {
+	exported, err := exportSecrets(secrets)
+	if err != nil {
+		return nil, errs.NewErrorExitError("Could not export secrets.", err)
+	}
+
+	values := make(map[string]string, len(exported))
+	for _, s := range exported {
+		name := strings.ToUpper(s.name)
+		if !k8sKeyName.MatchString(name) {
+			return nil, errs.NewExitError("Secret " + s.name + " cannot be used as a Kubernetes Secret key.")
+		}
+		values[name] = fmt.Sprint(s.value)
+	}
+
+	return values, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -15,6 +16,8 @@ import (
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/cmd/output"
 )
 
 func init() {
@@ -28,6 +31,9 @@ func init() {
 				Usage: "List your keypairs for an organization",
 				Flags: []cli.Flag{
 					orgFlag("org to show keypairs for", true),
+					newPlaceholder("expiring", "DURATION",
+						"Only list valid keypairs expiring within this time, such as 30d",
+						"", "", false),
 				},
 				Action: chain(
 					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
@@ -37,25 +43,36 @@ func init() {
 			{
 				Name:  "generate",
 				Usage: "Generate keyparis for an organization",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					orgFlag("org to generate keypairs for", false),
 					cli.BoolFlag{
-						Name:  "all",
-						Usage: "Perform command for all orgs without valid keypairs",
+						Name:  "all-orgs, all",
+						Usage: "Generate keypairs for all of your orgs without valid keypairs",
 					},
-				},
+				}, progressFlags...),
 				Action: chain(
 					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 					setUserEnv, checkRequiredFlags, generateKeypairs,
 				),
 			},
+			{
+				Name:  "renew",
+				Usage: "Replace your keypairs for an organization before they expire",
+				Flags: append([]cli.Flag{
+					orgFlag("org to renew keypairs for", true),
+				}, progressFlags...),
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					setUserEnv, checkRequiredFlags, renewKeypairs,
+				),
+			},
 			{
 				Name:  "revoke",
 				Usage: "Revoke the keypairs for an organization (used for testing only)",
 
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					orgFlag("org to revoke keypairs for", true),
-				},
+				}, progressFlags...),
 				Action: chain(
 					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 					setUserEnv, checkRequiredFlags, revokeKeypairs,
@@ -70,6 +87,15 @@ func init() {
 const keypairListFailed = "Could not list keypairs, please try again."
 
 func listKeypairs(ctx *cli.Context) error {
+	var before *time.Time
+	if expiring := ctx.String("expiring"); expiring != "" {
+		t, err := parseRelativeTime(expiring, time.Now(), 1)
+		if err != nil {
+			return errs.NewUsageExitError(err.Error(), ctx)
+		}
+		before = &t
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return err
@@ -93,19 +119,45 @@ func listKeypairs(ctx *cli.Context) error {
 		return errs.NewExitError(keypairListFailed)
 	}
 
+	now := time.Now()
+	if before != nil {
+		keypairs = expiringKeypairs(keypairs, *before)
+	}
+
+	if output.IsJSON(ctx) {
+		records := make([]output.Keypair, 0, len(keypairs))
+		for _, keypair := range keypairs {
+			records = append(records, output.NewKeypair(keypair, org.Body.Name, now))
+		}
+		return output.Write(os.Stdout, records)
+	}
+
+	if before != nil {
+		if len(keypairs) == 0 {
+			fmt.Printf("No keypairs in the %s org expire before %s.\n",
+				org.Body.Name, before.Format("2006-01-02"))
+			return nil
+		}
+	}
+
 	fmt.Println("")
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tORG\tKEY TYPE\tVALID\tCREATION DATE")
-	fmt.Fprintln(w, " \t \t \t \t ")
+	fmt.Fprintln(w, tableHeader("ID", "ORG", "KEY TYPE", "VALID", "CREATION DATE", "EXPIRES"))
+	fmt.Fprintln(w, " \t \t \t \t \t ")
 	for _, keypair := range keypairs {
 		pk := keypair.PublicKey.Body
 		valid := "YES"
-		if keypair.Revoked() {
+		if keypair.Revoked() || pk.Expired(now) {
 			valid = "NO"
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", keypair.PublicKey.ID,
-			org.Body.Name, pk.KeyType, valid, pk.Created.Format(time.RFC3339))
+
+		expires := "-"
+		if !pk.Expires.IsZero() {
+			expires = pk.Expires.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", keypair.PublicKey.ID,
+			org.Body.Name, pk.KeyType, valid, pk.Created.Format(time.RFC3339), expires)
 	}
 	w.Flush()
 	fmt.Println("")
@@ -113,7 +165,27 @@ func listKeypairs(ctx *cli.Context) error {
 	return nil
 }
 
+// expiringKeypairs returns the unrevoked keypairs that expire before the given
+// time, including those that already have.
+func expiringKeypairs(keypairs []api.KeypairResult, before time.Time) []api.KeypairResult {
+	var expiring []api.KeypairResult
+	for _, kp := range keypairs {
+		if !kp.Revoked() && kp.PublicKey.Body.Expired(before) {
+			expiring = append(expiring, kp)
+		}
+	}
+	return expiring
+}
+
+// keypairWorkers is how many orgs keypairs are generated for at once.
+const keypairWorkers = 4
+
 func generateKeypairs(ctx *cli.Context) error {
+	format, err := progressFormat(ctx)
+	if err != nil {
+		return err
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return err
@@ -122,24 +194,16 @@ func generateKeypairs(ctx *cli.Context) error {
 	client := api.NewClient(cfg)
 	c := context.Background()
 
-	orgNames := make(map[*identity.ID]string)
-	subjectOrgs := make(map[*identity.ID]*envelope.Org)
-	regenOrgs := make(map[*identity.ID]string)
-
-	if ctx.Bool("all") {
+	var subjectOrgs []envelope.Org
+	if ctx.Bool("all-orgs") {
 		// If all flag is supplied, we will get all their orgs
-		var orgs []envelope.Org
 		orgs, oErr := client.Orgs.List(c)
 		if oErr != nil {
 			return errs.NewExitError("Could not retrieve orgs, please try again.")
 		}
-		for _, org := range orgs {
-			subjectOrgs[org.ID] = &org
-			orgNames[org.ID] = org.Body.Name
-		}
+		subjectOrgs = orgs
 	} else {
 		// Verify the org they've specified exists
-		var org *envelope.Org
 		orgName := ctx.String("org")
 		if orgName == "" {
 			return errs.NewExitError("Missing flags: --org.")
@@ -148,18 +212,15 @@ func generateKeypairs(ctx *cli.Context) error {
 		if oErr != nil || org == nil {
 			return errs.NewExitError("Org '" + orgName + "' not found.")
 		}
-		subjectOrgs[org.ID] = org
-		orgNames[org.ID] = org.Body.Name
+		subjectOrgs = []envelope.Org{*org}
 	}
 
 	// Iterate over target orgs and identify which keys exist
-	var pErr error
 	hasKey := make(map[identity.ID]map[primitive.KeyType]bool, len(subjectOrgs))
-	for orgID := range subjectOrgs {
-		keypairs, err := client.Keypairs.List(c, orgID)
+	for _, org := range subjectOrgs {
+		keypairs, err := client.Keypairs.List(c, org.ID)
 		if err != nil {
-			pErr = err
-			break
+			return errs.NewExitError("Error fetching required context.")
 		}
 		for _, kp := range keypairs {
 			if kp.Revoked() {
@@ -175,36 +236,56 @@ func generateKeypairs(ctx *cli.Context) error {
 		}
 	}
 
-	if pErr != nil {
-		return errs.NewExitError("Error fetching required context.")
+	// Regenerate for orgs which do not have both keys present
+	var regenIDs []*identity.ID
+	var regenNames []string
+	for _, org := range subjectOrgs {
+		if !hasKey[*org.ID][primitive.EncryptionKeyType] || !hasKey[*org.ID][primitive.SigningKeyType] {
+			regenIDs = append(regenIDs, org.ID)
+			regenNames = append(regenNames, org.Body.Name)
+		}
 	}
 
-	// Regenerate for orgs which do not have both keys present
-	for orgID := range subjectOrgs {
-		if !hasKey[*orgID][primitive.EncryptionKeyType] || !hasKey[*orgID][primitive.SigningKeyType] {
-			regenOrgs[orgID] = orgNames[orgID]
+	if len(regenIDs) == 0 {
+		if format == "text" {
+			fmt.Println("No keypairs missing.")
 		}
+		return nil
+	}
+
+	org := ""
+	if len(regenNames) == 1 {
+		org = regenNames[0]
+	}
+	if format == "text" {
+		label := "org"
+		if len(regenNames) > 1 {
+			label = "orgs"
+		}
+		fmt.Printf("Generating signing and encryption keypairs for %s: %s\n",
+			label, strings.Join(regenNames, ", "))
 	}
 
-	var rErr error
+	progress, wait := showProgress(format, org, ctx.Bool("quiet"))
+	genErrs := client.Keypairs.GenerateMany(c, regenIDs, keypairWorkers, progress)
+	wait()
 
-	for orgID, name := range regenOrgs {
-		fmt.Println("Generating signing and encryption keypairs for org: " + name)
-		err := client.Keypairs.Generate(c, orgID, &progress)
-		if err != nil && rErr == nil {
-			rErr = err
-			break
+	var failed []string
+	for i, err := range genErrs {
+		if err != nil {
+			failed = append(failed, regenNames[i])
+			if format == "text" {
+				fmt.Fprintf(os.Stderr, "Could not generate keypairs for org %s: %s\n", regenNames[i], err)
+			}
 		}
 	}
 
-	if rErr != nil {
-		return errs.NewExitError("Error while regenerating keypairs.")
+	if len(failed) > 0 {
+		return errs.NewExitError("Error while regenerating keypairs for " + strings.Join(failed, ", ") + ".")
 	}
 
-	if len(regenOrgs) > 0 {
+	if format == "text" {
 		fmt.Println("Keypair generation successful.")
-	} else {
-		fmt.Println("No keypairs missing.")
 	}
 	return nil
 }
@@ -228,7 +309,9 @@ func generateKeypairsForOrg(c context.Context, ctx *cli.Context, client *api.Cli
 		orgID = org.ID
 	}
 
-	err = client.Keypairs.Generate(c, orgID, &progress)
+	progress, wait := showProgress("text", "", false)
+	err = client.Keypairs.Generate(c, orgID, progress)
+	wait()
 	if err != nil {
 		return outputErr
 	}
@@ -236,7 +319,48 @@ func generateKeypairsForOrg(c context.Context, ctx *cli.Context, client *api.Cli
 	return nil
 }
 
+func renewKeypairs(ctx *cli.Context) error {
+	format, err := progressFormat(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	if format == "text" {
+		fmt.Printf("Renewing signing and encryption keypairs for org: %s\n", org.Body.Name)
+	}
+
+	progress, wait := showProgress(format, org.Body.Name, ctx.Bool("quiet"))
+	err = client.Keypairs.Renew(c, org.ID, progress)
+	wait()
+	if err != nil {
+		return errs.NewErrorExitError("Error while renewing keypairs.", err)
+	}
+
+	if format == "text" {
+		fmt.Println("Keypairs renewed.")
+	}
+	return nil
+}
+
 func revokeKeypairs(ctx *cli.Context) error {
+	format, err := progressFormat(ctx)
+	if err != nil {
+		return err
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return err
@@ -276,11 +400,17 @@ func revokeKeypairs(ctx *cli.Context) error {
 		return nil
 	}
 
-	err = client.Keypairs.Revoke(c, org.ID, &progress)
+	err = withMFA(c, func(c context.Context) error {
+		progress, wait := showProgress(format, org.Body.Name, ctx.Bool("quiet"))
+		defer wait()
+		return client.Keypairs.Revoke(c, org.ID, progress)
+	})
 	if err != nil {
 		return errs.NewErrorExitError("Error while revoking keypairs.", err)
 	}
 
-	fmt.Println("Keypairs revoked.")
+	if format == "text" {
+		fmt.Println("Keypairs revoked.")
+	}
 	return nil
 }
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/hints"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func init() {
+	keyrings := cli.Command{
+		Name:     "keyrings",
+		Usage:    "Manage the keyrings secrets are encrypted with",
+		Category: "ACCESS CONTROL",
+		Subcommands: []cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the keyrings in a project, and how many members each is shared with",
+				Flags: []cli.Flag{
+					orgFlag("Use this organization.", true),
+					projectFlag("Use this project.", true),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, listKeyringsCmd,
+				),
+			},
+			{
+				Name:      "members",
+				Usage:     "Show who can decrypt the secrets at a path, and with which keys",
+				ArgsUsage: "<path>",
+				Action:    chain(ensureDaemon, ensureSession, keyringMembersCmd),
+			},
+			{
+				Name:      "rotate",
+				Usage:     "Encrypt the secrets in a keyring with a new key, shared only with current members",
+				ArgsUsage: "<path>",
+				Flags: []cli.Flag{
+					stdAutoAcceptFlag,
+				},
+				Action: chain(ensureDaemon, ensureSession, rotateKeyringCmd),
+			},
+		},
+	}
+
+	Cmds = append(Cmds, keyrings)
+}
+
+const (
+	rotateKeyringFailed  = "Could not rotate keyring, please try again."
+	listKeyringsFailed   = "Could not list keyrings, please try again."
+	keyringMembersFailed = "Could not list keyring members, please try again."
+)
+
+func listKeyringsCmd(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	pe, err := pathexp.New(ctx.String("org"), ctx.String("project"),
+		[]string{"*"}, []string{"*"}, []string{"*"}, []string{"*"})
+	if err != nil {
+		return errs.NewErrorExitError(listKeyringsFailed, err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	keyrings, err := client.Keyrings.Members(c, org.ID, pe.String())
+	if err != nil {
+		return errs.NewErrorExitError(listKeyringsFailed, err)
+	}
+
+	if len(keyrings) == 0 {
+		fmt.Printf("No keyrings found in %s.\n", pe)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, tableHeader("PATH", "VERSION", "MEMBERS", "STATUS"))
+	for _, k := range keyrings {
+		status := "active"
+		if k.Frozen {
+			status = "frozen"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", k.PathExp, k.KeyringVersion,
+			activeMembers(k.Members), status)
+	}
+	w.Flush()
+
+	hints.Display([]string{"keyrings members"})
+	return nil
+}
+
+// activeMembers returns the number of users and machine tokens who can
+// decrypt a keyring with an unrevoked membership.
+func activeMembers(members []apitypes.KeyringMember) int {
+	owners := make(map[identity.ID]bool)
+	for _, m := range members {
+		if !m.Revoked {
+			owners[*m.OwnerID] = true
+		}
+	}
+	return len(owners)
+}
+
+func keyringMembersCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "A path is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	pe, err := pathexp.Parse(args[0])
+	if err != nil {
+		return errs.NewUsageExitError("Invalid path: "+err.Error(), ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, pe.Org.String())
+	if err != nil {
+		return err
+	}
+
+	keyrings, err := client.Keyrings.Members(c, org.ID, pe.String())
+	if err != nil {
+		return errs.NewErrorExitError(keyringMembersFailed, err)
+	}
+
+	if len(keyrings) == 0 {
+		fmt.Printf("No keyrings hold secrets for %s.\n", pe)
+		return nil
+	}
+
+	var ownerIDs []*identity.ID
+	for _, k := range keyrings {
+		for _, m := range k.Members {
+			ownerIDs = append(ownerIDs, m.OwnerID)
+		}
+	}
+
+	names, err := ownerNames(c, client, org.ID, ownerIDs)
+	if err != nil {
+		return errs.NewErrorExitError(keyringMembersFailed, err)
+	}
+
+	userType := (&primitive.User{}).Type()
+	for i, k := range keyrings {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		fmt.Printf("%s (version %d", k.PathExp, k.KeyringVersion)
+		if k.Frozen {
+			fmt.Print(", frozen")
+		}
+		fmt.Println(")")
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+		fmt.Fprintln(w, tableHeader("MEMBER", "TYPE", "PUBLIC KEY", "ENCRYPTED BY", "SHARED", "STATUS"))
+		for _, m := range k.Members {
+			name, ok := names[*m.OwnerID]
+			if !ok {
+				name = m.OwnerID.String()
+			}
+
+			kind := "machine"
+			if m.OwnerID.Type() == userType {
+				kind = "user"
+			}
+
+			status := "active"
+			if m.Revoked {
+				status = "revoked"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", name, kind, m.PublicKeyID,
+				m.EncryptingKeyID, m.Created.Local().Format("2006-01-02"), status)
+		}
+		w.Flush()
+	}
+
+	return nil
+}
+
+func rotateKeyringCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "A path is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	pe, err := pathexp.Parse(args[0])
+	if err != nil {
+		return errs.NewUsageExitError("Invalid path: "+err.Error(), ctx)
+	}
+
+	preamble := fmt.Sprintf("You are about to rotate the keyring for %s. Every secret in it "+
+		"will be encrypted again with a new key, shared only with those who currently "+
+		"have access to it.", pe)
+	abortErr := ConfirmDialogue(ctx, nil, &preamble, "", true)
+	if abortErr != nil {
+		return abortErr
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	rotation, err := client.Keyrings.Rotate(context.Background(), pe.String(), &progress)
+	if err != nil {
+		return errs.NewErrorExitError(rotateKeyringFailed, err)
+	}
+
+	fmt.Printf("\nKeyring for %s rotated to version %d.\n", rotation.PathExp, rotation.KeyringVersion)
+	fmt.Printf("%d secrets were encrypted again, and shared with %d members.\n",
+		rotation.Credentials, rotation.Members)
+
+	hints.Display([]string{"worklog"})
+	return nil
+}
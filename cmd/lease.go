@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+func init() {
+	lease := cli.Command{
+		Name:      "lease",
+		Usage:     "Print the secrets leased to a command run with torus run --lease",
+		ArgsUsage: "[name]",
+		Category:  "SECRETS",
+		Action:    chain(ensureDaemon, leaseCmd),
+	}
+
+	Cmds = append(Cmds, lease)
+}
+
+func leaseCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) > 1 {
+		return errs.NewUsageExitError("Too many arguments", ctx)
+	}
+
+	raw, ok := os.LookupEnv(leaseEnv)
+	if !ok {
+		return errs.NewExitError(leaseEnv + " is not set. Run the command with 'torus run --lease'.")
+	}
+
+	id, token, err := parseLease(raw)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+
+	vars, err := client.Leases.Get(context.Background(), id, token)
+	if err != nil {
+		return errs.NewErrorExitError("Could not read leased secrets.", err)
+	}
+
+	for _, v := range vars {
+		if len(args) == 0 {
+			fmt.Printf("%s=%s\n", v.Name, v.Value)
+		} else if v.Name == args[0] {
+			fmt.Println(v.Value)
+			return nil
+		}
+	}
+
+	if len(args) != 0 {
+		return errs.NewExitError(args[0] + " is not leased.")
+	}
+	return nil
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/urfave/cli"
 
 	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/dirprefs"
 	"github.com/manifoldco/torus-cli/errs"
@@ -151,6 +153,12 @@ func linkCmd(ctx *cli.Context) error {
 		return err
 	}
 
+	// Have the daemon warm its cache for the secrets `torus run` will most
+	// likely ask for next. This is only an optimization; ignore failures.
+	if preferences.Cache.Prefetch {
+		prefetchLinkedPath(c, client, preferences, oName, pName)
+	}
+
 	// Display the output
 	fmt.Println("\nThis directory and its subdirectories have been linked to:")
 	w := tabwriter.NewWriter(os.Stdout, 2, 0, 1, ' ', 0)
@@ -166,3 +174,79 @@ func linkCmd(ctx *cli.Context) error {
 	hints.Display([]string{"context", "set", "run", "view"})
 	return nil
 }
+
+// prefetchLinkedPath registers the path `torus run` would read secrets from
+// in the newly linked directory with the daemon's credential prefetch cache.
+func prefetchLinkedPath(c context.Context, client *api.Client, preferences *prefs.Preferences,
+	org, project string) {
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return
+	}
+
+	dc := &dirprefs.Context{}
+	defaults := prefs.Defaults{}
+	if preferences.Core.Context {
+		d, err := dirprefs.Load(true)
+		if err != nil {
+			return
+		}
+		dc, err = d.Resolve(os.Getenv("TORUS_ENVIRONMENT"), os.Getenv("TORUS_SERVICE"))
+		if err != nil {
+			return
+		}
+		defaults = preferences.Defaults
+	}
+
+	path, ok := linkedRunPath(os.Getenv, dc, defaults, org, project,
+		session.Type(), session.Username())
+	if !ok {
+		return
+	}
+
+	client.Credentials.Prefetch(c, path)
+}
+
+// linkedRunPath returns the path `torus run` would read secrets from in a
+// directory linked to org and project. Like run's flags, each part is taken
+// from the environment, then the directory's resolved context dc, then the
+// default preferences, and then run's own defaults. It returns false if
+// there is no environment to use, as machines have no default one.
+func linkedRunPath(getenv func(string) string, dc *dirprefs.Context, defaults prefs.Defaults,
+	org, project string, sessionType apitypes.SessionType, username string) (string, bool) {
+
+	pick := func(values ...string) string {
+		for _, v := range values {
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	var devEnv string
+	if sessionType == apitypes.UserSession {
+		devEnv = "dev-" + username
+	}
+
+	identity, err := identityString(string(sessionType), username)
+	if err != nil {
+		return "", false
+	}
+
+	environment := pick(getenv("TORUS_ENVIRONMENT"), dc.Environment, defaults.Environment, devEnv)
+	if environment == "" {
+		return "", false
+	}
+
+	return strings.Join([]string{
+		"",
+		pick(getenv("TORUS_ORG"), dc.Organization, org),
+		pick(getenv("TORUS_PROJECT"), dc.Project, project),
+		environment,
+		pick(getenv("TORUS_SERVICE"), dc.Service, defaults.Service, "default"),
+		identity,
+		pick(getenv("TORUS_INSTANCE"), "1"),
+	}, "/"), true
+}
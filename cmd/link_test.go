@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/dirprefs"
+	"github.com/manifoldco/torus-cli/prefs"
+)
+
+func TestLinkedRunPath(t *testing.T) {
+	tcs := []struct {
+		name        string
+		env         map[string]string
+		dc          dirprefs.Context
+		defaults    prefs.Defaults
+		sessionType apitypes.SessionType
+		path        string
+	}{
+		{
+			name:        "run defaults",
+			sessionType: apitypes.UserSession,
+			path:        "/acme/api/dev-jo/default/jo/1",
+		},
+		{
+			name:        "preferences",
+			defaults:    prefs.Defaults{Environment: "staging", Service: "web"},
+			sessionType: apitypes.UserSession,
+			path:        "/acme/api/staging/web/jo/1",
+		},
+		{
+			name:        "directory context",
+			dc:          dirprefs.Context{Project: "frontend", Environment: "qa", Service: "www"},
+			defaults:    prefs.Defaults{Environment: "staging", Service: "web"},
+			sessionType: apitypes.UserSession,
+			path:        "/acme/frontend/qa/www/jo/1",
+		},
+		{
+			name: "environment variables",
+			env: map[string]string{
+				"TORUS_ENVIRONMENT": "production",
+				"TORUS_SERVICE":     "worker",
+				"TORUS_INSTANCE":    "2",
+			},
+			dc:          dirprefs.Context{Environment: "qa", Service: "www"},
+			sessionType: apitypes.UserSession,
+			path:        "/acme/api/production/worker/jo/2",
+		},
+		{
+			name:        "machine",
+			defaults:    prefs.Defaults{Environment: "production"},
+			sessionType: apitypes.MachineSession,
+			path:        "/acme/api/production/default/machine-jo/1",
+		},
+		{name: "machine without environment", sessionType: apitypes.MachineSession},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			getenv := func(key string) string { return tc.env[key] }
+
+			path, ok := linkedRunPath(getenv, &tc.dc, tc.defaults, "acme", "api", tc.sessionType, "jo")
+			if ok != (tc.path != "") {
+				t.Fatalf("expected a path %t, got %t", tc.path != "", ok)
+			}
+			if path != tc.path {
+				t.Errorf("wrong path: %q != %q", path, tc.path)
+			}
+		})
+	}
+}
@@ -16,7 +16,13 @@ func init() {
 		Name:     "login",
 		Usage:    "Log in to a user account, authenticating the CLI",
 		Category: "ACCOUNT",
-		Action:   chain(ensureDaemon, login),
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "keychain",
+				Usage: "Save your login to the system keychain, so the daemon logs in again when it restarts",
+			},
+		},
+		Action: chain(ensureDaemon, login),
 	}
 	Cmds = append(Cmds, login)
 }
@@ -40,11 +46,24 @@ func login(ctx *cli.Context) error {
 	client := api.NewClient(cfg)
 
 	c := context.Background()
-	return performLogin(c, client, email, password, true)
+	err = performLogin(c, client, email, password, true)
+	if err != nil || !ctx.Bool("keychain") {
+		return err
+	}
+
+	err = client.Session.SaveToKeychain(c)
+	if err != nil {
+		return errs.NewErrorExitError("Could not save your login to the keychain.", err)
+	}
+
+	fmt.Println("Your login is saved to the keychain.")
+	return nil
 }
 
 func performLogin(c context.Context, client *api.Client, email, password string, shouldPrint bool) error {
-	err := client.Session.UserLogin(context.Background(), email, password)
+	err := withMFA(c, func(c context.Context) error {
+		return client.Session.UserLogin(c, email, password)
+	})
 	if err != nil {
 		return errs.NewErrorExitError("Login failed.", err)
 	}
@@ -54,7 +73,3 @@ func performLogin(c context.Context, client *api.Client, email, password string,
 	}
 	return nil
 }
-
-func testLogin(c context.Context, client *api.Client, email, password string) error {
-	return performLogin(c, client, email, password, false)
-}
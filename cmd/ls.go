@@ -14,6 +14,7 @@ import (
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/hints"
 	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
 )
 
 var targetMap = []string{"orgs", "projects", "envs", "services"}
@@ -30,6 +31,13 @@ func init() {
 				Name:  "verbose, v",
 				Usage: "Lists the types of resources and source path (shortcut for --format verbose)",
 			},
+			newSlicePlaceholder("tag", "TAG", "Only list secrets with this tag", "", "", false),
+			newPlaceholder("team", "TEAM",
+				"List the secrets under <path> that this team or machine role can read or write", "", "", false),
+			cli.BoolFlag{
+				Name:  "all",
+				Usage: "With --team, also list secrets the team can't read or write",
+			},
 		},
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
@@ -59,6 +67,13 @@ func listObjects(ctx *cli.Context) error {
 	c := context.Background()
 
 	args := ctx.Args()
+	if ctx.String("team") != "" {
+		return listTeamAccess(c, ctx, client)
+	}
+	if ctx.Bool("all") {
+		return errs.NewUsageExitError("--all can only be used with --team", ctx)
+	}
+
 	recursive := ctx.Bool("recursive")
 
 	format := ctx.String("format")
@@ -80,6 +95,11 @@ func listObjects(ctx *cli.Context) error {
 		return errs.NewUsageExitError("Invalid path supplied", ctx)
 	}
 
+	tags := ctx.StringSlice("tag")
+	if len(tags) > 0 && target != "secrets" {
+		return errs.NewUsageExitError("--tag can only be used when listing secrets", ctx)
+	}
+
 	var orgName string
 	var projectTree api.ProjectTreeSegment
 	var projectMap map[string]envelope.Project
@@ -150,6 +170,9 @@ func listObjects(ctx *cli.Context) error {
 				continue
 			}
 			name := body.GetName()
+			if !hasTags(body.GetMeta(), tags) {
+				continue
+			}
 			if matchPathSegment(targetName, name) {
 				paths = append(paths, fmt.Sprintf("%s/%s", body.GetPathExp(), name))
 			}
@@ -174,6 +197,16 @@ func listObjects(ctx *cli.Context) error {
 	return nil
 }
 
+// hasTags returns whether meta has every one of tags.
+func hasTags(meta *primitive.CredentialMeta, tags []string) bool {
+	for _, tag := range tags {
+		if !meta.HasTag(strings.ToLower(tag)) {
+			return false
+		}
+	}
+	return true
+}
+
 // return a map of the projects which match the supplied pathexp
 func matchingProjects(pexp *pathexp.PathExp, tree api.ProjectTreeSegment) map[string]envelope.Project {
 	projectMap := make(map[string]envelope.Project)
@@ -268,6 +301,7 @@ func projectTreeForOrg(c context.Context, client *api.Client, cpathExp *pathexp.
 		return nil, errs.NewErrorExitError("Could not retrieve project information", err)
 	}
 	if len(projectTree) > 0 {
+		projectTree[0].Projects = activeProjects(projectTree[0].Projects)
 		return &projectTree[0], nil
 	}
 
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/policyeval"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/cmd/output"
+)
+
+const listTeamFailed = "Could not list the team's access."
+
+// listTeamAccess lists the secrets under the given path, and whether the
+// team given by --team can read or write each of them, as decided by the
+// policies attached to the team.
+//
+// Only secrets the current user can see are listed. Each is checked the way
+// `torus policies test` checks a resource, so statements that use
+// ${username}, or conditions that require a recent login, never allow
+// access.
+func listTeamAccess(c context.Context, ctx *cli.Context, client *api.Client) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		return errs.NewUsageExitError("A path is required with --team, such as /org or /org/project.", ctx)
+	}
+	if len(ctx.StringSlice("tag")) > 0 {
+		return errs.NewUsageExitError("--tag can't be used with --team", ctx)
+	}
+
+	pe, err := pathexp.ParsePartial(args[0])
+	if err != nil {
+		return errs.NewUsageExitError("Invalid path: "+err.Error(), ctx)
+	}
+	if pe.Org.String() == "*" {
+		return errs.NewUsageExitError("The path must name an org.", ctx)
+	}
+
+	org, err := getOrg(c, client, pe.Org.String())
+	if err != nil {
+		return err
+	}
+
+	teamName := ctx.String("team")
+	teams, err := client.Teams.GetByName(c, org.ID, teamName)
+	if err != nil {
+		return errs.NewErrorExitError("Unable to lookup team.", err)
+	}
+	if len(teams) < 1 {
+		return errs.NewExitError("Team not found.")
+	}
+	team := teams[0]
+
+	policies, err := attachedPolicies(c, client, org.ID, []identity.ID{*team.ID})
+	if err != nil {
+		return errs.NewErrorExitError(listTeamFailed, err)
+	}
+
+	locations, err := client.Credentials.SearchNames(c, org.ID, "*", false, pe.String(), false)
+	if err != nil {
+		return errs.NewErrorExitError(listTeamFailed, err)
+	}
+
+	vars := policyeval.Vars{Org: org.Body.Name}
+	req := &policyeval.Request{Time: time.Now()}
+	if team.Body.TeamType == primitive.MachineTeamType {
+		req.MachineTeams = []string{team.Body.Name}
+	}
+
+	records := []output.SecretAccess{}
+	hidden := 0
+	for _, l := range locations {
+		raw := l.PathExp.String() + "/" + l.Name
+		resource, err := policyeval.ParseResource(raw)
+		if err != nil {
+			return errs.NewErrorExitError(listTeamFailed, err)
+		}
+
+		decisions, err := policyeval.Evaluate(policies, vars, req,
+			primitive.PolicyActionRead|primitive.PolicyActionUpdate, resource)
+		if err != nil {
+			return errs.NewErrorExitError(listTeamFailed, err)
+		}
+
+		access := output.SecretAccess{Path: l.PathExp.String(), Name: l.Name}
+		for _, d := range decisions {
+			switch d.Action {
+			case primitive.PolicyActionRead:
+				access.Read = d.Allowed
+			case primitive.PolicyActionUpdate:
+				access.Write = d.Allowed
+			}
+		}
+
+		if !access.Read && !access.Write && !ctx.Bool("all") {
+			hidden++
+			continue
+		}
+		records = append(records, access)
+	}
+
+	if output.IsJSON(ctx) {
+		return output.Write(os.Stdout, records)
+	}
+
+	fmt.Printf("Access for team %s to %s:\n\n", team.Body.Name, pe)
+	if len(records) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+		fmt.Fprintln(w, tableHeader("ACCESS", "SECRET"))
+		for _, r := range records {
+			fmt.Fprintf(w, "%s\t%s/%s\n", accessString(r.Read, r.Write), r.Path, r.Name)
+		}
+		w.Flush()
+	} else {
+		fmt.Println("The team can't read or write any of the secrets you can see.")
+	}
+
+	if hidden > 0 {
+		fmt.Printf("\n%d other secrets can't be read or written by the team; use --all to list them.\n", hidden)
+	}
+
+	return nil
+}
+
+// accessString describes read and write access as ls -l does.
+func accessString(read, write bool) string {
+	s := []byte("--")
+	if read {
+		s[0] = 'r'
+	}
+	if write {
+		s[1] = 'w'
+	}
+	return string(s)
+}
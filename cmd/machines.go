@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
+	"net"
 	"os"
 	"strings"
 	"text/tabwriter"
@@ -85,6 +86,24 @@ func init() {
 					checkRequiredFlags, destroyMachineCmd,
 				),
 			},
+			{
+				Name:      "restrict",
+				Usage:     "Limit the networks a machine's tokens can be used from",
+				ArgsUsage: "<id|name>",
+				Flags: []cli.Flag{
+					orgFlag("Org the machine belongs to", true),
+					newSlicePlaceholder("cidr", "CIDR",
+						"Network to allow logins from, e.g. 10.0.0.0/8", "", "", false),
+					cli.BoolFlag{
+						Name:  "clear",
+						Usage: "Allow logins from any network",
+					},
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, restrictMachineCmd,
+				),
+			},
 			{
 				Name:      "roles",
 				Usage:     "Lists and create machine roles for an organization",
@@ -177,6 +196,98 @@ func destroyMachineCmd(ctx *cli.Context) error {
 	return nil
 }
 
+func restrictMachineCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) > 1 {
+		return errs.NewUsageExitError("Too many arguments supplied.", ctx)
+	}
+	if len(args) < 1 {
+		return errs.NewUsageExitError("Name or ID is required", ctx)
+	}
+
+	cidrs, err := parseCIDRs(ctx.StringSlice("cidr"))
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+	if len(cidrs) == 0 && !ctx.Bool("clear") {
+		return errs.NewUsageExitError("Either --cidr or --clear is required", ctx)
+	}
+	if len(cidrs) > 0 && ctx.Bool("clear") {
+		return errs.NewUsageExitError("--cidr and --clear cannot be used together", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return errs.NewErrorExitError("Machine restrict failed", err)
+	}
+	if org == nil {
+		return errs.NewExitError("Org not found.")
+	}
+
+	machineID, err := identity.DecodeFromString(args[0])
+	if err != nil {
+		name := args[0]
+		machines, lErr := client.Machines.List(c, org.ID, nil, &name, nil)
+		if lErr != nil {
+			return errs.NewErrorExitError("Failed to retrieve machine", lErr)
+		}
+		if len(machines) < 1 {
+			return errs.NewExitError("Machine not found")
+		}
+		machineID = *machines[0].Machine.ID
+	}
+
+	err = client.Machines.Restrict(c, &machineID, cidrs)
+	if err != nil {
+		return errs.NewErrorExitError("Failed to restrict machine", err)
+	}
+
+	if len(cidrs) == 0 {
+		fmt.Println("Machine tokens can now be used from any network.")
+	} else {
+		fmt.Printf("Machine tokens can now only be used from %s.\n", strings.Join(cidrs, ", "))
+	}
+
+	return nil
+}
+
+// parseCIDRs validates a list of networks in CIDR notation, returning them
+// in canonical form. A bare IP address is treated as a single host.
+func parseCIDRs(in []string) ([]string, error) {
+	cidrs := []string{}
+	for _, c := range in {
+		if !strings.Contains(c, "/") {
+			ip := net.ParseIP(c)
+			if ip == nil {
+				return nil, fmt.Errorf("Invalid CIDR: %s", c)
+			}
+
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			c = fmt.Sprintf("%s/%d", c, bits)
+		}
+
+		_, ipNet, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CIDR: %s", c)
+		}
+
+		cidrs = append(cidrs, ipNet.String())
+	}
+
+	return cidrs, nil
+}
+
 func viewMachineCmd(ctx *cli.Context) error {
 	args := ctx.Args()
 	if len(args) > 1 {
@@ -290,15 +401,19 @@ func viewMachineCmd(ctx *cli.Context) error {
 	fmt.Println("")
 
 	w2 := tabwriter.NewWriter(os.Stdout, 0, 0, 8, ' ', 0)
-	fmt.Fprintf(w2, "TOKEN ID\tSTATE\tCREATED BY\tCREATED ON\n")
-	fmt.Fprintln(w2, " \t \t \t ")
+	fmt.Fprintln(w2, tableHeader("TOKEN ID", "STATE", "CREATED BY", "CREATED ON", "ALLOWED FROM"))
+	fmt.Fprintln(w2, " \t \t \t \t ")
 	for _, token := range machineSegment.Tokens {
 		tokenID := token.Token.ID
 		state := token.Token.Body.State
 		creator := profileMap[*token.Token.Body.CreatedBy]
 		createdBy := creator.Body.Username + " (" + creator.Body.Name + ")"
 		createdOn := token.Token.Body.Created.Format(time.RFC3339)
-		fmt.Fprintf(w2, "%s\t%s\t%s\t%s\n", tokenID, state, createdBy, createdOn)
+		allowed := "any"
+		if len(token.Token.Body.AllowedCIDRs) > 0 {
+			allowed = strings.Join(token.Token.Body.AllowedCIDRs, ", ")
+		}
+		fmt.Fprintf(w2, "%s\t%s\t%s\t%s\t%s\n", tokenID, state, createdBy, createdOn, allowed)
 	}
 
 	w2.Flush()
@@ -376,7 +491,7 @@ func listMachinesCmd(ctx *cli.Context) error {
 
 	fmt.Println("")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 8, ' ', 0)
-	fmt.Fprintln(w, "ID\tNAME\tSTATE\tROLE\tCREATION DATE")
+	fmt.Fprintln(w, tableHeader("ID", "NAME", "STATE", "ROLE", "CREATION DATE"))
 	fmt.Fprintln(w, " \t \t \t \t ")
 	for _, machine := range machines {
 		mID := machine.Machine.ID.String()
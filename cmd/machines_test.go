@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	got, err := parseCIDRs([]string{"10.1.2.3/8", "192.168.0.4", "2001:db8::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"10.0.0.0/8", "192.168.0.4/32", "2001:db8::1/128"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	for _, bad := range []string{"10.0.0.0/33", "laptop", "10.0.0/8"} {
+		if _, err := parseCIDRs([]string{bad}); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+func init() {
+	mfa := cli.Command{
+		Name:     "mfa",
+		Usage:    "Manage multi-factor authentication for your account",
+		Category: "ACCOUNT",
+		Subcommands: []cli.Command{
+			{
+				Name:   "status",
+				Usage:  "Show whether multi-factor authentication is enabled",
+				Action: chain(ensureDaemon, ensureSession, mfaStatusCmd),
+			},
+			{
+				Name:   "enable",
+				Usage:  "Require a code from an authenticator app to log in and make sensitive changes",
+				Action: chain(ensureDaemon, ensureSession, mfaEnableCmd),
+			},
+			{
+				Name:   "disable",
+				Usage:  "Stop requiring a code from an authenticator app",
+				Flags:  []cli.Flag{stdAutoAcceptFlag},
+				Action: chain(ensureDaemon, ensureSession, mfaDisableCmd),
+			},
+		},
+	}
+	Cmds = append(Cmds, mfa)
+}
+
+// withMFA calls fn with c. If the registry requires a code from the user's
+// authenticator app, they are prompted for one, and fn is called again with
+// the code.
+func withMFA(c context.Context, fn func(context.Context) error) error {
+	err := fn(c)
+	if !apitypes.IsMFARequiredError(err) {
+		return err
+	}
+
+	code, err := MFACodePrompt()
+	if err != nil {
+		return err
+	}
+
+	return fn(api.WithMFACode(c, code))
+}
+
+// mfaClient returns an api client for a logged in user, as machines can't use
+// multi-factor authentication.
+func mfaClient(c context.Context) (*api.Client, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client := api.NewClient(cfg)
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return nil, errs.NewErrorExitError("Error fetching user details", err)
+	}
+	if session.Type() == apitypes.MachineSession {
+		return nil, errs.NewExitError("Machines cannot use multi-factor authentication")
+	}
+
+	return client, nil
+}
+
+func mfaStatusCmd(ctx *cli.Context) error {
+	c := context.Background()
+	client, err := mfaClient(c)
+	if err != nil {
+		return err
+	}
+
+	status, err := client.MFA.Status(c)
+	if err != nil {
+		return errs.NewErrorExitError("Could not fetch multi-factor authentication status.", err)
+	}
+
+	if !status.Enabled {
+		fmt.Println("Multi-factor authentication is disabled.")
+		return nil
+	}
+
+	if status.EnabledAt != nil {
+		fmt.Printf("Multi-factor authentication is enabled, since %s.\n",
+			status.EnabledAt.Format("2006-01-02"))
+	} else {
+		fmt.Println("Multi-factor authentication is enabled.")
+	}
+	return nil
+}
+
+func mfaEnableCmd(ctx *cli.Context) error {
+	c := context.Background()
+	client, err := mfaClient(c)
+	if err != nil {
+		return err
+	}
+
+	status, err := client.MFA.Status(c)
+	if err != nil {
+		return errs.NewErrorExitError("Could not fetch multi-factor authentication status.", err)
+	}
+	if status.Enabled {
+		fmt.Println("Multi-factor authentication is already enabled.")
+		return nil
+	}
+
+	enrollment, err := client.MFA.Enroll(c)
+	if err != nil {
+		return errs.NewErrorExitError("Could not enable multi-factor authentication.", err)
+	}
+
+	fmt.Println("Add this secret to your authenticator app:")
+	fmt.Println("")
+	fmt.Printf("    %s\n", enrollment.Secret)
+	fmt.Println("")
+	fmt.Println("or add it using this URI:")
+	fmt.Println("")
+	fmt.Printf("    %s\n", enrollment.URI)
+	fmt.Println("")
+	fmt.Println("Then enter the code it generates to finish.")
+
+	code, err := MFACodePrompt()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.MFA.Confirm(c, code)
+	if err != nil {
+		return errs.NewErrorExitError("Could not enable multi-factor authentication.", err)
+	}
+
+	fmt.Println("\nMulti-factor authentication is enabled. You will be asked for a code " +
+		"when you log in, revoke keypairs, or change policies.")
+	return nil
+}
+
+func mfaDisableCmd(ctx *cli.Context) error {
+	c := context.Background()
+	client, err := mfaClient(c)
+	if err != nil {
+		return err
+	}
+
+	preamble := "You are about to disable multi-factor authentication. Your account " +
+		"will be protected by your password alone."
+	abortErr := ConfirmDialogue(ctx, nil, &preamble, "", true)
+	if abortErr != nil {
+		return abortErr
+	}
+
+	err = withMFA(c, client.MFA.Disable)
+	if err != nil {
+		return errs.NewErrorExitError("Could not disable multi-factor authentication.", err)
+	}
+
+	fmt.Println("Multi-factor authentication is disabled.")
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/dirprefs"
 	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/i18n"
 	"github.com/manifoldco/torus-cli/prefs"
 )
 
@@ -63,18 +64,9 @@ func ensureDaemon(ctx *cli.Context) error {
 
 	client := api.NewClient(cfg)
 
-	var v *apitypes.Version
-	increment := 5 * time.Millisecond
-	for d := increment; d < 1*time.Second; d += increment {
-		v, err = client.Version.Get(context.Background())
-		if err == nil {
-			break
-		}
-		time.Sleep(d)
-	}
-
+	v, err := waitForDaemon(client)
 	if err != nil {
-		return errs.NewErrorExitError("Could not communicate with daemon.", err)
+		return err
 	}
 
 	if v.Version == cfg.Version {
@@ -85,8 +77,8 @@ func ensureDaemon(ctx *cli.Context) error {
 		return errs.NewExitError("The daemon version is incorrect. Check for stale processes.")
 	}
 
-	fmt.Println("The daemon version is out of date and is being restarted.")
-	fmt.Println("You will need to login again.")
+	fmt.Println(i18n.T("The daemon version is out of date and is being restarted."))
+	fmt.Println(i18n.T("You will need to login again."))
 
 	_, err = stopDaemon(proc)
 	if err != nil {
@@ -96,6 +88,23 @@ func ensureDaemon(ctx *cli.Context) error {
 	return ensureDaemon(ctx)
 }
 
+// waitForDaemon returns the version of the daemon client talks to, waiting
+// for a moment for a newly spawned daemon to start.
+func waitForDaemon(client *api.Client) (*apitypes.Version, error) {
+	var v *apitypes.Version
+	var err error
+	increment := 5 * time.Millisecond
+	for d := increment; d < 1*time.Second; d += increment {
+		v, err = client.Version.Get(context.Background())
+		if err == nil {
+			return v, nil
+		}
+		time.Sleep(d)
+	}
+
+	return nil, errs.NewErrorExitError("Could not communicate with daemon.", err)
+}
+
 // ensureSession ensures that the user is logged in with the daemon and has a
 // valid session. If not, it will attempt to log the user in via environment
 // variables. If they do not exist, of the login fails, it will abort the
@@ -132,29 +141,31 @@ func ensureSession(ctx *cli.Context) error {
 	tokenSecret, hasTokenSecret := os.LookupEnv("TORUS_TOKEN_SECRET")
 
 	if hasEmail && hasPassword {
-		fmt.Println("Attempting to login with email: " + email)
+		fmt.Println(i18n.T("Attempting to login with email: %s", email))
 
-		err := client.Session.UserLogin(bgCtx, email, password)
+		err := withMFA(bgCtx, func(c context.Context) error {
+			return client.Session.UserLogin(c, email, password)
+		})
 		if err != nil {
-			fmt.Println("Could not log in.\n" + err.Error())
+			fmt.Println(i18n.T("Could not log in.") + "\n" + err.Error())
 		} else {
 			return nil
 		}
 	}
 
 	if hasTokenID && hasTokenSecret {
-		fmt.Println("Attempting to login with token id: " + tokenID)
+		fmt.Println(i18n.T("Attempting to login with token id: %s", tokenID))
 
 		err := client.Session.MachineLogin(bgCtx, tokenID, tokenSecret)
 		if err != nil {
-			fmt.Println("Could not log in\n" + err.Error())
+			fmt.Println(i18n.T("Could not log in.") + "\n" + err.Error())
 		} else {
 			return nil
 		}
 	}
 
-	msg := "You must be logged in to run '" + ctx.Command.FullName() + "'.\n" +
-		"Login using 'login' or create an account using 'signup'."
+	msg := i18n.T("You must be logged in to run '%s'.\n"+
+		"Login using 'login' or create an account using 'signup'.", ctx.Command.FullName())
 	return errs.NewExitError(msg)
 }
 
@@ -165,12 +176,55 @@ func loadDirPrefs(ctx *cli.Context) error {
 		return err
 	}
 
+	// The user has disabled reading arguments from prefs and .torus.json
+	if !p.Core.Context {
+		return nil
+	}
+
 	d, err := dirprefs.Load(true)
 	if err != nil {
 		return err
 	}
 
-	return reflectArgs(ctx, p, d, "json")
+	service := explicitFlag(ctx, "service")
+	dc, err := d.Resolve(explicitFlag(ctx, "environment"), service)
+	if err != nil {
+		return err
+	}
+
+	// A service set in .torus.json replaces the flag's default value.
+	if service == "" && dc.Service != "" && hasFlag(ctx, "service") {
+		ctx.Set("service", dc.Service)
+	}
+
+	return reflectArgs(ctx, p, dc, "json")
+}
+
+// explicitFlag returns the value of the named flag if it was given on the
+// command line or through its environment variable, and holds a single value.
+func explicitFlag(ctx *cli.Context, name string) string {
+	if !hasFlag(ctx, name) || !ctx.IsSet(name) {
+		return ""
+	}
+
+	if values, ok := ctx.Generic(name).(*cli.StringSlice); ok {
+		if len(*values) == 1 {
+			return (*values)[0]
+		}
+		return ""
+	}
+
+	return ctx.String(name)
+}
+
+// hasFlag returns whether the command has the named flag.
+func hasFlag(ctx *cli.Context, name string) bool {
+	for _, n := range ctx.FlagNames() {
+		if n == name {
+			return true
+		}
+	}
+	return false
 }
 
 // loadPrefDefaults loads default argument values from the .torusrc
@@ -230,14 +284,7 @@ func reflectArgs(ctx *cli.Context, p *prefs.Preferences, i interface{},
 func setUserEnv(ctx *cli.Context) error {
 	argName := "environment"
 	// Check for env flag, just in case this middleware is misused
-	hasEnvFlag := false
-	for _, name := range ctx.FlagNames() {
-		if name == argName {
-			hasEnvFlag = true
-			break
-		}
-	}
-	if !hasEnvFlag {
+	if !hasFlag(ctx, argName) {
 		return nil
 	}
 
@@ -325,7 +372,7 @@ func checkRequiredFlags(ctx *cli.Context) error {
 	}
 
 	if len(missing) > 0 {
-		msg := "Missing flags: " + strings.Join(missing, ", ")
+		msg := i18n.T("Missing flags: %s", strings.Join(missing, ", "))
 		return errs.NewUsageExitError(msg, ctx)
 	}
 
@@ -0,0 +1,755 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/migrate"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func init() {
+	migrateCmd := cli.Command{
+		Name:     "migrate",
+		Usage:    "Move an org to another registry",
+		Category: "ORGANIZATIONS",
+		Subcommands: []cli.Command{
+			{
+				Name:  "registry",
+				Usage: "Copy an org, and the secrets you can read, from one registry to another",
+				Flags: []cli.Flag{
+					newPlaceholder("from", "URL", "Registry to copy the org from", "", "", true),
+					newPlaceholder("to", "URL", "Registry to copy the org to", "", "", true),
+					orgFlag("Org to migrate", true),
+				},
+				Action: chain(checkRequiredFlags, migrateRegistryCmd),
+			},
+		},
+	}
+
+	Cmds = append(Cmds, migrateCmd)
+}
+
+const migrateFailed = "Could not migrate org."
+
+func migrateRegistryCmd(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	from, err := parseRegistryURL(ctx.String("from"))
+	if err != nil {
+		return errs.NewUsageExitError("Invalid --from: "+err.Error(), ctx)
+	}
+	to, err := parseRegistryURL(ctx.String("to"))
+	if err != nil {
+		return errs.NewUsageExitError("Invalid --to: "+err.Error(), ctx)
+	}
+	if sameRegistry(from, to) {
+		return errs.NewUsageExitError("--from and --to must be different registries", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	orgName := ctx.String("org")
+	state, err := migrate.Load(migrate.StatePath(cfg.TorusRoot, from, to, orgName), from, to, orgName)
+	if err != nil {
+		return errs.NewErrorExitError("Could not read migration state.", err)
+	}
+
+	src, stopSrc, err := migrationClient(ctx, cfg, from)
+	if err != nil {
+		return err
+	}
+	defer stopSrc()
+
+	dst, stopDst, err := migrationClient(ctx, cfg, to)
+	if err != nil {
+		return err
+	}
+	defer stopDst()
+
+	m := &registryMigration{
+		ctx:   ctx,
+		c:     context.Background(),
+		src:   src,
+		dst:   dst,
+		state: state,
+	}
+
+	err = m.run(orgName)
+	if saveErr := state.Save(); saveErr != nil && err == nil {
+		err = errs.NewErrorExitError("Could not save migration state.", saveErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	printMigrationSummary(state)
+	return nil
+}
+
+// parseRegistryURL parses the URL of a registry given on the command line.
+func parseRegistryURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSuffix(raw, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+		return nil, errors.New("expected a URL such as https://registry.example.com")
+	}
+
+	return u, nil
+}
+
+func sameRegistry(a, b *url.URL) bool {
+	return a.Scheme == b.Scheme && a.Host == b.Host &&
+		strings.TrimSuffix(a.Path, "/") == strings.TrimSuffix(b.Path, "/")
+}
+
+// migrationClient returns a client for a daemon talking to the registry at u,
+// logged in to it, and a function that stops the daemon once the migration is
+// done.
+//
+// The configured registry is reached through the usual daemon. Others are
+// reached through a daemon of their own, with its own Torus root inside
+// ours, started for the migration.
+func migrationClient(ctx *cli.Context, cfg *config.Config, u *url.URL) (*api.Client, func(), error) {
+	if sameRegistry(u, cfg.RegistryURI) {
+		if err := ensureDaemon(ctx); err != nil {
+			return nil, nil, err
+		}
+		if err := ensureSession(ctx); err != nil {
+			return nil, nil, err
+		}
+		return api.NewClient(cfg), func() {}, nil
+	}
+
+	root := migrate.RegistryRoot(cfg.TorusRoot, u)
+	err := os.MkdirAll(root, 0700)
+	if err != nil {
+		return nil, nil, errs.NewErrorExitError("Could not create "+root+".", err)
+	}
+
+	rcfg, err := config.NewConfig(root)
+	if err != nil {
+		return nil, nil, errs.NewErrorExitError("Failed to load config.", err)
+	}
+	rcfg.RegistryURI = u
+
+	proc, err := findDaemon(rcfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if proc == nil {
+		env := []string{"TORUS_ROOT=" + root, "TORUS_REGISTRY_URI=" + u.String()}
+		for _, e := range filterEnv() {
+			if !strings.HasPrefix(e, "TORUS_ROOT=") && !strings.HasPrefix(e, "TORUS_REGISTRY_URI=") {
+				env = append(env, e)
+			}
+		}
+
+		err = spawnDaemonEnv(env)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	stop := func() {
+		if proc, _ := findDaemon(rcfg); proc != nil {
+			stopDaemon(proc)
+		}
+	}
+
+	client := api.NewClient(rcfg)
+	_, err = waitForDaemon(client)
+	if err == nil {
+		err = migrationLogin(client, u)
+	}
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+
+	return client, stop, nil
+}
+
+// migrationLogin logs in to the registry at u, through client, unless it is
+// already logged in.
+func migrationLogin(client *api.Client, u *url.URL) error {
+	c := context.Background()
+	_, err := client.Session.Get(c)
+	if err == nil {
+		return nil
+	}
+	if cerr, ok := err.(*apitypes.Error); !ok || cerr.Type != apitypes.UnauthorizedError {
+		return errs.NewErrorExitError("Could not communicate with daemon.", err)
+	}
+
+	if promptsDisabled() {
+		return errs.NewExitError("You must log in to " + u.Host + " to migrate, but prompts are disabled.")
+	}
+
+	fmt.Printf("Log in to %s\n", u.Host)
+	email, err := EmailPrompt("")
+	if err != nil {
+		return err
+	}
+	password, err := PasswordPrompt(false, nil)
+	if err != nil {
+		return err
+	}
+
+	return performLogin(c, client, email, password, false)
+}
+
+// registryMigration copies an org from the src registry to the dst registry,
+// recording each object in state as it goes. Objects that can't be copied
+// are recorded rather than stopping the migration, so that as much as
+// possible is copied on each run.
+type registryMigration struct {
+	ctx   *cli.Context
+	c     context.Context
+	src   *api.Client
+	dst   *api.Client
+	state *migrate.State
+
+	srcOrg *envelope.Org
+	dstOrg *envelope.Org
+
+	// The destination's projects, teams, and policies by name, and the
+	// names of the source's teams and policies by ID.
+	projects    map[string]*identity.ID
+	teams       map[string]*identity.ID
+	policies    map[string]*identity.ID
+	srcTeams    map[identity.ID]*envelope.Team
+	srcPolicies map[identity.ID]string
+}
+
+func (m *registryMigration) run(orgName string) error {
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"org", func() error { return m.migrateOrg(orgName) }},
+		{"projects", m.migrateProjects},
+		{"environments and services", m.migrateEnvsAndServices},
+		{"teams", m.migrateTeams},
+		{"members", m.migrateMembers},
+		{"machines", m.migrateMachines},
+		{"policies", m.migratePolicies},
+		{"secrets", m.migrateCredentials},
+		{"keyring access", m.shareKeyrings},
+	}
+
+	for _, step := range steps {
+		fmt.Printf("Copying %s...\n", step.name)
+		err := step.fn()
+		if err != nil {
+			return err
+		}
+
+		// Save after every step, so an interrupted migration keeps as
+		// much of its progress as possible.
+		err = m.state.Save()
+		if err != nil {
+			return errs.NewErrorExitError("Could not save migration state.", err)
+		}
+	}
+
+	return nil
+}
+
+// record sets the status of an object, using err as the reason it failed,
+// if set.
+func (m *registryMigration) record(kind, name, source string, err error) {
+	if err != nil {
+		m.state.Record(kind, name, migrate.Failed, source, err.Error())
+		return
+	}
+	m.state.Record(kind, name, migrate.Done, source, "")
+}
+
+func (m *registryMigration) migrateOrg(name string) error {
+	var err error
+	m.srcOrg, err = getOrg(m.c, m.src, name)
+	if err != nil {
+		return err
+	}
+
+	m.dstOrg, err = m.dst.Orgs.GetByName(m.c, name)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+	if m.dstOrg == nil {
+		m.dstOrg, err = m.dst.Orgs.Create(m.c, name)
+		if err != nil {
+			return errs.NewErrorExitError("Could not create org on the destination registry.", err)
+		}
+	}
+
+	// Secrets can't be encrypted for the org until we have keypairs in it.
+	keypairs, err := m.dst.Keypairs.List(m.c, m.dstOrg.ID)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+	usable := false
+	for _, kp := range keypairs {
+		usable = usable || !kp.Revoked()
+	}
+	if !usable {
+		err = generateKeypairsForOrg(m.c, m.ctx, m.dst, m.dstOrg.ID, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	m.record(migrate.Org, name, "", nil)
+	return nil
+}
+
+func (m *registryMigration) migrateProjects() error {
+	srcProjects, err := m.src.Projects.List(m.c, &[]*identity.ID{m.srcOrg.ID}, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+	dstProjects, err := m.dst.Projects.List(m.c, &[]*identity.ID{m.dstOrg.ID}, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+
+	m.projects = make(map[string]*identity.ID, len(dstProjects))
+	for _, p := range dstProjects {
+		m.projects[p.Body.Name] = p.ID
+	}
+
+	for _, p := range srcProjects {
+		name := p.Body.Name
+		if _, ok := m.projects[name]; !ok {
+			created, err := m.dst.Projects.Create(m.c, m.dstOrg.ID, name)
+			if err == nil {
+				m.projects[name] = created.ID
+			}
+			m.record(migrate.Project, name, "", err)
+			continue
+		}
+		m.record(migrate.Project, name, "", nil)
+	}
+
+	return nil
+}
+
+func (m *registryMigration) migrateEnvsAndServices() error {
+	srcOrgs := &[]*identity.ID{m.srcOrg.ID}
+	dstOrgs := &[]*identity.ID{m.dstOrg.ID}
+
+	srcProjects, err := m.src.Projects.List(m.c, srcOrgs, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+	projectNames := make(map[identity.ID]string, len(srcProjects))
+	for _, p := range srcProjects {
+		projectNames[*p.ID] = p.Body.Name
+	}
+
+	srcEnvs, err := m.src.Environments.List(m.c, srcOrgs, nil, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+	dstEnvs, err := m.dst.Environments.List(m.c, dstOrgs, nil, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+	existing := make(map[string]bool, len(dstEnvs))
+	for _, e := range dstEnvs {
+		existing[e.Body.ProjectID.String()+"/"+e.Body.Name] = true
+	}
+
+	for _, e := range srcEnvs {
+		m.copyProjectObject(migrate.Environment, projectNames[*e.Body.ProjectID], e.Body.Name,
+			existing, m.dst.Environments.Create)
+	}
+
+	srcServices, err := m.src.Services.List(m.c, srcOrgs, nil, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+	dstServices, err := m.dst.Services.List(m.c, dstOrgs, nil, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+	existing = make(map[string]bool, len(dstServices))
+	for _, s := range dstServices {
+		existing[s.Body.ProjectID.String()+"/"+s.Body.Name] = true
+	}
+
+	for _, s := range srcServices {
+		m.copyProjectObject(migrate.Service, projectNames[*s.Body.ProjectID], s.Body.Name,
+			existing, m.dst.Services.Create)
+	}
+
+	return nil
+}
+
+// copyProjectObject creates an environment or service in a project on the
+// destination, unless it is in existing.
+func (m *registryMigration) copyProjectObject(kind, project, name string, existing map[string]bool,
+	create func(context.Context, *identity.ID, *identity.ID, string) error) {
+
+	projectID, ok := m.projects[project]
+	if !ok {
+		m.state.Record(kind, project+"/"+name, migrate.Waiting, "", "its project has not been copied")
+		return
+	}
+
+	var err error
+	if !existing[projectID.String()+"/"+name] {
+		err = create(m.c, m.dstOrg.ID, projectID, name)
+	}
+	m.record(kind, project+"/"+name, "", err)
+}
+
+func (m *registryMigration) migrateTeams() error {
+	srcTeams, err := m.src.Teams.GetByOrg(m.c, m.srcOrg.ID)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+	dstTeams, err := m.dst.Teams.GetByOrg(m.c, m.dstOrg.ID)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+
+	m.teams = make(map[string]*identity.ID, len(dstTeams))
+	for _, t := range dstTeams {
+		m.teams[t.Body.Name] = t.ID
+	}
+
+	m.srcTeams = make(map[identity.ID]*envelope.Team, len(srcTeams))
+	for i, t := range srcTeams {
+		m.srcTeams[*t.ID] = &srcTeams[i]
+		name := t.Body.Name
+		if _, ok := m.teams[name]; ok {
+			m.record(migrate.Team, name, "", nil)
+			continue
+		}
+
+		if t.Body.TeamType == primitive.SystemTeamType {
+			m.state.Record(migrate.Team, name, migrate.Failed, "",
+				"system team is missing from the destination registry")
+			continue
+		}
+
+		created, err := m.dst.Teams.Create(m.c, m.dstOrg.ID, name, t.Body.TeamType)
+		if err == nil {
+			m.teams[name] = created.ID
+		}
+		m.record(migrate.Team, name, "", err)
+	}
+
+	return nil
+}
+
+// migrateMembers adds the users of the source org to the same teams on the
+// destination. Users have to sign up to the destination registry, and be
+// invited to the org there, before they can be added; until then they are
+// recorded as waiting.
+func (m *registryMigration) migrateMembers() error {
+	memberships, err := m.src.Memberships.List(m.c, m.srcOrg.ID, nil, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+
+	teams := make(map[identity.ID][]string)
+	var ownerIDs []identity.ID
+	for _, ms := range memberships {
+		team, ok := m.srcTeams[*ms.Body.TeamID]
+		if !ok || team.Body.TeamType == primitive.MachineTeamType {
+			continue
+		}
+
+		owner := *ms.Body.OwnerID
+		if _, ok := teams[owner]; !ok {
+			ownerIDs = append(ownerIDs, owner)
+		}
+		teams[owner] = append(teams[owner], team.Body.Name)
+	}
+	if len(ownerIDs) == 0 {
+		return nil
+	}
+
+	profiles, err := m.src.Profiles.ListByID(m.c, ownerIDs)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+
+	for _, p := range *profiles {
+		names := teams[*p.ID]
+		sort.Strings(names)
+		m.migrateMember(p.Body.Username, names)
+	}
+
+	return nil
+}
+
+func (m *registryMigration) migrateMember(username string, teamNames []string) {
+	source := strings.Join(teamNames, ",")
+	if m.state.Done(migrate.Member, username, source) {
+		return
+	}
+
+	profile, err := m.dst.Profiles.ListByName(m.c, username)
+	if apitypes.IsNotFoundError(err) || (err == nil && profile == nil) {
+		m.state.Record(migrate.Member, username, migrate.Waiting, source,
+			"has not signed up to the destination registry")
+		return
+	}
+	if err != nil {
+		m.record(migrate.Member, username, source, err)
+		return
+	}
+
+	current, err := m.dst.Memberships.List(m.c, m.dstOrg.ID, profile.ID, nil)
+	if err != nil {
+		m.record(migrate.Member, username, source, err)
+		return
+	}
+	if len(current) == 0 {
+		m.state.Record(migrate.Member, username, migrate.Waiting, source,
+			"has not been invited to the org on the destination registry")
+		return
+	}
+
+	onTeam := make(map[identity.ID]bool, len(current))
+	for _, ms := range current {
+		onTeam[*ms.Body.TeamID] = true
+	}
+
+	for _, name := range teamNames {
+		teamID, ok := m.teams[name]
+		if !ok {
+			err = errors.New("team " + name + " has not been copied")
+			break
+		}
+		if onTeam[*teamID] {
+			continue
+		}
+
+		err = m.dst.Memberships.Create(m.c, profile.ID, m.dstOrg.ID, teamID)
+		if err != nil {
+			break
+		}
+	}
+
+	m.record(migrate.Member, username, source, err)
+}
+
+// migrateMachines records the machines that have to be created again on the
+// destination. Machine tokens are secrets of their own, so they can't be
+// copied.
+func (m *registryMigration) migrateMachines() error {
+	active := primitive.MachineActiveState
+	srcMachines, err := m.src.Machines.List(m.c, m.srcOrg.ID, &active, nil, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+	dstMachines, err := m.dst.Machines.List(m.c, m.dstOrg.ID, &active, nil, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+
+	existing := make(map[string]bool, len(dstMachines))
+	for _, s := range dstMachines {
+		existing[s.Machine.Body.Name] = true
+	}
+
+	for _, s := range srcMachines {
+		name := s.Machine.Body.Name
+		if existing[name] {
+			m.record(migrate.Machine, name, "", nil)
+			continue
+		}
+		m.state.Record(migrate.Machine, name, migrate.Waiting, "",
+			"create it again on the destination registry, and give it the new token")
+	}
+
+	return nil
+}
+
+// migratePolicies copies the org's user policies, and attaches them to the
+// same teams. System policies are created along with the org.
+func (m *registryMigration) migratePolicies() error {
+	srcPolicies, err := m.src.Policies.List(m.c, m.srcOrg.ID, "")
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+	dstPolicies, err := m.dst.Policies.List(m.c, m.dstOrg.ID, "")
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+
+	m.policies = make(map[string]*identity.ID, len(dstPolicies))
+	for _, p := range dstPolicies {
+		m.policies[p.Body.Policy.Name] = p.ID
+	}
+
+	m.srcPolicies = make(map[identity.ID]string, len(srcPolicies))
+	for _, p := range srcPolicies {
+		name := p.Body.Policy.Name
+		m.srcPolicies[*p.ID] = name
+		if _, ok := m.policies[name]; ok || p.Body.PolicyType != "user" {
+			continue
+		}
+
+		policy := *p.Body
+		policy.OrgID = m.dstOrg.ID
+		policy.Previous = nil
+
+		created, err := m.dst.Policies.Create(m.c, &policy)
+		if err == nil {
+			m.policies[name] = created.ID
+		}
+		m.record(migrate.Policy, name, "", err)
+	}
+
+	srcAttachments, err := m.src.Policies.AttachmentsList(m.c, m.srcOrg.ID, nil, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+	dstAttachments, err := m.dst.Policies.AttachmentsList(m.c, m.dstOrg.ID, nil, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+
+	attached := make(map[string]bool, len(dstAttachments))
+	for _, a := range dstAttachments {
+		attached[a.Body.PolicyID.String()+"/"+a.Body.OwnerID.String()] = true
+	}
+
+	for _, a := range srcAttachments {
+		team, ok := m.srcTeams[*a.Body.OwnerID]
+		if !ok {
+			continue
+		}
+
+		name := m.srcPolicies[*a.Body.PolicyID] + " to " + team.Body.Name
+		policyID, teamID := m.policies[m.srcPolicies[*a.Body.PolicyID]], m.teams[team.Body.Name]
+		if policyID == nil || teamID == nil {
+			m.state.Record(migrate.Attachment, name, migrate.Waiting, "",
+				"its policy or team has not been copied")
+			continue
+		}
+
+		err = nil
+		if !attached[policyID.String()+"/"+teamID.String()] {
+			err = m.dst.Policies.Attach(m.c, m.dstOrg.ID, policyID, teamID)
+		}
+		m.record(migrate.Attachment, name, "", err)
+	}
+
+	return nil
+}
+
+// migrateCredentials copies the current value of every secret we can read.
+// The destination's daemon encrypts them for the keyrings there, shared with
+// every member that has keypairs in the org. Secrets whose value has changed
+// since they were last copied are copied again.
+func (m *registryMigration) migrateCredentials() error {
+	srcProjects, err := m.src.Projects.List(m.c, &[]*identity.ID{m.srcOrg.ID}, nil)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+
+	for _, p := range srcProjects {
+		projectID, ok := m.projects[p.Body.Name]
+		if !ok {
+			continue
+		}
+
+		path := "/" + m.srcOrg.Body.Name + "/" + p.Body.Name + "/*/*/*/*"
+		creds, err := m.src.Credentials.Search(m.c, path)
+		if err != nil {
+			return errs.NewErrorExitError("Could not read secrets in "+path+".", err)
+		}
+
+		for _, cred := range creds {
+			body := *cred.Body
+			value := body.GetValue()
+			if value == nil || value.IsUnset() {
+				continue
+			}
+
+			name := body.GetPathExp().String() + "/" + body.GetName()
+			source := cred.ID.String()
+			if m.state.Done(migrate.Credential, name, source) {
+				continue
+			}
+
+			copied := newCredential(m.dstOrg.ID, projectID, body.GetPathExp(), body.GetName(), value)
+			if meta := body.GetMeta(); meta != nil {
+				copied.(*apitypes.CredentialV3).CredentialMeta = *meta
+			}
+
+			_, err = m.dst.Credentials.Create(m.c, &copied, false, false, nil)
+			m.record(migrate.Credential, name, source, err)
+		}
+
+		err = m.state.Save()
+		if err != nil {
+			return errs.NewErrorExitError("Could not save migration state.", err)
+		}
+	}
+
+	return nil
+}
+
+// shareKeyrings shares the destination's keyrings with members who have
+// generated keypairs there since the secrets in them were copied.
+func (m *registryMigration) shareKeyrings() error {
+	items, err := m.dst.Worklog.List(m.c, m.dstOrg.ID, apitypes.KeyringMembersWorklogType)
+	if err != nil {
+		return errs.NewErrorExitError(migrateFailed, err)
+	}
+
+	for _, item := range items {
+		_, err := m.dst.Worklog.Resolve(m.c, m.dstOrg.ID, item.ID)
+		if err != nil {
+			fmt.Printf("Could not share %s: %s\n", item.Subject, err)
+		}
+	}
+
+	return nil
+}
+
+func printMigrationSummary(state *migrate.State) {
+	counts := state.Counts()
+	fmt.Printf("\n%d copied, %d waiting, %d failed.\n",
+		counts[migrate.Done], counts[migrate.Waiting], counts[migrate.Failed])
+
+	pending := state.Pending()
+	if len(pending) == 0 {
+		fmt.Println("The migration is complete.")
+		return
+	}
+
+	fmt.Println()
+	for _, o := range pending {
+		fmt.Printf("%s %s %s: %s\n", o.Status, o.Kind, o.Name, o.Message)
+	}
+	fmt.Println("\nRun the migration again to pick up where it left off.")
+}
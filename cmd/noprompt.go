@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/promptui"
+)
+
+// NoPromptEnv turns off every interactive prompt when set to true, as the
+// global --no-prompt flag does. Prompts then take their answers from the
+// environment variables below, or their defaults, and otherwise fail.
+const NoPromptEnv = "TORUS_NONINTERACTIVE"
+
+// The environment variables prompts read their answers from when prompting is
+// turned off.
+const (
+	emailEnv            = "TORUS_EMAIL"
+	passwordEnv         = "TORUS_PASSWORD"
+	newPasswordEnv      = "TORUS_NEW_PASSWORD"
+	nameEnv             = "TORUS_NAME"
+	usernameEnv         = "TORUS_USERNAME"
+	inviteCodeEnv       = "TORUS_INVITE_CODE"
+	verificationCodeEnv = "TORUS_VERIFICATION_CODE"
+	recoveryCodeEnv     = "TORUS_RECOVERY_CODE"
+	mfaCodeEnv          = "TORUS_MFA_CODE"
+	bundlePassphraseEnv = "TORUS_BUNDLE_PASSPHRASE"
+	confirmNameEnv      = "TORUS_CONFIRM_NAME"
+)
+
+// newPasswordLabel labels prompts for a replacement password, which is read
+// from newPasswordEnv rather than passwordEnv when prompting is turned off.
+const newPasswordLabel = "New Password"
+
+// bundlePassphraseLabel labels prompts for the passphrase of a bundle, which is
+// read from bundlePassphraseEnv when prompting is turned off.
+const bundlePassphraseLabel = "Bundle passphrase"
+
+// promptsDisabled returns whether prompting is turned off.
+func promptsDisabled() bool {
+	v, err := strconv.ParseBool(os.Getenv(NoPromptEnv))
+	return err == nil && v
+}
+
+// noPromptValue returns the answer to the prompt with the given label when
+// prompting is turned off: the value of envVar if it is set, or defaultValue.
+// The answer is checked with validate, if given.
+func noPromptValue(label, envVar, defaultValue string, validate promptui.ValidateFunc) (string, error) {
+	value, ok := os.LookupEnv(envVar)
+	if !ok {
+		value = defaultValue
+	}
+	if value == "" {
+		return "", errs.NewPromptExitError(label, "Set "+envVar+" instead")
+	}
+
+	if validate != nil {
+		if err := validate(value); err != nil {
+			return "", errs.NewExitError(envVar + ": " + err.Error())
+		}
+	}
+
+	return value, nil
+}
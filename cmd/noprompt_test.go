@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+func TestNoPromptValue(t *testing.T) {
+	defer os.Unsetenv(usernameEnv)
+
+	t.Run("the default is used", func(t *testing.T) {
+		os.Unsetenv(usernameEnv)
+		v, err := noPromptValue("Username", usernameEnv, "jeff", validateUsername)
+		if err != nil || v != "jeff" {
+			t.Errorf("expected jeff, got %q, %v", v, err)
+		}
+	})
+
+	t.Run("the environment wins over the default", func(t *testing.T) {
+		os.Setenv(usernameEnv, "sue")
+		v, err := noPromptValue("Username", usernameEnv, "jeff", validateUsername)
+		if err != nil || v != "sue" {
+			t.Errorf("expected sue, got %q, %v", v, err)
+		}
+	})
+
+	t.Run("values are validated", func(t *testing.T) {
+		os.Setenv(usernameEnv, "Not Valid")
+		_, err := noPromptValue("Username", usernameEnv, "", validateUsername)
+		if err == nil || errs.IsPromptError(err) {
+			t.Errorf("expected a validation error, got %v", err)
+		}
+	})
+
+	t.Run("missing values are prompt errors", func(t *testing.T) {
+		os.Unsetenv(usernameEnv)
+		_, err := noPromptValue("Username", usernameEnv, "", validateUsername)
+		if !errs.IsPromptError(err) {
+			t.Errorf("expected a prompt error, got %v", err)
+		}
+	})
+}
+
+func TestValidateMFACode(t *testing.T) {
+	for _, code := range []string{"123456", "123 456"} {
+		if err := validateMFACode(code); err != nil {
+			t.Errorf("expected %q to be valid, got %v", code, err)
+		}
+	}
+	for _, code := range []string{"", "12345", "1234567", "abcdef"} {
+		if err := validateMFACode(code); err == nil {
+			t.Errorf("expected %q to be invalid", code)
+		}
+	}
+}
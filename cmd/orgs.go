@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
 
 	"github.com/urfave/cli"
@@ -13,6 +14,8 @@ import (
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/hints"
+
+	"github.com/manifoldco/torus-cli/cmd/output"
 )
 
 func init() {
@@ -44,6 +47,15 @@ func init() {
 					setUserEnv, checkRequiredFlags, orgsRemove,
 				),
 			},
+			{
+				Name:      "destroy",
+				Usage:     "Delete an organization, and every project and secret in it",
+				ArgsUsage: "<name>",
+				Action:    chain(ensureDaemon, ensureSession, orgsDestroy),
+			},
+			orgsTransferOwnershipCmd,
+			orgsAuditKeysCmd,
+			orgsOwnersCmd,
 		},
 	}
 	Cmds = append(Cmds, orgs)
@@ -111,6 +123,18 @@ func orgsListCmd(ctx *cli.Context) error {
 		return err
 	}
 
+	if output.IsJSON(ctx) {
+		records := make([]output.Org, 0, len(orgs))
+		for _, o := range orgs {
+			records = append(records, output.Org{
+				ID:       o.ID,
+				Name:     o.Body.Name,
+				Personal: session.Type() == apitypes.UserSession && o.Body.Name == session.Username(),
+			})
+		}
+		return output.Write(os.Stdout, records)
+	}
+
 	withoutPersonal := orgs
 
 	if session.Type() == apitypes.UserSession {
@@ -202,6 +226,11 @@ func orgsRemove(ctx *cli.Context) error {
 		return errs.NewExitError(userNotFound)
 	}
 
+	err = ensureOwnerSuccession(c, client, org, profile.ID)
+	if err != nil {
+		return err
+	}
+
 	err = client.Orgs.RemoveMember(c, *org.ID, *profile.ID)
 	if apitypes.IsNotFoundError(err) {
 		fmt.Println("User is not a member of the org.")
@@ -215,6 +244,58 @@ func orgsRemove(ctx *cli.Context) error {
 	return nil
 }
 
+func orgsDestroy(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) < 1 || args[0] == "" {
+		return errs.NewUsageExitError("Missing org name", ctx)
+	}
+	if len(args) > 1 {
+		return errs.NewUsageExitError("Too many arguments", ctx)
+	}
+	name := args[0]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	const destroyFailed = "Could not destroy org."
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return errs.NewErrorExitError(destroyFailed, err)
+	}
+	if session.Type() == apitypes.UserSession && name == session.Username() {
+		return errs.NewExitError("Your personal org cannot be destroyed.")
+	}
+
+	org, err := getOrg(c, client, name)
+	if err != nil {
+		return err
+	}
+
+	warning := "You are about to destroy the " + name + " org. Every project, " +
+		"secret, team and policy in it will be deleted, and its members will " +
+		"lose access. This cannot be undone."
+	err = ConfirmNamePrompt(name, warning)
+	if err != nil {
+		return handleSelectError(err, destroyFailed)
+	}
+
+	err = withMFA(c, func(c context.Context) error {
+		return client.Orgs.Delete(c, *org.ID)
+	})
+	if err != nil {
+		return errs.NewErrorExitError(destroyFailed, err)
+	}
+
+	fmt.Println("Org " + name + " destroyed.")
+	return nil
+}
+
 func getOrg(ctx context.Context, client *api.Client, name string) (*envelope.Org, error) {
 	org, err := client.Orgs.GetByName(ctx, name)
 	if err != nil {
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+// keyExpiryWarning is how long before a key expires that warnings are shown.
+const keyExpiryWarning = "30d"
+
+var orgsAuditKeysCmd = cli.Command{
+	Name:  "audit-keys",
+	Usage: "List members of an org whose keys have expired, or expire soon",
+	Flags: []cli.Flag{
+		orgFlag("org to check keys for", true),
+		newPlaceholder("within", "DURATION",
+			"Also list keys expiring within this time, such as 30d",
+			keyExpiryWarning, "", false),
+	},
+	Action: chain(
+		ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+		setUserEnv, checkRequiredFlags, orgsAuditKeys,
+	),
+}
+
+const orgsAuditKeysFailed = "Could not check keys, please try again."
+
+func orgsAuditKeys(ctx *cli.Context) error {
+	now := time.Now()
+	soon, err := parseRelativeTime(ctx.String("within"), now, 1)
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	tree, err := client.ClaimTree.Get(c, org.ID)
+	if err != nil {
+		return errs.NewErrorExitError(orgsAuditKeysFailed, err)
+	}
+
+	keys := expiringKeys(tree.PublicKeys, soon)
+	if len(keys) == 0 {
+		fmt.Printf("No keys in the %s org expire before %s.\n",
+			org.Body.Name, soon.Format("2006-01-02"))
+		return nil
+	}
+
+	names, err := keyOwnerNames(c, client, org.ID, keys)
+	if err != nil {
+		return errs.NewErrorExitError(orgsAuditKeysFailed, err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, tableHeader("OWNER", "KEY TYPE", "EXPIRES", "STATUS"))
+	for _, pk := range keys {
+		name, ok := names[*pk.Body.OwnerID]
+		if !ok {
+			name = pk.Body.OwnerID.String()
+		}
+
+		status := "expiring"
+		if pk.Body.Expired(now) {
+			status = "expired"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, pk.Body.KeyType,
+			pk.Body.Expires.Local().Format("2006-01-02"), status)
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d keys have expired or expire before %s. Their owners "+
+		"should run 'torus keypairs renew'.\n", len(keys), soon.Format("2006-01-02"))
+	return nil
+}
+
+// expiringKeys returns the public keys from segments that expire before the
+// given time, soonest first. Revoked keys are not included.
+func expiringKeys(segments []apitypes.PublicKeySegment, before time.Time) []*envelope.PublicKey {
+	var keys []*envelope.PublicKey
+	for i := range segments {
+		segment := &segments[i]
+		if segment.Revoked() || !segment.PublicKey.Body.Expired(before) {
+			continue
+		}
+		keys = append(keys, segment.PublicKey)
+	}
+
+	sort.Sort(keyExpirySorter(keys))
+	return keys
+}
+
+// keyOwnerNames returns the usernames and machine names of the owners of the
+// given keys, keyed by owner id.
+func keyOwnerNames(ctx context.Context, client *api.Client, orgID *identity.ID,
+	keys []*envelope.PublicKey) (map[identity.ID]string, error) {
+
+	ownerIDs := make([]*identity.ID, len(keys))
+	for i, pk := range keys {
+		ownerIDs[i] = pk.Body.OwnerID
+	}
+
+	return ownerNames(ctx, client, orgID, ownerIDs)
+}
+
+// ownerNames returns the usernames and machine names of the given owners of
+// keys or keyring memberships, keyed by owner id.
+func ownerNames(ctx context.Context, client *api.Client, orgID *identity.ID,
+	ownerIDs []*identity.ID) (map[identity.ID]string, error) {
+
+	userType := (&primitive.User{}).Type()
+
+	names := make(map[identity.ID]string)
+	var userIDs []identity.ID
+	machines := false
+	for _, id := range ownerIDs {
+		if id.Type() == userType {
+			userIDs = append(userIDs, *id)
+		} else {
+			machines = true
+		}
+	}
+
+	if len(userIDs) > 0 {
+		profiles, err := client.Profiles.ListByID(ctx, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range *profiles {
+			names[*p.ID] = p.Body.Username
+		}
+	}
+
+	// Machine keys are owned by the machine's tokens.
+	if machines {
+		segments, err := client.Machines.List(ctx, orgID, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range segments {
+			for _, t := range s.Tokens {
+				names[*t.Token.ID] = s.Machine.Body.Name
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// keyExpirySorter implements sort.Interface, for sorting public keys by
+// expiry time.
+type keyExpirySorter []*envelope.PublicKey
+
+func (k keyExpirySorter) Len() int      { return len(k) }
+func (k keyExpirySorter) Swap(i, j int) { k[i], k[j] = k[j], k[i] }
+func (k keyExpirySorter) Less(i, j int) bool {
+	return k[i].Body.Expires.Before(k[j].Body.Expires)
+}
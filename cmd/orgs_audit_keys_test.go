@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestExpiringKeys(t *testing.T) {
+	now := time.Now()
+
+	segment := func(id byte, expires time.Time, revoked bool) apitypes.PublicKeySegment {
+		s := apitypes.PublicKeySegment{
+			PublicKey: &envelope.PublicKey{
+				ID:   &identity.ID{0x01, 0x06, id},
+				Body: &primitive.PublicKey{Expires: expires},
+			},
+		}
+		if revoked {
+			s.Claims = []envelope.Claim{
+				{Body: &primitive.Claim{ClaimType: primitive.RevocationClaimType}},
+			}
+		}
+		return s
+	}
+
+	segments := []apitypes.PublicKeySegment{
+		segment(1, now.AddDate(0, 0, 10), false),
+		segment(2, now.AddDate(1, 0, 0), false),
+		segment(3, now.AddDate(0, 0, -10), true),
+		segment(4, now.AddDate(0, 0, -1), false),
+		segment(5, time.Time{}, false),
+	}
+
+	keys := expiringKeys(segments, now.AddDate(0, 0, 30))
+	if len(keys) != 2 {
+		t.Fatalf("got %d keys want 2", len(keys))
+	}
+	if keys[0].ID[2] != 4 || keys[1].ID[2] != 1 {
+		t.Errorf("wrong keys or order: got %s, %s", keys[0].ID, keys[1].ID)
+	}
+}
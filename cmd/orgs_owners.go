@@ -0,0 +1,477 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+// minOrgOwners is the fewest owners an org can be left with by demoting or
+// removing an owner, so that one owner leaving can't strand the org.
+const minOrgOwners = 2
+
+var orgsTransferOwnershipCmd = cli.Command{
+	Name:      "transfer-ownership",
+	Usage:     "Hand ownership of an organization to another member",
+	ArgsUsage: "<username>",
+	Flags: []cli.Flag{
+		orgFlag("org to transfer", true),
+	},
+	Action: chain(
+		ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+		setUserEnv, checkRequiredFlags, orgsTransferOwnership,
+	),
+}
+
+var orgsOwnersCmd = cli.Command{
+	Name:  "owners",
+	Usage: "Manage the owners of an organization",
+	Subcommands: []cli.Command{
+		{
+			Name:  "list",
+			Usage: "List the owners of an organization",
+			Flags: []cli.Flag{
+				orgFlag("org to list owners of", true),
+			},
+			Action: chain(
+				ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+				setUserEnv, checkRequiredFlags, orgsOwnersList,
+			),
+		},
+		{
+			Name:      "add",
+			Usage:     "Make a member of an organization a co-owner",
+			ArgsUsage: "<username>",
+			Flags: []cli.Flag{
+				orgFlag("org to add the owner to", true),
+			},
+			Action: chain(
+				ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+				setUserEnv, checkRequiredFlags, orgsOwnersAdd,
+			),
+		},
+		{
+			Name:      "remove",
+			Usage:     "Demote an owner of an organization",
+			ArgsUsage: "<username>",
+			Flags: []cli.Flag{
+				orgFlag("org to remove the owner from", true),
+			},
+			Action: chain(
+				ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+				setUserEnv, checkRequiredFlags, orgsOwnersRemove,
+			),
+		},
+	},
+}
+
+func orgsOwnersList(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	const listFailed = "Could not list owners."
+	owners, err := orgOwners(c, client, org.ID)
+	if err != nil {
+		return errs.NewErrorExitError(listFailed, err)
+	}
+
+	ids := make([]identity.ID, 0, len(owners))
+	for _, m := range owners {
+		ids = append(ids, *m.Body.OwnerID)
+	}
+
+	profiles, err := client.Profiles.ListByID(c, ids)
+	if err != nil {
+		return errs.NewErrorExitError(listFailed, err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, tableHeader("NAME", "USERNAME"))
+	for _, p := range *profiles {
+		fmt.Fprintf(w, "%s\t%s\n", p.Body.Name, p.Body.Username)
+	}
+	w.Flush()
+
+	if len(owners) < minOrgOwners {
+		fmt.Printf("\nThe %s org has fewer than %d owners. If its owner leaves, "+
+			"no one can manage it.\nAdd a co-owner with `%s orgs owners add`.\n",
+			org.Body.Name, minOrgOwners, ctx.App.Name)
+	}
+
+	return nil
+}
+
+func orgsOwnersAdd(ctx *cli.Context) error {
+	username, err := ownerUsernameArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, profile, err := orgAndProfile(c, client, ctx.String("org"), username)
+	if err != nil {
+		return err
+	}
+
+	added, err := addOrgOwner(c, client, org, profile)
+	if err != nil {
+		return err
+	}
+	if !added {
+		fmt.Println(username + " is already an owner of the " + org.Body.Name + " org.")
+		return nil
+	}
+
+	fmt.Println(username + " is now an owner of the " + org.Body.Name + " org.")
+	shareOrgSecrets(c, ctx, client, org, username)
+	return nil
+}
+
+// addOrgOwner adds the member with the given profile to the owner and admin
+// teams of org, returning false if they are already an owner.
+func addOrgOwner(c context.Context, client *api.Client, org *envelope.Org,
+	profile *apitypes.Profile) (bool, error) {
+
+	const addFailed = "Could not add owner."
+	username := profile.Body.Username
+
+	teams, err := client.Teams.List(c, org.ID, "", primitive.SystemTeamType)
+	if err != nil {
+		return false, errs.NewErrorExitError(addFailed, err)
+	}
+
+	memberships, err := client.Memberships.List(c, org.ID, profile.ID, nil)
+	if err != nil {
+		return false, errs.NewErrorExitError(addFailed, err)
+	}
+
+	joined := make(map[identity.ID]bool)
+	for _, m := range memberships {
+		joined[*m.Body.TeamID] = true
+	}
+
+	owner := findTeam(teams, primitive.OwnerTeamName)
+	admin := findTeam(teams, primitive.AdminTeamName)
+	member := findTeam(teams, primitive.MemberTeamName)
+	if owner == nil || admin == nil || member == nil {
+		return false, errs.NewExitError("Could not find the org's system teams.")
+	}
+
+	// Guests are only encoded into some keyrings, so only full members can
+	// become owners.
+	if !joined[*member.ID] {
+		return false, errs.NewExitError(username + " must be a member of the " +
+			org.Body.Name + " org to become an owner.")
+	}
+	if joined[*owner.ID] {
+		return false, nil
+	}
+
+	// Owners administer the org too.
+	if !joined[*admin.ID] {
+		err = client.Memberships.Create(c, profile.ID, org.ID, admin.ID)
+		if err != nil {
+			return false, errs.NewErrorExitError(addFailed, err)
+		}
+	}
+
+	err = client.Memberships.Create(c, profile.ID, org.ID, owner.ID)
+	if err != nil {
+		return false, errs.NewErrorExitError(addFailed, err)
+	}
+
+	return true, nil
+}
+
+// shareOrgSecrets makes sure a new owner can read every secret in org,
+// warning if that fails, as they are an owner regardless.
+func shareOrgSecrets(c context.Context, ctx *cli.Context, client *api.Client,
+	org *envelope.Org, username string) {
+
+	err := resolveKeyringMembers(c, client, org.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not share all of the org's secrets with %s: %s\n"+
+			"Run `%s worklog resolve` to try again.\n", username, err, ctx.App.Name)
+	}
+}
+
+func orgsOwnersRemove(ctx *cli.Context) error {
+	username, err := ownerUsernameArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, profile, err := orgAndProfile(c, client, ctx.String("org"), username)
+	if err != nil {
+		return err
+	}
+
+	const removeFailed = "Could not remove owner."
+	owners, err := orgOwners(c, client, org.ID)
+	if err != nil {
+		return errs.NewErrorExitError(removeFailed, err)
+	}
+
+	var membership *envelope.Membership
+	for i, m := range owners {
+		if *m.Body.OwnerID == *profile.ID {
+			membership = &owners[i]
+		}
+	}
+	if membership == nil {
+		return errs.NewExitError(username + " is not an owner of the " + org.Body.Name + " org.")
+	}
+
+	err = checkOwnerSuccession(org, len(owners))
+	if err != nil {
+		return err
+	}
+
+	err = client.Memberships.Delete(c, membership.ID)
+	if err != nil {
+		return errs.NewErrorExitError(removeFailed, err)
+	}
+
+	fmt.Printf("%s is no longer an owner of the %s org. They are still an admin; "+
+		"use `%s teams remove %s admin` to change that.\n",
+		username, org.Body.Name, ctx.App.Name, username)
+	return nil
+}
+
+func orgsTransferOwnership(ctx *cli.Context) error {
+	username, err := ownerUsernameArg(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	const transferFailed = "Could not transfer ownership."
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return errs.NewErrorExitError(transferFailed, err)
+	}
+	if session.Type() != apitypes.UserSession {
+		return errs.NewExitError("Machines cannot own an org.")
+	}
+	if username == session.Username() {
+		return errs.NewExitError("You cannot transfer ownership to yourself.")
+	}
+
+	org, profile, err := orgAndProfile(c, client, ctx.String("org"), username)
+	if err != nil {
+		return err
+	}
+	if org.Body.Name == session.Username() {
+		return errs.NewExitError("Your personal org cannot be transferred.")
+	}
+
+	owners, err := orgOwners(c, client, org.ID)
+	if err != nil {
+		return errs.NewErrorExitError(transferFailed, err)
+	}
+
+	var mine *envelope.Membership
+	alreadyOwner := false
+	for i, m := range owners {
+		switch *m.Body.OwnerID {
+		case *session.ID():
+			mine = &owners[i]
+		case *profile.ID:
+			alreadyOwner = true
+		}
+	}
+	if mine == nil {
+		return errs.NewExitError("You are not an owner of the " + org.Body.Name + " org.")
+	}
+
+	// Handing the org to someone who doesn't own it yet leaves it with as
+	// many owners as before; handing it to a co-owner leaves it with one less.
+	if alreadyOwner {
+		err = checkOwnerSuccession(org, len(owners))
+		if err != nil {
+			return err
+		}
+	}
+
+	warning := fmt.Sprintf("You are about to make %s an owner of the %s org, and stop "+
+		"being one yourself. You will still be an admin.", username, org.Body.Name)
+	err = ConfirmNamePrompt(org.Body.Name, warning)
+	if err != nil {
+		return handleSelectError(err, transferFailed)
+	}
+
+	added, err := addOrgOwner(c, client, org, profile)
+	if err != nil {
+		return err
+	}
+	if added {
+		shareOrgSecrets(c, ctx, client, org, username)
+	}
+
+	err = client.Memberships.Delete(c, mine.ID)
+	if err != nil {
+		return errs.NewErrorExitError(fmt.Sprintf("%s is now an owner of the %s org, "+
+			"but you could not be removed as one.", username, org.Body.Name), err)
+	}
+
+	fmt.Printf("%s now owns the %s org. You are still an admin; "+
+		"use `%s teams remove %s admin` to change that.\n",
+		username, org.Body.Name, ctx.App.Name, session.Username())
+	return nil
+}
+
+// ensureOwnerSuccession returns an error if removing the user from the owner
+// team of org would leave it with too few owners.
+func ensureOwnerSuccession(c context.Context, client *api.Client, org *envelope.Org,
+	userID *identity.ID) error {
+
+	owners, err := orgOwners(c, client, org.ID)
+	if err != nil {
+		return errs.NewErrorExitError("Could not look up the org's owners.", err)
+	}
+
+	for _, m := range owners {
+		if *m.Body.OwnerID == *userID {
+			return checkOwnerSuccession(org, len(owners))
+		}
+	}
+
+	return nil
+}
+
+// checkOwnerSuccession returns an error if an org with the given number of
+// owners can't lose one.
+func checkOwnerSuccession(org *envelope.Org, owners int) error {
+	if owners > minOrgOwners {
+		return nil
+	}
+
+	return errs.NewExitError(fmt.Sprintf(
+		"The %s org must keep at least %d owners. Add another owner first.",
+		org.Body.Name, minOrgOwners))
+}
+
+// orgOwners returns the memberships of the org's owner team.
+func orgOwners(c context.Context, client *api.Client, orgID *identity.ID) ([]envelope.Membership, error) {
+	teams, err := client.Teams.List(c, orgID, primitive.OwnerTeamName, primitive.SystemTeamType)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := findTeam(teams, primitive.OwnerTeamName)
+	if owner == nil {
+		return nil, fmt.Errorf("%s team not found", primitive.OwnerTeamName)
+	}
+
+	return client.Memberships.List(c, orgID, nil, owner.ID)
+}
+
+// resolveKeyringMembers adds anyone missing from the org's keyrings to them,
+// by resolving the org's keyring membership worklog items.
+func resolveKeyringMembers(c context.Context, client *api.Client, orgID *identity.ID) error {
+	items, err := client.Worklog.List(c, orgID, apitypes.KeyringMembersWorklogType)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.Type() != apitypes.KeyringMembersWorklogType {
+			continue
+		}
+
+		_, err := client.Worklog.Resolve(c, orgID, item.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findTeam(teams []envelope.Team, name string) *envelope.Team {
+	for i, t := range teams {
+		if t.Body.Name == name {
+			return &teams[i]
+		}
+	}
+	return nil
+}
+
+func ownerUsernameArg(ctx *cli.Context) (string, error) {
+	args := ctx.Args()
+	if len(args) < 1 || args[0] == "" {
+		return "", errs.NewUsageExitError("Missing username", ctx)
+	}
+	if len(args) > 1 {
+		return "", errs.NewUsageExitError("Too many arguments", ctx)
+	}
+	return args[0], nil
+}
+
+// orgAndProfile looks up the named org and user.
+func orgAndProfile(c context.Context, client *api.Client, orgName,
+	username string) (*envelope.Org, *apitypes.Profile, error) {
+
+	org, err := getOrg(c, client, orgName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	profile, err := client.Profiles.ListByName(c, username)
+	if apitypes.IsNotFoundError(err) || (err == nil && profile == nil) {
+		return nil, nil, errs.NewExitError("User not found.")
+	}
+	if err != nil {
+		return nil, nil, errs.NewErrorExitError("Could not look up user.", err)
+	}
+
+	return org, profile, nil
+}
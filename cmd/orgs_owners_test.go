@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestCheckOwnerSuccession(t *testing.T) {
+	org := &envelope.Org{Body: &primitive.Org{Name: "acme"}}
+
+	tcs := []struct {
+		owners int
+		ok     bool
+	}{
+		{owners: 1, ok: false},
+		{owners: minOrgOwners, ok: false},
+		{owners: minOrgOwners + 1, ok: true},
+	}
+
+	for _, tc := range tcs {
+		err := checkOwnerSuccession(org, tc.owners)
+		if ok := err == nil; ok != tc.ok {
+			t.Errorf("with %d owners: got %v, want ok=%t", tc.owners, err, tc.ok)
+		}
+	}
+}
+
+func TestFindTeam(t *testing.T) {
+	teams := []envelope.Team{
+		{Body: &primitive.Team{Name: primitive.AdminTeamName}},
+		{Body: &primitive.Team{Name: primitive.OwnerTeamName}},
+	}
+
+	if team := findTeam(teams, primitive.OwnerTeamName); team != &teams[1] {
+		t.Errorf("expected the owner team, got %v", team)
+	}
+	if team := findTeam(teams, primitive.MemberTeamName); team != nil {
+		t.Errorf("expected no member team, got %v", team)
+	}
+}
@@ -0,0 +1,225 @@
+// Package output writes the results of commands in a stable, machine-readable
+// format for scripting, selected with the global --output-format flag.
+//
+// It is not named --format, as many commands have their own --format flag for
+// the files or env vars they write.
+//
+// The records written are defined here, rather than being the registry's
+// envelopes, so that their shape only changes when we mean it to.
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// The formats a command's results can be written in.
+const (
+	Text = "text"
+	JSON = "json"
+)
+
+// Validate returns an error if format is not a known format.
+func Validate(format string) error {
+	switch format {
+	case "", Text, JSON:
+		return nil
+	default:
+		return errors.New("Unknown --output-format " + format + ", expected text or json")
+	}
+}
+
+// IsJSON returns whether the global --output-format flag asks for JSON.
+func IsJSON(ctx *cli.Context) bool {
+	return ctx.GlobalString("output-format") == JSON
+}
+
+// Write writes v to w as indented JSON.
+func Write(w io.Writer, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// Org is an org the user belongs to.
+type Org struct {
+	ID       *identity.ID `json:"id"`
+	Name     string       `json:"name"`
+	Personal bool         `json:"personal"`
+}
+
+// Team is a team in an org.
+type Team struct {
+	ID     *identity.ID `json:"id"`
+	Name   string       `json:"name"`
+	Type   string       `json:"type"`
+	Member bool         `json:"member"`
+}
+
+// TeamMember is a user in a team.
+type TeamMember struct {
+	ID       *identity.ID `json:"id"`
+	Username string       `json:"username"`
+	Name     string       `json:"name"`
+	You      bool         `json:"you"`
+}
+
+// Keypair is one of the user's keypairs for an org.
+type Keypair struct {
+	ID      *identity.ID `json:"id"`
+	Org     string       `json:"org"`
+	Type    string       `json:"type"`
+	Valid   bool         `json:"valid"`
+	Revoked bool         `json:"revoked"`
+	Created time.Time    `json:"created_at"`
+	Expires *time.Time   `json:"expires_at"`
+}
+
+// NewKeypair returns the record of a keypair in the named org, as of now.
+func NewKeypair(kp api.KeypairResult, org string, now time.Time) Keypair {
+	pk := kp.PublicKey.Body
+	k := Keypair{
+		ID:      kp.PublicKey.ID,
+		Org:     org,
+		Type:    string(pk.KeyType),
+		Revoked: kp.Revoked(),
+		Created: pk.Created.UTC(),
+	}
+	k.Valid = !k.Revoked && !pk.Expired(now)
+
+	if !pk.Expires.IsZero() {
+		expires := pk.Expires.UTC()
+		k.Expires = &expires
+	}
+
+	return k
+}
+
+// Invite is an invitation to join an org.
+type Invite struct {
+	ID        *identity.ID `json:"id"`
+	Email     string       `json:"email"`
+	Username  string       `json:"username"`
+	State     string       `json:"state"`
+	Delivery  string       `json:"delivery"`
+	InvitedBy string       `json:"invited_by"`
+	Created   *time.Time   `json:"created_at"`
+}
+
+// InviteResult is whether an invite sent by torus invites send for several
+// email addresses was sent, and why not if it wasn't.
+type InviteResult struct {
+	Email string   `json:"email"`
+	Teams []string `json:"teams"`
+	Sent  bool     `json:"sent"`
+	Error string   `json:"error,omitempty"`
+}
+
+// InviteApproval is whether an invite approved by torus invites approve
+// --all was approved, and why not if it wasn't.
+type InviteApproval struct {
+	Email    string `json:"email"`
+	Approved bool   `json:"approved"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Credential is a version of a secret. Value is a string, number, or bool,
+// or nil if the secret was unset.
+type Credential struct {
+	Name        string      `json:"name"`
+	Path        string      `json:"path"`
+	Version     int         `json:"version"`
+	Value       interface{} `json:"value"`
+	Description string      `json:"description,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	Expires     *time.Time  `json:"expires_at,omitempty"`
+}
+
+// NewCredential returns the record of a credential.
+func NewCredential(cred apitypes.CredentialEnvelope) (Credential, error) {
+	body := *cred.Body
+	c := Credential{
+		Name:    body.GetName(),
+		Path:    body.GetPathExp().String(),
+		Version: body.GetCredentialVersion(),
+	}
+
+	if v := body.GetValue(); v != nil && !v.IsUnset() {
+		raw, err := v.Raw()
+		if err != nil {
+			return c, err
+		}
+		c.Value = raw
+	}
+
+	if meta := body.GetMeta(); meta != nil {
+		c.Description = meta.Description
+		c.Tags = meta.Tags
+		c.Expires = meta.ExpiresAt
+	}
+
+	return c, nil
+}
+
+// NewCredentials returns the records of creds.
+func NewCredentials(creds []apitypes.CredentialEnvelope) ([]Credential, error) {
+	out := make([]Credential, 0, len(creds))
+	for _, cred := range creds {
+		c, err := NewCredential(cred)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+
+	return out, nil
+}
+
+// Location is where a secret found by torus search is set.
+type Location struct {
+	Org  string `json:"org"`
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+// SecretAccess is what a team can do with a secret, as listed by
+// torus ls --team.
+type SecretAccess struct {
+	Path  string `json:"path"`
+	Name  string `json:"name"`
+	Read  bool   `json:"read"`
+	Write bool   `json:"write"`
+}
+
+// Event is something that happened in an org. Events are written one per
+// line as they happen, rather than as an array.
+type Event struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Actor   string    `json:"actor"`
+	Subject string    `json:"subject"`
+}
+
+// NewEvent returns the record of an org event.
+func NewEvent(evt *apitypes.OrgEvent) Event {
+	return Event{
+		ID:      evt.ID,
+		Time:    evt.Time.UTC(),
+		Type:    evt.Type,
+		Actor:   evt.Actor,
+		Subject: evt.Subject,
+	}
+}
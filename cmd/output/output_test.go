@@ -0,0 +1,67 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func TestValidate(t *testing.T) {
+	for _, format := range []string{"", Text, JSON} {
+		if err := Validate(format); err != nil {
+			t.Errorf("%q: unexpected error: %s", format, err)
+		}
+	}
+
+	if err := Validate("yaml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestNewCredentials(t *testing.T) {
+	pe, err := pathexp.Parse("/acme/api/production/default/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cred := func(name string, version int, value *apitypes.CredentialValue) apitypes.CredentialEnvelope {
+		var body apitypes.Credential = &apitypes.CredentialV2{
+			BaseCredential: apitypes.BaseCredential{
+				Name:              name,
+				PathExp:           pe,
+				Value:             value,
+				CredentialVersion: version,
+			},
+		}
+		return apitypes.CredentialEnvelope{Version: 2, Body: &body}
+	}
+
+	records, err := NewCredentials([]apitypes.CredentialEnvelope{
+		cred("port", 2, apitypes.NewIntCredentialValue(8080)),
+		cred("token", 1, apitypes.NewUnsetCredentialValue()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	err = Write(buf, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`"name": "port"`,
+		`"path": "/acme/api/production/default/*/*"`,
+		`"version": 2`,
+		`"value": 8080`,
+		`"value": null`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %s:\n%s", want, buf.String())
+		}
+	}
+}
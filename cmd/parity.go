@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+func init() {
+	parity := cli.Command{
+		Name:     "parity",
+		Usage:    "Report secrets that are set in some environments but missing in others",
+		Category: "SECRETS",
+		Flags: []cli.Flag{
+			orgFlag("Use this organization.", true),
+			projectFlag("Use this project.", true),
+			serviceFlag("Use this service.", "default", true),
+			userFlag("Use this user.", false),
+			machineFlag("Use this machine.", false),
+			instanceFlag("Use this instance.", false),
+			newSlicePlaceholder("envs", "ENV,ENV",
+				"Environments to compare, such as dev,staging,production", "", "", true),
+			cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Exit with an error if any secret is missing from an environment",
+			},
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setUserEnv, checkRequiredFlags, parityCmd,
+		),
+	}
+
+	Cmds = append(Cmds, parity)
+}
+
+// parityGap is a secret that is missing from some of the compared
+// environments.
+type parityGap struct {
+	name    string
+	missing []string
+}
+
+func parityCmd(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	var envs []string
+	for _, v := range ctx.StringSlice("envs") {
+		for _, env := range strings.Split(v, ",") {
+			if env = strings.TrimSpace(env); env != "" {
+				envs = append(envs, env)
+			}
+		}
+	}
+	if len(envs) < 2 {
+		return errs.NewUsageExitError("At least two environments are required.", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return err
+	}
+
+	identity, err := deriveIdentity(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, len(envs))
+	for i, env := range envs {
+		paths[i], err = diffPath(ctx, env, identity)
+		if err != nil {
+			return err
+		}
+	}
+
+	creds, err := client.Credentials.GetMany(c, paths)
+	if err != nil {
+		return errs.NewErrorExitError("Error fetching secrets", err)
+	}
+
+	gaps := paritySecrets(envs, creds)
+	if len(gaps) == 0 {
+		fmt.Printf("All secrets are set in %s.\n", strings.Join(envs, ", "))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, tableHeader("SECRET", "MISSING FROM"))
+	for _, g := range gaps {
+		fmt.Fprintf(w, "%s\t%s\n", strings.ToUpper(g.name), strings.Join(g.missing, ", "))
+	}
+	w.Flush()
+	fmt.Println()
+
+	msg := fmt.Sprintf("%d secrets are missing from some environments.", len(gaps))
+	if ctx.Bool("strict") {
+		return errs.NewExitError(msg)
+	}
+
+	fmt.Println(msg)
+	return nil
+}
+
+// paritySecrets returns the secrets that are set in some of envs, but not all
+// of them. creds[i] holds the credentials for envs[i], and each is resolved
+// the same way as `torus view`. The result is sorted by name.
+func paritySecrets(envs []string, creds [][]apitypes.CredentialEnvelope) []parityGap {
+	sets := make([]credentialSet, len(creds))
+	names := make(map[string]bool)
+	for i, envCreds := range creds {
+		sets[i] = credentialSet{}
+		for _, c := range envCreds {
+			sets[i].Add(c)
+		}
+		for name := range sets[i] {
+			names[name] = true
+		}
+	}
+
+	var gaps []parityGap
+	for name := range names {
+		var missing []string
+		for i, set := range sets {
+			if _, ok := set[name]; !ok {
+				missing = append(missing, envs[i])
+			}
+		}
+		if len(missing) > 0 {
+			gaps = append(gaps, parityGap{name: name, missing: missing})
+		}
+	}
+
+	sort.Sort(parityGapSorter(gaps))
+	return gaps
+}
+
+// parityGapSorter implements sort.Interface, for sorting parityGaps by name.
+type parityGapSorter []parityGap
+
+func (p parityGapSorter) Len() int           { return len(p) }
+func (p parityGapSorter) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p parityGapSorter) Less(i, j int) bool { return p[i].name < p[j].name }
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func TestParitySecrets(t *testing.T) {
+	cred := func(env, name string) apitypes.CredentialEnvelope {
+		pe, err := pathexp.Parse("/org/project/" + env + "/api/*/1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var body apitypes.Credential = &apitypes.CredentialV2{
+			BaseCredential: apitypes.BaseCredential{
+				Name:    name,
+				PathExp: pe,
+				Value:   apitypes.NewStringCredentialValue("value"),
+			},
+			State: "set",
+		}
+		return apitypes.CredentialEnvelope{Version: 2, Body: &body}
+	}
+
+	envs := []string{"dev", "staging", "production"}
+	creds := [][]apitypes.CredentialEnvelope{
+		{cred("dev", "port"), cred("dev", "debug"), cred("dev", "url")},
+		{cred("staging", "port"), cred("staging", "url"), cred("staging", "token")},
+		{cred("production", "port"), cred("production", "token")},
+	}
+
+	gaps := paritySecrets(envs, creds)
+
+	want := []parityGap{
+		{name: "debug", missing: []string{"staging", "production"}},
+		{name: "token", missing: []string{"dev"}},
+		{name: "url", missing: []string{"production"}},
+	}
+	if !reflect.DeepEqual(gaps, want) {
+		t.Errorf("got %+v, want %+v", gaps, want)
+	}
+}
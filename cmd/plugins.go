@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/dirprefs"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/prefs"
+)
+
+// pluginPrefix is the prefix of executables on the PATH that are made
+// available as torus commands. An executable named torus-deploy is run by
+// `torus deploy`.
+const pluginPrefix = "torus-"
+
+// Plugins returns a command for every plugin found on the PATH. Plugins may
+// not replace any of the builtin commands.
+//
+// Plugins are run with the resolved org, project, environment and service in
+// TORUS_ORG, TORUS_PROJECT, TORUS_ENVIRONMENT and TORUS_SERVICE, and with
+// TORUS_ROOT and TORUS_DAEMON_SOCKET pointing at the running, logged in
+// daemon, so they can reuse its session to fetch secrets.
+func Plugins(builtin []cli.Command) []cli.Command {
+	taken := make(map[string]bool)
+	for _, c := range builtin {
+		for _, name := range c.Names() {
+			taken[name] = true
+		}
+	}
+
+	plugins := discoverPlugins(os.Getenv("PATH"))
+
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		if !taken[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	cmds := make([]cli.Command, len(names))
+	for i, name := range names {
+		cmds[i] = cli.Command{
+			Name:            name,
+			Usage:           "Run the " + pluginPrefix + name + " plugin",
+			ArgsUsage:       "[arguments...]",
+			Category:        pluginCategory,
+			SkipFlagParsing: true,
+			Action: chain(
+				ensureDaemon, ensureSession, pluginCmd(plugins[name]),
+			),
+		}
+	}
+
+	return cmds
+}
+
+// discoverPlugins returns the path of every plugin in the given PATH list,
+// keyed by command name. Earlier entries in the list take precedence.
+func discoverPlugins(pathList string) map[string]string {
+	plugins := make(map[string]string)
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, f := range files {
+			name := strings.TrimPrefix(f.Name(), pluginPrefix)
+			if name == f.Name() || name == "" || strings.HasPrefix(name, "-") {
+				continue
+			}
+
+			if _, ok := plugins[name]; ok {
+				continue
+			}
+
+			// ReadDir does not follow symlinks, which plugins are often
+			// installed as.
+			path := filepath.Join(dir, f.Name())
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+				continue
+			}
+
+			plugins[name] = path
+		}
+	}
+
+	return plugins
+}
+
+func pluginCmd(path string) func(*cli.Context) error {
+	return func(ctx *cli.Context) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+
+		pluginEnv, err := resolvePluginContext(cfg)
+		if err != nil {
+			return errs.NewErrorExitError("Could not resolve context for plugin.", err)
+		}
+
+		cmd := exec.Command(path, ctx.Args()...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		// Drop any values we are replacing with resolved ones, so the plugin
+		// does not see duplicates.
+		for _, e := range filterEnv() {
+			replaced := false
+			for _, pe := range pluginEnv {
+				key := pe[:strings.Index(pe, "=")+1]
+				replaced = replaced || strings.HasPrefix(e, key)
+			}
+
+			if !replaced {
+				cmd.Env = append(cmd.Env, e)
+			}
+		}
+		cmd.Env = append(cmd.Env, pluginEnv...)
+
+		return runProcess(cmd, "Failed to run plugin")
+	}
+}
+
+// resolvePluginContext returns the environment variables describing the
+// context a plugin runs in. Context is resolved the same way as for builtin
+// commands: from the environment, then .torus.json, then the preferences
+// defaults.
+func resolvePluginContext(cfg *config.Config) ([]string, error) {
+	values := map[string]string{
+		"org":         os.Getenv("TORUS_ORG"),
+		"project":     os.Getenv("TORUS_PROJECT"),
+		"environment": os.Getenv("TORUS_ENVIRONMENT"),
+		"service":     os.Getenv("TORUS_SERVICE"),
+	}
+
+	p, err := prefs.NewPreferences()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Core.Context {
+		d, err := dirprefs.Load(true)
+		if err != nil {
+			return nil, err
+		}
+
+		dc, err := d.Resolve(values["environment"], values["service"])
+		if err != nil {
+			return nil, err
+		}
+
+		fill := func(name, value string) {
+			if values[name] == "" {
+				values[name] = value
+			}
+		}
+
+		fill("org", dc.Organization)
+		fill("project", dc.Project)
+		fill("environment", dc.Environment)
+		fill("service", dc.Service)
+		fill("org", p.Defaults.Organization)
+		fill("project", p.Defaults.Project)
+		fill("environment", p.Defaults.Environment)
+		fill("service", p.Defaults.Service)
+	}
+
+	if values["service"] == "" {
+		values["service"] = "default"
+	}
+
+	if values["environment"] == "" {
+		client := api.NewClient(cfg)
+		session, err := client.Session.Who(context.Background())
+		if err != nil {
+			return nil, err
+		}
+
+		if session.Type() == apitypes.UserSession {
+			values["environment"] = "dev-" + session.Username()
+		}
+	}
+
+	env := []string{
+		"TORUS_ROOT=" + cfg.TorusRoot,
+		"TORUS_DAEMON_SOCKET=" + cfg.SocketPath,
+		"TORUS_DAEMON_GRPC_SOCKET=" + cfg.GRPCSocketPath,
+	}
+	for _, name := range []string{"org", "project", "environment", "service"} {
+		if values[name] != "" {
+			env = append(env, "TORUS_"+strings.ToUpper(name)+"="+values[name])
+		}
+	}
+
+	return env, nil
+}
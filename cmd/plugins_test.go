@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiscoverPlugins(t *testing.T) {
+	first, err := ioutil.TempDir("", "torus-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(first)
+
+	second, err := ioutil.TempDir("", "torus-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(second)
+
+	files := []struct {
+		dir  string
+		name string
+		mode os.FileMode
+	}{
+		{first, "torus-deploy", 0755},
+		{first, "torus-notexec", 0644},
+		{first, "torus-", 0755},
+		{first, "other", 0755},
+		{second, "torus-deploy", 0755},
+		{second, "torus-rotate", 0755},
+	}
+
+	for _, f := range files {
+		err := ioutil.WriteFile(filepath.Join(f.dir, f.name), []byte("#!/bin/sh\n"), f.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = os.Mkdir(filepath.Join(second, "torus-dir"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	missing := filepath.Join(first, "missing")
+	plugins := discoverPlugins(strings.Join([]string{missing, first, second}, string(os.PathListSeparator)))
+
+	expected := map[string]string{
+		"deploy": filepath.Join(first, "torus-deploy"),
+		"rotate": filepath.Join(second, "torus-rotate"),
+	}
+
+	if len(plugins) != len(expected) {
+		t.Errorf("wrong plugins found: %v", plugins)
+	}
+
+	for name, path := range expected {
+		if plugins[name] != path {
+			t.Errorf("wrong path for %s. got %q want %q", name, plugins[name], path)
+		}
+	}
+}
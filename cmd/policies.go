@@ -12,9 +12,11 @@ import (
 	"github.com/urfave/cli"
 
 	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/hints"
 	"github.com/manifoldco/torus-cli/identity"
 )
 
@@ -47,7 +49,30 @@ func init() {
 					setUserEnv, checkRequiredFlags, viewPolicyCmd,
 				),
 			},
-
+			{
+				Name:      "attachments",
+				Usage:     "List the teams and roles a policy is attached to",
+				ArgsUsage: "<policy>",
+				Flags: []cli.Flag{
+					orgFlag("org the policy belongs to", true),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					setUserEnv, checkRequiredFlags, policyAttachmentsCmd,
+				),
+			},
+			{
+				Name:      "sign",
+				Usage:     "Sign a policy created before the registry supported signing them",
+				ArgsUsage: "<policy>",
+				Flags: []cli.Flag{
+					orgFlag("org the policy belongs to", true),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					setUserEnv, checkRequiredFlags, signPolicyCmd,
+				),
+			},
 			{
 				Name:      "detach",
 				Usage:     "Detach (but not delete) a policy from a team or role",
@@ -60,6 +85,7 @@ func init() {
 					setUserEnv, checkRequiredFlags, detachPolicies,
 				),
 			},
+			policiesTestCmd,
 		},
 	}
 	Cmds = append(Cmds, policies)
@@ -117,7 +143,8 @@ func detachPolicies(ctx *cli.Context) error {
 	}()
 
 	go func() {
-		teams, tErr := client.Teams.GetByName(c, org.ID, teamName)
+		var teams []envelope.Team
+		teams, tErr = client.Teams.GetByName(c, org.ID, teamName)
 		if len(teams) < 1 || tErr != nil {
 			waitPolicy.Done()
 			return
@@ -148,7 +175,9 @@ func detachPolicies(ctx *cli.Context) error {
 		return errs.NewExitError(policyName + " policy is not currently attached to " + teamName)
 	}
 
-	err = client.Policies.Detach(c, attachments[0].ID)
+	err = withMFA(c, func(c context.Context) error {
+		return client.Policies.Detach(c, attachments[0].ID)
+	})
 	if err != nil {
 		if strings.Contains(err.Error(), "system team") {
 			return errs.NewExitError("Cannot delete system team attachment")
@@ -182,7 +211,7 @@ func listPolicies(ctx *cli.Context) error {
 	}
 
 	var getAttachments, display sync.WaitGroup
-	getAttachments.Add(3)
+	getAttachments.Add(4)
 	display.Add(1)
 
 	var policies []envelope.Policy
@@ -206,11 +235,19 @@ func listPolicies(ctx *cli.Context) error {
 		getAttachments.Done()
 	}()
 
-	if aErr != nil || pErr != nil || tErr != nil {
+	var signatures []apitypes.PolicySignature
+	var sErr error
+	go func() {
+		signatures, sErr = client.Policies.Signatures(c, org.ID)
+		getAttachments.Done()
+	}()
+
+	if aErr != nil || pErr != nil || tErr != nil || sErr != nil {
 		return cli.NewMultiError(
 			pErr,
 			aErr,
 			tErr,
+			sErr,
 			errs.NewExitError(policyListFailed),
 		)
 	}
@@ -218,6 +255,7 @@ func listPolicies(ctx *cli.Context) error {
 	teamsByID := make(map[identity.ID]envelope.Team)
 	policiesByName := make(map[string]envelope.Policy)
 	attachedTeamsByPolicyID := make(map[identity.ID][]string)
+	statusByPolicyID := make(map[identity.ID]string)
 	var sortedNames []string
 
 	go func() {
@@ -234,14 +272,17 @@ func listPolicies(ctx *cli.Context) error {
 			ID := *a.Body.PolicyID
 			attachedTeamsByPolicyID[ID] = append(attachedTeamsByPolicyID[ID], teamsByID[*a.Body.OwnerID].Body.Name)
 		}
+		for _, s := range signatures {
+			statusByPolicyID[*s.PolicyID] = s.Status
+		}
 		display.Done()
 	}()
 
 	display.Wait()
 	fmt.Println("")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
-	fmt.Fprintln(w, "POLICY NAME\tTYPE\tATTACHED TO")
-	fmt.Fprintln(w, " \t \t ")
+	fmt.Fprintln(w, tableHeader("POLICY NAME", "TYPE", "SIGNATURE", "ATTACHED TO"))
+	fmt.Fprintln(w, " \t \t \t ")
 	for _, name := range sortedNames {
 		teamNames := ""
 		policy := policiesByName[name]
@@ -249,7 +290,8 @@ func listPolicies(ctx *cli.Context) error {
 		if len(attachedTeamsByPolicyID[policyID]) > 0 {
 			teamNames = strings.Join(attachedTeamsByPolicyID[policyID], ", ")
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\n", policy.Body.Policy.Name, policy.Body.PolicyType, teamNames)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", policy.Body.Policy.Name, policy.Body.PolicyType,
+			statusByPolicyID[policyID], teamNames)
 	}
 
 	w.Flush()
@@ -295,17 +337,168 @@ func viewPolicyCmd(ctx *cli.Context) error {
 	policy := policies[0]
 	p := policy.Body.Policy
 
+	signature, err := policySignature(c, client, org.ID, policy.ID)
+	if err != nil {
+		return errs.NewErrorExitError("Unable to verify policy signature.", err)
+	}
+
 	w := tabwriter.NewWriter(os.Stdout, 2, 0, 1, ' ', 0)
 
 	fmt.Fprintf(w, "Name:\t%s\n", p.Name)
 	fmt.Fprintf(w, "Description:\t%s\n", p.Description)
+	fmt.Fprintf(w, "Signature:\t%s\n", signature)
 	fmt.Fprintln(w, "")
 	w.Flush()
 
 	for _, stmt := range p.Statements {
-		fmt.Fprintf(w, "%s\t%s\t%s\n", stmt.Effect.String(), stmt.Action.ShortString(), stmt.Resource)
+		fmt.Fprintf(w, "%s\t%s\t%s", stmt.Effect.String(), stmt.Action.ShortString(), stmt.Resource)
+		if stmt.Conditions != nil {
+			fmt.Fprintf(w, "\tif %s", stmt.Conditions.String())
+		}
+		fmt.Fprintln(w, "")
 	}
 	w.Flush()
 
 	return nil
 }
+
+func policyAttachmentsCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "policy name is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+	policyName := args[0]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	policies, err := client.Policies.List(c, org.ID, policyName)
+	if err != nil {
+		return errs.NewErrorExitError("Unable to list policies.", err)
+	}
+	if len(policies) < 1 {
+		return errs.NewExitError("Policy '" + policyName + "' not found.")
+	}
+
+	segments, err := client.Policies.AttachedTeams(c, org.ID, policies[0].ID)
+	if err != nil {
+		return errs.NewErrorExitError("Unable to list policy attachments.", err)
+	}
+
+	if len(segments) == 0 {
+		fmt.Println("Policy " + policyName + " is not attached to any teams or roles.")
+		return nil
+	}
+
+	fmt.Println("")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(w, tableHeader("TEAM", "TYPE", "ATTACHMENT ID"))
+	fmt.Fprintln(w, " \t \t ")
+	for _, s := range segments {
+		name := "-"
+		teamType := "-"
+		if s.Team != nil {
+			name = s.Team.Body.Name
+			teamType = string(s.Team.Body.TeamType)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, teamType, s.Attachment.ID)
+	}
+	w.Flush()
+	fmt.Println("")
+
+	hints.Display([]string{"policies detach"})
+	return nil
+}
+
+func signPolicyCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "policy name is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+	policyName := args[0]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	policies, err := client.Policies.List(c, org.ID, policyName)
+	if err != nil {
+		return errs.NewErrorExitError("Unable to list policies.", err)
+	}
+	if len(policies) < 1 {
+		return errs.NewExitError("Policy '" + policyName + "' not found.")
+	}
+
+	err = withMFA(c, func(c context.Context) error {
+		_, err := client.Policies.Sign(c, org.ID, policies[0].ID)
+		return err
+	})
+	if err != nil {
+		return errs.NewErrorExitError("Could not sign policy.", err)
+	}
+
+	fmt.Println("Policy " + policyName + " has been signed.")
+	return nil
+}
+
+// policySignature describes the outcome of verifying the policy's signature,
+// naming who signed it.
+func policySignature(c context.Context, client *api.Client, orgID,
+	policyID *identity.ID) (string, error) {
+
+	signatures, err := client.Policies.Signatures(c, orgID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, s := range signatures {
+		if *s.PolicyID != *policyID {
+			continue
+		}
+
+		switch s.Status {
+		case apitypes.PolicySigned:
+			names, err := ownerNames(c, client, orgID, []*identity.ID{s.SignerID})
+			if err != nil {
+				return "", err
+			}
+			name, ok := names[*s.SignerID]
+			if !ok {
+				name = s.SignerID.String()
+			}
+			return "signed by " + name, nil
+		case apitypes.PolicyInvalid:
+			return "INVALID, " + s.Problem, nil
+		}
+		return s.Status, nil
+	}
+
+	return apitypes.PolicyUnsigned, nil
+}
@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/policyeval"
+)
+
+var policiesTestCmd = cli.Command{
+	Name:      "test",
+	Usage:     "Check whether a user, team, or machine can take actions on a resource",
+	ArgsUsage: "<crudl> <resource>",
+	Flags: []cli.Flag{
+		orgFlag("org to check policies in", true),
+		newPlaceholder("user", "USER", "Check access for this user (default: you)", "", "", false),
+		newPlaceholder("team", "TEAM", "Check access for this team or machine role", "", "", false),
+		newPlaceholder("machine", "MACHINE", "Check access for this machine", "", "", false),
+	},
+	Action: chain(
+		ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+		setUserEnv, checkRequiredFlags, policiesTest,
+	),
+}
+
+const policiesTestFailed = "Could not test policies."
+
+func policiesTest(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 2 {
+		msg := "actions and resource are required."
+		if len(args) > 2 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	action, err := parseAction(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource, err := policyeval.ParseResource(args[1])
+	if err != nil {
+		return errs.NewErrorExitError("Invalid resource.", err)
+	}
+
+	subjects := 0
+	for _, f := range []string{"user", "team", "machine"} {
+		if ctx.String(f) != "" {
+			subjects++
+		}
+	}
+	if subjects > 1 {
+		return errs.NewUsageExitError("Only one of --user, --team, or --machine can be given.", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := getOrg(c, client, ctx.String("org"))
+	if err != nil {
+		return err
+	}
+
+	vars := policyeval.Vars{Org: org.Body.Name}
+	subject, teamIDs, err := policySubjectTeams(c, ctx, client, org, &vars)
+	if err != nil {
+		return err
+	}
+
+	policies, err := attachedPolicies(c, client, org.ID, teamIDs)
+	if err != nil {
+		return errs.NewErrorExitError(policiesTestFailed, err)
+	}
+
+	req, err := policyRequest(c, client, org, subject, teamIDs)
+	if err != nil {
+		return errs.NewErrorExitError(policiesTestFailed, err)
+	}
+
+	decisions, err := policyeval.Evaluate(policies, vars, req, action, resource)
+	if err != nil {
+		return errs.NewErrorExitError(policiesTestFailed, err)
+	}
+
+	fmt.Printf("Access for %s to %s:\n\n", subject, resource)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(w, tableHeader("ACTION", "EFFECT", "POLICY", "STATEMENT"))
+	for _, d := range decisions {
+		effect := "deny"
+		if d.Allowed {
+			effect = "allow"
+		}
+
+		policy, stmt := "-", "no matching statement"
+		if d.Statement != nil {
+			policy = d.Policy.Body.Policy.Name
+			stmt = fmt.Sprintf("%s %s %s", d.Statement.Effect.String(),
+				d.Statement.Action.ShortString(), d.Statement.Resource)
+			if d.Statement.Conditions != nil {
+				stmt += " if " + d.Statement.Conditions.String()
+			}
+		}
+		if d.Unmet != "" {
+			stmt += " (unmet: " + d.Unmet + ")"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Action.String(), effect, policy, stmt)
+	}
+	w.Flush()
+
+	signatures, err := client.Policies.Signatures(c, org.ID)
+	if err != nil {
+		return errs.NewErrorExitError("Unable to verify policy signatures.", err)
+	}
+	warnUntrustedPolicies(policies, signatures)
+
+	return nil
+}
+
+// warnUntrustedPolicies warns about any of the policies whose signature is
+// invalid, as their statements may have been changed since they were signed.
+func warnUntrustedPolicies(policies []envelope.Policy, signatures []apitypes.PolicySignature) {
+	invalid := make(map[identity.ID]string)
+	for _, s := range signatures {
+		if s.Status == apitypes.PolicyInvalid {
+			invalid[*s.PolicyID] = s.Problem
+		}
+	}
+
+	for _, p := range policies {
+		if problem, ok := invalid[*p.ID]; ok {
+			fmt.Fprintf(os.Stderr, "\nWarning: the %s policy can't be trusted: %s. "+
+				"Its statements may have been tampered with.\n", p.Body.Policy.Name, problem)
+		}
+	}
+}
+
+// policySubjectTeams returns a description of the user, team or machine given
+// by ctx, and the ids of the teams whose policies apply to them. With no
+// subject given, the current session is used.
+func policySubjectTeams(c context.Context, ctx *cli.Context, client *api.Client,
+	org *envelope.Org, vars *policyeval.Vars) (string, []identity.ID, error) {
+
+	if name := ctx.String("team"); name != "" {
+		teams, err := client.Teams.GetByName(c, org.ID, name)
+		if err != nil {
+			return "", nil, errs.NewErrorExitError("Unable to lookup team.", err)
+		}
+		if len(teams) < 1 {
+			return "", nil, errs.NewExitError("Team not found.")
+		}
+		return "team " + name, []identity.ID{*teams[0].ID}, nil
+	}
+
+	var machineID *identity.ID
+	var userID *identity.ID
+	name := ctx.String("machine")
+	switch {
+	case name != "":
+		machines, err := client.Machines.List(c, org.ID, nil, &name, nil)
+		if err != nil {
+			return "", nil, errs.NewErrorExitError("Unable to lookup machine.", err)
+		}
+		if len(machines) < 1 {
+			return "", nil, errs.NewExitError("Machine not found.")
+		}
+		machineID = machines[0].Machine.ID
+	case ctx.String("user") != "":
+		name = ctx.String("user")
+		profile, err := client.Profiles.ListByName(c, name)
+		if apitypes.IsNotFoundError(err) || (err == nil && profile == nil) {
+			return "", nil, errs.NewExitError("User not found.")
+		}
+		if err != nil {
+			return "", nil, errs.NewErrorExitError("Unable to lookup user.", err)
+		}
+		userID = profile.ID
+	default:
+		session, err := client.Session.Who(c)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if session.Type() == apitypes.MachineSession {
+			name = session.Name()
+			machineID = session.ID()
+		} else {
+			name = session.Username()
+			userID = session.ID()
+		}
+	}
+
+	var memberships []envelope.Membership
+	if machineID != nil {
+		machine, err := client.Machines.Get(c, machineID)
+		if err != nil {
+			return "", nil, errs.NewErrorExitError("Unable to lookup machine.", err)
+		}
+		memberships = machine.Memberships
+		name = "machine " + name
+	} else {
+		var err error
+		memberships, err = client.Memberships.List(c, org.ID, userID, nil)
+		if err != nil {
+			return "", nil, errs.NewErrorExitError("Unable to lookup teams.", err)
+		}
+		vars.Username = name
+		name = "user " + name
+	}
+
+	teamIDs := make([]identity.ID, 0, len(memberships))
+	for _, m := range memberships {
+		teamIDs = append(teamIDs, *m.Body.TeamID)
+	}
+
+	return name, teamIDs, nil
+}
+
+// policyRequest returns the request to evaluate conditions against: now, as
+// the subject's teams. As the subject is not logging in, conditions requiring
+// a recent login are never met.
+func policyRequest(c context.Context, client *api.Client, org *envelope.Org,
+	subject string, teamIDs []identity.ID) (*policyeval.Request, error) {
+
+	req := &policyeval.Request{Time: time.Now()}
+	if !strings.HasPrefix(subject, "machine ") {
+		return req, nil
+	}
+
+	teams, err := client.Teams.GetByOrg(c, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	member := make(map[identity.ID]bool, len(teamIDs))
+	for _, id := range teamIDs {
+		member[id] = true
+	}
+	for _, t := range teams {
+		if member[*t.ID] {
+			req.MachineTeams = append(req.MachineTeams, t.Body.Name)
+		}
+	}
+
+	return req, nil
+}
+
+// attachedPolicies returns the policies attached to any of the given teams.
+func attachedPolicies(c context.Context, client *api.Client, orgID *identity.ID,
+	teamIDs []identity.ID) ([]envelope.Policy, error) {
+
+	all, err := client.Policies.List(c, orgID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	attached := make(map[identity.ID]bool)
+	for i := range teamIDs {
+		attachments, err := client.Policies.AttachmentsList(c, orgID, &teamIDs[i], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range attachments {
+			attached[*a.Body.PolicyID] = true
+		}
+	}
+
+	var policies []envelope.Policy
+	for _, p := range all {
+		if attached[*p.ID] {
+			policies = append(policies, p)
+		}
+	}
+
+	return policies, nil
+}
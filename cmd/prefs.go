@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/prefs"
+	"github.com/manifoldco/torus-cli/telemetry"
 
 	"github.com/go-ini/ini"
 	"github.com/kr/text"
@@ -35,6 +38,12 @@ func init() {
 					return listPref(ctx)
 				},
 			},
+			{
+				Name:      "telemetry",
+				Usage:     "Turn recording anonymous usage data on or off",
+				ArgsUsage: "<on|off>",
+				Action:    setTelemetryPref,
+			},
 		},
 	}
 	Cmds = append(Cmds, prefs)
@@ -42,39 +51,77 @@ func init() {
 
 func listPref(ctx *cli.Context) error {
 	const loadErr = "Failed to load prefs."
-	preferences, err := prefs.NewPreferences()
+	preferences, err := prefs.LoadFile()
 	if err != nil {
 		return errs.NewErrorExitError(loadErr, err)
 	}
 
-	spacer := "    "
 	filepath, _ := prefs.RcPath()
 	fmt.Println("\n" + filepath + "\n")
 
-	coreCount := preferences.CountFields("Core")
-	defaultsCount := preferences.CountFields("Defaults")
+	sections := []struct {
+		name  string
+		field string
+		value interface{}
+	}{
+		{"core", "Core", &preferences.Core},
+		{"defaults", "Defaults", &preferences.Defaults},
+		{"telemetry", "Telemetry", &preferences.Telemetry},
+		{"cache", "Cache", &preferences.Cache},
+	}
+
+	count := 0
+	for _, s := range sections {
+		if preferences.CountFields(s.field) == 0 {
+			continue
+		}
 
-	if coreCount > 0 {
-		fmt.Println("[core]")
-		fc := ini.Empty()
-		err = ini.ReflectFrom(fc, &preferences.Core)
+		count++
+		err = printPrefSection(s.name, s.value)
 		if err != nil {
 			return errs.NewErrorExitError(loadErr, err)
 		}
-		fc.WriteToIndent(text.NewIndentWriter(os.Stdout, []byte(spacer)), spacer)
 	}
 
-	if defaultsCount > 0 {
-		fmt.Println("[defaults]")
-		fd := ini.Empty()
-		err = ini.ReflectFrom(fd, &preferences.Defaults)
+	var registries, profiles, backends []string
+	for name := range preferences.Registries {
+		registries = append(registries, name)
+	}
+	for name := range preferences.Profiles {
+		profiles = append(profiles, name)
+	}
+	for name := range preferences.Backends {
+		backends = append(backends, name)
+	}
+	sort.Strings(registries)
+	sort.Strings(profiles)
+	sort.Strings(backends)
+
+	for _, name := range registries {
+		count++
+		err = printPrefSection("registry."+name, preferences.Registries[name])
 		if err != nil {
 			return errs.NewErrorExitError(loadErr, err)
 		}
-		fd.WriteToIndent(text.NewIndentWriter(os.Stdout, []byte(spacer)), spacer)
 	}
 
-	if defaultsCount < 1 && coreCount < 1 {
+	for _, name := range profiles {
+		count++
+		err = printPrefSection("profile."+name, preferences.Profiles[name])
+		if err != nil {
+			return errs.NewErrorExitError(loadErr, err)
+		}
+	}
+
+	for _, name := range backends {
+		count++
+		err = printPrefSection("backend."+name, preferences.Backends[name])
+		if err != nil {
+			return errs.NewErrorExitError(loadErr, err)
+		}
+	}
+
+	if count == 0 {
 		fmt.Println("No preferences set. Use 'torus prefs set' to update.")
 		fmt.Println("")
 	}
@@ -82,6 +129,20 @@ func listPref(ctx *cli.Context) error {
 	return nil
 }
 
+// printPrefSection prints the preferences in v as the named ini section.
+func printPrefSection(name string, v interface{}) error {
+	spacer := "    "
+	fmt.Println("[" + name + "]")
+	f := ini.Empty()
+	err := ini.ReflectFrom(f, v)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteToIndent(text.NewIndentWriter(os.Stdout, []byte(spacer)), spacer)
+	return err
+}
+
 func setPref(ctx *cli.Context) error {
 	args := ctx.Args()
 	key := args.Get(0)
@@ -98,7 +159,7 @@ func setPref(ctx *cli.Context) error {
 }
 
 func setPrefByName(key, value string) error {
-	preferences, err := prefs.NewPreferences()
+	preferences, err := prefs.LoadFile()
 	if err != nil {
 		return errs.NewErrorExitError("Failed to load prefs.", err)
 	}
@@ -122,20 +183,46 @@ func setPrefByName(key, value string) error {
 		return err
 	}
 
-	// Reflect struct to ini format
-	cfg := ini.Empty()
-	err = ini.ReflectFrom(cfg, &result)
+	err = result.Save()
 	if err != nil {
 		return errs.NewErrorExitError("Failed to save preferences.", err)
 	}
 
-	// Save updated ini to filePath
-	rcPath, _ := prefs.RcPath()
-	err = cfg.SaveTo(rcPath)
+	fmt.Println("Preferences updated.")
+	return nil
+}
+
+func setTelemetryPref(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		return errs.NewUsageExitError("Must supply on or off", ctx)
+	}
+
+	var value string
+	switch args[0] {
+	case "on":
+		value = "true"
+	case "off":
+		value = "false"
+	default:
+		return errs.NewUsageExitError("Unknown value: "+args[0], ctx)
+	}
+
+	err := setPrefByName("telemetry.enabled", value)
+	if err != nil || value == "true" {
+		return err
+	}
+
+	// Don't keep anything around that the user no longer wants recorded.
+	cfg, err := config.LoadConfig()
 	if err != nil {
-		return errs.NewErrorExitError("Failed to save preferences.", err)
+		return err
+	}
+
+	err = telemetry.NewQueue(cfg.TorusRoot).Clear()
+	if err != nil {
+		return errs.NewErrorExitError("Could not remove queued usage data.", err)
 	}
 
-	fmt.Println("Preferences updated.")
 	return nil
 }
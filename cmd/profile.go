@@ -35,6 +35,14 @@ func init() {
 					ensureDaemon, ensureSession, setUserEnv, profileEdit,
 				),
 			},
+			{
+				Name:      "discoverable",
+				Usage:     "Show or set whether admins can find your account by email when inviting you",
+				ArgsUsage: "[on|off]",
+				Action: chain(
+					ensureDaemon, ensureSession, profileDiscoverable,
+				),
+			},
 		},
 	}
 	Cmds = append(Cmds, profile)
@@ -101,14 +109,13 @@ func profileEdit(ctx *cli.Context) error {
 		return err
 	}
 
-	var newPassword string
+	var currentPassword, newPassword string
 	err = AskPerform("Would you like to change your password")
 	if err == nil {
-		password, err := changePassword(&c, client, session)
+		currentPassword, newPassword, err = changePasswordPrompt()
 		if err != nil {
 			return err
 		}
-		newPassword = password
 	}
 
 	// Don't perform any action if no changes occurred
@@ -125,9 +132,6 @@ func profileEdit(ctx *cli.Context) error {
 	if ogName != name {
 		delta.Name = name
 	}
-	if newPassword != "" {
-		delta.Password = newPassword
-	}
 
 	preamble := "\nYou are about to update your profile to the values entered above."
 	if email != ogEmail {
@@ -139,17 +143,22 @@ func profileEdit(ctx *cli.Context) error {
 	}
 
 	// Update the profile
-	result, err := client.Users.Update(c, delta)
-	if err != nil {
-		return errs.NewErrorExitError("Failed to update profile.", err)
+	if delta.Email != "" || delta.Name != "" {
+		_, err = client.Users.Update(c, delta)
+		if err != nil {
+			return errs.NewErrorExitError("Failed to update profile.", err)
+		}
 	}
-	currentEmail := result.Body.Email
 
-	// If the password has changed, log the user in again
+	// The daemon re-encrypts the master key with the new password, and keeps
+	// the session alive.
 	if newPassword != "" {
-		err = performLogin(c, client, currentEmail, newPassword, false)
+		_, err = client.Users.ChangePassword(c, currentPassword, newPassword)
+		if apitypes.IsUnauthorizedError(err) {
+			return errs.NewExitError("Invalid password.")
+		}
 		if err != nil {
-			return err
+			return errs.NewErrorExitError("Failed to change password.", err)
 		}
 	}
 
@@ -170,21 +179,61 @@ func profileEdit(ctx *cli.Context) error {
 	return nil
 }
 
-func changePassword(c *context.Context, client *api.Client, session *api.Session) (string, error) {
-	// Retrieve current password value
+// profileDiscoverable shows, or sets, whether the user's account can be found
+// by their email address when they are invited to an org.
+func profileDiscoverable(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) > 1 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	var settings *apitypes.DirectorySettings
+	switch {
+	case len(args) == 0:
+		settings, err = client.Directory.Settings(c)
+		if err != nil {
+			return errs.NewErrorExitError("Could not retrieve directory settings.", err)
+		}
+	case args[0] == "on" || args[0] == "off":
+		settings, err = client.Directory.SetDiscoverable(c, args[0] == "on")
+		if err != nil {
+			return errs.NewErrorExitError("Could not update directory settings.", err)
+		}
+	default:
+		return errs.NewUsageExitError("Expected on or off.", ctx)
+	}
+
+	if settings.Discoverable {
+		fmt.Println("Org admins who invite your email address can see your username.")
+	} else {
+		fmt.Println("Your account can't be found by email address.")
+	}
+
+	return nil
+}
+
+// changePasswordPrompt asks for the current password and a new one. The
+// current password is checked by the daemon when the change is made.
+func changePasswordPrompt() (string, string, error) {
 	oldLabel := "Current Password"
 	currentPassword, err := PasswordPrompt(false, &oldLabel)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	// Test the user's current password
-	err = testLogin(*c, client, session.Email(), currentPassword)
+	newLabel := newPasswordLabel
+	newPassword, err := PasswordPrompt(true, &newLabel)
 	if err != nil {
-		return "", errs.NewExitError("Invalid password.")
+		return "", "", err
 	}
 
-	// Obtain new value for password
-	newLabel := "New Password"
-	return PasswordPrompt(true, &newLabel)
+	return currentPassword, newPassword, nil
 }
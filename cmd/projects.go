@@ -39,12 +39,41 @@ func init() {
 				Usage: "List services for an organization",
 				Flags: []cli.Flag{
 					orgFlag("List projects in an organization", true),
+					cli.BoolFlag{
+						Name:  "archived",
+						Usage: "Include archived projects",
+					},
 				},
 				Action: chain(
 					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 					setUserEnv, checkRequiredFlags, listProjectsCmd,
 				),
 			},
+			{
+				Name:      "archive",
+				Usage:     "Archive a project, hiding it from listings and blocking new secrets",
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					orgFlag("Archive the project in this org", true),
+					stdAutoAcceptFlag,
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, archiveProjectCmd,
+				),
+			},
+			{
+				Name:      "restore",
+				Usage:     "Restore an archived project",
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					orgFlag("Restore the project in this org", true),
+				},
+				Action: chain(
+					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+					checkRequiredFlags, restoreProjectCmd,
+				),
+			},
 		},
 	}
 	Cmds = append(Cmds, projects)
@@ -58,6 +87,9 @@ func listProjectsCmd(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	if !ctx.Bool("archived") {
+		projects = activeProjects(projects)
+	}
 
 	fmt.Println("")
 	count := strconv.Itoa(len(projects))
@@ -65,13 +97,29 @@ func listProjectsCmd(ctx *cli.Context) error {
 	fmt.Println(title)
 	fmt.Println(strings.Repeat("-", utf8.RuneCountInString(title)))
 	for _, project := range projects {
-		fmt.Println(project.Body.Name)
+		if project.Body.Archived() {
+			fmt.Println(project.Body.Name + " (archived)")
+		} else {
+			fmt.Println(project.Body.Name)
+		}
 	}
 	fmt.Println("")
 
 	return nil
 }
 
+// activeProjects returns the projects that have not been archived.
+func activeProjects(projects []envelope.Project) []envelope.Project {
+	active := make([]envelope.Project, 0, len(projects))
+	for _, p := range projects {
+		if !p.Body.Archived() {
+			active = append(active, p)
+		}
+	}
+
+	return active
+}
+
 func listProjects(ctx *context.Context, client *api.Client, orgID *identity.ID, name *string) ([]envelope.Project, error) {
 	c, client, err := NewAPIClient(ctx, client)
 	if err != nil {
@@ -196,3 +244,82 @@ func createProjectByName(c context.Context, client *api.Client, orgID *identity.
 	fmt.Printf("Project %s created.\n", name)
 	return project, nil
 }
+
+func archiveProjectCmd(ctx *cli.Context) error {
+	return setProjectState(ctx, true)
+}
+
+func restoreProjectCmd(ctx *cli.Context) error {
+	return setProjectState(ctx, false)
+}
+
+// setProjectState archives or restores the project named by the command's
+// argument.
+func setProjectState(ctx *cli.Context, archive bool) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		return errs.NewUsageExitError("A project name is required.", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, err := client.Orgs.GetByName(c, ctx.String("org"))
+	if err != nil {
+		return errs.NewErrorExitError(projectListFailed, err)
+	}
+	if org == nil {
+		return errs.NewExitError("Org not found.")
+	}
+
+	name := args[0]
+	projects, err := listProjects(&c, client, org.ID, &name)
+	if err != nil {
+		return errs.NewErrorExitError(projectListFailed, err)
+	}
+	if len(projects) != 1 {
+		return errs.NewExitError("Project not found.")
+	}
+	project := projects[0]
+
+	if project.Body.Archived() == archive {
+		state := "active"
+		if archive {
+			state = "archived"
+		}
+		fmt.Printf("Project %s is already %s.\n", name, state)
+		return nil
+	}
+
+	if !archive {
+		_, err = client.Projects.Restore(c, project.ID)
+		if err != nil {
+			return errs.NewErrorExitError("Could not restore project.", err)
+		}
+
+		fmt.Printf("Project %s restored.\n", name)
+		return nil
+	}
+
+	preamble := fmt.Sprintf("You are about to archive the %s project. It will be hidden "+
+		"from listings, and no secrets can be set in it until it is restored. "+
+		"Its secrets are kept, and can still be read.", name)
+	abortErr := ConfirmDialogue(ctx, nil, &preamble, "", true)
+	if abortErr != nil {
+		return abortErr
+	}
+
+	_, err = client.Projects.Archive(c, project.ID)
+	if err != nil {
+		return errs.NewErrorExitError("Could not archive project.", err)
+	}
+
+	fmt.Printf("Project %s archived. Use '%s projects restore %s' to restore it.\n",
+		name, ctx.App.Name, name)
+	return nil
+}
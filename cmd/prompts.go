@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/asaskevich/govalidator"
@@ -11,6 +12,7 @@ import (
 	"github.com/manifoldco/torus-cli/api"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/i18n"
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/prefs"
 	"github.com/manifoldco/torus-cli/primitive"
@@ -21,6 +23,8 @@ const slugPattern = "^[a-z][a-z0-9\\-\\_]{0,63}$"
 const namePattern = "^[a-zA-Z\\s,\\.'\\-pL]{1,64}$"
 const inviteCodePattern = "^[0-9a-ht-zjkmnpqr]{10}$"
 const verifyCodePattern = "^[0-9a-ht-zjkmnpqr]{9}$"
+const mfaCodePattern = "^[0-9]{6}$"
+const recoveryCodePattern = "^[0-9a-ht-zjkmnpqr]{4}(-?[0-9a-ht-zjkmnpqr]{4}){5}$"
 
 func validateSlug(slugType string) promptui.ValidateFunc {
 	msg := slugType + " names can only use a-z, 0-9, hyphens and underscores"
@@ -37,11 +41,70 @@ func validateInviteCode(input string) error {
 	if govalidator.StringMatches(input, inviteCodePattern) {
 		return nil
 	}
-	return promptui.NewValidationError("Please enter a valid invite code")
+	return promptui.NewValidationError(i18n.T("Please enter a valid invite code"))
+}
+
+func validateVerifyCode(input string) error {
+	if govalidator.StringMatches(strings.ToLower(input), verifyCodePattern) {
+		return nil
+	}
+	return promptui.NewValidationError(i18n.T("Please enter a valid code"))
+}
+
+func validateRecoveryCode(input string) error {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if govalidator.StringMatches(input, recoveryCodePattern) {
+		return nil
+	}
+	return promptui.NewValidationError(i18n.T("Please enter a valid recovery code"))
+}
+
+func validateMFACode(input string) error {
+	if govalidator.StringMatches(strings.Replace(input, " ", "", -1), mfaCodePattern) {
+		return nil
+	}
+	return promptui.NewValidationError(i18n.T("Please enter the 6 digit code from your authenticator app"))
+}
+
+func validatePassword(input string) error {
+	length := len(input)
+	if length >= 8 {
+		return nil
+	}
+	if length > 0 {
+		return promptui.NewValidationError(i18n.T("Passwords must be at least 8 characters"))
+	}
+
+	return promptui.NewValidationError(i18n.T("Please enter your password"))
+}
+
+func validateEmail(input string) error {
+	if govalidator.IsEmail(input) {
+		return nil
+	}
+	return promptui.NewValidationError(i18n.T("Please enter a valid email address"))
+}
+
+func validateUsername(input string) error {
+	if govalidator.StringMatches(input, slugPattern) {
+		return nil
+	}
+	return promptui.NewValidationError(i18n.T("Please enter a valid username"))
+}
+
+func validateFullName(input string) error {
+	if govalidator.StringMatches(input, namePattern) {
+		return nil
+	}
+	return promptui.NewValidationError(i18n.T("Please enter a valid name"))
 }
 
 // AskPerform prompts the user if they want to do a specified action
 func AskPerform(label string) error {
+	if promptsDisabled() {
+		return errs.NewPromptExitError(label, "")
+	}
+
 	preferences, err := prefs.NewPreferences()
 	if err != nil {
 		return err
@@ -66,12 +129,23 @@ func ConfirmDialogue(ctx *cli.Context, labelOverride, warningOverride *string, d
 		return nil
 	}
 
-	label := "Do you wish to continue"
+	label := i18n.T("Do you wish to continue")
 	if labelOverride != nil {
 		label = *labelOverride
 	}
 
-	warning := "The action you are about to perform cannot be undone."
+	if promptsDisabled() {
+		if strings.ToLower(defaultValue) == "y" {
+			return nil
+		}
+		hint := ""
+		if allowSkip {
+			hint = "Use --yes to confirm"
+		}
+		return errs.NewPromptExitError(label, hint)
+	}
+
+	warning := i18n.T("The action you are about to perform cannot be undone.")
 	if warningOverride != nil {
 		warning = *warningOverride
 	}
@@ -88,6 +162,40 @@ func ConfirmDialogue(ctx *cli.Context, labelOverride, warningOverride *string, d
 	return err
 }
 
+// ConfirmNamePrompt shows warning, and has the user type name to confirm an
+// action that can't be undone. Unlike ConfirmDialogue, it can't be skipped
+// with --yes; when prompting is turned off, name is read from confirmNameEnv.
+func ConfirmNamePrompt(name, warning string) error {
+	validate := func(input string) error {
+		if input != name {
+			return promptui.NewValidationError("Enter " + name + " to confirm")
+		}
+		return nil
+	}
+
+	if promptsDisabled() {
+		_, err := noPromptValue("Confirm name", confirmNameEnv, "", validate)
+		return err
+	}
+
+	preferences, err := prefs.NewPreferences()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(warning)
+	fmt.Println()
+
+	prompt := promptui.Prompt{
+		Label:     i18n.T("Type " + name + " to confirm"),
+		Validate:  validate,
+		IsVimMode: preferences.Core.Vim,
+	}
+
+	_, err = prompt.Run()
+	return err
+}
+
 // NamePrompt prompts the user to input a person's name
 func NamePrompt(override *string, defaultValue string, autoAccept bool) (string, error) {
 	var prompt promptui.Prompt
@@ -96,11 +204,18 @@ func NamePrompt(override *string, defaultValue string, autoAccept bool) (string,
 		return "", err
 	}
 
-	label := "Name"
+	label := i18n.T("Name")
 	if override != nil {
 		label = *override
 	}
 
+	if promptsDisabled() && !autoAccept {
+		if defaultValue == "" {
+			return "", errs.NewPromptExitError(label, "Give it as an argument instead")
+		}
+		autoAccept = true
+	}
+
 	if autoAccept {
 		err := validateSlug(strings.ToLower(label))(defaultValue)
 		if err != nil {
@@ -122,27 +237,70 @@ func NamePrompt(override *string, defaultValue string, autoAccept bool) (string,
 
 // VerificationPrompt prompts the user to input an email verify code
 func VerificationPrompt() (string, error) {
+	if promptsDisabled() {
+		return noPromptValue("Verification code", verificationCodeEnv, "", validateVerifyCode)
+	}
+
 	preferences, err := prefs.NewPreferences()
 	if err != nil {
 		return "", err
 	}
 	prompt := promptui.Prompt{
-		Label: "Verification code",
-		Validate: func(input string) error {
-			input = strings.ToLower(input)
-			if govalidator.StringMatches(input, verifyCodePattern) {
-				return nil
-			}
-			return promptui.NewValidationError("Please enter a valid code")
-		},
+		Label:     i18n.T("Verification code"),
+		Validate:  validateVerifyCode,
 		IsVimMode: preferences.Core.Vim,
 	}
 
 	return prompt.Run()
 }
 
+// RecoveryCodePrompt prompts the user to input one of their recovery codes
+func RecoveryCodePrompt() (string, error) {
+	if promptsDisabled() {
+		return noPromptValue("Recovery code", recoveryCodeEnv, "", validateRecoveryCode)
+	}
+
+	preferences, err := prefs.NewPreferences()
+	if err != nil {
+		return "", err
+	}
+	prompt := promptui.Prompt{
+		Label:     i18n.T("Recovery code"),
+		Validate:  validateRecoveryCode,
+		IsVimMode: preferences.Core.Vim,
+	}
+
+	return prompt.Run()
+}
+
+// MFACodePrompt prompts the user to input a code from their authenticator app
+func MFACodePrompt() (string, error) {
+	var code string
+	var err error
+	if promptsDisabled() {
+		code, err = noPromptValue("Authentication code", mfaCodeEnv, "", validateMFACode)
+	} else {
+		preferences, perr := prefs.NewPreferences()
+		if perr != nil {
+			return "", perr
+		}
+		prompt := promptui.Prompt{
+			Label:     i18n.T("Authentication code"),
+			Validate:  validateMFACode,
+			IsVimMode: preferences.Core.Vim,
+		}
+		code, err = prompt.Run()
+	}
+
+	return strings.Replace(code, " ", "", -1), err
+}
+
 // SelectProjectPrompt prompts the user to select an org from a list, or enter a new name
 func SelectProjectPrompt(projects []envelope.Project) (int, string, error) {
+	if promptsDisabled() {
+		return 0, "", errs.NewPromptExitError("Project", "Use --project instead")
+	}
+
 	preferences, err := prefs.NewPreferences()
 	if err != nil {
 		return 0, "", err
@@ -155,9 +313,9 @@ func SelectProjectPrompt(projects []envelope.Project) (int, string, error) {
 
 	// Get the user's org selection
 	prompt := promptui.SelectWithAdd{
-		Label:     "Select project",
+		Label:     i18n.T("Select project"),
 		Items:     names,
-		AddLabel:  "Create a new project",
+		AddLabel:  i18n.T("Create a new project"),
 		Validate:  validateSlug("project"),
 		IsVimMode: preferences.Core.Vim,
 	}
@@ -167,6 +325,10 @@ func SelectProjectPrompt(projects []envelope.Project) (int, string, error) {
 
 // SelectOrgPrompt prompts the user to select an org from a list, or enter a new name
 func SelectOrgPrompt(orgs []envelope.Org) (int, string, error) {
+	if promptsDisabled() {
+		return 0, "", errs.NewPromptExitError("Organization", "Use --org instead")
+	}
+
 	preferences, err := prefs.NewPreferences()
 	if err != nil {
 		return 0, "", err
@@ -179,9 +341,9 @@ func SelectOrgPrompt(orgs []envelope.Org) (int, string, error) {
 
 	// Get the user's org selection
 	prompt := promptui.SelectWithAdd{
-		Label:     "Select organization",
+		Label:     i18n.T("Select organization"),
 		Items:     names,
-		AddLabel:  "Create a new organization",
+		AddLabel:  i18n.T("Create a new organization"),
 		Validate:  validateSlug("org"),
 		IsVimMode: preferences.Core.Vim,
 	}
@@ -192,6 +354,10 @@ func SelectOrgPrompt(orgs []envelope.Org) (int, string, error) {
 // SelectTeamPrompt prompts the user to select a team from a list or enter a
 // new name, an optional label can be provided.
 func SelectTeamPrompt(teams []envelope.Team, label, addLabel string) (int, string, error) {
+	if promptsDisabled() {
+		return 0, "", errs.NewPromptExitError("Team", "")
+	}
+
 	preferences, err := prefs.NewPreferences()
 	if err != nil {
 		return 0, "", err
@@ -203,11 +369,11 @@ func SelectTeamPrompt(teams []envelope.Team, label, addLabel string) (int, strin
 	}
 
 	if label == "" {
-		label = "Select Team"
+		label = i18n.T("Select Team")
 	}
 
 	if addLabel == "" {
-		label = "Create a new team"
+		label = i18n.T("Create a new team")
 	}
 
 	prompt := promptui.SelectWithAdd{
@@ -222,7 +388,7 @@ func SelectTeamPrompt(teams []envelope.Team, label, addLabel string) (int, strin
 }
 
 func handleSelectError(err error, generic string) error {
-	if err == promptui.ErrEOF || err == promptui.ErrInterrupt {
+	if err == promptui.ErrEOF || err == promptui.ErrInterrupt || errs.IsPromptError(err) {
 		return err
 	}
 
@@ -251,6 +417,7 @@ func SelectCreateProject(c context.Context, client *api.Client, orgID *identity.
 
 	var idx int
 	if name == "" {
+		projects = activeProjects(projects)
 		idx, name, err = SelectProjectPrompt(projects)
 		if err != nil {
 			return nil, "", false, err
@@ -265,10 +432,10 @@ func SelectCreateProject(c context.Context, client *api.Client, orgID *identity.
 			}
 		}
 		if !found {
-			fmt.Println(promptui.FailedValue("Project name", name))
+			fmt.Println(promptui.FailedValue(i18n.T("Project name"), name))
 			return nil, "", false, errs.NewExitError("Project not found.")
 		}
-		fmt.Println(promptui.SuccessfulValue("Project name", name))
+		fmt.Println(promptui.SuccessfulValue(i18n.T("Project name"), name))
 	}
 
 	if idx == promptui.SelectedAdd {
@@ -311,10 +478,10 @@ func SelectCreateOrg(c context.Context, client *api.Client, name string) (*envel
 			}
 		}
 		if !found {
-			fmt.Println(promptui.FailedValue("Org name", name))
+			fmt.Println(promptui.FailedValue(i18n.T("Org name"), name))
 			return nil, "", false, errs.NewExitError("Org not found")
 		}
-		fmt.Println(promptui.SuccessfulValue("Org name", name))
+		fmt.Println(promptui.SuccessfulValue(i18n.T("Org name"), name))
 	}
 
 	if idx == promptui.SelectedAdd {
@@ -339,10 +506,13 @@ func SelectCreateRole(c context.Context, client *api.Client, orgID *identity.ID,
 		return nil, "", false, err
 	}
 
-	label := "Select Machine Role"
-	addLabel := "Create a new role"
+	label := i18n.T("Select Machine Role")
+	addLabel := i18n.T("Create a new role")
 	var idx int
 	if name == "" {
+		if promptsDisabled() {
+			return nil, "", false, errs.NewPromptExitError("Machine Role", "Use --role instead")
+		}
 		idx, name, err = SelectTeamPrompt(teams, label, addLabel)
 		if err != nil {
 			return nil, "", false, err
@@ -358,10 +528,10 @@ func SelectCreateRole(c context.Context, client *api.Client, orgID *identity.ID,
 		}
 
 		if !found {
-			fmt.Println(promptui.FailedValue("Machine Role", name))
+			fmt.Println(promptui.FailedValue(i18n.T("Machine Role"), name))
 			return nil, "", false, errs.NewExitError("Role not found")
 		}
-		fmt.Println(promptui.SuccessfulValue("Machine Role", name))
+		fmt.Println(promptui.SuccessfulValue(i18n.T("Machine Role"), name))
 	}
 
 	if idx == promptui.SelectedAdd {
@@ -375,31 +545,38 @@ func SelectCreateRole(c context.Context, client *api.Client, orgID *identity.ID,
 const PasswordMask = '●'
 
 // PasswordPrompt prompts the user to input a password value
+//
+// When prompting is turned off, the password is read from TORUS_PASSWORD,
+// TORUS_NEW_PASSWORD for a replacement password, or TORUS_BUNDLE_PASSPHRASE
+// for the passphrase of a bundle.
 func PasswordPrompt(shouldConfirm bool, labelOverride *string) (string, error) {
-	preferences, err := prefs.NewPreferences()
-	if err != nil {
-		return "", err
-	}
-
-	label := "Password"
+	label := i18n.T("Password")
 	if labelOverride != nil {
 		label = *labelOverride
 	}
 
-	prompt := promptui.Prompt{
-		Label: label,
-		Mask:  PasswordMask,
-		Validate: func(input string) error {
-			length := len(input)
-			if length >= 8 {
-				return nil
-			}
-			if length > 0 {
-				return promptui.NewValidationError("Passwords must be at least 8 characters")
+	if promptsDisabled() {
+		env := passwordEnv
+		if labelOverride != nil {
+			switch *labelOverride {
+			case newPasswordLabel:
+				env = newPasswordEnv
+			case bundlePassphraseLabel:
+				env = bundlePassphraseEnv
 			}
+		}
+		return noPromptValue(label, env, "", validatePassword)
+	}
 
-			return promptui.NewValidationError("Please enter your password")
-		},
+	preferences, err := prefs.NewPreferences()
+	if err != nil {
+		return "", err
+	}
+
+	prompt := promptui.Prompt{
+		Label:     label,
+		Mask:      PasswordMask,
+		Validate:  validatePassword,
 		IsVimMode: preferences.Core.Vim,
 	}
 
@@ -412,17 +589,17 @@ func PasswordPrompt(shouldConfirm bool, labelOverride *string) (string, error) {
 	}
 
 	prompt = promptui.Prompt{
-		Label: "Confirm " + label,
+		Label: i18n.T("Confirm %s", label),
 		Mask:  '●',
 		Validate: func(input string) error {
 			if len(input) > 0 {
 				if input != password {
-					return promptui.NewValidationError("Passwords do not match")
+					return promptui.NewValidationError(i18n.T("Passwords do not match"))
 				}
 				return nil
 			}
 
-			return promptui.NewValidationError("Please confirm your password")
+			return promptui.NewValidationError(i18n.T("Please confirm your password"))
 		},
 		IsVimMode: preferences.Core.Vim,
 	}
@@ -437,19 +614,18 @@ func PasswordPrompt(shouldConfirm bool, labelOverride *string) (string, error) {
 
 // EmailPrompt prompts the user to input an email
 func EmailPrompt(defaultValue string) (string, error) {
+	if promptsDisabled() {
+		return noPromptValue("Email", emailEnv, defaultValue, validateEmail)
+	}
+
 	preferences, err := prefs.NewPreferences()
 	if err != nil {
 		return "", err
 	}
 
 	prompt := promptui.Prompt{
-		Label: "Email",
-		Validate: func(input string) error {
-			if govalidator.IsEmail(input) {
-				return nil
-			}
-			return promptui.NewValidationError("Please enter a valid email address")
-		},
+		Label:     i18n.T("Email"),
+		Validate:  validateEmail,
 		IsVimMode: preferences.Core.Vim,
 	}
 	if defaultValue != "" {
@@ -461,19 +637,18 @@ func EmailPrompt(defaultValue string) (string, error) {
 
 // UsernamePrompt prompts the user to input a person's name
 func UsernamePrompt(un string) (string, error) {
+	if promptsDisabled() {
+		return noPromptValue("Username", usernameEnv, un, validateUsername)
+	}
+
 	preferences, err := prefs.NewPreferences()
 	if err != nil {
 		return "", err
 	}
 
 	prompt := promptui.Prompt{
-		Label: "Username",
-		Validate: func(input string) error {
-			if govalidator.StringMatches(input, slugPattern) {
-				return nil
-			}
-			return promptui.NewValidationError("Please enter a valid username")
-		},
+		Label:     i18n.T("Username"),
+		Validate:  validateUsername,
 		IsVimMode: preferences.Core.Vim,
 	}
 	if un != "" {
@@ -485,19 +660,18 @@ func UsernamePrompt(un string) (string, error) {
 
 // FullNamePrompt prompts the user to input a person's name
 func FullNamePrompt(name string) (string, error) {
+	if promptsDisabled() {
+		return noPromptValue("Name", nameEnv, name, validateFullName)
+	}
+
 	preferences, err := prefs.NewPreferences()
 	if err != nil {
 		return "", err
 	}
 
 	prompt := promptui.Prompt{
-		Label: "Name",
-		Validate: func(input string) error {
-			if govalidator.StringMatches(input, namePattern) {
-				return nil
-			}
-			return promptui.NewValidationError("Please enter a valid name")
-		},
+		Label:     i18n.T("Name"),
+		Validate:  validateFullName,
 		IsVimMode: preferences.Core.Vim,
 	}
 	if name != "" {
@@ -509,13 +683,17 @@ func FullNamePrompt(name string) (string, error) {
 
 // InviteCodePrompt prompts the user to input an invite code
 func InviteCodePrompt(defaultValue string) (string, error) {
+	if promptsDisabled() {
+		return noPromptValue("Invite Code", inviteCodeEnv, defaultValue, validateInviteCode)
+	}
+
 	preferences, err := prefs.NewPreferences()
 	if err != nil {
 		return "", err
 	}
 
 	prompt := promptui.Prompt{
-		Label:     "Invite Code",
+		Label:     i18n.T("Invite Code"),
 		Default:   defaultValue,
 		Validate:  validateInviteCode,
 		IsVimMode: preferences.Core.Vim,
@@ -525,7 +703,19 @@ func InviteCodePrompt(defaultValue string) (string, error) {
 }
 
 // SelectAcceptAction prompts the user to select an org from a list, or enter a new name
+//
+// When prompting is turned off, login is chosen if TORUS_EMAIL and
+// TORUS_PASSWORD are set.
 func SelectAcceptAction() (int, string, error) {
+	if promptsDisabled() {
+		_, hasEmail := os.LookupEnv(emailEnv)
+		_, hasPassword := os.LookupEnv(passwordEnv)
+		if hasEmail && hasPassword {
+			return 0, "Login", nil
+		}
+		return 0, "", errs.NewPromptExitError("Login or signup", "Set "+emailEnv+" and "+passwordEnv+" to log in")
+	}
+
 	preferences, err := prefs.NewPreferences()
 	if err != nil {
 		return 0, "", err
@@ -537,7 +727,7 @@ func SelectAcceptAction() (int, string, error) {
 	}
 
 	prompt := promptui.Select{
-		Label:     "Do you want to login or create an account?",
+		Label:     i18n.T("Do you want to login or create an account?"),
 		Items:     names,
 		IsVimMode: preferences.Core.Vim,
 	}
@@ -547,6 +737,10 @@ func SelectAcceptAction() (int, string, error) {
 
 // SelectProfileAction prompts the user to select an option from a list
 func SelectProfileAction() (int, string, error) {
+	if promptsDisabled() {
+		return 0, "", errs.NewPromptExitError("Profile update", "")
+	}
+
 	preferences, err := prefs.NewPreferences()
 	if err != nil {
 		return 0, "", err
@@ -559,7 +753,7 @@ func SelectProfileAction() (int, string, error) {
 
 	// Get the user's org selection
 	prompt := promptui.Select{
-		Label:     "What would you like to update?",
+		Label:     i18n.T("What would you like to update?"),
 		Items:     names,
 		IsVimMode: preferences.Core.Vim,
 	}
@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/dirprefs"
 	"github.com/manifoldco/torus-cli/errs"
 
 	"github.com/urfave/cli"
@@ -26,6 +30,39 @@ func init() {
 			machineFlag("Use this machine.", false),
 			serviceFlag("Use this service.", "default", true),
 			stdInstanceFlag,
+			cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Do not run the command if any secrets required by the project catalog are missing",
+			},
+			cli.BoolFlag{
+				Name:  "watch",
+				Usage: "Restart the command with new values whenever the secrets change",
+			},
+			cli.DurationFlag{
+				Name:  "lease",
+				Usage: "Hold secrets in the daemon for this long (e.g. 1h), passing them on a file descriptor instead of the environment",
+			},
+			stdOfflineFlag,
+			cli.BoolFlag{
+				Name:  "exclude-expired",
+				Usage: "Leave secrets that have expired out of the command's environment",
+			},
+			newSlicePlaceholder("env-override", "KEY=VALUE",
+				"Set KEY to VALUE for this run only, over any secret of the same name", "", "", false),
+			newSlicePlaceholder("env-file", "FILE",
+				"Set the KEY=VALUE lines of FILE for this run only, over any secrets of the same name", "", "", false),
+			newPlaceholder("prefix", "PREFIX",
+				"Add PREFIX to the name of each secret, turning dashes into underscores", "", "", false),
+			cli.BoolTFlag{
+				Name:  "uppercase",
+				Usage: "Upper case variable names, use --uppercase=false to keep the case of secret names",
+			},
+			newPlaceholder("name-map", "FILE",
+				"Name the secrets listed as SECRET=NAME lines in FILE with the given names", "", "", false),
+			cli.BoolFlag{
+				Name:  "explain",
+				Usage: "Print where each variable in the command's environment came from",
+			},
 		},
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
@@ -45,12 +82,100 @@ func runCmd(ctx *cli.Context) error {
 		args = strings.Split(args[0], " ")
 	}
 
-	secrets, _, err := getSecrets(ctx)
+	if ctx.Bool("watch") {
+		if ctx.Bool("offline") {
+			return errs.NewUsageExitError("Cannot specify --watch and --offline at the same time", ctx)
+		}
+		if ctx.Duration("lease") > 0 {
+			return errs.NewUsageExitError("Cannot specify --watch and --lease at the same time", ctx)
+		}
+		return runWatched(ctx, args)
+	}
+
+	if ttl := ctx.Duration("lease"); ttl > 0 {
+		return runLeased(ctx, args, ttl)
+	}
+
+	vars, _, err := getRunSecrets(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Create the command. It gets this processes's stdio.
+	return runProcess(secretsCommand(args, vars), "Failed to run command")
+}
+
+// getRunSecrets returns the variables to run a command with: the secrets,
+// with any overrides on top. They are checked against the project catalog if
+// --strict was given.
+func getRunSecrets(ctx *cli.Context) ([]runVar, string, error) {
+	overrides, err := runOverrides(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	namer, err := runEnvNamer(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secrets, path, err := getSecrets(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if ctx.Bool("exclude-expired") {
+		secrets = withoutExpired(secrets, time.Now())
+	}
+
+	vars, err := runEnv(secrets, overrides, namer)
+	if err != nil {
+		return nil, "", errs.NewErrorExitError("Could not name variables.", err)
+	}
+
+	if ctx.Bool("explain") {
+		explainRunEnv(os.Stderr, vars)
+	}
+
+	if ctx.Bool("strict") {
+		values := make(map[string]string, len(vars))
+		for _, v := range vars {
+			if !v.unset {
+				values[v.name] = v.value
+			}
+		}
+
+		problems, err := checkCatalogValues(ctx.String("service"), values)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if len(problems) > 0 {
+			msg := "Required secrets are missing or invalid:\n"
+			for _, p := range problems {
+				msg += fmt.Sprintf("  %s: %s\n", p.Entry.Name, p.Reason)
+			}
+			return nil, "", errs.NewExitError(msg + "Not running command.")
+		}
+	}
+
+	return vars, path, nil
+}
+
+// withoutExpired returns the secrets that had not expired by now.
+func withoutExpired(secrets []apitypes.CredentialEnvelope, now time.Time) []apitypes.CredentialEnvelope {
+	current := []apitypes.CredentialEnvelope{}
+	for _, secret := range secrets {
+		if !(*secret.Body).GetMeta().Expired(now) {
+			current = append(current, secret)
+		}
+	}
+
+	return current
+}
+
+// secretsCommand creates the command given by args, with vars added to its
+// environment. It gets this processes's stdio.
+func secretsCommand(args []string, vars []runVar) *exec.Cmd {
 	cmd := exec.Command(args[0], args[1:]...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
@@ -58,16 +183,25 @@ func runCmd(ctx *cli.Context) error {
 	cmd.Env = filterEnv()
 
 	// Add the secrets into the env
-	for _, secret := range secrets {
-		value := (*secret.Body).GetValue()
-		key := strings.ToUpper((*secret.Body).GetName())
-
-		cmd.Env = append(cmd.Env, key+"="+value.String())
+	for _, v := range vars {
+		cmd.Env = append(cmd.Env, v.name+"="+v.value)
 	}
 
-	err = cmd.Start()
+	return cmd
+}
+
+// runProcess starts cmd, relaying any signals we receive to it, and waits
+// for it to finish. If cmd exits unsuccessfully, we exit with its status.
+func runProcess(cmd *exec.Cmd, failMsg string) error {
+	return exitWithStatus(relayProcess(cmd, failMsg))
+}
+
+// relayProcess starts cmd, relaying any signals we receive to it, and waits
+// for it to finish, returning the error it exited with.
+func relayProcess(cmd *exec.Cmd, failMsg string) error {
+	err := cmd.Start()
 	if err != nil {
-		return errs.NewErrorExitError("Failed to run command", err)
+		return errs.NewErrorExitError(failMsg, err)
 	}
 
 	done := make(chan bool)
@@ -86,6 +220,12 @@ func runCmd(ctx *cli.Context) error {
 
 	err = cmd.Wait()
 	close(done)
+	return err
+}
+
+// exitWithStatus exits with the status of a command that exited
+// unsuccessfully with err. Any other error is returned.
+func exitWithStatus(err error) error {
 	if err != nil {
 		if exiterr, ok := err.(*exec.ExitError); ok {
 			if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
@@ -103,7 +243,9 @@ func filterEnv() []string {
 	env := []string{}
 	for _, e := range os.Environ() {
 		if strings.HasPrefix(e, "TORUS_EMAIL=") || strings.HasPrefix(e, "TORUS_PASSWORD=") ||
-			strings.HasPrefix(e, "TORUS_TOKEN_ID=") || strings.HasPrefix(e, "TORUS_TOKEN_SECRET=") {
+			strings.HasPrefix(e, "TORUS_TOKEN_ID=") || strings.HasPrefix(e, "TORUS_TOKEN_SECRET=") ||
+			strings.HasPrefix(e, "TORUS_NEW_PASSWORD=") || strings.HasPrefix(e, "TORUS_RECOVERY_CODE=") ||
+			strings.HasPrefix(e, "TORUS_MFA_CODE=") {
 			continue
 		}
 		env = append(env, e)
@@ -111,3 +253,35 @@ func filterEnv() []string {
 
 	return env
 }
+
+// checkCatalog compares the given secrets against the credentials the
+// project catalog, found in .torus.json, requires for the given service.
+func checkCatalog(service string, secrets []apitypes.CredentialEnvelope) ([]dirprefs.CatalogProblem, error) {
+	values := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		value := (*secret.Body).GetValue()
+		if value.IsUnset() {
+			continue
+		}
+
+		values[(*secret.Body).GetName()] = value.String()
+	}
+
+	return checkCatalogValues(service, values)
+}
+
+// checkCatalogValues compares the given values, keyed by name, against the
+// project catalog.
+func checkCatalogValues(service string, values map[string]string) ([]dirprefs.CatalogProblem, error) {
+	d, err := dirprefs.Load(true)
+	if err != nil {
+		return nil, err
+	}
+
+	dc, err := d.Resolve("", service)
+	if err != nil {
+		return nil, err
+	}
+
+	return dc.Catalog.Missing(service, values), nil
+}
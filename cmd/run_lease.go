@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+const (
+	// leaseEnv holds the lease of a command run with --lease, as
+	// <id>.<token>, for reading its secrets with torus lease.
+	leaseEnv = "TORUS_LEASE"
+
+	// secretsFDEnv holds the file descriptor a command run with --lease can
+	// read its secrets from.
+	secretsFDEnv = "TORUS_SECRETS_FD"
+)
+
+// runLeased runs the command given by args with its secrets held by the
+// daemon for ttl, rather than put in its environment. The command is given
+// the lease, and, except on Windows, a pipe on fd 3 to read its secrets from,
+// as NAME=VALUE entries each ended by a NUL byte. The lease is revoked once
+// the command exits.
+func runLeased(ctx *cli.Context, args []string, ttl time.Duration) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	vars, _, err := getRunSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	leaseVars := make([]apitypes.LeaseVar, len(vars))
+	for i, v := range vars {
+		leaseVars[i] = apitypes.LeaseVar{Name: v.name, Value: v.value}
+	}
+
+	lease, err := client.Leases.Create(c, leaseVars, ttl)
+	if err != nil {
+		return errs.NewErrorExitError("Could not lease secrets.", err)
+	}
+
+	cmd := secretsCommand(args, nil)
+	cmd.Env = append(cmd.Env, leaseEnv+"="+lease.ID+"."+lease.Token)
+
+	if runtime.GOOS != "windows" {
+		r, w, err := os.Pipe()
+		if err != nil {
+			client.Leases.Revoke(c, lease.ID, lease.Token)
+			return errs.NewErrorExitError("Could not pass secrets to command.", err)
+		}
+		defer r.Close()
+
+		cmd.ExtraFiles = []*os.File{r}
+		cmd.Env = append(cmd.Env, secretsFDEnv+"=3")
+
+		// The command may never read its secrets. If it doesn't, the write
+		// fails once the read end is closed, after it exits.
+		go func() {
+			for _, v := range vars {
+				_, err := w.WriteString(v.name + "=" + v.value + "\x00")
+				if err != nil {
+					break
+				}
+			}
+			w.Close()
+		}()
+	}
+
+	err = relayProcess(cmd, "Failed to run command")
+
+	revokeErr := client.Leases.Revoke(c, lease.ID, lease.Token)
+	if revokeErr != nil {
+		fmt.Fprintf(os.Stderr, "Could not revoke lease; it expires at %s.\n",
+			lease.ExpiresAt.Local().Format(time.RFC3339))
+	}
+
+	return exitWithStatus(err)
+}
+
+// parseLease splits a lease, as set in leaseEnv, into its id and token.
+func parseLease(raw string) (string, string, error) {
+	parts := strings.SplitN(raw, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errs.NewExitError("Invalid " + leaseEnv + ".")
+	}
+	return parts[0], parts[1], nil
+}
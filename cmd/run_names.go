@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+// envNamer turns the names of secrets into the names of the environment
+// variables they are injected as.
+type envNamer struct {
+	prefix    string
+	uppercase bool
+
+	// mapping holds names for specific secrets, keyed by the lower case
+	// secret name. Mapped names are used as they are.
+	mapping map[string]string
+}
+
+// defaultEnvNamer upper cases secret names, as torus has always done.
+var defaultEnvNamer = &envNamer{uppercase: true}
+
+// runEnvNamer returns the envNamer described by the --prefix, --uppercase and
+// --name-map flags.
+func runEnvNamer(ctx *cli.Context) (*envNamer, error) {
+	n := &envNamer{
+		prefix:    ctx.String("prefix"),
+		uppercase: ctx.BoolT("uppercase"),
+	}
+
+	file := ctx.String("name-map")
+	if file == "" {
+		return n, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errs.NewErrorExitError("Could not read name map.", err)
+	}
+	defer f.Close()
+
+	lines, err := readEnvImport(f)
+	if err != nil {
+		return nil, errs.NewErrorExitError("Could not read name map from "+file+".", err)
+	}
+
+	n.mapping = make(map[string]string, len(lines))
+	for _, l := range lines {
+		n.mapping[strings.ToLower(l.name)] = l.value.String()
+	}
+
+	return n, nil
+}
+
+// name returns the variable name for the named secret. With a prefix, dashes
+// become underscores, so db-url is injected as APP_DB_URL.
+func (n *envNamer) name(secret string) string {
+	if mapped, ok := n.mapping[strings.ToLower(secret)]; ok {
+		return mapped
+	}
+
+	name := secret
+	if n.prefix != "" {
+		name = n.prefix + strings.Replace(name, "-", "_", -1)
+	}
+
+	return n.override(name)
+}
+
+// override returns the variable name for a name given with --env-override or
+// --env-file. It is only upper cased, so overrides are given the final names.
+func (n *envNamer) override(name string) string {
+	if n.uppercase {
+		return strings.ToUpper(name)
+	}
+	return name
+}
+
+// nameCollisionError is returned when different secrets would be injected as
+// the same variable.
+type nameCollisionError struct {
+	name    string
+	secrets []string
+}
+
+func (e *nameCollisionError) Error() string {
+	sort.Strings(e.secrets)
+	return fmt.Sprintf("secrets %s would all be injected as %s",
+		strings.Join(e.secrets, ", "), e.name)
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+// runVar is a variable in the environment of a command started by torus run.
+type runVar struct {
+	name     string
+	value    string
+	unset    bool
+	override bool
+
+	// source is the path of the secret the value came from, or the flag
+	// that overrode it.
+	source string
+
+	// replaced is the path of the secret an override took the place of.
+	replaced string
+}
+
+// runOverride is a value given to torus run that is layered on top of the
+// secrets for a single run. Overrides are never written to the registry.
+type runOverride struct {
+	name   string
+	value  string
+	source string
+}
+
+// runOverrides reads the overrides given by --env-file and --env-override.
+// Values from --env-override win over those from files, and later files win
+// over earlier ones.
+func runOverrides(ctx *cli.Context) ([]runOverride, error) {
+	var overrides []runOverride
+
+	for _, file := range ctx.StringSlice("env-file") {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, errs.NewErrorExitError("Could not read overrides.", err)
+		}
+
+		vars, err := readEnvImport(f)
+		f.Close()
+		if err != nil {
+			return nil, errs.NewErrorExitError("Could not read overrides from "+file+".", err)
+		}
+
+		for _, v := range vars {
+			overrides = append(overrides, runOverride{
+				name:   v.name,
+				value:  v.value.String(),
+				source: "--env-file " + file,
+			})
+		}
+	}
+
+	for _, raw := range ctx.StringSlice("env-override") {
+		o, err := parseEnvOverride(raw)
+		if err != nil {
+			return nil, errs.NewUsageExitError(err.Error(), ctx)
+		}
+		overrides = append(overrides, *o)
+	}
+
+	return overrides, nil
+}
+
+func parseEnvOverride(raw string) (*runOverride, error) {
+	idx := strings.Index(raw, "=")
+	if idx < 1 {
+		return nil, fmt.Errorf("expected KEY=value for --env-override, got %q", raw)
+	}
+
+	return &runOverride{
+		name:   raw[:idx],
+		value:  raw[idx+1:],
+		source: "--env-override",
+	}, nil
+}
+
+// runEnv returns the variables to run a command with: the secrets, named by
+// namer, with the overrides layered on top. It fails if different secrets
+// would be given the same name.
+func runEnv(secrets []apitypes.CredentialEnvelope, overrides []runOverride,
+	namer *envNamer) ([]runVar, error) {
+
+	byName := make(map[string]*runVar, len(secrets)+len(overrides))
+	secretNames := make(map[string]string, len(secrets))
+	var names []string
+
+	for _, secret := range secrets {
+		body := *secret.Body
+		value := body.GetValue()
+		secretName := strings.ToLower(body.GetName())
+		name := namer.name(secretName)
+
+		if other, ok := secretNames[name]; ok && other != secretName {
+			return nil, &nameCollisionError{name: name, secrets: []string{other, secretName}}
+		}
+		secretNames[name] = secretName
+
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = &runVar{
+			name:   name,
+			value:  value.String(),
+			unset:  value.IsUnset(),
+			source: body.GetPathExp().String(),
+		}
+	}
+
+	for _, o := range overrides {
+		name := namer.override(o.name)
+
+		v, ok := byName[name]
+		if !ok {
+			names = append(names, name)
+			v = &runVar{name: name}
+			byName[name] = v
+		} else if !v.override {
+			v.replaced = v.source
+		}
+
+		v.value = o.value
+		v.unset = false
+		v.override = true
+		v.source = o.source
+	}
+
+	sort.Strings(names)
+	vars := make([]runVar, len(names))
+	for i, name := range names {
+		vars[i] = *byName[name]
+	}
+
+	return vars, nil
+}
+
+// explainRunEnv writes where each variable came from to w. Values are not
+// shown.
+func explainRunEnv(w io.Writer, vars []runVar) {
+	tw := tabwriter.NewWriter(w, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, tableHeader("NAME", "SOURCE"))
+	for _, v := range vars {
+		source := v.source
+		if v.override {
+			source = "override from " + v.source
+			if v.replaced != "" {
+				source += ", replacing " + v.replaced
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", v.name, source)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func runCred(pe *pathexp.PathExp, name, value string) apitypes.CredentialEnvelope {
+	var body apitypes.Credential = &apitypes.CredentialV2{
+		BaseCredential: apitypes.BaseCredential{
+			Name:    name,
+			PathExp: pe,
+			Value:   apitypes.NewStringCredentialValue(value),
+		},
+		State: "set",
+	}
+	return apitypes.CredentialEnvelope{Version: 2, Body: &body}
+}
+
+func TestRunEnv(t *testing.T) {
+	pe, err := pathexp.Parse("/org/project/production/api/jeff/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secrets := []apitypes.CredentialEnvelope{
+		runCred(pe, "port", "80"),
+		runCred(pe, "host", "example.com"),
+	}
+	overrides := []runOverride{
+		{name: "host", value: "staging.example.com", source: "--env-file local.env"},
+		{name: "HOST", value: "localhost", source: "--env-override"},
+		{name: "DEBUG", value: "1", source: "--env-override"},
+	}
+
+	vars, err := runEnv(secrets, overrides, defaultEnvNamer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"DEBUG": "1", "HOST": "localhost", "PORT": "80"}
+	if len(vars) != len(want) {
+		t.Fatalf("got %d vars, want %d", len(vars), len(want))
+	}
+	for _, v := range vars {
+		if want[v.name] != v.value {
+			t.Errorf("%s: got %q, want %q", v.name, v.value, want[v.name])
+		}
+	}
+
+	// Only the variables that replaced a secret say which one.
+	b := &bytes.Buffer{}
+	explainRunEnv(b, vars)
+	out := b.String()
+	for _, line := range []string{
+		"DEBUG  override from --env-override\n",
+		"HOST   override from --env-override, replacing " + pe.String() + "\n",
+		"PORT   " + pe.String() + "\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected explanation to contain %q, got:\n%s", line, out)
+		}
+	}
+}
+
+func TestRunEnvNames(t *testing.T) {
+	pe, err := pathexp.Parse("/org/project/production/api/jeff/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	namer := &envNamer{
+		prefix:    "app_",
+		uppercase: true,
+		mapping:   map[string]string{"token": "GITHUB_TOKEN"},
+	}
+
+	t.Run("names are transformed", func(t *testing.T) {
+		secrets := []apitypes.CredentialEnvelope{
+			runCred(pe, "db-url", "postgres://db"),
+			runCred(pe, "token", "abc"),
+		}
+		overrides := []runOverride{{name: "app_db_url", value: "postgres://localhost"}}
+
+		vars, err := runEnv(secrets, overrides, namer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string]string{"APP_DB_URL": "postgres://localhost", "GITHUB_TOKEN": "abc"}
+		if len(vars) != len(want) {
+			t.Fatalf("got %d vars, want %d", len(vars), len(want))
+		}
+		for _, v := range vars {
+			if want[v.name] != v.value {
+				t.Errorf("%s: got %q, want %q", v.name, v.value, want[v.name])
+			}
+		}
+	})
+
+	t.Run("collisions are errors", func(t *testing.T) {
+		secrets := []apitypes.CredentialEnvelope{
+			runCred(pe, "db-url", "a"),
+			runCred(pe, "db_url", "b"),
+		}
+
+		_, err := runEnv(secrets, nil, namer)
+		if err == nil || !strings.Contains(err.Error(), "db-url, db_url would all be injected as APP_DB_URL") {
+			t.Errorf("expected a collision, got %v", err)
+		}
+	})
+
+	t.Run("case can be kept", func(t *testing.T) {
+		n := &envNamer{}
+		if name := n.name("db-url"); name != "db-url" {
+			t.Errorf("expected db-url, got %s", name)
+		}
+	})
+}
+
+func TestParseEnvOverride(t *testing.T) {
+	o, err := parseEnvOverride("URL=http://localhost/?a=b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.name != "URL" || o.value != "http://localhost/?a=b" {
+		t.Errorf("unexpected override %+v", o)
+	}
+
+	for _, raw := range []string{"URL", "=value"} {
+		if _, err := parseEnvOverride(raw); err == nil {
+			t.Errorf("expected %q to be invalid", raw)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+const (
+	// runStopGrace is how long a watched command is given to exit after
+	// being asked to, before it is killed.
+	runStopGrace = 10 * time.Second
+
+	// runWatchRetry is how long to wait before watching for changes again
+	// after the daemon could not be reached.
+	runWatchRetry = 30 * time.Second
+)
+
+// runWatched runs the command given by args, restarting it with the new
+// values whenever the secrets it was given change. It exits when the
+// command exits on its own.
+func runWatched(ctx *cli.Context, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+
+	vars, path, err := getRunSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	rev, err := client.Credentials.Watch(context.Background(), path, "")
+	if err != nil {
+		return errs.NewErrorExitError("Could not watch secrets for changes.", err)
+	}
+
+	changes := make(chan bool, 1)
+	go watchSecrets(client, path, rev.Revision, changes)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals) // give us all signals to relay
+	defer signal.Stop(signals)
+
+	for {
+		cmd := secretsCommand(args, vars)
+		err := cmd.Start()
+		if err != nil {
+			return errs.NewErrorExitError("Failed to run command", err)
+		}
+
+		exited := make(chan error, 1)
+		go func() { exited <- cmd.Wait() }()
+
+	running:
+		for {
+			select {
+			case err := <-exited:
+				return exitWithStatus(err)
+			case s := <-signals:
+				cmd.Process.Signal(s)
+			case <-changes:
+				updated, _, err := getRunSecrets(ctx)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Secrets changed, but could not be retrieved. "+
+						"Not restarting command.\n%s\n", err)
+					continue
+				}
+
+				fmt.Fprintln(os.Stderr, "Secrets changed. Restarting command.")
+				vars = updated
+				stopProcess(cmd, exited)
+				break running
+			}
+		}
+	}
+}
+
+// watchSecrets long polls the daemon for changes to the secrets at path,
+// notifying changes of every new revision.
+func watchSecrets(client *api.Client, path, revision string, changes chan<- bool) {
+	for {
+		rev, err := client.Credentials.Watch(context.Background(), path, revision)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not watch secrets for changes: %s\n", err)
+			time.Sleep(runWatchRetry)
+			continue
+		}
+
+		if rev.Revision == revision {
+			continue
+		}
+
+		revision = rev.Revision
+		select {
+		case changes <- true:
+		default: // a restart is already pending
+		}
+	}
+}
+
+// stopProcess asks cmd to exit, killing it if it has not exited within
+// runStopGrace. exited receives the result of waiting on cmd.
+func stopProcess(cmd *exec.Cmd, exited <-chan error) {
+	err := cmd.Process.Signal(syscall.SIGTERM)
+	if err == nil {
+		select {
+		case <-exited:
+			return
+		case <-time.After(runStopGrace):
+		}
+	}
+
+	cmd.Process.Kill()
+	<-exited
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+
+	"github.com/manifoldco/torus-cli/cmd/output"
+)
+
+func init() {
+	search := cli.Command{
+		Name:      "search",
+		Usage:     "Find where secrets are set by name, across every path you can read",
+		ArgsUsage: "<pattern>",
+		Category:  "SECRETS",
+		Flags: []cli.Flag{
+			orgFlag("Only search this org, instead of every org you belong to", false),
+			newPlaceholder("path", "PATHEXP",
+				"Only list secrets set at paths overlapping PATHEXP", "", "", false),
+			cli.BoolFlag{
+				Name:  "regex, E",
+				Usage: "Match names with a regular expression, instead of a glob",
+			},
+		},
+		Action: chain(ensureDaemon, ensureSession, checkRequiredFlags, searchCmd),
+	}
+
+	Cmds = append(Cmds, search)
+}
+
+const searchFailed = "Could not search secrets."
+
+func searchCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "A pattern is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided.\n" +
+				"Note: patterns containing wildcards must be wrapped in quotes."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	orgName := ctx.String("org")
+	path := ctx.String("path")
+	if path != "" {
+		pe, err := pathexp.ParsePartial(path)
+		if err != nil {
+			return errs.NewUsageExitError("Invalid --path: "+err.Error(), ctx)
+		}
+
+		if org := string(pe.Org); org != "*" {
+			if orgName != "" && orgName != org {
+				return errs.NewUsageExitError("--path is not in --org "+orgName, ctx)
+			}
+			orgName = org
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	var orgs []envelope.Org
+	if orgName != "" {
+		org, err := getOrg(c, client, orgName)
+		if err != nil {
+			return err
+		}
+		orgs = []envelope.Org{*org}
+	} else {
+		orgs, err = client.Orgs.List(c)
+		if err != nil {
+			return errs.NewErrorExitError(searchFailed, err)
+		}
+	}
+
+	records := []output.Location{}
+	for _, org := range orgs {
+		locations, err := client.Credentials.SearchNames(c, org.ID, args[0], ctx.Bool("regex"), path, false)
+		if err != nil {
+			return errs.NewErrorExitError(searchFailed, err)
+		}
+
+		for _, l := range locations {
+			records = append(records, output.Location{
+				Org:  org.Body.Name,
+				Path: l.PathExp.String(),
+				Name: l.Name,
+			})
+		}
+	}
+
+	if output.IsJSON(ctx) {
+		return output.Write(os.Stdout, records)
+	}
+
+	if len(records) == 0 {
+		fmt.Fprintln(os.Stderr, "No secrets found.")
+		return nil
+	}
+
+	for _, r := range records {
+		fmt.Println(r.Path + "/" + r.Name)
+	}
+
+	return nil
+}
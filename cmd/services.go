@@ -96,6 +96,7 @@ func listServicesCmd(ctx *cli.Context) error {
 		if err != nil {
 			return errs.NewErrorExitError(serviceListFailed, err)
 		}
+		projects = activeProjects(projects)
 
 	} else {
 		// Retrieve only a single project by name
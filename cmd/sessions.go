@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/hints"
+)
+
+func init() {
+	sessions := cli.Command{
+		Name:     "sessions",
+		Usage:    "View and revoke the active sessions of your account",
+		Category: "ACCOUNT",
+		Subcommands: []cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List the active sessions of your account",
+				Action: chain(ensureDaemon, ensureSession, listSessionsCmd),
+			},
+			{
+				Name:      "revoke",
+				Usage:     "Revoke an active session, logging out the device it belongs to",
+				ArgsUsage: "[id]",
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "all-others",
+						Usage: "Revoke every session except the current one",
+					},
+					stdAutoAcceptFlag,
+				},
+				Action: chain(ensureDaemon, ensureSession, revokeSessionsCmd),
+			},
+		},
+	}
+
+	Cmds = append(Cmds, sessions)
+}
+
+const sessionsListFailed = "Could not list sessions, please try again."
+const sessionsRevokeFailed = "Could not revoke sessions, please try again."
+
+func listSessionsCmd(ctx *cli.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	sessions, err := client.Sessions.List(context.Background())
+	if err != nil {
+		return errs.NewErrorExitError(sessionsListFailed, err)
+	}
+
+	fmt.Println("")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, tableHeader(" ", "ID", "DEVICE", "IP", "VERSION", "CREATED", "LAST USED"))
+	fmt.Fprintln(w, " \t \t \t \t \t \t ")
+	for _, s := range sessions {
+		current := " "
+		if s.Current {
+			current = "*"
+		}
+
+		lastUsed := "-"
+		if s.LastUsed != nil {
+			lastUsed = s.LastUsed.Format(time.RFC3339)
+		}
+
+		device := s.Device
+		if device == "" {
+			device = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", current, s.ID, device, s.IP,
+			s.Version, s.Created.Format(time.RFC3339), lastUsed)
+	}
+	w.Flush()
+
+	fmt.Println("\n  * Current session")
+
+	hints.Display([]string{"sessions revoke"})
+	return nil
+}
+
+func revokeSessionsCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	allOthers := ctx.Bool("all-others")
+	if allOthers && len(args) > 0 {
+		return errs.NewUsageExitError("Cannot specify an id and --all-others", ctx)
+	}
+	if !allOthers && len(args) < 1 {
+		return errs.NewUsageExitError("Missing session id", ctx)
+	}
+	if len(args) > 1 {
+		return errs.NewUsageExitError("Too many arguments", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	sessions, err := client.Sessions.List(c)
+	if err != nil {
+		return errs.NewErrorExitError(sessionsRevokeFailed, err)
+	}
+
+	var targets []apitypes.ActiveSession
+	for _, s := range sessions {
+		if (allOthers && !s.Current) || (!allOthers && s.ID == args[0]) {
+			targets = append(targets, s)
+		}
+	}
+
+	if !allOthers {
+		if len(targets) < 1 {
+			return errs.NewExitError("Session not found.")
+		}
+		if targets[0].Current {
+			return errs.NewExitError(
+				"This is your current session. Use 'torus logout' to end it.")
+		}
+	}
+
+	if len(targets) == 0 {
+		fmt.Println("You have no other active sessions.")
+		return nil
+	}
+
+	preamble := fmt.Sprintf("You are about to revoke %d session(s). "+
+		"The devices they belong to will be logged out.", len(targets))
+	abortErr := ConfirmDialogue(ctx, nil, &preamble, "", true)
+	if abortErr != nil {
+		return abortErr
+	}
+
+	for _, s := range targets {
+		err = client.Sessions.Revoke(c, s.ID)
+		if err != nil {
+			return errs.NewErrorExitError(sessionsRevokeFailed, err)
+		}
+	}
+
+	if len(targets) == 1 {
+		fmt.Printf("Session %s revoked.\n", targets[0].ID)
+	} else {
+		fmt.Printf("%d sessions revoked.\n", len(targets))
+	}
+
+	return nil
+}
@@ -3,19 +3,26 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/urfave/cli"
 
 	"github.com/manifoldco/torus-cli/api"
 	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/hints"
+	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
 )
 
-var setUnsetFlags = []cli.Flag{
+// credentialPathFlags identify the path of a single secret.
+var credentialPathFlags = []cli.Flag{
 	stdOrgFlag,
 	stdProjectFlag,
 	newSlicePlaceholder("environment, e", "ENV", "Use this environment.",
@@ -28,13 +35,49 @@ var setUnsetFlags = []cli.Flag{
 		"*", "TORUS_INSTANCE", true),
 }
 
+var forceSetFlag = cli.BoolFlag{
+	Name:  "force, f",
+	Usage: "Overwrite the secret, even if someone else changed it at the same time.",
+}
+
+var setUnsetFlags = append(credentialPathFlags, forceSetFlag)
+
+// credentialMetaFlags describe the secret being set. They are stored
+// alongside it, unencrypted.
+var credentialMetaFlags = []cli.Flag{
+	newPlaceholder("description", "TEXT", "Describe the secret.", "", "", false),
+	newSlicePlaceholder("tag", "TAG", "Tag the secret.", "", "", false),
+	newPlaceholder("expires", "TIME",
+		"Mark the secret as expiring after this long (e.g. 90d), or on this date.", "", "", false),
+	cli.BoolFlag{
+		Name:  "honeytoken",
+		Usage: "Mark the secret as a honeytoken, alerting the org whenever it is read.",
+	},
+	newPlaceholder("broker", "PROVIDER",
+		"Make the secret dynamic: its value configures PROVIDER, which mints a short-lived value on every read.",
+		"", "", false),
+	newPlaceholder("ttl", "DURATION",
+		"How long values minted by --broker last (e.g. 15m), defaults to 1h.", "", "", false),
+}
+
+// defaultBrokerTTL is how long values minted for dynamic secrets last, unless
+// --ttl is given.
+const defaultBrokerTTL = time.Hour
+
 func init() {
 	set := cli.Command{
 		Name:      "set",
 		Usage:     "Set a secret for a service and environment",
-		ArgsUsage: "<name|path> <value>",
+		ArgsUsage: "<name|path> [value]",
 		Category:  "SECRETS",
-		Flags:     setUnsetFlags,
+		Flags: append(append(append([]cli.Flag{}, setUnsetFlags...), credentialMetaFlags...),
+			newPlaceholder("generate", "SPEC",
+				"Generate a random value in the daemon instead of giving one, "+
+					"e.g. length=32,charset=alnum, hex, base64, or uuid.", "", "", false),
+			newPlaceholder("file", "FILE",
+				"Set the value to the exact contents of FILE, such as a certificate. Use - for stdin.",
+				"", "", false),
+		),
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 			setSliceDefaults, setCmd,
@@ -46,6 +89,47 @@ func init() {
 
 func setCmd(ctx *cli.Context) error {
 	args := ctx.Args()
+
+	var contents []byte
+	if file := ctx.String("file"); file != "" {
+		if len(args) != 1 || ctx.String("generate") != "" {
+			msg := "name is required."
+			if len(args) > 1 || ctx.String("generate") != "" {
+				msg = "A value can't be given with --file."
+			}
+			return errs.NewUsageExitError(msg, ctx)
+		}
+
+		var err error
+		if file == "-" {
+			contents, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			contents, err = ioutil.ReadFile(file)
+		}
+		if err != nil {
+			return errs.NewErrorExitError("Could not read "+file+".", err)
+		}
+		args = append(args, "")
+	}
+
+	var spec *apitypes.GeneratorSpec
+	if generate := ctx.String("generate"); generate != "" {
+		if len(args) != 1 {
+			msg := "name is required."
+			if len(args) > 1 {
+				msg = "A value can't be given with --generate."
+			}
+			return errs.NewUsageExitError(msg, ctx)
+		}
+
+		var err error
+		spec, err = apitypes.ParseGeneratorSpec(generate)
+		if err != nil {
+			return errs.NewUsageExitError("Invalid --generate: "+err.Error(), ctx)
+		}
+		args = append(args, "")
+	}
+
 	if len(args) != 2 {
 		msg := "name and value are required."
 		if len(args) > 2 {
@@ -54,9 +138,25 @@ func setCmd(ctx *cli.Context) error {
 		return errs.NewUsageExitError(msg, ctx)
 	}
 
+	// Generated values are never seen by the CLI, so can't be checked.
+	if spec == nil {
+		value := args[1]
+		if ctx.String("file") != "" {
+			value = string(contents)
+		}
+
+		err := checkRules(args[0][strings.LastIndex(args[0], "/")+1:], value)
+		if err != nil {
+			return err
+		}
+	}
+
 	cred, err := setCredential(ctx, args[0], func() *apitypes.CredentialValue {
+		if ctx.String("file") != "" {
+			return apitypes.NewFileCredentialValue(contents)
+		}
 		return apitypes.NewStringCredentialValue(args[1])
-	})
+	}, spec)
 
 	if err != nil {
 		return errs.NewErrorExitError("Could not set credential.", err)
@@ -65,6 +165,10 @@ func setCmd(ctx *cli.Context) error {
 	name := (*cred.Body).GetName()
 	pe := (*cred.Body).GetPathExp()
 	fmt.Printf("\nCredential %s has been set at %s/%s\n", name, pe, name)
+	if spec != nil {
+		fmt.Printf("Its value was generated by the daemon (%s). "+
+			"Use 'torus view' or 'torus run' to read it.\n", spec)
+	}
 
 	hints.Display([]string{"view", "run"})
 	return nil
@@ -90,35 +194,45 @@ func determineCredential(ctx *cli.Context, nameOrPath string) (*pathexp.PathExp,
 			return nil, nil, errs.NewExitError("Secret name cannot be wildcard")
 		}
 	} else {
-		// Falling back to flags. do the expensive population of the user flag now,
-		// and see if any required flags (all of them) are missing.
-		err := chain(setUserEnv, checkRequiredFlags)(ctx)
+		var err error
+		pe, err = determinePathExp(ctx)
 		if err != nil {
 			return nil, nil, err
 		}
+	}
 
-		identity, err := deriveIdentitySlice(ctx)
-		if err != nil {
-			return nil, nil, err
-		}
+	return pe, &name, nil
+}
 
-		pe, err = pathexp.New(
-			ctx.String("org"),
-			ctx.String("project"),
-			ctx.StringSlice("environment"),
-			ctx.StringSlice("service"),
-			identity,
-			ctx.StringSlice("instance"),
-		)
-		if err != nil {
-			return nil, nil, err
-		}
+// determinePathExp returns the path expression described by the
+// credentialPathFlags.
+func determinePathExp(ctx *cli.Context) (*pathexp.PathExp, error) {
+	// Do the expensive population of the user flag now, and see if any
+	// required flags (all of them) are missing.
+	err := chain(setUserEnv, checkRequiredFlags)(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return pe, &name, nil
+	idents, err := deriveIdentitySlice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return pathexp.New(
+		ctx.String("org"),
+		ctx.String("project"),
+		ctx.StringSlice("environment"),
+		ctx.StringSlice("service"),
+		idents,
+		ctx.StringSlice("instance"),
+	)
 }
 
-func setCredential(ctx *cli.Context, nameOrPath string, valueMaker func() *apitypes.CredentialValue) (*apitypes.CredentialEnvelope, error) {
+// setCredential sets the secret named by nameOrPath to the value made by
+// valueMaker, or, if generate is not nil, to a value the daemon generates.
+func setCredential(ctx *cli.Context, nameOrPath string, valueMaker func() *apitypes.CredentialValue,
+	generate *apitypes.GeneratorSpec) (*apitypes.CredentialEnvelope, error) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return nil, err
@@ -136,18 +250,176 @@ func setCredential(ctx *cli.Context, nameOrPath string, valueMaker func() *apity
 		name = *credName
 	}
 
+	meta, err := credentialMeta(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.Honeytoken {
+		ok, err := client.Supports(c, api.FeatureHoneytokens)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errs.NewErrorExitError("Could not mark secret as a honeytoken.",
+				&api.UnsupportedError{Feature: api.FeatureHoneytokens})
+		}
+	}
+
+	if meta.Broker != nil {
+		ok, err := client.Supports(c, api.FeatureBroker)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errs.NewErrorExitError("Could not make the secret dynamic.",
+				&api.UnsupportedError{Feature: api.FeatureBroker})
+		}
+	}
+
+	org, project, err := credentialOwners(c, client, pe)
+	if err != nil {
+		return nil, err
+	}
+
+	cred := newCredential(org.ID, project.ID, pe, name, valueMaker())
+	cred.(*apitypes.CredentialV3).CredentialMeta = *meta
+	if !ctx.Bool("force") {
+		locations, err := client.Credentials.SearchNames(c, org.ID, strings.ToLower(name), false, pe.String(), true)
+		if err != nil {
+			return nil, err
+		}
+		expectVersions([]apitypes.Credential{cred}, locations)
+	}
+
+	create := func(confirmed bool) (*apitypes.CredentialEnvelope, error) {
+		if generate != nil {
+			return client.Credentials.Generate(c, &cred, generate, ctx.Bool("force"), confirmed, &progress)
+		}
+		return client.Credentials.Create(c, &cred, ctx.Bool("force"), confirmed, &progress)
+	}
+
+	env, err := create(false)
+	if apitypes.IsConfirmationRequiredError(err) {
+		err = confirmProtectedWrite(ctx, err)
+		if err != nil {
+			return nil, err
+		}
+		env, err = create(true)
+	}
+	if apitypes.IsConflictError(err) {
+		return nil, errs.NewExitError(err.Error() +
+			"\nCheck its new value, and run the command again, or use --force to overwrite it.")
+	}
+
+	return env, err
+}
+
+// credentialMeta returns the metadata given by the credentialMetaFlags. If
+// none are given, the daemon keeps the metadata of the previous version.
+func credentialMeta(ctx *cli.Context) (*primitive.CredentialMeta, error) {
+	meta := &primitive.CredentialMeta{
+		Description: ctx.String("description"),
+		Honeytoken:  ctx.Bool("honeytoken"),
+	}
+
+	for _, tag := range ctx.StringSlice("tag") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !meta.HasTag(tag) {
+			meta.Tags = append(meta.Tags, tag)
+		}
+	}
+
+	if expires := ctx.String("expires"); expires != "" {
+		t, err := parseRelativeTime(expires, time.Now(), 1)
+		if err != nil {
+			return nil, errs.NewUsageExitError("Invalid --expires: "+expires, ctx)
+		}
+
+		t = t.UTC()
+		meta.ExpiresAt = &t
+	}
+
+	provider, rawTTL := ctx.String("broker"), ctx.String("ttl")
+	if provider == "" && rawTTL != "" {
+		return nil, errs.NewUsageExitError("--ttl can only be given with --broker", ctx)
+	}
+	if provider != "" {
+		ttl := defaultBrokerTTL
+		if rawTTL != "" {
+			var err error
+			ttl, err = time.ParseDuration(rawTTL)
+			if err != nil || ttl < time.Minute {
+				return nil, errs.NewUsageExitError("Invalid --ttl: "+rawTTL+
+					". It must be at least 1m.", ctx)
+			}
+		}
+
+		meta.Broker = &primitive.BrokerSpec{
+			Provider: strings.ToLower(provider),
+			TTL:      int64(ttl / time.Second),
+		}
+	}
+
+	return meta, nil
+}
+
+// confirmProtectedWrite asks the user to confirm a write that the daemon
+// refused with err, because it changes secrets in a protected environment.
+// Protected writes are always confirmed, even when confirmations are
+// otherwise skipped.
+func confirmProtectedWrite(ctx *cli.Context, err error) error {
+	preamble := strings.Join(err.(*apitypes.Error).Err, " ")
+	return ConfirmDialogue(ctx, nil, &preamble, "", false)
+}
+
+// archivedProjectError returns the error for setting secrets in the named
+// archived project.
+func archivedProjectError(name string) error {
+	return errs.NewExitError("Project " + name + " is archived, so secrets cannot be set in it.\n" +
+		"Use 'torus projects restore " + name + "' to restore it.")
+}
+
+// credentialOwners returns the org and project secrets at pe belong to,
+// refusing archived projects.
+func credentialOwners(c context.Context, client *api.Client, pe *pathexp.PathExp) (*envelope.Org, *envelope.Project, error) {
 	org, err := client.Orgs.GetByName(c, pe.Org.String())
 	if org == nil || err != nil {
-		return nil, errs.NewExitError("Org not found")
+		return nil, nil, errs.NewExitError("Org not found")
 	}
 
 	pName := pe.Project.String()
 	projects, err := listProjects(&c, client, org.ID, &pName)
 	if len(projects) != 1 || err != nil {
-		return nil, errs.NewExitError("Project not found")
+		return nil, nil, errs.NewExitError("Project not found")
 	}
-	project := projects[0]
-	value := valueMaker()
+	if projects[0].Body.Archived() {
+		return nil, nil, archivedProjectError(pName)
+	}
+
+	return org, &projects[0], nil
+}
+
+// expectVersions marks each of creds with the latest version of it found in
+// locations, or with none if it is not there, so the daemon refuses to write
+// it if it has been changed since.
+func expectVersions(creds []apitypes.Credential, locations []apitypes.CredentialLocation) {
+	for _, cred := range creds {
+		base := &cred.(*apitypes.CredentialV3).BaseCredential
+		base.Previous = nil
+		base.CredentialVersion = 1
+		for _, l := range locations {
+			if l.Name == base.Name && l.PathExp.Equal(base.PathExp) {
+				base.Previous = l.ID
+				base.CredentialVersion = l.CredentialVersion + 1
+			}
+		}
+	}
+}
+
+// newCredential returns the unencrypted body of a secret to be set.
+func newCredential(orgID, projectID *identity.ID, pe *pathexp.PathExp, name string,
+	value *apitypes.CredentialValue) apitypes.Credential {
 
 	state := "set"
 	if value.IsUnset() {
@@ -155,18 +427,16 @@ func setCredential(ctx *cli.Context, nameOrPath string, valueMaker func() *apity
 		value = nil
 	}
 
-	var cred apitypes.Credential
-	cBodyV2 := apitypes.CredentialV2{
-		BaseCredential: apitypes.BaseCredential{
-			OrgID:     org.ID,
-			ProjectID: project.ID,
-			Name:      strings.ToLower(name),
-			PathExp:   pe,
-			Value:     value,
+	return &apitypes.CredentialV3{
+		CredentialV2: apitypes.CredentialV2{
+			BaseCredential: apitypes.BaseCredential{
+				OrgID:     orgID,
+				ProjectID: projectID,
+				Name:      strings.ToLower(name),
+				PathExp:   pe,
+				Value:     value,
+			},
+			State: state,
 		},
-		State: state,
 	}
-	cred = &cBodyV2
-
-	return client.Credentials.Create(c, &cred, &progress)
 }
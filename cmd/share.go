@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+// maxShareTTL is the longest a one-time link may stay redeemable.
+const maxShareTTL = 7 * 24 * time.Hour
+
+func init() {
+	share := cli.Command{
+		Name:      "share",
+		Usage:     "Share a secret with a teammate through a one-time link",
+		ArgsUsage: "<name|path>",
+		Category:  "SECRETS",
+		Flags: append(credentialPathFlags,
+			newPlaceholder("ttl", "DURATION",
+				"How long the link can be redeemed for, such as 1h or 2d (at most 7d)",
+				"1h", "", false),
+			cli.IntFlag{
+				Name:  "max-reads",
+				Usage: "How many times the link can be redeemed",
+				Value: 1,
+			},
+		),
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setSliceDefaults, shareCmd,
+		),
+	}
+
+	redeem := cli.Command{
+		Name:      "redeem",
+		Usage:     "Print a secret shared with you by `torus share`",
+		ArgsUsage: "<token>",
+		Category:  "SECRETS",
+		Action:    chain(ensureDaemon, ensureSession, redeemCmd),
+	}
+
+	Cmds = append(Cmds, share, redeem)
+}
+
+func shareCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		msg := "Name or path is required."
+		if len(args) > 1 {
+			msg = "Too many arguments provided."
+		}
+		return errs.NewUsageExitError(msg, ctx)
+	}
+
+	now := time.Now()
+	expires, err := parseRelativeTime(ctx.String("ttl"), now, 1)
+	if err != nil {
+		return errs.NewUsageExitError(err.Error(), ctx)
+	}
+	if !expires.After(now) || expires.Sub(now) > maxShareTTL {
+		return errs.NewUsageExitError("--ttl must be between 1s and 7d", ctx)
+	}
+
+	maxReads := ctx.Int("max-reads")
+	if maxReads < 1 {
+		return errs.NewUsageExitError("--max-reads must be at least 1", ctx)
+	}
+
+	pe, name, creds, err := credentialHistory(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if len(creds) == 0 || (*creds[0].Body).GetValue() == nil {
+		return errs.NewExitError(fmt.Sprintf("Secret %s/%s not found.", pe, name))
+	}
+	value := (*creds[0].Body).GetValue()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	parsed, err := pathexp.Parse(pe)
+	if err != nil {
+		return errs.NewErrorExitError("Invalid path.", err)
+	}
+
+	org, err := getOrg(c, client, parsed.Org.String())
+	if err != nil {
+		return err
+	}
+
+	token, share, err := client.Shares.Create(c, org.ID, name, value, expires, maxReads)
+	if err != nil {
+		return errs.NewErrorExitError("Could not share secret.", err)
+	}
+
+	times := "once"
+	if share.MaxReads > 1 {
+		times = fmt.Sprintf("%d times", share.MaxReads)
+	}
+
+	fmt.Fprintf(os.Stderr, "Send this token to your teammate. They can redeem it %s, "+
+		"until %s, with 'torus redeem <token>':\n\n",
+		times, share.Expires.Local().Format("2006-01-02 15:04 MST"))
+	fmt.Println(token)
+	fmt.Fprintln(os.Stderr, "\nAnyone with the token can read the secret, so send it privately.")
+
+	return nil
+}
+
+func redeemCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		return errs.NewUsageExitError("A token is required.", ctx)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+
+	secret, share, err := client.Shares.Redeem(context.Background(), args[0])
+	if err == api.ErrInvalidShareToken {
+		return errs.NewUsageExitError("Invalid token.", ctx)
+	}
+	if err != nil {
+		return errs.NewErrorExitError("Could not redeem shared secret.", err)
+	}
+
+	remaining := share.MaxReads - share.Reads
+	fmt.Fprintf(os.Stderr, "%s (can be redeemed %d more times):\n", secret.Name, remaining)
+	fmt.Println(secret.Value.String())
+
+	return nil
+}
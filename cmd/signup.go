@@ -144,6 +144,17 @@ func signup(ctx *cli.Context, subCommand bool) error {
 	fmt.Println("")
 	fmt.Println("Your account has been created!")
 
+	// Recovery codes are the only way back in after forgetting a password,
+	// so give them out up front where the registry supports them.
+	if ok, _ := client.Supports(c, api.FeatureRecovery); ok {
+		codes, err := client.Users.GenerateRecoveryCodes(c)
+		if err != nil {
+			fmt.Println("Could not generate recovery codes. Run `torus account recovery-codes` to try again.")
+		} else {
+			printRecoveryCodes(codes)
+		}
+	}
+
 	if !subCommand {
 		fmt.Println("")
 		fmt.Println("We have emailed you a verification code.")
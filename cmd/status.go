@@ -6,9 +6,11 @@ import (
 	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/manifoldco/torus-cli/api"
 	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/dirprefs"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/prefs"
 
@@ -40,11 +42,21 @@ func init() {
 				StringFlag: cli.StringFlag{Name: "instance", EnvVar: "TORUS_INSTANCE", Value: "1", Hidden: true},
 				Required:   true,
 			},
+			cli.BoolFlag{
+				Name:  "diagnose",
+				Usage: "Explain where each part of the context came from, and check the daemon, session, keypairs, and registry",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.Bool("diagnose") {
+				return statusDiagnoseCmd(ctx)
+			}
+
+			return chain(
+				ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+				setUserEnv, statusCmd,
+			)(ctx)
 		},
-		Action: chain(
-			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
-			setUserEnv, statusCmd,
-		),
 	}
 
 	Cmds = append(Cmds, status)
@@ -105,5 +117,107 @@ func statusCmd(ctx *cli.Context) error {
 	credPath := strings.Join(parts, "/")
 	fmt.Printf("\nCredential path: %s\n", credPath)
 
+	// Checking keypairs is only a courtesy; the rest of the status is still
+	// worth showing if it fails.
+	err = statusKeypairs(c, client, org)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\nWarning: Could not check whether your keypairs expire soon: %s\n", err)
+	}
+
+	return statusCatalog(ctx, service)
+}
+
+// statusKeypairs warns about any of the user's keypairs for the given org
+// that have expired, or expire soon.
+func statusKeypairs(c context.Context, client *api.Client, orgName string) error {
+	org, err := getOrg(c, client, orgName)
+	if err != nil {
+		return err
+	}
+
+	keypairs, err := client.Keypairs.List(c, org.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	soon, _ := parseRelativeTime(keyExpiryWarning, now, 1)
+
+	warned := false
+	for _, kp := range keypairs {
+		pk := kp.PublicKey.Body
+		if kp.Revoked() || !pk.Expired(soon) {
+			continue
+		}
+
+		if !warned {
+			fmt.Println("")
+			warned = true
+		}
+
+		verb := "expires"
+		if pk.Expired(now) {
+			verb = "expired"
+		}
+		fmt.Printf("Warning: Your %s key for the %s org %s on %s.\n",
+			pk.KeyType, org.Body.Name, verb, pk.Expires.Local().Format("2006-01-02"))
+	}
+
+	if warned {
+		fmt.Printf("Run 'torus keypairs renew --org %s' to replace your keypairs.\n",
+			org.Body.Name)
+	}
+
+	return nil
+}
+
+// statusCatalog reports on the secrets required by the project catalog for
+// the given service, if any are listed.
+func statusCatalog(ctx *cli.Context, service string) error {
+	d, err := dirprefs.Load(true)
+	if err != nil {
+		return err
+	}
+
+	dc, err := d.Resolve(ctx.String("environment"), service)
+	if err != nil {
+		return err
+	}
+
+	required := dc.Catalog.Required(service)
+	if len(required) == 0 {
+		return nil
+	}
+
+	secrets, _, err := getSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	problems, err := checkCatalog(service, secrets)
+	if err != nil {
+		return err
+	}
+
+	reasons := make(map[string]string, len(problems))
+	for _, p := range problems {
+		reasons[p.Entry.Name] = p.Reason
+	}
+
+	fmt.Println("\nRequired secrets:")
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	for _, e := range required {
+		state := "ok"
+		if r, ok := reasons[e.Name]; ok {
+			state = r
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", e.Name, state, e.Description)
+	}
+	w.Flush()
+
+	if len(problems) > 0 {
+		fmt.Printf("\n%d required secret(s) missing or invalid.\n", len(problems))
+	}
+
 	return nil
 }
@@ -0,0 +1,382 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/dirprefs"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/prefs"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+// contextFlags are the flags whose values, and where they came from, are
+// shown by status --diagnose.
+var contextFlags = []struct {
+	name   string
+	label  string
+	envVar string
+}{
+	{"org", "Org", "TORUS_ORG"},
+	{"project", "Project", "TORUS_PROJECT"},
+	{"environment", "Environment", "TORUS_ENVIRONMENT"},
+	{"service", "Service", "TORUS_SERVICE"},
+	{"instance", "Instance", "TORUS_INSTANCE"},
+}
+
+// contextSources records where each context flag got its value, by
+// comparing the flag values before and after each middleware is run.
+type contextSources struct {
+	ctx     *cli.Context
+	values  map[string]string
+	sources map[string]string
+}
+
+func newContextSources(ctx *cli.Context) *contextSources {
+	s := &contextSources{
+		ctx:     ctx,
+		values:  make(map[string]string),
+		sources: make(map[string]string),
+	}
+
+	for _, f := range contextFlags {
+		value := ctx.String(f.name)
+		s.values[f.name] = value
+		if !ctx.IsSet(f.name) {
+			continue
+		}
+
+		if env := os.Getenv(f.envVar); env != "" && env == value {
+			s.sources[f.name] = f.envVar + " environment variable"
+		} else {
+			s.sources[f.name] = "--" + f.name + " flag"
+		}
+	}
+
+	return s
+}
+
+// run calls fn, crediting source with any context flags it changes.
+func (s *contextSources) run(source string, fn func(*cli.Context) error) error {
+	err := fn(s.ctx)
+
+	for _, f := range contextFlags {
+		value := s.ctx.String(f.name)
+		if value != s.values[f.name] {
+			s.values[f.name] = value
+			s.sources[f.name] = source
+		}
+	}
+
+	return err
+}
+
+// source returns where the named flag got its value.
+func (s *contextSources) source(name string) string {
+	if source, ok := s.sources[name]; ok {
+		return source
+	}
+	if s.values[name] != "" {
+		return "default"
+	}
+	return "not set"
+}
+
+// diagnosis collects the problems found by status --diagnose.
+type diagnosis struct {
+	problems []string
+}
+
+func (d *diagnosis) problem(format string, a ...interface{}) {
+	d.problems = append(d.problems, fmt.Sprintf(format, a...))
+}
+
+// statusDiagnoseCmd explains how the context was resolved, and checks each
+// thing needed to read secrets in it. Unlike status, it doesn't start the
+// daemon, or require a session, so it can report on what is missing.
+func statusDiagnoseCmd(ctx *cli.Context) error {
+	preferences, err := prefs.NewPreferences()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	d := &diagnosis{}
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	sources := newContextSources(ctx)
+	if !preferences.Core.Context {
+		d.problem("Context is disabled, so .torus.json and preferences are ignored. "+
+			"Use '%s prefs' to enable it.", ctx.App.Name)
+	} else {
+		diagnoseDirPrefs(d, sources)
+		diagnosePrefDefaults(d, sources, preferences)
+	}
+
+	fmt.Println("Daemon")
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	running := diagnoseDaemon(c, w, d, cfg, client)
+	w.Flush()
+
+	var session *api.Session
+	if running {
+		fmt.Println("\nSession")
+		w = tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+		session = diagnoseSession(c, w, d, client)
+		diagnoseRegistry(c, w, d, client, preferences)
+		w.Flush()
+	}
+
+	if session != nil && session.Type() == apitypes.UserSession {
+		err = sources.run("default for your user", setUserEnv)
+		if err != nil {
+			d.problem("Could not set the default environment: %s", err)
+		}
+	}
+
+	fmt.Println("\nContext")
+	w = tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	for _, f := range contextFlags {
+		fmt.Fprintf(w, "  %s:\t%s\t(%s)\n", f.label, ctx.String(f.name), sources.source(f.name))
+	}
+	w.Flush()
+
+	if session != nil {
+		diagnoseSecrets(c, d, ctx, client, session)
+	}
+
+	fmt.Println("")
+	if len(d.problems) == 0 {
+		fmt.Println("No problems found.")
+		return nil
+	}
+
+	fmt.Printf("%d problem(s) found:\n", len(d.problems))
+	for _, p := range d.problems {
+		fmt.Printf("  - %s\n", p)
+	}
+
+	return nil
+}
+
+func diagnoseDirPrefs(d *diagnosis, sources *contextSources) {
+	source := ".torus.json"
+	dp, err := dirprefs.Load(true)
+	if err == nil && dp.Path != "" {
+		source = dp.Path
+	}
+
+	err = sources.run(source, loadDirPrefs)
+	if err != nil {
+		d.problem("Could not read %s: %s", source, err)
+	}
+}
+
+func diagnosePrefDefaults(d *diagnosis, sources *contextSources, preferences *prefs.Preferences) {
+	source := "preferences"
+	if name := os.Getenv("TORUS_PROFILE"); name != "" {
+		source = "profile " + name + " from TORUS_PROFILE"
+	} else if preferences.Core.Profile != "" {
+		source = "profile " + preferences.Core.Profile
+	}
+
+	err := sources.run(source, loadPrefDefaults)
+	if err != nil {
+		d.problem("Could not read preferences: %s", err)
+	}
+}
+
+// diagnoseDaemon reports whether the daemon is running, and if it is the same
+// version as the CLI.
+func diagnoseDaemon(c context.Context, w *tabwriter.Writer, d *diagnosis,
+	cfg *config.Config, client *api.Client) bool {
+
+	proc, err := findDaemon(cfg)
+	if err != nil {
+		fmt.Fprintf(w, "  Running:\tunknown (%s)\n", err)
+		d.problem("Could not check if the daemon is running: %s", err)
+		return false
+	}
+	if proc == nil {
+		fmt.Fprintf(w, "  Running:\tno\n")
+		d.problem("The daemon is not running. Any other torus command will start it, " +
+			"or run 'torus daemon start'.")
+		return false
+	}
+	fmt.Fprintf(w, "  Running:\tyes (pid %d)\n", proc.Pid)
+
+	v, err := client.Version.Get(c)
+	if err != nil {
+		fmt.Fprintf(w, "  Version:\tunknown (%s)\n", err)
+		d.problem("The daemon is running, but not responding. " +
+			"Run 'torus daemon restart' to restart it.")
+		return false
+	}
+
+	fmt.Fprintf(w, "  Version:\t%s\n", v.Version)
+	if v.Version != cfg.Version {
+		d.problem("The daemon is version %s, but the CLI is version %s. "+
+			"Run 'torus daemon restart' to restart it.", v.Version, cfg.Version)
+	}
+
+	return true
+}
+
+// diagnoseSession returns the daemon's session, or nil if it is logged out.
+func diagnoseSession(c context.Context, w *tabwriter.Writer, d *diagnosis,
+	client *api.Client) *api.Session {
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		if apitypes.IsUnauthorizedError(err) {
+			fmt.Fprintf(w, "  Logged in:\tno\n")
+			d.problem("You are not logged in. Use 'torus login' to log in.")
+		} else {
+			fmt.Fprintf(w, "  Logged in:\tunknown (%s)\n", err)
+			d.problem("Could not fetch your session: %s", err)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(w, "  Logged in:\tyes, as %s %s\n", session.Type(), session.Username())
+	return session
+}
+
+// diagnoseRegistry reports whether the daemon can reach the registry.
+func diagnoseRegistry(c context.Context, w *tabwriter.Writer, d *diagnosis,
+	client *api.Client, preferences *prefs.Preferences) {
+
+	fmt.Fprintf(w, "  Registry:\t%s\n", preferences.Core.RegistryURI)
+
+	v, err := client.Version.GetRegistry(c)
+	if err != nil {
+		fmt.Fprintf(w, "  Reachable:\tno (%s)\n", err)
+		d.problem("The registry at %s could not be reached. Secrets can only be read "+
+			"from the daemon's cache.", preferences.Core.RegistryURI)
+		return
+	}
+
+	fmt.Fprintf(w, "  Reachable:\tyes (version %s)\n", v.Version)
+}
+
+// diagnoseSecrets checks the org exists, the user's keypairs for it are
+// usable, and how many secrets are set at the resolved path.
+func diagnoseSecrets(c context.Context, d *diagnosis, ctx *cli.Context,
+	client *api.Client, session *api.Session) {
+
+	var missing []string
+	for _, f := range contextFlags {
+		if ctx.String(f.name) == "" {
+			missing = append(missing, "--"+f.name)
+		}
+	}
+	if len(missing) > 0 {
+		d.problem("No value for %s. Use '%s link' to link this directory to a project, "+
+			"or set them as flags.", strings.Join(missing, ", "), ctx.App.Name)
+		return
+	}
+
+	orgName := ctx.String("org")
+	org, err := client.Orgs.GetByName(c, orgName)
+	if err != nil {
+		d.problem("Could not look up the %s org: %s", orgName, err)
+		return
+	}
+	if org == nil {
+		d.problem("The %s org does not exist, or you are not a member of it.", orgName)
+		return
+	}
+
+	fmt.Println("\nKeypairs")
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	diagnoseKeypairs(c, w, d, client, org.ID, orgName)
+	w.Flush()
+
+	ident, err := deriveIdentity(ctx, session)
+	if err != nil {
+		d.problem("Could not determine your identity: %s", err)
+		return
+	}
+
+	parts := []string{
+		"", orgName, ctx.String("project"), ctx.String("environment"),
+		ctx.String("service"), ident, ctx.String("instance"),
+	}
+	path := strings.Join(parts, "/")
+
+	fmt.Println("\nSecrets")
+	w = tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Path:\t%s\n", path)
+
+	secrets, cachedAt, err := client.Credentials.GetCached(c, path, false)
+	switch {
+	case err != nil:
+		fmt.Fprintf(w, "  Secrets:\tunknown\n")
+		d.problem("Could not read the secrets at %s: %s", path, err)
+	case len(secrets) == 0:
+		fmt.Fprintf(w, "  Secrets:\tnone\n")
+		d.problem("No secrets are set for %s. Check the context above is the one "+
+			"you expect.", path)
+	default:
+		fmt.Fprintf(w, "  Secrets:\t%d\n", len(secrets))
+	}
+	if cachedAt != nil {
+		fmt.Fprintf(w, "  Cached:\t%s ago\n", formatAge(time.Since(*cachedAt)))
+	}
+	w.Flush()
+}
+
+// diagnoseKeypairs reports on the user's active signing and encryption
+// keypairs for the org.
+func diagnoseKeypairs(c context.Context, w *tabwriter.Writer, d *diagnosis,
+	client *api.Client, orgID *identity.ID, orgName string) {
+
+	keypairs, err := client.Keypairs.List(c, orgID)
+	if err != nil {
+		d.problem("Could not list your keypairs for the %s org: %s", orgName, err)
+		return
+	}
+
+	now := time.Now()
+	soon, _ := parseRelativeTime(keyExpiryWarning, now, 1)
+
+	for _, kt := range []primitive.KeyType{primitive.SigningKeyType, primitive.EncryptionKeyType} {
+		var pk *primitive.PublicKey
+		for _, kp := range keypairs {
+			if !kp.Revoked() && kp.PublicKey.Body.KeyType == kt {
+				pk = kp.PublicKey.Body
+			}
+		}
+
+		switch {
+		case pk == nil:
+			fmt.Fprintf(w, "  %s:\tmissing\n", kt)
+			d.problem("You have no %s keypair for the %s org. Run 'torus keypairs "+
+				"generate --org %s' to create one.", kt, orgName, orgName)
+		case pk.Expired(now):
+			fmt.Fprintf(w, "  %s:\texpired on %s\n", kt, pk.Expires.Local().Format("2006-01-02"))
+			d.problem("Your %s keypair for the %s org has expired. Run 'torus keypairs "+
+				"renew --org %s' to replace it.", kt, orgName, orgName)
+		case pk.Expires.IsZero():
+			fmt.Fprintf(w, "  %s:\tvalid\n", kt)
+		case pk.Expired(soon):
+			fmt.Fprintf(w, "  %s:\texpires on %s\n", kt, pk.Expires.Local().Format("2006-01-02"))
+		default:
+			fmt.Fprintf(w, "  %s:\tvalid until %s\n", kt, pk.Expires.Local().Format("2006-01-02"))
+		}
+	}
+}
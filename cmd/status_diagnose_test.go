@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestContextSources(t *testing.T) {
+	flagset := flag.NewFlagSet("", flag.ContinueOnError)
+	flagset.String("org", "", "")
+	flagset.String("project", "", "")
+	flagset.String("environment", "", "")
+	flagset.String("service", "default", "")
+	flagset.String("instance", "1", "")
+	flagset.Parse([]string{"--org", "acme"})
+
+	ctx := cli.NewContext(nil, flagset, nil)
+	ctx.Command = cli.Command{}
+
+	sources := newContextSources(ctx)
+	sources.run(".torus.json", func(ctx *cli.Context) error {
+		ctx.Set("project", "site")
+		ctx.Set("service", "web")
+		return nil
+	})
+	sources.run("preferences", func(ctx *cli.Context) error {
+		// Setting a flag to the value it already has doesn't credit the source.
+		ctx.Set("project", "site")
+		return nil
+	})
+
+	want := map[string]string{
+		"org":         "--org flag",
+		"project":     ".torus.json",
+		"environment": "not set",
+		"service":     ".torus.json",
+		"instance":    "default",
+	}
+	for name, source := range want {
+		if got := sources.source(name); got != source {
+			t.Errorf("%s: got source %q, want %q", name, got, source)
+		}
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"github.com/manifoldco/torus-cli/hints"
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/cmd/output"
 )
 
 func init() {
@@ -158,6 +160,23 @@ func teamsListCmd(ctx *cli.Context) error {
 		)
 	}
 
+	if output.IsJSON(ctx) {
+		records := []output.Team{}
+		for _, t := range teams {
+			if isMachineTeam(t.Body) {
+				continue
+			}
+
+			records = append(records, output.Team{
+				ID:     t.ID,
+				Name:   t.Body.Name,
+				Type:   string(t.Body.TeamType),
+				Member: memberOf[*t.ID],
+			})
+		}
+		return output.Write(os.Stdout, records)
+	}
+
 	w := tabwriter.NewWriter(os.Stdout, 2, 0, 1, ' ', 0)
 	for _, t := range teams {
 		if isMachineTeam(t.Body) {
@@ -256,6 +275,10 @@ func teamMembersListCmd(ctx *cli.Context) error {
 	}
 
 	if len(memberships) == 0 {
+		if output.IsJSON(ctx) {
+			return output.Write(os.Stdout, []output.TeamMember{})
+		}
+
 		fmt.Printf("%s has no members\n", team.Body.Name)
 		return nil
 	}
@@ -278,6 +301,19 @@ func teamMembersListCmd(ctx *cli.Context) error {
 		return errs.NewExitError("User not found.")
 	}
 
+	if output.IsJSON(ctx) {
+		records := make([]output.TeamMember, 0, len(*profiles))
+		for _, profile := range *profiles {
+			records = append(records, output.TeamMember{
+				ID:       profile.ID,
+				Username: profile.Body.Username,
+				Name:     profile.Body.Name,
+				You:      session.Username() == profile.Body.Username,
+			})
+		}
+		return output.Write(os.Stdout, records)
+	}
+
 	count := strconv.Itoa(len(memberships))
 	title := "members of the " + team.Body.Name + " team (" + count + ")"
 
@@ -451,6 +487,13 @@ func teamsRemoveCmd(ctx *cli.Context) error {
 		return errs.NewExitError("Memberships not found.")
 	}
 
+	if team.Body.Name == primitive.OwnerTeamName {
+		err = ensureOwnerSuccession(c, client, org, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = client.Memberships.Delete(c, memberships[0].ID)
 	if err != nil {
 		msg := teamRemoveFailed
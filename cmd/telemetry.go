@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/prefs"
+	"github.com/manifoldco/torus-cli/promptui"
+	"github.com/manifoldco/torus-cli/telemetry"
+)
+
+// pluginCategory is the help category of plugin commands. Their names are
+// chosen by whoever installed them, so they are recorded as "plugin".
+const pluginCategory = "PLUGINS"
+
+func init() {
+	telemetryCmd := cli.Command{
+		Name:     "telemetry",
+		Usage:    "View the usage data recorded on this machine",
+		Category: "SYSTEM",
+		Subcommands: []cli.Command{
+			{
+				Name:   "show",
+				Usage:  "Show the recorded usage data, exactly as it would be sent",
+				Action: showTelemetry,
+			},
+		},
+	}
+	Cmds = append(Cmds, telemetryCmd)
+}
+
+// Instrument records an event for every run of the given commands, and their
+// subcommands, if the user has turned telemetry on.
+func Instrument(cmds []cli.Command) {
+	instrument(cmds, "")
+}
+
+func instrument(cmds []cli.Command, parent string) {
+	for i := range cmds {
+		c := &cmds[i]
+		name := strings.TrimSpace(parent + " " + c.Name)
+		if c.Category == pluginCategory {
+			name = "plugin"
+		}
+
+		instrument(c.Subcommands, name)
+
+		action, ok := c.Action.(func(*cli.Context) error)
+		if !ok {
+			continue
+		}
+
+		c.Action = func(ctx *cli.Context) error {
+			start := time.Now()
+			err := action(ctx)
+			recordTelemetry(name, start, err)
+			return err
+		}
+	}
+}
+
+// recordTelemetry queues an event for the command if telemetry is on. Failing
+// to record it never fails the command.
+func recordTelemetry(command string, start time.Time, err error) {
+	preferences, pErr := prefs.NewPreferences()
+	if pErr != nil || !preferences.Telemetry.Enabled {
+		return
+	}
+
+	cfg, cErr := config.LoadConfig()
+	if cErr != nil {
+		return
+	}
+
+	telemetry.NewQueue(cfg.TorusRoot).Add(telemetry.Event{
+		Command:  command,
+		Duration: int64(time.Since(start) / time.Millisecond),
+		Error:    errorCategory(err),
+		Time:     start,
+	})
+}
+
+// errorCategory returns the telemetry category of err, without any of its
+// message.
+func errorCategory(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errs.IsUsageError(err):
+		return telemetry.ErrUsage
+	case err == promptui.ErrInterrupt || err == promptui.ErrEOF || err == promptui.ErrAbort:
+		return telemetry.ErrInterrupted
+	case err == api.ErrRegistryUnavailable:
+		return telemetry.ErrUnavailable
+	}
+
+	if apiErr, ok := err.(*apitypes.Error); ok {
+		return apiErr.Type
+	}
+	if _, ok := err.(cli.ExitCoder); ok {
+		return telemetry.ErrExit
+	}
+
+	return telemetry.Other
+}
+
+func showTelemetry(ctx *cli.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	preferences, err := prefs.NewPreferences()
+	if err != nil {
+		return errs.NewErrorExitError("Failed to load prefs.", err)
+	}
+
+	events, err := telemetry.NewQueue(cfg.TorusRoot).Events()
+	if err != nil {
+		return errs.NewErrorExitError("Could not read queued usage data.", err)
+	}
+
+	if preferences.Telemetry.Enabled {
+		fmt.Fprintln(os.Stderr, "Telemetry is on. Turn it off with 'torus prefs telemetry off'.")
+	} else {
+		fmt.Fprintln(os.Stderr, "Telemetry is off. Turn it on with 'torus prefs telemetry on'.")
+	}
+
+	out, err := json.MarshalIndent(telemetry.NewPayload(config.Version, events), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func init() {
+	tmpl := cli.Command{
+		Name:     "template",
+		Usage:    "Render a template file, filling in secrets",
+		Category: "SECRETS",
+		Flags: []cli.Flag{
+			newPlaceholder("input, i", "FILE", "Template to render (default: stdin)", "", "", false),
+			newPlaceholder("output, o", "FILE", "File to write (default: stdout)", "", "", false),
+			newPlaceholder("file-dir", "DIR",
+				"Directory secretFile writes secrets to (default: a new temporary directory)",
+				"", "", false),
+
+			// -i and -o are taken by the files, so the org and instance flags
+			// have no short names here.
+			newPlaceholder("org", "ORG", "Use this organization.", "", "TORUS_ORG", false),
+			projectFlag("Use this project.", false),
+			envFlag("Use this environment.", false),
+			serviceFlag("Use this service.", "default", false),
+			userFlag("Use this user.", false),
+			machineFlag("Use this machine.", false),
+			newPlaceholder("instance", "INSTANCE", "Use this instance.", "1", "TORUS_INSTANCE", false),
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			setUserEnv, checkRequiredFlags, templateCmd,
+		),
+	}
+
+	Cmds = append(Cmds, tmpl)
+}
+
+func templateCmd(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	var src []byte
+	var err error
+	if in := ctx.String("input"); in != "" && in != "-" {
+		src, err = ioutil.ReadFile(in)
+	} else {
+		src, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return errs.NewErrorExitError("Could not read template.", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	session, err := client.Session.Who(c)
+	if err != nil {
+		return err
+	}
+
+	identity, err := deriveIdentity(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	// Bare secret names are read from the current context, when it is
+	// complete enough to make a path.
+	contextPath := ""
+	if ctx.String("org") != "" && ctx.String("project") != "" && ctx.String("environment") != "" {
+		contextPath = strings.Join([]string{
+			"", ctx.String("org"), ctx.String("project"), ctx.String("environment"),
+			ctx.String("service"), identity, ctx.String("instance"),
+		}, "/")
+	}
+
+	secrets := newTemplateSecrets(contextPath, func(path string) ([]apitypes.CredentialEnvelope, error) {
+		return client.Credentials.Get(c, path)
+	})
+	secrets.fileDir = ctx.String("file-dir")
+
+	out, err := renderTemplate(ctx.String("input"), src, secrets)
+	if err != nil {
+		return errs.NewErrorExitError("Could not render template.", err)
+	}
+
+	if o := ctx.String("output"); o != "" && o != "-" {
+		err = ioutil.WriteFile(o, out, 0600)
+	} else {
+		_, err = os.Stdout.Write(out)
+	}
+	if err != nil {
+		return errs.NewErrorExitError("Could not write output.", err)
+	}
+
+	return nil
+}
+
+// renderTemplate renders src as a Go template, with a secret function for
+// looking up secrets, and a secretFile function for writing them to files. Nothing is returned unless the whole template renders.
+func renderTemplate(name string, src []byte, secrets *templateSecrets) ([]byte, error) {
+	if name == "" {
+		name = "stdin"
+	}
+
+	t, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"secret":     secrets.secret,
+			"secretFile": secrets.secretFile,
+		}).
+		Parse(string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bytes.Buffer{}
+	err = t.Execute(b, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// templateSecrets looks up the secrets referenced by a template, fetching the
+// secrets of each path once.
+type templateSecrets struct {
+	contextPath string
+	get         func(path string) ([]apitypes.CredentialEnvelope, error)
+	paths       map[string]credentialSet
+
+	// fileDir is where secretFile writes secrets. A temporary directory is
+	// made for them if it is empty.
+	fileDir string
+}
+
+func newTemplateSecrets(contextPath string,
+	get func(string) ([]apitypes.CredentialEnvelope, error)) *templateSecrets {
+
+	return &templateSecrets{
+		contextPath: contextPath,
+		get:         get,
+		paths:       make(map[string]credentialSet),
+	}
+}
+
+// secret returns the value of the secret referenced by ref. ref is either the
+// name of a secret in the current context, or a full path followed by the
+// name of a secret, such as /org/project/env/service/user/1/name.
+func (t *templateSecrets) secret(ref string) (string, error) {
+	value, err := t.lookup(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return value.String(), nil
+}
+
+// secretFile writes the exact value of the secret referenced by ref to a
+// file readable only by the current user, and returns the file's path. It
+// is for secrets such as certificates, that programs read from a file.
+func (t *templateSecrets) secretFile(ref string) (string, error) {
+	value, err := t.lookup(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if t.fileDir == "" {
+		t.fileDir, err = ioutil.TempDir("", "torus-template-")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	path := filepath.Join(t.fileDir, strings.ToLower(name))
+	err = ioutil.WriteFile(path, []byte(value.String()), 0600)
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// lookup returns the value of the secret referenced by ref.
+func (t *templateSecrets) lookup(ref string) (*apitypes.CredentialValue, error) {
+	path, name := t.contextPath, ref
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		if !strings.HasPrefix(ref, "/") {
+			return nil, fmt.Errorf("secret %q must be a name, or a full path and name", ref)
+		}
+
+		path, name = ref[:i], ref[i+1:]
+		if _, err := pathexp.Parse(path); err != nil {
+			return nil, fmt.Errorf("secret %q has an invalid path: %s", ref, err)
+		}
+	} else if path == "" {
+		return nil, fmt.Errorf("secret %q has no path, and --org, --project and "+
+			"--environment are not all set", ref)
+	}
+
+	set, ok := t.paths[path]
+	if !ok {
+		creds, err := t.get(path)
+		if err != nil {
+			return nil, err
+		}
+
+		set = credentialSet{}
+		for _, c := range creds {
+			set.Add(c)
+		}
+		t.paths[path] = set
+	}
+
+	cred, ok := set[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("secret %s is not set at %s", name, path)
+	}
+
+	return (*cred.Body).GetValue(), nil
+}
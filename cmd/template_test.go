@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	cred := func(path, name, value string) apitypes.CredentialEnvelope {
+		pe, err := pathexp.Parse(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var body apitypes.Credential = &apitypes.CredentialV2{
+			BaseCredential: apitypes.BaseCredential{
+				Name:    name,
+				PathExp: pe,
+				Value:   apitypes.NewStringCredentialValue(value),
+			},
+			State: "set",
+		}
+		return apitypes.CredentialEnvelope{Version: 2, Body: &body}
+	}
+
+	dev := "/org/project/dev/api/jeff/1"
+	prod := "/org/project/production/api/jeff/1"
+	fetched := map[string]int{}
+	get := func(path string) ([]apitypes.CredentialEnvelope, error) {
+		fetched[path]++
+		switch path {
+		case dev:
+			return []apitypes.CredentialEnvelope{
+				cred(dev, "port", "8080"), cred(dev, "host", "localhost"),
+			}, nil
+		case prod:
+			return []apitypes.CredentialEnvelope{cred(prod, "host", "example.com")}, nil
+		}
+		return nil, nil
+	}
+
+	tcs := []struct {
+		name        string
+		contextPath string
+		src         string
+		out         string
+		err         string
+	}{
+		{
+			name:        "context",
+			contextPath: dev,
+			src:         `{{ secret "host" }}:{{ secret "PORT" }}`,
+			out:         "localhost:8080",
+		},
+		{
+			name:        "full path",
+			contextPath: dev,
+			src:         `{{ secret "/org/project/production/api/jeff/1/host" }}`,
+			out:         "example.com",
+		},
+		{
+			name:        "missing",
+			contextPath: dev,
+			src:         `{{ secret "token" }}`,
+			err:         "not set",
+		},
+		{
+			name: "no context",
+			src:  `{{ secret "host" }}`,
+			err:  "no path",
+		},
+		{
+			name:        "relative path",
+			contextPath: dev,
+			src:         `{{ secret "production/host" }}`,
+			err:         "full path",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := renderTemplate("test", []byte(tc.src), newTemplateSecrets(tc.contextPath, get))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("expected an error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tc.out {
+				t.Errorf("got %q want %q", out, tc.out)
+			}
+		})
+	}
+
+	// Only the context and missing cases read the context path.
+	if fetched[dev] != 2 {
+		t.Errorf("expected the context path to be fetched once per render, got %d", fetched[dev])
+	}
+
+	t.Run("secret file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "torus-template-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		secrets := newTemplateSecrets(prod, get)
+		secrets.fileDir = dir
+		out, err := renderTemplate("test", []byte(`{{ secretFile "HOST" }}`), secrets)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(dir, "host")
+		if string(out) != path {
+			t.Errorf("got %q want %q", out, path)
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "example.com" {
+			t.Errorf("got file contents %q", b)
+		}
+	})
+}
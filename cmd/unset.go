@@ -1,21 +1,34 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/urfave/cli"
 
+	"github.com/manifoldco/torus-cli/api"
 	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
 )
 
+const unsetFailed = "Could not unset credential"
+
 func init() {
 	unset := cli.Command{
 		Name:      "unset",
-		Usage:     "Remove a secret from a service and environment",
-		ArgsUsage: "<name|path>",
+		Usage:     "Remove a secret, or the secrets matching a pattern, from a service and environment",
+		ArgsUsage: "<name|path|pattern>",
 		Category:  "SECRETS",
-		Flags:     append(setUnsetFlags, stdAutoAcceptFlag),
+		Flags: append(append([]cli.Flag{}, setUnsetFlags...),
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "List the secrets that would be unset, without unsetting them.",
+			},
+			stdAutoAcceptFlag,
+		),
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 			setSliceDefaults, unsetCmd,
@@ -25,6 +38,12 @@ func init() {
 	Cmds = append(Cmds, unset)
 }
 
+// isNamePattern reports whether name is a glob pattern, such as DB_*, rather
+// than the name of a single secret.
+func isNamePattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
 func unsetCmd(ctx *cli.Context) error {
 	args := ctx.Args()
 	if len(args) != 1 {
@@ -37,7 +56,16 @@ func unsetCmd(ctx *cli.Context) error {
 
 	pathexp, cname, err := determineCredential(ctx, args[0])
 	if err != nil {
-		return errs.NewErrorExitError("Could not unset credential", err)
+		return errs.NewErrorExitError(unsetFailed, err)
+	}
+
+	if isNamePattern(*cname) {
+		return unsetPatternCmd(ctx, pathexp, *cname)
+	}
+
+	if ctx.Bool("dry-run") {
+		fmt.Printf("%s/%s would be unset.\n", pathexp.String(), *cname)
+		return nil
 	}
 
 	preamble := fmt.Sprintf("You are about to unset \"%s/%s\". This cannot be undone.", pathexp.String(), *cname)
@@ -50,10 +78,10 @@ func unsetCmd(ctx *cli.Context) error {
 	var cred *apitypes.CredentialEnvelope
 	cred, err = setCredential(ctx, args[0], func() *apitypes.CredentialValue {
 		return apitypes.NewUnsetCredentialValue()
-	})
+	}, nil)
 
 	if err != nil {
-		return errs.NewErrorExitError("Could not unset credential", err)
+		return errs.NewErrorExitError(unsetFailed, err)
 	}
 
 	name := (*cred.Body).GetName()
@@ -63,3 +91,76 @@ func unsetCmd(ctx *cli.Context) error {
 
 	return nil
 }
+
+// unsetPatternCmd unsets every secret set at exactly pe whose name matches
+// pattern. The daemon finds them, so their values are never decrypted.
+func unsetPatternCmd(ctx *cli.Context, pe *pathexp.PathExp, pattern string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	org, project, err := credentialOwners(c, client, pe)
+	if err != nil {
+		return err
+	}
+
+	locations, err := client.Credentials.SearchNames(c, org.ID, pattern, false, pe.String(), true)
+	if err != nil {
+		return errs.NewErrorExitError(unsetFailed, err)
+	}
+
+	if len(locations) == 0 {
+		fmt.Printf("No secrets matching %s are set at %s.\n", pattern, pe)
+		return nil
+	}
+
+	verb := "will"
+	if ctx.Bool("dry-run") {
+		verb = "would"
+	}
+	fmt.Printf("%d secrets matching %s %s be unset:\n\n", len(locations), pattern, verb)
+	for _, l := range locations {
+		fmt.Printf("  %s/%s\n", l.PathExp, l.Name)
+	}
+	fmt.Println()
+
+	if ctx.Bool("dry-run") {
+		return nil
+	}
+
+	preamble := fmt.Sprintf("You are about to unset %d secrets at \"%s\". This cannot be undone.",
+		len(locations), pe)
+	abortErr := ConfirmDialogue(ctx, nil, &preamble, "", true)
+	if abortErr != nil {
+		return abortErr
+	}
+
+	creds := make([]apitypes.Credential, len(locations))
+	for i, l := range locations {
+		creds[i] = newCredential(org.ID, project.ID, pe, l.Name, apitypes.NewUnsetCredentialValue())
+	}
+	expectVersions(creds, locations)
+
+	_, err = client.Credentials.CreateBatch(c, creds, ctx.Bool("force"), false, &progress)
+	if apitypes.IsConfirmationRequiredError(err) {
+		err = confirmProtectedWrite(ctx, err)
+		if err != nil {
+			return err
+		}
+		_, err = client.Credentials.CreateBatch(c, creds, ctx.Bool("force"), true, &progress)
+	}
+	if apitypes.IsConflictError(err) {
+		return errs.NewExitError(err.Error() +
+			"\nCheck their new values, and run the command again, or use --force to unset them.")
+	}
+	if err != nil {
+		return errs.NewErrorExitError(unsetFailed, err)
+	}
+
+	fmt.Printf("\n%d secrets have been unset at %s.\n", len(locations), pe)
+	return nil
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/dirprefs"
+	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+const validateFailed = "Could not validate secrets."
+
+func init() {
+	validate := cli.Command{
+		Name:     "validate",
+		Usage:    "Check the secrets in a project against the rules in .torus.json",
+		Category: "SECRETS",
+		Flags: []cli.Flag{
+			orgFlag("Use this organization.", true),
+			projectFlag("Use this project.", true),
+			envFlag("Only check secrets in this environment.", false),
+			serviceFlag("Only check secrets for this service.", "", false),
+		},
+		Action: chain(
+			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
+			checkRequiredFlags, validateCmd,
+		),
+	}
+
+	Cmds = append(Cmds, validate)
+}
+
+// loadRules returns the rules secrets are validated against, found in
+// .torus.json.
+func loadRules() (dirprefs.Rules, error) {
+	d, err := dirprefs.Load(true)
+	if err != nil {
+		return nil, err
+	}
+
+	dc, err := d.Resolve("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return dc.Rules, nil
+}
+
+// checkRules returns an error describing how value breaks the rules for the
+// named secret, if it does.
+func checkRules(name, value string) error {
+	rules, err := loadRules()
+	if err != nil {
+		return errs.NewErrorExitError("Could not load rules.", err)
+	}
+
+	problems := rules.Check(name, value)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return errs.NewExitError("The value of " + name + " breaks the rules in .torus.json: " +
+		describeRuleProblems(problems) + ".")
+}
+
+func describeRuleProblems(problems []dirprefs.RuleProblem) string {
+	reasons := make([]string, len(problems))
+	for i, p := range problems {
+		reasons[i] = p.Reason + " (" + p.Name + ")"
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// ruleViolation is a secret whose value breaks the rules.
+type ruleViolation struct {
+	path     string
+	name     string
+	problems []dirprefs.RuleProblem
+}
+
+type ruleViolations []ruleViolation
+
+func (v ruleViolations) Len() int      { return len(v) }
+func (v ruleViolations) Swap(i, j int) { v[i], v[j] = v[j], v[i] }
+func (v ruleViolations) Less(i, j int) bool {
+	if v[i].path != v[j].path {
+		return v[i].path < v[j].path
+	}
+	return v[i].name < v[j].name
+}
+
+func validateCmd(ctx *cli.Context) error {
+	if len(ctx.Args()) > 0 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+
+	rules, err := loadRules()
+	if err != nil {
+		return errs.NewErrorExitError("Could not load rules.", err)
+	}
+	if len(rules) == 0 {
+		fmt.Println("No rules are set in .torus.json.")
+		return nil
+	}
+
+	env, service := ctx.String("environment"), ctx.String("service")
+	if env == "" {
+		env = "*"
+	}
+	if service == "" {
+		service = "*"
+	}
+
+	pe, err := pathexp.New(ctx.String("org"), ctx.String("project"),
+		[]string{env}, []string{service}, []string{"*"}, []string{"*"})
+	if err != nil {
+		return errs.NewErrorExitError(validateFailed, err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := api.NewClient(cfg)
+	c := context.Background()
+
+	creds, err := client.Credentials.Search(c, pe.String())
+	if err != nil {
+		return errs.NewErrorExitError(validateFailed, err)
+	}
+
+	var violations ruleViolations
+	for _, cred := range creds {
+		body := *cred.Body
+		value := body.GetValue()
+		if value == nil || value.IsUnset() {
+			continue
+		}
+
+		problems := rules.Check(body.GetName(), value.String())
+		if len(problems) > 0 {
+			violations = append(violations, ruleViolation{
+				path:     body.GetPathExp().String(),
+				name:     body.GetName(),
+				problems: problems,
+			})
+		}
+	}
+
+	if len(violations) == 0 {
+		fmt.Printf("All secrets at %s pass the rules in .torus.json.\n", pe)
+		return nil
+	}
+
+	sort.Sort(violations)
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, tableHeader("SECRET", "PATH", "PROBLEMS"))
+	for _, v := range violations {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", strings.ToUpper(v.name), v.path, describeRuleProblems(v.problems))
+	}
+	w.Flush()
+	fmt.Println()
+
+	return errs.NewExitError(fmt.Sprintf("%d secrets break the rules in .torus.json.", len(violations)))
+}
@@ -19,13 +19,23 @@ func init() {
 		Name:     "version",
 		Usage:    "Display versions of utility components",
 		Category: "SYSTEM",
-		Action:   VersionLookup,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "verify",
+				Usage: "Check this binary against the signed release manifest for its version",
+			},
+		},
+		Action: VersionLookup,
 	}
 	Cmds = append(Cmds, version)
 }
 
 // VersionLookup ensures the environment is ready and then executes version cmd
 func VersionLookup(ctx *cli.Context) error {
+	if ctx.Bool("verify") {
+		return verifyVersionCmd(ctx)
+	}
+
 	return chain(
 		ensureDaemon, listVersionsCmd,
 	)(ctx)
@@ -43,6 +53,9 @@ func listVersionsCmd(ctx *cli.Context) error {
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
 	fmt.Fprintf(w, "%s\t%s\n", "CLI", cfg.Version)
+	if config.Commit != "" {
+		fmt.Fprintf(w, "%s\t%s (%s)\n", "Build", config.Commit, config.Builder)
+	}
 	fmt.Fprintf(w, "%s\t%s\n", "Daemon", daemonVersion.Version)
 	fmt.Fprintf(w, "%s\t%s\n", "Registry", registryVersion.Version)
 	w.Flush()
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kardianos/osext"
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/manifoldco/torus-cli/base64"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
+)
+
+const verifyFailed = "Could not verify the torus binary."
+
+// verifyVersionCmd checks the running binary against the signed checksum
+// manifest published for its version.
+func verifyVersionCmd(ctx *cli.Context) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Version == "alpha" {
+		return errs.NewExitError("Development builds have no release manifest to verify against.")
+	}
+
+	path, err := executablePath()
+	if err != nil {
+		return errs.NewErrorExitError(verifyFailed, err)
+	}
+
+	sum, err := fileChecksum(path)
+	if err != nil {
+		return errs.NewErrorExitError(verifyFailed, err)
+	}
+
+	name := fmt.Sprintf("%s/%s/torus_%s_BINARIES_SHA256SUMS", config.ReleaseURL,
+		cfg.Version, cfg.Version)
+	manifest, err := fetchRelease(name)
+	if err != nil {
+		return errs.NewErrorExitError(verifyFailed, err)
+	}
+	sig, err := fetchRelease(name + ".sig")
+	if err != nil {
+		return errs.NewErrorExitError(verifyFailed, err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\n", "Version", cfg.Version)
+	fmt.Fprintf(w, "%s\t%s\n", "Commit", valueOrUnknown(config.Commit))
+	fmt.Fprintf(w, "%s\t%s\n", "Builder", valueOrUnknown(config.Builder))
+	fmt.Fprintf(w, "%s\t%s\n", "Binary", path)
+	fmt.Fprintf(w, "%s\t%s\n", "SHA256", sum)
+	w.Flush()
+	fmt.Println("")
+
+	err = verifyReleaseManifest(manifest, sig, ed25519.PublicKey(cfg.PublicKey.PublicKey))
+	if err != nil {
+		return errs.NewErrorExitError("The release manifest for "+cfg.Version+" is not validly signed.", err)
+	}
+
+	platform := runtime.GOOS + "-" + runtime.GOARCH
+	expected, ok := parseChecksumManifest(manifest)[platform]
+	if !ok {
+		return errs.NewExitError("The release manifest for " + cfg.Version +
+			" has no binary for " + platform + ".")
+	}
+	if expected != sum {
+		return errs.NewExitError("This binary does NOT match the release of " + cfg.Version +
+			" for " + platform + ". It may have been tampered with.")
+	}
+
+	fmt.Println("This binary matches the signed release of " + cfg.Version + ".")
+	return nil
+}
+
+// executablePath returns the absolute path of the running binary, with
+// symlinks resolved. It is asked of the OS, rather than trusting os.Args[0],
+// which the caller controls.
+func executablePath() (string, error) {
+	path, err := osext.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(path)
+}
+
+// fileChecksum returns the hex encoded SHA256 sum of the file at path.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fetchRelease downloads a file published with a release.
+func fetchRelease(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not download %s: %s", url, resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// verifyReleaseManifest checks that sig, a base64url encoded ed25519
+// signature, was made over manifest by key.
+func verifyReleaseManifest(manifest, sig []byte, key ed25519.PublicKey) error {
+	if len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid public key")
+	}
+
+	decoded, err := base64.NewValueFromString(string(bytes.TrimSpace(sig)))
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(key, manifest, *decoded) {
+		return errors.New("signature does not match")
+	}
+
+	return nil
+}
+
+// parseChecksumManifest returns the checksums in a manifest of
+// "<sha256>  <platform>" lines, by platform.
+func parseChecksumManifest(manifest []byte) map[string]string {
+	sums := make(map[string]string)
+
+	s := bufio.NewScanner(bytes.NewReader(manifest))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 2 {
+			sums[fields[1]] = strings.ToLower(fields[0])
+		}
+	}
+
+	return sums
+}
+
+func valueOrUnknown(v string) string {
+	if v == "" {
+		return "unknown"
+	}
+	return v
+}
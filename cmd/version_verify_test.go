@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/manifoldco/torus-cli/base64"
+)
+
+func TestVerifyReleaseManifest(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := []byte("abc123  linux-amd64\n")
+	sig := []byte(base64.NewValue(ed25519.Sign(priv, manifest)).String() + "\n")
+
+	err = verifyReleaseManifest(manifest, sig, pub)
+	if err != nil {
+		t.Error("unexpected error:", err)
+	}
+
+	err = verifyReleaseManifest([]byte("def456  linux-amd64\n"), sig, pub)
+	if err == nil {
+		t.Error("expected a changed manifest to fail verification")
+	}
+
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = verifyReleaseManifest(manifest, sig, other)
+	if err == nil {
+		t.Error("expected verification with another key to fail")
+	}
+}
+
+func TestParseChecksumManifest(t *testing.T) {
+	sums := parseChecksumManifest([]byte(
+		"ABC123  darwin-amd64\ndef456  linux-amd64\n\nmalformed\n"))
+
+	if len(sums) != 2 {
+		t.Errorf("wrong number of checksums: %v", sums)
+	}
+	if sums["darwin-amd64"] != "abc123" || sums["linux-amd64"] != "def456" {
+		t.Errorf("wrong checksums: %v", sums)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/urfave/cli"
 
@@ -15,13 +17,17 @@ import (
 	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/hints"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/cmd/output"
 )
 
 func init() {
 	view := cli.Command{
-		Name:     "view",
-		Usage:    "View secrets for the current service and environment",
-		Category: "SECRETS",
+		Name:      "view",
+		Usage:     "View secrets for the current service and environment",
+		ArgsUsage: "[name]",
+		Category:  "SECRETS",
 		Flags: []cli.Flag{
 			stdOrgFlag,
 			stdProjectFlag,
@@ -35,6 +41,10 @@ func init() {
 				Name:  "verbose, v",
 				Usage: "Lists the sources of the secrets (shortcut for --format verbose)",
 			},
+			stdOfflineFlag,
+			newPlaceholder("out", "FILE",
+				"Write the exact value of the named secret to FILE, such as a certificate",
+				"", "", false),
 		},
 		Action: chain(
 			ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
@@ -46,11 +56,31 @@ func init() {
 }
 
 func viewCmd(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) > 1 {
+		return errs.NewUsageExitError("Too many arguments provided.", ctx)
+	}
+	if len(args) == 0 && ctx.String("out") != "" {
+		return errs.NewUsageExitError("A secret name is required with --out.", ctx)
+	}
+
 	secrets, path, err := getSecrets(ctx)
 	if err != nil {
 		return err
 	}
 
+	if len(args) == 1 {
+		return viewSecretValue(secrets, path, args[0], ctx.String("out"))
+	}
+
+	if output.IsJSON(ctx) {
+		records, err := output.NewCredentials(secrets)
+		if err != nil {
+			return err
+		}
+		return output.Write(os.Stdout, records)
+	}
+
 	if ctx.Bool("verbose") && ctx.IsSet("format") {
 		return errs.NewUsageExitError(
 			"Cannot specify --format and --verbose at the same time", ctx)
@@ -76,6 +106,44 @@ func viewCmd(ctx *cli.Context) error {
 	return err
 }
 
+// viewSecretValue writes the exact value of the named secret to the file out,
+// or to stdout if out is empty or -. Nothing is added to the value, so files
+// such as certificates come back byte for byte.
+func viewSecretValue(secrets []apitypes.CredentialEnvelope, path, name, out string) error {
+	cset := credentialSet{}
+	for _, c := range secrets {
+		cset.Add(c)
+	}
+
+	cred, ok := cset[strings.ToLower(name)]
+	if !ok {
+		return errs.NewExitError("Secret " + name + " is not set at " + path + ".")
+	}
+	value := []byte((*cred.Body).GetValue().String())
+
+	if out == "" || out == "-" {
+		_, err := os.Stdout.Write(value)
+		return err
+	}
+
+	err := ioutil.WriteFile(out, value, 0600)
+	if err != nil {
+		return errs.NewErrorExitError("Could not write "+out+".", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %s to %s.\n", name, out)
+	return nil
+}
+
+// displayValue returns a value for listing. Binary values are described
+// rather than shown, as they can't be printed.
+func displayValue(value *apitypes.CredentialValue) string {
+	if value.IsBinary() {
+		return fmt.Sprintf("(binary, %d bytes)", len(value.String()))
+	}
+	return value.String()
+}
+
 func printEnvFormat(secrets []apitypes.CredentialEnvelope, path string) error {
 	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
 
@@ -83,7 +151,7 @@ func printEnvFormat(secrets []apitypes.CredentialEnvelope, path string) error {
 		value := (*secret.Body).GetValue()
 		name := (*secret.Body).GetName()
 		key := strings.ToUpper(name)
-		fmt.Fprintf(w, "%s=%s\n", key, value.String())
+		fmt.Fprintf(w, "%s=%s\n", key, displayValue(value))
 	}
 	w.Flush()
 
@@ -99,7 +167,8 @@ func printVerboseFormat(secrets []apitypes.CredentialEnvelope, path string) erro
 		name := (*secret.Body).GetName()
 		key := strings.ToUpper(name)
 		spath := (*secret.Body).GetPathExp().String() + "/" + name
-		fmt.Fprintf(w, "%s=%s\t%s\n", key, value.String(), spath)
+		fmt.Fprintf(w, "%s=%s\t%s\t%s\n", key, displayValue(value), spath,
+			describeMeta((*secret.Body).GetMeta()))
 	}
 	w.Flush()
 
@@ -107,6 +176,33 @@ func printVerboseFormat(secrets []apitypes.CredentialEnvelope, path string) erro
 
 }
 
+// describeMeta returns a short summary of a secret's metadata.
+func describeMeta(meta *primitive.CredentialMeta) string {
+	parts := []string{}
+	if meta.Description != "" {
+		parts = append(parts, meta.Description)
+	}
+	if len(meta.Tags) > 0 {
+		parts = append(parts, "["+strings.Join(meta.Tags, ", ")+"]")
+	}
+	if meta.ExpiresAt != nil {
+		verb := "expires"
+		if meta.Expired(time.Now()) {
+			verb = "expired"
+		}
+		parts = append(parts, verb+" "+meta.ExpiresAt.Local().Format("2006-01-02"))
+	}
+	if meta.Honeytoken {
+		parts = append(parts, "honeytoken")
+	}
+	if meta.Broker != nil {
+		ttl := time.Duration(meta.Broker.TTL) * time.Second
+		parts = append(parts, "dynamic ("+meta.Broker.Provider+", "+ttl.String()+")")
+	}
+
+	return strings.Join(parts, " ")
+}
+
 func printJSONFormat(secrets []apitypes.CredentialEnvelope, path string) error {
 	keyMap := make(map[string]interface{})
 
@@ -130,7 +226,17 @@ func printJSONFormat(secrets []apitypes.CredentialEnvelope, path string) error {
 	return nil
 }
 
+// offlineStaleAge is how old secrets read with --offline may be before a
+// warning is shown.
+const offlineStaleAge = 24 * time.Hour
+
 func getSecrets(ctx *cli.Context) ([]apitypes.CredentialEnvelope, string, error) {
+	return getServiceSecrets(ctx, ctx.String("service"))
+}
+
+// getServiceSecrets returns the secrets for the given service, in the org,
+// project, environment and instance given by the command's flags.
+func getServiceSecrets(ctx *cli.Context, service string) ([]apitypes.CredentialEnvelope, string, error) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return nil, "", err
@@ -151,20 +257,54 @@ func getSecrets(ctx *cli.Context) ([]apitypes.CredentialEnvelope, string, error)
 
 	parts := []string{
 		"", ctx.String("org"), ctx.String("project"), ctx.String("environment"),
-		ctx.String("service"), identity, ctx.String("instance"),
+		service, identity, ctx.String("instance"),
 	}
 
 	path := strings.Join(parts, "/")
 
-	secrets, err := client.Credentials.Get(c, path)
+	secrets, cachedAt, err := client.Credentials.GetCached(c, path, ctx.Bool("offline"))
 	if err != nil {
 		return nil, "", errs.NewErrorExitError("Error fetching secrets", err)
 	}
+	if cachedAt != nil && !ctx.Bool("offline") {
+		fmt.Fprintf(os.Stderr, "Warning: Could not reach the registry. "+
+			"Using secrets cached %s ago.\n", formatAge(time.Since(*cachedAt)))
+	} else if cachedAt != nil && time.Since(*cachedAt) > offlineStaleAge {
+		fmt.Fprintf(os.Stderr, "Warning: Using secrets cached %s ago. "+
+			"They may be out of date.\n", formatAge(time.Since(*cachedAt)))
+	}
 
 	cset := credentialSet{}
 	for _, c := range secrets {
 		cset.Add(c)
 	}
 
-	return cset.ToSlice(), path, nil
+	secrets = cset.ToSlice()
+	for _, secret := range secrets {
+		meta := (*secret.Body).GetMeta()
+		if meta.Expired(time.Now()) {
+			fmt.Fprintf(os.Stderr, "Warning: Secret %s expired on %s.\n",
+				(*secret.Body).GetName(), meta.ExpiresAt.Local().Format("2006-01-02"))
+		}
+	}
+
+	return secrets, path, nil
+}
+
+// formatAge returns a rough, human readable length of d.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "less than a minute"
+	case d < 2*time.Minute:
+		return "1 minute"
+	case d < time.Hour:
+		return fmt.Sprintf("%d minutes", d/time.Minute)
+	case d < 2*time.Hour:
+		return "1 hour"
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	default:
+		return fmt.Sprintf("%d days", d/(24*time.Hour))
+	}
 }
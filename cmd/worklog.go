@@ -24,7 +24,12 @@ func init() {
 			{
 				Name:  "list",
 				Usage: "List worklog maintenance tasks",
-				Flags: []cli.Flag{stdOrgFlag},
+				Flags: []cli.Flag{
+					stdOrgFlag,
+					newSlicePlaceholder("type, t", "TYPE",
+						"Only include items of this type, such as keyring, revoked, or claims",
+						"", "", false),
+				},
 				Action: chain(
 					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 					checkRequiredFlags, worklogList,
@@ -44,7 +49,12 @@ func init() {
 				Name:      "resolve",
 				Usage:     "Act on and resolve the given worklog items",
 				ArgsUsage: "[identity...]",
-				Flags:     []cli.Flag{stdOrgFlag},
+				Flags: []cli.Flag{
+					stdOrgFlag,
+					newSlicePlaceholder("type, t", "TYPE",
+						"Only include items of this type, such as keyring, revoked, or claims",
+						"", "", false),
+				},
 				Action: chain(
 					ensureDaemon, ensureSession, loadDirPrefs, loadPrefDefaults,
 					checkRequiredFlags, worklogResolve,
@@ -69,7 +79,12 @@ func worklogList(ctx *cli.Context) error {
 		return err
 	}
 
-	items, err := client.Worklog.List(c, org.ID)
+	itemType, err := worklogType(ctx)
+	if err != nil {
+		return err
+	}
+
+	items, err := client.Worklog.List(c, org.ID, itemType)
 	if err != nil {
 		return err
 	}
@@ -80,7 +95,7 @@ func worklogList(ctx *cli.Context) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "IDENTITY\tTYPE\tSUBJECT")
+	fmt.Fprintln(w, tableHeader("IDENTITY", "TYPE", "SUBJECT"))
 	for _, item := range items {
 		fmt.Fprintf(w, "%s\t%s\t%s\n", item.ID, item.Type(), item.Subject)
 	}
@@ -89,6 +104,26 @@ func worklogList(ctx *cli.Context) error {
 	return nil
 }
 
+// worklogType returns the worklog types given by the --type flag, or every
+// type if none were given.
+func worklogType(ctx *cli.Context) (apitypes.WorklogType, error) {
+	names := ctx.StringSlice("type")
+	if len(names) == 0 {
+		return apitypes.AnyWorklogType, nil
+	}
+
+	var itemType apitypes.WorklogType
+	for _, name := range names {
+		t, err := apitypes.ParseWorklogType(name)
+		if err != nil {
+			return 0, errs.NewUsageExitError(err.Error(), ctx)
+		}
+		itemType |= t
+	}
+
+	return itemType, nil
+}
+
 func worklogView(ctx *cli.Context) error {
 	args := ctx.Args()
 	if len(args) != 1 {
@@ -157,7 +192,12 @@ func worklogResolve(ctx *cli.Context) error {
 		idents = append(idents, ident)
 	}
 
-	items, err := client.Worklog.List(c, org.ID)
+	itemType, err := worklogType(ctx)
+	if err != nil {
+		return err
+	}
+
+	items, err := client.Worklog.List(c, org.ID, itemType)
 	if err != nil {
 		return err
 	}
@@ -181,10 +221,18 @@ func worklogResolve(ctx *cli.Context) error {
 
 	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
 	for _, item := range toResolve {
-		if item.Type() == apitypes.InviteApproveWorklogType {
+		var confirm string
+		switch item.Type() {
+		case apitypes.InviteApproveWorklogType:
+			confirm = "Approve invite for " + item.Subject
+		case apitypes.StaleClaimsWorklogType:
+			confirm = "Renew your keypairs for org " + item.Subject
+		}
+
+		if confirm != "" {
 			w.Flush()
 
-			err = AskPerform("Approve invite for " + item.Subject)
+			err = AskPerform(confirm)
 			switch err {
 			case nil:
 			case promptui.ErrAbort:
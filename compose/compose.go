@@ -0,0 +1,243 @@
+// Package compose generates docker-compose override files that give the
+// services in a compose file their secrets from torus, through env files
+// written for each service.
+package compose
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ServiceLabel is the label a compose service can be given to choose the
+// torus service it receives secrets from. A value of SkipService leaves the
+// service out of the override file.
+const (
+	ServiceLabel = "sh.torus.service"
+	SkipService  = "-"
+)
+
+const (
+	generatedHeader = "# Generated by torus compose generate. Remove with torus compose clean."
+	envDirComment   = "# torus-env-dir: "
+	envDirPrefix    = "torus-compose-"
+)
+
+// File is the part of a docker-compose file needed to generate an override.
+type File struct {
+	Version  string             `yaml:"version"`
+	Services map[string]Service `yaml:"services"`
+}
+
+// Service is a service in a docker-compose file.
+type Service struct {
+	Labels Labels `yaml:"labels"`
+}
+
+// Labels are the labels of a compose service. Compose accepts them as either
+// a map, or a list of KEY=VALUE strings.
+type Labels map[string]string
+
+// UnmarshalYAML reads labels in either of the forms compose accepts.
+func (l *Labels) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	m := map[string]string{}
+	if err := unmarshal(&m); err == nil {
+		*l = m
+		return nil
+	}
+
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return errors.New("labels must be a map, or a list of KEY=VALUE strings")
+	}
+
+	for _, label := range list {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) == 1 {
+			parts = append(parts, "")
+		}
+		m[parts[0]] = parts[1]
+	}
+
+	*l = m
+	return nil
+}
+
+// Parse reads a docker-compose file.
+func Parse(b []byte) (*File, error) {
+	f := &File{}
+	err := yaml.Unmarshal(b, f)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(f.Services) == 0 {
+		return nil, errors.New("no services found; only compose files with a services section are supported")
+	}
+
+	return f, nil
+}
+
+// Load reads the docker-compose file at path.
+func Load(path string) (*File, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(b)
+}
+
+// TorusServices returns the torus service each compose service receives its
+// secrets from, keyed by the compose service's name. A service named in
+// mapping uses the torus service given there. Otherwise the ServiceLabel is
+// used, falling back to a torus service of the same name. Services mapped to
+// SkipService are left out.
+func (f *File) TorusServices(mapping map[string]string) map[string]string {
+	services := make(map[string]string, len(f.Services))
+	for name, svc := range f.Services {
+		torus, ok := mapping[name]
+		if !ok {
+			torus, ok = svc.Labels[ServiceLabel]
+		}
+		if !ok || torus == "" {
+			torus = name
+		}
+
+		if torus != SkipService {
+			services[name] = torus
+		}
+	}
+
+	return services
+}
+
+// Override is a docker-compose override file, adding an env file to each
+// service.
+type Override struct {
+	Version  string                     `yaml:"version,omitempty"`
+	Services map[string]OverrideService `yaml:"services"`
+}
+
+// OverrideService is a service in an Override.
+type OverrideService struct {
+	EnvFile []string `yaml:"env_file"`
+}
+
+// NewOverride returns an Override for a compose file with the given version,
+// giving each service the env file in envFiles.
+func NewOverride(version string, envFiles map[string]string) *Override {
+	o := &Override{
+		Version:  version,
+		Services: make(map[string]OverrideService, len(envFiles)),
+	}
+	for name, path := range envFiles {
+		o.Services[name] = OverrideService{EnvFile: []string{path}}
+	}
+
+	return o
+}
+
+// Marshal returns the override file, marked as generated by torus, and
+// recording the directory its env files are written in so it can be cleaned
+// up later.
+func (o *Override) Marshal(envDir string) ([]byte, error) {
+	b, err := yaml.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(generatedHeader + "\n")
+	buf.WriteString(envDirComment + envDir + "\n")
+	buf.Write(b)
+	return buf.Bytes(), nil
+}
+
+// Generated returns whether an override file was generated by torus, and the
+// directory its env files were written in.
+func Generated(b []byte) (bool, string) {
+	s := bufio.NewScanner(bytes.NewReader(b))
+	if !s.Scan() || s.Text() != generatedHeader {
+		return false, ""
+	}
+
+	if s.Scan() && strings.HasPrefix(s.Text(), envDirComment) {
+		return true, strings.TrimPrefix(s.Text(), envDirComment)
+	}
+
+	return true, ""
+}
+
+// memoryDirs are directories backed by memory on most systems, which env
+// files are preferably written to, so that secrets never reach the disk.
+var memoryDirs = []string{os.Getenv("XDG_RUNTIME_DIR"), "/dev/shm"}
+
+// NewEnvDir creates a directory, readable only by the current user, to write
+// env files in. It's created in memory backed storage when there is some, or
+// in the system's temporary directory otherwise.
+func NewEnvDir() (string, error) {
+	for _, dir := range memoryDirs {
+		if dir == "" {
+			continue
+		}
+
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			if envDir, err := ioutil.TempDir(dir, envDirPrefix); err == nil {
+				return envDir, nil
+			}
+		}
+	}
+
+	return ioutil.TempDir("", envDirPrefix)
+}
+
+// RemoveEnvDir removes a directory created by NewEnvDir, along with the env
+// files in it. Directories that were not created by NewEnvDir are refused.
+func RemoveEnvDir(dir string) error {
+	if !strings.HasPrefix(filepath.Base(dir), envDirPrefix) {
+		return errors.New(dir + " was not created by torus")
+	}
+
+	err := os.RemoveAll(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// WriteEnvFile writes values to an env file for the named service in dir,
+// readable only by the current user, and returns its path. Env files can't
+// hold values spanning more than one line.
+func WriteEnvFile(dir, service string, values map[string]string) (string, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	for _, name := range names {
+		value := values[name]
+		if strings.ContainsAny(value, "\r\n") {
+			return "", errors.New("the value of " + name + " spans more than one line")
+		}
+
+		buf.WriteString(name + "=" + value + "\n")
+	}
+
+	path := filepath.Join(dir, service+".env")
+	err := ioutil.WriteFile(path, buf.Bytes(), 0600)
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
@@ -0,0 +1,116 @@
+package compose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const composeFile = `
+version: "3"
+services:
+  web:
+    image: nginx
+    labels:
+      - "sh.torus.service=frontend"
+  api:
+    image: api
+    labels:
+      sh.torus.service: "-"
+  db:
+    image: postgres
+  worker:
+    image: api
+`
+
+func TestTorusServices(t *testing.T) {
+	f, err := Parse([]byte(composeFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Version != "3" {
+		t.Errorf("got version %q, want 3", f.Version)
+	}
+
+	got := f.TorusServices(map[string]string{"worker": "jobs"})
+	want := map[string]string{
+		"web":    "frontend",
+		"db":     "db",
+		"worker": "jobs",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got services %v, want %v", got, want)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	dir, err := NewEnvDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveEnvDir(dir)
+
+	path, err := WriteEnvFile(dir, "web", map[string]string{"PORT": "80", "HOST": "a b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("env files are private", func(t *testing.T) {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("got mode %s, want 0600", info.Mode().Perm())
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "HOST=a b\nPORT=80\n" {
+			t.Errorf("got env file %q", b)
+		}
+	})
+
+	t.Run("multi-line values are refused", func(t *testing.T) {
+		_, err := WriteEnvFile(dir, "api", map[string]string{"KEY": "a\nb"})
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("override records its env dir", func(t *testing.T) {
+		b, err := NewOverride("3", map[string]string{"web": path}).Marshal(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		generated, envDir := Generated(b)
+		if !generated || envDir != dir {
+			t.Errorf("got generated %t, env dir %q", generated, envDir)
+		}
+
+		generated, _ = Generated([]byte("version: \"3\"\n"))
+		if generated {
+			t.Error("hand written override reported as generated")
+		}
+	})
+
+	t.Run("only env dirs are removed", func(t *testing.T) {
+		other := filepath.Join(filepath.Dir(dir), "not-torus")
+		if err := RemoveEnvDir(other); err == nil {
+			t.Error("expected an error removing another directory")
+		}
+
+		if err := RemoveEnvDir(dir); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Error("env dir was not removed")
+		}
+	})
+}
@@ -9,9 +9,12 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"sort"
+	"strconv"
 
 	"github.com/manifoldco/torus-cli/data"
 	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
 	"github.com/manifoldco/torus-cli/prefs"
 )
 
@@ -19,6 +22,16 @@ import (
 var Version = "alpha"
 var apiVersion = "0.2.0"
 
+// Commit and Builder record where the binary came from: the git commit it
+// was built from, and the toolchain that built it. They are set via the
+// Makefile, and are empty for other builds.
+var Commit = ""
+var Builder = ""
+
+// ReleaseURL is where release binaries and their checksum manifests are
+// published.
+const ReleaseURL = "https://get.torus.sh"
+
 const requiredPermissions = 0700
 
 // Config represents the static and user defined configuration data
@@ -27,14 +40,62 @@ type Config struct {
 	APIVersion string
 	Version    string
 
-	TorusRoot  string
-	SocketPath string
-	PidPath    string
-	DBPath     string
+	TorusRoot    string
+	SocketPath   string
+	PidPath      string
+	DBPath       string
+	AuditLogPath string
+
+	// GRPCSocketPath is where the daemon serves its gRPC interface.
+	GRPCSocketPath string
+
+	// ResolveTokenPath holds the token for the daemon's resolve API.
+	ResolveTokenPath string
 
 	RegistryURI *url.URL
 	CABundle    *x509.CertPool
 	PublicKey   *prefs.PublicKey
+
+	// Prefetch is whether the daemon keeps credentials for recently used
+	// paths cached while it is idle.
+	Prefetch bool
+
+	// Retries is how many times the CLI retries reads that fail because the
+	// daemon or registry can't be reached. CircuitBreaker is whether it stops
+	// making requests for a while once the registry is repeatedly unreachable.
+	Retries        int
+	CircuitBreaker bool
+
+	// ReadOnly is whether the daemon rejects every request that would change
+	// anything in the registry, leaving it able only to read secrets.
+	ReadOnly bool
+
+	// BreakGlass is the reason given, through --break-glass or
+	// TORUS_BREAK_GLASS, for reading secrets from frozen keyrings.
+	BreakGlass string
+
+	// Trace is whether the CLI prints a transcript of its requests to the
+	// daemon, set through --verbose or TORUS_VERBOSE. TraceFile, if set, is
+	// where the transcript is written instead, as JSON lines.
+	Trace     bool
+	TraceFile string
+
+	// Backends are the stores of secrets the daemon uses instead of, or
+	// alongside, the registry, for the paths they are configured for.
+	Backends []Backend
+}
+
+// Backend is a store of secrets other than the registry, configured in a
+// [backend.NAME] section of the preferences.
+type Backend struct {
+	Name     string
+	Type     string
+	PathExps []*pathexp.PathExp
+
+	// File is where a file backend keeps its secrets. RegistryURI is the
+	// registry of a registry backend.
+	File        string
+	RegistryURI *url.URL
 }
 
 // NewConfig returns a new Config, with loaded user preferences.
@@ -54,28 +115,101 @@ func NewConfig(torusRoot string) (*Config, error) {
 		return nil, err
 	}
 
-	registryURI, err := url.Parse(preferences.Core.RegistryURI)
+	// TORUS_REGISTRY_URI overrides the preferences, so that a daemon can be
+	// run for another registry, such as during a migration.
+	rawRegistryURI := preferences.Core.RegistryURI
+	if uri := os.Getenv("TORUS_REGISTRY_URI"); uri != "" {
+		rawRegistryURI = uri
+	}
+
+	registryURI, err := url.Parse(rawRegistryURI)
 	if err != nil {
 		return nil, fmt.Errorf("invalid registry_uri")
 	}
 
+	trace, _ := strconv.ParseBool(os.Getenv("TORUS_VERBOSE"))
+
+	backends, err := loadBackends(preferences, torusRoot)
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
 		APIVersion: apiVersion,
 		Version:    Version,
 
-		TorusRoot:  torusRoot,
-		SocketPath: path.Join(torusRoot, "daemon.socket"),
-		PidPath:    path.Join(torusRoot, "daemon.pid"),
-		DBPath:     path.Join(torusRoot, "daemon.db"),
+		TorusRoot:    torusRoot,
+		SocketPath:   path.Join(torusRoot, "daemon.socket"),
+		PidPath:      path.Join(torusRoot, "daemon.pid"),
+		DBPath:       path.Join(torusRoot, "daemon.db"),
+		AuditLogPath: path.Join(torusRoot, "audit.log"),
+
+		GRPCSocketPath:   path.Join(torusRoot, "daemon.grpc.socket"),
+		ResolveTokenPath: path.Join(torusRoot, "resolve.token"),
 
 		RegistryURI: registryURI,
 		CABundle:    caBundle,
 		PublicKey:   publicKey,
+
+		Prefetch: preferences.Cache.Prefetch,
+
+		Retries:        preferences.Core.Retries,
+		CircuitBreaker: preferences.Core.CircuitBreaker,
+
+		ReadOnly: preferences.Core.ReadOnly,
+
+		BreakGlass: os.Getenv("TORUS_BREAK_GLASS"),
+
+		Trace:     trace,
+		TraceFile: os.Getenv("TORUS_TRACE_FILE"),
+
+		Backends: backends,
 	}
 
 	return cfg, nil
 }
 
+// loadBackends returns the backends set in preferences, in order of name.
+// Backends without paths aren't used.
+func loadBackends(preferences *prefs.Preferences, torusRoot string) ([]Backend, error) {
+	var names []string
+	for name := range preferences.Backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var backends []Backend
+	for _, name := range names {
+		b := preferences.Backends[name]
+		if b.Paths == "" {
+			continue
+		}
+
+		pes, err := b.PathExps()
+		if err != nil {
+			return nil, fmt.Errorf("invalid paths for backend %s: %s", name, err)
+		}
+
+		backend := Backend{Name: name, Type: b.Type, PathExps: pes}
+		switch b.Type {
+		case "file":
+			backend.File = b.File
+			if backend.File == "" {
+				backend.File = path.Join(torusRoot, "backends", name+".json")
+			}
+		case "registry":
+			backend.RegistryURI, err = url.Parse(preferences.Registries[b.Registry].URI)
+			if err != nil {
+				return nil, fmt.Errorf("invalid registry for backend %s", name)
+			}
+		}
+
+		backends = append(backends, backend)
+	}
+
+	return backends, nil
+}
+
 func torusRootPath() string {
 	torusRoot := os.Getenv("TORUS_ROOT")
 	if len(torusRoot) == 0 {
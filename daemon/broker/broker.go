@@ -0,0 +1,132 @@
+// Package broker runs the provider plugins that mint the values of dynamic
+// secrets, such as short-lived database users.
+//
+// A provider is an executable named torus-broker-<provider> on the daemon's
+// PATH. It is run once per action, with a Request encoded as JSON on its
+// stdin. To create a value, it writes a Lease as JSON to its stdout. Anything
+// it writes to stderr is included in the error if it exits unsuccessfully.
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// providerPrefix is the prefix of provider executables.
+const providerPrefix = "torus-broker-"
+
+// timeout is how long a provider has to complete an action.
+const timeout = 30 * time.Second
+
+// The actions a provider is asked to perform.
+const (
+	Create = "create"
+	Revoke = "revoke"
+)
+
+var providerName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Request is what a provider is asked to do.
+type Request struct {
+	Action string `json:"action"`
+
+	// Config is the dynamic secret's value, such as a connection URL for a
+	// database user that can create and drop other users.
+	Config string `json:"config"`
+
+	// TTL is how many seconds a created value should last. Providers should
+	// make values expire on their own where they can, in case they are
+	// never revoked.
+	TTL int64 `json:"ttl,omitempty"`
+
+	// Caller is the name of the user or machine reading the secret, and
+	// Path is where it was read. Providers include them in the names of the
+	// users they create.
+	Caller string `json:"caller,omitempty"`
+	Path   string `json:"path,omitempty"`
+
+	// Handle is given to revoke a value, as returned with its Lease.
+	Handle json.RawMessage `json:"handle,omitempty"`
+}
+
+// Lease is a value created by a provider.
+type Lease struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Handle identifies the value to the provider, such as the name of the
+	// user it created. It is given back to revoke the value.
+	Handle json.RawMessage `json:"handle"`
+}
+
+// Run asks provider to perform req, and returns what it wrote to stdout.
+func Run(ctx context.Context, provider string, req *Request) ([]byte, error) {
+	if !providerName.MatchString(provider) {
+		return nil, fmt.Errorf("invalid provider name %q", provider)
+	}
+
+	name := providerPrefix + provider
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("provider %s is not installed: %s was not found on the daemon's PATH",
+			provider, name)
+	}
+
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("provider %s could not %s: %s", provider, req.Action, msg)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// CreateLease asks provider to create a value, as described by req.
+func CreateLease(ctx context.Context, provider string, req *Request) (*Lease, error) {
+	req.Action = Create
+	out, err := Run(ctx, provider, req)
+	if err != nil {
+		return nil, err
+	}
+
+	lease := &Lease{}
+	err = json.Unmarshal(out, lease)
+	if err != nil {
+		return nil, fmt.Errorf("provider %s wrote an invalid lease: %s", provider, err)
+	}
+	if lease.Value == "" || lease.ExpiresAt.IsZero() {
+		return nil, errors.New("provider " + provider + " wrote a lease without a value or expiry")
+	}
+
+	return lease, nil
+}
+
+// RevokeLease asks provider to revoke the value identified by handle.
+func RevokeLease(ctx context.Context, provider, config string, handle json.RawMessage) error {
+	_, err := Run(ctx, provider, &Request{Action: Revoke, Config: config, Handle: handle})
+	return err
+}
@@ -0,0 +1,72 @@
+package broker
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fakeProvider = `#!/bin/sh
+input=$(cat)
+case "$input" in
+*'"action":"create"'*)
+	echo '{"value":"postgres://minted@db/app","expires_at":"2030-01-01T00:00:00Z","handle":{"user":"minted"}}' ;;
+*'"handle":{"user":"minted"}'*)
+	exit 0 ;;
+*)
+	echo "bad request" >&2
+	exit 1 ;;
+esac
+`
+
+func TestProviders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "torus-broker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, providerPrefix+"fake"), []byte(fakeProvider), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	defer os.Setenv("PATH", path)
+
+	ctx := context.Background()
+
+	t.Run("create", func(t *testing.T) {
+		lease, err := CreateLease(ctx, "fake", &Request{Config: "postgres://admin@db/app", TTL: 60})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lease.Value != "postgres://minted@db/app" || string(lease.Handle) != `{"user":"minted"}` {
+			t.Errorf("unexpected lease %+v", lease)
+		}
+
+		err = RevokeLease(ctx, "fake", "postgres://admin@db/app", lease.Handle)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("errors include stderr", func(t *testing.T) {
+		err := RevokeLease(ctx, "fake", "", []byte(`{"user":"other"}`))
+		if err == nil || !strings.Contains(err.Error(), "bad request") {
+			t.Errorf("got %v, want the provider's message", err)
+		}
+	})
+
+	t.Run("missing and invalid providers", func(t *testing.T) {
+		for _, name := range []string{"missing", "../fake"} {
+			if _, err := CreateLease(ctx, name, &Request{}); err == nil {
+				t.Errorf("expected an error for provider %q", name)
+			}
+		}
+	})
+}
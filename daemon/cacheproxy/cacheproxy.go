@@ -0,0 +1,386 @@
+// Package cacheproxy provides a caching HTTP proxy for the registry, so that
+// one daemon can serve the registry reads of many daemons on the same
+// network.
+package cacheproxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/facebookgo/httpdown"
+
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// DefaultTTL is how long responses are cached for, unless configured
+// otherwise.
+const DefaultTTL = time.Minute
+
+// maxEntries bounds the number of cached responses.
+const maxEntries = 10000
+
+// gzipThreshold is the smallest cached response worth compressing.
+const gzipThreshold = 1024
+
+// IdentityFunc returns the ID of the user or machine a registry auth token
+// belongs to.
+type IdentityFunc func(ctx context.Context, token string) (string, error)
+
+// RegistryIdentity returns an IdentityFunc that looks tokens up with the
+// registry's /self endpoint.
+func RegistryIdentity(client *registry.Client) IdentityFunc {
+	return func(ctx context.Context, token string) (string, error) {
+		self, err := client.Self.Get(ctx, token)
+		if err != nil {
+			return "", err
+		}
+
+		return self.Identity.GetID().String(), nil
+	}
+}
+
+// CacheProxy forwards requests to the registry, caching successful GET
+// responses.
+//
+// Registry responses depend on who is asking, so responses are cached per
+// identity rather than per token. Daemons logged in as the same machine share
+// cached responses, while every other identity has its own. Any successful
+// write clears the cache.
+type CacheProxy struct {
+	u        *url.URL
+	t        http.RoundTripper
+	ttl      time.Duration
+	identity IdentityFunc
+	proxy    *httputil.ReverseProxy
+
+	l net.Listener
+	s httpdown.Server
+
+	mutex    sync.Mutex
+	entries  map[string]*entry
+	tokens   map[string]*entry
+	inflight map[string]*call
+}
+
+type entry struct {
+	expires time.Time
+	status  int
+	header  http.Header
+	body    []byte
+
+	// gzipped is the compressed body, for clients that accept gzip encoded
+	// responses. It is nil for small responses.
+	gzipped []byte
+
+	// identity is set for token lookups.
+	identity string
+}
+
+// call is a registry request shared by every caller asking for the same
+// thing at the same time.
+type call struct {
+	done chan struct{}
+	resp *entry
+	err  error
+}
+
+// New returns a new CacheProxy for the registry at u.
+func New(u *url.URL, t http.RoundTripper, ttl time.Duration, identity IdentityFunc) *CacheProxy {
+	p := &CacheProxy{
+		u:        u,
+		t:        t,
+		ttl:      ttl,
+		identity: identity,
+		entries:  make(map[string]*entry),
+		tokens:   make(map[string]*entry),
+		inflight: make(map[string]*call),
+	}
+
+	p.proxy = &httputil.ReverseProxy{
+		Transport: t,
+		Director:  p.direct,
+	}
+
+	return p
+}
+
+// Listen serves the proxy on addr until it is closed. TLS is used if both a
+// certificate and key file are given.
+func (p *CacheProxy) Listen(addr, certFile, keyFile string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			l.Close()
+			return err
+		}
+
+		l = tls.NewListener(l, &tls.Config{Certificates: []tls.Certificate{cert}})
+	}
+
+	p.l = l
+
+	h := httpdown.HTTP{}
+	p.s = h.Serve(&http.Server{Handler: p}, l)
+	return p.s.Wait()
+}
+
+// Close gracefully stops the proxy.
+func (p *CacheProxy) Close() error {
+	if p.s == nil {
+		return nil
+	}
+	return p.s.Stop()
+}
+
+// Addr returns the address the proxy is listening on.
+func (p *CacheProxy) Addr() string {
+	if p.l == nil {
+		return ""
+	}
+	return p.l.Addr().String()
+}
+
+func (p *CacheProxy) direct(r *http.Request) {
+	r.URL.Scheme = p.u.Scheme
+	r.URL.Host = p.u.Host
+	r.Host = p.u.Host
+	r.URL.Path = strings.TrimSuffix(p.u.Path, "/") + r.URL.Path
+}
+
+func (p *CacheProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if r.Method != "GET" || token == "" {
+		p.forward(w, r)
+		return
+	}
+
+	id, err := p.lookupIdentity(r.Context(), token)
+	if err != nil {
+		// Let the registry explain what is wrong with the token.
+		p.proxy.ServeHTTP(w, r)
+		return
+	}
+
+	key := id + " " + r.Header.Get("X-Registry-Version") + " " + r.URL.RequestURI()
+	resp, err := p.get(key, r)
+	if err != nil {
+		log.Printf("Error proxying %s: %s", r.URL.Path, err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	for k, v := range resp.header {
+		w.Header()[k] = v
+	}
+
+	body := resp.body
+	if resp.gzipped != nil {
+		w.Header().Set("Vary", "Accept-Encoding")
+		if acceptsGzip(r) {
+			w.Header().Set("Content-Encoding", "gzip")
+			body = resp.gzipped
+		}
+	}
+
+	w.WriteHeader(resp.status)
+	w.Write(body)
+}
+
+// forward passes r straight through to the registry, clearing the cache if
+// it changed anything.
+func (p *CacheProxy) forward(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	p.proxy.ServeHTTP(rec, r)
+
+	if r.Method != "GET" && r.Method != "HEAD" && rec.status < 300 {
+		p.mutex.Lock()
+		p.entries = make(map[string]*entry)
+		p.mutex.Unlock()
+	}
+}
+
+// get returns the cached response for key, fetching it with r if it is
+// missing or expired. Concurrent requests for the same key share one fetch.
+func (p *CacheProxy) get(key string, r *http.Request) (*entry, error) {
+	p.mutex.Lock()
+	if e, ok := p.entries[key]; ok && time.Now().Before(e.expires) {
+		p.mutex.Unlock()
+		return e, nil
+	}
+
+	if c, ok := p.inflight[key]; ok {
+		p.mutex.Unlock()
+		<-c.done
+		return c.resp, c.err
+	}
+
+	c := &call{done: make(chan struct{})}
+	p.inflight[key] = c
+	p.mutex.Unlock()
+
+	c.resp, c.err = p.fetch(r)
+
+	p.mutex.Lock()
+	delete(p.inflight, key)
+	if c.err == nil && c.resp.status == http.StatusOK {
+		p.store(p.entries, key, c.resp)
+	}
+	p.mutex.Unlock()
+	close(c.done)
+
+	return c.resp, c.err
+}
+
+// fetch performs r against the registry, reading the whole response.
+func (p *CacheProxy) fetch(r *http.Request) (*entry, error) {
+	out := &http.Request{
+		Method: r.Method,
+		URL:    &url.URL{Path: r.URL.Path, RawQuery: r.URL.RawQuery},
+		Header: make(http.Header, len(r.Header)),
+		Body:   ioutil.NopCloser(&bytes.Buffer{}),
+	}
+	for k, v := range r.Header {
+		out.Header[k] = v
+	}
+	p.direct(out)
+
+	// The cached response is shared by clients that may not accept the same
+	// encodings. Let the transport negotiate and decode compression, and
+	// compress the response again when serving it.
+	out.Header.Del("Accept-Encoding")
+
+	// Waiters share this fetch, so it must not be canceled by whoever
+	// happened to start it.
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+	defer cancel()
+
+	resp, err := p.t.RoundTrip(out.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	for _, k := range []string{"Content-Type", "X-Request-Id"} {
+		if v := resp.Header.Get(k); v != "" {
+			header.Set(k, v)
+		}
+	}
+
+	e := &entry{
+		expires: time.Now().Add(p.ttl),
+		status:  resp.StatusCode,
+		header:  header,
+		body:    body,
+	}
+
+	if len(body) >= gzipThreshold {
+		e.gzipped, err = gzipBytes(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// acceptsGzip returns whether the client making r accepts gzip encoded
+// responses.
+func acceptsGzip(r *http.Request) bool {
+	for _, v := range r.Header["Accept-Encoding"] {
+		for _, enc := range strings.Split(v, ",") {
+			parts := strings.SplitN(enc, ";", 2)
+			if len(parts) == 2 && strings.TrimSpace(parts[1]) == "q=0" {
+				continue
+			}
+
+			enc = strings.TrimSpace(parts[0])
+			if enc == "gzip" || enc == "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// lookupIdentity returns the identity for token, asking the registry if it
+// has not been seen recently.
+func (p *CacheProxy) lookupIdentity(ctx context.Context, token string) (string, error) {
+	p.mutex.Lock()
+	e, ok := p.tokens[token]
+	p.mutex.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.identity, nil
+	}
+
+	id, err := p.identity(ctx, token)
+	if err != nil {
+		return "", err
+	}
+
+	p.mutex.Lock()
+	p.store(p.tokens, token, &entry{expires: time.Now().Add(p.ttl), identity: id})
+	p.mutex.Unlock()
+
+	return id, nil
+}
+
+// store adds e to m under key, dropping expired entries if m is full. If m is
+// still full, e is not stored. The mutex must be held.
+func (p *CacheProxy) store(m map[string]*entry, key string, e *entry) {
+	if len(m) >= maxEntries {
+		now := time.Now()
+		for k, v := range m {
+			if now.After(v.expires) {
+				delete(m, k)
+			}
+		}
+	}
+
+	if len(m) < maxEntries {
+		m[key] = e
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
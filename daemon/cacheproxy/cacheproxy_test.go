@@ -0,0 +1,255 @@
+package cacheproxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testProxy(t *testing.T, hits *int32) (*httptest.Server, func()) {
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+
+	u, err := url.Parse(registry.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity := func(ctx context.Context, token string) (string, error) {
+		switch token {
+		case "a1", "a2":
+			return "machine-a", nil
+		case "b":
+			return "machine-b", nil
+		}
+		return "", errors.New("bad token")
+	}
+
+	p := New(u, http.DefaultTransport, time.Minute, identity)
+	proxy := httptest.NewServer(p)
+
+	return proxy, func() {
+		proxy.Close()
+		registry.Close()
+	}
+}
+
+func request(t *testing.T, method, u, token string) {
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("%s %s: got status %d", method, u, resp.StatusCode)
+	}
+}
+
+func TestCacheProxy(t *testing.T) {
+	var hits int32
+	proxy, done := testProxy(t, &hits)
+	defer done()
+
+	check := func(want int32) {
+		if got := atomic.LoadInt32(&hits); got != want {
+			t.Errorf("registry hits: got %d want %d", got, want)
+		}
+	}
+
+	request(t, "GET", proxy.URL+"/keyrings?org_id=1", "a1")
+	check(1)
+
+	t.Run("same identity is cached", func(t *testing.T) {
+		request(t, "GET", proxy.URL+"/keyrings?org_id=1", "a2")
+		check(1)
+	})
+
+	t.Run("other identities are not shared", func(t *testing.T) {
+		request(t, "GET", proxy.URL+"/keyrings?org_id=1", "b")
+		check(2)
+	})
+
+	t.Run("writes clear the cache", func(t *testing.T) {
+		request(t, "POST", proxy.URL+"/credentials", "a1")
+		check(3)
+
+		request(t, "GET", proxy.URL+"/keyrings?org_id=1", "a1")
+		check(4)
+	})
+
+	t.Run("unknown tokens are passed through", func(t *testing.T) {
+		request(t, "GET", proxy.URL+"/keyrings?org_id=1", "c")
+		request(t, "GET", proxy.URL+"/keyrings?org_id=1", "c")
+		check(6)
+	})
+}
+
+func TestCacheProxyConcurrent(t *testing.T) {
+	var hits int32
+	proxy, done := testProxy(t, &hits)
+	defer done()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request(t, "GET", proxy.URL+"/claimtree?org_id=1", "a1")
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("registry hits: got %d want 1", got)
+	}
+}
+
+// largeBody is a registry response worth compressing, shaped like a list of
+// credentials.
+var largeBody = func() []byte {
+	b := &bytes.Buffer{}
+	b.WriteString("[")
+	for i := 0; i < 500; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(b, `{"id":"%032d","version":2,"body":{"name":"secret_%d",`+
+			`"pathexp":"/org/project/production/api/*/*","state":"set"}}`, i, i)
+	}
+	b.WriteString("]")
+	return b.Bytes()
+}()
+
+// gzipRegistry serves largeBody, compressed if the client accepts it.
+func gzipRegistry(t testing.TB) (*httptest.Server, *url.URL) {
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !acceptsGzip(r) {
+			w.Write(largeBody)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write(largeBody)
+		gz.Close()
+	}))
+
+	u, err := url.Parse(registry.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry, u
+}
+
+func identityA(ctx context.Context, token string) (string, error) {
+	return "machine-a", nil
+}
+
+func TestCacheProxyCompression(t *testing.T) {
+	registry, u := gzipRegistry(t)
+	defer registry.Close()
+
+	proxy := httptest.NewServer(New(u, http.DefaultTransport, time.Minute, identityA))
+	defer proxy.Close()
+
+	get := func(encoding string) []byte {
+		req, err := http.NewRequest("GET", proxy.URL+"/credentials", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer a1")
+		req.Header.Set("Accept-Encoding", encoding)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Header.Get("Content-Encoding") != "gzip" {
+			return body
+		}
+		if encoding != "gzip" {
+			t.Errorf("got a gzip response when asking for %s", encoding)
+		}
+
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		plain, err := ioutil.ReadAll(gz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(body) >= len(plain) {
+			t.Errorf("compressed body is not smaller: %d >= %d", len(body), len(plain))
+		}
+		return plain
+	}
+
+	for _, encoding := range []string{"gzip", "identity", "gzip"} {
+		if body := get(encoding); !bytes.Equal(body, largeBody) {
+			t.Errorf("%s: wrong body %q", encoding, body)
+		}
+	}
+}
+
+func BenchmarkCacheProxy(b *testing.B) {
+	registry, u := gzipRegistry(b)
+	defer registry.Close()
+
+	proxy := httptest.NewServer(New(u, http.DefaultTransport, time.Minute, identityA))
+	defer proxy.Close()
+
+	for _, encoding := range []string{"gzip", "identity"} {
+		b.Run(encoding, func(b *testing.B) {
+			var transferred int64
+			for i := 0; i < b.N; i++ {
+				req, err := http.NewRequest("GET", proxy.URL+"/credentials", nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				req.Header.Set("Authorization", "Bearer a1")
+				req.Header.Set("Accept-Encoding", encoding)
+
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					b.Fatal(err)
+				}
+				n, _ := io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+				transferred += n
+			}
+
+			b.SetBytes(int64(len(largeBody)))
+			b.Logf("%d bytes transferred per response", transferred/int64(b.N))
+		})
+	}
+}
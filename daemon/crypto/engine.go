@@ -4,9 +4,7 @@ package crypto
 import (
 	"context"
 	"crypto/rand"
-	"encoding/json"
 	"errors"
-	"strconv"
 
 	"github.com/dchest/blake2b"
 	"github.com/keybase/go-triplesec"
@@ -15,6 +13,7 @@ import (
 	"golang.org/x/crypto/nacl/secretbox"
 
 	"github.com/manifoldco/torus-cli/base64"
+	"github.com/manifoldco/torus-cli/canonical"
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/primitive"
 
@@ -344,6 +343,13 @@ func (e *Engine) WithUnboxer(ctx context.Context, encMec, mecNonce []byte,
 	return fn(&u)
 }
 
+// NewUnboxer returns an Unboxer for credentials in a keyring, given its
+// master encryption key, as decrypted by Unbox. It is for callers that keep
+// keyring keys, rather than decrypting them with WithUnboxer on every use.
+func NewUnboxer(mek []byte) Unboxer {
+	return &unboxerImpl{mek: mek}
+}
+
 // CloneMembership decrypts the given KeyringMember object, and creates another
 // for the targeted user.
 func (e *Engine) CloneMembership(ctx context.Context, encMec, mecNonce []byte, privKP *EncryptionKeyPair, encPubKey, targetPubKey []byte) ([]byte, []byte, error) {
@@ -355,6 +361,53 @@ func (e *Engine) CloneMembership(ctx context.Context, encMec, mecNonce []byte, p
 	return e.Box(ctx, mek, privKP, targetPubKey)
 }
 
+// CloneMemberships decrypts the given KeyringMember object, like
+// CloneMembership, and encrypts it for each of the targeted users. The private
+// key is only unsealed once, however many users there are.
+func (e *Engine) CloneMemberships(ctx context.Context, encMec, mecNonce []byte, privKP *EncryptionKeyPair,
+	encPubKey []byte, targetPubKeys [][]byte) ([][]byte, [][]byte, error) {
+
+	privKey, err := e.Unseal(ctx, privKP.Private, privKP.PNonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privkb := [32]byte{}
+	copy(privkb[:], privKey)
+
+	nonceb := [24]byte{}
+	copy(nonceb[:], mecNonce)
+
+	pubkb := [32]byte{}
+	copy(pubkb[:], encPubKey)
+
+	mek, success := box.Open([]byte{}, encMec, &nonceb, &pubkb, &privkb)
+	if !success {
+		return nil, nil, errors.New("Failed to decrypt ciphertext")
+	}
+
+	cts := make([][]byte, len(targetPubKeys))
+	nonces := make([][]byte, len(targetPubKeys))
+	for i, targetPubKey := range targetPubKeys {
+		err = ctxutil.ErrIfDone(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		nonce := [24]byte{}
+		_, err = rand.Read(nonce[:])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		copy(pubkb[:], targetPubKey)
+		cts[i] = box.Seal([]byte{}, mek, &nonce, &pubkb, &privkb)
+		nonces[i] = nonce[:]
+	}
+
+	return cts, nonces, nil
+}
+
 // GenerateKeyPairs generates and ed25519 signing key pair, and a curve25519
 // encryption key pair for the user, encrypting the private keys in
 // triplesec-v3 with the user's master key.
@@ -428,6 +481,25 @@ func (e *Engine) Verify(ctx context.Context, s SignatureKeyPair, b, sig []byte)
 	return ed25519.Verify(s.Public, b, sig), nil
 }
 
+// VerifySigned verifies that sig is a valid signature of the immutable body,
+// made by the holder of the given public signing key. The signed bytes are
+// computed the same way as when the body was originally signed.
+func (e *Engine) VerifySigned(ctx context.Context, body identity.Immutable,
+	sig *primitive.Signature, public []byte) (bool, error) {
+
+	if sig == nil || sig.Value == nil || len(public) != ed25519.PublicKeySize {
+		return false, nil
+	}
+
+	b, err := canonical.SigningBytes(body.Version(), body)
+	if err != nil {
+		return false, err
+	}
+
+	s := SignatureKeyPair{Public: ed25519.PublicKey(public)}
+	return e.Verify(ctx, s, b, *sig.Value)
+}
+
 func (e *Engine) signAndID(ctx context.Context, body identity.Immutable,
 	sigID *identity.ID, sigKP *SignatureKeyPair) (*identity.ID, *primitive.Signature, error) {
 
@@ -436,12 +508,12 @@ func (e *Engine) signAndID(ctx context.Context, body identity.Immutable,
 		return nil, nil, err
 	}
 
-	b, err := json.Marshal(&body)
+	b, err := canonical.SigningBytes(body.Version(), body)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	s, err := e.Sign(ctx, *sigKP, append([]byte(strconv.Itoa(body.Version())), b...))
+	s, err := e.Sign(ctx, *sigKP, b)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -507,6 +579,25 @@ func (e *Engine) ChangePassword(ctx context.Context, newPassword string) (*primi
 	return EncryptPasswordObject(ctx, newPassword, &currentMasterKey)
 }
 
+// WrapMasterKeys encrypts the current user's master key with each of the
+// given recovery codes.
+func (e *Engine) WrapMasterKeys(ctx context.Context, codes []string) ([]*primitive.MasterKey, error) {
+	masterKey, err := e.unsealMasterKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := make([]*primitive.MasterKey, len(codes))
+	for i, code := range codes {
+		wrapped[i], err = WrapMasterKey(ctx, code, masterKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return wrapped, nil
+}
+
 // deriveKey Derives a single use key from the given master key via blake2b
 // and a nonce.
 func deriveKey(ctx context.Context, mk, nonce []byte, size uint8) ([]byte, error) {
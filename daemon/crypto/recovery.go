@@ -0,0 +1,99 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/manifoldco/torus-cli/base32"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+const (
+	// recoveryCodeBytes is the amount of randomness in a recovery code,
+	// giving 24 base32 characters.
+	recoveryCodeBytes = 15
+
+	// recoveryCodeGroup is the number of characters between each dash in
+	// a recovery code, to make them easier to copy by hand.
+	recoveryCodeGroup = 4
+
+	// recoveryLookupPrefix separates the hash used to find a recovery code
+	// from any other use of the code.
+	recoveryLookupPrefix = "torus-recovery-lookup:"
+)
+
+// NewRecoveryCode returns a random, human readable recovery code.
+func NewRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeBytes)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	code := base32.EncodeToString(b)
+	groups := make([]string, 0, len(code)/recoveryCodeGroup)
+	for i := 0; i < len(code); i += recoveryCodeGroup {
+		groups = append(groups, code[i:i+recoveryCodeGroup])
+	}
+
+	return strings.Join(groups, "-"), nil
+}
+
+// NormalizeRecoveryCode returns code as it was generated, ignoring case,
+// whitespace, and dashes.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, code)
+
+	var groups []string
+	for i := 0; i < len(code); i += recoveryCodeGroup {
+		end := i + recoveryCodeGroup
+		if end > len(code) {
+			end = len(code)
+		}
+		groups = append(groups, code[i:end])
+	}
+
+	return strings.Join(groups, "-")
+}
+
+// RecoveryLookup returns the value the registry uses to find the master key
+// wrapped with a recovery code. It can't be used to recover the code, or the
+// key wrapped with it.
+func RecoveryLookup(code string) string {
+	sum := sha256.Sum256([]byte(recoveryLookupPrefix + NormalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// WrapMasterKey encrypts masterKey with a recovery code, the same way it is
+// encrypted with a password.
+func WrapMasterKey(ctx context.Context, code string, masterKey []byte) (*primitive.MasterKey, error) {
+	return CreateMasterKeyObject(ctx, []byte(NormalizeRecoveryCode(code)), &masterKey)
+}
+
+// UnwrapMasterKey decrypts a master key that was wrapped with a recovery
+// code.
+func UnwrapMasterKey(ctx context.Context, code string, m *primitive.MasterKey) ([]byte, error) {
+	if m == nil || m.Value == nil {
+		return nil, errors.New("missing master key")
+	}
+	if m.Alg != Triplesec {
+		return nil, errors.New("unknown master key alg: " + m.Alg)
+	}
+
+	ts, err := newTriplesec(ctx, []byte(NormalizeRecoveryCode(code)))
+	if err != nil {
+		return nil, err
+	}
+
+	return ts.Decrypt(*m.Value)
+}
@@ -0,0 +1,53 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRecoveryCode(t *testing.T) {
+	code, err := NewRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 29 {
+		t.Errorf("expected a 29 character code, got %q", code)
+	}
+
+	t.Run("normalizes typed codes", func(t *testing.T) {
+		typed := " " + strings.ToUpper(strings.Replace(code, "-", " ", -1)) + " "
+		if NormalizeRecoveryCode(typed) != code {
+			t.Errorf("expected %q, got %q", code, NormalizeRecoveryCode(typed))
+		}
+		if RecoveryLookup(typed) != RecoveryLookup(code) {
+			t.Error("lookup differs for the same code")
+		}
+	})
+
+	t.Run("wraps and unwraps the master key", func(t *testing.T) {
+		ctx := context.Background()
+		masterKey := []byte("a master key, nothing up my sleeve")
+		m, err := WrapMasterKey(ctx, code, masterKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := UnwrapMasterKey(ctx, code, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, masterKey) {
+			t.Error("master key not unwrapped")
+		}
+
+		other, err := NewRecoveryCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := UnwrapMasterKey(ctx, other, m); err == nil {
+			t.Error("expected an error unwrapping with another code")
+		}
+	})
+}
@@ -13,3 +13,39 @@ func ErrIfDone(ctx context.Context) error {
 		return nil
 	}
 }
+
+type breakGlassKey struct{}
+
+// WithBreakGlass returns a copy of ctx carrying the reason given for reading
+// secrets from frozen keyrings.
+func WithBreakGlass(ctx context.Context, reason string) context.Context {
+	if reason == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, breakGlassKey{}, reason)
+}
+
+// BreakGlass returns the reason given for reading secrets from frozen
+// keyrings, or an empty string if none was.
+func BreakGlass(ctx context.Context) string {
+	reason, _ := ctx.Value(breakGlassKey{}).(string)
+	return reason
+}
+
+type mfaCodeKey struct{}
+
+// WithMFACode returns a copy of ctx carrying a code from the user's
+// authenticator app, to send to the registry.
+func WithMFACode(ctx context.Context, code string) context.Context {
+	if code == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, mfaCodeKey{}, code)
+}
+
+// MFACode returns the code from the user's authenticator app carried by ctx,
+// or an empty string if there is none.
+func MFACode(ctx context.Context) string {
+	code, _ := ctx.Value(mfaCodeKey{}).(string)
+	return code
+}
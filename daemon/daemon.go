@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/nightlyone/lockfile"
 
@@ -13,10 +15,13 @@ import (
 	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/identity"
 
+	"github.com/manifoldco/torus-cli/daemon/cacheproxy"
 	"github.com/manifoldco/torus-cli/daemon/crypto"
 	"github.com/manifoldco/torus-cli/daemon/db"
 	"github.com/manifoldco/torus-cli/daemon/logic"
 	"github.com/manifoldco/torus-cli/daemon/registry"
+	"github.com/manifoldco/torus-cli/daemon/resolver"
+	"github.com/manifoldco/torus-cli/daemon/sentinel"
 	"github.com/manifoldco/torus-cli/daemon/session"
 	"github.com/manifoldco/torus-cli/daemon/socket"
 )
@@ -24,13 +29,23 @@ import (
 // Daemon is the torus coprocess that contains session secrets, handles
 // cryptographic operations, and communication with the registry.
 type Daemon struct {
-	proxy       *socket.AuthProxy
-	lock        lockfile.Lockfile // actually a string
-	session     session.Session
-	config      *config.Config
-	db          *db.DB
-	logic       *logic.Engine
-	hasShutdown bool
+	proxy          *socket.AuthProxy
+	rpc            *socket.RPCServer
+	lock           lockfile.Lockfile // actually a string
+	session        session.Session
+	config         *config.Config
+	db             *db.DB
+	logic          *logic.Engine
+	client         *registry.Client
+	transport      *http.Transport
+	cacheProxy     *cacheproxy.CacheProxy
+	cacheProxyOpts CacheProxyOptions
+	resolver       *resolver.Server
+	resolverAddr   string
+	sentinel       *sentinel.Sentinel
+	hasShutdown    bool
+
+	stopBackground context.CancelFunc
 }
 
 // New creates a new Daemon.
@@ -71,25 +86,116 @@ func New(cfg *config.Config, groupShared bool) (*Daemon, error) {
 	client := registry.NewClient(cfg.RegistryURI.String(), cfg.APIVersion,
 		cfg.Version, session, transport)
 	logic := logic.NewEngine(cfg, session, db, cryptoEngine, client)
+	addBackends(cfg, logic, session, transport)
 
 	proxy, err := socket.NewAuthProxy(cfg, session, db, transport, client, logic, groupShared)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create auth proxy: %s", err)
 	}
 
+	rpc, err := socket.NewRPCServer(cfg, session, logic, groupShared)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create rpc server: %s", err)
+	}
+
 	daemon := &Daemon{
 		proxy:       proxy,
+		rpc:         rpc,
 		lock:        lock,
 		session:     session,
 		config:      cfg,
 		db:          db,
 		logic:       logic,
+		client:      client,
+		transport:   transport,
 		hasShutdown: false,
 	}
 
 	return daemon, nil
 }
 
+// addBackends configures engine to use the credential backends set in the
+// preferences.
+func addBackends(cfg *config.Config, engine *logic.Engine, s session.Session,
+	transport *http.Transport) {
+
+	for _, b := range cfg.Backends {
+		switch b.Type {
+		case "file":
+			engine.AddBackend(b.Name, b.PathExps, logic.NewFileBackend(engine, b.File))
+		case "registry":
+			client := registry.NewClient(b.RegistryURI.String(), cfg.APIVersion,
+				cfg.Version, s, transport)
+			engine.AddBackend(b.Name, b.PathExps, logic.NewRegistryBackend(engine, client))
+		}
+		log.Printf("Using %s backend %s for %v", b.Type, b.Name, b.PathExps)
+	}
+}
+
+// CacheProxyOptions configure a daemon to also serve as a caching proxy of
+// the registry for other daemons.
+type CacheProxyOptions struct {
+	// Addr is the TCP address to listen on.
+	Addr string
+
+	// CertFile and KeyFile are used to serve over TLS, when both are set.
+	CertFile string
+	KeyFile  string
+
+	// TTL is how long registry responses are cached for.
+	TTL time.Duration
+}
+
+// EnableCacheProxy configures the daemon to run a caching proxy of the
+// registry alongside its domain socket, once it is Run.
+func (d *Daemon) EnableCacheProxy(opts CacheProxyOptions) {
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = cacheproxy.DefaultTTL
+	}
+
+	d.cacheProxy = cacheproxy.New(d.config.RegistryURI, d.transport, ttl,
+		cacheproxy.RegistryIdentity(d.client))
+	d.cacheProxyOpts = opts
+}
+
+// ResolveAPIOptions configure a daemon to serve the resolve API, for
+// programs like a Terraform provider to read secrets with.
+type ResolveAPIOptions struct {
+	// Addr is the loopback TCP address to listen on.
+	Addr string
+
+	// Rate is the most requests served per second.
+	Rate float64
+}
+
+// EnableResolveAPI configures the daemon to serve the resolve API alongside
+// its domain socket, once it is Run. Requests are authenticated with the
+// token in the config's resolve token file, which is created if needed.
+func (d *Daemon) EnableResolveAPI(opts ResolveAPIOptions) error {
+	token, err := resolver.LoadToken(d.config.ResolveTokenPath)
+	if err != nil {
+		return err
+	}
+
+	d.resolver = resolver.New(d.logic, d.session.Self, token, opts.Rate)
+	d.resolverAddr = opts.Addr
+	return nil
+}
+
+// EnableSentinel configures the daemon to continuously verify its orgs,
+// keyrings and cache in the background, once it is Run. If cfg.Exit is set,
+// Run returns sentinel.ErrAnomaly when an anomaly is found.
+func (d *Daemon) EnableSentinel(cfg sentinel.Config) error {
+	s, err := sentinel.New(d.logic, cfg)
+	if err != nil {
+		return err
+	}
+
+	d.sentinel = s
+	return nil
+}
+
 // Addr returns the domain socket the Daemon is listening on.
 func (d *Daemon) Addr() string {
 	return d.proxy.Addr()
@@ -142,7 +248,62 @@ func (d *Daemon) Run() error {
 		}
 	}
 
-	return d.proxy.Listen()
+	if !hasEmail && !hasTokenID {
+		err := d.logic.Session.Unlock(context.Background())
+		if err != nil {
+			log.Printf("Could not login from the keychain: %s", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.stopBackground = cancel
+	go d.logic.RunPrefetch(ctx)
+	go d.logic.RunBroker(ctx)
+	go d.logic.Leases.Run(ctx)
+
+	done := make(chan error, 2)
+	if d.sentinel != nil {
+		go func() {
+			if err := d.sentinel.Run(ctx); err != nil {
+				done <- err
+			}
+		}()
+	}
+
+	if d.cacheProxy != nil {
+		opts := d.cacheProxyOpts
+		go func() {
+			log.Printf("Caching registry proxy listening on %s", opts.Addr)
+			err := d.cacheProxy.Listen(opts.Addr, opts.CertFile, opts.KeyFile)
+			if err != nil {
+				log.Printf("Error running caching registry proxy: %s", err)
+			}
+		}()
+	}
+
+	if d.resolver != nil {
+		addr := d.resolverAddr
+		go func() {
+			log.Printf("Resolve API listening on %s", addr)
+			err := d.resolver.Listen(addr)
+			if err != nil {
+				log.Printf("Error running resolve API: %s", err)
+			}
+		}()
+	}
+
+	go func() {
+		err := d.rpc.Listen()
+		if err != nil {
+			log.Printf("Error running rpc server: %s", err)
+		}
+	}()
+
+	go func() {
+		done <- d.proxy.Listen()
+	}()
+
+	return <-done
 }
 
 // Shutdown gracefully shuts down the daemon.
@@ -152,10 +313,30 @@ func (d *Daemon) Shutdown() error {
 	}
 
 	d.hasShutdown = true
+	if d.stopBackground != nil {
+		d.stopBackground()
+	}
+
 	if err := d.lock.Unlock(); err != nil {
 		return fmt.Errorf("Could not unlock: %s", err)
 	}
 
+	if d.cacheProxy != nil {
+		if err := d.cacheProxy.Close(); err != nil {
+			return fmt.Errorf("Could not stop caching registry proxy: %s", err)
+		}
+	}
+
+	if d.resolver != nil {
+		if err := d.resolver.Close(); err != nil {
+			return fmt.Errorf("Could not stop resolve API: %s", err)
+		}
+	}
+
+	if err := d.rpc.Close(); err != nil {
+		return fmt.Errorf("Could not stop rpc server: %s", err)
+	}
+
 	if err := d.proxy.Close(); err != nil {
 		return fmt.Errorf("Could not stop http proxy: %s", err)
 	}
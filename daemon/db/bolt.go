@@ -0,0 +1,93 @@
+package db
+
+import (
+	"github.com/boltdb/bolt"
+)
+
+// boltStore is a Store kept in a BoltDB file.
+type boltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens, or creates, the BoltDB file at path as a Store.
+func NewBoltStore(path string) (Store, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Get(bucket, key []byte) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+
+		// Values are only valid during the transaction, so copy them out.
+		if v := b.Get(key); v != nil {
+			value = append([]byte{}, v...)
+		}
+		return nil
+	})
+
+	return value, err
+}
+
+func (s *boltStore) Write(writes ...Write) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, w := range writes {
+			b, err := tx.CreateBucketIfNotExists(w.Bucket)
+			if err != nil {
+				return err
+			}
+
+			err = b.Put(w.Key, w.Value)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *boltStore) ForEach(bucket []byte, fn func(key, value []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			return fn(append([]byte{}, k...), append([]byte{}, v...))
+		})
+	})
+}
+
+func (s *boltStore) Delete(bucket, key []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		return b.Delete(key)
+	})
+}
+
+func (s *boltStore) DeleteBucket(bucket []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := tx.DeleteBucket(bucket)
+		if err == bolt.ErrBucketNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
@@ -10,26 +10,27 @@ import (
 	"log"
 	"os"
 
-	"github.com/boltdb/bolt"
-
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
 )
 
-var schemaVersion = []byte{0x01}
+var (
+	schemaVersion = []byte{0x01}
+
+	metaBucket = []byte("meta")
+	versionKey = []byte("version")
+)
 
 // DB is a persistent store for encrypted or non-sensitvie values.
 type DB struct {
-	db *bolt.DB
+	store Store
 }
 
 // NewDB creates a new db or opens an existing db at the given path.
 // If the db already exists but has a mismatched version, it will be cleared
 // before being returned.
 func NewDB(path string) (*DB, error) {
-	db := &DB{}
-
-	valid, err := db.initBolt(path)
+	db, valid, err := openBolt(path)
 	if valid && err == nil {
 		return db, nil
 	}
@@ -38,13 +39,17 @@ func NewDB(path string) (*DB, error) {
 		log.Print("DB schema version is incorrect. Clearing db")
 	}
 
+	if db != nil {
+		db.Close()
+	}
+
 	err = os.Remove(path)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to remove db! Please manually remove %s",
 			path)
 	}
 
-	valid, err = db.initBolt(path)
+	db, valid, err = openBolt(path)
 	if err != nil {
 		return nil, err
 	}
@@ -56,88 +61,117 @@ func NewDB(path string) (*DB, error) {
 	return db, nil
 }
 
+// NewStoreDB returns a DB kept in the given Store, such as one from
+// NewMemoryStore. It returns an error if the store holds a db of another
+// schema version.
+func NewStoreDB(store Store) (*DB, error) {
+	db := &DB{store: store}
+
+	valid, err := db.checkMeta()
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, fmt.Errorf("Incorrect db schema version")
+	}
+
+	return db, nil
+}
+
 // Close closes all db resources
 func (db *DB) Close() error {
-	return db.db.Close()
+	return db.store.Close()
 }
 
-// initBolt initializes the backing bolt db, checking that the metadata schema
+// openBolt opens the bolt db at path, checking that the metadata schema
 // version matches what we expect
-func (db *DB) initBolt(path string) (bool, error) {
-	var err error
-	db.db, err = bolt.Open(path, 0600, nil)
+func openBolt(path string) (*DB, bool, error) {
+	store, err := NewBoltStore(path)
 	if err != nil {
-		return false, err
+		return nil, false, err
 	}
 
-	return db.checkMeta()
+	db := &DB{store: store}
+	valid, err := db.checkMeta()
+	return db, valid, err
 }
 
 // checkMeta check's the db's metadata, ensuring the version of the db is
 // correct, or setting it if it does not exist.
 func (db *DB) checkMeta() (bool, error) {
-	var version []byte
-	err := db.db.Update(func(tx *bolt.Tx) error {
-		meta, err := tx.CreateBucketIfNotExists([]byte("meta"))
-		if err != nil {
-			return err
-		}
-
-		version = meta.Get([]byte("version"))
-		if version != nil {
-			return nil
-		}
+	version, err := db.store.Get(metaBucket, versionKey)
+	if err != nil {
+		return false, err
+	}
 
-		err = meta.Put([]byte("version"), schemaVersion)
+	if version == nil {
+		version = schemaVersion
+		err = db.store.Write(Write{Bucket: metaBucket, Key: versionKey, Value: version})
 		if err != nil {
-			return err
+			return false, err
 		}
-		version = schemaVersion
-		return nil
-	})
+	}
 
-	return bytes.Equal(version, schemaVersion), err
+	return bytes.Equal(version, schemaVersion), nil
 }
 
 // Set stores the serialized value of env into the db, under key id.
 // Stored values are grouped by their type.
 func (db *DB) Set(envs ...envelope.Envelope) error {
-	return db.db.Update(func(tx *bolt.Tx) error {
-		for _, env := range envs {
-			id := env.GetID()
-
-			b, err := json.Marshal(env)
-			if err != nil {
-				return err
-			}
-
-			bucket, err := tx.CreateBucketIfNotExists([]byte{id.Type()})
-			if err != nil {
-				return err
-			}
-			err = bucket.Put(id[:], b)
-			if err != nil {
-				return err
-			}
+	writes := make([]Write, len(envs))
+	for i, env := range envs {
+		id := env.GetID()
+
+		b, err := json.Marshal(env)
+		if err != nil {
+			return err
 		}
 
-		return nil
-	})
+		writes[i] = Write{Bucket: []byte{id.Type()}, Key: id[:], Value: b}
+	}
+
+	return db.store.Write(writes...)
 }
 
 // Get returns the value of id in env. It returns an error if id does not exist.
 func (db *DB) Get(id *identity.ID, env envelope.Envelope) error {
-	return db.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte{id.Type()})
-		if bucket == nil {
-			return errors.New("ID not found")
-		}
+	b, err := db.store.Get([]byte{id.Type()}, id[:])
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return errors.New("ID not found")
+	}
 
-		b := bucket.Get(id[:])
-		if b == nil {
-			return errors.New("ID not found")
-		}
+	return json.Unmarshal(b, env)
+}
+
+// Put stores value under key in the named bucket, for values that are not
+// envelopes.
+func (db *DB) Put(bucket, key string, value []byte) error {
+	return db.store.Write(Write{Bucket: []byte(bucket), Key: []byte(key), Value: value})
+}
+
+// Fetch returns the value stored by Put under key in the named bucket, or nil
+// if there is none.
+func (db *DB) Fetch(bucket, key string) ([]byte, error) {
+	return db.store.Get([]byte(bucket), []byte(key))
+}
+
+// Delete removes the value stored by Put under key in the named bucket.
+func (db *DB) Delete(bucket, key string) error {
+	return db.store.Delete([]byte(bucket), []byte(key))
+}
+
+// Clear removes every value stored by Put in the named bucket.
+func (db *DB) Clear(bucket string) error {
+	return db.store.DeleteBucket([]byte(bucket))
+}
 
-		return json.Unmarshal(b, env)
+// ForEach calls fn with every key and value stored by Put in the named bucket,
+// in key order. It stops at the first error returned by fn.
+func (db *DB) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	return db.store.ForEach([]byte(bucket), func(k, v []byte) error {
+		return fn(string(k), v)
 	})
 }
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "torus-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bolt, err := NewBoltStore(filepath.Join(dir, "daemon.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stores := []struct {
+		name  string
+		store Store
+	}{
+		{"bolt", bolt},
+		{"memory", NewMemoryStore()},
+	}
+
+	for _, tc := range stores {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := NewStoreDB(tc.store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			v, err := db.Fetch("offline", "missing")
+			if err != nil || v != nil {
+				t.Errorf("got %q, %v for a missing key", v, err)
+			}
+
+			for _, k := range []string{"b", "a", "c"} {
+				err = db.Put("offline", k, []byte("value "+k))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			v, err = db.Fetch("offline", "b")
+			if err != nil || string(v) != "value b" {
+				t.Errorf("got %q, %v, want value b", v, err)
+			}
+
+			var keys string
+			err = db.ForEach("offline", func(k string, v []byte) error {
+				keys += k
+				return nil
+			})
+			if err != nil || keys != "abc" {
+				t.Errorf("got keys %q, %v, want abc in order", keys, err)
+			}
+
+			err = db.Delete("offline", "a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, _ = db.Fetch("offline", "a")
+			if v != nil {
+				t.Error("value remains after deleting it")
+			}
+
+			err = db.Clear("offline")
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, _ = db.Fetch("offline", "b")
+			if v != nil {
+				t.Error("value remains after clearing its bucket")
+			}
+			if err = db.Clear("offline"); err != nil {
+				t.Errorf("clearing a missing bucket failed: %s", err)
+			}
+		})
+	}
+}
+
+func TestSchemaVersion(t *testing.T) {
+	store := NewMemoryStore()
+	store.Write(Write{Bucket: metaBucket, Key: versionKey, Value: []byte{0xff}})
+
+	if _, err := NewStoreDB(store); err == nil {
+		t.Error("expected an error opening a db of another schema version")
+	}
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"sort"
+	"sync"
+)
+
+// memoryStore is a Store that only lives as long as the process. It is
+// useful for tests, and for daemons that should leave nothing on disk.
+type memoryStore struct {
+	mutex   sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+// NewMemoryStore returns an empty Store held in memory.
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: make(map[string]map[string][]byte)}
+}
+
+func (s *memoryStore) Get(bucket, key []byte) ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	v, ok := s.buckets[string(bucket)][string(key)]
+	if !ok {
+		return nil, nil
+	}
+
+	return append([]byte{}, v...), nil
+}
+
+func (s *memoryStore) Write(writes ...Write) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, w := range writes {
+		b, ok := s.buckets[string(w.Bucket)]
+		if !ok {
+			b = make(map[string][]byte)
+			s.buckets[string(w.Bucket)] = b
+		}
+
+		b[string(w.Key)] = append([]byte{}, w.Value...)
+	}
+
+	return nil
+}
+
+func (s *memoryStore) ForEach(bucket []byte, fn func(key, value []byte) error) error {
+	// Copy the bucket first, so fn may write to the store.
+	s.mutex.RLock()
+	b := s.buckets[string(bucket)]
+	keys := make([]string, 0, len(b))
+	values := make(map[string][]byte, len(b))
+	for k, v := range b {
+		keys = append(keys, k)
+		values[k] = append([]byte{}, v...)
+	}
+	s.mutex.RUnlock()
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		err := fn([]byte(k), values[k])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStore) Delete(bucket, key []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.buckets[string(bucket)], string(key))
+	return nil
+}
+
+func (s *memoryStore) DeleteBucket(bucket []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.buckets, string(bucket))
+	return nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
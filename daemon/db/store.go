@@ -0,0 +1,38 @@
+package db
+
+// Store is a backend for a DB, holding values under keys in named buckets.
+//
+// Stores only hold bytes. Everything the daemon persists goes through a DB,
+// so a Store can be swapped out, or wrapped, without touching the code that
+// uses it.
+type Store interface {
+	// Get returns the value of key in bucket, or nil if there is none. The
+	// returned value is owned by the caller.
+	Get(bucket, key []byte) ([]byte, error)
+
+	// Write stores every value in writes, creating their buckets as needed.
+	// Either all of them are stored, or none are.
+	Write(writes ...Write) error
+
+	// ForEach calls fn with every key and value in bucket, in key order. It
+	// stops at the first error returned by fn.
+	ForEach(bucket []byte, fn func(key, value []byte) error) error
+
+	// Delete removes key from bucket. Deleting a key that does not exist is
+	// not an error.
+	Delete(bucket, key []byte) error
+
+	// DeleteBucket removes bucket and everything in it. Deleting a bucket
+	// that does not exist is not an error.
+	DeleteBucket(bucket []byte) error
+
+	// Close releases the Store's resources.
+	Close() error
+}
+
+// Write is a value to store in a bucket, as part of Store.Write.
+type Write struct {
+	Bucket []byte
+	Key    []byte
+	Value  []byte
+}
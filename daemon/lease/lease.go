@@ -0,0 +1,203 @@
+// Package lease holds secrets handed to processes started by torus run, for
+// as long as their lease lasts, so they needn't be put in the process's
+// environment, where they can be read from /proc/<pid>/environ.
+//
+// The values of a lease are kept in memory that is locked, so it's never
+// swapped to disk, and are wiped once the lease is revoked or expires. A
+// lease can only be read or revoked with the token it was created with.
+package lease
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxTTL is the longest a lease may last.
+const MaxTTL = 24 * time.Hour
+
+// tick is how often expired leases are revoked.
+const tick = time.Second
+
+// ErrNotFound is returned when a lease doesn't exist, has expired, or the
+// token given for it is wrong.
+var ErrNotFound = errors.New("lease not found")
+
+// Var is a variable held by a lease.
+type Var struct {
+	Name  string
+	Value string
+}
+
+// Lease identifies a lease, once it's been created.
+type Lease struct {
+	ID        string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// lease is a lease as held by a Store. Its variables are encoded in buf as
+// NAME=VALUE entries, each ended by a NUL byte, as in /proc/<pid>/environ.
+type lease struct {
+	token     []byte
+	expiresAt time.Time
+	buf       []byte
+}
+
+// Store holds leases in memory.
+type Store struct {
+	mutex  sync.Mutex
+	leases map[string]*lease
+}
+
+// NewStore returns an empty Store.
+func NewStore() *Store {
+	return &Store{leases: make(map[string]*lease)}
+}
+
+// Create creates a lease holding vars, that expires after ttl.
+func (s *Store) Create(vars []Var, ttl time.Duration) (*Lease, error) {
+	if ttl <= 0 || ttl > MaxTTL {
+		return nil, errors.New("lease ttl must be positive, and at most " + MaxTTL.String())
+	}
+
+	size := 0
+	for _, v := range vars {
+		if v.Name == "" || strings.ContainsAny(v.Name, "=\x00") ||
+			strings.ContainsRune(v.Value, 0) {
+			return nil, errors.New("invalid variable " + v.Name)
+		}
+		size += len(v.Name) + len(v.Value) + 2
+	}
+
+	random := make([]byte, 40)
+	_, err := rand.Read(random)
+	if err != nil {
+		return nil, err
+	}
+	id, token := hex.EncodeToString(random[:8]), hex.EncodeToString(random[8:])
+
+	buf, err := lockedAlloc(size)
+	if err != nil {
+		return nil, err
+	}
+
+	n := 0
+	for _, v := range vars {
+		n += copy(buf[n:], v.Name)
+		buf[n] = '='
+		n++
+		n += copy(buf[n:], v.Value)
+		buf[n] = 0
+		n++
+	}
+
+	l := &lease{
+		token:     []byte(token),
+		expiresAt: time.Now().Add(ttl),
+		buf:       buf,
+	}
+
+	s.mutex.Lock()
+	s.leases[id] = l
+	s.mutex.Unlock()
+
+	return &Lease{ID: id, Token: token, ExpiresAt: l.expiresAt}, nil
+}
+
+// find returns the unexpired lease with the given id and token.
+func (s *Store) find(id, token string) *lease {
+	l, ok := s.leases[id]
+	if !ok || !hmac.Equal(l.token, []byte(token)) || !time.Now().Before(l.expiresAt) {
+		return nil
+	}
+	return l
+}
+
+// Get returns the variables held by the lease with the given id and token.
+func (s *Store) Get(id, token string) ([]Var, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	l := s.find(id, token)
+	if l == nil {
+		return nil, ErrNotFound
+	}
+
+	vars := []Var{}
+	for _, entry := range bytes.Split(l.buf, []byte{0}) {
+		i := bytes.IndexByte(entry, '=')
+		if i == -1 {
+			continue
+		}
+		vars = append(vars, Var{Name: string(entry[:i]), Value: string(entry[i+1:])})
+	}
+
+	return vars, nil
+}
+
+// Revoke revokes the lease with the given id and token, wiping its values.
+func (s *Store) Revoke(id, token string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.find(id, token) == nil {
+		return ErrNotFound
+	}
+
+	s.remove(id)
+	return nil
+}
+
+// RevokeAll revokes every lease, such as when the user logs out.
+func (s *Store) RevokeAll() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id := range s.leases {
+		s.remove(id)
+	}
+}
+
+// revokeExpired revokes the leases that expired before now.
+func (s *Store) revokeExpired(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id, l := range s.leases {
+		if !now.Before(l.expiresAt) {
+			s.remove(id)
+		}
+	}
+}
+
+// remove wipes and forgets the lease with the given id. The caller must hold
+// the mutex.
+func (s *Store) remove(id string) {
+	l := s.leases[id]
+	delete(s.leases, id)
+	lockedFree(l.buf)
+}
+
+// Run revokes leases as they expire. It blocks until ctx is done, when every
+// lease is revoked.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.RevokeAll()
+			return
+		case now := <-ticker.C:
+			s.revokeExpired(now)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package lease
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore()
+	vars := []Var{
+		{Name: "DATABASE_URL", Value: "postgres://db/app?sslmode=require"},
+		{Name: "EMPTY", Value: ""},
+		{Name: "MULTILINE", Value: "a=b\nc"},
+	}
+
+	l, err := s.Create(vars, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("get", func(t *testing.T) {
+		got, err := s.Get(l.ID, l.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, vars) {
+			t.Errorf("got %v, want %v", got, vars)
+		}
+	})
+
+	t.Run("wrong token", func(t *testing.T) {
+		if _, err := s.Get(l.ID, l.Token[1:]); err != ErrNotFound {
+			t.Errorf("got %v, want ErrNotFound", err)
+		}
+		if err := s.Revoke(l.ID, ""); err != ErrNotFound {
+			t.Errorf("got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		if err := s.Revoke(l.ID, l.Token); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Get(l.ID, l.Token); err != ErrNotFound {
+			t.Errorf("got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		l, err := s.Create(vars, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s.revokeExpired(time.Now())
+		if _, err := s.Get(l.ID, l.Token); err != nil {
+			t.Errorf("lease revoked before expiring: %s", err)
+		}
+
+		s.revokeExpired(l.ExpiresAt)
+		if _, ok := s.leases[l.ID]; ok {
+			t.Error("expired lease was not revoked")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := s.Create([]Var{{Name: "A=B", Value: "c"}}, time.Minute); err == nil {
+			t.Error("expected error for name containing =")
+		}
+		if _, err := s.Create(vars, MaxTTL+time.Second); err == nil {
+			t.Error("expected error for ttl over MaxTTL")
+		}
+	})
+}
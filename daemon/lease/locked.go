@@ -0,0 +1,44 @@
+//go:build !windows
+// +build !windows
+
+package lease
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// lockedAlloc returns size bytes of memory, mapped outside of the Go heap so
+// the runtime never copies it, and locked so it's never swapped to disk.
+func lockedAlloc(size int) ([]byte, error) {
+	if size == 0 {
+		return nil, nil
+	}
+
+	buf, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE,
+		syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Mlock(buf)
+	if err != nil {
+		syscall.Munmap(buf)
+		return nil, fmt.Errorf("could not lock memory, check the memlock limit (ulimit -l): %s", err)
+	}
+
+	return buf, nil
+}
+
+// lockedFree wipes and releases memory returned by lockedAlloc.
+func lockedFree(buf []byte) {
+	if buf == nil {
+		return
+	}
+
+	for i := range buf {
+		buf[i] = 0
+	}
+	syscall.Munlock(buf)
+	syscall.Munmap(buf)
+}
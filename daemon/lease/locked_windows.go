@@ -0,0 +1,13 @@
+package lease
+
+// lockedAlloc returns size bytes of memory. Memory isn't locked on Windows.
+func lockedAlloc(size int) ([]byte, error) {
+	return make([]byte, size), nil
+}
+
+// lockedFree wipes memory returned by lockedAlloc.
+func lockedFree(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
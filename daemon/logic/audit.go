@@ -0,0 +1,463 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/crypto"
+	"github.com/manifoldco/torus-cli/daemon/observer"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// keyringAuditor checks the keyrings, keyring members, and credentials of a
+// single org against the org's public keys.
+type keyringAuditor struct {
+	engine   *Engine
+	segments map[identity.ID]*apitypes.PublicKeySegment
+	keypairs *crypto.KeyPairs
+	result   *apitypes.KeyringAudit
+}
+
+// AuditKeyrings walks every keyring the current user or machine can see in
+// the given org, verifying the signatures of keyrings, their members, and
+// credentials, and checking that credential nonces and version histories are
+// consistent.
+//
+// Only our own keyring memberships can be decrypted. Those are checked by
+// decrypting them; everyone else's are checked against the public keys they
+// claim to be encrypted for.
+func (e *Engine) AuditKeyrings(ctx context.Context, notifier *observer.Notifier,
+	orgID *identity.ID) (*apitypes.KeyringAudit, error) {
+
+	n := notifier.Notifier(3)
+
+	org, err := e.client.Orgs.Get(ctx, orgID)
+	if err != nil {
+		log.Printf("Error retrieving org: %s", err)
+		return nil, err
+	}
+
+	projects, err := e.client.Projects.List(ctx, orgID)
+	if err != nil {
+		log.Printf("Error retrieving projects: %s", err)
+		return nil, err
+	}
+
+	var graphs []registry.CredentialGraph
+	for _, project := range projects {
+		pg, err := e.client.CredentialGraph.Search(ctx,
+			"/"+org.Body.Name+"/"+project.Body.Name+"/*/*/*/*", e.session.AuthID())
+		if err != nil {
+			log.Printf("Error retrieving credential graphs: %s", err)
+			return nil, err
+		}
+		graphs = append(graphs, pg...)
+	}
+
+	n.Notify(observer.Progress, "Keyrings retrieved", true)
+
+	trees, err := e.client.ClaimTree.List(ctx, orgID, nil)
+	if err != nil {
+		log.Printf("Error retrieving claim tree: %s", err)
+		return nil, err
+	}
+
+	_, _, kp, err := fetchKeyPairs(ctx, e.client, orgID)
+	if err != nil {
+		log.Printf("Error fetching keypairs: %s", err)
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Public keys retrieved", true)
+
+	a := &keyringAuditor{
+		engine:   e,
+		segments: make(map[identity.ID]*apitypes.PublicKeySegment),
+		keypairs: kp,
+		result:   &apitypes.KeyringAudit{Issues: []apitypes.KeyringAuditIssue{}},
+	}
+
+	for _, tree := range trees {
+		for i := range tree.PublicKeys {
+			segment := &tree.PublicKeys[i]
+			a.segments[*segment.PublicKey.ID] = segment
+		}
+	}
+
+	err = a.audit(ctx, graphs)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Keyrings audited", true)
+
+	return a.result, nil
+}
+
+func (a *keyringAuditor) audit(ctx context.Context, graphs []registry.CredentialGraph) error {
+	// All versions of the keyring for a path expression are needed to
+	// follow credential histories across keyring rotations.
+	byPathExp := make(map[string][]registry.CredentialGraph)
+	for _, graph := range graphs {
+		pe := graph.GetKeyring().PathExp().String()
+		byPathExp[pe] = append(byPathExp[pe], graph)
+	}
+
+	pathExps := make([]string, 0, len(byPathExp))
+	for pe := range byPathExp {
+		pathExps = append(pathExps, pe)
+	}
+	sort.Strings(pathExps)
+
+	for _, pe := range pathExps {
+		pgraphs := byPathExp[pe]
+		sort.Sort(graphSorter(pgraphs))
+
+		for _, graph := range pgraphs {
+			err := a.auditKeyring(ctx, graph)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := a.auditCredentials(ctx, pgraphs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *keyringAuditor) report(keyring envelope.KeyringInf, subject, problem string) {
+	a.result.Issues = append(a.result.Issues, apitypes.KeyringAuditIssue{
+		KeyringID: keyring.GetID(),
+		PathExp:   keyring.PathExp().String(),
+		Subject:   subject,
+		Problem:   problem,
+	})
+}
+
+// verify checks that body was signed by the given signature, which must have
+// been made with a signing key from the org. It returns a description of any
+// problem found.
+func (a *keyringAuditor) verify(ctx context.Context, body identity.Immutable,
+	sig *primitive.Signature) (string, error) {
+
+	if sig.PublicKeyID == nil {
+		return "is not signed", nil
+	}
+
+	signer, ok := a.segments[*sig.PublicKeyID]
+	if !ok {
+		return "is signed by an unknown key", nil
+	}
+
+	if signer.PublicKey.Body.KeyType != primitive.SigningKeyType {
+		return "is signed by a key that is not a signing key", nil
+	}
+
+	ok, err := a.engine.crypto.VerifySigned(ctx, body, sig, *signer.PublicKey.Body.Key.Value)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "has an invalid signature", nil
+	}
+
+	return "", nil
+}
+
+func (a *keyringAuditor) auditKeyring(ctx context.Context, graph registry.CredentialGraph) error {
+	a.result.Keyrings++
+
+	keyring := graph.GetKeyring()
+	var problem string
+	var err error
+	switch k := keyring.(type) {
+	case *envelope.KeyringV1:
+		problem, err = a.verify(ctx, k.Body, &k.Signature)
+	case *envelope.Keyring:
+		problem, err = a.verify(ctx, k.Body, &k.Signature)
+	}
+	if err != nil {
+		return err
+	}
+	if problem != "" {
+		a.report(keyring, keyring.GetID().String(), "Keyring "+problem)
+	}
+
+	switch g := graph.(type) {
+	case *registry.CredentialGraphV1:
+		for _, m := range g.Members {
+			err := a.auditMemberV1(ctx, keyring, &m)
+			if err != nil {
+				return err
+			}
+		}
+	case *registry.CredentialGraphV2:
+		revoked := make(map[identity.ID]bool)
+		for _, c := range g.Claims {
+			problem, err := a.verify(ctx, c.Body, &c.Signature)
+			if err != nil {
+				return err
+			}
+			if problem != "" {
+				a.report(keyring, c.ID.String(), "Keyring member claim "+problem)
+				continue
+			}
+
+			if c.Body.ClaimType == primitive.RevocationClaimType {
+				revoked[*c.Body.KeyringMemberID] = true
+			}
+		}
+
+		for _, m := range g.Members {
+			err := a.auditMember(ctx, keyring, &m, revoked[*m.Member.ID])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *keyringAuditor) auditMemberV1(ctx context.Context, keyring envelope.KeyringInf,
+	m *envelope.KeyringMemberV1) error {
+
+	a.result.Members++
+
+	problem, err := a.verify(ctx, m.Body, &m.Signature)
+	if err != nil {
+		return err
+	}
+	if problem != "" {
+		a.report(keyring, m.ID.String(), "Keyring member "+problem)
+		return nil
+	}
+
+	return a.auditMemberKeys(ctx, keyring, m.ID, m.Body.OwnerID, m.Body.PublicKeyID,
+		m.Body.EncryptingKeyID, m.Body.Key, false)
+}
+
+func (a *keyringAuditor) auditMember(ctx context.Context, keyring envelope.KeyringInf,
+	m *registry.KeyringMember, revoked bool) error {
+
+	a.result.Members++
+
+	problem, err := a.verify(ctx, m.Member.Body, &m.Member.Signature)
+	if err != nil {
+		return err
+	}
+	if problem != "" {
+		a.report(keyring, m.Member.ID.String(), "Keyring member "+problem)
+		return nil
+	}
+
+	var key *primitive.KeyringMemberKey
+	if m.MEKShare != nil {
+		problem, err := a.verify(ctx, m.MEKShare.Body, &m.MEKShare.Signature)
+		if err != nil {
+			return err
+		}
+		if problem != "" {
+			a.report(keyring, m.Member.ID.String(), "Keyring member share "+problem)
+			return nil
+		}
+		key = m.MEKShare.Body.Key
+	}
+
+	body := m.Member.Body
+	return a.auditMemberKeys(ctx, keyring, m.Member.ID, body.OwnerID, body.PublicKeyID,
+		body.EncryptingKeyID, key, revoked)
+}
+
+// auditMemberKeys checks the keys a keyring membership was encrypted with. If
+// the membership is ours, its share of the keyring master key is decrypted.
+func (a *keyringAuditor) auditMemberKeys(ctx context.Context, keyring envelope.KeyringInf,
+	memberID, ownerID, publicKeyID, encryptingKeyID *identity.ID,
+	key *primitive.KeyringMemberKey, revoked bool) error {
+
+	subject := memberID.String()
+
+	member, ok := a.segments[*publicKeyID]
+	if !ok {
+		a.report(keyring, subject, "Keyring member is encrypted for an unknown key")
+		return nil
+	}
+	if member.PublicKey.Body.KeyType != primitive.EncryptionKeyType ||
+		*member.PublicKey.Body.OwnerID != *ownerID {
+		a.report(keyring, subject, "Keyring member is encrypted for a key that is "+
+			"not its owner's encryption key")
+		return nil
+	}
+
+	encrypting, ok := a.segments[*encryptingKeyID]
+	if !ok || encrypting.PublicKey.Body.KeyType != primitive.EncryptionKeyType {
+		a.report(keyring, subject, "Keyring member is encrypted by an unknown key")
+		return nil
+	}
+
+	if !revoked && member.Revoked() {
+		a.report(keyring, subject, "Keyring member is encrypted for a revoked key, "+
+			"but has not been revoked")
+	}
+
+	if revoked || *ownerID != *a.engine.session.AuthID() {
+		return nil
+	}
+
+	if !bytes.Equal(*member.PublicKey.Body.Key.Value, a.keypairs.Encryption.Public[:]) {
+		// This membership was made for one of our older keys, which we no
+		// longer hold the private half of.
+		return nil
+	}
+
+	if key == nil || key.Value == nil || key.Nonce == nil {
+		a.report(keyring, subject, "Keyring member is missing its share of the master key")
+		return nil
+	}
+
+	_, err := a.engine.crypto.Unbox(ctx, *key.Value, *key.Nonce,
+		&a.keypairs.Encryption, *encrypting.PublicKey.Body.Key.Value)
+	if err != nil {
+		a.report(keyring, subject, "Keyring member could not be decrypted")
+	}
+
+	return nil
+}
+
+// auditCredentials checks the signatures, nonces, and version histories of
+// the credentials in every version of a keyring.
+func (a *keyringAuditor) auditCredentials(ctx context.Context, graphs []registry.CredentialGraph) error {
+	creds := make(map[identity.ID]envelope.CredentialInf)
+	keyrings := make(map[identity.ID]envelope.KeyringInf)
+	for _, graph := range graphs {
+		keyring := graph.GetKeyring()
+		nonces := make(map[string]bool)
+
+		for _, cred := range graph.GetCredentials() {
+			a.result.Credentials++
+			creds[*cred.GetID()] = cred
+			keyrings[*cred.GetID()] = keyring
+
+			subject := cred.GetID().String()
+
+			var problem string
+			var err error
+			switch c := cred.(type) {
+			case *envelope.CredentialV1:
+				problem, err = a.verify(ctx, c.Body, &c.Signature)
+			case *envelope.CredentialV2:
+				problem, err = a.verify(ctx, c.Body, &c.Signature)
+			case *envelope.Credential:
+				problem, err = a.verify(ctx, c.Body, &c.Signature)
+			}
+			if err != nil {
+				return err
+			}
+			if problem != "" {
+				a.report(keyring, subject, "Credential "+cred.Name()+" "+problem)
+			}
+
+			if cred.Unset() {
+				continue
+			}
+
+			// Each credential's key is derived from the keyring master key
+			// and its nonce, so nonces must never repeat within a keyring.
+			nonce := cred.Nonce()
+			if nonce == nil || len(*nonce) == 0 {
+				a.report(keyring, subject, "Credential "+cred.Name()+" has no nonce")
+			} else if nonces[nonce.String()] {
+				a.report(keyring, subject, "Credential "+cred.Name()+
+					" reuses the nonce of another credential")
+			} else {
+				nonces[nonce.String()] = true
+			}
+
+			value := cred.Credential()
+			if value == nil || value.Nonce == nil || len(*value.Nonce) == 0 {
+				a.report(keyring, subject, "Credential "+cred.Name()+
+					" has no ciphertext nonce")
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(creds))
+	byID := make(map[string]envelope.CredentialInf, len(creds))
+	for _, cred := range creds {
+		ids = append(ids, cred.GetID().String())
+		byID[cred.GetID().String()] = cred
+	}
+	sort.Strings(ids)
+
+	children := make(map[identity.ID]string)
+	for _, id := range ids {
+		cred := byID[id]
+		keyring := keyrings[*cred.GetID()]
+		problem := checkCredentialHistory(cred, creds)
+		if problem != "" {
+			a.report(keyring, id, "Credential "+cred.Name()+" "+problem)
+		}
+
+		previous := cred.Previous()
+		if previous == nil {
+			continue
+		}
+
+		if other, ok := children[*previous]; ok {
+			a.report(keyring, id, fmt.Sprintf("Credential %s and %s were both "+
+				"written as the next version of %s", id, other, previous))
+		}
+		children[*previous] = id
+	}
+
+	return nil
+}
+
+// checkCredentialHistory returns a description of any inconsistency between
+// cred and the previous version it links to. Previous versions we cannot see
+// are not reported.
+func checkCredentialHistory(cred envelope.CredentialInf,
+	creds map[identity.ID]envelope.CredentialInf) string {
+
+	previousID := cred.Previous()
+	if previousID == nil {
+		if cred.CredentialVersion() != 1 {
+			return fmt.Sprintf("is version %d, but has no previous version",
+				cred.CredentialVersion())
+		}
+		return ""
+	}
+
+	if *previousID == *cred.GetID() {
+		return "is its own previous version"
+	}
+
+	previous, ok := creds[*previousID]
+	if !ok {
+		return ""
+	}
+
+	if previous.Name() != cred.Name() ||
+		previous.PathExp().String() != cred.PathExp().String() {
+		return "links to a previous version of a different credential"
+	}
+
+	if previous.CredentialVersion()+1 != cred.CredentialVersion() {
+		return fmt.Sprintf("is version %d, but its previous version is %d",
+			cred.CredentialVersion(), previous.CredentialVersion())
+	}
+
+	return ""
+}
@@ -0,0 +1,52 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func auditCred(id, prev *identity.ID, name string, version int) envelope.CredentialInf {
+	return &envelope.Credential{
+		ID:      id,
+		Version: 3,
+		Body: &primitive.Credential{
+			BaseCredential: primitive.BaseCredential{
+				Name:              name,
+				PathExp:           mustPathExp("/o/p/e/s/u/i"),
+				Previous:          prev,
+				CredentialVersion: version,
+			},
+		},
+	}
+}
+
+func TestCheckCredentialHistory(t *testing.T) {
+	first := auditCred(id1, nil, "name", 1)
+	creds := map[identity.ID]envelope.CredentialInf{*id1: first}
+
+	tcs := []struct {
+		name    string
+		cred    envelope.CredentialInf
+		problem bool
+	}{
+		{"first version", first, false},
+		{"next version", auditCred(id2, id1, "name", 2), false},
+		{"invisible previous", auditCred(id2, id3, "name", 5), false},
+		{"missing previous", auditCred(id2, nil, "name", 2), true},
+		{"own previous", auditCred(id2, id2, "name", 2), true},
+		{"different credential", auditCred(id2, id1, "other", 2), true},
+		{"skipped version", auditCred(id2, id1, "name", 3), true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			problem := checkCredentialHistory(tc.cred, creds)
+			if (problem != "") != tc.problem {
+				t.Errorf("wrong result. got %q, wanted problem: %t", problem, tc.problem)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/natefinch/lumberjack"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// honeytokenReportTimeout bounds how long the daemon tries to report a
+// honeytoken read to the registry.
+const honeytokenReportTimeout = 30 * time.Second
+
+const (
+	// auditLogMaxSize is how large the audit log may grow, in megabytes,
+	// before it is rotated.
+	auditLogMaxSize = 10
+
+	// auditLogMaxBackups is how many rotated audit logs are kept. Unlike the
+	// daemon log, they aren't removed by age, so a quiet machine keeps its
+	// history.
+	auditLogMaxBackups = 10
+)
+
+// auditLog appends an event for every credential the daemon decrypts to a
+// local file, one JSON encoded apitypes.AuditEvent per line. The file is
+// rotated by size, keeping a bounded number of old ones beside it.
+type auditLog struct {
+	mutex   sync.Mutex
+	path    string
+	out     *lumberjack.Logger
+	created bool
+}
+
+func newAuditLog(path string) *auditLog {
+	return &auditLog{
+		path: path,
+		out: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    auditLogMaxSize,
+			MaxBackups: auditLogMaxBackups,
+		},
+	}
+}
+
+// record appends events to the log. Errors are logged, as failing to audit a
+// read should not fail the read itself.
+func (a *auditLog) record(events []apitypes.AuditEvent) {
+	if a == nil || a.path == "" || len(events) == 0 {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	// Rotated files keep the mode of the one they replace, so only the
+	// first needs creating with restricted permissions.
+	if !a.created {
+		f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			log.Printf("Error opening audit log: %s", err)
+			return
+		}
+		f.Close()
+		a.created = true
+	}
+
+	// Each event is written in a single call, so none is split across
+	// files when the log is rotated.
+	for _, event := range events {
+		b, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("Error writing audit log: %s", err)
+			return
+		}
+
+		_, err = a.out.Write(append(b, '\n'))
+		if err != nil {
+			log.Printf("Error writing audit log: %s", err)
+			return
+		}
+	}
+}
+
+// recordReads records that the logged in user or machine decrypted creds.
+// Reads of honeytokens are also reported to the registry, which alerts the
+// org.
+func (e *Engine) recordReads(creds []PlaintextCredentialEnvelope) {
+	now := time.Now().UTC()
+	actor := e.session.ID()
+	events := readEvents(creds, actor, now)
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Printf("Error looking up hostname: %s", err)
+	}
+
+	reads := honeytokenReads(creds, actor, e.session.AuthID(), hostname, now)
+	for _, read := range reads {
+		log.Printf("Honeytoken %s read by %s on %s", read.Path, read.Reader, read.Hostname)
+		events = append(events, apitypes.AuditEvent{
+			Time:    now,
+			OrgID:   read.OrgID,
+			ActorID: actor,
+			Action:  apitypes.AuditHoneytokenRead,
+			Subject: read.Path,
+		})
+	}
+
+	e.audit.record(events)
+
+	if len(reads) > 0 {
+		go e.reportHoneytokenReads(reads)
+	}
+}
+
+// recordRewrites records that the daemon decrypted creds on the session's
+// behalf only to encrypt them again, such as when rotating a keyring. The
+// values never leave the daemon, so honeytokens among them are not reported.
+func (e *Engine) recordRewrites(creds []PlaintextCredentialEnvelope) {
+	e.audit.record(readEvents(creds, e.session.ID(), time.Now().UTC()))
+}
+
+// readEvents returns an audit event for each of creds read by actor.
+func readEvents(creds []PlaintextCredentialEnvelope, actor *identity.ID,
+	now time.Time) []apitypes.AuditEvent {
+
+	events := make([]apitypes.AuditEvent, 0, len(creds))
+	for _, cred := range creds {
+		events = append(events, apitypes.AuditEvent{
+			Time:    now,
+			OrgID:   cred.Body.OrgID,
+			ActorID: actor,
+			Action:  apitypes.AuditCredentialRead,
+			Subject: cred.Body.PathExp.String() + "/" + cred.Body.Name,
+		})
+	}
+
+	return events
+}
+
+// reportHoneytokenReads sends reads to the registry. It runs apart from the
+// read itself, so the reader gets no sign that they tripped an alert.
+func (e *Engine) reportHoneytokenReads(reads []apitypes.HoneytokenRead) {
+	ctx, cancel := context.WithTimeout(context.Background(), honeytokenReportTimeout)
+	defer cancel()
+
+	for i := range reads {
+		err := e.client.Honeytokens.Report(ctx, &reads[i])
+		if err != nil {
+			log.Printf("Error reporting read of honeytoken %s: %s", reads[i].Path, err)
+		}
+	}
+}
+
+// honeytokenReads returns a HoneytokenRead for each of creds marked as a
+// honeytoken.
+func honeytokenReads(creds []PlaintextCredentialEnvelope, reader, authID *identity.ID,
+	hostname string, now time.Time) []apitypes.HoneytokenRead {
+
+	var reads []apitypes.HoneytokenRead
+	for _, cred := range creds {
+		if !cred.Body.Honeytoken {
+			continue
+		}
+
+		reads = append(reads, apitypes.HoneytokenRead{
+			Time:     now,
+			OrgID:    cred.Body.OrgID,
+			Path:     cred.Body.PathExp.String() + "/" + cred.Body.Name,
+			Reader:   reader,
+			AuthID:   authID,
+			Hostname: hostname,
+		})
+	}
+
+	return reads
+}
@@ -0,0 +1,119 @@
+package logic
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func TestAuditLogRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "torus-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a := newAuditLog(filepath.Join(dir, "audit.log"))
+	a.record([]apitypes.AuditEvent{{Action: apitypes.AuditCredentialRead, Subject: "a"}})
+	a.record([]apitypes.AuditEvent{{Action: apitypes.AuditCredentialRead, Subject: "b"}})
+
+	f, err := os.Open(a.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	subjects := []string{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		event := apitypes.AuditEvent{}
+		if err := json.Unmarshal(s.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		subjects = append(subjects, event.Subject)
+	}
+
+	if len(subjects) != 2 || subjects[0] != "a" || subjects[1] != "b" {
+		t.Errorf("wrong events recorded: %v", subjects)
+	}
+
+	var nilLog *auditLog
+	nilLog.record([]apitypes.AuditEvent{{Subject: "c"}})
+}
+
+func TestAuditLogRotates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "torus-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a := newAuditLog(filepath.Join(dir, "audit.log"))
+	a.out.MaxSize = 1
+	a.out.MaxBackups = 2
+	defer a.out.Close()
+
+	// Write a few megabytes of events. Old backups are removed in the
+	// background, so only check that the log was rotated.
+	subject := strings.Repeat("s", 1024)
+	for i := 0; i < 4*1024; i++ {
+		a.record([]apitypes.AuditEvent{{Action: apitypes.AuditCredentialRead, Subject: subject}})
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 2 {
+		t.Fatalf("expected the log and its backups, got %d files", len(files))
+	}
+
+	for _, f := range files {
+		if f.Size() > 1024*1024 {
+			t.Errorf("%s was not rotated: %d bytes", f.Name(), f.Size())
+		}
+		if f.Mode().Perm() != 0600 {
+			t.Errorf("%s is readable by others: %s", f.Name(), f.Mode())
+		}
+	}
+}
+
+func TestHoneytokenReads(t *testing.T) {
+	pe, err := pathexp.Parse("/o/p/e/s/u/i")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cred := func(name string, honeytoken bool) PlaintextCredentialEnvelope {
+		body := &PlaintextCredential{Name: name, PathExp: pe}
+		body.Honeytoken = honeytoken
+		return PlaintextCredentialEnvelope{Body: body}
+	}
+
+	reader := identity.ID{0x01, 0x01, 0x01}
+	now := time.Now()
+	creds := []PlaintextCredentialEnvelope{cred("a", false), cred("b", true)}
+
+	reads := honeytokenReads(creds, &reader, &reader, "laptop", now)
+	if len(reads) != 1 {
+		t.Fatalf("got %d reads, want 1", len(reads))
+	}
+
+	read := reads[0]
+	if read.Path != "/o/p/e/s/u/i/b" || *read.Reader != reader || read.Hostname != "laptop" {
+		t.Errorf("wrong read reported: %+v", read)
+	}
+
+	if reads := honeytokenReads(creds[:1], &reader, &reader, "laptop", now); len(reads) != 0 {
+		t.Errorf("got reads of plain secrets: %+v", reads)
+	}
+}
@@ -0,0 +1,204 @@
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+// fileBackendVersion is the version of the file written by a file backend.
+const fileBackendVersion = 1
+
+// fileBackend is a Backend keeping credentials in a file on this machine.
+// The credentials are sealed with the user's master key, so they can only be
+// read by the same user, while logged in. Only the latest version of each
+// credential is kept.
+type fileBackend struct {
+	engine *Engine
+	path   string
+	mutex  sync.Mutex
+}
+
+// fileBackendContents is the file written by a fileBackend.
+type fileBackendContents struct {
+	Version int    `json:"version"`
+	Nonce   []byte `json:"nonce"`
+	Sealed  []byte `json:"sealed"`
+}
+
+// NewFileBackend returns a Backend keeping credentials, sealed with the
+// logged in user's master key, in the file at path.
+func NewFileBackend(e *Engine, path string) Backend {
+	return &fileBackend{engine: e, path: path}
+}
+
+func (f *fileBackend) Writable() bool {
+	return true
+}
+
+func (f *fileBackend) Credentials(ctx context.Context, notifier *observer.Notifier,
+	cpath, cpathexp *string) ([]PlaintextCredentialEnvelope, error) {
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	creds, err := f.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var match func(*pathexp.PathExp) bool
+	if cpath != nil {
+		r, err := pathResource(*cpath)
+		if err != nil {
+			return nil, err
+		}
+		match = func(pe *pathexp.PathExp) bool {
+			cr, err := pathResource(pe.String())
+			return err == nil && cr.Covers(r)
+		}
+	} else {
+		search, err := pathexp.Parse(*cpathexp)
+		if err != nil {
+			return nil, err
+		}
+		match = search.Overlaps
+	}
+
+	matched := []PlaintextCredentialEnvelope{}
+	for _, cred := range creds {
+		if match(cred.Body.PathExp) {
+			matched = append(matched, cred)
+		}
+	}
+
+	return matched, nil
+}
+
+func (f *fileBackend) Append(ctx context.Context, creds []*PlaintextCredentialEnvelope) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	stored, err := f.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, cred := range creds {
+		id, err := newBackendCredentialID()
+		if err != nil {
+			return err
+		}
+
+		body := *cred.Body
+		body.Previous = nil
+		body.CredentialVersion = 1
+
+		i := findStored(stored, body.PathExp, body.Name)
+		if i == -1 {
+			stored = append(stored, PlaintextCredentialEnvelope{})
+			i = len(stored) - 1
+		} else {
+			body.Previous = stored[i].ID
+			body.CredentialVersion = stored[i].Body.CredentialVersion + 1
+		}
+
+		cred.ID = &id
+		cred.Version = 3
+		cred.Body = &body
+		stored[i] = *cred
+	}
+
+	return f.save(ctx, stored)
+}
+
+// findStored returns the index of the credential with the given name at pe,
+// or -1 if there is none.
+func findStored(creds []PlaintextCredentialEnvelope, pe *pathexp.PathExp, name string) int {
+	for i, cred := range creds {
+		if cred.Body.Name == name && cred.Body.PathExp.Equal(pe) {
+			return i
+		}
+	}
+	return -1
+}
+
+// newBackendCredentialID returns a random ID for a credential stored in a
+// backend, which has no signature to derive one from.
+func newBackendCredentialID() (identity.ID, error) {
+	id := identity.ID{0x01, (&primitive.Credential{}).Type()}
+	_, err := rand.Read(id[2:])
+	return id, err
+}
+
+// load returns the credentials in the file, or none if it doesn't exist yet.
+func (f *fileBackend) load(ctx context.Context) ([]PlaintextCredentialEnvelope, error) {
+	b, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	contents := fileBackendContents{}
+	err = json.Unmarshal(b, &contents)
+	if err != nil {
+		return nil, err
+	}
+
+	pt, err := f.engine.crypto.Unseal(ctx, contents.Sealed, contents.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds []PlaintextCredentialEnvelope
+	err = json.Unmarshal(pt, &creds)
+	return creds, err
+}
+
+// save seals creds, and replaces the contents of the file with them.
+func (f *fileBackend) save(ctx context.Context, creds []PlaintextCredentialEnvelope) error {
+	pt, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+
+	sealed, nonce, err := f.engine.crypto.Seal(ctx, pt)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(&fileBackendContents{
+		Version: fileBackendVersion,
+		Nonce:   nonce,
+		Sealed:  sealed,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(f.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so the file is never left half
+	// written.
+	tmp := f.path + ".tmp"
+	err = ioutil.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
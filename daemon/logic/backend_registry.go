@@ -0,0 +1,49 @@
+package logic
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+
+	"github.com/manifoldco/torus-cli/daemon/observer"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// registryBackend is a Backend reading credentials from another registry
+// instance, such as the one an org is being migrated away from. It's read
+// only, so that credentials are only ever written to one registry.
+type registryBackend struct {
+	engine *Engine
+}
+
+// NewRegistryBackend returns a Backend reading credentials from the registry
+// client talks to, with the session, keys, and policies of e. The registry
+// must accept the session's auth token, as a replica or caching proxy of the
+// registry e talks to does.
+func NewRegistryBackend(e *Engine, client *registry.Client) Backend {
+	engine := NewEngine(e.config, e.session, e.db, e.crypto, client)
+
+	// Only the registry the session belongs to can revoke it.
+	client.OnUnauthorized(nil)
+
+	return &registryBackend{engine: engine}
+}
+
+func (r *registryBackend) Writable() bool {
+	return false
+}
+
+func (r *registryBackend) Credentials(ctx context.Context, notifier *observer.Notifier,
+	cpath, cpathexp *string) ([]PlaintextCredentialEnvelope, error) {
+
+	return r.engine.retrieveCredentials(ctx, notifier, cpath, cpathexp)
+}
+
+func (r *registryBackend) Append(ctx context.Context, creds []*PlaintextCredentialEnvelope) error {
+	return &apitypes.Error{
+		StatusCode: http.StatusBadRequest,
+		Type:       apitypes.BadRequestError,
+		Err:        []string{"Registry backends are read only"},
+	}
+}
@@ -0,0 +1,170 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/policyeval"
+
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+// Backend is a store of credentials other than the registry, such as an
+// encrypted file, or another registry instance. Backends are given plaintext
+// credentials; it's up to each to protect them.
+type Backend interface {
+	// Credentials returns the credentials stored for the given CPath, or
+	// matching the given CPathExp. Only one is set.
+	Credentials(ctx context.Context, notifier *observer.Notifier,
+		cpath, cpathexp *string) ([]PlaintextCredentialEnvelope, error)
+
+	// Writable returns whether credentials can be appended to the backend.
+	Writable() bool
+
+	// Append stores creds, which share a path expression, as the next
+	// version of any credentials of the same names.
+	Append(ctx context.Context, creds []*PlaintextCredentialEnvelope) error
+}
+
+// namedBackend is a Backend, as configured for the engine.
+type namedBackend struct {
+	Backend
+	name     string
+	pathexps []*pathexp.PathExp
+}
+
+// AddBackend configures the engine to use b for the credentials at the given
+// path expressions, along with the registry.
+//
+// Credentials are read from the registry, and from every backend configured
+// for the path read. When they hold credentials of the same name at the same
+// path expression, the registry's wins. Credentials are written to the first
+// writable backend configured for all of their path expression, or to the
+// registry if there is none.
+//
+// Backends must be added before the engine is used.
+func (e *Engine) AddBackend(name string, pes []*pathexp.PathExp, b Backend) {
+	e.backends = append(e.backends, &namedBackend{Backend: b, name: name, pathexps: pes})
+}
+
+// pathResource returns the policy resource for every secret at raw, a path
+// or path expression, for comparing it with others.
+func pathResource(raw string) (*policyeval.Resource, error) {
+	return policyeval.ParseResource(raw + "/*")
+}
+
+// covers returns whether the backend is configured for every path matched
+// by r.
+func (b *namedBackend) covers(r *policyeval.Resource) bool {
+	for _, pe := range b.pathexps {
+		br, err := pathResource(pe.String())
+		if err == nil && br.Covers(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps returns whether the backend is configured for any path matched
+// by pe.
+func (b *namedBackend) overlaps(pe *pathexp.PathExp) bool {
+	for _, bpe := range b.pathexps {
+		if bpe.Overlaps(pe) {
+			return true
+		}
+	}
+	return false
+}
+
+// backendCredentials returns the credentials from each backend configured
+// for part of the given CPath or CPathExp, in the order they were added.
+func (e *Engine) backendCredentials(ctx context.Context, notifier *observer.Notifier,
+	cpath, cpathexp *string) ([]PlaintextCredentialEnvelope, error) {
+
+	if len(e.backends) == 0 {
+		return nil, nil
+	}
+
+	raw := cpathexp
+	if cpath != nil {
+		raw = cpath
+	}
+	pe, err := pathexp.Parse(*raw)
+	if err != nil {
+		return nil, &apitypes.Error{
+			StatusCode: http.StatusBadRequest,
+			Type:       apitypes.BadRequestError,
+			Err:        []string{err.Error()},
+		}
+	}
+
+	var creds []PlaintextCredentialEnvelope
+	for _, b := range e.backends {
+		if !b.overlaps(pe) {
+			continue
+		}
+
+		bcreds, err := b.Credentials(ctx, notifier, cpath, cpathexp)
+		if err != nil {
+			log.Printf("Error retrieving credentials from backend %s: %s", b.name, err)
+			return nil, &apitypes.Error{
+				StatusCode: http.StatusInternalServerError,
+				Type:       apitypes.InternalServerError,
+				Err:        []string{"Could not read secrets from backend " + b.name + ": " + err.Error()},
+			}
+		}
+		creds = append(creds, bcreds...)
+	}
+
+	return creds, nil
+}
+
+// resolveCredentials returns the credentials for the given CPath or
+// CPathExp, from the registry and any backends configured for it.
+func (e *Engine) resolveCredentials(ctx context.Context, notifier *observer.Notifier,
+	cpath, cpathexp *string) ([]PlaintextCredentialEnvelope, error) {
+
+	creds, err := e.retrieveCredentials(ctx, notifier, cpath, cpathexp)
+	if err != nil {
+		return nil, err
+	}
+
+	bcreds, err := e.backendCredentials(ctx, notifier, cpath, cpathexp)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(creds, bcreds...), nil
+}
+
+// writeBackend returns the backend credentials at pe are written to, or nil
+// if they are written to the registry. Writes to a path expression that a
+// writable backend is configured for only part of are refused, as they
+// would be split between stores.
+func (e *Engine) writeBackend(pe *pathexp.PathExp) (*namedBackend, error) {
+	r, err := pathResource(pe.String())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range e.backends {
+		if !b.Writable() || !b.overlaps(pe) {
+			continue
+		}
+
+		if !b.covers(r) {
+			return nil, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err: []string{"Backend " + b.name + " holds the secrets at part of " +
+					pe.String() + ". Set them at a narrower path."},
+			}
+		}
+		return b, nil
+	}
+
+	return nil, nil
+}
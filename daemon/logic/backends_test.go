@@ -0,0 +1,100 @@
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/pathexp"
+
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+type fakeBackend struct {
+	writable bool
+	reads    int
+}
+
+func (f *fakeBackend) Credentials(ctx context.Context, notifier *observer.Notifier,
+	cpath, cpathexp *string) ([]PlaintextCredentialEnvelope, error) {
+
+	f.reads++
+	return []PlaintextCredentialEnvelope{{}}, nil
+}
+
+func (f *fakeBackend) Writable() bool { return f.writable }
+
+func (f *fakeBackend) Append(ctx context.Context, creds []*PlaintextCredentialEnvelope) error {
+	return nil
+}
+
+func backendEngine(t *testing.T) (*Engine, *fakeBackend, *fakeBackend) {
+	local := &fakeBackend{writable: true}
+	old := &fakeBackend{}
+
+	e := &Engine{}
+	e.AddBackend("local", []*pathexp.PathExp{mustPathExp("/acme/app/dev/**")}, local)
+	e.AddBackend("old", []*pathexp.PathExp{mustPathExp("/acme/app/**")}, old)
+	return e, local, old
+}
+
+func TestBackendCredentials(t *testing.T) {
+	tcs := []struct {
+		name          string
+		path          string
+		local, legacy int
+	}{
+		{"both", "/acme/app/dev/api/jo/1", 1, 1},
+		{"one", "/acme/app/prod/api/jo/1", 0, 1},
+		{"none", "/acme/site/dev/api/jo/1", 0, 0},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			e, local, old := backendEngine(t)
+			creds, err := e.backendCredentials(context.Background(), nil, &tc.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if local.reads != tc.local || old.reads != tc.legacy {
+				t.Errorf("got reads local=%d old=%d, want %d and %d",
+					local.reads, old.reads, tc.local, tc.legacy)
+			}
+			if len(creds) != tc.local+tc.legacy {
+				t.Errorf("got %d credentials, want %d", len(creds), tc.local+tc.legacy)
+			}
+		})
+	}
+}
+
+func TestWriteBackend(t *testing.T) {
+	tcs := []struct {
+		pathexp string
+		backend string
+		err     bool
+	}{
+		{"/acme/app/dev/api/*/*", "local", false},
+		{"/acme/app/dev/[api|web]/*/1", "local", false},
+		{"/acme/app/prod/api/*/*", "", false},
+		{"/acme/app/*/api/*/*", "", true},
+		{"/acme/app/d*/api/*/*", "", true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.pathexp, func(t *testing.T) {
+			e, _, _ := backendEngine(t)
+			b, err := e.writeBackend(mustPathExp(tc.pathexp))
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v, want error %t", err, tc.err)
+			}
+
+			name := ""
+			if b != nil {
+				name = b.name
+			}
+			if name != tc.backend {
+				t.Errorf("got backend %q, want %q", name, tc.backend)
+			}
+		})
+	}
+}
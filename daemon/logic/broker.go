@@ -0,0 +1,230 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/satori/go.uuid"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+
+	"github.com/manifoldco/torus-cli/daemon/broker"
+)
+
+// brokerBucket is the db bucket holding the leases of values minted for
+// dynamic secrets, until they are revoked.
+const brokerBucket = "broker"
+
+// brokerTick is how often expired leases are revoked.
+const brokerTick = time.Minute
+
+// brokerLease is a value minted for a dynamic secret, kept until it is
+// revoked. The provider's configuration, and the handle it needs to revoke
+// the value, are sealed with the user's master key, so leases can only be
+// revoked by the same user, while logged in.
+type brokerLease struct {
+	Provider  string    `json:"provider"`
+	Path      string    `json:"path"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	Nonce  []byte `json:"nonce"`
+	Sealed []byte `json:"sealed"`
+}
+
+// brokerSecrets is the sealed part of a brokerLease.
+type brokerSecrets struct {
+	Config string          `json:"config"`
+	Handle json.RawMessage `json:"handle"`
+}
+
+// brokerCredentials replaces the value of each dynamic secret in creds with
+// a new short-lived value minted by its provider, and records a lease to
+// revoke the value once it expires. Other secrets are returned as they are.
+func (e *Engine) brokerCredentials(ctx context.Context,
+	creds []PlaintextCredentialEnvelope) ([]PlaintextCredentialEnvelope, error) {
+
+	for i, cred := range creds {
+		spec := cred.Body.Broker
+		if spec == nil || *cred.Body.State == "unset" {
+			continue
+		}
+
+		value := apitypes.CredentialValue{}
+		err := json.Unmarshal([]byte(cred.Body.Value), &value)
+		if err != nil {
+			return nil, err
+		}
+
+		path := cred.Body.PathExp.String() + "/" + cred.Body.Name
+		lease, err := broker.CreateLease(ctx, spec.Provider, &broker.Request{
+			Config: value.String(),
+			TTL:    spec.TTL,
+			Caller: e.callerName(),
+			Path:   path,
+		})
+		if err != nil {
+			log.Printf("Error minting value for %s: %s", path, err)
+			return nil, &apitypes.Error{
+				Type: apitypes.InternalServerError,
+				Err:  []string{"Could not mint a value for " + path + ": " + err.Error()},
+			}
+		}
+
+		err = e.storeLease(ctx, spec.Provider, path, value.String(), lease)
+		if err != nil {
+			// A value we can't revoke would outlive its lease, so revoke it
+			// now rather than handing it out.
+			log.Printf("Error recording lease for %s: %s", path, err)
+			revokeErr := broker.RevokeLease(ctx, spec.Provider, value.String(), lease.Handle)
+			if revokeErr != nil {
+				log.Printf("Error revoking value for %s: %s", path, revokeErr)
+			}
+			return nil, err
+		}
+
+		minted, err := json.Marshal(apitypes.NewStringCredentialValue(lease.Value))
+		if err != nil {
+			return nil, err
+		}
+
+		body := *cred.Body
+		body.Value = string(minted)
+		expires := lease.ExpiresAt.UTC()
+		body.ExpiresAt = &expires
+		creds[i].Body = &body
+	}
+
+	return creds, nil
+}
+
+// callerName returns the name of the logged in user or machine, for
+// providers to include in the names of the values they mint.
+func (e *Engine) callerName() string {
+	switch identity := e.session.Self().Identity.(type) {
+	case *envelope.User:
+		return identity.Body.Username
+	case *envelope.Machine:
+		return identity.Body.Name
+	default:
+		return ""
+	}
+}
+
+// storeLease records lease, minted for path, so that it is revoked once it
+// expires.
+func (e *Engine) storeLease(ctx context.Context, provider, path, config string,
+	lease *broker.Lease) error {
+
+	pt, err := json.Marshal(&brokerSecrets{Config: config, Handle: lease.Handle})
+	if err != nil {
+		return err
+	}
+
+	sealed, nonce, err := e.crypto.Seal(ctx, pt)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(&brokerLease{
+		Provider:  provider,
+		Path:      path,
+		ExpiresAt: lease.ExpiresAt,
+		Nonce:     nonce,
+		Sealed:    sealed,
+	})
+	if err != nil {
+		return err
+	}
+
+	return e.db.Put(brokerBucket, e.leaseKeyPrefix()+uuid.NewV4().String(), b)
+}
+
+// leaseKeyPrefix returns the prefix of the keys of leases minted for the
+// current user or machine.
+func (e *Engine) leaseKeyPrefix() string {
+	return e.session.AuthID().String() + ":"
+}
+
+// RunBroker revokes the values minted for dynamic secrets as their leases
+// expire. It blocks until ctx is done.
+//
+// Leases can only be revoked while the user who read them is logged in.
+// Providers are asked to make values expire on their own, in case they are
+// not.
+func (e *Engine) RunBroker(ctx context.Context) {
+	ticker := time.NewTicker(brokerTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.revokeExpiredLeases(ctx, time.Now())
+		}
+	}
+}
+
+// revokeExpiredLeases revokes the current user's leases that expired
+// before now. Leases that fail to be revoked are tried again later.
+func (e *Engine) revokeExpiredLeases(ctx context.Context, now time.Time) {
+	if e.session.Type() == apitypes.NotLoggedIn {
+		return
+	}
+
+	prefix := e.leaseKeyPrefix()
+	expired := make(map[string]*brokerLease)
+	err := e.db.ForEach(brokerBucket, func(key string, value []byte) error {
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		lease := &brokerLease{}
+		err := json.Unmarshal(value, lease)
+		if err != nil {
+			log.Printf("Error decoding lease %s: %s", key, err)
+			return nil
+		}
+
+		if lease.ExpiresAt.Before(now) {
+			expired[key] = lease
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error reading leases: %s", err)
+		return
+	}
+
+	for key, lease := range expired {
+		err := e.revokeLease(ctx, lease)
+		if err != nil {
+			log.Printf("Error revoking value for %s: %s", lease.Path, err)
+			continue
+		}
+
+		err = e.db.Delete(brokerBucket, key)
+		if err != nil {
+			log.Printf("Error removing lease %s: %s", key, err)
+		}
+	}
+}
+
+func (e *Engine) revokeLease(ctx context.Context, lease *brokerLease) error {
+	pt, err := e.crypto.Unseal(ctx, lease.Sealed, lease.Nonce)
+	if err != nil {
+		return err
+	}
+
+	secrets := &brokerSecrets{}
+	err = json.Unmarshal(pt, secrets)
+	if err != nil {
+		return err
+	}
+
+	return broker.RevokeLease(ctx, lease.Provider, secrets.Config, secrets.Handle)
+}
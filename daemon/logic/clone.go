@@ -0,0 +1,165 @@
+package logic
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+// CloneEnvironment copies the secrets set in one environment of a project to
+// another, decrypting them, and encrypting them again in the keyrings of the
+// destination. Secrets set for both environments already, through a wildcard
+// or alternation, are left alone, as are secrets already set at their
+// destination, unless the request overwrites them.
+//
+// Secrets are written a path expression at a time. If writing one fails,
+// those already written are not removed.
+func (e *Engine) CloneEnvironment(ctx context.Context, notifier *observer.Notifier,
+	req *apitypes.EnvironmentCloneRequest, confirmed bool) (*apitypes.EnvironmentClone, error) {
+
+	for _, name := range []string{req.Org, req.Project, req.From, req.To} {
+		if !pathexp.ValidSlug(name) {
+			return nil, cloneError("Invalid name " + name)
+		}
+	}
+	if req.Service != "" && !pathexp.ValidSlug(req.Service) {
+		return nil, cloneError("Invalid service name " + req.Service)
+	}
+	if req.From == req.To {
+		return nil, cloneError("Cannot clone an environment to itself")
+	}
+
+	n := notifier.Notifier(2)
+
+	prefix := "/" + req.Org + "/" + req.Project + "/"
+	src := prefix + req.From + "/*/*/*"
+	source, err := e.retrieveCredentials(ctx, n, nil, &src)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Secrets retrieved", true)
+
+	dst := prefix + req.To + "/*/*/*"
+	existing, err := e.retrieveCredentials(ctx, n, nil, &dst)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Existing secrets retrieved", true)
+
+	plan, err := planClone(req, source, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.DryRun {
+		for _, creds := range plan.groups {
+			_, err = e.AppendCredentials(ctx, notifier, creds, req.Overwrite, confirmed)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return plan.result, nil
+}
+
+// clonePlan is the secrets to write to clone an environment, grouped by the
+// path expression they are written to, along with the result to report.
+type clonePlan struct {
+	groups [][]*PlaintextCredentialEnvelope
+	result *apitypes.EnvironmentClone
+}
+
+// planClone returns the copies of the set secrets in source to write to clone
+// an environment as req asks, given the secrets existing in the destination.
+func planClone(req *apitypes.EnvironmentCloneRequest, source,
+	existing []PlaintextCredentialEnvelope) (*clonePlan, error) {
+
+	set := make(map[string]bool, len(existing))
+	for _, cred := range existing {
+		if isUnset(cred) {
+			continue
+		}
+		set[cred.Body.PathExp.String()+"/"+cred.Body.Name] = true
+	}
+
+	plan := &clonePlan{
+		result: &apitypes.EnvironmentClone{
+			Cloned:  []apitypes.CredentialLocation{},
+			Skipped: []apitypes.CredentialLocation{},
+		},
+	}
+
+	groups := make(map[string][]*PlaintextCredentialEnvelope)
+	for _, cred := range source {
+		body := cred.Body
+		if isUnset(cred) || !body.PathExp.Envs.Contains(req.From) {
+			continue
+		}
+		if req.Service != "" && !body.PathExp.Services.Contains(req.Service) {
+			continue
+		}
+		if body.PathExp.Envs.Contains(req.To) {
+			plan.result.Shared++
+			continue
+		}
+
+		services := body.PathExp.Services.String()
+		if req.Service != "" {
+			services = req.Service
+		}
+
+		pe, err := pathexp.Parse(strings.Join([]string{"", req.Org, req.Project, req.To,
+			services, body.PathExp.Identities.String(), body.PathExp.Instances.String()}, "/"))
+		if err != nil {
+			return nil, err
+		}
+
+		location := apitypes.CredentialLocation{OrgID: body.OrgID, PathExp: pe, Name: body.Name}
+		if set[pe.String()+"/"+body.Name] && !req.Overwrite {
+			plan.result.Skipped = append(plan.result.Skipped, location)
+			continue
+		}
+		plan.result.Cloned = append(plan.result.Cloned, location)
+
+		copied := *body
+		copied.PathExp = pe
+		copied.Previous = nil
+		copied.CredentialVersion = 0
+		groups[pe.String()] = append(groups[pe.String()], &PlaintextCredentialEnvelope{
+			Version: cred.Version,
+			Body:    &copied,
+		})
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		plan.groups = append(plan.groups, groups[k])
+	}
+
+	return plan, nil
+}
+
+func isUnset(cred PlaintextCredentialEnvelope) bool {
+	return cred.Body.State != nil && *cred.Body.State == "unset"
+}
+
+func cloneError(msg string) error {
+	return &apitypes.Error{
+		StatusCode: http.StatusBadRequest,
+		Type:       apitypes.BadRequestError,
+		Err:        []string{msg},
+	}
+}
@@ -0,0 +1,113 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func cloneCred(t *testing.T, pe, name, state string) PlaintextCredentialEnvelope {
+	p, err := pathexp.Parse(pe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return PlaintextCredentialEnvelope{
+		Version: 2,
+		Body: &PlaintextCredential{
+			Name:              name,
+			PathExp:           p,
+			Value:             "value",
+			State:             &state,
+			CredentialVersion: 3,
+		},
+	}
+}
+
+func TestPlanClone(t *testing.T) {
+	source := []PlaintextCredentialEnvelope{
+		cloneCred(t, "/acme/site/staging/*/*/*", "url", "set"),
+		cloneCred(t, "/acme/site/staging/api/*/*", "port", "set"),
+		cloneCred(t, "/acme/site/staging/web/*/*", "theme", "set"),
+		cloneCred(t, "/acme/site/staging/api/*/*", "old", "unset"),
+		cloneCred(t, "/acme/site/[staging|production]/*/*/*", "region", "set"),
+	}
+	existing := []PlaintextCredentialEnvelope{
+		cloneCred(t, "/acme/site/production/api/*/*", "port", "set"),
+	}
+
+	tcs := []struct {
+		name   string
+		req    apitypes.EnvironmentCloneRequest
+		cloned []string
+		skip   int
+		groups int
+	}{
+		{
+			name:   "all services",
+			req:    apitypes.EnvironmentCloneRequest{},
+			cloned: []string{"/acme/site/production/*/*/*/url", "/acme/site/production/web/*/*/theme"},
+			skip:   1,
+			groups: 2,
+		},
+		{
+			name: "overwrite",
+			req:  apitypes.EnvironmentCloneRequest{Overwrite: true},
+			cloned: []string{
+				"/acme/site/production/*/*/*/url",
+				"/acme/site/production/api/*/*/port",
+				"/acme/site/production/web/*/*/theme",
+			},
+			groups: 3,
+		},
+		{
+			name:   "one service",
+			req:    apitypes.EnvironmentCloneRequest{Service: "web"},
+			cloned: []string{"/acme/site/production/web/*/*/url", "/acme/site/production/web/*/*/theme"},
+			groups: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.req
+			req.Org, req.Project, req.From, req.To = "acme", "site", "staging", "production"
+
+			plan, err := planClone(&req, source, existing)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]bool{}
+			for _, l := range plan.result.Cloned {
+				got[l.PathExp.String()+"/"+l.Name] = true
+			}
+			if len(got) != len(tc.cloned) {
+				t.Errorf("got %d cloned, want %d: %v", len(got), len(tc.cloned), got)
+			}
+			for _, c := range tc.cloned {
+				if !got[c] {
+					t.Errorf("%s was not cloned", c)
+				}
+			}
+
+			if len(plan.result.Skipped) != tc.skip {
+				t.Errorf("got %d skipped, want %d", len(plan.result.Skipped), tc.skip)
+			}
+			if plan.result.Shared != 1 {
+				t.Errorf("got %d shared, want 1", plan.result.Shared)
+			}
+			if len(plan.groups) != tc.groups {
+				t.Errorf("got %d groups, want %d", len(plan.groups), tc.groups)
+			}
+			for _, g := range plan.groups {
+				for _, c := range g {
+					if c.Body.CredentialVersion != 0 {
+						t.Errorf("%s kept its credential version", c.Body.Name)
+					}
+				}
+			}
+		})
+	}
+}
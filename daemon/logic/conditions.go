@@ -0,0 +1,270 @@
+package logic
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/policyeval"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+// conditionsTTL is how long the policies used to check conditions are cached
+// for, so reads served by the prefetcher don't wait on the registry.
+const conditionsTTL = time.Minute
+
+// policyConditions enforces the conditions of policy statements, such as
+// business hours or a recent login.
+//
+// The registry enforces the rest of each statement, but does not know about
+// conditions, so the daemon checks them before reading or writing secrets on
+// the session's behalf.
+type policyConditions struct {
+	engine *Engine
+
+	mutex sync.Mutex
+	orgs  map[identity.ID]*actorPolicies
+}
+
+// actorPolicies are the policies attached to the session's teams in an org.
+// Untrusted says why each policy with an invalid signature can't be trusted.
+//
+// They are kept with the offline copy of credentials, so the conditions can
+// be enforced without the registry.
+type actorPolicies struct {
+	fetched time.Time
+
+	Policies     []envelope.Policy `json:"policies"`
+	Untrusted    []string          `json:"untrusted"`
+	Vars         policyeval.Vars   `json:"vars"`
+	MachineTeams []string          `json:"machine_teams"`
+}
+
+func newPolicyConditions(e *Engine) *policyConditions {
+	return &policyConditions{
+		engine: e,
+		orgs:   make(map[identity.ID]*actorPolicies),
+	}
+}
+
+// reset drops the cached policies, as is done when the session changes.
+func (c *policyConditions) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.orgs = make(map[identity.ID]*actorPolicies)
+}
+
+// check returns an error if the statement deciding action on any of the
+// resources in org has a condition that is not met. Resources are policy
+// resources, such as /org/project/env/service/identity/instance/name.
+func (c *policyConditions) check(ctx context.Context, orgID *identity.ID,
+	action primitive.PolicyAction, resources []string) error {
+
+	ap, err := c.get(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	return ap.check(c.engine.session.AuthTime(), action, resources)
+}
+
+// check returns an error if the statement deciding action on any of the
+// resources has a condition that is not met by an actor who last logged in at
+// authenticated.
+func (ap *actorPolicies) check(authenticated time.Time, action primitive.PolicyAction,
+	resources []string) error {
+
+	// A policy that may have been tampered with can't be evaluated, as
+	// ignoring it could lift its conditions.
+	if len(ap.Untrusted) > 0 {
+		return &apitypes.Error{
+			StatusCode: http.StatusForbidden,
+			Type:       apitypes.UnauthorizedError,
+			Err:        ap.Untrusted,
+		}
+	}
+	if len(ap.Policies) == 0 {
+		return nil
+	}
+
+	req := &policyeval.Request{
+		Time:          time.Now(),
+		MachineTeams:  ap.MachineTeams,
+		Authenticated: authenticated,
+	}
+
+	for _, raw := range resources {
+		resource, err := policyeval.ParseResource(strings.ToLower(raw))
+		if err != nil {
+			return err
+		}
+
+		decisions, err := policyeval.Evaluate(ap.Policies, ap.Vars, req, action, resource)
+		if err != nil {
+			return &apitypes.Error{
+				StatusCode: http.StatusForbidden,
+				Type:       apitypes.UnauthorizedError,
+				Err:        []string{"Could not check policy conditions: " + err.Error()},
+			}
+		}
+
+		for _, d := range decisions {
+			if d.Unmet == "" {
+				continue
+			}
+
+			return &apitypes.Error{
+				StatusCode: http.StatusForbidden,
+				Type:       apitypes.UnauthorizedError,
+				Err: []string{"The " + d.Policy.Body.Policy.Name + " policy denies " +
+					d.Action.String() + " on " + raw + ": " + d.Unmet},
+			}
+		}
+	}
+
+	return nil
+}
+
+// get returns the policies attached to the session's teams in org. No
+// policies are returned if none in the org have conditions, and all of their
+// signatures can be trusted.
+func (c *policyConditions) get(ctx context.Context, orgID *identity.ID) (*actorPolicies, error) {
+	c.mutex.Lock()
+	ap, ok := c.orgs[*orgID]
+	c.mutex.Unlock()
+	if ok && time.Since(ap.fetched) < conditionsTTL {
+		return ap, nil
+	}
+
+	ap, err := c.fetch(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	c.orgs[*orgID] = ap
+	c.mutex.Unlock()
+
+	return ap, nil
+}
+
+func (c *policyConditions) fetch(ctx context.Context, orgID *identity.ID) (*actorPolicies, error) {
+	client := c.engine.client
+	ap := &actorPolicies{fetched: time.Now()}
+
+	all, err := client.Policies.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Signatures are checked first, as removing a policy's conditions is
+	// itself tampering.
+	signatures, err := c.engine.verifyPolicies(ctx, orgID, all)
+	if err != nil {
+		return nil, err
+	}
+	if !hasConditions(all) && !hasInvalid(signatures) {
+		return ap, nil
+	}
+
+	org, err := client.Orgs.Get(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := client.Memberships.List(ctx, orgID, nil, c.engine.session.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	teamIDs := make(map[identity.ID]bool, len(memberships))
+	for _, m := range memberships {
+		teamIDs[*m.Body.TeamID] = true
+	}
+
+	attachments, err := client.Policies.AttachmentsList(ctx, orgID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	attached := make(map[identity.ID]bool)
+	for _, a := range attachments {
+		if teamIDs[*a.Body.OwnerID] {
+			attached[*a.Body.PolicyID] = true
+		}
+	}
+
+	for i, p := range all {
+		if !attached[*p.ID] {
+			continue
+		}
+
+		ap.Policies = append(ap.Policies, p)
+		if s := signatures[i]; s.Status == apitypes.PolicyInvalid {
+			ap.Untrusted = append(ap.Untrusted, "The "+p.Body.Policy.Name+
+				" policy can't be trusted: "+s.Problem+". Ask an org admin to create it again.")
+		}
+	}
+
+	ap.Vars.Org = org.Body.Name
+	switch identity := c.engine.session.Self().Identity.(type) {
+	case *envelope.User:
+		ap.Vars.Username = identity.Body.Username
+	case *envelope.Machine:
+		teams, err := client.Teams.List(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range teams {
+			if teamIDs[*t.ID] {
+				ap.MachineTeams = append(ap.MachineTeams, t.Body.Name)
+			}
+		}
+	}
+
+	return ap, nil
+}
+
+func hasInvalid(signatures []apitypes.PolicySignature) bool {
+	for _, s := range signatures {
+		if s.Status == apitypes.PolicyInvalid {
+			return true
+		}
+	}
+	return false
+}
+
+func hasConditions(policies []envelope.Policy) bool {
+	for _, p := range policies {
+		for _, stmt := range p.Body.Policy.Statements {
+			if stmt.Conditions != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// credentialResources returns the policy resource of each credential. If
+// cpath is set, it is used as the path of every credential, as that is where
+// they are being read from.
+func credentialResources(cpath *string, creds []*PlaintextCredentialEnvelope) []string {
+	resources := make([]string, len(creds))
+	for i, cred := range creds {
+		var path string
+		if cpath != nil {
+			path = *cpath
+		} else {
+			path = cred.Body.PathExp.String()
+		}
+		resources[i] = path + "/" + cred.Body.Name
+	}
+	return resources
+}
@@ -75,7 +75,7 @@ func buildGraph(rawPathExp string, version int, secrets ...cred) registry.Creden
 
 		cred := envelope.Credential{
 			ID:      secret.id,
-			Version: 2,
+			Version: 3,
 			Body: &primitive.Credential{
 				BaseCredential: base,
 				State:          secret.state,
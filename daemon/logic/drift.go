@@ -0,0 +1,234 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/observer"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// keypairRenewWindow is how long before they expire the current user's
+// keypairs are reported as needing to be renewed.
+const keypairRenewWindow = 30 * 24 * time.Hour
+
+// staleClaimsHandler finds the current user's keypairs that have expired, or
+// are about to, and renews them. Once a key expires, other members stop
+// trusting the claims made with it, and can no longer share secrets with it.
+type staleClaimsHandler struct {
+	engine *Engine
+}
+
+func (staleClaimsHandler) resolveErr() string {
+	return "Error renewing keypairs"
+}
+
+func (h *staleClaimsHandler) list(ctx context.Context, org *envelope.Org) ([]apitypes.WorklogItem, error) {
+	encClaimed, sigClaimed, err := fetchRegistryKeyPairs(ctx, h.engine.client, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Missing keypairs are reported by the keypairs worklog type.
+	if encClaimed == nil || sigClaimed == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	expires := expiringKeypairs([]*registry.ClaimedKeyPair{encClaimed, sigClaimed},
+		now.Add(keypairRenewWindow))
+	if expires == nil {
+		return nil, nil
+	}
+
+	verb := "expire"
+	if expires.Before(now) {
+		verb = "expired"
+	}
+
+	item := apitypes.WorklogItem{
+		Subject: org.Body.Name,
+		Summary: fmt.Sprintf("Your keypairs for org %s %s on %s, and should be renewed.",
+			org.Body.Name, verb, expires.Format("2006-01-02")),
+	}
+	item.CreateID(apitypes.StaleClaimsWorklogType)
+
+	return []apitypes.WorklogItem{item}, nil
+}
+
+func (h *staleClaimsHandler) resolve(ctx context.Context, n *observer.Notifier,
+	orgID *identity.ID, item *apitypes.WorklogItem) (*apitypes.WorklogResult, error) {
+
+	err := h.engine.RenewKeypairs(ctx, n, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apitypes.WorklogResult{
+		ID:      item.ID,
+		State:   apitypes.SuccessWorklogResult,
+		Message: "Keypairs renewed.",
+	}, nil
+}
+
+// expiringKeypairs returns the earliest expiry of the unrevoked keypairs that
+// expire before the given time, or nil if none do.
+func expiringKeypairs(keypairs []*registry.ClaimedKeyPair, before time.Time) *time.Time {
+	var earliest *time.Time
+	for _, kp := range keypairs {
+		pk := kp.PublicKey.Body
+		if kp.Revoked() || !pk.Expired(before) {
+			continue
+		}
+
+		if earliest == nil || pk.Expires.Before(*earliest) {
+			expires := pk.Expires
+			earliest = &expires
+		}
+	}
+
+	return earliest
+}
+
+// revokedKeysHandler finds keyrings whose master encryption key is only
+// shared with a member through an encryption key they have since revoked, and
+// shares it again with their current key. Until then, the member can't read
+// the secrets in the keyring.
+type revokedKeysHandler struct {
+	engine *Engine
+}
+
+func (revokedKeysHandler) resolveErr() string {
+	return "Error re-encrypting keyring"
+}
+
+func (h *revokedKeysHandler) list(ctx context.Context, org *envelope.Org) ([]apitypes.WorklogItem, error) {
+	graphs, err := listActiveGraphs(ctx, h.engine.client, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := getKeyringMembers(ctx, h.engine.client, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	trees, err := h.engine.client.ClaimTree.List(ctx, org.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	keys := newOrgEncryptionKeys(trees, org.ID, time.Now())
+
+	stale := make(map[string]apitypes.WorklogItem)
+	for _, graph := range graphs {
+		owners, err := keys.revokedMembers(graph, members.For(graph.GetKeyring().PathExp()))
+		if err != nil {
+			return nil, err
+		}
+		if len(owners) == 0 {
+			continue
+		}
+
+		path := graph.GetKeyring().PathExp().String()
+		if _, ok := stale[path]; !ok {
+			item := apitypes.WorklogItem{
+				Subject: path,
+				Summary: "One or more users can only read these secrets with a revoked key.",
+			}
+			item.CreateID(apitypes.RevokedKeysWorklogType)
+			stale[path] = item
+		}
+	}
+
+	return sortedWorklogItems(stale), nil
+}
+
+func (h *revokedKeysHandler) resolve(ctx context.Context, n *observer.Notifier,
+	orgID *identity.ID, item *apitypes.WorklogItem) (*apitypes.WorklogResult, error) {
+
+	trees, err := h.engine.client.ClaimTree.List(ctx, orgID, nil)
+	if err != nil {
+		return nil, err
+	}
+	keys := newOrgEncryptionKeys(trees, orgID, time.Now())
+
+	err = shareKeyrings(ctx, h.engine, orgID, item.Subject, keys.revokedMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apitypes.WorklogResult{
+		ID:      item.ID,
+		State:   apitypes.SuccessWorklogResult,
+		Message: "Keyring shared with current keys.",
+	}, nil
+}
+
+// orgEncryptionKeys records which encryption keys in an org have been
+// revoked, and which owners still have a key that secrets can be shared with.
+type orgEncryptionKeys struct {
+	revoked map[identity.ID]bool
+	usable  map[identity.ID]bool
+}
+
+func newOrgEncryptionKeys(trees []registry.ClaimTree, orgID *identity.ID,
+	now time.Time) *orgEncryptionKeys {
+
+	keys := &orgEncryptionKeys{
+		revoked: make(map[identity.ID]bool),
+		usable:  make(map[identity.ID]bool),
+	}
+
+	for _, tree := range trees {
+		if tree.Org == nil || *tree.Org.ID != *orgID {
+			continue
+		}
+
+		for _, segment := range tree.PublicKeys {
+			key := segment.PublicKey
+			if key.Body.KeyType != primitive.EncryptionKeyType {
+				continue
+			}
+
+			switch {
+			case segment.Revoked():
+				keys.revoked[*key.ID] = true
+			case !key.Body.Expired(now):
+				keys.usable[*key.Body.OwnerID] = true
+			}
+		}
+	}
+
+	return keys
+}
+
+// revokedMembers returns the members whose membership in the keyring of graph
+// is encrypted for a revoked key, and who have a current key to share it with
+// instead. Members without any membership are left to the keyring worklog
+// type.
+func (k *orgEncryptionKeys) revokedMembers(graph registry.CredentialGraph,
+	members []identity.ID) ([]identity.ID, error) {
+
+	var revoked []identity.ID
+	for _, member := range members {
+		m, _, err := graph.FindMember(&member)
+		if err == registry.ErrMemberNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if m.PublicKeyID != nil && k.revoked[*m.PublicKeyID] && k.usable[member] {
+			revoked = append(revoked, member)
+		}
+	}
+
+	return revoked, nil
+}
@@ -0,0 +1,107 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+func keySegment(id byte, owner identity.ID, expires time.Time, revoked bool) apitypes.PublicKeySegment {
+	s := apitypes.PublicKeySegment{
+		PublicKey: &envelope.PublicKey{
+			ID: &identity.ID{0x01, 0x06, id},
+			Body: &primitive.PublicKey{
+				OwnerID: &owner,
+				KeyType: primitive.EncryptionKeyType,
+				Expires: expires,
+			},
+		},
+	}
+	if revoked {
+		s.Claims = []envelope.Claim{
+			{Body: &primitive.Claim{ClaimType: primitive.RevocationClaimType}},
+		}
+	}
+	return s
+}
+
+func TestExpiringKeypairs(t *testing.T) {
+	owner := identity.ID{0x01, 0x01, 0x01}
+	now := time.Now()
+
+	claimed := func(id byte, expires time.Time, revoked bool) *registry.ClaimedKeyPair {
+		return &registry.ClaimedKeyPair{PublicKeySegment: keySegment(id, owner, expires, revoked)}
+	}
+
+	soon := now.AddDate(0, 0, 10)
+	keypairs := []*registry.ClaimedKeyPair{
+		claimed(1, now.AddDate(1, 0, 0), false),
+		claimed(2, soon, false),
+		claimed(3, now.AddDate(0, 0, -1), true),
+	}
+
+	expires := expiringKeypairs(keypairs, now.Add(keypairRenewWindow))
+	if expires == nil || !expires.Equal(soon) {
+		t.Errorf("got expiry %v, want %v", expires, soon)
+	}
+
+	if expires := expiringKeypairs(keypairs[:1], now.Add(keypairRenewWindow)); expires != nil {
+		t.Errorf("expected no expiring keypairs, got %v", expires)
+	}
+}
+
+func TestRevokedMembers(t *testing.T) {
+	orgID := identity.ID{0x01, 0x04, 0x01}
+	renewed := identity.ID{0x01, 0x01, 0x01}
+	current := identity.ID{0x01, 0x01, 0x02}
+	gone := identity.ID{0x01, 0x01, 0x03}
+	missing := identity.ID{0x01, 0x01, 0x04}
+	now := time.Now()
+	year := now.AddDate(1, 0, 0)
+
+	trees := []registry.ClaimTree{{
+		Org: &envelope.Org{ID: &orgID},
+		PublicKeys: []apitypes.PublicKeySegment{
+			keySegment(1, renewed, year, true),
+			keySegment(2, renewed, year, false),
+			keySegment(3, current, year, false),
+			keySegment(4, gone, year, true),
+			keySegment(5, missing, year, false),
+		},
+	}}
+	keys := newOrgEncryptionKeys(trees, &orgID, now)
+
+	member := func(id byte, owner identity.ID, key byte) registry.KeyringMember {
+		return registry.KeyringMember{Member: &envelope.KeyringMember{
+			ID: &identity.ID{0x01, 0x0a, id},
+			Body: &primitive.KeyringMember{
+				Created:     now,
+				OwnerID:     &owner,
+				PublicKeyID: &identity.ID{0x01, 0x06, key},
+			},
+		}}
+	}
+
+	graph := &registry.CredentialGraphV2{KeyringSectionV2: registry.KeyringSectionV2{
+		Members: []registry.KeyringMember{
+			member(1, renewed, 1),
+			member(2, current, 3),
+			member(3, gone, 4),
+		},
+	}}
+
+	owners, err := keys.revokedMembers(graph, []identity.ID{renewed, current, gone, missing})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(owners) != 1 || owners[0] != renewed {
+		t.Errorf("got owners %v, want only %s", owners, &renewed)
+	}
+}
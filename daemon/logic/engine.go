@@ -6,16 +6,21 @@ package logic
 import (
 	"context"
 	"log"
+	"net/http"
+	"sort"
+	"time"
 
 	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/base64"
 	"github.com/manifoldco/torus-cli/config"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
 	"github.com/manifoldco/torus-cli/primitive"
 
 	"github.com/manifoldco/torus-cli/daemon/crypto"
 	"github.com/manifoldco/torus-cli/daemon/db"
+	"github.com/manifoldco/torus-cli/daemon/lease"
 	"github.com/manifoldco/torus-cli/daemon/observer"
 	"github.com/manifoldco/torus-cli/daemon/registry"
 	"github.com/manifoldco/torus-cli/daemon/session"
@@ -27,15 +32,24 @@ import (
 // All data passing in and out of the engine is unencrypted for the currently
 // logged in user.
 type Engine struct {
-	config  *config.Config
-	session session.Session
-	db      *db.DB
-	crypto  *crypto.Engine
-	client  *registry.Client
+	config     *config.Config
+	session    session.Session
+	db         *db.DB
+	crypto     *crypto.Engine
+	client     *registry.Client
+	trust      *keyTrust
+	prefetch   *prefetcher
+	audit      *auditLog
+	conditions *policyConditions
+	events     *eventHub
+	search     *searchIndex
+	keys       *keyringKeys
+	backends   []*namedBackend
 
 	Worklog Worklog
 	Machine Machine
 	Session Session
+	Leases  *lease.Store
 }
 
 // NewEngine returns a new Engine
@@ -48,21 +62,129 @@ func NewEngine(c *config.Config, s session.Session, db *db.DB, e *crypto.Engine,
 		crypto:  e,
 		client:  client,
 	}
+	engine.trust = newKeyTrust(engine)
+	engine.prefetch = newPrefetcher(engine, c.Prefetch)
+	engine.audit = newAuditLog(c.AuditLogPath)
+	engine.conditions = newPolicyConditions(engine)
+	engine.events = newEventHub(client.Events.List)
+	engine.search = newSearchIndex()
+	engine.keys = newKeyringKeys()
 	engine.Worklog = newWorklog(engine)
 	engine.Machine = Machine{engine: engine}
 	engine.Session = Session{engine: engine}
+	client.OnUnauthorized(engine.Session.TokenRejected)
+	engine.Leases = lease.NewStore()
 	return engine
 }
 
 // AppendCredential attempts to append a plain-text Credential object to the
 // Credential Graph.
+//
+// The credential is written as the next version of the latest one. Unless
+// force is true, the write fails with a conflict if that is not the version
+// the caller last saw, as given by the credential's Previous and
+// CredentialVersion, or if another version is written in the meantime.
 func (e *Engine) AppendCredential(ctx context.Context, notifier *observer.Notifier,
-	cred *PlaintextCredentialEnvelope) (*PlaintextCredentialEnvelope, error) {
+	cred *PlaintextCredentialEnvelope, force, confirmed bool) (*PlaintextCredentialEnvelope, error) {
 
-	n := notifier.Notifier(4)
+	creds, err := e.AppendCredentials(ctx, notifier,
+		[]*PlaintextCredentialEnvelope{cred}, force, confirmed)
+	if err != nil {
+		return nil, err
+	}
+
+	return creds[0], nil
+}
+
+// AppendCredentials appends many plain-text Credential objects that share a
+// single path expression to the Credential Graph at once.
+//
+// The credentials are encrypted with the same keyring, and sent to the
+// registry in a single request. Like AppendCredential, unless force is true,
+// the write fails with a conflict if any of them was changed in the meantime.
+//
+// Writes to an environment the org has protected fail unless confirmed is
+// true, and writes matching one through a wildcard always fail.
+func (e *Engine) AppendCredentials(ctx context.Context, notifier *observer.Notifier,
+	creds []*PlaintextCredentialEnvelope, force, confirmed bool) ([]*PlaintextCredentialEnvelope, error) {
+
+	if len(creds) == 0 {
+		return creds, nil
+	}
+
+	first := creds[0].Body
+	for _, cred := range creds[1:] {
+		if cred.Body.PathExp.String() != first.PathExp.String() {
+			return nil, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"All secrets must be set at the same path"},
+			}
+		}
+	}
+
+	err := e.checkProtectedEnvs(ctx, first.OrgID, first.PathExp, confirmed)
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.conditions.check(ctx, first.OrgID, primitive.PolicyActionUpdate,
+		credentialResources(nil, creds))
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := e.writeBackend(first.PathExp)
+	if err != nil {
+		return nil, err
+	}
+	if b != nil {
+		err = b.Append(ctx, creds)
+		if err != nil {
+			log.Printf("Error writing credentials to backend %s: %s", b.name, err)
+			return nil, err
+		}
+		return creds, nil
+	}
+
+	_, err = e.appendCredentials(ctx, notifier, creds, force, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return creds, nil
+}
+
+// appendCredentials encrypts and stores creds, which must all belong in the
+// same keyring. If rotate is true, a new version of the keyring is always
+// created for them, rather than only when its members have changed. The
+// keyring the credentials were stored in is returned.
+func (e *Engine) appendCredentials(ctx context.Context, notifier *observer.Notifier,
+	creds []*PlaintextCredentialEnvelope, force, rotate bool) (registry.CredentialGraph, error) {
+
+	first := creds[0].Body
+	gpe, err := first.PathExp.WithInstance("*")
+	if err != nil {
+		return nil, err
+	}
+	for _, cred := range creds[1:] {
+		cpe, err := cred.Body.PathExp.WithInstance("*")
+		if err != nil {
+			return nil, err
+		}
+		if cpe.String() != gpe.String() {
+			return nil, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"All secrets must be set at the same path"},
+			}
+		}
+	}
+
+	n := notifier.Notifier(3 + uint(len(creds)))
 
 	// Ensure we have an existing keyring for this credential's pathexp
-	graphs, err := e.client.CredentialGraph.List(ctx, "", cred.Body.PathExp,
+	graphs, err := e.client.CredentialGraph.List(ctx, "", first.PathExp,
 		e.session.AuthID())
 	if err != nil {
 		log.Printf("Error retrieving credential graphs: %s", err)
@@ -71,7 +193,7 @@ func (e *Engine) AppendCredential(ctx context.Context, notifier *observer.Notifi
 
 	n.Notify(observer.Progress, "Credentials retrieved", true)
 
-	sigID, encID, kp, err := fetchKeyPairs(ctx, e.client, cred.Body.OrgID)
+	sigID, encID, kp, err := fetchWritableKeyPairs(ctx, e.client, first.OrgID)
 	if err != nil {
 		log.Printf("Error fetching keypairs: %s", err)
 		return nil, err
@@ -86,23 +208,36 @@ func (e *Engine) AppendCredential(ctx context.Context, notifier *observer.Notifi
 	}
 
 	// Find the credentialgraph/keyring that we should store our credential in
-	graph, err := cgs.Head(cred.Body.PathExp)
+	graph, err := cgs.Head(first.PathExp)
 	if err != nil {
 		return nil, err
 	}
 
-	// Find the  most recent version of this credential to act as our previous.
-	previousCred, err := cgs.HeadCredential(cred.Body.PathExp, cred.Body.Name)
+	err = checkFrozenWrite(graph)
 	if err != nil {
-		log.Printf("error finding credentials to match: %s", err)
 		return nil, err
 	}
 
+	expired := false
+	if graph != nil && !graph.HasRevocations() && !rotate {
+		trees, err := e.client.ClaimTree.List(ctx, first.OrgID, nil)
+		if err != nil {
+			log.Printf("Error retrieving claim tree: %s", err)
+			return nil, err
+		}
+		expired = hasExpiredMembers(graph, trees, time.Now())
+	}
+
 	var newGraph *registry.CredentialGraphV2
-	// No matching CredentialGraph/KeyRing for this credential.
-	// We'll make a new one now.
-	if graph == nil || graph.HasRevocations() {
-		newGraph, err = createCredentialGraph(ctx, cred.Body, graph,
+	var replaced *identity.ID
+	// No matching CredentialGraph/KeyRing for this credential, or the one we
+	// have is shared with keys that may no longer be trusted. We'll make a new
+	// one now.
+	if graph == nil || graph.HasRevocations() || expired || rotate {
+		if graph != nil {
+			replaced = graph.GetKeyring().GetID()
+		}
+		newGraph, err = createCredentialGraph(ctx, first, graph,
 			sigID, encID, kp, e.client, e.crypto)
 		if err != nil {
 			log.Printf("error creating credential graph: %s", err)
@@ -112,37 +247,13 @@ func (e *Engine) AppendCredential(ctx context.Context, notifier *observer.Notifi
 		graph = newGraph
 	}
 
-	// Construct an encrypted and signed version of the credential
-	credBody := primitive.Credential{
-		State: cred.Body.State,
-		BaseCredential: primitive.BaseCredential{
-			Name:      cred.Body.Name,
-			PathExp:   cred.Body.PathExp,
-			KeyringID: graph.GetKeyring().GetID(),
-			ProjectID: cred.Body.ProjectID,
-			OrgID:     cred.Body.OrgID,
-			Credential: &primitive.CredentialValue{
-				Algorithm: crypto.SecretBox,
-			},
-		},
-	}
-
-	if previousCred == nil {
-		log.Printf("no previous")
-		credBody.Previous = nil
-		credBody.CredentialVersion = 1
-	} else {
-		credBody.Previous = previousCred.GetID()
-		credBody.CredentialVersion = previousCred.CredentialVersion() + 1
-	}
-
 	krm, mekshare, err := graph.FindMember(e.session.AuthID())
 	if err != nil {
 		log.Printf("Error finding keyring membership: %s", err)
 		return nil, err
 	}
 
-	encryptingKey, err := findEncryptingKey(ctx, e.client, credBody.OrgID,
+	encryptingKey, err := findEncryptingKey(ctx, e.client, first.OrgID,
 		krm.EncryptingKeyID)
 	if err != nil {
 		log.Printf("Error finding encrypting key: %s", err)
@@ -151,46 +262,169 @@ func (e *Engine) AppendCredential(ctx context.Context, notifier *observer.Notifi
 
 	n.Notify(observer.Progress, "Encrypting key retrieved", true)
 
-	// Derive a key for the credential using the keyring master key
-	// and use the derived key to encrypt the credential
-	cekNonce, ctNonce, ct, err := e.crypto.BoxCredential(
-		ctx, []byte(cred.Body.Value), *mekshare.Key.Value, *mekshare.Key.Nonce,
-		&kp.Encryption, *encryptingKey.Key.Value)
-	if err != nil {
-		log.Printf("Error encrypting credential: %s", err)
-		return nil, err
+	signed := make([]envelope.CredentialInf, len(creds))
+	previous := []*identity.ID{}
+	for i, cred := range creds {
+		// Find the  most recent version of this credential to act as our previous.
+		previousCred, err := cgs.HeadCredential(cred.Body.PathExp, cred.Body.Name)
+		if err != nil {
+			log.Printf("error finding credentials to match: %s", err)
+			return nil, err
+		}
+
+		if !force && !isExpectedVersion(cred.Body, previousCred) {
+			// The caller may have seen a stale version through a cached
+			// search, so drop it before they try again.
+			e.prefetch.reset(false)
+			return nil, writeConflict(creds)
+		}
+
+		// Construct an encrypted and signed version of the credential
+		credBody := primitive.Credential{
+			State:          cred.Body.State,
+			CredentialMeta: cred.Body.CredentialMeta,
+			BaseCredential: primitive.BaseCredential{
+				Name:      cred.Body.Name,
+				PathExp:   cred.Body.PathExp,
+				KeyringID: graph.GetKeyring().GetID(),
+				ProjectID: cred.Body.ProjectID,
+				OrgID:     cred.Body.OrgID,
+				Credential: &primitive.CredentialValue{
+					Algorithm: crypto.SecretBox,
+				},
+			},
+		}
+
+		// New values keep the metadata of the version they replace, unless
+		// they come with their own.
+		unset := cred.Body.State != nil && *cred.Body.State == "unset"
+		if previousCred != nil && credBody.CredentialMeta.Empty() && !unset {
+			credBody.CredentialMeta = *previousCred.Meta()
+		}
+
+		if previousCred == nil {
+			credBody.Previous = nil
+			credBody.CredentialVersion = 1
+		} else {
+			credBody.Previous = previousCred.GetID()
+			credBody.CredentialVersion = previousCred.CredentialVersion() + 1
+			previous = append(previous, credBody.Previous)
+		}
+
+		// Derive a key for the credential using the keyring master key
+		// and use the derived key to encrypt the credential
+		cekNonce, ctNonce, ct, err := e.crypto.BoxCredential(
+			ctx, []byte(cred.Body.Value), *mekshare.Key.Value, *mekshare.Key.Nonce,
+			&kp.Encryption, *encryptingKey.Key.Value)
+		if err != nil {
+			log.Printf("Error encrypting credential: %s", err)
+			return nil, err
+		}
+
+		credBody.Nonce = base64.NewValue(cekNonce)
+
+		credBody.Credential.Nonce = base64.NewValue(ctNonce)
+		credBody.Credential.Value = base64.NewValue(ct)
+
+		// Credentials without metadata are written with the v2 schema, so
+		// registries and clients that predate v3 can still read them.
+		if credBody.CredentialMeta.Empty() {
+			signed[i], err = e.crypto.SignedCredentialV2(ctx, &primitive.CredentialV2{
+				BaseCredential: credBody.BaseCredential,
+				State:          credBody.State,
+			}, sigID, &kp.Signature)
+		} else {
+			signed[i], err = e.crypto.SignedCredential(ctx, &credBody, sigID, &kp.Signature)
+		}
+		if err != nil {
+			log.Printf("Error signing credential body: %s", err)
+			return nil, err
+		}
+
+		n.Notify(observer.Progress, "Credential encrypted", true)
 	}
 
-	credBody.Nonce = base64.NewValue(cekNonce)
+	if force {
+		previous = nil
+	}
 
-	credBody.Credential.Nonce = base64.NewValue(ctNonce)
-	credBody.Credential.Value = base64.NewValue(ct)
+	switch {
+	case newGraph != nil:
+		newGraph.Credentials = signed
+		_, err = e.client.CredentialGraph.Post(ctx, &graph, replaced)
+	case len(signed) == 1:
+		var prev *identity.ID
+		if len(previous) > 0 {
+			prev = previous[0]
+		}
+		_, err = e.client.Credentials.Create(ctx, signed[0], prev)
+	default:
+		_, err = e.client.Credentials.CreateBatch(ctx, signed, previous)
+	}
 
-	signed, err := e.crypto.SignedCredential(ctx, &credBody, sigID, &kp.Signature)
 	if err != nil {
-		log.Printf("Error signing credential body: %s", err)
+		log.Printf("error creating credential: %s", err)
+		if isWriteConflict(err) {
+			return nil, writeConflict(creds)
+		}
 		return nil, err
 	}
 
-	n.Notify(observer.Progress, "Credential encrypted", true)
+	e.prefetch.reset(false)
+	return graph, nil
+}
 
-	if newGraph != nil {
-		newGraph.Credentials = []envelope.CredentialInf{signed}
-		_, err = e.client.CredentialGraph.Post(ctx, &graph)
-	} else {
-		_, err = e.client.Credentials.Create(ctx, signed)
+// isExpectedVersion returns whether head, the latest version of cred, is the
+// version the caller last saw, as given by cred's Previous and
+// CredentialVersion. A caller that saw no version expects the credential to
+// be missing or unset. Callers that don't say what they saw expect any
+// version.
+func isExpectedVersion(cred *PlaintextCredential, head envelope.CredentialInf) bool {
+	if cred.CredentialVersion == 0 {
+		return true
+	}
+
+	if cred.Previous == nil {
+		return head == nil || head.Unset()
+	}
+
+	return head != nil && *head.GetID() == *cred.Previous
+}
+
+// writeConflict returns the error for creds having been changed by someone
+// else while they were being written.
+func writeConflict(creds []*PlaintextCredentialEnvelope) error {
+	first := creds[0].Body
+	msg := "Secret " + first.Name + " was changed by someone else while it was being set."
+	if len(creds) > 1 {
+		msg = "Secrets at " + first.PathExp.String() +
+			" were changed by someone else while they were being set."
+	}
+
+	return &apitypes.Error{
+		StatusCode: http.StatusConflict,
+		Type:       apitypes.ConflictError,
+		Err:        []string{msg},
 	}
+}
+
+// RetrieveCredentials returns all credentials for the given CPath string,
+// from the registry and any backends configured for it. Dynamic secrets are
+// returned with newly minted values.
+func (e *Engine) RetrieveCredentials(ctx context.Context,
+	notifier *observer.Notifier, cpath, cpathexp *string) ([]PlaintextCredentialEnvelope, error) {
 
+	creds, err := e.resolveCredentials(ctx, notifier, cpath, cpathexp)
 	if err != nil {
-		log.Printf("error creating credential: %s", err)
 		return nil, err
 	}
 
-	return cred, nil
+	return e.brokerCredentials(ctx, creds)
 }
 
-// RetrieveCredentials returns all credentials for the given CPath string
-func (e *Engine) RetrieveCredentials(ctx context.Context,
+// retrieveCredentials returns the credentials for the given CPath string as
+// they are stored, with the configuration of dynamic secrets as their values.
+func (e *Engine) retrieveCredentials(ctx context.Context,
 	notifier *observer.Notifier, cpath, cpathexp *string) ([]PlaintextCredentialEnvelope, error) {
 	if cpath != nil && cpathexp != nil {
 		panic("cannot use both cpath and cpathexp")
@@ -199,101 +433,158 @@ func (e *Engine) RetrieveCredentials(ctx context.Context,
 		panic("cpath or cpathexp required")
 	}
 
-	var err error
-	var graphs []registry.CredentialGraph
+	// Memoized keyring keys share the prefetcher's generation too.
+	generation := e.prefetch.current()
+
+	var bundle *credentialBundle
 	if cpath != nil {
-		graphs, err = e.client.CredentialGraph.List(ctx, *cpath, nil, e.session.AuthID())
-	} else if cpathexp != nil {
-		graphs, err = e.client.CredentialGraph.Search(ctx, *cpathexp, e.session.AuthID())
+		bundle = e.prefetch.get(*cpath)
+	}
+
+	if bundle == nil {
+		var err error
+		bundle, err = e.fetchCredentialBundle(ctx, cpath, cpathexp)
+		if err != nil {
+			return nil, err
+		}
+
+		if cpath != nil {
+			e.prefetch.store(*cpath, bundle, generation)
+		}
 	}
+
+	err := e.checkFrozenReads(ctx, bundle.graphs)
 	if err != nil {
-		log.Printf("error retrieving credential graphs: %s", err)
 		return nil, err
 	}
 
-	cgs := newCredentialGraphSet()
-	err = cgs.Add(graphs...)
+	var steps uint = 1
+	for _, graph := range bundle.graphs {
+		steps += uint(len(graph.GetCredentials()))
+	}
+
+	n := notifier.Notifier(steps)
+	n.Notify(observer.Progress, "Credentials retrieved", true)
+
+	creds, err := e.decryptBundle(ctx, n, bundle, generation)
 	if err != nil {
 		return nil, err
 	}
 
-	activeGraphs, err := cgs.Prune()
+	err = e.checkReadConditions(ctx, cpath, creds)
 	if err != nil {
 		return nil, err
 	}
 
-	var steps uint = 1
-	for _, graph := range activeGraphs {
-		steps += uint(len(graph.GetCredentials()))
+	e.recordReads(creds)
+	return creds, nil
+}
+
+// checkReadConditions enforces policy conditions on reading creds, which
+// were retrieved for cpath, if set.
+func (e *Engine) checkReadConditions(ctx context.Context, cpath *string,
+	creds []PlaintextCredentialEnvelope) error {
+
+	byOrg := make(map[identity.ID][]*PlaintextCredentialEnvelope)
+	for i := range creds {
+		orgID := *creds[i].Body.OrgID
+		byOrg[orgID] = append(byOrg[orgID], &creds[i])
 	}
 
-	n := notifier.Notifier(steps)
-	n.Notify(observer.Progress, "Credentials retrieved", true)
+	for orgID, orgCreds := range byOrg {
+		id := orgID
+		err := e.conditions.check(ctx, &id, primitive.PolicyActionRead,
+			credentialResources(cpath, orgCreds))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WatchCredentials registers a CPath for the daemon to prefetch credentials
+// for while it is idle.
+func (e *Engine) WatchCredentials(cpath string) {
+	e.prefetch.watch(cpath)
+}
+
+// RunPrefetch keeps the credentials for watched CPaths cached while the daemon
+// is idle. It blocks until ctx is done, and returns immediately if prefetching
+// is disabled.
+func (e *Engine) RunPrefetch(ctx context.Context) {
+	e.prefetch.run(ctx)
+}
+
+// PrefetchStats returns the current state of the credential prefetch cache.
+func (e *Engine) PrefetchStats() *apitypes.PrefetchStats {
+	return e.prefetch.stats()
+}
+
+// CredentialHistory returns every version of the named credential at the
+// given path expression, newest first.
+//
+// Versions stored in keyrings we are not a member of, or were shared with one
+// of our older keys, cannot be decrypted, and are left out.
+func (e *Engine) CredentialHistory(ctx context.Context, notifier *observer.Notifier,
+	pe *pathexp.PathExp, name string) ([]PlaintextCredentialEnvelope, error) {
 
-	keypairs := make(map[identity.ID]*crypto.KeyPairs)
-	encryptingKeys := make(map[identity.ID]*primitive.PublicKey)
+	n := notifier.Notifier(3)
+
+	graphs, err := e.client.CredentialGraph.List(ctx, "", pe, e.session.AuthID())
+	if err != nil {
+		log.Printf("Error retrieving credential graphs: %s", err)
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Credentials retrieved", true)
 
-	// Loop over the trees and unpack the credentials; later on we will
-	// actually do real work and decrypt each of these credentials but for
-	// now we just need ot return a list of them!
 	creds := []PlaintextCredentialEnvelope{}
-	for _, graph := range activeGraphs {
-		orgID := graph.GetKeyring().OrgID()
-		kp, ok := keypairs[*orgID]
-		if !ok {
-			_, _, kp, err = fetchKeyPairs(ctx, e.client, orgID)
-			if err != nil {
-				log.Printf("Error fetching keypairs: %s", err)
-				return nil, err
-			}
-			keypairs[*orgID] = kp
-		}
+	if len(graphs) == 0 {
+		return creds, nil
+	}
+
+	err = e.checkFrozenReads(ctx, graphs)
+	if err != nil {
+		return nil, err
+	}
 
+	_, encID, kp, err := fetchKeyPairs(ctx, e.client, graphs[0].GetKeyring().OrgID())
+	if err != nil {
+		log.Printf("Error fetching keypairs: %s", err)
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Keypairs retrieved", true)
+
+	for _, graph := range graphs {
 		krm, mekshare, err := graph.FindMember(e.session.AuthID())
+		if err == registry.ErrMemberNotFound || (err == nil && *krm.PublicKeyID != *encID) {
+			continue
+		}
 		if err != nil {
 			log.Printf("Error finding keyring membership: %s", err)
 			return nil, err
 		}
 
-		encryptingKey, ok := encryptingKeys[*krm.EncryptingKeyID]
-		if !ok {
-			encryptingKey, err = findEncryptingKey(ctx, e.client, orgID,
-				krm.EncryptingKeyID)
-			if err != nil {
-				log.Printf("Error finding encrypting key for user: %s", err)
-				return nil, err
-			}
-			encryptingKeys[*krm.EncryptingKeyID] = encryptingKey
+		encryptingKey, err := findEncryptingKey(ctx, e.client, krm.OrgID,
+			krm.EncryptingKeyID)
+		if err != nil {
+			log.Printf("Error finding encrypting key: %s", err)
+			return nil, err
 		}
 
 		err = e.crypto.WithUnboxer(ctx, *mekshare.Key.Value, *mekshare.Key.Nonce, &kp.Encryption, *encryptingKey.Key.Value, func(u crypto.Unboxer) error {
 			for _, cred := range graph.GetCredentials() {
-				state := "set"
-				if cred.Unset() {
-					state = "unset"
+				if cred.Name() != name || cred.PathExp().String() != pe.String() {
+					continue
 				}
 
-				pt, err := u.Unbox(ctx, *cred.Credential().Value, *cred.Nonce(), *cred.Credential().Nonce)
+				plainCred, err := decryptCredential(ctx, u, cred)
 				if err != nil {
-					log.Printf("Error decrypting credential: %s", err)
 					return err
 				}
-
-				plainCred := PlaintextCredentialEnvelope{
-					ID:      cred.GetID(),
-					Version: cred.GetVersion(),
-					Body: &PlaintextCredential{
-						Name:      cred.Name(),
-						PathExp:   cred.PathExp(),
-						ProjectID: cred.ProjectID(),
-						OrgID:     cred.OrgID(),
-						Value:     string(pt),
-						State:     &state,
-					},
-				}
-				creds = append(creds, plainCred)
-
-				n.Notify(observer.Progress, "Credential decrypted", true)
+				creds = append(creds, *plainCred)
 			}
 			return nil
 		})
@@ -302,9 +593,106 @@ func (e *Engine) RetrieveCredentials(ctx context.Context,
 		}
 	}
 
+	sort.Sort(credentialHistorySorter(creds))
+	e.recordReads(creds)
+	n.Notify(observer.Progress, "Credentials decrypted", true)
+
 	return creds, nil
 }
 
+// decryptCredential decrypts a single credential with u.
+func decryptCredential(ctx context.Context, u crypto.Unboxer,
+	cred envelope.CredentialInf) (*PlaintextCredentialEnvelope, error) {
+
+	state := "set"
+	if cred.Unset() {
+		state = "unset"
+	}
+
+	pt, err := u.Unbox(ctx, *cred.Credential().Value, *cred.Nonce(), *cred.Credential().Nonce)
+	if err != nil {
+		log.Printf("Error decrypting credential: %s", err)
+		return nil, err
+	}
+
+	return &PlaintextCredentialEnvelope{
+		ID:      cred.GetID(),
+		Version: cred.GetVersion(),
+		Body: &PlaintextCredential{
+			Name:              cred.Name(),
+			PathExp:           cred.PathExp(),
+			ProjectID:         cred.ProjectID(),
+			OrgID:             cred.OrgID(),
+			Value:             string(pt),
+			State:             &state,
+			Previous:          cred.Previous(),
+			CredentialVersion: cred.CredentialVersion(),
+			CredentialMeta:    *cred.Meta(),
+		},
+	}, nil
+}
+
+// credentialHistorySorter sorts credentials newest version first.
+type credentialHistorySorter []PlaintextCredentialEnvelope
+
+func (c credentialHistorySorter) Len() int      { return len(c) }
+func (c credentialHistorySorter) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c credentialHistorySorter) Less(i, j int) bool {
+	return c[i].Body.CredentialVersion > c[j].Body.CredentialVersion
+}
+
+// credentialBundle holds everything needed to decrypt the credentials for a
+// path: the active credential graphs, and the keypairs and encrypting keys
+// they are shared with. Everything in it is still encrypted, so it is safe to
+// cache.
+type credentialBundle struct {
+	graphs         []registry.CredentialGraph
+	keypairs       map[identity.ID]*crypto.KeyPairs
+	encryptingKeys map[identity.ID]*primitive.PublicKey
+}
+
+// fetchCredentialBundle retrieves the credential bundle for the given CPath
+// or CPathExp from the registry.
+func (e *Engine) fetchCredentialBundle(ctx context.Context, cpath,
+	cpathexp *string) (*credentialBundle, error) {
+
+	var err error
+	var graphs []registry.CredentialGraph
+	if cpath != nil {
+		graphs, err = e.client.CredentialGraph.List(ctx, *cpath, nil, e.session.AuthID())
+	} else if cpathexp != nil {
+		graphs, err = e.client.CredentialGraph.Search(ctx, *cpathexp, e.session.AuthID())
+	}
+	if err != nil {
+		log.Printf("error retrieving credential graphs: %s", err)
+		return nil, err
+	}
+
+	cgs := newCredentialGraphSet()
+	err = cgs.Add(graphs...)
+	if err != nil {
+		return nil, err
+	}
+
+	activeGraphs, err := cgs.Prune()
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &credentialBundle{
+		graphs:         activeGraphs,
+		keypairs:       make(map[identity.ID]*crypto.KeyPairs),
+		encryptingKeys: make(map[identity.ID]*primitive.PublicKey),
+	}
+
+	err = e.fetchBundleKeys(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
 // ApproveInvite approves an invitation of a user into an organzation by
 // encoding them into a Keyring.
 func (e *Engine) ApproveInvite(ctx context.Context, notifier *observer.Notifier,
@@ -328,8 +716,14 @@ func (e *Engine) ApproveInvite(ctx context.Context, notifier *observer.Notifier,
 
 	n.Notify(observer.Progress, "Invite retrieved", true)
 
+	scopes, err := getInviteGuestScopes(ctx, e.client, invite)
+	if err != nil {
+		log.Printf("could not determine guest scopes for invite: %s", err)
+		return nil, err
+	}
+
 	v1members, v2members, err := createKeyringMemberships(ctx, e.crypto,
-		e.client, e.session, invite.Body.OrgID, invite.Body.InviteeID)
+		e.client, e.session, invite.Body.OrgID, invite.Body.InviteeID, scopes)
 	if err != nil {
 		return nil, err
 	}
@@ -363,59 +757,66 @@ func (e *Engine) ApproveInvite(ctx context.Context, notifier *observer.Notifier,
 	return invite, nil
 }
 
-// GenerateKeypairs creates a signing and encrypting keypair for the current
+// GenerateKeypairs creates signing and encrypting keypairs for the current
 // user for the given organization.
 func (e *Engine) GenerateKeypairs(ctx context.Context, notifier *observer.Notifier,
 	OrgID *identity.ID) error {
 
 	n := notifier.Notifier(4)
 
-	kp, err := e.crypto.GenerateKeyPairs(ctx)
+	_, err := e.createKeypairs(ctx, n, OrgID, nil)
 	if err != nil {
-		log.Printf("Error generating keypairs: %s", err)
 		return err
 	}
 
-	n.Notify(observer.Progress, "Keypairs generated", true)
+	e.prefetch.reset(false)
 
-	pubsig, privsig, err := packageSigningKeypair(ctx, e.crypto, e.session.AuthID(),
-		OrgID, kp)
-	if err != nil {
-		log.Printf("Error packaging signing keypair: %s", err)
-		return err
-	}
+	// With keys in place the user can start sharing secrets in this org.
+	// Verify everyone else's keys now, so that work is already done.
+	e.trust.bootstrap(OrgID)
 
-	sigBody := primitive.NewClaim(OrgID, e.session.AuthID(), pubsig.ID, pubsig.ID,
-		primitive.SignatureClaimType)
-	sigclaim, err := e.crypto.SignedClaim(ctx, sigBody, pubsig.ID, &kp.Signature)
-	if err != nil {
-		log.Printf("Error creating signature claim: %s", err)
-		return err
-	}
+	return nil
+}
 
-	n.Notify(observer.Progress, "Signing keys signed", true)
+// createdKeypairs are newly uploaded keypairs, along with the self-signed
+// claims made against them.
+type createdKeypairs struct {
+	kp       *crypto.KeyPairs
+	sigKey   *envelope.PublicKey
+	sigClaim *envelope.Claim
+	encKey   *envelope.PublicKey
+	encClaim *envelope.Claim
+}
 
-	pubsig, privsig, claims, err := e.client.KeyPairs.Post(ctx, pubsig,
-		privsig, sigclaim)
+// createKeypairs generates new signing and encrypting keypairs for the
+// current user in the given org, and uploads them with self-signed claims.
+//
+// If sig is given, it is used as the new signing keypair, and only an
+// encryption keypair is created.
+func (e *Engine) createKeypairs(ctx context.Context, n *observer.Notifier,
+	OrgID *identity.ID, sig *registry.ClaimedKeyPair) (*createdKeypairs, error) {
+
+	kp, err := e.crypto.GenerateKeyPairs(ctx)
 	if err != nil {
-		log.Printf("Error uploading signature keypair: %s", err)
-		return err
+		log.Printf("Error generating keypairs: %s", err)
+		return nil, err
 	}
 
-	objs := make([]envelope.Envelope, len(claims)+2)
-	objs[0] = pubsig
-	objs[1] = privsig
-	for i, claim := range claims {
-		objs[i+2] = &claim
+	n.NotifyStep(observer.Progress, apitypes.KeypairStepDerive, "Keypairs generated", true)
+
+	var pubsig *envelope.PublicKey
+	var sigHead *envelope.Claim
+	if sig == nil {
+		pubsig, sigHead, err = e.uploadSigningKeypair(ctx, n, OrgID, kp)
+	} else {
+		kp.Signature = bundleKeypairs(sig, nil).Signature
+		pubsig = sig.PublicKey
+		sigHead, err = sig.HeadClaim()
 	}
-	err = e.db.Set(objs...)
 	if err != nil {
-		log.Printf("Error storing signing keys in local db: %s", err)
-		return err
+		return nil, err
 	}
 
-	n.Notify(observer.Progress, "Signing keys uploaded", true)
-
 	pubenc, privenc, err := packageEncryptionKeypair(ctx, e.crypto, e.session.AuthID(),
 		OrgID, kp, pubsig)
 	if err != nil {
@@ -427,31 +828,93 @@ func (e *Engine) GenerateKeypairs(ctx context.Context, notifier *observer.Notifi
 	encclaim, err := e.crypto.SignedClaim(ctx, encBody, pubsig.ID, &kp.Signature)
 	if err != nil {
 		log.Printf("Error creating signature claim for encryption key: %s", err)
-		return err
+		return nil, err
 	}
 
-	n.Notify(observer.Progress, "Encryption keys signed", true)
+	n.NotifyStep(observer.Progress, apitypes.KeypairStepClaim, "Encryption keys signed", true)
 
-	pubenc, privenc, claims, err = e.client.KeyPairs.Post(ctx, pubenc,
+	pubenc, privenc, encClaims, err := e.client.KeyPairs.Post(ctx, pubenc,
 		privenc, encclaim)
 	if err != nil {
 		log.Printf("Error uploading encryption keypair: %s", err)
-		return err
+		return nil, err
 	}
 
-	objs = make([]envelope.Envelope, len(claims)+2)
+	objs := make([]envelope.Envelope, len(encClaims)+2)
 	objs[0] = pubenc
 	objs[1] = privenc
-	for i, claim := range claims {
+	for i, claim := range encClaims {
 		objs[i+2] = &claim
 	}
 	err = e.db.Set(objs...)
 	if err != nil {
 		log.Printf("Error storing encryption keys in local db: %s", err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	created := &createdKeypairs{
+		kp:       kp,
+		sigKey:   pubsig,
+		sigClaim: sigHead,
+		encKey:   pubenc,
+		encClaim: encclaim,
+	}
+	if len(encClaims) > 0 {
+		created.encClaim = &encClaims[len(encClaims)-1]
+	}
+
+	return created, nil
+}
+
+// uploadSigningKeypair uploads the signing keypair in kp for the current user
+// in the given org, with a self-signed claim. It returns the uploaded public
+// key and the head of its claims.
+func (e *Engine) uploadSigningKeypair(ctx context.Context, n *observer.Notifier,
+	OrgID *identity.ID, kp *crypto.KeyPairs) (*envelope.PublicKey, *envelope.Claim, error) {
+
+	pubsig, privsig, err := packageSigningKeypair(ctx, e.crypto, e.session.AuthID(),
+		OrgID, kp)
+	if err != nil {
+		log.Printf("Error packaging signing keypair: %s", err)
+		return nil, nil, err
+	}
+
+	sigBody := primitive.NewClaim(OrgID, e.session.AuthID(), pubsig.ID, pubsig.ID,
+		primitive.SignatureClaimType)
+	sigclaim, err := e.crypto.SignedClaim(ctx, sigBody, pubsig.ID, &kp.Signature)
+	if err != nil {
+		log.Printf("Error creating signature claim: %s", err)
+		return nil, nil, err
+	}
+
+	n.NotifyStep(observer.Progress, apitypes.KeypairStepClaim, "Signing keys signed", true)
+
+	pubsig, privsig, sigClaims, err := e.client.KeyPairs.Post(ctx, pubsig,
+		privsig, sigclaim)
+	if err != nil {
+		log.Printf("Error uploading signature keypair: %s", err)
+		return nil, nil, err
+	}
+
+	objs := make([]envelope.Envelope, len(sigClaims)+2)
+	objs[0] = pubsig
+	objs[1] = privsig
+	for i, claim := range sigClaims {
+		objs[i+2] = &claim
+	}
+	err = e.db.Set(objs...)
+	if err != nil {
+		log.Printf("Error storing signing keys in local db: %s", err)
+		return nil, nil, err
+	}
+
+	n.NotifyStep(observer.Progress, apitypes.KeypairStepUpload, "Signing keys uploaded", true)
+
+	if len(sigClaims) > 0 {
+		sigclaim = &sigClaims[len(sigClaims)-1]
+	}
+
+	return pubsig, sigclaim, nil
 }
 
 // RevokeKeypairs creates revocation claims for the signing and encrypting
@@ -531,6 +994,7 @@ func (e *Engine) RevokeKeypairs(ctx context.Context, notifier *observer.Notifier
 
 	n.Notify(observer.Progress, "Signing key revocation uploaded", true)
 
+	e.prefetch.reset(false)
 	return nil
 }
 
@@ -538,3 +1002,15 @@ func (e *Engine) RevokeKeypairs(ctx context.Context, notifier *observer.Notifier
 func (e *Engine) ChangePassword(ctx context.Context, newPassword string) (*primitive.UserPassword, *primitive.MasterKey, error) {
 	return e.crypto.ChangePassword(ctx, newPassword)
 }
+
+// isWriteConflict returns whether err is the registry rejecting a write
+// because its precondition on the previous version no longer holds.
+func isWriteConflict(err error) bool {
+	apiErr, ok := err.(*apitypes.Error)
+	if !ok {
+		return false
+	}
+
+	return apiErr.StatusCode == http.StatusPreconditionFailed ||
+		apiErr.StatusCode == http.StatusConflict
+}
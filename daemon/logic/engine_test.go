@@ -0,0 +1,67 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestIsExpectedVersion(t *testing.T) {
+	head := func(id *identity.ID, state *string) envelope.CredentialInf {
+		return &envelope.Credential{
+			ID:      id,
+			Version: 3,
+			Body:    &primitive.Credential{State: state},
+		}
+	}
+
+	tcs := []struct {
+		name     string
+		cred     PlaintextCredential
+		head     envelope.CredentialInf
+		expected bool
+	}{
+		{name: "nothing expected", head: head(id1, nil), expected: true},
+		{
+			name:     "saw none, none set",
+			cred:     PlaintextCredential{CredentialVersion: 1},
+			expected: true,
+		},
+		{
+			name:     "saw none, unset",
+			cred:     PlaintextCredential{CredentialVersion: 1},
+			head:     head(id1, &unset),
+			expected: true,
+		},
+		{
+			name: "saw none, set since",
+			cred: PlaintextCredential{CredentialVersion: 1},
+			head: head(id1, nil),
+		},
+		{
+			name:     "saw head",
+			cred:     PlaintextCredential{Previous: id1, CredentialVersion: 2},
+			head:     head(id1, nil),
+			expected: true,
+		},
+		{
+			name: "changed since",
+			cred: PlaintextCredential{Previous: id1, CredentialVersion: 2},
+			head: head(id2, nil),
+		},
+		{
+			name: "removed since",
+			cred: PlaintextCredential{Previous: id1, CredentialVersion: 2},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isExpectedVersion(&tc.cred, tc.head); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
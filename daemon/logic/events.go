@@ -0,0 +1,169 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+const (
+	// eventsPoll is how often the registry is checked for new events in an
+	// org while anyone is subscribed to them.
+	eventsPoll = 5 * time.Second
+
+	// eventsBuffer is how many events are held for a subscriber that is
+	// slow to read them, before further events are dropped.
+	eventsBuffer = 64
+)
+
+// listEvents returns the events in an org after the given event ID.
+type listEvents func(ctx context.Context, orgID *identity.ID,
+	after string) ([]apitypes.OrgEvent, error)
+
+// eventHub polls the registry for the events in each org that has
+// subscribers, and hands them out to every subscriber of the org. Orgs share
+// a single poll no matter how many subscribe to them.
+type eventHub struct {
+	list listEvents
+	poll time.Duration
+
+	mu    sync.Mutex
+	feeds map[identity.ID]*eventFeed
+}
+
+// eventFeed is the state of polling for one org's events.
+type eventFeed struct {
+	subscribers map[chan apitypes.OrgEvent]bool
+	stop        context.CancelFunc
+}
+
+func newEventHub(list listEvents) *eventHub {
+	return &eventHub{
+		list:  list,
+		poll:  eventsPoll,
+		feeds: make(map[identity.ID]*eventFeed),
+	}
+}
+
+// subscribe returns a channel of the events in an org that happen from now
+// on. The channel is closed once ctx is done.
+func (h *eventHub) subscribe(ctx context.Context, orgID *identity.ID) (<-chan apitypes.OrgEvent, error) {
+	h.mu.Lock()
+	feed, ok := h.feeds[*orgID]
+	h.mu.Unlock()
+
+	var cursor string
+	if !ok {
+		// Find where the org's events are up to, so only new ones are
+		// sent, and so any problem talking to the registry is returned to
+		// the subscriber.
+		events, err := h.list(ctx, orgID, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(events) > 0 {
+			cursor = events[len(events)-1].ID
+		}
+	}
+
+	events := make(chan apitypes.OrgEvent, eventsBuffer)
+
+	h.mu.Lock()
+	feed, ok = h.feeds[*orgID]
+	if !ok {
+		pollCtx, stop := context.WithCancel(context.Background())
+		feed = &eventFeed{
+			subscribers: make(map[chan apitypes.OrgEvent]bool),
+			stop:        stop,
+		}
+		h.feeds[*orgID] = feed
+		go h.run(pollCtx, orgID, cursor)
+	}
+	feed.subscribers[events] = true
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		h.unsubscribe(orgID, events)
+	}()
+
+	return events, nil
+}
+
+func (h *eventHub) unsubscribe(orgID *identity.ID, events chan apitypes.OrgEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	feed := h.feeds[*orgID]
+	delete(feed.subscribers, events)
+	close(events)
+
+	if len(feed.subscribers) == 0 {
+		feed.stop()
+		delete(h.feeds, *orgID)
+	}
+}
+
+// run polls for an org's events after cursor until ctx is done.
+func (h *eventHub) run(ctx context.Context, orgID *identity.ID, cursor string) {
+	ticker := time.NewTicker(h.poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		events, err := h.list(ctx, orgID, cursor)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Error polling for org events: %s", err)
+			continue
+		}
+
+		if len(events) == 0 {
+			continue
+		}
+		cursor = events[len(events)-1].ID
+
+		h.publish(orgID, events)
+	}
+}
+
+// publish sends events to every subscriber of the org. Events are dropped for
+// subscribers that have fallen too far behind, rather than holding up the
+// others.
+func (h *eventHub) publish(orgID *identity.ID, events []apitypes.OrgEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	feed, ok := h.feeds[*orgID]
+	if !ok {
+		return
+	}
+
+	for subscriber := range feed.subscribers {
+		for _, evt := range events {
+			select {
+			case subscriber <- evt:
+			default:
+				log.Printf("Dropping org event %s for slow subscriber", evt.ID)
+			}
+		}
+	}
+}
+
+// SubscribeEvents returns a channel of the events in an org, such as invites
+// being approved or secrets changing, as they happen. The channel is closed
+// once ctx is done.
+func (e *Engine) SubscribeEvents(ctx context.Context, orgID *identity.ID) (<-chan apitypes.OrgEvent, error) {
+	return e.events.subscribe(ctx, orgID)
+}
@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+// fakeEvents is a registry event log for an org.
+type fakeEvents struct {
+	mu     sync.Mutex
+	events []apitypes.OrgEvent
+}
+
+func (f *fakeEvents) add(id, typ string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, apitypes.OrgEvent{ID: id, Type: typ})
+}
+
+func (f *fakeEvents) list(ctx context.Context, orgID *identity.ID,
+	after string) ([]apitypes.OrgEvent, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, evt := range f.events {
+		if evt.ID == after {
+			return append([]apitypes.OrgEvent{}, f.events[i+1:]...), nil
+		}
+	}
+	return append([]apitypes.OrgEvent{}, f.events...), nil
+}
+
+func receive(t *testing.T, events <-chan apitypes.OrgEvent) apitypes.OrgEvent {
+	select {
+	case evt := <-events:
+		return evt
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return apitypes.OrgEvent{}
+}
+
+func TestEventHub(t *testing.T) {
+	orgID, err := identity.NewMutable(&primitive.Org{Name: "knotty-buoy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeEvents{}
+	f.add("1", apitypes.EventMemberAdded)
+
+	h := newEventHub(f.list)
+	h.poll = 5 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a, err := h.subscribe(ctx, &orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := h.subscribe(ctx, &orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.add("2", apitypes.EventInviteApproved)
+
+	t.Run("only new events are sent to every subscriber", func(t *testing.T) {
+		for _, events := range []<-chan apitypes.OrgEvent{a, b} {
+			evt := receive(t, events)
+			if evt.ID != "2" {
+				t.Errorf("expected event 2, got %s", evt.ID)
+			}
+		}
+	})
+
+	t.Run("subscribers share a feed", func(t *testing.T) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if len(h.feeds) != 1 {
+			t.Errorf("expected 1 feed, got %d", len(h.feeds))
+		}
+	})
+
+	t.Run("polling stops when everyone unsubscribes", func(t *testing.T) {
+		cancel()
+		for _, events := range []<-chan apitypes.OrgEvent{a, b} {
+			for range events {
+			}
+		}
+
+		h.mu.Lock()
+		feeds := len(h.feeds)
+		h.mu.Unlock()
+		if feeds != 0 {
+			t.Errorf("expected no feeds, got %d", feeds)
+		}
+	})
+}
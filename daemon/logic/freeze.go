@@ -0,0 +1,172 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/ctxutil"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// FreezeKeyrings freezes every keyring matching the requested path
+// expression, for use during a security incident. Frozen keyrings can't be
+// written to, and can only be read by org owners who break the glass.
+//
+// Cached credentials may have come from the frozen keyrings, so the prefetch
+// and offline caches are cleared.
+func (e *Engine) FreezeKeyrings(ctx context.Context,
+	req *apitypes.KeyringFreezeRequest) (*apitypes.KeyringFreezeResult, error) {
+
+	if req.Reason == "" {
+		return nil, &apitypes.Error{
+			StatusCode: http.StatusBadRequest,
+			Type:       apitypes.BadRequestError,
+			Err:        []string{"A reason is required to freeze keyrings"},
+		}
+	}
+
+	result, err := e.client.Keyring.Freeze(ctx, req)
+	if err != nil {
+		log.Printf("Error freezing keyrings: %s", err)
+		return nil, err
+	}
+
+	e.prefetch.reset(false)
+	e.clearOfflineCache()
+
+	return result, nil
+}
+
+// UnfreezeKeyrings lifts the freeze on every keyring matching the requested
+// path expression.
+func (e *Engine) UnfreezeKeyrings(ctx context.Context,
+	req *apitypes.KeyringFreezeRequest) (*apitypes.KeyringFreezeResult, error) {
+
+	result, err := e.client.Keyring.Unfreeze(ctx, req)
+	if err != nil {
+		log.Printf("Error unfreezing keyrings: %s", err)
+		return nil, err
+	}
+
+	e.prefetch.reset(false)
+	return result, nil
+}
+
+// checkFrozenWrite fails if graph, the keyring about to be written to, is
+// frozen.
+func checkFrozenWrite(graph registry.CredentialGraph) error {
+	if graph == nil || graph.GetFreeze() == nil {
+		return nil
+	}
+
+	return &apitypes.Error{
+		StatusCode: http.StatusForbidden,
+		Type:       apitypes.UnauthorizedError,
+		Err: []string{"The keyring for " + graph.GetKeyring().PathExp().String() +
+			" is frozen (" + graph.GetFreeze().Reason + "). " +
+			"Secrets can not be changed until it is unfrozen with 'torus unfreeze'."},
+	}
+}
+
+// checkFrozenReads enforces the freeze on any frozen keyrings in graphs before
+// their secrets are read. Reading them requires a break glass reason, set on
+// ctx with ctxutil.WithBreakGlass, and membership of the org's owner team.
+// Each frozen keyring read is recorded in the audit log with the reason.
+//
+// Keyrings may have been frozen from another machine, so any offline copies
+// of their secrets are dropped.
+func (e *Engine) checkFrozenReads(ctx context.Context, graphs []registry.CredentialGraph) error {
+	var frozen []registry.CredentialGraph
+	for _, graph := range graphs {
+		if graph.GetFreeze() != nil {
+			frozen = append(frozen, graph)
+		}
+	}
+	if len(frozen) == 0 {
+		return nil
+	}
+
+	e.forgetFrozen(frozen)
+
+	reason := ctxutil.BreakGlass(ctx)
+	if reason == "" {
+		return &apitypes.Error{
+			StatusCode: http.StatusForbidden,
+			Type:       apitypes.UnauthorizedError,
+			Err: []string{"The keyring for " + frozen[0].GetKeyring().PathExp().String() +
+				" is frozen (" + frozen[0].GetFreeze().Reason + "). " +
+				"Org owners can read it by giving a reason with --break-glass."},
+		}
+	}
+
+	owner := make(map[identity.ID]bool)
+	for _, graph := range frozen {
+		orgID := graph.GetKeyring().OrgID()
+		if _, ok := owner[*orgID]; ok {
+			continue
+		}
+
+		ok, err := isOrgOwner(ctx, e.client, orgID, e.session.ID())
+		if err != nil {
+			log.Printf("Error checking org owner membership: %s", err)
+			return err
+		}
+		owner[*orgID] = ok
+	}
+
+	now := time.Now().UTC()
+	events := make([]apitypes.AuditEvent, 0, len(frozen))
+	for _, graph := range frozen {
+		if !owner[*graph.GetKeyring().OrgID()] {
+			return &apitypes.Error{
+				StatusCode: http.StatusForbidden,
+				Type:       apitypes.UnauthorizedError,
+				Err: []string{"The keyring for " + graph.GetKeyring().PathExp().String() +
+					" is frozen. Only org owners can break the glass to read it."},
+			}
+		}
+
+		events = append(events, apitypes.AuditEvent{
+			Time:    now,
+			OrgID:   graph.GetKeyring().OrgID(),
+			ActorID: e.session.ID(),
+			Action:  apitypes.AuditBreakGlass,
+			Subject: graph.GetKeyring().PathExp().String(),
+			Reason:  reason,
+		})
+	}
+
+	e.audit.record(events)
+	return nil
+}
+
+// isOrgOwner returns whether ownerID is a member of the org's owner team.
+func isOrgOwner(ctx context.Context, client *registry.Client, orgID,
+	ownerID *identity.ID) (bool, error) {
+
+	teams, err := client.Teams.List(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, team := range teams {
+		if team.Body.Name != primitive.OwnerTeamName ||
+			team.Body.TeamType != primitive.SystemTeamType {
+			continue
+		}
+
+		memberships, err := client.Memberships.List(ctx, orgID, team.ID, ownerID)
+		if err != nil {
+			return false, err
+		}
+		return len(memberships) > 0, nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,56 @@
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+
+	"github.com/manifoldco/torus-cli/daemon/db"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+func frozenGraph(rawPathExp string) registry.CredentialGraph {
+	graph := buildGraph(rawPathExp, 1).(*registry.CredentialGraphV2)
+	graph.Keyring.Body.OrgID = &identity.ID{0x01, 0x04, 0x01}
+	graph.Frozen = &apitypes.KeyringFreeze{Reason: "incident"}
+	return graph
+}
+
+func TestCheckFrozenWrite(t *testing.T) {
+	if err := checkFrozenWrite(nil); err != nil {
+		t.Errorf("new keyring: %s", err)
+	}
+	if err := checkFrozenWrite(buildGraph("/o/p/e/s/*/1", 1)); err != nil {
+		t.Errorf("unfrozen keyring: %s", err)
+	}
+
+	err := checkFrozenWrite(frozenGraph("/o/p/e/s/*/1"))
+	if apiErr, ok := err.(*apitypes.Error); !ok || apiErr.Type != apitypes.UnauthorizedError {
+		t.Errorf("frozen keyring: got %v, want unauthorized error", err)
+	}
+}
+
+func TestCheckFrozenReadsRequiresReason(t *testing.T) {
+	store, err := db.NewStoreDB(db.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &Engine{db: store}
+	ctx := context.Background()
+
+	err = e.checkFrozenReads(ctx, []registry.CredentialGraph{buildGraph("/o/p/e/s/*/1", 1)})
+	if err != nil {
+		t.Errorf("unfrozen keyring: %s", err)
+	}
+
+	graphs := []registry.CredentialGraph{
+		buildGraph("/o/p/e/s/*/1", 1),
+		frozenGraph("/o/p/prod/s/*/1"),
+	}
+	err = e.checkFrozenReads(ctx, graphs)
+	if apiErr, ok := err.(*apitypes.Error); !ok || apiErr.Type != apitypes.UnauthorizedError {
+		t.Errorf("frozen keyring without reason: got %v, want unauthorized error", err)
+	}
+}
@@ -0,0 +1,82 @@
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+
+	"github.com/satori/go.uuid"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+var charsets = map[string]string{
+	apitypes.CharsetAlnum:   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	apitypes.CharsetAlpha:   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	apitypes.CharsetNumeric: "0123456789",
+	apitypes.CharsetSymbols: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789" +
+		"!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// AppendGeneratedCredential appends cred like AppendCredential, with a value
+// generated by the daemon as described by spec, so that it never passes
+// through the CLI's arguments or the user's shell.
+func (e *Engine) AppendGeneratedCredential(ctx context.Context, notifier *observer.Notifier,
+	cred *PlaintextCredentialEnvelope, spec *apitypes.GeneratorSpec,
+	force, confirmed bool) (*PlaintextCredentialEnvelope, error) {
+
+	value, err := generateValue(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Values are stored in their JSON encoded form.
+	raw, err := json.Marshal(apitypes.NewStringCredentialValue(value))
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(raw, &cred.Body.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.AppendCredential(ctx, notifier, cred, force, confirmed)
+}
+
+// generateValue returns a random value as described by spec, read from the
+// system's CSPRNG.
+func generateValue(spec *apitypes.GeneratorSpec) (string, error) {
+	switch spec.Type {
+	case apitypes.GenerateUUID:
+		return uuid.NewV4().String(), nil
+	case apitypes.GenerateHex, apitypes.GenerateBase64:
+		b := make([]byte, spec.Length)
+		_, err := rand.Read(b)
+		if err != nil {
+			return "", err
+		}
+
+		if spec.Type == apitypes.GenerateHex {
+			return hex.EncodeToString(b), nil
+		}
+		return base64.StdEncoding.EncodeToString(b), nil
+	default:
+		chars := charsets[spec.Charset]
+		max := big.NewInt(int64(len(chars)))
+		out := make([]byte, spec.Length)
+		for i := range out {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			out[i] = chars[n.Int64()]
+		}
+
+		return string(out), nil
+	}
+}
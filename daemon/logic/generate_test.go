@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+func TestGenerateValue(t *testing.T) {
+	t.Run("password", func(t *testing.T) {
+		v, err := generateValue(&apitypes.GeneratorSpec{
+			Type: apitypes.GeneratePassword, Length: 40, Charset: apitypes.CharsetNumeric,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(v) != 40 || strings.Trim(v, "0123456789") != "" {
+			t.Errorf("expected 40 digits, got %q", v)
+		}
+	})
+
+	t.Run("hex", func(t *testing.T) {
+		v, err := generateValue(&apitypes.GeneratorSpec{Type: apitypes.GenerateHex, Length: 16})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, err := hex.DecodeString(v); err != nil || len(b) != 16 {
+			t.Errorf("expected 16 hex encoded bytes, got %q", v)
+		}
+	})
+
+	t.Run("base64", func(t *testing.T) {
+		v, err := generateValue(&apitypes.GeneratorSpec{Type: apitypes.GenerateBase64, Length: 24})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, err := base64.StdEncoding.DecodeString(v); err != nil || len(b) != 24 {
+			t.Errorf("expected 24 base64 encoded bytes, got %q", v)
+		}
+	})
+
+	t.Run("uuid", func(t *testing.T) {
+		v, err := generateValue(&apitypes.GeneratorSpec{Type: apitypes.GenerateUUID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(v) != 36 || v[14] != '4' {
+			t.Errorf("expected a v4 uuid, got %q", v)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package logic
+
+import (
+	"context"
+	"strings"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// keyringMembers holds everyone that should be encoded into an org's
+// keyrings. Regular members belong in every keyring, while guests only belong
+// in the keyrings their scopes cover.
+type keyringMembers struct {
+	all    []identity.ID
+	guests map[identity.ID][]*pathexp.PathExp
+}
+
+// For returns the subjects that should be members of the keyring with the
+// given path expression.
+func (k *keyringMembers) For(pe *pathexp.PathExp) []identity.ID {
+	members := append([]identity.ID{}, k.all...)
+	for id, scopes := range k.guests {
+		if guestScopeCovers(scopes, pe) {
+			members = append(members, id)
+		}
+	}
+
+	return members
+}
+
+// isGuestTeam returns whether the team was created to hold guests.
+func isGuestTeam(team *envelope.Team) bool {
+	return team.Body.TeamType == primitive.UserTeamType &&
+		strings.HasPrefix(team.Body.Name, primitive.GuestTeamPrefix)
+}
+
+// guestScopeCovers returns whether any of the guest's scopes lets them read
+// secrets stored in the keyring with the given path expression. A keyring
+// whose environment segment is a glob covers every environment it matches.
+func guestScopeCovers(scopes []*pathexp.PathExp, keyring *pathexp.PathExp) bool {
+	for _, scope := range scopes {
+		if scope.Org.String() != keyring.Org.String() ||
+			scope.Project.String() != keyring.Project.String() {
+			continue
+		}
+
+		if keyring.Envs.Contains(scope.Envs.String()) ||
+			keyring.Envs.String() == scope.Envs.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getGuestScopes returns the read scopes granted to each of the given teams,
+// keyed by team id. Only guest teams are included.
+func getGuestScopes(ctx context.Context, client *registry.Client, orgID *identity.ID,
+	teams []envelope.Team) (map[identity.ID][]*pathexp.PathExp, error) {
+
+	guestTeams := make(map[identity.ID]bool)
+	for i := range teams {
+		if isGuestTeam(&teams[i]) {
+			guestTeams[*teams[i].ID] = true
+		}
+	}
+
+	scopes := make(map[identity.ID][]*pathexp.PathExp)
+	if len(guestTeams) == 0 {
+		return scopes, nil
+	}
+
+	policies, err := client.Policies.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	policiesByID := make(map[identity.ID]*envelope.Policy, len(policies))
+	for i := range policies {
+		policiesByID[*policies[i].ID] = &policies[i]
+	}
+
+	attachments, err := client.Policies.AttachmentsList(ctx, orgID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range attachments {
+		teamID := attachment.Body.OwnerID
+		if !guestTeams[*teamID] {
+			continue
+		}
+
+		policy, ok := policiesByID[*attachment.Body.PolicyID]
+		if !ok {
+			continue
+		}
+
+		for _, stmt := range policy.Body.Policy.Statements {
+			if stmt.Effect != primitive.PolicyEffectAllow ||
+				stmt.Action&primitive.PolicyActionRead == 0 {
+				continue
+			}
+
+			// Resources are a path expression followed by a secret name.
+			idx := strings.LastIndex(stmt.Resource, "/")
+			if idx == -1 {
+				continue
+			}
+
+			pe, err := pathexp.Parse(stmt.Resource[:idx])
+			if err != nil {
+				continue
+			}
+
+			scopes[*teamID] = append(scopes[*teamID], pe)
+		}
+	}
+
+	return scopes, nil
+}
+
+// getInviteGuestScopes returns the read scopes an invitee will be granted, if
+// every team they are invited to is a guest team. If any pending team is not a
+// guest team, the invitee is a regular member, and nil is returned.
+func getInviteGuestScopes(ctx context.Context, client *registry.Client,
+	invite *envelope.OrgInvite) ([]*pathexp.PathExp, error) {
+
+	teams, err := client.Teams.List(ctx, invite.Body.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make(map[identity.ID]bool, len(invite.Body.PendingTeams))
+	for _, id := range invite.Body.PendingTeams {
+		pending[id] = true
+	}
+
+	var invited []envelope.Team
+	for _, team := range teams {
+		if !pending[*team.ID] {
+			continue
+		}
+
+		if !isGuestTeam(&team) {
+			return nil, nil
+		}
+		invited = append(invited, team)
+	}
+
+	if len(invited) == 0 {
+		return nil, nil
+	}
+
+	byTeam, err := getGuestScopes(ctx, client, invite.Body.OrgID, invited)
+	if err != nil {
+		return nil, err
+	}
+
+	// A guest with no readable scopes is still a guest; return an empty,
+	// non-nil slice so they are encoded into no keyrings.
+	scopes := []*pathexp.PathExp{}
+	for _, s := range byTeam {
+		scopes = append(scopes, s...)
+	}
+
+	return scopes, nil
+}
@@ -0,0 +1,214 @@
+package logic
+
+import (
+	"context"
+	"log"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/base64"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/crypto"
+	"github.com/manifoldco/torus-cli/daemon/observer"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// pendingApproval is an accepted invite being approved by ApproveInvites,
+// along with the keyring memberships created for its invitee.
+type pendingApproval struct {
+	invite    *envelope.OrgInvite
+	scopes    []*pathexp.PathExp
+	pubKey    *envelope.PublicKey
+	v1members []envelope.KeyringMemberV1
+	v2members []registry.KeyringMember
+	err       error
+}
+
+// ApproveInvites approves every accepted invite to an org. Unlike approving
+// each invite with ApproveInvite, the current user's keys, the org's claims,
+// and its keyrings are fetched once, and each keyring's master encryption key
+// is decrypted once and encrypted for every invitee that belongs in it.
+//
+// An invite that can't be approved doesn't stop the others from being
+// approved. The outcome for each invite is returned.
+func (e *Engine) ApproveInvites(ctx context.Context, notifier *observer.Notifier,
+	orgID *identity.ID) ([]apitypes.InviteApproval, error) {
+
+	invites, err := e.client.OrgInvite.List(ctx, orgID,
+		[]string{primitive.OrgInviteAcceptedState}, "")
+	if err != nil {
+		log.Printf("could not list org invites: %s", err)
+		return nil, err
+	}
+
+	approvals := make([]apitypes.InviteApproval, len(invites))
+	if len(invites) == 0 {
+		return approvals, nil
+	}
+
+	n := notifier.Notifier(uint(len(invites)) + 2)
+
+	sigID, encID, kp, err := fetchWritableKeyPairs(ctx, e.client, orgID)
+	if err != nil {
+		log.Printf("could not fetch keypairs for org: %s", err)
+		return nil, err
+	}
+
+	claimTrees, err := e.client.ClaimTree.List(ctx, orgID, nil)
+	if err != nil {
+		log.Printf("could not retrieve claim tree for invite approval: %s", err)
+		return nil, err
+	}
+
+	graphs, err := findActiveGraphs(ctx, e.client, e.session, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Keyrings retrieved", true)
+
+	pending := make([]pendingApproval, len(invites))
+	for i := range invites {
+		p := &pending[i]
+		p.invite = &invites[i]
+		approvals[i] = apitypes.InviteApproval{
+			InviteID: p.invite.ID,
+			Email:    p.invite.Body.Email,
+		}
+
+		p.scopes, p.err = getInviteGuestScopes(ctx, e.client, p.invite)
+		if p.err != nil {
+			log.Printf("could not determine guest scopes for invite: %s", p.err)
+			continue
+		}
+
+		p.pubKey, p.err = findEncryptionPublicKey(claimTrees, orgID, p.invite.Body.InviteeID)
+		if p.err != nil {
+			log.Printf("could not find encryption key for invitee: %s", p.err)
+		}
+	}
+
+	for _, graph := range graphs {
+		var targets []*pendingApproval
+		var targetKeys [][]byte
+		for i := range pending {
+			p := &pending[i]
+			if p.err != nil {
+				continue
+			}
+			if p.scopes != nil && !guestScopeCovers(p.scopes, graph.GetKeyring().PathExp()) {
+				continue
+			}
+
+			targets = append(targets, p)
+			targetKeys = append(targetKeys, *p.pubKey.Body.Key.Value)
+		}
+		if len(targets) == 0 {
+			continue
+		}
+
+		krm, mekshare, err := graph.FindMember(e.session.AuthID())
+		if err != nil {
+			log.Printf("could not find keyring membership: %s", err)
+			return nil, &apitypes.Error{
+				Type: apitypes.NotFoundError,
+				Err:  []string{"Keyring membership not found."},
+			}
+		}
+
+		encPubKey, err := findEncryptionPublicKeyByID(claimTrees, orgID, krm.EncryptingKeyID)
+		if err != nil {
+			log.Printf("could not find encypting public key for membership: %s", err)
+			return nil, err
+		}
+
+		encMeks, nonces, err := e.crypto.CloneMemberships(ctx, *mekshare.Key.Value,
+			*mekshare.Key.Nonce, &kp.Encryption, *encPubKey.Body.Key.Value, targetKeys)
+		if err != nil {
+			log.Printf("could not clone keyring membership: %s", err)
+			return nil, err
+		}
+
+		for i, p := range targets {
+			key := &primitive.KeyringMemberKey{
+				Algorithm: crypto.EasyBox,
+				Nonce:     base64.NewValue(nonces[i]),
+				Value:     base64.NewValue(encMeks[i]),
+			}
+			ownerID := p.invite.Body.InviteeID
+
+			switch k := graph.GetKeyring().(type) {
+			case *envelope.KeyringV1:
+				member, err := newV1KeyringMember(ctx, e.crypto, krm.OrgID, k.Body.ProjectID,
+					krm.KeyringID, ownerID, p.pubKey.ID, encID, sigID, key, kp)
+				if err != nil {
+					return nil, err
+				}
+				p.v1members = append(p.v1members, *member)
+			case *envelope.Keyring:
+				member, err := newV2KeyringMember(ctx, e.crypto, krm.OrgID, krm.KeyringID,
+					ownerID, p.pubKey.ID, encID, sigID, key, kp)
+				if err != nil {
+					return nil, err
+				}
+				p.v2members = append(p.v2members, *member)
+			default:
+				return nil, &apitypes.Error{
+					Type: apitypes.InternalServerError,
+					Err:  []string{"Unknown keyring schema version"},
+				}
+			}
+		}
+	}
+
+	n.Notify(observer.Progress, "Keyring memberships created", true)
+
+	for i := range pending {
+		p := &pending[i]
+		if p.err == nil {
+			p.err = e.finishApproval(ctx, p)
+		}
+
+		if p.err != nil {
+			approvals[i].Error = p.err.Error()
+			n.Notify(observer.Progress, "Could not approve "+p.invite.Body.Email, true)
+			continue
+		}
+
+		approvals[i].Approved = true
+		n.Notify(observer.Progress, "Approved "+p.invite.Body.Email, true)
+	}
+
+	return approvals, nil
+}
+
+// finishApproval approves an invite, and uploads its invitee's keyring
+// memberships, in the same order as ApproveInvite.
+func (e *Engine) finishApproval(ctx context.Context, p *pendingApproval) error {
+	_, err := e.client.OrgInvite.Approve(ctx, p.invite.ID)
+	if err != nil {
+		log.Printf("could not approve org invite: %s", err)
+		return err
+	}
+
+	if len(p.v1members) != 0 {
+		_, err = e.client.KeyringMember.Post(ctx, p.v1members)
+		if err != nil {
+			log.Printf("error uploading memberships: %s", err)
+			return err
+		}
+	}
+
+	for _, member := range p.v2members {
+		err = e.client.Keyring.Members.Post(ctx, member)
+		if err != nil {
+			log.Printf("error uploading memberships: %s", err)
+			return err
+		}
+	}
+
+	return nil
+}
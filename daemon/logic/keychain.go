@@ -0,0 +1,161 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/base64"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/keychain"
+)
+
+var errUnknownSessionType = errors.New("unknown session type")
+
+// keychainAccount names the keychain entry holding the login for the
+// daemon's registry.
+func (s *Session) keychainAccount() string {
+	return s.engine.config.RegistryURI.String()
+}
+
+// SaveToKeychain stores the login of the current session in the operating
+// system's keychain, so the daemon can log in again on its own when it
+// restarts.
+func (s *Session) SaveToKeychain() error {
+	sess := s.engine.session
+	if !sess.HasToken() || !sess.HasPassphrase() {
+		return &apitypes.Error{
+			StatusCode: http.StatusUnauthorized,
+			Type:       apitypes.UnauthorizedError,
+			Err:        []string{"You must be logged in to save your login to the keychain"},
+		}
+	}
+
+	var creds apitypes.LoginCredential
+	switch sess.Type() {
+	case apitypes.UserSession:
+		user, ok := sess.Self().Identity.(*envelope.User)
+		if !ok {
+			return errUnknownSessionType
+		}
+		creds = &apitypes.UserLogin{Email: user.Body.Email, Password: string(sess.Passphrase())}
+	case apitypes.MachineSession:
+		secret := base64.Value(sess.Passphrase())
+		creds = &apitypes.MachineLogin{TokenID: sess.AuthID(), Secret: &secret}
+	default:
+		return errUnknownSessionType
+	}
+
+	raw, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(&apitypes.Login{Type: sess.Type(), Credentials: raw})
+	if err != nil {
+		return err
+	}
+
+	err = keychain.Set(s.keychainAccount(), b)
+	if err != nil {
+		return &apitypes.Error{
+			StatusCode: http.StatusInternalServerError,
+			Type:       apitypes.InternalServerError,
+			Err:        []string{"Could not save login to the keychain: " + err.Error()},
+		}
+	}
+
+	log.Printf("Saved login to the keychain")
+	return nil
+}
+
+// Unlock logs in with the login stored in the keychain, if there is one. A
+// login the registry no longer accepts, such as after a password change, is
+// removed. Logins that need a multi-factor authentication code can't be
+// completed, and are kept.
+//
+// Nobody gave their credentials, so the session's authentication time is
+// left unknown, and conditions requiring a recent login aren't met.
+func (s *Session) Unlock(ctx context.Context) error {
+	b, err := keychain.Get(s.keychainAccount())
+	if err == keychain.ErrNotFound || err == keychain.ErrUnsupported {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	login := apitypes.Login{}
+	err = json.Unmarshal(b, &login)
+	if err != nil {
+		return err
+	}
+
+	var creds apitypes.LoginCredential
+	switch login.Type {
+	case apitypes.UserSession:
+		creds = &apitypes.UserLogin{}
+	case apitypes.MachineSession:
+		creds = &apitypes.MachineLogin{}
+	default:
+		return errUnknownSessionType
+	}
+
+	err = json.Unmarshal(login.Credentials, creds)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Attempting to login from the keychain as: %s", creds.Identifier())
+	err = s.login(ctx, creds, time.Time{})
+	if apitypes.IsMFARequiredError(err) {
+		return errors.New("the login in the keychain needs a multi-factor " +
+			"authentication code; log in with torus login")
+	}
+	if apitypes.IsUnauthorizedError(err) {
+		log.Printf("Login from the keychain was rejected, removing it")
+		s.forgetKeychain()
+	}
+	return err
+}
+
+// updateKeychain saves the current session's login over the one stored in the
+// keychain for user, such as after their password changed, so the daemon can
+// still log in on its own. Nothing is saved if user's login wasn't stored.
+func (s *Session) updateKeychain(user *envelope.User) {
+	b, err := keychain.Get(s.keychainAccount())
+	if err == keychain.ErrNotFound || err == keychain.ErrUnsupported {
+		return
+	}
+	if err != nil {
+		log.Printf("Could not read login from the keychain: %s", err)
+		return
+	}
+
+	login := apitypes.Login{}
+	stored := apitypes.UserLogin{}
+	err = json.Unmarshal(b, &login)
+	if err == nil && login.Type == apitypes.UserSession {
+		err = json.Unmarshal(login.Credentials, &stored)
+	}
+	if err != nil || login.Type != apitypes.UserSession || stored.Email != user.Body.Email {
+		return
+	}
+
+	err = s.SaveToKeychain()
+	if err != nil {
+		log.Printf("Could not update login in the keychain: %s", err)
+	}
+}
+
+// forgetKeychain removes the login stored in the keychain, if any.
+func (s *Session) forgetKeychain() {
+	err := keychain.Delete(s.keychainAccount())
+	if err != nil && err != keychain.ErrUnsupported {
+		log.Printf("Could not remove login from the keychain: %s", err)
+	}
+}
@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/observer"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// KeyringMembers returns the current version of every keyring in the org
+// whose path expression overlaps pe, and so may hold secrets for a path it
+// contains, along with who each is shared with. Nothing is decrypted, so
+// keyrings the current user or machine is not a member of are included.
+func (e *Engine) KeyringMembers(ctx context.Context, notifier *observer.Notifier,
+	orgID *identity.ID, pe *pathexp.PathExp) ([]apitypes.KeyringMembers, error) {
+
+	n := notifier.Notifier(1)
+
+	sections, err := e.client.Keyring.List(ctx, orgID, nil)
+	if err != nil {
+		log.Printf("Error retrieving keyrings: %s", err)
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Keyrings retrieved", true)
+
+	return currentKeyringMembers(sections, pe), nil
+}
+
+// currentKeyringMembers returns the members of the newest version of each
+// keyring in sections whose path expression overlaps pe, sorted by path
+// expression.
+func currentKeyringMembers(sections []registry.KeyringSection,
+	pe *pathexp.PathExp) []apitypes.KeyringMembers {
+
+	current := make(map[string]registry.KeyringSection)
+	for _, s := range sections {
+		kpe := s.GetKeyring().PathExp()
+		if !pe.Overlaps(kpe) {
+			continue
+		}
+
+		key := kpe.String()
+		if c, ok := current[key]; ok && c.KeyringVersion() >= s.KeyringVersion() {
+			continue
+		}
+		current[key] = s
+	}
+
+	keys := make([]string, 0, len(current))
+	for k := range current {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]apitypes.KeyringMembers, 0, len(keys))
+	for _, k := range keys {
+		s := current[k]
+		result = append(result, apitypes.KeyringMembers{
+			KeyringID:      s.GetKeyring().GetID(),
+			PathExp:        k,
+			KeyringVersion: s.KeyringVersion(),
+			Frozen:         s.GetFreeze() != nil,
+			Members:        sectionMembers(s),
+		})
+	}
+
+	return result
+}
+
+// sectionMembers returns the members of a keyring, oldest first.
+func sectionMembers(s registry.KeyringSection) []apitypes.KeyringMember {
+	members := []apitypes.KeyringMember{}
+	switch k := s.(type) {
+	case *registry.KeyringSectionV1:
+		for _, m := range k.Members {
+			members = append(members, apitypes.KeyringMember{
+				ID:              m.ID,
+				OwnerID:         m.Body.OwnerID,
+				Created:         m.Body.Created,
+				PublicKeyID:     m.Body.PublicKeyID,
+				EncryptingKeyID: m.Body.EncryptingKeyID,
+			})
+		}
+	case *registry.KeyringSectionV2:
+		revoked := make(map[identity.ID]bool)
+		for _, c := range k.Claims {
+			if c.Body.ClaimType == primitive.RevocationClaimType {
+				revoked[*c.Body.KeyringMemberID] = true
+			}
+		}
+
+		for _, m := range k.Members {
+			members = append(members, apitypes.KeyringMember{
+				ID:              m.Member.ID,
+				OwnerID:         m.Member.Body.OwnerID,
+				Created:         m.Member.Body.Created,
+				PublicKeyID:     m.Member.Body.PublicKeyID,
+				EncryptingKeyID: m.Member.Body.EncryptingKeyID,
+				Revoked:         revoked[*m.Member.ID],
+			})
+		}
+	}
+
+	sort.Stable(memberSorter(members))
+	return members
+}
+
+// memberSorter implements sort.Interface, for sorting keyring members by
+// when they were created.
+type memberSorter []apitypes.KeyringMember
+
+func (m memberSorter) Len() int           { return len(m) }
+func (m memberSorter) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m memberSorter) Less(i, j int) bool { return m[i].Created.Before(m[j].Created) }
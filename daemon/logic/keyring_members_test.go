@@ -0,0 +1,83 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+func TestCurrentKeyringMembers(t *testing.T) {
+	now := time.Now()
+	owner := identity.ID{0x01, 0x01, 0x01}
+
+	section := func(t *testing.T, pe string, version int, members ...byte) *registry.KeyringSectionV2 {
+		p, err := pathexp.Parse(pe)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s := &registry.KeyringSectionV2{
+			Keyring: &envelope.Keyring{
+				ID: &identity.ID{0x01, 0x09, byte(version)},
+				Body: &primitive.Keyring{BaseKeyring: primitive.BaseKeyring{
+					PathExp:        p,
+					KeyringVersion: version,
+				}},
+			},
+		}
+		for _, id := range members {
+			s.Members = append(s.Members, registry.KeyringMember{Member: &envelope.KeyringMember{
+				ID: &identity.ID{0x01, 0x0a, id},
+				Body: &primitive.KeyringMember{
+					OwnerID: &owner,
+					Created: now.Add(-time.Duration(id) * time.Hour),
+				},
+			}})
+		}
+		return s
+	}
+
+	revokedID := identity.ID{0x01, 0x0a, 2}
+	latest := section(t, "/o/p/prod/*/*/*", 2, 1, 2)
+	latest.Claims = []envelope.KeyringMemberClaim{{
+		Body: &primitive.KeyringMemberClaim{
+			KeyringMemberID: &revokedID,
+			ClaimType:       primitive.RevocationClaimType,
+		},
+	}}
+
+	sections := []registry.KeyringSection{
+		section(t, "/o/p/prod/*/*/*", 1, 1),
+		latest,
+		section(t, "/o/p/dev/*/*/*", 1, 1),
+		section(t, "/o/p/*/api/*/*", 1, 1),
+	}
+
+	pe, err := pathexp.Parse("/o/p/prod/api/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := currentKeyringMembers(sections, pe)
+	if len(got) != 2 {
+		t.Fatalf("got %d keyrings, want 2: %+v", len(got), got)
+	}
+	if got[0].PathExp != "/o/p/*/api/*/*" || got[1].PathExp != "/o/p/prod/*/*/*" {
+		t.Errorf("got keyrings %s and %s", got[0].PathExp, got[1].PathExp)
+	}
+
+	prod := got[1]
+	if prod.KeyringVersion != 2 || len(prod.Members) != 2 {
+		t.Fatalf("got version %d with %d members, want the newest version with 2",
+			prod.KeyringVersion, len(prod.Members))
+	}
+	if *prod.Members[0].ID != revokedID || !prod.Members[0].Revoked || prod.Members[1].Revoked {
+		t.Errorf("got members %+v, want the oldest first, and only it revoked", prod.Members)
+	}
+}
@@ -79,7 +79,7 @@ func (m *Machine) CreateToken(ctx context.Context, notifier *observer.Notifier,
 	// Create an "empty" machine session in order to create a Crypto engine on
 	// behalf of the machine for deriving and uploading these keys.
 	sess := session.NewSession()
-	err = sess.Set(apitypes.MachineSession, machine, token, *secret, "")
+	err = sess.Set(apitypes.MachineSession, machine, token, *secret, "", time.Time{})
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +114,7 @@ func (m *Machine) EncodeToken(ctx context.Context, notifier *observer.Notifier,
 	n.Notify(observer.Progress, "Creating keyring memberships for token", true)
 
 	v1members, v2members, err := createKeyringMemberships(ctx, m.engine.crypto,
-		m.engine.client, m.engine.session, token.Body.OrgID, token.ID)
+		m.engine.client, m.engine.session, token.Body.OrgID, token.ID, nil)
 	if err != nil {
 		return err
 	}
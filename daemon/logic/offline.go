@@ -0,0 +1,389 @@
+package logic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/ctxutil"
+	"github.com/manifoldco/torus-cli/daemon/observer"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// offlineBucket is the db bucket holding the offline credential cache.
+const offlineBucket = "offline"
+
+// offlineEntry is the cached copy of the credentials at a path. The
+// credentials are sealed with the user's master key, so they can only be read
+// by the same user, while logged in.
+type offlineEntry struct {
+	Fetched time.Time `json:"fetched"`
+
+	// Versions maps the ID of each credential to its version, to tell when
+	// the cached copy is out of date.
+	Versions map[string]int `json:"versions"`
+
+	// Conditions is a digest of the sealed policies, to tell when they have
+	// changed.
+	Conditions string `json:"conditions"`
+
+	// Keyrings are the keyrings the credentials were read from, as their org
+	// ID and path expression, so the entry can be dropped once one of them
+	// is seen to be frozen.
+	Keyrings []string `json:"keyrings"`
+
+	Nonce  []byte `json:"nonce"`
+	Sealed []byte `json:"sealed"`
+}
+
+// offlineContents is the sealed part of an offline cache entry. The policies
+// deciding reads of the credentials are kept with them, keyed by org ID, so
+// their conditions are enforced offline too.
+type offlineContents struct {
+	Credentials []PlaintextCredentialEnvelope `json:"credentials"`
+	Conditions  map[string]*actorPolicies     `json:"conditions"`
+}
+
+// RetrievePathCredentials returns the credentials for cpath, like
+// RetrieveCredentials, keeping a copy of them in the offline cache.
+// Dynamic secrets are cached as they are stored, and values are minted for
+// them even when offline, as their providers may still be reachable.
+//
+// If offline is true, or the registry can not be reached, the cached copy is
+// returned instead, along with the time it was fetched. The time is nil for
+// credentials fresh from the registry. Policy conditions are checked against
+// the policies cached with the credentials; if there are none, the cached
+// copy is refused.
+func (e *Engine) RetrievePathCredentials(ctx context.Context, notifier *observer.Notifier,
+	cpath string, offline bool) ([]PlaintextCredentialEnvelope, *time.Time, error) {
+
+	if e.session.Type() == apitypes.NotLoggedIn {
+		return nil, nil, &apitypes.Error{
+			StatusCode: http.StatusUnauthorized,
+			Type:       apitypes.UnauthorizedError,
+			Err:        []string{"You must be logged in to read secrets"},
+		}
+	}
+
+	if !offline {
+		creds, err := e.resolveCredentials(ctx, notifier, &cpath, nil)
+		if err == nil {
+			// Secrets read by breaking the glass on a frozen keyring are
+			// never kept offline.
+			if ctxutil.BreakGlass(ctx) == "" {
+				e.cacheCredentials(ctx, cpath, creds)
+			}
+
+			creds, err = e.brokerCredentials(ctx, creds)
+			return creds, nil, err
+		}
+		if !isUnreachable(err) {
+			return nil, nil, err
+		}
+
+		log.Printf("Registry unreachable, using offline cache: %s", err)
+	}
+
+	contents, fetched, err := e.cachedCredentials(ctx, cpath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if contents == nil {
+		return nil, nil, &apitypes.Error{
+			StatusCode: http.StatusNotFound,
+			Type:       apitypes.NotFoundError,
+			Err: []string{"No secrets are cached for " + cpath +
+				". Fetch them once while online to use them offline."},
+		}
+	}
+
+	creds := contents.Credentials
+	err = e.checkCachedConditions(cpath, contents)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e.recordReads(creds)
+	creds, err = e.brokerCredentials(ctx, creds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return creds, fetched, nil
+}
+
+// cacheCredentials stores creds as the offline copy of the credentials at
+// cpath, along with the policies deciding reads of them. They are only sealed
+// again when their versions or the policies changed. Errors are logged, as
+// the cache is best effort.
+func (e *Engine) cacheCredentials(ctx context.Context, cpath string, creds []PlaintextCredentialEnvelope) {
+	contents := &offlineContents{
+		Credentials: creds,
+		Conditions:  make(map[string]*actorPolicies),
+	}
+
+	versions := make(map[string]int, len(creds))
+	var keyrings []string
+	seen := make(map[string]bool)
+	for _, cred := range creds {
+		versions[cred.ID.String()] = cred.Body.CredentialVersion
+
+		keyring := offlineKeyring(cred.Body.OrgID, cred.Body.PathExp)
+		if !seen[keyring] {
+			seen[keyring] = true
+			keyrings = append(keyrings, keyring)
+		}
+
+		orgID := cred.Body.OrgID
+		if _, ok := contents.Conditions[orgID.String()]; ok {
+			continue
+		}
+
+		ap, err := e.conditions.get(ctx, orgID)
+		if err != nil {
+			log.Printf("Error retrieving policies for offline cache: %s", err)
+			return
+		}
+		contents.Conditions[orgID.String()] = ap
+	}
+
+	conditions, err := json.Marshal(contents.Conditions)
+	if err != nil {
+		log.Printf("Error encoding policies for offline cache: %s", err)
+		return
+	}
+	digest := sha256.Sum256(conditions)
+	digestHex := hex.EncodeToString(digest[:])
+
+	entry, err := e.offlineEntry(cpath)
+	if err != nil {
+		log.Printf("Error reading offline cache: %s", err)
+	}
+
+	if entry == nil || !sameVersions(entry.Versions, versions) || entry.Conditions != digestHex {
+		pt, err := json.Marshal(contents)
+		if err != nil {
+			log.Printf("Error encoding credentials for offline cache: %s", err)
+			return
+		}
+
+		sealed, nonce, err := e.crypto.Seal(ctx, pt)
+		if err != nil {
+			log.Printf("Error sealing credentials for offline cache: %s", err)
+			return
+		}
+
+		entry = &offlineEntry{
+			Versions:   versions,
+			Conditions: digestHex,
+			Nonce:      nonce,
+			Sealed:     sealed,
+		}
+	}
+
+	entry.Fetched = time.Now()
+	entry.Keyrings = keyrings
+	b, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Error encoding offline cache entry: %s", err)
+		return
+	}
+
+	err = e.db.Put(offlineBucket, e.offlineKey(cpath), b)
+	if err != nil {
+		log.Printf("Error writing offline cache: %s", err)
+	}
+}
+
+// cachedCredentials returns the offline copy of the credentials at cpath,
+// and when it was fetched. It returns nil if there is no copy.
+func (e *Engine) cachedCredentials(ctx context.Context, cpath string) (*offlineContents, *time.Time, error) {
+	entry, err := e.offlineEntry(cpath)
+	if err != nil || entry == nil {
+		return nil, nil, err
+	}
+
+	pt, err := e.crypto.Unseal(ctx, entry.Sealed, entry.Nonce)
+	if err != nil {
+		log.Printf("Error unsealing offline credentials: %s", err)
+		return nil, nil, err
+	}
+
+	contents, err := decodeOfflineContents(pt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if contents.Conditions == nil {
+		return nil, nil, &apitypes.Error{
+			StatusCode: http.StatusForbidden,
+			Type:       apitypes.UnauthorizedError,
+			Err: []string{"The secrets cached for " + cpath + " were cached by an older " +
+				"version of torus. Fetch them once while online to use them offline."},
+		}
+	}
+
+	return contents, &entry.Fetched, nil
+}
+
+// decodeOfflineContents decodes the unsealed contents of an offline cache
+// entry. Entries cached by older versions hold only the credentials, and are
+// returned without any conditions.
+func decodeOfflineContents(pt []byte) (*offlineContents, error) {
+	contents := &offlineContents{}
+	err := json.Unmarshal(pt, contents)
+	if _, ok := err.(*json.UnmarshalTypeError); ok {
+		contents = &offlineContents{}
+		err = json.Unmarshal(pt, &contents.Credentials)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return contents, nil
+}
+
+// checkCachedConditions enforces the policy conditions on reading the
+// offline copy of the credentials at cpath, using the policies cached with
+// them. Credentials in an org without cached policies are refused.
+func (e *Engine) checkCachedConditions(cpath string, contents *offlineContents) error {
+	resources := make(map[string][]string)
+	for i := range contents.Credentials {
+		cred := &contents.Credentials[i]
+		orgID := cred.Body.OrgID.String()
+		resources[orgID] = append(resources[orgID],
+			credentialResources(&cpath, []*PlaintextCredentialEnvelope{cred})...)
+	}
+
+	authenticated := e.session.AuthTime()
+	for orgID, orgResources := range resources {
+		ap, ok := contents.Conditions[orgID]
+		if !ok || ap == nil {
+			return &apitypes.Error{
+				StatusCode: http.StatusForbidden,
+				Type:       apitypes.UnauthorizedError,
+				Err: []string{"The policies for the secrets cached for " + cpath +
+					" are not cached, so their conditions can't be checked offline."},
+			}
+		}
+
+		err := ap.check(authenticated, primitive.PolicyActionRead, orgResources)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clearOfflineCache removes every cached copy of credentials, as is done on
+// logout.
+func (e *Engine) clearOfflineCache() {
+	err := e.db.Clear(offlineBucket)
+	if err != nil {
+		log.Printf("Error clearing offline cache: %s", err)
+	}
+}
+
+// forgetFrozen drops every offline copy of credentials read from one of the
+// frozen keyrings in graphs, so they aren't served while the registry can't
+// be reached. Errors are logged, as the cache is best effort.
+func (e *Engine) forgetFrozen(graphs []registry.CredentialGraph) {
+	frozen := make(map[string]bool)
+	for _, graph := range graphs {
+		if graph.GetFreeze() != nil {
+			keyring := graph.GetKeyring()
+			frozen[offlineKeyring(keyring.OrgID(), keyring.PathExp())] = true
+		}
+	}
+	if len(frozen) == 0 {
+		return
+	}
+
+	var keys []string
+	err := e.db.ForEach(offlineBucket, func(key string, value []byte) error {
+		entry := &offlineEntry{}
+		if err := json.Unmarshal(value, entry); err != nil {
+			return nil
+		}
+
+		for _, keyring := range entry.Keyrings {
+			if frozen[keyring] {
+				keys = append(keys, key)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error reading offline cache: %s", err)
+		return
+	}
+
+	for _, key := range keys {
+		err := e.db.Delete(offlineBucket, key)
+		if err != nil {
+			log.Printf("Error removing frozen secrets from offline cache: %s", err)
+		}
+	}
+}
+
+func (e *Engine) offlineEntry(cpath string) (*offlineEntry, error) {
+	b, err := e.db.Fetch(offlineBucket, e.offlineKey(cpath))
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	entry := &offlineEntry{}
+	err = json.Unmarshal(b, entry)
+	return entry, err
+}
+
+// offlineKey returns the key of the cached copy of cpath for the current
+// user or machine.
+func (e *Engine) offlineKey(cpath string) string {
+	return e.session.AuthID().String() + ":" + cpath
+}
+
+// offlineKeyring identifies the keyring at pe in an org for offline cache
+// entries.
+func offlineKeyring(orgID *identity.ID, pe *pathexp.PathExp) string {
+	return orgID.String() + ":" + pe.String()
+}
+
+func sameVersions(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id, v := range a {
+		if bv, ok := b[id]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// isUnreachable returns whether err means the registry could not be reached
+// or did not respond, rather than refusing the request.
+func isUnreachable(err error) bool {
+	switch err := err.(type) {
+	case *apitypes.Error:
+		return err.StatusCode == http.StatusRequestTimeout ||
+			err.StatusCode == http.StatusBadGateway ||
+			err.StatusCode == http.StatusServiceUnavailable ||
+			err.StatusCode == http.StatusGatewayTimeout
+	case net.Error:
+		return true
+	default:
+		return false
+	}
+}
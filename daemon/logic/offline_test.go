@@ -0,0 +1,162 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/db"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+	"github.com/manifoldco/torus-cli/daemon/session"
+)
+
+func TestSameVersions(t *testing.T) {
+	a := map[string]int{"one": 1, "two": 2}
+
+	if !sameVersions(a, map[string]int{"two": 2, "one": 1}) {
+		t.Error("expected equal versions to match")
+	}
+	if sameVersions(a, map[string]int{"one": 1, "two": 3}) {
+		t.Error("expected a changed version not to match")
+	}
+	if sameVersions(a, map[string]int{"one": 1}) {
+		t.Error("expected a removed credential not to match")
+	}
+	if sameVersions(a, map[string]int{"one": 1, "three": 2}) {
+		t.Error("expected a replaced credential not to match")
+	}
+}
+
+func TestIsUnreachable(t *testing.T) {
+	tcs := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network error", &net.OpError{Op: "dial", Err: errors.New("refused")}, true},
+		{"timeout", &apitypes.Error{StatusCode: http.StatusRequestTimeout}, true},
+		{"unavailable", &apitypes.Error{StatusCode: http.StatusServiceUnavailable}, true},
+		{"not found", &apitypes.Error{StatusCode: http.StatusNotFound}, false},
+		{"unauthorized", &apitypes.Error{StatusCode: http.StatusUnauthorized}, false},
+		{"other", errors.New("bad things"), false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isUnreachable(tc.err); got != tc.want {
+				t.Errorf("got %t want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckCachedConditions(t *testing.T) {
+	orgID := identity.ID{0x01, 0x04, 0x01}
+	cpath := "/acme/app/dev/default/jeff/1"
+
+	// The session has never logged in, so recent_auth is never met.
+	recentAuth := &primitive.Policy{PolicyType: "user", OrgID: &orgID}
+	recentAuth.Policy.Name = "recent-reads"
+	recentAuth.Policy.Statements = []primitive.PolicyStatement{{
+		Effect:     primitive.PolicyEffectAllow,
+		Action:     primitive.PolicyActionRead,
+		Resource:   "/acme/app/dev/*/*/*/*",
+		Conditions: &primitive.PolicyConditions{RecentAuth: "15m"},
+	}}
+
+	tcs := []struct {
+		name       string
+		conditions map[string]*actorPolicies
+		ok         bool
+	}{
+		{
+			name:       "no conditions",
+			conditions: map[string]*actorPolicies{orgID.String(): {}},
+			ok:         true,
+		},
+		{
+			name: "unmet condition",
+			conditions: map[string]*actorPolicies{orgID.String(): {
+				Policies: []envelope.Policy{{ID: &identity.ID{0x01, 0x11, 0x01}, Version: 1, Body: recentAuth}},
+			}},
+		},
+		{
+			name: "untrusted policy",
+			conditions: map[string]*actorPolicies{orgID.String(): {
+				Untrusted: []string{"The read-dev policy can't be trusted"},
+			}},
+		},
+		{name: "no cached policies", conditions: map[string]*actorPolicies{}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &Engine{session: session.NewSession()}
+			contents := &offlineContents{
+				Credentials: []PlaintextCredentialEnvelope{{
+					ID:   &identity.ID{0x01, 0x0d, 0x01},
+					Body: &PlaintextCredential{Name: "password", OrgID: &orgID},
+				}},
+				Conditions: tc.conditions,
+			}
+
+			err := e.checkCachedConditions(cpath, contents)
+			if tc.ok && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if !tc.ok && err == nil {
+				t.Error("expected the cached credentials to be refused")
+			}
+		})
+	}
+}
+
+func TestForgetFrozen(t *testing.T) {
+	orgID := identity.ID{0x01, 0x04, 0x01}
+
+	store, err := db.NewStoreDB(db.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &Engine{db: store}
+
+	dev := mustPathExp("/acme/app/dev/*/*/*")
+	prod := mustPathExp("/acme/app/prod/*/*/*")
+
+	entries := map[string][]string{
+		"dev":  {offlineKeyring(&orgID, dev)},
+		"prod": {offlineKeyring(&orgID, prod)},
+		"both": {offlineKeyring(&orgID, prod), offlineKeyring(&orgID, dev)},
+	}
+	for key, keyrings := range entries {
+		b, err := json.Marshal(&offlineEntry{Keyrings: keyrings})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Put(offlineBucket, key, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e.forgetFrozen([]registry.CredentialGraph{
+		frozenGraph("/acme/app/dev/*/*/*"),
+		buildGraph("/acme/app/prod/*/*/*", 1),
+	})
+
+	for key, kept := range map[string]bool{"dev": false, "prod": true, "both": false} {
+		b, err := store.Fetch(offlineBucket, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (b != nil) != kept {
+			t.Errorf("%s: got kept %t, want %t", key, b != nil, kept)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// DeleteOrg deletes the org from the registry, and forgets everything the
+// daemon has cached for it.
+func (e *Engine) DeleteOrg(ctx context.Context, orgID *identity.ID) error {
+	org, err := e.client.Orgs.Get(ctx, orgID)
+	if err != nil {
+		log.Printf("Error retrieving org: %s", err)
+		return err
+	}
+
+	err = e.client.Orgs.Delete(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	e.forgetOrg(org)
+	return nil
+}
+
+// forgetOrg drops the daemon's cached verification of the org's public keys,
+// its search index, and the offline copies of its secrets. Decrypted keyring
+// keys aren't kept by org, so all of them are dropped, along with prefetched
+// secrets; they are fetched again when next needed.
+func (e *Engine) forgetOrg(org *envelope.Org) {
+	e.trust.forget(org.ID)
+	e.search.forget(org.ID)
+	e.keys.reset()
+	e.prefetch.reset(false)
+
+	// Entries are keyed by the identity they were cached for, and their path.
+	prefix := "/" + org.Body.Name + "/"
+	var keys []string
+	err := e.db.ForEach(offlineBucket, func(key string, value []byte) error {
+		i := strings.Index(key, ":")
+		if i >= 0 && strings.HasPrefix(key[i+1:], prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error reading offline cache: %s", err)
+		return
+	}
+
+	for _, key := range keys {
+		err := e.db.Delete(offlineBucket, key)
+		if err != nil {
+			log.Printf("Error removing %s from offline cache: %s", key, err)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/base64"
+	"github.com/manifoldco/torus-cli/canonical"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/crypto"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// systemPolicyType is the type of the policies the registry creates for each
+// org. The registry has no signing key of its own, so they are never signed.
+const systemPolicyType = "system"
+
+// CreatePolicy signs the policy with the session's signing key for its org,
+// and creates it in the registry.
+func (e *Engine) CreatePolicy(ctx context.Context, policy *primitive.Policy) (*envelope.Policy, error) {
+	if policy.OrgID == nil {
+		return nil, &apitypes.Error{
+			StatusCode: http.StatusBadRequest,
+			Type:       apitypes.BadRequestError,
+			Err:        []string{"Policy has no org_id"},
+		}
+	}
+
+	err := e.signPolicy(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := identity.NewMutable(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.client.Policies.Create(ctx, &envelope.Policy{
+		ID:      &id,
+		Version: uint8(policy.Version()),
+		Body:    policy,
+	})
+}
+
+// SignPolicy signs an existing policy in org with the session's signing key,
+// and saves the signature in the registry. It is used to sign policies
+// created before the registry supported signing them.
+//
+// The policy is signed as it is stored, so it should be reviewed first.
+func (e *Engine) SignPolicy(ctx context.Context, orgID, policyID *identity.ID) (*envelope.Policy, error) {
+	policies, err := e.client.Policies.List(ctx, orgID)
+	if err != nil {
+		log.Printf("Error retrieving policies: %s", err)
+		return nil, err
+	}
+
+	var policy *envelope.Policy
+	for i, p := range policies {
+		if *p.ID == *policyID {
+			policy = &policies[i]
+			break
+		}
+	}
+	if policy == nil {
+		return nil, &apitypes.Error{
+			StatusCode: http.StatusNotFound,
+			Type:       apitypes.NotFoundError,
+			Err:        []string{"Policy not found"},
+		}
+	}
+	if policy.Body.PolicyType == systemPolicyType {
+		return nil, &apitypes.Error{
+			StatusCode: http.StatusBadRequest,
+			Type:       apitypes.BadRequestError,
+			Err:        []string{"System policies can't be signed"},
+		}
+	}
+	if len(policy.Unknown) > 0 {
+		return nil, &apitypes.Error{
+			StatusCode: http.StatusBadRequest,
+			Type:       apitypes.BadRequestError,
+			Err:        []string{"Policy has fields this version of torus can't sign"},
+		}
+	}
+
+	err = e.signPolicy(ctx, policy.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.client.Policies.Update(ctx, policy)
+}
+
+// signPolicy sets the policy's signature, made with the session's signing key
+// for its org.
+func (e *Engine) signPolicy(ctx context.Context, policy *primitive.Policy) error {
+	sigID, _, kp, err := fetchWritableKeyPairs(ctx, e.client, policy.OrgID)
+	if err != nil {
+		log.Printf("Error retrieving keypairs: %s", err)
+		return err
+	}
+
+	b, err := policySigningBytes(policy)
+	if err != nil {
+		return err
+	}
+
+	sig, err := e.crypto.Sign(ctx, kp.Signature, b)
+	if err != nil {
+		log.Printf("Error signing policy: %s", err)
+		return err
+	}
+
+	policy.Signature = &primitive.Signature{
+		PublicKeyID: sigID,
+		Algorithm:   crypto.EdDSA,
+		Value:       base64.NewValue(sig),
+	}
+
+	return nil
+}
+
+// PolicySignatures verifies the signature of every policy in the org.
+func (e *Engine) PolicySignatures(ctx context.Context, orgID *identity.ID) ([]apitypes.PolicySignature, error) {
+	policies, err := e.client.Policies.List(ctx, orgID)
+	if err != nil {
+		log.Printf("Error retrieving policies: %s", err)
+		return nil, err
+	}
+
+	return e.verifyPolicies(ctx, orgID, policies)
+}
+
+// verifyPolicies verifies the signatures of the given policies in org,
+// returning the outcome for each, in the same order. Signatures must be made
+// by a signing key in the org's claim tree that is trusted and not revoked.
+//
+// Once the registry signs policies, a policy with conditions must be signed;
+// otherwise stripping its signature would be enough to change them. Other
+// unsigned policies, created before the registry signed them, are still used,
+// but a warning is logged until they are signed with torus policies sign.
+// Registries whose capabilities can't be retrieved are assumed not to sign
+// policies, so a failed lookup doesn't refuse every secret.
+func (e *Engine) verifyPolicies(ctx context.Context, orgID *identity.ID,
+	policies []envelope.Policy) ([]apitypes.PolicySignature, error) {
+
+	results := make([]apitypes.PolicySignature, len(policies))
+	if len(policies) == 0 {
+		return results, nil
+	}
+
+	signed, err := e.client.Supports(ctx, registry.FeatureSignedPolicy)
+	if err != nil {
+		log.Printf("Error retrieving registry capabilities: %s", err)
+		signed = false
+	}
+
+	var segments map[identity.ID]*apitypes.PublicKeySegment
+	for i, p := range policies {
+		results[i] = apitypes.PolicySignature{PolicyID: p.ID, Status: apitypes.PolicyUnsigned}
+		if p.Body.Signature == nil {
+			if !signed || p.Body.PolicyType == systemPolicyType {
+				continue
+			}
+
+			if hasConditions(policies[i : i+1]) {
+				results[i].Status = apitypes.PolicyInvalid
+				results[i].Problem = "policy has conditions but is not signed"
+			} else {
+				log.Printf("Policy %s in org %s is not signed; sign it with torus policies sign",
+					p.Body.Policy.Name, orgID)
+			}
+			continue
+		}
+
+		if segments == nil {
+			trees, err := e.client.ClaimTree.List(ctx, orgID, nil)
+			if err != nil {
+				log.Printf("Error retrieving claim tree: %s", err)
+				return nil, err
+			}
+
+			segments = make(map[identity.ID]*apitypes.PublicKeySegment)
+			for _, tree := range trees {
+				for j := range tree.PublicKeys {
+					segment := &tree.PublicKeys[j]
+					segments[*segment.PublicKey.ID] = segment
+				}
+			}
+		}
+
+		signer, problem, err := e.verifyPolicy(ctx, orgID, &policies[i], segments)
+		if err != nil {
+			return nil, err
+		}
+
+		if problem != "" {
+			results[i].Status = apitypes.PolicyInvalid
+			results[i].Problem = problem
+		} else {
+			results[i].Status = apitypes.PolicySigned
+			results[i].SignerID = signer
+		}
+	}
+
+	return results, nil
+}
+
+// verifyPolicy verifies the signature of a signed policy, returning who signed
+// it, or the reason it can't be trusted.
+func (e *Engine) verifyPolicy(ctx context.Context, orgID *identity.ID, p *envelope.Policy,
+	segments map[identity.ID]*apitypes.PublicKeySegment) (*identity.ID, string, error) {
+
+	sig := p.Body.Signature
+	if len(p.Unknown) > 0 {
+		return nil, "policy has fields this version of torus can't verify", nil
+	}
+	if p.Body.OrgID == nil || *p.Body.OrgID != *orgID {
+		return nil, "policy belongs to another org", nil
+	}
+	if sig.PublicKeyID == nil || sig.Value == nil {
+		return nil, "signature is incomplete", nil
+	}
+
+	segment, ok := segments[*sig.PublicKeyID]
+	if !ok {
+		return nil, "signed by an unknown key", nil
+	}
+
+	pk := segment.PublicKey
+	if pk.Body.KeyType != primitive.SigningKeyType || pk.Body.Key.Value == nil ||
+		len(*pk.Body.Key.Value) != ed25519.PublicKeySize {
+		return nil, "signed by a key that is not a signing key", nil
+	}
+	if segment.Revoked() {
+		return nil, "signed by a revoked key", nil
+	}
+
+	// Signatures made before the key expired remain valid, so only
+	// anomalies in the key's claims are considered.
+	trust, err := e.trust.verifySegment(ctx, segment, segments)
+	if err != nil {
+		return nil, "", err
+	}
+	if trust.anomaly != "" {
+		return nil, "signed by an untrusted key: " + trust.anomaly, nil
+	}
+
+	b, err := policySigningBytes(p.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s := crypto.SignatureKeyPair{Public: ed25519.PublicKey(*pk.Body.Key.Value)}
+	ok, err = e.crypto.Verify(ctx, s, b, *sig.Value)
+	if err != nil {
+		return nil, "", err
+	}
+	if !ok {
+		return nil, "signature is invalid", nil
+	}
+
+	return pk.Body.OwnerID, "", nil
+}
+
+// policySigningBytes returns the bytes signed for a policy: its schema version
+// and canonical body, without the signature.
+func policySigningBytes(policy *primitive.Policy) ([]byte, error) {
+	unsigned := *policy
+	unsigned.Signature = nil
+	return canonical.SigningBytes(unsigned.Version(), &unsigned)
+}
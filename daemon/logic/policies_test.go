@@ -0,0 +1,221 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/base64"
+	"github.com/manifoldco/torus-cli/canonical"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/crypto"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+	"github.com/manifoldco/torus-cli/daemon/session"
+)
+
+func TestPolicySigningBytes(t *testing.T) {
+	policy := &primitive.Policy{PolicyType: "user"}
+	policy.Policy.Name = "read-dev"
+	policy.Policy.Statements = []primitive.PolicyStatement{{
+		Effect:   primitive.PolicyEffectAllow,
+		Action:   primitive.PolicyActionRead,
+		Resource: "/acme/app/dev/*/*/*/*",
+	}}
+
+	unsigned, err := policySigningBytes(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig := &primitive.Signature{Algorithm: "eddsa", Value: base64.NewValue([]byte("sig"))}
+	policy.Signature = sig
+	signed, err := policySigningBytes(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(unsigned, signed) {
+		t.Errorf("expected the signature to be left out of the signed bytes:\n%s\n%s", unsigned, signed)
+	}
+	if policy.Signature != sig {
+		t.Error("expected the policy's signature to be kept")
+	}
+
+	policy.Policy.Statements[0].Effect = primitive.PolicyEffectDeny
+	tampered, err := policySigningBytes(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(signed, tampered) {
+		t.Error("expected changing a statement to change the signed bytes")
+	}
+}
+
+// signingSegment returns a self-signed signing key belonging to owner in org,
+// as it appears in the org's claim tree, along with its private key.
+func signingSegment(t *testing.T, id byte, orgID, owner identity.ID) (apitypes.PublicKeySegment, ed25519.PrivateKey) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := &primitive.PublicKey{
+		Algorithm: crypto.EdDSA,
+		Key:       primitive.PublicKeyValue{Value: base64.NewValue(public)},
+		OrgID:     &orgID,
+		OwnerID:   &owner,
+		KeyType:   primitive.SigningKeyType,
+	}
+
+	return apitypes.PublicKeySegment{
+		PublicKey: &envelope.PublicKey{
+			ID:        &identity.ID{0x01, 0x06, id},
+			Version:   1,
+			Body:      body,
+			Signature: signBody(t, body, nil, private),
+		},
+	}, private
+}
+
+// signBody signs the immutable body with private, as made by the key keyID.
+func signBody(t *testing.T, body identity.Immutable, keyID *identity.ID, private ed25519.PrivateKey) primitive.Signature {
+	b, err := canonical.SigningBytes(body.Version(), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return primitive.Signature{
+		PublicKeyID: keyID,
+		Algorithm:   crypto.EdDSA,
+		Value:       base64.NewValue(ed25519.Sign(private, b)),
+	}
+}
+
+// claimTreeEngine returns an Engine backed by a registry serving the given
+// features and claim tree for org. The registry must be closed when done.
+func claimTreeEngine(features []string, orgID identity.ID,
+	segments ...apitypes.PublicKeySegment) (*Engine, *httptest.Server) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v interface{}
+		switch r.URL.Path {
+		case "/capabilities":
+			v = &apitypes.Capabilities{Features: features}
+		case "/claimtree":
+			v = []registry.ClaimTree{{
+				Org:        &envelope.Org{ID: &orgID},
+				PublicKeys: segments,
+			}}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(v)
+	}))
+
+	e := &Engine{
+		crypto: crypto.NewEngine(session.NewSession()),
+		client: registry.NewClient(srv.URL, "0.1.0", "test", session.NewSession(), &http.Transport{}),
+	}
+	e.trust = newKeyTrust(e)
+	return e, srv
+}
+
+func TestVerifyPolicies(t *testing.T) {
+	orgID := identity.ID{0x01, 0x04, 0x01}
+	owner := identity.ID{0x01, 0x01, 0x01}
+	segment, private := signingSegment(t, 1, orgID, owner)
+
+	policy := func(id byte, policyType string, sign bool) envelope.Policy {
+		body := &primitive.Policy{PolicyType: policyType, OrgID: &orgID}
+		body.Policy.Name = "read-dev"
+		body.Policy.Statements = []primitive.PolicyStatement{{
+			Effect:   primitive.PolicyEffectAllow,
+			Action:   primitive.PolicyActionRead,
+			Resource: "/acme/app/dev/*/*/*/*",
+			Conditions: &primitive.PolicyConditions{
+				Hours: "09:00-17:00",
+			},
+		}}
+
+		if sign {
+			b, err := policySigningBytes(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body.Signature = &primitive.Signature{
+				PublicKeyID: segment.PublicKey.ID,
+				Algorithm:   crypto.EdDSA,
+				Value:       base64.NewValue(ed25519.Sign(private, b)),
+			}
+		}
+
+		return envelope.Policy{ID: &identity.ID{0x01, 0x11, id}, Version: 1, Body: body}
+	}
+
+	signed := policy(1, "user", true)
+
+	stripped := policy(2, "user", true)
+	stripped.Body.Signature = nil
+
+	tampered := policy(3, "user", true)
+	tampered.Body.Policy.Statements[0].Conditions = nil
+
+	system := policy(4, "system", false)
+
+	// Policies created before the registry signed them have no conditions.
+	legacy := policy(5, "user", false)
+	legacy.Body.Policy.Statements[0].Conditions = nil
+
+	policies := []envelope.Policy{signed, stripped, tampered, system, legacy}
+
+	tcs := []struct {
+		name     string
+		features []string
+		statuses []string
+	}{
+		{
+			name:     "signing registry",
+			features: []string{registry.FeatureSignedPolicy},
+			statuses: []string{apitypes.PolicySigned, apitypes.PolicyInvalid,
+				apitypes.PolicyInvalid, apitypes.PolicyUnsigned, apitypes.PolicyUnsigned},
+		},
+		{
+			name: "older registry",
+			statuses: []string{apitypes.PolicySigned, apitypes.PolicyUnsigned,
+				apitypes.PolicyInvalid, apitypes.PolicyUnsigned, apitypes.PolicyUnsigned},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			e, srv := claimTreeEngine(tc.features, orgID, segment)
+			defer srv.Close()
+
+			results, err := e.verifyPolicies(context.Background(), &orgID, policies)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, r := range results {
+				if r.Status != tc.statuses[i] {
+					t.Errorf("policy %d: got status %q (%s), want %q", i, r.Status, r.Problem, tc.statuses[i])
+				}
+			}
+
+			if results[0].SignerID == nil || *results[0].SignerID != owner {
+				t.Errorf("got signer %v, want %v", results[0].SignerID, owner)
+			}
+		})
+	}
+}
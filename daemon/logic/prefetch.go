@@ -0,0 +1,275 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+const (
+	// prefetchIdle is how long the daemon must go without serving
+	// credentials before it starts refreshing its cache in the background.
+	prefetchIdle = 2 * time.Minute
+
+	// prefetchRefresh is how old a cached bundle may get before an idle
+	// daemon refreshes it.
+	prefetchRefresh = 5 * time.Minute
+
+	// prefetchMaxAge is how old a cached bundle may be and still be served.
+	// Past this, credentials are always fetched from the registry.
+	prefetchMaxAge = 10 * time.Minute
+
+	// prefetchExpiry is how long a path is watched for after it was last
+	// requested or registered.
+	prefetchExpiry = 7 * 24 * time.Hour
+
+	prefetchTick = time.Minute
+)
+
+// prefetcher caches the encrypted credential bundles for the paths credentials
+// are commonly retrieved from, keeping them warm while the daemon is idle, so
+// the next `torus run` does not wait on the registry.
+//
+// Paths are learned from credential requests, and from `torus link`. Cached
+// bundles are dropped whenever the daemon changes credentials or keys, or the
+// session changes.
+//
+// A prefetched bundle is served at most once: to the first request after an
+// idle period, which is the one that would otherwise wait. Later requests
+// fetch from the registry, so secrets changed elsewhere are seen as soon as
+// the daemon is busy again.
+type prefetcher struct {
+	engine  *Engine
+	enabled bool
+
+	mutex       sync.Mutex
+	paths       map[string]*prefetchEntry
+	lastActive  time.Time
+	lastRefresh *time.Time
+	hits        uint64
+	misses      uint64
+
+	// generation is bumped on every reset, so bundles fetched before a
+	// reset are not stored after it.
+	generation uint64
+}
+
+type prefetchEntry struct {
+	bundle    *credentialBundle
+	fetched   time.Time
+	requested time.Time
+
+	// served is set once the bundle has been served, or if it was fetched
+	// for a request, so it is fetched again before it is next served.
+	served bool
+}
+
+func newPrefetcher(e *Engine, enabled bool) *prefetcher {
+	return &prefetcher{
+		engine:  e,
+		enabled: enabled,
+		paths:   make(map[string]*prefetchEntry),
+	}
+}
+
+// watch registers path to be prefetched.
+func (p *prefetcher) watch(path string) {
+	if !p.enabled {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	entry, ok := p.paths[path]
+	if !ok {
+		entry = &prefetchEntry{}
+		p.paths[path] = entry
+	}
+	entry.requested = time.Now()
+}
+
+// get returns the prefetched bundle for path, if it is fresh enough and has
+// not been served yet, recording the request as a hit or a miss.
+func (p *prefetcher) get(path string) *credentialBundle {
+	if !p.enabled {
+		return nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	p.lastActive = now
+
+	entry, ok := p.paths[path]
+	if !ok || entry.bundle == nil || entry.served || now.Sub(entry.fetched) > prefetchMaxAge {
+		p.misses++
+		return nil
+	}
+
+	p.hits++
+	entry.requested = now
+	entry.served = true
+	return entry.bundle
+}
+
+// current returns the cache generation, to be passed to store for a bundle
+// about to be fetched.
+func (p *prefetcher) current() uint64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.generation
+}
+
+// store caches the bundle fetched for a request for path, and starts watching
+// it. The bundle is discarded if the cache has been reset since generation.
+// It is not served until it has been refreshed while the daemon was idle.
+func (p *prefetcher) store(path string, bundle *credentialBundle, generation uint64) {
+	if !p.enabled {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if generation != p.generation {
+		return
+	}
+
+	now := time.Now()
+	p.paths[path] = &prefetchEntry{
+		bundle:    bundle,
+		fetched:   now,
+		requested: now,
+		served:    true,
+	}
+}
+
+// reset drops every cached bundle. Watched paths are kept when the session
+// is unchanged, as they will still be wanted.
+func (p *prefetcher) reset(forgetPaths bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.generation++
+	if forgetPaths {
+		p.paths = make(map[string]*prefetchEntry)
+		return
+	}
+
+	for _, entry := range p.paths {
+		entry.bundle = nil
+	}
+}
+
+// run refreshes stale bundles whenever the daemon is idle, until ctx is done.
+func (p *prefetcher) run(ctx context.Context) {
+	if !p.enabled {
+		return
+	}
+
+	ticker := time.NewTicker(prefetchTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.refresh(ctx)
+		}
+	}
+}
+
+// refresh fetches fresh bundles for every watched path whose bundle is
+// missing or getting stale, forgetting paths that have not been used in a
+// long time.
+func (p *prefetcher) refresh(ctx context.Context) {
+	if p.engine.session.Type() == apitypes.NotLoggedIn {
+		return
+	}
+
+	now := time.Now()
+	stale, generation := p.stale(now)
+	for _, path := range stale {
+		bundle, err := p.engine.fetchCredentialBundle(ctx, &path, nil)
+		if err != nil {
+			log.Printf("Error prefetching credentials for %s: %s", path, err)
+			continue
+		}
+
+		p.update(path, bundle, generation, time.Now())
+	}
+
+	p.mutex.Lock()
+	p.lastRefresh = &now
+	p.mutex.Unlock()
+}
+
+// stale returns the watched paths whose bundles need fetching as of now, and
+// the generation to pass to update for them. Paths that have not been used in
+// a long time are forgotten. Nothing is stale unless the daemon is idle.
+func (p *prefetcher) stale(now time.Time) ([]string, uint64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if now.Sub(p.lastActive) < prefetchIdle {
+		return nil, p.generation
+	}
+
+	var stale []string
+	for path, entry := range p.paths {
+		if now.Sub(entry.requested) > prefetchExpiry {
+			delete(p.paths, path)
+			continue
+		}
+
+		if entry.bundle == nil || entry.served || now.Sub(entry.fetched) > prefetchRefresh {
+			stale = append(stale, path)
+		}
+	}
+
+	sort.Strings(stale)
+	return stale, p.generation
+}
+
+// update caches bundle, refreshed at fetched, to be served for path. It is
+// only kept if path is still watched, and the cache has not been reset since
+// generation.
+func (p *prefetcher) update(path string, bundle *credentialBundle, generation uint64, fetched time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if entry, ok := p.paths[path]; ok && generation == p.generation {
+		entry.bundle = bundle
+		entry.fetched = fetched
+		entry.served = false
+	}
+}
+
+// stats returns the prefetcher's current state and hit rate.
+func (p *prefetcher) stats() *apitypes.PrefetchStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats := &apitypes.PrefetchStats{
+		Enabled:     p.enabled,
+		Hits:        p.hits,
+		Misses:      p.misses,
+		LastRefresh: p.lastRefresh,
+		Paths:       []string{},
+	}
+
+	for path := range p.paths {
+		stats.Paths = append(stats.Paths, path)
+	}
+	sort.Strings(stats.Paths)
+
+	return stats
+}
@@ -0,0 +1,242 @@
+package logic
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPrefetcherGet(t *testing.T) {
+	now := time.Now()
+	bundle := &credentialBundle{}
+
+	tcs := []struct {
+		name    string
+		enabled bool
+		entry   *prefetchEntry
+		hit     bool
+	}{
+		{name: "disabled", entry: &prefetchEntry{bundle: bundle, fetched: now}},
+		{name: "not watched", enabled: true},
+		{name: "no bundle", enabled: true, entry: &prefetchEntry{fetched: now}},
+		{
+			name:    "already served",
+			enabled: true,
+			entry:   &prefetchEntry{bundle: bundle, fetched: now, served: true},
+		},
+		{
+			name:    "too old",
+			enabled: true,
+			entry:   &prefetchEntry{bundle: bundle, fetched: now.Add(-prefetchMaxAge - time.Minute)},
+		},
+		{
+			name:    "fresh",
+			enabled: true,
+			entry:   &prefetchEntry{bundle: bundle, fetched: now},
+			hit:     true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newPrefetcher(nil, tc.enabled)
+			if tc.entry != nil {
+				p.paths["/o/p/e/s/u/1"] = tc.entry
+			}
+
+			got := p.get("/o/p/e/s/u/1")
+			if tc.hit != (got != nil) {
+				t.Fatalf("expected hit %t, got bundle %v", tc.hit, got)
+			}
+
+			if !tc.enabled {
+				return
+			}
+
+			stats := p.stats()
+			if tc.hit && (stats.Hits != 1 || stats.Misses != 0) ||
+				!tc.hit && (stats.Hits != 0 || stats.Misses != 1) {
+				t.Errorf("wrong hit rate: %d hits, %d misses", stats.Hits, stats.Misses)
+			}
+
+			if tc.hit && p.get("/o/p/e/s/u/1") != nil {
+				t.Error("bundle served more than once")
+			}
+		})
+	}
+}
+
+func TestPrefetcherStore(t *testing.T) {
+	bundle := &credentialBundle{}
+
+	tcs := []struct {
+		name   string
+		reset  bool
+		stored bool
+	}{
+		{name: "current generation", stored: true},
+		{name: "reset since fetched", reset: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newPrefetcher(nil, true)
+			generation := p.current()
+			if tc.reset {
+				p.reset(false)
+			}
+
+			p.store("/o/p/e/s/u/1", bundle, generation)
+
+			entry, ok := p.paths["/o/p/e/s/u/1"]
+			if ok != tc.stored {
+				t.Fatalf("expected stored %t, got %t", tc.stored, ok)
+			}
+			if !ok {
+				return
+			}
+
+			// A bundle fetched for a request was just used by it, so it
+			// isn't served again until it is refreshed.
+			if !entry.served || p.get("/o/p/e/s/u/1") != nil {
+				t.Error("bundle fetched for a request was served")
+			}
+		})
+	}
+}
+
+func TestPrefetcherReset(t *testing.T) {
+	tcs := []struct {
+		name        string
+		forgetPaths bool
+	}{
+		{name: "same session"},
+		{name: "new session", forgetPaths: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newPrefetcher(nil, true)
+			p.paths["/o/p/e/s/u/1"] = &prefetchEntry{bundle: &credentialBundle{}, fetched: time.Now()}
+			generation := p.current()
+
+			p.reset(tc.forgetPaths)
+
+			if p.current() == generation {
+				t.Error("generation not bumped")
+			}
+
+			entry, ok := p.paths["/o/p/e/s/u/1"]
+			if ok == tc.forgetPaths {
+				t.Fatalf("expected path kept %t, got %t", !tc.forgetPaths, ok)
+			}
+			if ok && entry.bundle != nil {
+				t.Error("bundle kept after reset")
+			}
+		})
+	}
+}
+
+func TestPrefetcherStale(t *testing.T) {
+	now := time.Now()
+	bundle := &credentialBundle{}
+
+	fresh := func() *prefetchEntry {
+		return &prefetchEntry{bundle: bundle, fetched: now, requested: now}
+	}
+
+	tcs := []struct {
+		name       string
+		lastActive time.Time
+		entry      *prefetchEntry
+		stale      bool
+		forgotten  bool
+	}{
+		{name: "fresh", entry: fresh()},
+		{name: "no bundle", entry: &prefetchEntry{requested: now}, stale: true},
+		{
+			name:  "served",
+			entry: &prefetchEntry{bundle: bundle, fetched: now, requested: now, served: true},
+			stale: true,
+		},
+		{
+			name: "refresh due",
+			entry: &prefetchEntry{
+				bundle: bundle, fetched: now.Add(-prefetchRefresh - time.Minute), requested: now,
+			},
+			stale: true,
+		},
+		{
+			name:       "busy",
+			lastActive: now,
+			entry:      &prefetchEntry{requested: now},
+		},
+		{
+			name:      "unused",
+			entry:     &prefetchEntry{requested: now.Add(-prefetchExpiry - time.Hour)},
+			forgotten: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newPrefetcher(nil, true)
+			p.lastActive = tc.lastActive
+			p.paths["/o/p/e/s/u/1"] = tc.entry
+
+			stale, generation := p.stale(now)
+			if generation != p.current() {
+				t.Errorf("wrong generation: %d != %d", generation, p.current())
+			}
+
+			var want []string
+			if tc.stale {
+				want = []string{"/o/p/e/s/u/1"}
+			}
+			if !reflect.DeepEqual(stale, want) {
+				t.Errorf("expected stale %v, got %v", want, stale)
+			}
+
+			if _, ok := p.paths["/o/p/e/s/u/1"]; ok == tc.forgotten {
+				t.Errorf("expected forgotten %t, got %t", tc.forgotten, !ok)
+			}
+		})
+	}
+}
+
+func TestPrefetcherUpdate(t *testing.T) {
+	bundle := &credentialBundle{}
+
+	tcs := []struct {
+		name    string
+		watched bool
+		reset   bool
+		served  bool
+	}{
+		{name: "watched", watched: true, served: true},
+		{name: "no longer watched"},
+		{name: "reset while fetching", watched: true, reset: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newPrefetcher(nil, true)
+			if tc.watched {
+				p.watch("/o/p/e/s/u/1")
+			}
+			generation := p.current()
+			if tc.reset {
+				p.reset(false)
+			}
+
+			p.update("/o/p/e/s/u/1", bundle, generation, time.Now())
+
+			if got := p.get("/o/p/e/s/u/1"); (got != nil) != tc.served {
+				t.Fatalf("expected served %t, got bundle %v", tc.served, got)
+			}
+			if p.get("/o/p/e/s/u/1") != nil {
+				t.Error("refreshed bundle served more than once")
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+// checkProtectedEnvs enforces the org's protected environments on a write of
+// secrets to pe.
+//
+// A path expression that matches a protected environment through a wildcard
+// or alternation may not be written to at all, so that a secret meant for
+// development can't leak into production. Naming a protected environment
+// exactly is allowed only once the write has been confirmed.
+func (e *Engine) checkProtectedEnvs(ctx context.Context, orgID *identity.ID,
+	pe *pathexp.PathExp, confirmed bool) error {
+
+	settings, err := e.client.Orgs.GetSettings(ctx, orgID)
+	if apitypes.IsNotFoundError(err) {
+		return nil // the org has no settings
+	}
+	if err != nil {
+		log.Printf("Error retrieving org settings: %s", err)
+		return err
+	}
+
+	for _, env := range settings.Environments {
+		if !env.Protected || !pe.Envs.Contains(env.Name) {
+			continue
+		}
+
+		if pe.Envs.String() != env.Name {
+			return &apitypes.Error{
+				StatusCode: http.StatusForbidden,
+				Type:       apitypes.UnauthorizedError,
+				Err: []string{"The environment " + env.Name + " is protected, " +
+					"and can not be written to with the wildcard " + pe.Envs.String()},
+			}
+		}
+
+		if !confirmed {
+			return &apitypes.Error{
+				StatusCode: http.StatusPreconditionRequired,
+				Type:       apitypes.ConfirmationRequiredError,
+				Err: []string{"You are about to change secrets in the protected " +
+					"environment " + env.Name + "."},
+			}
+		}
+	}
+
+	return nil
+}
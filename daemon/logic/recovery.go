@@ -0,0 +1,99 @@
+package logic
+
+import (
+	"context"
+	"log"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/daemon/crypto"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// recoveryCodeCount is the number of recovery codes a user is given.
+const recoveryCodeCount = 10
+
+// GenerateRecoveryCodes creates a new set of one-time recovery codes for the
+// current user, replacing any they had before.
+//
+// Each code wraps the user's master key. Only a hash of the code and the
+// wrapped key are sent to the registry, so it can't use the codes to decrypt
+// the user's secrets.
+func (s *Session) GenerateRecoveryCodes(ctx context.Context) (*apitypes.RecoveryCodes, error) {
+	if s.engine.session.Type() != apitypes.UserSession {
+		return nil, &apitypes.Error{
+			Type: apitypes.UnauthorizedError,
+			Err:  []string{"only users can have recovery codes"},
+		}
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := crypto.NewRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+	}
+
+	masters, err := s.engine.crypto.WrapMasterKeys(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := make([]registry.RecoveryCode, len(codes))
+	for i, code := range codes {
+		wrapped[i] = registry.RecoveryCode{
+			Lookup: crypto.RecoveryLookup(code),
+			Master: masters[i],
+		}
+	}
+
+	err = s.engine.client.Recovery.Replace(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apitypes.RecoveryCodes{Codes: codes}, nil
+}
+
+// Recover replaces a forgotten password using a recovery code.
+//
+// The registry hands back the master key wrapped with the code, which is
+// unwrapped here and encrypted again with the new password. The code can't be
+// used again.
+func (s *Session) Recover(ctx context.Context, req *apitypes.RecoveryRequest) error {
+	if req.Email == "" || req.Code == "" {
+		return &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{"email and recovery code are required"},
+		}
+	}
+
+	if len(req.Password) < 8 {
+		return &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{"passwords must be at least 8 characters"},
+		}
+	}
+
+	grant, err := s.engine.client.Recovery.Start(ctx, req.Email, crypto.RecoveryLookup(req.Code))
+	if err != nil {
+		return err
+	}
+
+	masterKey, err := crypto.UnwrapMasterKey(ctx, req.Code, grant.Master)
+	if err != nil {
+		log.Printf("Could not unwrap master key: %s", err)
+		return &apitypes.Error{
+			Type: apitypes.UnauthorizedError,
+			Err:  []string{"invalid recovery code"},
+		}
+	}
+
+	pw, master, err := crypto.EncryptPasswordObject(ctx, req.Password, &masterKey)
+	if err != nil {
+		return err
+	}
+
+	return s.engine.client.Recovery.Reset(ctx, grant.Token, pw, master)
+}
@@ -0,0 +1,320 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/base64"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/crypto"
+	"github.com/manifoldco/torus-cli/daemon/observer"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// RenewKeypairs replaces the current user's keypairs for the given org before
+// they expire.
+//
+// New keypairs are generated and uploaded, and the old signing key signs a
+// claim against each new key, so others can see the new keys continue from
+// the old ones. Every keyring membership is then shared again with the new
+// encryption key, and the old keypairs are revoked.
+//
+// Each step is skipped if it was already done, so a renewal that failed part
+// way through is finished by running it again.
+func (e *Engine) RenewKeypairs(ctx context.Context, notifier *observer.Notifier,
+	orgID *identity.ID) error {
+
+	n := notifier.Notifier(7)
+
+	keyPairs, err := e.client.KeyPairs.List(ctx, orgID)
+	if err != nil {
+		log.Printf("Error retrieving keypairs: %s", err)
+		return err
+	}
+
+	r, err := planRenewal(keyPairs)
+	if err != nil {
+		return err
+	}
+	if r.newSig != nil {
+		log.Printf("Resuming keypair renewal for org %s", orgID)
+	}
+	oldKP := bundleKeypairs(r.oldSig, r.oldEnc)
+
+	// Find the memberships to move over before creating any keys, so nothing
+	// is left half done if they can't be read.
+	graphs, err := findActiveGraphs(ctx, e.client, e.session, orgID)
+	if err != nil {
+		return err
+	}
+
+	created, err := e.renewedKeypairs(ctx, n, orgID, r)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range []struct {
+		key  *envelope.PublicKey
+		head *envelope.Claim
+	}{
+		{created.sigKey, created.sigClaim},
+		{created.encKey, created.encClaim},
+	} {
+		// The continuity claim is the last made against a new key, so a
+		// head signed by the old key means it was already uploaded.
+		if *k.head.Signature.PublicKeyID == *r.oldSig.PublicKey.ID {
+			continue
+		}
+
+		body := primitive.NewClaim(orgID, e.session.AuthID(), k.head.ID, k.key.ID,
+			primitive.SignatureClaimType)
+		claim, err := e.crypto.SignedClaim(ctx, body, r.oldSig.PublicKey.ID, &oldKP.Signature)
+		if err != nil {
+			log.Printf("Error creating continuity claim: %s", err)
+			return err
+		}
+
+		_, err = e.client.Claims.Create(ctx, claim)
+		if err != nil {
+			log.Printf("Error uploading continuity claim: %s", err)
+			return err
+		}
+	}
+
+	n.Notify(observer.Progress, "New keys signed by old keys", true)
+
+	err = e.renewMemberships(ctx, graphs, oldKP, created)
+	if err != nil {
+		return err
+	}
+
+	n.Notify(observer.Progress, "Keyring memberships moved to new keys", true)
+
+	for _, old := range []*registry.ClaimedKeyPair{r.oldEnc, r.oldSig} {
+		if old == nil {
+			continue
+		}
+
+		prev, err := old.HeadClaim()
+		if err != nil {
+			return err
+		}
+
+		body := primitive.NewClaim(orgID, e.session.AuthID(), prev.ID,
+			old.PublicKey.ID, primitive.RevocationClaimType)
+		claim, err := e.crypto.SignedClaim(ctx, body, r.oldSig.PublicKey.ID, &oldKP.Signature)
+		if err != nil {
+			log.Printf("Error creating revocation claim: %s", err)
+			return err
+		}
+
+		_, err = e.client.Claims.Create(ctx, claim)
+		if err != nil {
+			log.Printf("Error uploading revocation claim: %s", err)
+			return err
+		}
+	}
+
+	n.Notify(observer.Progress, "Old keys revoked", true)
+
+	e.prefetch.reset(false)
+	e.trust.bootstrap(orgID)
+
+	return nil
+}
+
+// renewal holds the keypairs involved in renewing the user's keys for an
+// org. The new keypairs are set when an earlier renewal uploaded them before
+// it was interrupted, and oldEnc is nil if it was already revoked.
+type renewal struct {
+	oldSig *registry.ClaimedKeyPair
+	oldEnc *registry.ClaimedKeyPair
+	newSig *registry.ClaimedKeyPair
+	newEnc *registry.ClaimedKeyPair
+}
+
+// planRenewal works out how far a renewal of the given keypairs has got.
+//
+// Keypairs are only revoked once a renewal is complete, so while one is in
+// progress the user has two unrevoked signing keys. Each encryption key is
+// signed by the signing key it was created with, which tells the old and new
+// encryption keys apart.
+func planRenewal(keyPairs []registry.ClaimedKeyPair) (*renewal, error) {
+	var sigs, encs []*registry.ClaimedKeyPair
+	for i := range keyPairs {
+		kp := &keyPairs[i]
+		if kp.Revoked() {
+			continue
+		}
+
+		switch kt := kp.PublicKey.Body.KeyType; kt {
+		case primitive.SigningKeyType:
+			sigs = append(sigs, kp)
+		case primitive.EncryptionKeyType:
+			encs = append(encs, kp)
+		default:
+			return nil, &apitypes.Error{
+				Type: apitypes.InternalServerError,
+				Err:  []string{fmt.Sprintf("Unknown key type: %s", kt)},
+			}
+		}
+	}
+
+	if len(sigs) == 0 || len(encs) == 0 {
+		return nil, &apitypes.Error{
+			Type: apitypes.NotFoundError,
+			Err:  []string{"No keypairs to renew. Run 'torus keypairs generate' instead."},
+		}
+	}
+	if len(sigs) > 2 || len(encs) > 2 {
+		return nil, &apitypes.Error{
+			Type: apitypes.InternalServerError,
+			Err:  []string{"Too many active keypairs to renew"},
+		}
+	}
+
+	r := &renewal{oldSig: sigs[0]}
+	if len(sigs) == 2 {
+		if sigs[1].PublicKey.Body.Created.Before(sigs[0].PublicKey.Body.Created) {
+			sigs[0], sigs[1] = sigs[1], sigs[0]
+		}
+		r.oldSig, r.newSig = sigs[0], sigs[1]
+	}
+
+	for _, enc := range encs {
+		switch signer := *enc.PublicKey.Signature.PublicKeyID; {
+		case signer == *r.oldSig.PublicKey.ID && r.oldEnc == nil:
+			r.oldEnc = enc
+		case r.newSig != nil && signer == *r.newSig.PublicKey.ID && r.newEnc == nil:
+			r.newEnc = enc
+		default:
+			return nil, &apitypes.Error{
+				Type: apitypes.InternalServerError,
+				Err:  []string{"Encryption keypair does not match a signing keypair"},
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// renewedKeypairs returns the new keypairs for r, creating those that were
+// not uploaded by an earlier renewal.
+func (e *Engine) renewedKeypairs(ctx context.Context, n *observer.Notifier,
+	orgID *identity.ID, r *renewal) (*createdKeypairs, error) {
+
+	if r.newEnc == nil {
+		return e.createKeypairs(ctx, n, orgID, r.newSig)
+	}
+
+	sigClaim, err := r.newSig.HeadClaim()
+	if err != nil {
+		return nil, err
+	}
+
+	encClaim, err := r.newEnc.HeadClaim()
+	if err != nil {
+		return nil, err
+	}
+
+	return &createdKeypairs{
+		kp:       bundleKeypairs(r.newSig, r.newEnc),
+		sigKey:   r.newSig.PublicKey,
+		sigClaim: sigClaim,
+		encKey:   r.newEnc.PublicKey,
+		encClaim: encClaim,
+	}, nil
+}
+
+// renewMemberships shares the master encryption key of each keyring in graphs
+// with the user's new encryption key, decrypting it with the old one.
+func (e *Engine) renewMemberships(ctx context.Context, graphs []registry.CredentialGraph,
+	oldKP *crypto.KeyPairs, created *createdKeypairs) error {
+
+	authID := e.session.AuthID()
+	newPub := *created.encKey.Body.Key.Value
+
+	var v1members []envelope.KeyringMemberV1
+	for _, graph := range graphs {
+		krm, mekshare, err := graph.FindMember(authID)
+		if err != nil {
+			log.Printf("Error finding keyring membership: %s", err)
+			return err
+		}
+
+		// Already shared with the new key by an earlier renewal.
+		if *krm.PublicKeyID == *created.encKey.ID {
+			continue
+		}
+
+		encryptingKey, err := findEncryptingKey(ctx, e.client, krm.OrgID,
+			krm.EncryptingKeyID)
+		if err != nil {
+			log.Printf("Error finding encrypting key: %s", err)
+			return err
+		}
+
+		mek, err := e.crypto.Unbox(ctx, *mekshare.Key.Value, *mekshare.Key.Nonce,
+			&oldKP.Encryption, *encryptingKey.Key.Value)
+		if err != nil {
+			log.Printf("Error decrypting keyring membership: %s", err)
+			return err
+		}
+
+		ct, nonce, err := e.crypto.Box(ctx, mek, &created.kp.Encryption, newPub)
+		if err != nil {
+			log.Printf("Error encrypting keyring membership: %s", err)
+			return err
+		}
+
+		key := &primitive.KeyringMemberKey{
+			Algorithm: crypto.EasyBox,
+			Nonce:     base64.NewValue(nonce),
+			Value:     base64.NewValue(ct),
+		}
+
+		switch k := graph.GetKeyring().(type) {
+		case *envelope.KeyringV1:
+			member, err := newV1KeyringMember(ctx, e.crypto, krm.OrgID, k.Body.ProjectID,
+				krm.KeyringID, authID, created.encKey.ID, created.encKey.ID,
+				created.sigKey.ID, key, created.kp)
+			if err != nil {
+				return err
+			}
+			v1members = append(v1members, *member)
+		case *envelope.Keyring:
+			member, err := newV2KeyringMember(ctx, e.crypto, krm.OrgID, krm.KeyringID,
+				authID, created.encKey.ID, created.encKey.ID, created.sigKey.ID, key,
+				created.kp)
+			if err != nil {
+				return err
+			}
+
+			err = e.client.Keyring.Members.Post(ctx, *member)
+			if err != nil {
+				log.Printf("Error uploading keyring membership: %s", err)
+				return err
+			}
+		default:
+			return &apitypes.Error{
+				Type: apitypes.InternalServerError,
+				Err:  []string{"Unknown keyring schema version"},
+			}
+		}
+	}
+
+	if len(v1members) != 0 {
+		_, err := e.client.KeyringMember.Post(ctx, v1members)
+		if err != nil {
+			log.Printf("Error uploading keyring memberships: %s", err)
+			return err
+		}
+	}
+
+	return nil
+}
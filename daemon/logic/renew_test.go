@@ -0,0 +1,115 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+func TestPlanRenewal(t *testing.T) {
+	now := time.Now()
+
+	keypair := func(id, signer byte, kt primitive.KeyType, age time.Duration,
+		revoked bool) registry.ClaimedKeyPair {
+
+		kp := registry.ClaimedKeyPair{PublicKeySegment: apitypes.PublicKeySegment{
+			PublicKey: &envelope.PublicKey{
+				ID: &identity.ID{0x01, 0x06, id},
+				Body: &primitive.PublicKey{
+					Created: now.Add(-age),
+					KeyType: kt,
+				},
+				Signature: primitive.Signature{PublicKeyID: &identity.ID{0x01, 0x06, signer}},
+			},
+		}}
+		if revoked {
+			kp.Claims = []envelope.Claim{
+				{Body: &primitive.Claim{ClaimType: primitive.RevocationClaimType}},
+			}
+		}
+		return kp
+	}
+
+	oldSig := keypair(1, 1, primitive.SigningKeyType, time.Hour, false)
+	oldEnc := keypair(2, 1, primitive.EncryptionKeyType, time.Hour, false)
+	newSig := keypair(3, 3, primitive.SigningKeyType, time.Minute, false)
+	newEnc := keypair(4, 3, primitive.EncryptionKeyType, time.Minute, false)
+	revokedEnc := keypair(2, 1, primitive.EncryptionKeyType, time.Hour, true)
+
+	tcs := []struct {
+		name     string
+		keyPairs []registry.ClaimedKeyPair
+		want     []byte // oldSig, oldEnc, newSig, newEnc ids; 0 if unset
+		err      bool
+	}{
+		{name: "no keypairs", err: true},
+		{
+			name:     "not started",
+			keyPairs: []registry.ClaimedKeyPair{oldSig, oldEnc},
+			want:     []byte{1, 2, 0, 0},
+		},
+		{
+			name:     "signing key uploaded",
+			keyPairs: []registry.ClaimedKeyPair{newSig, oldSig, oldEnc},
+			want:     []byte{1, 2, 3, 0},
+		},
+		{
+			name:     "both keys uploaded",
+			keyPairs: []registry.ClaimedKeyPair{oldSig, oldEnc, newSig, newEnc},
+			want:     []byte{1, 2, 3, 4},
+		},
+		{
+			name:     "encryption key revoked",
+			keyPairs: []registry.ClaimedKeyPair{oldSig, revokedEnc, newSig, newEnc},
+			want:     []byte{1, 0, 3, 4},
+		},
+		{
+			name:     "unknown signer",
+			keyPairs: []registry.ClaimedKeyPair{oldSig, newEnc},
+			err:      true,
+		},
+		{
+			name: "too many keys",
+			keyPairs: []registry.ClaimedKeyPair{
+				oldSig, oldEnc, newSig, newEnc,
+				keypair(5, 5, primitive.SigningKeyType, 0, false),
+			},
+			err: true,
+		},
+	}
+
+	id := func(kp *registry.ClaimedKeyPair) byte {
+		if kp == nil {
+			return 0
+		}
+		return kp.PublicKey.ID[2]
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := planRenewal(tc.keyPairs)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []byte{id(r.oldSig), id(r.oldEnc), id(r.newSig), id(r.newEnc)}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("wrong keypairs: %v != %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/crypto"
+	"github.com/manifoldco/torus-cli/daemon/observer"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// resolveWorkers bounds how many registry requests, or keyrings being
+// decrypted, are in flight at once while resolving credentials.
+const resolveWorkers = 8
+
+// parallel calls fn for every i in [0, n), running up to resolveWorkers at
+// once. It returns the error for the lowest i that failed, if any.
+func parallel(n int, fn func(i int) error) error {
+	errs := make([]error, n)
+	sem := make(chan struct{}, resolveWorkers)
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		go func(i int) {
+			errs[i] = fn(i)
+			<-sem
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keyringKeys memoizes decrypted keyring master encryption keys, by keyring
+// ID. A keyring's key never changes; new keys get new keyrings. Decrypting a keyring's key first
+// unseals our private key with the master key, which is deliberately slow.
+//
+// Keys are forgotten whenever the prefetch generation changes, as it does
+// when the session, our keys, or any keyring's state changes.
+type keyringKeys struct {
+	mutex      sync.Mutex
+	generation uint64
+	keys       map[identity.ID][]byte
+}
+
+func newKeyringKeys() *keyringKeys {
+	return &keyringKeys{keys: make(map[identity.ID][]byte)}
+}
+
+// get returns the memoized key for the keyring, if it was stored at the
+// given generation.
+func (k *keyringKeys) get(keyringID *identity.ID, generation uint64) []byte {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if generation != k.generation {
+		return nil
+	}
+
+	return k.keys[*keyringID]
+}
+
+// store memoizes the key for the keyring, decrypted at the given
+// generation. Every key is forgotten once a newer generation is stored.
+func (k *keyringKeys) store(keyringID *identity.ID, mek []byte, generation uint64) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if generation < k.generation {
+		return
+	}
+	if generation > k.generation {
+		k.keys = make(map[identity.ID][]byte)
+		k.generation = generation
+	}
+
+	k.keys[*keyringID] = mek
+}
+
+// reset forgets every memoized key.
+func (k *keyringKeys) reset() {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.keys = make(map[identity.ID][]byte)
+}
+
+// fetchBundleKeys fetches our keypairs, and the keys that shared each
+// keyring with us, for the graphs in bundle. Each org's keys are fetched
+// concurrently, with one query for all of the org's encrypting keys.
+func (e *Engine) fetchBundleKeys(ctx context.Context, bundle *credentialBundle) error {
+	var orgIDs []identity.ID
+	keyIDs := make(map[identity.ID][]identity.ID)
+	seen := make(map[identity.ID]bool)
+	for _, graph := range bundle.graphs {
+		orgID := *graph.GetKeyring().OrgID()
+		if _, ok := keyIDs[orgID]; !ok {
+			orgIDs = append(orgIDs, orgID)
+			keyIDs[orgID] = nil
+		}
+
+		krm, _, err := graph.FindMember(e.session.AuthID())
+		if err != nil {
+			log.Printf("Error finding keyring membership: %s", err)
+			return err
+		}
+
+		if !seen[*krm.EncryptingKeyID] {
+			seen[*krm.EncryptingKeyID] = true
+			keyIDs[orgID] = append(keyIDs[orgID], *krm.EncryptingKeyID)
+		}
+	}
+
+	keypairs := make([]*crypto.KeyPairs, len(orgIDs))
+	encryptingKeys := make([]map[identity.ID]*primitive.PublicKey, len(orgIDs))
+	err := parallel(len(orgIDs), func(i int) error {
+		_, _, kp, err := fetchKeyPairs(ctx, e.client, &orgIDs[i])
+		if err != nil {
+			log.Printf("Error fetching keypairs: %s", err)
+			return err
+		}
+		keypairs[i] = kp
+
+		encryptingKeys[i], err = findEncryptingKeys(ctx, e.client, &orgIDs[i], keyIDs[orgIDs[i]])
+		if err != nil {
+			log.Printf("Error finding encrypting key for user: %s", err)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, orgID := range orgIDs {
+		bundle.keypairs[orgID] = keypairs[i]
+		for id, key := range encryptingKeys[i] {
+			bundle.encryptingKeys[id] = key
+		}
+	}
+
+	return nil
+}
+
+// decryptBundle decrypts every credential in bundle, decrypting keyrings
+// concurrently, and notifying n as each credential is decrypted. The
+// credentials are returned in the same order as the bundle's graphs.
+func (e *Engine) decryptBundle(ctx context.Context, n *observer.Notifier,
+	bundle *credentialBundle, generation uint64) ([]PlaintextCredentialEnvelope, error) {
+
+	decrypted := make([][]PlaintextCredentialEnvelope, len(bundle.graphs))
+	err := parallel(len(bundle.graphs), func(i int) error {
+		graph := bundle.graphs[i]
+
+		u, err := e.keyringUnboxer(ctx, bundle, graph, generation)
+		if err != nil {
+			return err
+		}
+
+		for _, cred := range graph.GetCredentials() {
+			plainCred, err := decryptCredential(ctx, u, cred)
+			if err != nil {
+				return err
+			}
+			decrypted[i] = append(decrypted[i], *plainCred)
+
+			n.Notify(observer.Progress, "Credential decrypted", true)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	creds := []PlaintextCredentialEnvelope{}
+	for _, graphCreds := range decrypted {
+		creds = append(creds, graphCreds...)
+	}
+
+	return creds, nil
+}
+
+// keyringUnboxer returns an Unboxer for the credentials in graph, decrypting
+// the keyring's key unless it is memoized.
+func (e *Engine) keyringUnboxer(ctx context.Context, bundle *credentialBundle,
+	graph registry.CredentialGraph, generation uint64) (crypto.Unboxer, error) {
+
+	krm, mekshare, err := graph.FindMember(e.session.AuthID())
+	if err != nil {
+		log.Printf("Error finding keyring membership: %s", err)
+		return nil, err
+	}
+
+	// Only use a memoized key while we can still decrypt it ourselves.
+	if mekshare == nil {
+		return nil, registry.ErrMemberNotFound
+	}
+
+	keyringID := krm.KeyringID
+	if mek := e.keys.get(keyringID, generation); mek != nil {
+		return crypto.NewUnboxer(mek), nil
+	}
+
+	kp := bundle.keypairs[*graph.GetKeyring().OrgID()]
+	encryptingKey := bundle.encryptingKeys[*krm.EncryptingKeyID]
+
+	mek, err := e.crypto.Unbox(ctx, *mekshare.Key.Value, *mekshare.Key.Nonce,
+		&kp.Encryption, *encryptingKey.Key.Value)
+	if err != nil {
+		log.Printf("Error decrypting keyring key: %s", err)
+		return nil, err
+	}
+
+	e.keys.store(keyringID, mek, generation)
+	return crypto.NewUnboxer(mek), nil
+}
@@ -0,0 +1,87 @@
+package logic
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestParallel(t *testing.T) {
+	t.Run("bounds workers", func(t *testing.T) {
+		var mutex sync.Mutex
+		running, max := 0, 0
+		seen := make([]bool, 50)
+
+		err := parallel(len(seen), func(i int) error {
+			mutex.Lock()
+			running++
+			if running > max {
+				max = running
+			}
+			seen[i] = true
+			mutex.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if max > resolveWorkers {
+			t.Errorf("%d workers ran at once, want at most %d", max, resolveWorkers)
+		}
+		for i, ok := range seen {
+			if !ok {
+				t.Errorf("fn not called for %d", i)
+			}
+		}
+	})
+
+	t.Run("returns the first error", func(t *testing.T) {
+		err := parallel(20, func(i int) error {
+			if i == 3 || i == 15 {
+				return errors.New("failed " + string('a'+rune(i)))
+			}
+			return nil
+		})
+		if err == nil || err.Error() != "failed d" {
+			t.Errorf("got %v, want the error for 3", err)
+		}
+	})
+}
+
+func TestKeyringKeys(t *testing.T) {
+	k := newKeyringKeys()
+	id, err := identity.NewMutable(&primitive.Org{Name: "knotty-buoy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k.store(&id, []byte("key"), 1)
+	if string(k.get(&id, 1)) != "key" {
+		t.Error("key not memoized")
+	}
+	if k.get(&id, 2) != nil {
+		t.Error("key returned for a newer generation")
+	}
+
+	k.store(&id, []byte("stale"), 0)
+	if string(k.get(&id, 1)) != "key" {
+		t.Error("key from an older generation was stored")
+	}
+
+	other, _ := identity.NewMutable(&primitive.Org{Name: "sandy-wharf"})
+	k.store(&other, []byte("other"), 2)
+	if k.get(&id, 2) != nil {
+		t.Error("keys from older generations were not forgotten")
+	}
+}
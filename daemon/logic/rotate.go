@@ -0,0 +1,166 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/crypto"
+	"github.com/manifoldco/torus-cli/daemon/observer"
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// RotateKeyring creates a new version of the keyring for the given path
+// expression, with a new master encryption key, shared only with those who
+// currently have access to it. Every secret still in use in the keyring is
+// decrypted, and encrypted again under the new key.
+//
+// Members who have been removed since the keyring was last written to can no
+// longer read secrets written after the rotation. Secrets they already read
+// should still be changed.
+func (e *Engine) RotateKeyring(ctx context.Context, notifier *observer.Notifier,
+	pe *pathexp.PathExp) (*apitypes.KeyringRotation, error) {
+
+	n := notifier.Notifier(2)
+
+	gpe, err := pe.WithInstance("*")
+	if err != nil {
+		return nil, err
+	}
+
+	graphs, err := e.client.CredentialGraph.List(ctx, "", gpe, e.session.AuthID())
+	if err != nil {
+		log.Printf("Error retrieving credential graphs: %s", err)
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Credentials retrieved", true)
+
+	cgs := newCredentialGraphSet()
+	for _, graph := range graphs {
+		if graph.GetKeyring().PathExp().String() == gpe.String() {
+			cgs.Add(graph)
+		}
+	}
+
+	head, err := cgs.Head(gpe)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, &apitypes.Error{
+			StatusCode: http.StatusNotFound,
+			Type:       apitypes.NotFoundError,
+			Err:        []string{"No keyring found for " + gpe.String()},
+		}
+	}
+
+	err = checkFrozenWrite(head)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := cgs.Prune()
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := e.decryptGraphs(ctx, active)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Notify(observer.Progress, "Credentials decrypted", true)
+
+	if len(creds) == 0 {
+		return nil, &apitypes.Error{
+			StatusCode: http.StatusBadRequest,
+			Type:       apitypes.BadRequestError,
+			Err:        []string{"No secrets are stored in the keyring for " + gpe.String()},
+		}
+	}
+
+	err = e.conditions.check(ctx, head.GetKeyring().OrgID(), primitive.PolicyActionUpdate,
+		credentialResources(nil, creds))
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := e.appendCredentials(ctx, notifier, creds, true, true)
+	if err != nil {
+		return nil, err
+	}
+
+	rotation := &apitypes.KeyringRotation{
+		KeyringID:      graph.GetKeyring().GetID(),
+		PathExp:        gpe.String(),
+		KeyringVersion: graph.KeyringVersion(),
+		Credentials:    len(creds),
+	}
+	if g, ok := graph.(*registry.CredentialGraphV2); ok {
+		rotation.Members = len(g.Members)
+	}
+
+	return rotation, nil
+}
+
+// decryptGraphs decrypts every credential in graphs, which must all belong to
+// the same org.
+func (e *Engine) decryptGraphs(ctx context.Context,
+	graphs []registry.CredentialGraph) ([]*PlaintextCredentialEnvelope, error) {
+
+	creds := []*PlaintextCredentialEnvelope{}
+	if len(graphs) == 0 {
+		return creds, nil
+	}
+
+	_, _, kp, err := fetchKeyPairs(ctx, e.client, graphs[0].GetKeyring().OrgID())
+	if err != nil {
+		log.Printf("Error fetching keypairs: %s", err)
+		return nil, err
+	}
+
+	for _, graph := range graphs {
+		krm, mekshare, err := graph.FindMember(e.session.AuthID())
+		if err == registry.ErrMemberNotFound {
+			return nil, &apitypes.Error{
+				StatusCode: http.StatusForbidden,
+				Type:       apitypes.UnauthorizedError,
+				Err: []string{"You do not have access to every secret in the keyring for " +
+					graph.GetKeyring().PathExp().String()},
+			}
+		}
+		if err != nil {
+			log.Printf("Error finding keyring membership: %s", err)
+			return nil, err
+		}
+
+		encryptingKey, err := findEncryptingKey(ctx, e.client, krm.OrgID,
+			krm.EncryptingKeyID)
+		if err != nil {
+			log.Printf("Error finding encrypting key: %s", err)
+			return nil, err
+		}
+
+		err = e.crypto.WithUnboxer(ctx, *mekshare.Key.Value, *mekshare.Key.Nonce, &kp.Encryption, *encryptingKey.Key.Value, func(u crypto.Unboxer) error {
+			for _, cred := range graph.GetCredentials() {
+				plainCred, err := decryptCredential(ctx, u, cred)
+				if err != nil {
+					return err
+				}
+				creds = append(creds, plainCred)
+				e.recordRewrites([]PlaintextCredentialEnvelope{*plainCred})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return creds, nil
+}
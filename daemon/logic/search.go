@@ -0,0 +1,217 @@
+package logic
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// searchIndexMaxAge is how old an org's index may be and still be searched.
+// Indexes are also rebuilt whenever the daemon changes credentials or keys,
+// or the session changes.
+const searchIndexMaxAge = 10 * time.Minute
+
+// CredentialQuery matches the names of secrets, and the paths they are set
+// at.
+type CredentialQuery struct {
+	// Pattern is matched against secret names. It is a glob matching the
+	// whole name, or, if Regex is set, a regular expression matching any
+	// part of it.
+	Pattern string
+	Regex   bool
+
+	// PathExp, if set, limits the search to secrets set at paths that
+	// overlap it, or, if Exact is set, at exactly it.
+	PathExp *pathexp.PathExp
+	Exact   bool
+}
+
+// matcher returns a function reporting whether a name matches the query's
+// pattern.
+func (q *CredentialQuery) matcher() (func(string) bool, error) {
+	if q.Regex {
+		re, err := regexp.Compile(q.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+
+	// Names are always lower case.
+	pattern := strings.ToLower(q.Pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	return func(name string) bool {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}, nil
+}
+
+// searchIndex caches the names and paths, but never the values, of the
+// secrets in each org that the session can read.
+type searchIndex struct {
+	mutex sync.Mutex
+	orgs  map[identity.ID]*searchIndexEntry
+}
+
+type searchIndexEntry struct {
+	locations  []apitypes.CredentialLocation
+	built      time.Time
+	generation uint64
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{orgs: make(map[identity.ID]*searchIndexEntry)}
+}
+
+// get returns the org's index, if it was built at the given prefetch
+// generation, and is recent enough to be searched.
+func (s *searchIndex) get(orgID *identity.ID, generation uint64) []apitypes.CredentialLocation {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.orgs[*orgID]
+	if !ok || entry.generation != generation || time.Since(entry.built) > searchIndexMaxAge {
+		return nil
+	}
+
+	return entry.locations
+}
+
+// store caches the org's index, built at the given prefetch generation.
+func (s *searchIndex) store(orgID *identity.ID, locations []apitypes.CredentialLocation,
+	generation uint64) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.orgs[*orgID] = &searchIndexEntry{
+		locations:  locations,
+		built:      time.Now(),
+		generation: generation,
+	}
+}
+
+// forget drops the org's index.
+func (s *searchIndex) forget(orgID *identity.ID) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.orgs, *orgID)
+}
+
+// SearchCredentials returns where the secrets in the org that match query are
+// set, sorted by path and name. Only keyrings the session is a member of are
+// searched, and no secrets are decrypted.
+func (e *Engine) SearchCredentials(ctx context.Context, orgID *identity.ID,
+	query *CredentialQuery) ([]apitypes.CredentialLocation, error) {
+
+	match, err := query.matcher()
+	if err != nil {
+		return nil, &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{"Invalid pattern: " + err.Error()},
+		}
+	}
+
+	// Cache invalidation shares the prefetcher's generation, which is bumped
+	// whenever credentials, keys, or the session change.
+	generation := e.prefetch.current()
+	locations := e.search.get(orgID, generation)
+	if locations == nil {
+		locations, err = e.indexCredentials(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		e.search.store(orgID, locations, generation)
+	}
+
+	return matchLocations(locations, match, query.PathExp, query.Exact), nil
+}
+
+// indexCredentials lists where each current secret in the org is set.
+func (e *Engine) indexCredentials(ctx context.Context,
+	orgID *identity.ID) ([]apitypes.CredentialLocation, error) {
+
+	cgs, err := fetchOrgGraphs(ctx, e.client, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	graphs, err := cgs.Prune()
+	if err != nil {
+		return nil, err
+	}
+
+	locations := []apitypes.CredentialLocation{}
+	for _, graph := range graphs {
+		_, _, err := graph.FindMember(e.session.AuthID())
+		if err == registry.ErrMemberNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cred := range graph.GetCredentials() {
+			if cred.Unset() {
+				continue
+			}
+			locations = append(locations, apitypes.CredentialLocation{
+				OrgID:   orgID,
+				PathExp: cred.PathExp(),
+				Name:    cred.Name(),
+
+				ID:                cred.GetID(),
+				CredentialVersion: cred.CredentialVersion(),
+			})
+		}
+	}
+
+	return locations, nil
+}
+
+// matchLocations returns the locations whose names match, and whose paths
+// overlap pe, or equal it if exact is true, if it is set, sorted by path and
+// name.
+func matchLocations(locations []apitypes.CredentialLocation, match func(string) bool,
+	pe *pathexp.PathExp, exact bool) []apitypes.CredentialLocation {
+
+	matched := []apitypes.CredentialLocation{}
+	for _, l := range locations {
+		if !match(l.Name) {
+			continue
+		}
+		if pe != nil && (exact && !pe.Equal(l.PathExp) || !exact && !pe.Overlaps(l.PathExp)) {
+			continue
+		}
+		matched = append(matched, l)
+	}
+
+	sort.Sort(locationSorter(matched))
+	return matched
+}
+
+type locationSorter []apitypes.CredentialLocation
+
+func (l locationSorter) Len() int      { return len(l) }
+func (l locationSorter) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l locationSorter) Less(i, j int) bool {
+	pi, pj := l[i].PathExp.String(), l[j].PathExp.String()
+	if pi != pj {
+		return pi < pj
+	}
+	return l[i].Name < l[j].Name
+}
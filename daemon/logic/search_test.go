@@ -0,0 +1,85 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/pathexp"
+)
+
+func TestMatchLocations(t *testing.T) {
+	location := func(path, name string) apitypes.CredentialLocation {
+		pe, err := pathexp.Parse(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return apitypes.CredentialLocation{PathExp: pe, Name: name}
+	}
+
+	locations := []apitypes.CredentialLocation{
+		location("/o/p/staging/api/*/*", "stripe_key"),
+		location("/o/p/production/api/*/*", "stripe_key"),
+		location("/o/p/production/api/*/*", "database_url"),
+		location("/o/p/*/worker/*/*", "stripe_webhook_secret"),
+	}
+
+	names := func(locations []apitypes.CredentialLocation) []string {
+		var names []string
+		for _, l := range locations {
+			names = append(names, l.PathExp.String()+"/"+l.Name)
+		}
+		return names
+	}
+
+	tcs := []struct {
+		name  string
+		query CredentialQuery
+		want  []string
+	}{
+		{"glob", CredentialQuery{Pattern: "STRIPE_*"}, []string{
+			"/o/p/*/worker/*/*/stripe_webhook_secret",
+			"/o/p/production/api/*/*/stripe_key",
+			"/o/p/staging/api/*/*/stripe_key",
+		}},
+		{"whole name", CredentialQuery{Pattern: "stripe"}, nil},
+		{"regex", CredentialQuery{Pattern: "_(url|secret)$", Regex: true}, []string{
+			"/o/p/*/worker/*/*/stripe_webhook_secret",
+			"/o/p/production/api/*/*/database_url",
+		}},
+		{"path", CredentialQuery{Pattern: "stripe*", PathExp: location("/o/p/production/*/*/*", "").PathExp}, []string{
+			"/o/p/*/worker/*/*/stripe_webhook_secret",
+			"/o/p/production/api/*/*/stripe_key",
+		}},
+		{"exact path", CredentialQuery{Pattern: "*", PathExp: location("/o/p/production/api/*/*", "").PathExp, Exact: true}, []string{
+			"/o/p/production/api/*/*/database_url",
+			"/o/p/production/api/*/*/stripe_key",
+		}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			match, err := tc.query.matcher()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := names(matchLocations(locations, match, tc.query.PathExp, tc.query.Exact))
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("got %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid patterns", func(t *testing.T) {
+		for _, q := range []CredentialQuery{{Pattern: "[a"}, {Pattern: "(a", Regex: true}} {
+			if _, err := q.matcher(); err == nil {
+				t.Errorf("expected an error for %q", q.Pattern)
+			}
+		}
+	})
+}
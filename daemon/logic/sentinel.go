@@ -0,0 +1,277 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+// Sentinel check names, as reported in a SentinelAnomaly.
+const (
+	ClaimsCheck         = "claims"
+	KeyringMembersCheck = "keyring-members"
+	OfflineCacheCheck   = "offline-cache"
+
+	// IncompleteCheck is reported when a pass of the checks could not be
+	// completed.
+	IncompleteCheck = "incomplete"
+)
+
+// SentinelAnomaly is a problem found while continuously verifying the
+// session's orgs, keyrings, and local cache.
+type SentinelAnomaly struct {
+	Check   string `json:"check"`
+	Subject string `json:"subject"`
+	Problem string `json:"problem"`
+}
+
+// Key identifies the anomaly, so it is only reported once while it persists.
+func (a *SentinelAnomaly) Key() string {
+	return a.Check + " " + a.Subject + " " + a.Problem
+}
+
+func (a *SentinelAnomaly) String() string {
+	return a.Check + ": " + a.Subject + ": " + a.Problem
+}
+
+// SentinelCheck re-verifies the claim chains of every org the session belongs
+// to, looks for members of the keyrings at paths who should not be there, and
+// checks that the offline cache can still be read.
+//
+// Every check is run, even if an earlier one fails. The error returned is
+// that of the first check that could not be completed.
+func (e *Engine) SentinelCheck(ctx context.Context, paths []*pathexp.PathExp) ([]SentinelAnomaly, error) {
+	if e.session.Type() == apitypes.NotLoggedIn {
+		return nil, nil
+	}
+
+	var anomalies []SentinelAnomaly
+	var firstErr error
+	record := func(found []SentinelAnomaly, err error) {
+		anomalies = append(anomalies, found...)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	trees, err := e.client.ClaimTree.List(ctx, nil, e.session.AuthID())
+	if err != nil {
+		return nil, err
+	}
+
+	orgs := make(map[string]*envelope.Org, len(trees))
+	for _, tree := range trees {
+		if tree.Org != nil {
+			orgs[tree.Org.Body.Name] = tree.Org
+		}
+	}
+
+	record(e.checkClaims(ctx, orgs))
+	record(e.checkKeyringMembers(ctx, orgs, paths))
+	record(e.checkOfflineCache(ctx))
+
+	return anomalies, firstErr
+}
+
+// checkClaims verifies the claim tree of each org, reporting every public key
+// that fails verification.
+func (e *Engine) checkClaims(ctx context.Context, orgs map[string]*envelope.Org) ([]SentinelAnomaly, error) {
+	var anomalies []SentinelAnomaly
+	for _, name := range sortedOrgNames(orgs) {
+		org := orgs[name]
+		err := e.trust.verify(ctx, org.ID)
+		if err != nil {
+			return anomalies, fmt.Errorf("verifying claims in org %s: %s", name, err)
+		}
+
+		results, _ := e.trust.anomalies(org.ID)
+		for _, r := range results {
+			anomalies = append(anomalies, SentinelAnomaly{
+				Check:   ClaimsCheck,
+				Subject: "org " + name + " public key " + r.publicKeyID.String(),
+				Problem: r.anomaly,
+			})
+		}
+	}
+
+	return anomalies, nil
+}
+
+// checkKeyringMembers reports the members of the active keyrings at paths who
+// are neither members of the org, nor guests given access to the keyring.
+func (e *Engine) checkKeyringMembers(ctx context.Context, orgs map[string]*envelope.Org,
+	paths []*pathexp.PathExp) ([]SentinelAnomaly, error) {
+
+	var anomalies []SentinelAnomaly
+	members := make(map[string]*keyringMembers)
+	for _, pe := range paths {
+		name := pe.Org.String()
+		org, ok := orgs[name]
+		if !ok {
+			return anomalies, fmt.Errorf("%s: not a member of org %s", pe, name)
+		}
+
+		if _, ok := members[name]; !ok {
+			m, err := getKeyringMembers(ctx, e.client, org.ID)
+			if err != nil {
+				return anomalies, err
+			}
+			members[name] = m
+		}
+
+		graphs, err := e.client.CredentialGraph.List(ctx, "", pe, nil)
+		if err != nil {
+			return anomalies, err
+		}
+		e.forgetFrozen(graphs)
+
+		cgs := newCredentialGraphSet()
+		err = cgs.Add(graphs...)
+		if err != nil {
+			return anomalies, err
+		}
+
+		active, err := cgs.Active()
+		if err != nil {
+			return anomalies, err
+		}
+
+		for _, graph := range active {
+			kpe := graph.GetKeyring().PathExp()
+			expected := make(map[identity.ID]bool)
+			for _, id := range members[name].For(kpe) {
+				expected[id] = true
+			}
+
+			for _, owner := range keyringOwners(graph) {
+				if expected[owner] {
+					continue
+				}
+
+				anomalies = append(anomalies, SentinelAnomaly{
+					Check:   KeyringMembersCheck,
+					Subject: kpe.String(),
+					Problem: "unexpected keyring member " + owner.String(),
+				})
+			}
+		}
+	}
+
+	return anomalies, nil
+}
+
+// keyringOwners returns the owners of every unrevoked membership of the
+// keyring in graph.
+func keyringOwners(graph registry.CredentialGraph) []identity.ID {
+	var owners []identity.ID
+	switch g := graph.(type) {
+	case *registry.CredentialGraphV1:
+		for _, m := range g.Members {
+			owners = append(owners, *m.Body.OwnerID)
+		}
+	case *registry.CredentialGraphV2:
+		revoked := make(map[identity.ID]bool)
+		for _, c := range g.Claims {
+			if c.Body.ClaimType == primitive.RevocationClaimType {
+				revoked[*c.Body.KeyringMemberID] = true
+			}
+		}
+
+		for _, m := range g.Members {
+			if !revoked[*m.Member.ID] {
+				owners = append(owners, *m.Member.Body.OwnerID)
+			}
+		}
+	}
+
+	return owners
+}
+
+// checkOfflineCache reads back every credential the current session has in
+// the offline cache, reporting any entries that can't be decrypted, or that
+// don't hold the versions they were stored with.
+func (e *Engine) checkOfflineCache(ctx context.Context) ([]SentinelAnomaly, error) {
+	prefix := e.session.AuthID().String() + ":"
+
+	var anomalies []SentinelAnomaly
+	entries := make(map[string]*offlineEntry)
+	var cpaths []string
+	err := e.db.ForEach(offlineBucket, func(key string, value []byte) error {
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		cpath := strings.TrimPrefix(key, prefix)
+		entry := &offlineEntry{}
+		if err := json.Unmarshal(value, entry); err != nil {
+			anomalies = append(anomalies, SentinelAnomaly{
+				Check:   OfflineCacheCheck,
+				Subject: cpath,
+				Problem: "cache entry is corrupt",
+			})
+			return nil
+		}
+
+		entries[cpath] = entry
+		cpaths = append(cpaths, cpath)
+		return nil
+	})
+	if err != nil {
+		return anomalies, err
+	}
+
+	for _, cpath := range cpaths {
+		if problem := e.verifyOfflineEntry(ctx, entries[cpath]); problem != "" {
+			anomalies = append(anomalies, SentinelAnomaly{
+				Check:   OfflineCacheCheck,
+				Subject: cpath,
+				Problem: problem,
+			})
+		}
+	}
+
+	return anomalies, nil
+}
+
+// verifyOfflineEntry returns the reason entry can't be trusted, or an empty
+// string if it can.
+func (e *Engine) verifyOfflineEntry(ctx context.Context, entry *offlineEntry) string {
+	pt, err := e.crypto.Unseal(ctx, entry.Sealed, entry.Nonce)
+	if err != nil {
+		return "cached secrets can not be decrypted"
+	}
+
+	contents, err := decodeOfflineContents(pt)
+	if err != nil {
+		return "cached secrets are corrupt"
+	}
+
+	versions := make(map[string]int, len(contents.Credentials))
+	for _, cred := range contents.Credentials {
+		versions[cred.ID.String()] = cred.Body.CredentialVersion
+	}
+	if !sameVersions(entry.Versions, versions) {
+		return "cached secrets do not match their recorded versions"
+	}
+
+	return ""
+}
+
+func sortedOrgNames(orgs map[string]*envelope.Org) []string {
+	names := make([]string, 0, len(orgs))
+	for name := range orgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
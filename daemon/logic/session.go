@@ -2,24 +2,37 @@ package logic
 
 import (
 	"context"
+	"crypto/subtle"
 	"log"
+	"sync/atomic"
+	"time"
 
 	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/base64"
 	"github.com/manifoldco/torus-cli/daemon/crypto"
 	"github.com/manifoldco/torus-cli/daemon/registry"
 	"github.com/manifoldco/torus-cli/daemon/session"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/primitive"
 )
 
 // Session represents the business logic for creating and managing tokens (and
 // their underlying effects on the current session)
 type Session struct {
 	engine *Engine
+
+	// checking is 1 while a rejected token is being checked.
+	checking int32
 }
 
 // Login attempts to create a valid auth token to authorize http requests made
 // against the registry.
 func (s *Session) Login(ctx context.Context, creds apitypes.LoginCredential) error {
+	return s.login(ctx, creds, time.Now())
+}
+
+// login logs in with creds, recording authenticated as when they were given.
+func (s *Session) login(ctx context.Context, creds apitypes.LoginCredential, authenticated time.Time) error {
 	if !creds.Valid() {
 		return &apitypes.Error{
 			Type: apitypes.BadRequestError,
@@ -49,7 +62,11 @@ func (s *Session) Login(ctx context.Context, creds apitypes.LoginCredential) err
 		s.engine.db.Set(self.Auth)
 	}
 
-	return s.engine.session.Set(self.Type, self.Identity, self.Auth, creds.Passphrase(), authToken)
+	s.engine.trust.reset()
+	s.engine.prefetch.reset(true)
+	s.engine.conditions.reset()
+	return s.engine.session.Set(self.Type, self.Identity, self.Auth, creds.Passphrase(), authToken,
+		authenticated)
 }
 
 // Logout destroys the current session if it exists, otherwise, it returns an
@@ -80,20 +97,10 @@ func (s *Session) Logout(ctx context.Context) error {
 			// In any case, the daemon has gotten out of sync with the
 			// server. Remove our local copy of the auth token.
 			log.Printf("Got 4XX removing auth token. Treating as success")
-			logoutErr := s.engine.session.Logout()
-			if logoutErr != nil {
-				return logoutErr
-			}
-
-			return nil
+			return s.end()
 		}
 	case nil:
-		logoutErr := s.engine.session.Logout()
-		if logoutErr != nil {
-			return logoutErr
-		}
-
-		return nil
+		return s.end()
 	default:
 		return err
 	}
@@ -101,6 +108,102 @@ func (s *Session) Logout(ctx context.Context) error {
 	return nil
 }
 
+// TokenRejected is called when the registry rejects a request made with token
+// as unauthorized. The registry also does so when the user lacks access to
+// something, so the token is checked by fetching the current identity with it.
+// If that is rejected too, the session has been revoked remotely, such as with
+// torus sessions revoke on another device, and it is ended here so the user
+// must log in again.
+func (s *Session) TokenRejected(token string) {
+	if token == "" || token != s.engine.session.Token() {
+		return
+	}
+
+	// The check is itself rejected when the session was revoked, so only
+	// one runs at a time.
+	if !atomic.CompareAndSwapInt32(&s.checking, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&s.checking, 0)
+
+		_, err := s.engine.client.Self.Get(context.Background(), token)
+		if !apitypes.IsUnauthorizedError(err) || token != s.engine.session.Token() {
+			return
+		}
+
+		log.Printf("Session was revoked by the registry, logging out")
+		if err := s.end(); err != nil {
+			log.Printf("Error ending revoked session: %s", err)
+		}
+	}()
+}
+
+// end forgets the current session, along with everything read with it, and
+// any login saved to the keychain.
+func (s *Session) end() error {
+	s.forgetKeychain()
+	s.engine.prefetch.reset(true)
+	s.engine.clearOfflineCache()
+	s.engine.Leases.RevokeAll()
+	return s.engine.session.Logout()
+}
+
+type passwordUpdate struct {
+	Password *primitive.UserPassword `json:"password"`
+	Master   *primitive.MasterKey    `json:"master"`
+}
+
+// ChangePassword changes the current user's password, re-encrypting their
+// master key with the new one.
+//
+// The session is updated in place, rather than logging in again, so the auth
+// token is kept. Sessions on other devices keep working too; they hold their
+// own copy of the master key, encrypted with the old password.
+func (s *Session) ChangePassword(ctx context.Context, current, password string) (*envelope.User, error) {
+	sess := s.engine.session
+	if sess.Type() != apitypes.UserSession {
+		return nil, &apitypes.Error{
+			Type: apitypes.UnauthorizedError,
+			Err:  []string{"only users can change their password"},
+		}
+	}
+
+	if subtle.ConstantTimeCompare([]byte(current), sess.Passphrase()) != 1 {
+		return nil, &apitypes.Error{
+			Type: apitypes.UnauthorizedError,
+			Err:  []string{"current password is incorrect"},
+		}
+	}
+
+	if len(password) < 8 {
+		return nil, &apitypes.Error{
+			Type: apitypes.BadRequestError,
+			Err:  []string{"passwords must be at least 8 characters"},
+		}
+	}
+
+	pw, master, err := s.engine.ChangePassword(ctx, password)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.engine.client.Users.Update(ctx, passwordUpdate{Password: pw, Master: master})
+	if err != nil {
+		return nil, err
+	}
+
+	s.engine.db.Set(user)
+	err = sess.Set(apitypes.UserSession, user, user, []byte(password), sess.Token(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.updateKeychain(user)
+	return user, nil
+}
+
 func attemptPDPKALogin(ctx context.Context, client *registry.Client, s session.Session, creds apitypes.LoginCredential) (string, error) {
 	salt, loginToken, err := client.Tokens.PostLogin(ctx, creds)
 	if err != nil {
@@ -128,5 +231,10 @@ func attemptHMACLogin(ctx context.Context, client *registry.Client, s session.Se
 		return "", err
 	}
 
-	return client.Tokens.PostAuth(ctx, loginToken, hmac)
+	var mfaCode string
+	if login, ok := creds.(*apitypes.UserLogin); ok {
+		mfaCode = login.MFACode
+	}
+
+	return client.Tokens.PostAuth(ctx, loginToken, hmac, mfaCode)
 }
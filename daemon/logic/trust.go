@@ -0,0 +1,387 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+// trustTimeout bounds a single background verification pass of an org's
+// claim tree.
+const trustTimeout = 2 * time.Minute
+
+// keyTrust verifies the claim chains of every public key in an org, caching
+// the results.
+//
+// Public keys and claims are immutable and only ever appended to, so a key
+// that has been verified only needs to be checked again when new claims are
+// made against it. This lets verification run incrementally, in the
+// background, rather than blocking the first credential share in a new org on
+// a full synchronous pass.
+type keyTrust struct {
+	engine *Engine
+
+	mutex sync.Mutex
+	orgs  map[identity.ID]*orgTrust
+}
+
+// orgTrust holds the cached verification results for a single org.
+type orgTrust struct {
+	running bool
+
+	// verified is set once a pass has completed, even if the org has no
+	// public keys to verify.
+	verified bool
+	keys     map[identity.ID]keyTrustResult
+}
+
+// keyTrustResult is the outcome of verifying a single public key and the
+// claims made against it. An empty anomaly means the key is trusted.
+type keyTrustResult struct {
+	publicKeyID *identity.ID
+	ownerID     *identity.ID
+	claims      int
+	anomaly     string
+
+	// Expiry depends on when the result is read, rather than when the key
+	// was verified, so it is checked separately from the anomaly.
+	expires time.Time
+	revoked bool
+}
+
+// problem returns the reason the key is not trusted as of now, or an empty
+// string if it is. Revoked keys are no longer used, so are not reported as
+// expired.
+func (r *keyTrustResult) problem(now time.Time) string {
+	if r.anomaly != "" {
+		return r.anomaly
+	}
+
+	if !r.revoked && !r.expires.IsZero() && r.expires.Before(now) {
+		return "public key expired on " + r.expires.Format("2006-01-02")
+	}
+
+	return ""
+}
+
+func newKeyTrust(e *Engine) *keyTrust {
+	return &keyTrust{
+		engine: e,
+		orgs:   make(map[identity.ID]*orgTrust),
+	}
+}
+
+// bootstrap starts verifying the claim tree for the given org in the
+// background. It returns immediately; if a pass is already running for the
+// org, no new pass is started.
+func (t *keyTrust) bootstrap(orgID *identity.ID) {
+	t.mutex.Lock()
+	ot := t.org(orgID)
+	if ot.running {
+		t.mutex.Unlock()
+		return
+	}
+	ot.running = true
+	t.mutex.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), trustTimeout)
+		defer cancel()
+
+		err := t.verify(ctx, orgID)
+		if err != nil {
+			log.Printf("Error verifying claim tree for org %s: %s", orgID, err)
+		}
+
+		t.mutex.Lock()
+		t.org(orgID).running = false
+		t.mutex.Unlock()
+	}()
+}
+
+// reset drops all cached verification results. It is called when the session
+// changes, as results are only meaningful for the user who computed them.
+func (t *keyTrust) reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.orgs = make(map[identity.ID]*orgTrust)
+}
+
+// forget drops the cached verification results for the given org.
+func (t *keyTrust) forget(orgID *identity.ID) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.orgs, *orgID)
+}
+
+// org returns the orgTrust for the given org, creating it if needed. The
+// caller must hold the mutex.
+func (t *keyTrust) org(orgID *identity.ID) *orgTrust {
+	ot, ok := t.orgs[*orgID]
+	if !ok {
+		ot = &orgTrust{keys: make(map[identity.ID]keyTrustResult)}
+		t.orgs[*orgID] = ot
+	}
+
+	return ot
+}
+
+// anomalies returns the cached verification failures for the given org, in a
+// stable order. The bool return is false if the org has never been verified.
+func (t *keyTrust) anomalies(orgID *identity.ID) ([]keyTrustResult, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	ot, ok := t.orgs[*orgID]
+	if !ok || !ot.verified {
+		return nil, false
+	}
+
+	now := time.Now()
+	byID := make(map[string]keyTrustResult)
+	for _, r := range ot.keys {
+		if problem := r.problem(now); problem != "" {
+			r.anomaly = problem
+			byID[r.publicKeyID.String()] = r
+		}
+	}
+
+	keys := make([]string, 0, len(byID))
+	for k := range byID {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	results := make([]keyTrustResult, 0, len(keys))
+	for _, k := range keys {
+		results = append(results, byID[k])
+	}
+
+	return results, true
+}
+
+// verify fetches the claim tree for the given org and verifies every public
+// key that has changed since it was last verified.
+func (t *keyTrust) verify(ctx context.Context, orgID *identity.ID) error {
+	trees, err := t.engine.client.ClaimTree.List(ctx, orgID, nil)
+	if err != nil {
+		return err
+	}
+
+	segments := make(map[identity.ID]*apitypes.PublicKeySegment)
+	for _, tree := range trees {
+		if tree.Org == nil || *tree.Org.ID != *orgID {
+			continue
+		}
+
+		for i := range tree.PublicKeys {
+			segment := &tree.PublicKeys[i]
+			segments[*segment.PublicKey.ID] = segment
+		}
+	}
+
+	t.mutex.Lock()
+	cached := make(map[identity.ID]keyTrustResult, len(t.org(orgID).keys))
+	for id, r := range t.org(orgID).keys {
+		cached[id] = r
+	}
+	t.mutex.Unlock()
+
+	results := make(map[identity.ID]keyTrustResult, len(segments))
+	for id, segment := range segments {
+		if r, ok := cached[id]; ok && r.claims == len(segment.Claims) {
+			results[id] = r
+			continue
+		}
+
+		r, err := t.verifySegment(ctx, segment, segments)
+		if err != nil {
+			return err
+		}
+		results[id] = r
+	}
+
+	t.mutex.Lock()
+	ot := t.org(orgID)
+	ot.keys = results
+	ot.verified = true
+	t.mutex.Unlock()
+
+	return nil
+}
+
+// verifySegment verifies a public key's signature, and the signatures and
+// ordering of all claims made against it.
+func (t *keyTrust) verifySegment(ctx context.Context,
+	segment *apitypes.PublicKeySegment,
+	segments map[identity.ID]*apitypes.PublicKeySegment) (keyTrustResult, error) {
+
+	pk := segment.PublicKey
+	result := keyTrustResult{
+		publicKeyID: pk.ID,
+		ownerID:     pk.Body.OwnerID,
+		claims:      len(segment.Claims),
+		expires:     pk.Body.Expires,
+		revoked:     segment.Revoked(),
+	}
+
+	// Signing keys are self-signed; all other keys are signed by a signing
+	// key belonging to the same owner.
+	signer := pk
+	if pk.Signature.PublicKeyID != nil {
+		s, ok := segments[*pk.Signature.PublicKeyID]
+		if !ok {
+			result.anomaly = "public key is signed by an unknown key"
+			return result, nil
+		}
+		signer = s.PublicKey
+	} else if pk.Body.KeyType != primitive.SigningKeyType {
+		result.anomaly = "encryption key is not signed by a signing key"
+		return result, nil
+	}
+
+	if signer.Body.KeyType != primitive.SigningKeyType ||
+		*signer.Body.OwnerID != *pk.Body.OwnerID {
+		result.anomaly = "public key is signed by a key belonging to another owner"
+		return result, nil
+	}
+
+	ok, err := t.engine.crypto.VerifySigned(ctx, pk.Body, &pk.Signature,
+		*signer.Body.Key.Value)
+	if err != nil {
+		return result, err
+	}
+	if !ok {
+		result.anomaly = "public key signature is invalid"
+		return result, nil
+	}
+
+	claims := make(map[identity.ID]*envelope.Claim, len(segment.Claims))
+	for i := range segment.Claims {
+		claims[*segment.Claims[i].ID] = &segment.Claims[i]
+	}
+
+	roots := 0
+	for _, claim := range claims {
+		if claim.Body.PublicKeyID == nil || *claim.Body.PublicKeyID != *pk.ID {
+			result.anomaly = "claim " + claim.ID.String() + " targets another key"
+			return result, nil
+		}
+
+		if claim.Body.Previous == nil {
+			result.anomaly = "claim " + claim.ID.String() + " has no previous"
+			return result, nil
+		}
+
+		if *claim.Body.Previous == *pk.ID {
+			roots++
+		} else if _, ok := claims[*claim.Body.Previous]; !ok {
+			result.anomaly = "claim " + claim.ID.String() + " breaks the claim chain"
+			return result, nil
+		}
+
+		var claimSigner *apitypes.PublicKeySegment
+		if claim.Signature.PublicKeyID != nil {
+			claimSigner = segments[*claim.Signature.PublicKeyID]
+		}
+		if claimSigner == nil ||
+			*claimSigner.PublicKey.Body.OwnerID != *claim.Body.OwnerID {
+			result.anomaly = "claim " + claim.ID.String() + " is signed by an unknown key"
+			return result, nil
+		}
+
+		ok, err := t.engine.crypto.VerifySigned(ctx, claim.Body, &claim.Signature,
+			*claimSigner.PublicKey.Body.Key.Value)
+		if err != nil {
+			return result, err
+		}
+		if !ok {
+			result.anomaly = "claim " + claim.ID.String() + " signature is invalid"
+			return result, nil
+		}
+	}
+
+	if len(claims) > 0 && roots != 1 {
+		result.anomaly = fmt.Sprintf("claim chain has %d roots", roots)
+		return result, nil
+	}
+
+	if len(claims) > 0 {
+		if _, err := segment.HeadClaim(); err != nil {
+			result.anomaly = "claim chain contains a cycle"
+		}
+	}
+
+	return result, nil
+}
+
+type keyTrustHandler struct {
+	engine *Engine
+}
+
+func (keyTrustHandler) resolveErr() string {
+	return "Error verifying public keys"
+}
+
+func (h *keyTrustHandler) list(ctx context.Context, org *envelope.Org) ([]apitypes.WorklogItem, error) {
+	results, ok := h.engine.trust.anomalies(org.ID)
+	if !ok {
+		// Never block listing on a full verification pass. Start one, and
+		// report any anomalies the next time the worklog is listed.
+		h.engine.trust.bootstrap(org.ID)
+		return nil, nil
+	}
+
+	var items []apitypes.WorklogItem
+	for _, r := range results {
+		item := apitypes.WorklogItem{
+			Subject:   r.publicKeyID.String(),
+			Summary:   fmt.Sprintf("Could not verify a public key in org %s: %s.", org.Body.Name, r.anomaly),
+			SubjectID: r.ownerID,
+		}
+		item.CreateID(apitypes.KeyTrustWorklogType)
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (h *keyTrustHandler) resolve(ctx context.Context, n *observer.Notifier,
+	orgID *identity.ID, item *apitypes.WorklogItem) (*apitypes.WorklogResult, error) {
+
+	err := h.engine.trust.verify(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	results, _ := h.engine.trust.anomalies(orgID)
+	for _, r := range results {
+		if r.publicKeyID.String() == item.Subject {
+			return &apitypes.WorklogResult{
+				ID:    item.ID,
+				State: apitypes.ManualWorklogResult,
+				Message: "The public key " + item.Subject + " failed verification: " +
+					r.anomaly + ". Contact an org administrator before sharing secrets.",
+			}, nil
+		}
+	}
+
+	return &apitypes.WorklogResult{
+		ID:      item.ID,
+		State:   apitypes.SuccessWorklogResult,
+		Message: "Public key verified.",
+	}, nil
+}
@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+// signedClaim returns a claim of claimType against the key of segment,
+// following previous, and signed by that key.
+func signedClaim(t *testing.T, id byte, segment apitypes.PublicKeySegment, previous *identity.ID,
+	claimType primitive.ClaimType, private ed25519.PrivateKey) envelope.Claim {
+
+	pk := segment.PublicKey
+	body := &primitive.Claim{
+		OrgID:       pk.Body.OrgID,
+		OwnerID:     pk.Body.OwnerID,
+		Previous:    previous,
+		PublicKeyID: pk.ID,
+		ClaimType:   claimType,
+	}
+
+	return envelope.Claim{
+		ID:        &identity.ID{0x01, 0x08, id},
+		Version:   1,
+		Body:      body,
+		Signature: signBody(t, body, pk.ID, private),
+	}
+}
+
+func TestKeyTrustAnomalies(t *testing.T) {
+	orgID := identity.ID{0x01, 0x04, 0x01}
+	owner := identity.ID{0x01, 0x01, 0x01}
+
+	valid := func() apitypes.PublicKeySegment {
+		segment, private := signingSegment(t, 1, orgID, owner)
+		first := signedClaim(t, 1, segment, segment.PublicKey.ID, primitive.SignatureClaimType, private)
+		second := signedClaim(t, 2, segment, first.ID, primitive.SignatureClaimType, private)
+		segment.Claims = []envelope.Claim{first, second}
+		return segment
+	}
+
+	broken := func() apitypes.PublicKeySegment {
+		segment, private := signingSegment(t, 1, orgID, owner)
+		first := signedClaim(t, 1, segment, segment.PublicKey.ID, primitive.SignatureClaimType, private)
+		second := signedClaim(t, 2, segment, &identity.ID{0x01, 0x08, 0x09},
+			primitive.SignatureClaimType, private)
+		segment.Claims = []envelope.Claim{first, second}
+		return segment
+	}
+
+	// An expired key that was revoked is no longer used, so it isn't
+	// reported.
+	revoked := func() apitypes.PublicKeySegment {
+		segment, private := signingSegment(t, 1, orgID, owner)
+		pk := segment.PublicKey
+		pk.Body.Expires = time.Now().Add(-24 * time.Hour)
+		pk.Signature = signBody(t, pk.Body, nil, private)
+
+		first := signedClaim(t, 1, segment, pk.ID, primitive.SignatureClaimType, private)
+		second := signedClaim(t, 2, segment, first.ID, primitive.RevocationClaimType, private)
+		segment.Claims = []envelope.Claim{first, second}
+		return segment
+	}
+
+	tcs := []struct {
+		name     string
+		segments []apitypes.PublicKeySegment
+		anomaly  string
+	}{
+		{name: "no keys"},
+		{name: "valid chain", segments: []apitypes.PublicKeySegment{valid()}},
+		{
+			name:     "broken chain",
+			segments: []apitypes.PublicKeySegment{broken()},
+			anomaly:  "claim " + (&identity.ID{0x01, 0x08, 0x02}).String() + " breaks the claim chain",
+		},
+		{name: "revoked signer", segments: []apitypes.PublicKeySegment{revoked()}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			e, srv := claimTreeEngine(nil, orgID, tc.segments...)
+			defer srv.Close()
+
+			if _, ok := e.trust.anomalies(&orgID); ok {
+				t.Fatal("org reported as verified before it was verified")
+			}
+
+			err := e.trust.verify(context.Background(), &orgID)
+			if err != nil {
+				t.Fatal("unexpected error verifying claim tree:", err)
+			}
+
+			results, ok := e.trust.anomalies(&orgID)
+			if !ok {
+				t.Fatal("org not reported as verified")
+			}
+
+			if tc.anomaly == "" {
+				if len(results) != 0 {
+					t.Errorf("unexpected anomalies: %+v", results)
+				}
+				return
+			}
+
+			if len(results) != 1 {
+				t.Fatalf("expected one anomaly, got %+v", results)
+			}
+			if results[0].anomaly != tc.anomaly {
+				t.Errorf("wrong anomaly: %q != %q", results[0].anomaly, tc.anomaly)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package logic
 import (
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
 )
 
 // PlaintextCredentialEnvelope is an unencrypted credential object
@@ -20,4 +21,12 @@ type PlaintextCredential struct {
 	ProjectID *identity.ID     `json:"project_id"`
 	Value     string           `json:"value"`
 	State     *string          `json:"state"`
+
+	primitive.CredentialMeta
+
+	// Previous and CredentialVersion are set when reading credentials. When
+	// setting them, they may give the version the caller last saw, with a
+	// nil Previous and a CredentialVersion of 1 meaning it saw none.
+	Previous          *identity.ID `json:"previous,omitempty"`
+	CredentialVersion int          `json:"credential_version,omitempty"`
 }
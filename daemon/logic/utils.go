@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"log"
+	"net/http"
 	"time"
 
 	"golang.org/x/crypto/ed25519"
@@ -13,6 +14,7 @@ import (
 	"github.com/manifoldco/torus-cli/base64"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
 	"github.com/manifoldco/torus-cli/primitive"
 
 	"github.com/manifoldco/torus-cli/daemon/crypto"
@@ -85,10 +87,11 @@ func createCredentialGraph(ctx context.Context, credBody *PlaintextCredential,
 		return nil, err
 	}
 
-	subjects, err := getKeyringMembers(ctx, client, credBody.OrgID)
+	members, err := getKeyringMembers(ctx, client, credBody.OrgID)
 	if err != nil {
 		return nil, err
 	}
+	subjects := members.For(pathExp)
 
 	claimTrees, err := client.ClaimTree.List(ctx, credBody.OrgID, nil)
 	if err != nil {
@@ -105,7 +108,7 @@ func createCredentialGraph(ctx context.Context, credBody *PlaintextCredential,
 	}
 
 	// use their public key to encrypt the mek with a random nonce.
-	members := []registry.KeyringMember{}
+	keyringMembers := []registry.KeyringMember{}
 	for _, subject := range subjects {
 		// For this user, find their public encryption key
 		encPubKey, err := findEncryptionPublicKey(claimTrees, credBody.OrgID, &subject)
@@ -132,14 +135,14 @@ func createCredentialGraph(ctx context.Context, credBody *PlaintextCredential,
 			return nil, err
 		}
 
-		members = append(members, *member)
+		keyringMembers = append(keyringMembers, *member)
 	}
 
 	graph := registry.CredentialGraphV2{
 		KeyringSectionV2: registry.KeyringSectionV2{
 			Keyring: keyring,
 			Claims:  []envelope.KeyringMemberClaim{},
-			Members: members,
+			Members: keyringMembers,
 		},
 	}
 
@@ -199,11 +202,14 @@ func newV2KeyringMember(ctx context.Context, engine *crypto.Engine,
 	}, nil
 }
 
+// createKeyringMemberships encodes the owner into every active keyring the
+// current user is a member of. If scopes is not nil, the owner is a guest, and
+// is only encoded into the keyrings covered by their scopes.
 func createKeyringMemberships(ctx context.Context, c *crypto.Engine, client *registry.Client,
-	s session.Session, orgID, ownerID *identity.ID) ([]envelope.KeyringMemberV1, []registry.KeyringMember, error) {
+	s session.Session, orgID, ownerID *identity.ID, scopes []*pathexp.PathExp) ([]envelope.KeyringMemberV1, []registry.KeyringMember, error) {
 
 	// Get this user's keypairs
-	sigID, encID, kp, err := fetchKeyPairs(ctx, client, orgID)
+	sigID, encID, kp, err := fetchWritableKeyPairs(ctx, client, orgID)
 	if err != nil {
 		log.Printf("could not fetch keypairs for org: %s", err)
 		return nil, nil, err
@@ -228,28 +234,11 @@ func createKeyringMemberships(ctx context.Context, c *crypto.Engine, client *reg
 	// Get all the keyrings and memberships for the current user. This way we
 	// can decrypt the MEK for each and then create a new KeyringMember for
 	// our wonderful new org member!
-	org, err := client.Orgs.Get(ctx, orgID)
+	activeGraphs, err := findActiveGraphs(ctx, client, s, orgID)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	projects, err := client.Projects.List(ctx, org.ID)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	var graphs []registry.CredentialGraph
-	for _, project := range projects {
-		projGraphs, err := client.CredentialGraph.Search(ctx,
-			"/"+org.Body.Name+"/"+project.Body.Name+"/*/*/*/*", s.AuthID())
-		if err != nil {
-			log.Printf("Error retrieving credential graphs: %s", err)
-			return nil, nil, err
-		}
-
-		graphs = append(graphs, projGraphs...)
-	}
-
 	// Find encryption keys for user
 	targetPubKey, err := findEncryptionPublicKey(claimTrees, orgID, ownerID)
 	if err != nil {
@@ -257,20 +246,13 @@ func createKeyringMemberships(ctx context.Context, c *crypto.Engine, client *reg
 		return nil, nil, err
 	}
 
-	cgs := newCredentialGraphSet()
-	err = cgs.Add(graphs...)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	activeGraphs, err := cgs.Active()
-	if err != nil {
-		return nil, nil, err
-	}
-
 	v1members := []envelope.KeyringMemberV1{}
 	v2members := []registry.KeyringMember{}
 	for _, graph := range activeGraphs {
+		if scopes != nil && !guestScopeCovers(scopes, graph.GetKeyring().PathExp()) {
+			continue
+		}
+
 		krm, mekshare, err := graph.FindMember(s.AuthID())
 		if err != nil {
 			log.Printf("could not find keyring membership: %s", err)
@@ -326,6 +308,42 @@ func createKeyringMemberships(ctx context.Context, c *crypto.Engine, client *reg
 	return v1members, v2members, nil
 }
 
+// findActiveGraphs returns the latest version of every credential graph in
+// the org that the current user is a member of.
+func findActiveGraphs(ctx context.Context, client *registry.Client,
+	s session.Session, orgID *identity.ID) ([]registry.CredentialGraph, error) {
+
+	org, err := client.Orgs.Get(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := client.Projects.List(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var graphs []registry.CredentialGraph
+	for _, project := range projects {
+		projGraphs, err := client.CredentialGraph.Search(ctx,
+			"/"+org.Body.Name+"/"+project.Body.Name+"/*/*/*/*", s.AuthID())
+		if err != nil {
+			log.Printf("Error retrieving credential graphs: %s", err)
+			return nil, err
+		}
+
+		graphs = append(graphs, projGraphs...)
+	}
+
+	cgs := newCredentialGraphSet()
+	err = cgs.Add(graphs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return cgs.Active()
+}
+
 // fetchRegistryKeyPairs fetches the user's signing and encryption keypairs
 // from the registry for the given org id.
 // It returns an error if the keypairs cannot be fetched from the registry,
@@ -384,6 +402,43 @@ func fetchKeyPairs(ctx context.Context, client *registry.Client,
 	return sigClaimed.PublicKey.ID, encClaimed.PublicKey.ID, kp, nil
 }
 
+// fetchWritableKeyPairs fetches the user's keypairs like fetchKeyPairs, for
+// signing new objects and sharing secrets. It returns an error if either
+// keypair has expired, as other members would no longer trust what they sign
+// or encrypt.
+func fetchWritableKeyPairs(ctx context.Context, client *registry.Client,
+	orgID *identity.ID) (*identity.ID, *identity.ID, *crypto.KeyPairs, error) {
+
+	encClaimed, sigClaimed, err := fetchRegistryKeyPairs(ctx, client, orgID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if sigClaimed == nil || encClaimed == nil {
+		return nil, nil, nil, &apitypes.Error{
+			Type: apitypes.NotFoundError,
+			Err:  []string{"Missing encryption or signing keypairs"},
+		}
+	}
+
+	now := time.Now()
+	for _, claimed := range []*registry.ClaimedKeyPair{sigClaimed, encClaimed} {
+		pk := claimed.PublicKey.Body
+		if pk.Expired(now) {
+			return nil, nil, nil, &apitypes.Error{
+				StatusCode: http.StatusForbidden,
+				Type:       apitypes.UnauthorizedError,
+				Err: []string{fmt.Sprintf("Your %s key for this org expired on %s. "+
+					"Run 'torus keypairs renew' to replace it.", pk.KeyType,
+					pk.Expires.Format("2006-01-02"))},
+			}
+		}
+	}
+
+	kp := bundleKeypairs(sigClaimed, encClaimed)
+	return sigClaimed.PublicKey.ID, encClaimed.PublicKey.ID, kp, nil
+}
+
 func bundleKeypairs(sigClaimed, encClaimed *registry.ClaimedKeyPair) *crypto.KeyPairs {
 
 	sigPub := sigClaimed.PublicKey.Body.Key.Value
@@ -416,6 +471,19 @@ func bundleKeypairs(sigClaimed, encClaimed *registry.ClaimedKeyPair) *crypto.Key
 func findEncryptingKey(ctx context.Context, client *registry.Client, orgID *identity.ID,
 	encryptingKeyID *identity.ID) (*primitive.PublicKey, error) {
 
+	keys, err := findEncryptingKeys(ctx, client, orgID, []identity.ID{*encryptingKeyID})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys[*encryptingKeyID], nil
+}
+
+// findEncryptingKeys finds each of the given public keys in the org, with a
+// single query to the registry.
+func findEncryptingKeys(ctx context.Context, client *registry.Client, orgID *identity.ID,
+	encryptingKeyIDs []identity.ID) (map[identity.ID]*primitive.PublicKey, error) {
+
 	claimTrees, err := client.ClaimTree.List(ctx, orgID, nil)
 	if err != nil {
 		return nil, err
@@ -430,23 +498,26 @@ func findEncryptingKey(ctx context.Context, client *registry.Client, orgID *iden
 		}
 	}
 
-	var encryptingKey *primitive.PublicKey
+	all := make(map[identity.ID]*primitive.PublicKey, len(claimTrees[0].PublicKeys))
 	for _, segment := range claimTrees[0].PublicKeys {
-		if *segment.PublicKey.ID == *encryptingKeyID {
-			encryptingKey = segment.PublicKey.Body
-			break
-		}
+		all[*segment.PublicKey.ID] = segment.PublicKey.Body
 	}
-	if encryptingKey == nil {
-		return nil, &apitypes.Error{
-			Type: apitypes.NotFoundError,
-			Err: []string{
-				fmt.Sprintf("Encrypting key not found: %s", encryptingKeyID),
-			},
+
+	keys := make(map[identity.ID]*primitive.PublicKey, len(encryptingKeyIDs))
+	for _, id := range encryptingKeyIDs {
+		key, ok := all[id]
+		if !ok {
+			return nil, &apitypes.Error{
+				Type: apitypes.NotFoundError,
+				Err: []string{
+					fmt.Sprintf("Encrypting key not found: %s", &id),
+				},
+			}
 		}
+		keys[id] = key
 	}
 
-	return encryptingKey, nil
+	return keys, nil
 }
 
 // findSystemTeams takes in a list of team objects and returns the members and machines
@@ -490,7 +561,8 @@ func findSystemTeams(teams []envelope.Team) (*envelope.Team, *envelope.Team, err
 func findEncryptionPublicKey(trees []registry.ClaimTree, orgID *identity.ID,
 	userID *identity.ID) (*envelope.PublicKey, error) {
 
-	// Loop over claimtree looking for the users encryption key
+	// Loop over claimtree looking for the users unexpired encryption key
+	now := time.Now()
 	var encKey *envelope.PublicKey
 	for _, tree := range trees {
 		if *tree.Org.ID != *orgID {
@@ -498,7 +570,7 @@ func findEncryptionPublicKey(trees []registry.ClaimTree, orgID *identity.ID,
 		}
 
 		for _, segment := range tree.PublicKeys {
-			if segment.Revoked() {
+			if segment.Revoked() || segment.PublicKey.Body.Expired(now) {
 				continue
 			}
 
@@ -523,6 +595,44 @@ func findEncryptionPublicKey(trees []registry.ClaimTree, orgID *identity.ID,
 	return encKey, nil
 }
 
+// hasExpiredMembers returns whether graph shares its master key with any
+// encryption key in trees that has expired as of now. New secrets are never
+// encrypted for expired keys, so such a keyring is replaced by one shared
+// only with unexpired keys before secrets are added to it.
+func hasExpiredMembers(graph registry.CredentialGraph, trees []registry.ClaimTree, now time.Time) bool {
+	var keyIDs []*identity.ID
+	switch g := graph.(type) {
+	case *registry.CredentialGraphV1:
+		for _, m := range g.Members {
+			keyIDs = append(keyIDs, m.Body.PublicKeyID)
+		}
+	case *registry.CredentialGraphV2:
+		for _, m := range g.Members {
+			keyIDs = append(keyIDs, m.Member.Body.PublicKeyID)
+		}
+	}
+
+	expired := make(map[identity.ID]bool)
+	for _, tree := range trees {
+		for _, segment := range tree.PublicKeys {
+			if segment.PublicKey.Body.Expired(now) {
+				expired[*segment.PublicKey.ID] = true
+			}
+		}
+	}
+
+	for _, id := range keyIDs {
+		if expired[*id] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findEncryptionPublicKeyByID returns the encryption key with the given ID.
+// Revoked keys are included, as they still encrypt memberships shared before
+// their owner renewed or revoked them.
 func findEncryptionPublicKeyByID(trees []registry.ClaimTree, orgID *identity.ID,
 	ID *identity.ID) (*envelope.PublicKey, error) {
 
@@ -534,10 +644,6 @@ func findEncryptionPublicKeyByID(trees []registry.ClaimTree, orgID *identity.ID,
 		}
 
 		for _, segment := range tree.PublicKeys {
-			if segment.Revoked() {
-				continue
-			}
-
 			key := segment.PublicKey
 			if *key.ID != *ID {
 				continue
@@ -610,12 +716,13 @@ func packagePrivateKey(ctx context.Context, engine *crypto.Engine, ownerID,
 	return engine.SignedPrivateKey(ctx, &body, sigID, sigKP)
 }
 
-// getKeyringMembers returns a slice of IDs of all subjects that should be
-// members of a keyring.
-// This includes both users, and the active tokens of machines.
+// getKeyringMembers returns all subjects that should be members of the org's
+// keyrings.
+// This includes users, the active tokens of machines, and guests, who are only
+// members of the keyrings their guest team's policies allow them to read.
 // XXX: we need to filter the members down based on ACL
 func getKeyringMembers(ctx context.Context, client *registry.Client,
-	orgID *identity.ID) ([]identity.ID, error) {
+	orgID *identity.ID) (*keyringMembers, error) {
 
 	teams, err := client.Teams.List(ctx, orgID)
 	if err != nil {
@@ -637,9 +744,11 @@ func getKeyringMembers(ctx context.Context, client *registry.Client,
 		return nil, err
 	}
 
-	var members []identity.ID
+	members := &keyringMembers{guests: make(map[identity.ID][]*pathexp.PathExp)}
+	regular := make(map[identity.ID]bool)
 	for _, membership := range userMembers {
-		members = append(members, *membership.Body.OwnerID)
+		members.all = append(members.all, *membership.Body.OwnerID)
+		regular[*membership.Body.OwnerID] = true
 	}
 
 	for _, membership := range machineMembers {
@@ -651,11 +760,32 @@ func getKeyringMembers(ctx context.Context, client *registry.Client,
 
 		for _, token := range segment.Tokens {
 			if token.Token.Body.State == primitive.MachineTokenActiveState {
-				members = append(members, *token.Token.ID)
+				members.all = append(members.all, *token.Token.ID)
 				break
 			}
 		}
 	}
 
+	guestScopes, err := getGuestScopes(ctx, client, orgID, teams)
+	if err != nil {
+		return nil, err
+	}
+
+	for teamID, scopes := range guestScopes {
+		id := teamID
+		guestMembers, err := client.Memberships.List(ctx, orgID, &id, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, membership := range guestMembers {
+			owner := *membership.Body.OwnerID
+			if regular[owner] { // already a member of every keyring
+				continue
+			}
+			members.guests[owner] = append(members.guests[owner], scopes...)
+		}
+	}
+
 	return members, nil
 }
@@ -0,0 +1,63 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/registry"
+)
+
+func TestHasExpiredMembers(t *testing.T) {
+	now := time.Now()
+	current := &identity.ID{0x01, 0x06, 0x01}
+	expired := &identity.ID{0x01, 0x06, 0x02}
+
+	trees := []registry.ClaimTree{{
+		PublicKeys: []apitypes.PublicKeySegment{
+			{PublicKey: &envelope.PublicKey{
+				ID:   current,
+				Body: &primitive.PublicKey{Expires: now.Add(24 * time.Hour)},
+			}},
+			{PublicKey: &envelope.PublicKey{
+				ID:   expired,
+				Body: &primitive.PublicKey{Expires: now.Add(-24 * time.Hour)},
+			}},
+		},
+	}}
+
+	graph := func(keyIDs ...*identity.ID) registry.CredentialGraph {
+		cg := buildGraph("/o/p/e/s/u/i", 1).(*registry.CredentialGraphV2)
+		for _, id := range keyIDs {
+			cg.Members = append(cg.Members, registry.KeyringMember{
+				Member: &envelope.KeyringMember{
+					Body: &primitive.KeyringMember{PublicKeyID: id},
+				},
+			})
+		}
+		return cg
+	}
+
+	tcs := []struct {
+		name    string
+		graph   registry.CredentialGraph
+		expired bool
+	}{
+		{name: "no members", graph: graph()},
+		{name: "current keys", graph: graph(current)},
+		{name: "expired key", graph: graph(current, expired), expired: true},
+		{name: "unknown key", graph: graph(&identity.ID{0x01, 0x06, 0x03})},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := hasExpiredMembers(tc.graph, trees, now); got != tc.expired {
+				t.Errorf("expected %t, got %t", tc.expired, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package logic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+const (
+	// watchPoll is how often the registry is checked for changes while a
+	// client is watching a path.
+	watchPoll = 15 * time.Second
+
+	// watchTimeout is how long a watch request waits for a change before
+	// returning the unchanged revision, so clients and proxies do not give
+	// up on the connection.
+	watchTimeout = 55 * time.Second
+)
+
+// AwaitCredentialsChange waits until the revision of the credentials at the
+// given CPath differs from revision, and returns the new revision. If the
+// credentials do not change within watchTimeout, the unchanged revision is
+// returned. An empty revision returns the current revision right away.
+func (e *Engine) AwaitCredentialsChange(ctx context.Context, path,
+	revision string) (*apitypes.CredentialsRevision, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, watchTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(watchPoll)
+	defer ticker.Stop()
+
+	unchanged := &apitypes.CredentialsRevision{Path: path, Revision: revision}
+	for {
+		current, err := e.credentialsRevision(ctx, path)
+		if ctx.Err() != nil {
+			return unchanged, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if current != revision {
+			return &apitypes.CredentialsRevision{Path: path, Revision: current}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return unchanged, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// credentialsRevision returns a digest of the IDs of every keyring and
+// credential for path. Any write to a credential, or rotation of a keyring,
+// creates a new object, and so changes the revision.
+func (e *Engine) credentialsRevision(ctx context.Context, path string) (string, error) {
+	graphs, err := e.client.CredentialGraph.List(ctx, path, nil, e.session.AuthID())
+	if err != nil {
+		log.Printf("error retrieving credential graphs: %s", err)
+		return "", err
+	}
+
+	ids := []string{}
+	for _, graph := range graphs {
+		ids = append(ids, graph.GetKeyring().GetID().String())
+		for _, cred := range graph.GetCredentials() {
+			ids = append(ids, cred.GetID().String())
+		}
+	}
+	sort.Strings(ids)
+
+	h := sha256.New()
+	for _, id := range ids {
+		h.Write([]byte(id))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
@@ -38,6 +38,9 @@ func newWorklog(e *Engine) Worklog {
 			apitypes.MissingKeypairsWorklogType: &missingKeypairsHandler{engine: e},
 			apitypes.InviteApproveWorklogType:   &inviteApproveHandler{engine: e},
 			apitypes.KeyringMembersWorklogType:  &keyringMembersHandler{engine: e},
+			apitypes.KeyTrustWorklogType:        &keyTrustHandler{engine: e},
+			apitypes.StaleClaimsWorklogType:     &staleClaimsHandler{engine: e},
+			apitypes.RevokedKeysWorklogType:     &revokedKeysHandler{engine: e},
 		},
 	}
 
@@ -296,7 +299,94 @@ func (keyringMembersHandler) resolveErr() string {
 }
 
 func (h *keyringMembersHandler) list(ctx context.Context, org *envelope.Org) ([]apitypes.WorklogItem, error) {
-	// Find all of the credential graphs in this org.
+	graphs, err := listActiveGraphs(ctx, h.engine.client, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// XXX: this logic will be much different when we selectively encode the
+	// members of a keyring based on ACLs.
+	members, err := getKeyringMembers(ctx, h.engine.client, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make(map[string]apitypes.WorklogItem)
+	for _, graph := range graphs {
+		owners, err := missingMembers(graph, members.For(graph.GetKeyring().PathExp()))
+		if err != nil {
+			return nil, err
+		}
+		if len(owners) == 0 {
+			continue
+		}
+
+		path := graph.GetKeyring().PathExp().String()
+		if _, ok := missing[path]; !ok {
+			item := apitypes.WorklogItem{
+				Subject: path,
+				Summary: "One or more users are missing access to these secrets.",
+			}
+			item.CreateID(apitypes.KeyringMembersWorklogType)
+			missing[path] = item
+		}
+	}
+
+	return sortedWorklogItems(missing), nil
+}
+
+func (h *keyringMembersHandler) resolve(ctx context.Context, n *observer.Notifier,
+	orgID *identity.ID, item *apitypes.WorklogItem) (*apitypes.WorklogResult, error) {
+
+	err := shareKeyrings(ctx, h.engine, orgID, item.Subject, missingMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apitypes.WorklogResult{
+		ID:      item.ID,
+		State:   apitypes.SuccessWorklogResult,
+		Message: "Missing user(s) added to keyring.",
+	}, nil
+}
+
+// missingMembers returns the members who have no membership in the keyring
+// of graph.
+func missingMembers(graph registry.CredentialGraph, members []identity.ID) ([]identity.ID, error) {
+	var missing []identity.ID
+	for _, member := range members {
+		m, _, err := graph.FindMember(&member)
+		if err != nil && err != registry.ErrMemberNotFound {
+			return nil, err
+		}
+
+		if m == nil {
+			missing = append(missing, member)
+		}
+	}
+
+	return missing, nil
+}
+
+// listActiveGraphs returns the active versions of every credential graph in
+// the org.
+func listActiveGraphs(ctx context.Context, client *registry.Client,
+	orgID *identity.ID) ([]registry.CredentialGraph, error) {
+
+	cgs, err := fetchOrgGraphs(ctx, client, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Inactive versions don't matter, as there is nothing there a user would
+	// want to access.
+	return cgs.Active()
+}
+
+// fetchOrgGraphs returns every version of every credential graph in the org.
+func fetchOrgGraphs(ctx context.Context, client *registry.Client,
+	orgID *identity.ID) (*credentialGraphSet, error) {
+
 	// We need to get all credential graphs. To do this, we first need to know
 	// their pathexps. Use keyring listing for this.
 	//
@@ -304,7 +394,7 @@ func (h *keyringMembersHandler) list(ctx context.Context, org *envelope.Org) ([]
 	// the subsequent List call will return all versions.
 	cgs := newCredentialGraphSet()
 	paths := make(map[string]*pathexp.PathExp)
-	keyrings, err := h.engine.client.Keyring.List(ctx, org.ID, nil)
+	keyrings, err := client.Keyring.List(ctx, orgID, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -315,7 +405,7 @@ func (h *keyringMembersHandler) list(ctx context.Context, org *envelope.Org) ([]
 	}
 
 	for _, pe := range paths {
-		graphs, err := h.engine.client.CredentialGraph.List(ctx, "", pe, nil)
+		graphs, err := client.CredentialGraph.List(ctx, "", pe, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -326,142 +416,107 @@ func (h *keyringMembersHandler) list(ctx context.Context, org *envelope.Org) ([]
 		}
 	}
 
-	// To find out if any keyrings are missing members, we need walk through all
-	// active versions of each keyring, to see if anyone is missing.
-	// Inactive versions don't matter, as there is nothing there a user would
-	// want to access.
-	graphs, err := cgs.Active()
-	if err != nil {
-		return nil, err
-	}
-
-	// XXX: this logic will be much different when we selectively encode the
-	// members of a keyring based on ACLs.
-	members, err := getKeyringMembers(ctx, h.engine.client, org.ID)
-	if err != nil {
-		return nil, err
-	}
-
-	missing := make(map[string]apitypes.WorklogItem)
-	for _, graph := range graphs {
-		for _, member := range members {
-			m, _, err := graph.FindMember(&member)
-			if err != nil && err != registry.ErrMemberNotFound {
-				return nil, err
-			}
-
-			if m != nil {
-				continue
-			}
-
-			path := graph.GetKeyring().PathExp().String()
-			if _, ok := missing[path]; !ok {
-				item := apitypes.WorklogItem{
-					Subject: path,
-					Summary: "One or more users are missing access to these secrets.",
-				}
-				item.CreateID(apitypes.KeyringMembersWorklogType)
-				missing[path] = item
-			}
-			// at least one member is missing. we can stop looking here.
-			break
-		}
-	}
+	return cgs, nil
+}
 
-	// Always return the items in a consistent order.
-	keys := make([]string, 0, len(missing))
-	for k := range missing {
+// sortedWorklogItems returns the items, keyed by subject, in a consistent
+// order.
+func sortedWorklogItems(items map[string]apitypes.WorklogItem) []apitypes.WorklogItem {
+	keys := make([]string, 0, len(items))
+	for k := range items {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
 
-	items := make([]apitypes.WorklogItem, 0, len(missing))
+	sorted := make([]apitypes.WorklogItem, 0, len(items))
 	for _, k := range keys {
-		items = append(items, missing[k])
+		sorted = append(sorted, items[k])
 	}
 
-	return items, nil
+	return sorted
 }
 
-func (h *keyringMembersHandler) resolve(ctx context.Context, n *observer.Notifier,
-	orgID *identity.ID, item *apitypes.WorklogItem) (*apitypes.WorklogResult, error) {
+// shareKeyrings shares the master encryption key of each active version of
+// the keyring at path with the members chosen by needShare, encrypted for
+// their current encryption key. The key is decrypted with the current user's
+// own membership.
+func shareKeyrings(ctx context.Context, e *Engine, orgID *identity.ID, path string,
+	needShare func(registry.CredentialGraph, []identity.ID) ([]identity.ID, error)) error {
 
 	// Preamble. Get the current user's keypairs, and the org's claims for
 	// pubkey lookup.
-	sigID, encID, kp, err := fetchKeyPairs(ctx, h.engine.client, orgID)
+	sigID, encID, kp, err := fetchWritableKeyPairs(ctx, e.client, orgID)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	claimTrees, err := h.engine.client.ClaimTree.List(ctx, orgID, nil)
+	claimTrees, err := e.client.ClaimTree.List(ctx, orgID, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Get all the users that should be a member of this keyring.
-	members, err := getKeyringMembers(ctx, h.engine.client, orgID)
+	members, err := getKeyringMembers(ctx, e.client, orgID)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	pe, err := pathexp.Parse(item.Subject)
+	pe, err := pathexp.Parse(path)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	cgs := newCredentialGraphSet()
-	graphs, err := h.engine.client.CredentialGraph.List(ctx, "", pe, nil)
+	graphs, err := e.client.CredentialGraph.List(ctx, "", pe, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	err = cgs.Add(graphs...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	graphs, err = cgs.Active()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	for _, graph := range graphs {
+		owners, err := needShare(graph, members.For(graph.GetKeyring().PathExp()))
+		if err != nil {
+			return err
+		}
+		if len(owners) == 0 {
+			continue
+		}
+
 		// The current user's mekshare info for this credential graph version
-		// will be the same across all missing users, so look it up first.
-		krm, mekshare, err := graph.FindMember(h.engine.session.AuthID())
+		// will be the same across all users, so look it up first.
+		krm, mekshare, err := graph.FindMember(e.session.AuthID())
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		encPubKey, err := findEncryptionPublicKeyByID(claimTrees, orgID, krm.EncryptingKeyID)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		for _, member := range members {
-			m, _, err := graph.FindMember(&member)
-			if err != nil && err != registry.ErrMemberNotFound {
-				return nil, err
-			}
-
-			if m != nil {
-				continue
-			}
-
-			// We have a missing user. Find their public key, clone the existing
-			// user's copy of the master encryption key for this keyring, and
-			// post the result. Now the user is a member!
-
+		for _, member := range owners {
+			// Find the user's public key, clone the current user's copy of
+			// the master encryption key for this keyring, and post the result.
+			// Now the user is a member!
 			targetPubKey, err := findEncryptionPublicKey(claimTrees, orgID, &member)
 			if err != nil {
-				return nil, err
+				return err
 			}
 
-			encMek, nonce, err := h.engine.crypto.CloneMembership(ctx,
+			encMek, nonce, err := e.crypto.CloneMembership(ctx,
 				*mekshare.Key.Value, *mekshare.Key.Nonce, &kp.Encryption,
 				*encPubKey.Body.Key.Value, *targetPubKey.Body.Key.Value)
 			if err != nil {
-				return nil, err
+				return err
 			}
 
 			key := &primitive.KeyringMemberKey{
@@ -474,34 +529,30 @@ func (h *keyringMembersHandler) resolve(ctx context.Context, n *observer.Notifie
 			switch k := keyring.(type) {
 			case *envelope.KeyringV1:
 				projectID := k.Body.ProjectID
-				membership, err := newV1KeyringMember(ctx, h.engine.crypto, orgID, projectID,
+				membership, err := newV1KeyringMember(ctx, e.crypto, orgID, projectID,
 					krm.KeyringID, &member, targetPubKey.ID, encID, sigID, key, kp)
 				if err != nil {
-					return nil, err
+					return err
 				}
 
-				_, err = h.engine.client.KeyringMember.Post(ctx, []envelope.KeyringMemberV1{*membership})
+				_, err = e.client.KeyringMember.Post(ctx, []envelope.KeyringMemberV1{*membership})
 				if err != nil {
-					return nil, err
+					return err
 				}
 
 			case *envelope.Keyring:
-				membership, err := newV2KeyringMember(ctx, h.engine.crypto, orgID, krm.KeyringID,
+				membership, err := newV2KeyringMember(ctx, e.crypto, orgID, krm.KeyringID,
 					&member, targetPubKey.ID, encID, sigID, key, kp)
 				if err != nil {
-					return nil, err
+					return err
 				}
-				err = h.engine.client.Keyring.Members.Post(ctx, *membership)
+				err = e.client.Keyring.Members.Post(ctx, *membership)
 				if err != nil {
-					return nil, err
+					return err
 				}
 			}
 		}
 	}
 
-	return &apitypes.WorklogResult{
-		ID:      item.ID,
-		State:   apitypes.SuccessWorklogResult,
-		Message: "Missing user(s) added to keyring.",
-	}, nil
+	return nil
 }
@@ -11,10 +11,14 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"time"
 )
 
 type ctxkey string
 
+// now returns the current time, for timing transactions.
+var now = time.Now
+
 // CtxRequestID is the context WithValue key for a request id.
 var CtxRequestID ctxkey = "id"
 
@@ -33,13 +37,18 @@ const (
 type event struct {
 	ID        string    `json:"id"`
 	Type      EventType `json:"-"` // type is included in the SSE
+	Step      string    `json:"step,omitempty"`
 	Message   string    `json:"message"`
 	Completed uint      `json:"completed"`
 	Total     uint      `json:"total"`
+
+	// Elapsed is the number of milliseconds since the transaction started.
+	Elapsed int64 `json:"elapsed"`
 }
 
 type notification struct {
 	Type      EventType
+	Step      string
 	Message   string
 	Increment bool
 }
@@ -58,6 +67,7 @@ type Observer struct {
 
 type transaction struct {
 	requestID      string
+	started        time.Time
 	total          uint
 	current        uint
 	events         chan<- *event
@@ -92,9 +102,11 @@ func (t *transaction) start() {
 				evt := &event{
 					ID:        t.requestID,
 					Type:      notification.Type,
+					Step:      notification.Step,
 					Message:   notification.Message,
 					Completed: t.current,
 					Total:     t.total,
+					Elapsed:   int64(now().Sub(t.started) / time.Millisecond),
 				}
 
 				t.events <- evt
@@ -128,8 +140,16 @@ func (n *Notifier) Notifier(total uint) *Notifier {
 // Notify publishes an event to all SSE observers. This function panics when it
 // is called more often than it is supposed to have been called.
 func (n *Notifier) Notify(eventType EventType, message string, increment bool) {
+	n.NotifyStep(eventType, "", message, increment)
+}
+
+// NotifyStep publishes an event like Notify, identifying the step of the
+// transaction that it belongs to, so that consumers can tell steps apart
+// without parsing the message.
+func (n *Notifier) NotifyStep(eventType EventType, step, message string, increment bool) {
 	notif := &notification{
 		Type:      eventType,
+		Step:      step,
 		Message:   message,
 		Increment: increment,
 	}
@@ -190,6 +210,7 @@ func (o *Observer) Notifier(ctx context.Context, total uint) (*Notifier, error)
 
 	t := &transaction{
 		requestID:      id,
+		started:        now(),
 		total:          total,
 		current:        0,
 		totalUpdates:   totalUpdates,
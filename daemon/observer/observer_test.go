@@ -5,6 +5,7 @@ import (
 	"context"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/satori/go.uuid"
 )
@@ -40,6 +41,27 @@ func TestNotifier(t *testing.T) {
 		}
 	})
 
+	t.Run("NotifyStep names the step", func(t *testing.T) {
+		o := New()
+		id := uuid.NewV4().String()
+		ctx := context.WithValue(context.Background(), CtxRequestID, id)
+
+		parent, err := o.Notifier(ctx, 2)
+		if err != nil {
+			t.Errorf("unexpected error constructing notifier: %s", err)
+		}
+
+		go parent.NotifyStep(Progress, "upload", "uploaded", true)
+		evt := <-o.notify
+
+		if evt.Step != "upload" || evt.Message != "uploaded" {
+			t.Errorf("wrong step or message: %s %s", evt.Step, evt.Message)
+		}
+		if evt.Elapsed < 0 {
+			t.Errorf("negative elapsed time: %d", evt.Elapsed)
+		}
+	})
+
 	// Test will timeout if this behaviour is not correct
 	t.Run("a chained Notify resolves when ctx is cancelled", func(t *testing.T) {
 		o := New()
@@ -128,6 +150,10 @@ func TestObserverServeHTTP(t *testing.T) {
 
 		r := httptest.NewRequest("GET", "/observe", nil)
 
+		start := time.Now()
+		now = func() time.Time { return start }
+		defer func() { now = time.Now }()
+
 		n, err := o.Notifier(ctx, 1)
 		if err != nil {
 			t.Errorf("unexpected error constructing Notifier: %s", err)
@@ -150,7 +176,7 @@ func TestObserverServeHTTP(t *testing.T) {
 		}
 
 		expectedEvent := []byte(
-			"event: progress\ndata: {\"id\":\"" + id + "\",\"message\":\"hi\",\"completed\":1,\"total\":1}\n\n",
+			"event: progress\ndata: {\"id\":\"" + id + "\",\"message\":\"hi\",\"completed\":1,\"total\":1,\"elapsed\":0}\n\n",
 		)
 		if !bytes.Equal(rw.Body.Bytes(), expectedEvent) {
 			t.Errorf("Event data does not match. got:\n%s\nwanted:\n%s", rw.Body.Bytes(), expectedEvent)
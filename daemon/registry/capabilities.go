@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"context"
+	"log"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+// Features of newer registries that change how the daemon behaves.
+const (
+	FeatureSignedPolicy = "signed_policies"
+	FeatureGzipRequests = "gzip_requests"
+)
+
+// Capabilities returns the registry's capabilities, asking it only the first
+// time it is called. Registries without a capabilities endpoint support no
+// optional features.
+func (c *Client) Capabilities(ctx context.Context) (*apitypes.Capabilities, error) {
+	c.capsMutex.Lock()
+	defer c.capsMutex.Unlock()
+
+	if c.caps != nil {
+		return c.caps, nil
+	}
+
+	req, err := c.NewRequest("GET", "/capabilities", nil, nil)
+	if err != nil {
+		log.Printf("Error building http request: %s", err)
+		return nil, err
+	}
+
+	caps := &apitypes.Capabilities{}
+	_, err = c.Do(ctx, req, caps)
+	if apitypes.IsNotFoundError(err) {
+		caps, err = &apitypes.Capabilities{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.caps = caps
+	return caps, nil
+}
+
+// Supports returns whether the registry supports feature.
+func (c *Client) Supports(ctx context.Context, feature string) (bool, error) {
+	caps, err := c.Capabilities(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return caps.Supports(feature), nil
+}
@@ -6,12 +6,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/manifoldco/torus-cli/apitypes"
 
+	"github.com/manifoldco/torus-cli/daemon/ctxutil"
 	"github.com/manifoldco/torus-cli/daemon/session"
 )
 
@@ -23,6 +28,19 @@ type Client struct {
 	version    string
 	sess       session.Session
 
+	// gzipRequests is 1 while large request bodies may be sent compressed,
+	// to registries that support it. It is cleared if the registry rejects a
+	// compressed request anyway.
+	gzipRequests int32
+
+	// caps caches the registry's capabilities once they are first asked for.
+	capsMutex sync.Mutex
+	caps      *apitypes.Capabilities
+
+	// unauthorized is called with the token of each request the registry
+	// rejects as unauthorized.
+	unauthorized func(token string)
+
 	KeyPairs        *KeyPairs
 	Tokens          *Tokens
 	Users           *Users
@@ -39,16 +57,24 @@ type Client struct {
 	CredentialGraph *CredentialGraphClient
 	Machines        *MachinesClient
 	Self            *SelfClient
+	Policies        *PoliciesClient
+	Events          *EventsClient
+	Recovery        *RecoveryClient
+	Honeytokens     *HoneytokensClient
 }
 
 // NewClient returns a new Client.
+//
+// Responses are compressed whenever the registry supports it, as t asks for
+// gzip encoded responses and decodes them transparently.
 func NewClient(prefix string, apiVersion string, version string, sess session.Session, t *http.Transport) *Client {
 	c := &Client{
-		client:     &http.Client{Transport: t},
-		prefix:     prefix,
-		apiVersion: apiVersion,
-		version:    version,
-		sess:       sess,
+		client:       &http.Client{Transport: t},
+		prefix:       prefix,
+		apiVersion:   apiVersion,
+		version:      version,
+		sess:         sess,
+		gzipRequests: 1,
 	}
 
 	c.KeyPairs = &KeyPairs{client: c}
@@ -68,10 +94,20 @@ func NewClient(prefix string, apiVersion string, version string, sess session.Se
 	c.CredentialGraph = &CredentialGraphClient{client: c}
 	c.Machines = &MachinesClient{client: c}
 	c.Self = &SelfClient{client: c}
+	c.Policies = &PoliciesClient{client: c}
+	c.Events = &EventsClient{client: c}
+	c.Recovery = &RecoveryClient{client: c}
+	c.Honeytokens = &HoneytokensClient{client: c}
 
 	return c
 }
 
+// OnUnauthorized sets fn to be called with the token of each request the
+// registry rejects as unauthorized. It must be set before the Client is used.
+func (c *Client) OnUnauthorized(fn func(token string)) {
+	c.unauthorized = fn
+}
+
 // NewRequest constructs a new http.Request, with a body containing the json
 // representation of body, if provided.
 func (c *Client) NewRequest(method, path string, query *url.Values,
@@ -127,11 +163,36 @@ func (c *Client) NewTokenRequest(token, method, path string, query *url.Values,
 // If the request errors with a JSON formatted response body, it will be
 // unmarshaled into the returned error.
 func (c *Client) Do(ctx context.Context, r *http.Request, v interface{}) (*http.Response, error) {
+	if code := ctxutil.MFACode(ctx); code != "" {
+		r.Header.Set(apitypes.MFACodeHeader, code)
+	}
+
 	ctx, cancelFunc := context.WithTimeout(ctx, 6*time.Second)
 	r = r.WithContext(ctx)
 	defer cancelFunc()
 
+	var plain []byte
+	var err error
+	if c.compressRequest(ctx, r) {
+		plain, err = compressBody(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	resp, err := c.client.Do(r)
+
+	// Registries that can't decode compressed requests reject them as an
+	// unsupported media type. Stop compressing, and send this one again.
+	if err == nil && plain != nil && resp.StatusCode == http.StatusUnsupportedMediaType {
+		resp.Body.Close()
+		log.Printf("Registry does not accept compressed requests, sending them uncompressed")
+		atomic.StoreInt32(&c.gzipRequests, 0)
+
+		uncompressBody(r, plain)
+		resp, err = c.client.Do(r)
+	}
+
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			err = &apitypes.Error{
@@ -146,6 +207,10 @@ func (c *Client) Do(ctx context.Context, r *http.Request, v interface{}) (*http.
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized && c.unauthorized != nil {
+		c.unauthorized(BearerToken(r))
+	}
+
 	err = checkResponseCode(resp)
 	if err != nil {
 		return resp, err
@@ -162,6 +227,32 @@ func (c *Client) Do(ctx context.Context, r *http.Request, v interface{}) (*http.
 	return resp, nil
 }
 
+// compressRequest returns whether the body of r should be compressed: it is
+// large enough, and the registry accepts compressed requests.
+func (c *Client) compressRequest(ctx context.Context, r *http.Request) bool {
+	// Requests without a body are never compressed, so the capabilities
+	// request doesn't look up capabilities itself.
+	if atomic.LoadInt32(&c.gzipRequests) == 0 || r.ContentLength < gzipThreshold {
+		return false
+	}
+
+	ok, err := c.Supports(ctx, FeatureGzipRequests)
+	if err != nil {
+		log.Printf("Error retrieving registry capabilities: %s", err)
+		return false
+	}
+	if !ok {
+		atomic.StoreInt32(&c.gzipRequests, 0)
+	}
+
+	return ok
+}
+
+// BearerToken returns the auth token r is authorized with, if any.
+func BearerToken(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
 func checkResponseCode(r *http.Response) error {
 	if r.StatusCode >= 200 && r.StatusCode < 300 {
 		return nil
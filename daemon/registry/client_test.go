@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/daemon/session"
+)
+
+func TestOnUnauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/denied" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"type": "unauthorized", "error": ["revoked"]}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	var rejected []string
+	c := NewClient(srv.URL, "0.1.0", "test", session.NewSession(), &http.Transport{})
+	c.OnUnauthorized(func(token string) {
+		rejected = append(rejected, token)
+	})
+
+	for _, path := range []string{"/ok", "/denied"} {
+		req, err := c.NewTokenRequest("tok", "GET", path, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = c.Do(context.Background(), req, nil)
+		if path == "/denied" && !apitypes.IsUnauthorizedError(err) {
+			t.Errorf("expected an unauthorized error, got %v", err)
+		}
+	}
+
+	if len(rejected) != 1 || rejected[0] != "tok" {
+		t.Errorf("got rejected tokens %v, want [tok]", rejected)
+	}
+}
@@ -0,0 +1,52 @@
+package registry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+)
+
+// gzipThreshold is the smallest request body worth compressing. Smaller
+// bodies fit in a packet or two either way.
+const gzipThreshold = 1024
+
+// compressBody gzips the body of r if it is large enough to be worth it. The
+// uncompressed body is returned, so r can be resent as is if the registry
+// does not accept compressed requests. It is nil if r was left alone.
+func compressBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.ContentLength < gzipThreshold {
+		return nil, nil
+	}
+
+	plain, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+
+	b := &bytes.Buffer{}
+	w := gzip.NewWriter(b)
+	if _, err := w.Write(plain); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	setBody(r, b.Bytes())
+	r.Header.Set("Content-Encoding", "gzip")
+	return plain, nil
+}
+
+// uncompressBody restores the uncompressed body of r, returned by
+// compressBody.
+func uncompressBody(r *http.Request, plain []byte) {
+	setBody(r, plain)
+	r.Header.Del("Content-Encoding")
+}
+
+func setBody(r *http.Request, b []byte) {
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+	r.ContentLength = int64(len(b))
+}
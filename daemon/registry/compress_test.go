@@ -0,0 +1,144 @@
+package registry
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/daemon/session"
+)
+
+// largeRequest is a request body worth compressing, shaped like a batch of
+// keyring members.
+func largeRequest() []map[string]string {
+	body := make([]map[string]string, 200)
+	for i := range body {
+		body[i] = map[string]string{
+			"owner_id":   fmt.Sprintf("%032d", i),
+			"keyring_id": "0123456789abcdef0123456789abcdef",
+			"pathexp":    "/org/project/production/api/*/*",
+		}
+	}
+	return body
+}
+
+func TestRequestCompression(t *testing.T) {
+	tcs := []struct {
+		name       string
+		advertises bool
+		accepts    bool
+		compressed int32
+	}{
+		{name: "supported", advertises: true, accepts: true, compressed: 2},
+		{name: "unsupported", compressed: 0},
+
+		// Compression is given up on after the first rejection.
+		{name: "rejected", advertises: true, compressed: 1},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			accepts := tc.accepts
+
+			var compressed int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/capabilities" {
+					caps := apitypes.Capabilities{}
+					if tc.advertises {
+						caps.Features = []string{FeatureGzipRequests}
+					}
+					json.NewEncoder(w).Encode(&caps)
+					return
+				}
+
+				var body io.Reader = r.Body
+				if r.Header.Get("Content-Encoding") == "gzip" {
+					atomic.AddInt32(&compressed, 1)
+					if !accepts {
+						w.WriteHeader(http.StatusUnsupportedMediaType)
+						return
+					}
+
+					gz, err := gzip.NewReader(r.Body)
+					if err != nil {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					body = gz
+				}
+
+				var members []map[string]string
+				if err := json.NewDecoder(body).Decode(&members); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				json.NewEncoder(w).Encode(len(members))
+			}))
+			defer srv.Close()
+
+			c := NewClient(srv.URL, "0.1.0", "test", session.NewSession(), &http.Transport{})
+			for i := 0; i < 2; i++ {
+				req, err := c.NewTokenRequest("", "POST", "/keyring-members", nil, largeRequest())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var n int
+				_, err = c.Do(context.Background(), req, &n)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n != 200 {
+					t.Errorf("registry got %d members, want 200", n)
+				}
+			}
+
+			if got := atomic.LoadInt32(&compressed); got != tc.compressed {
+				t.Errorf("got %d compressed requests, want %d", got, tc.compressed)
+			}
+		})
+	}
+
+	t.Run("small requests", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "http://localhost", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		plain, err := compressBody(req)
+		if err != nil || plain != nil || req.Header.Get("Content-Encoding") != "" {
+			t.Error("small requests should not be compressed")
+		}
+	})
+}
+
+func BenchmarkCompressBody(b *testing.B) {
+	body, err := json.Marshal(largeRequest())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(body)))
+	var size int64
+	for i := 0; i < b.N; i++ {
+		req, err := http.NewRequest("POST", "http://localhost", strings.NewReader(string(body)))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := compressBody(req); err != nil {
+			b.Fatal(err)
+		}
+		size = req.ContentLength
+	}
+
+	b.Logf("%d byte body compressed to %d bytes", len(body), size)
+}
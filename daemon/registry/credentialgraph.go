@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/url"
 
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/pathexp"
@@ -54,13 +56,26 @@ func (c *CredentialGraphV2) GetCredentials() []envelope.CredentialInf {
 // Post creates a new CredentialGraph on the registry.
 //
 // The CredentialGraph includes the keyring, it's members, and credentials.
-func (c *CredentialGraphClient) Post(ctx context.Context, t *CredentialGraph) (*CredentialGraphV2, error) {
+//
+// If previous is provided, the registry will only accept the graph if
+// previous is still the latest version of its keyring. Otherwise, it will
+// only accept it if there is no keyring for its path expression yet. Either
+// way, it returns a 412 if the precondition no longer holds.
+func (c *CredentialGraphClient) Post(ctx context.Context, t *CredentialGraph,
+	previous *identity.ID) (*CredentialGraphV2, error) {
+
 	req, err := c.client.NewRequest("POST", "/credentialgraph", nil, t)
 	if err != nil {
 		log.Printf("Error building http request: %s", err)
 		return nil, err
 	}
 
+	if previous != nil {
+		req.Header.Set("If-Match", `"`+previous.String()+`"`)
+	} else {
+		req.Header.Set("If-None-Match", "*")
+	}
+
 	resp := CredentialGraphV2{}
 	_, err = c.client.Do(ctx, req, &resp)
 	if err != nil {
@@ -120,8 +135,9 @@ func (c *CredentialGraphClient) getGraph(ctx context.Context, query url.Values)
 	resp := []struct {
 		Keyring     *envelope.Signed              `json:"keyring"`
 		Members     json.RawMessage               `json:"members"`
-		Credentials []envelope.Signed             `json:"credentials"`
+		Credentials envelope.List                 `json:"credentials"`
 		Claims      []envelope.KeyringMemberClaim `json:"claims"`
+		Frozen      *apitypes.KeyringFreeze       `json:"frozen"`
 	}{}
 
 	_, err = c.client.Do(ctx, req, &resp)
@@ -132,23 +148,12 @@ func (c *CredentialGraphClient) getGraph(ctx context.Context, query url.Values)
 	converted := make([]CredentialGraph, len(resp))
 	for i, g := range resp {
 		creds := make([]envelope.CredentialInf, len(g.Credentials))
-		for i, ec := range g.Credentials {
-			switch ec.Body.(type) {
-			case *primitive.CredentialV1:
-				creds[i] = &envelope.CredentialV1{
-					ID:        ec.ID,
-					Version:   ec.Version,
-					Signature: ec.Signature,
-					Body:      ec.Body.(*primitive.CredentialV1),
-				}
-			case *primitive.Credential:
-				creds[i] = &envelope.Credential{
-					ID:        ec.ID,
-					Version:   ec.Version,
-					Signature: ec.Signature,
-					Body:      ec.Body.(*primitive.Credential),
-				}
+		for i, e := range g.Credentials {
+			cred, ok := e.(envelope.CredentialInf)
+			if !ok {
+				return nil, fmt.Errorf("Unexpected credential graph member %s", e.GetID())
 			}
+			creds[i] = cred
 		}
 
 		if g.Keyring.Version == 1 {
@@ -162,6 +167,7 @@ func (c *CredentialGraphClient) getGraph(ctx context.Context, query url.Values)
 			c := CredentialGraphV1{
 				KeyringSectionV1: KeyringSectionV1{
 					Keyring: kre,
+					Frozen:  g.Frozen,
 				},
 				Credentials: creds,
 			}
@@ -182,6 +188,7 @@ func (c *CredentialGraphClient) getGraph(ctx context.Context, query url.Values)
 				KeyringSectionV2: KeyringSectionV2{
 					Keyring: kre,
 					Claims:  g.Claims,
+					Frozen:  g.Frozen,
 				},
 				Credentials: creds,
 			}
@@ -2,9 +2,13 @@ package registry
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"strings"
 
 	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
 )
 
 // Credentials represents the `/credentials` registry endpoint, used for
@@ -13,19 +17,85 @@ type Credentials struct {
 	client *Client
 }
 
-// Create creates the provided credential in the registry.
-func (c *Credentials) Create(ctx context.Context, credential *envelope.Credential) (*envelope.Credential, error) {
+// Create creates the provided credential, of any schema version, in the
+// registry.
+//
+// If previous is provided, the registry will only accept the credential if
+// previous is still the latest version of it, returning a 412 otherwise.
+func (c *Credentials) Create(ctx context.Context, credential envelope.CredentialInf,
+	previous *identity.ID) (envelope.CredentialInf, error) {
+
 	req, err := c.client.NewRequest("POST", "/credentials", nil, credential)
 	if err != nil {
 		log.Printf("Error building http request: %s", err)
 		return nil, err
 	}
 
-	resp := &envelope.Credential{}
-	_, err = c.client.Do(ctx, req, resp)
+	if previous != nil {
+		req.Header.Set("If-Match", `"`+previous.String()+`"`)
+	}
+
+	raw := json.RawMessage{}
+	_, err = c.client.Do(ctx, req, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := envelope.Decode(raw)
 	if err != nil {
 		return nil, err
 	}
 
-	return resp, nil
+	creds, err := credentialInfs(envelope.List{e})
+	if err != nil {
+		return nil, err
+	}
+
+	return creds[0], nil
+}
+
+// CreateBatch creates all of the provided credentials in the registry in a
+// single request. Either all of them are created, or none are.
+//
+// If previous is provided, the registry will only accept the credentials if
+// each of the listed versions is still the latest, returning a 412 otherwise.
+func (c *Credentials) CreateBatch(ctx context.Context, credentials []envelope.CredentialInf,
+	previous []*identity.ID) ([]envelope.CredentialInf, error) {
+
+	req, err := c.client.NewRequest("POST", "/credentials/batch", nil, credentials)
+	if err != nil {
+		log.Printf("Error building http request: %s", err)
+		return nil, err
+	}
+
+	if len(previous) > 0 {
+		tags := make([]string, len(previous))
+		for i, id := range previous {
+			tags[i] = `"` + id.String() + `"`
+		}
+		req.Header.Set("If-Match", strings.Join(tags, ", "))
+	}
+
+	resp := envelope.List{}
+	_, err = c.client.Do(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentialInfs(resp)
+}
+
+// credentialInfs returns the envelopes in l as credentials, failing if any of
+// them is not a credential.
+func credentialInfs(l envelope.List) ([]envelope.CredentialInf, error) {
+	creds := make([]envelope.CredentialInf, len(l))
+	for i, e := range l {
+		cred, ok := e.(envelope.CredentialInf)
+		if !ok {
+			return nil, fmt.Errorf("Unexpected credential %s", e.GetID())
+		}
+		creds[i] = cred
+	}
+
+	return creds, nil
 }
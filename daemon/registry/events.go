@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// EventsClient represents the `/events` registry endpoint, used for
+// following the activity in an org.
+type EventsClient struct {
+	client *Client
+}
+
+// List returns the events in an org after the event with the given ID, oldest
+// first. If after is empty, the most recent events are returned.
+func (e *EventsClient) List(ctx context.Context, orgID *identity.ID,
+	after string) ([]apitypes.OrgEvent, error) {
+
+	if orgID == nil {
+		return nil, errors.New("must provide org id")
+	}
+
+	v := &url.Values{}
+	v.Set("org_id", orgID.String())
+	if after != "" {
+		v.Set("after", after)
+	}
+
+	req, err := e.client.NewRequest("GET", "/events", v, nil)
+	if err != nil {
+		log.Printf("Error building GET /events request: %s", err)
+		return nil, err
+	}
+
+	events := []apitypes.OrgEvent{}
+	_, err = e.client.Do(ctx, req, &events)
+	if err != nil {
+		log.Printf("Error performing GET /events request: %s", err)
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,30 @@
+package registry
+
+import (
+	"context"
+	"log"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+// HoneytokensClient represents the `/honeytokens` registry endpoints, used to
+// alert an org when a secret marked as a honeytoken is read.
+type HoneytokensClient struct {
+	client *Client
+}
+
+// Report tells the registry that a honeytoken was read. The registry records
+// it as an event in the honeytoken's org.
+func (h *HoneytokensClient) Report(ctx context.Context, read *apitypes.HoneytokenRead) error {
+	req, err := h.client.NewRequest("POST", "/honeytokens/reads", nil, read)
+	if err != nil {
+		log.Printf("Error building POST /honeytokens/reads request: %s", err)
+		return err
+	}
+
+	_, err = h.client.Do(ctx, req, nil)
+	if err != nil {
+		log.Printf("Error performing POST /honeytokens/reads request: %s", err)
+	}
+	return err
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/url"
 
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
 	"github.com/manifoldco/torus-cli/primitive"
@@ -26,6 +27,7 @@ type KeyringClient struct {
 // versions.
 type KeyringSection interface {
 	GetKeyring() envelope.KeyringInf
+	GetFreeze() *apitypes.KeyringFreeze
 	KeyringVersion() int
 	FindMember(*identity.ID) (*primitive.KeyringMember, *primitive.MEKShare, error)
 	HasRevocations() bool
@@ -36,6 +38,7 @@ type KeyringSection interface {
 type KeyringSectionV1 struct {
 	Keyring *envelope.KeyringV1        `json:"keyring"`
 	Members []envelope.KeyringMemberV1 `json:"members"`
+	Frozen  *apitypes.KeyringFreeze    `json:"frozen,omitempty"`
 }
 
 // GetKeyring returns the Keyring object in this KeyringSection
@@ -43,6 +46,11 @@ func (k *KeyringSectionV1) GetKeyring() envelope.KeyringInf {
 	return k.Keyring
 }
 
+// GetFreeze returns the freeze on this keyring, or nil if it is not frozen.
+func (k *KeyringSectionV1) GetFreeze() *apitypes.KeyringFreeze {
+	return k.Frozen
+}
+
 // KeyringVersion returns the version of the keyring itself (not its schema).
 func (k *KeyringSectionV1) KeyringVersion() int {
 	return k.Keyring.Body.KeyringVersion
@@ -50,23 +58,31 @@ func (k *KeyringSectionV1) KeyringVersion() int {
 
 // FindMember returns the membership and mekshare for the given user id.
 // The data is returned in V2 format.
+//
+// An owner has more than one membership after renewing their keypairs; the
+// most recently created one is returned.
 func (k *KeyringSectionV1) FindMember(id *identity.ID) (*primitive.KeyringMember, *primitive.MEKShare, error) {
 	var krm *primitive.KeyringMember
 	var mekshare *primitive.MEKShare
 	for _, m := range k.Members {
-		if *m.Body.OwnerID == *id {
-			krm = &primitive.KeyringMember{
-				OrgID:           m.Body.OrgID,
-				KeyringID:       m.Body.KeyringID,
-				OwnerID:         m.Body.OwnerID,
-				PublicKeyID:     m.Body.PublicKeyID,
-				EncryptingKeyID: m.Body.EncryptingKeyID,
-			}
+		if *m.Body.OwnerID != *id {
+			continue
+		}
+		if krm != nil && !m.Body.Created.After(krm.Created) {
+			continue
+		}
 
-			mekshare = &primitive.MEKShare{
-				Key: m.Body.Key,
-			}
-			break
+		krm = &primitive.KeyringMember{
+			Created:         m.Body.Created,
+			OrgID:           m.Body.OrgID,
+			KeyringID:       m.Body.KeyringID,
+			OwnerID:         m.Body.OwnerID,
+			PublicKeyID:     m.Body.PublicKeyID,
+			EncryptingKeyID: m.Body.EncryptingKeyID,
+		}
+
+		mekshare = &primitive.MEKShare{
+			Key: m.Body.Key,
 		}
 	}
 
@@ -88,6 +104,7 @@ type KeyringSectionV2 struct {
 	Keyring *envelope.Keyring             `json:"keyring"`
 	Members []KeyringMember               `json:"members"`
 	Claims  []envelope.KeyringMemberClaim `json:"claims"`
+	Frozen  *apitypes.KeyringFreeze       `json:"frozen,omitempty"`
 }
 
 // GetKeyring returns the Keyring object in this KeyringSection
@@ -95,6 +112,11 @@ func (k *KeyringSectionV2) GetKeyring() envelope.KeyringInf {
 	return k.Keyring
 }
 
+// GetFreeze returns the freeze on this keyring, or nil if it is not frozen.
+func (k *KeyringSectionV2) GetFreeze() *apitypes.KeyringFreeze {
+	return k.Frozen
+}
+
 // KeyringVersion returns the version of the keyring itself (not its schema).
 func (k *KeyringSectionV2) KeyringVersion() int {
 	return k.Keyring.Body.KeyringVersion
@@ -103,9 +125,10 @@ func (k *KeyringSectionV2) KeyringVersion() int {
 // FindMember returns the membership and mekshare for the given user id.
 //
 // An owner (user/machine token) may have multiple memberships, one per
-// encryption key. There will only be one unrevoked membership.
-// Either this unrevoked membership will be returned, or the result will error
-// with ErrMemberNotFound.
+// encryption key. Usually only one is unrevoked, but after renewing their
+// keypairs an owner has an unrevoked membership for both the old and new keys.
+// The most recently created unrevoked membership will be returned, or the
+// result will error with ErrMemberNotFound.
 func (k *KeyringSectionV2) FindMember(id *identity.ID) (*primitive.KeyringMember, *primitive.MEKShare, error) {
 	var krm *primitive.KeyringMember
 	var mekshare *primitive.MEKShare
@@ -124,12 +147,16 @@ outerLoop:
 
 			}
 
+			if krm != nil && !m.Member.Body.Created.After(krm.Created) {
+				continue
+			}
+
 			krm = m.Member.Body
 			// We never get the MEKShare for another user returned.
+			mekshare = nil
 			if m.MEKShare != nil {
 				mekshare = m.MEKShare.Body
 			}
-			break
 		}
 	}
 
@@ -180,6 +207,7 @@ func (k *KeyringClient) List(ctx context.Context, orgID *identity.ID,
 		Keyring *envelope.Signed              `json:"keyring"`
 		Members json.RawMessage               `json:"members"`
 		Claims  []envelope.KeyringMemberClaim `json:"claims"`
+		Frozen  *apitypes.KeyringFreeze       `json:"frozen"`
 	}{}
 
 	_, err = k.client.Do(ctx, req, &resp)
@@ -199,6 +227,7 @@ func (k *KeyringClient) List(ctx context.Context, orgID *identity.ID,
 
 			s := KeyringSectionV1{
 				Keyring: kre,
+				Frozen:  k.Frozen,
 			}
 			err := json.Unmarshal(k.Members, &s.Members)
 			if err != nil {
@@ -215,6 +244,7 @@ func (k *KeyringClient) List(ctx context.Context, orgID *identity.ID,
 			s := KeyringSectionV2{
 				Keyring: kre,
 				Claims:  k.Claims,
+				Frozen:  k.Frozen,
 			}
 			err := json.Unmarshal(k.Members, &s.Members)
 			if err != nil {
@@ -226,3 +256,38 @@ func (k *KeyringClient) List(ctx context.Context, orgID *identity.ID,
 
 	return converted, nil
 }
+
+// Freeze freezes every keyring in the org matching the given path expression,
+// so its secrets can't be changed, and can only be read by breaking the glass.
+// The number of keyrings frozen is returned.
+func (k *KeyringClient) Freeze(ctx context.Context,
+	req *apitypes.KeyringFreezeRequest) (*apitypes.KeyringFreezeResult, error) {
+
+	return k.freeze(ctx, "/keyrings/freeze", req)
+}
+
+// Unfreeze lifts the freeze on every keyring in the org matching the given
+// path expression.
+func (k *KeyringClient) Unfreeze(ctx context.Context,
+	req *apitypes.KeyringFreezeRequest) (*apitypes.KeyringFreezeResult, error) {
+
+	return k.freeze(ctx, "/keyrings/unfreeze", req)
+}
+
+func (k *KeyringClient) freeze(ctx context.Context, path string,
+	body *apitypes.KeyringFreezeRequest) (*apitypes.KeyringFreezeResult, error) {
+
+	req, err := k.client.NewRequest("POST", path, nil, body)
+	if err != nil {
+		log.Printf("Error building http request for POST %s: %s", path, err)
+		return nil, err
+	}
+
+	result := &apitypes.KeyringFreezeResult{}
+	_, err = k.client.Do(ctx, req, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestFindMemberPrefersNewest(t *testing.T) {
+	owner := identity.ID{0x01, 0x01, 0x01}
+	other := identity.ID{0x01, 0x01, 0x02}
+	oldKey := identity.ID{0x01, 0x06, 0x01}
+	newKey := identity.ID{0x01, 0x06, 0x02}
+	now := time.Now()
+
+	t.Run("v1", func(t *testing.T) {
+		member := func(ownerID, keyID identity.ID, created time.Time) envelope.KeyringMemberV1 {
+			return envelope.KeyringMemberV1{Body: &primitive.KeyringMemberV1{
+				Created:         created,
+				OwnerID:         &ownerID,
+				EncryptingKeyID: &keyID,
+			}}
+		}
+
+		k := &KeyringSectionV1{Members: []envelope.KeyringMemberV1{
+			member(owner, oldKey, now.Add(-time.Hour)),
+			member(owner, newKey, now),
+			member(other, oldKey, now.Add(time.Hour)),
+		}}
+
+		krm, _, err := k.FindMember(&owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *krm.EncryptingKeyID != newKey {
+			t.Errorf("got membership for key %s, want %s", krm.EncryptingKeyID, &newKey)
+		}
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		revokedID := identity.ID{0x01, 0x0a, 0x03}
+		member := func(id byte, keyID identity.ID, created time.Time) KeyringMember {
+			return KeyringMember{Member: &envelope.KeyringMember{
+				ID: &identity.ID{0x01, 0x0a, id},
+				Body: &primitive.KeyringMember{
+					Created:         created,
+					OwnerID:         &owner,
+					EncryptingKeyID: &keyID,
+				},
+			}}
+		}
+
+		k := &KeyringSectionV2{
+			Members: []KeyringMember{
+				member(1, oldKey, now.Add(-time.Hour)),
+				member(2, newKey, now),
+				member(3, oldKey, now.Add(time.Hour)),
+			},
+			Claims: []envelope.KeyringMemberClaim{{
+				Body: &primitive.KeyringMemberClaim{
+					KeyringMemberID: &revokedID,
+					ClaimType:       primitive.RevocationClaimType,
+				},
+			}},
+		}
+
+		krm, _, err := k.FindMember(&owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *krm.EncryptingKeyID != newKey {
+			t.Errorf("got membership for key %s, want %s", krm.EncryptingKeyID, &newKey)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
 )
@@ -31,3 +32,38 @@ func (o *Orgs) Get(ctx context.Context, orgID *identity.ID) (*envelope.Org, erro
 
 	return &org, nil
 }
+
+// GetSettings returns the settings of the organization with the given ID.
+func (o *Orgs) GetSettings(ctx context.Context, orgID *identity.ID) (*apitypes.OrgSettings, error) {
+	req, err := o.client.NewRequest("GET", "/orgs/"+orgID.String()+"/settings", nil, nil)
+	if err != nil {
+		log.Printf("Error building GET /orgs/:id/settings api request: %s", err)
+		return nil, err
+	}
+
+	settings := apitypes.OrgSettings{}
+	_, err = o.client.Do(ctx, req, &settings)
+	if err != nil {
+		log.Printf("Error performing api request: %s", err)
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// Delete deletes the organization with the given ID, along with everything
+// in it.
+func (o *Orgs) Delete(ctx context.Context, orgID *identity.ID) error {
+	req, err := o.client.NewRequest("DELETE", "/orgs/"+orgID.String(), nil, nil)
+	if err != nil {
+		log.Printf("Error building DELETE /orgs/:id api request: %s", err)
+		return err
+	}
+
+	_, err = o.client.Do(ctx, req, nil)
+	if err != nil {
+		log.Printf("Error performing api request: %s", err)
+	}
+
+	return err
+}
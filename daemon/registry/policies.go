@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"context"
+	"log"
+	"net/url"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/identity"
+)
+
+// PoliciesClient represents the `/policies` and `/policy-attachments` registry
+// endpoints, used for reading the access control policies of an org.
+type PoliciesClient struct {
+	client *Client
+}
+
+// Create creates the given policy, which the daemon has signed.
+func (p *PoliciesClient) Create(ctx context.Context, policy *envelope.Policy) (*envelope.Policy, error) {
+	req, err := p.client.NewRequest("POST", "/policies", nil, policy)
+	if err != nil {
+		log.Printf("could not build POST /policies request: %s", err)
+		return nil, err
+	}
+
+	resp := &envelope.Policy{}
+	_, err = p.client.Do(ctx, req, resp)
+	if err != nil {
+		log.Printf("could not perform POST /policies: %s", err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Update replaces the given policy, as when the daemon signs a policy that
+// was created unsigned.
+func (p *PoliciesClient) Update(ctx context.Context, policy *envelope.Policy) (*envelope.Policy, error) {
+	path := "/policies/" + policy.ID.String()
+	req, err := p.client.NewRequest("PUT", path, nil, policy)
+	if err != nil {
+		log.Printf("could not build PUT %s request: %s", path, err)
+		return nil, err
+	}
+
+	resp := &envelope.Policy{}
+	_, err = p.client.Do(ctx, req, resp)
+	if err != nil {
+		log.Printf("could not perform PUT %s: %s", path, err)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// List returns all policies for the given org.
+func (p *PoliciesClient) List(ctx context.Context, orgID *identity.ID) ([]envelope.Policy, error) {
+	query := &url.Values{}
+	query.Set("org_id", orgID.String())
+
+	req, err := p.client.NewRequest("GET", "/policies", query, nil)
+	if err != nil {
+		log.Printf("could not build GET /policies request: %s", err)
+		return nil, err
+	}
+
+	policies := []envelope.Policy{}
+	_, err = p.client.Do(ctx, req, &policies)
+	if err != nil {
+		log.Printf("could not perform GET /policies: %s", err)
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// AttachmentsList returns all policy attachments for the given org. If an
+// ownerID is provided, only attachments to that team are returned.
+func (p *PoliciesClient) AttachmentsList(ctx context.Context, orgID,
+	ownerID *identity.ID) ([]envelope.PolicyAttachment, error) {
+
+	query := &url.Values{}
+	query.Set("org_id", orgID.String())
+	if ownerID != nil {
+		query.Set("owner_id", ownerID.String())
+	}
+
+	req, err := p.client.NewRequest("GET", "/policy-attachments", query, nil)
+	if err != nil {
+		log.Printf("could not build GET /policy-attachments request: %s", err)
+		return nil, err
+	}
+
+	attachments := []envelope.PolicyAttachment{}
+	_, err = p.client.Do(ctx, req, &attachments)
+	if err != nil {
+		log.Printf("could not perform GET /policy-attachments: %s", err)
+		return nil, err
+	}
+
+	return attachments, nil
+}
@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"context"
+	"log"
+
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+// RecoveryClient represents the registry endpoints for recovering an account
+// with a recovery code.
+//
+// The registry only ever sees a hash of each code, used to find it, and the
+// user's master key encrypted with the code. It can't decrypt the master key.
+type RecoveryClient struct {
+	client *Client
+}
+
+// RecoveryCode is the user's master key, wrapped with a recovery code.
+type RecoveryCode struct {
+	Lookup string               `json:"lookup"`
+	Master *primitive.MasterKey `json:"master"`
+}
+
+// RecoveryGrant is returned when starting a recovery. Token authorizes
+// resetting the password, and Master is the master key wrapped with the code
+// used.
+type RecoveryGrant struct {
+	Token  string               `json:"token"`
+	Master *primitive.MasterKey `json:"master"`
+}
+
+type recoveryStart struct {
+	Email  string `json:"email"`
+	Lookup string `json:"lookup"`
+}
+
+type recoveryReset struct {
+	Password *primitive.UserPassword `json:"password"`
+	Master   *primitive.MasterKey    `json:"master"`
+}
+
+// Replace replaces the current user's recovery codes with the given codes.
+func (r *RecoveryClient) Replace(ctx context.Context, codes []RecoveryCode) error {
+	req, err := r.client.NewRequest("PUT", "/users/self/recovery-codes", nil, codes)
+	if err != nil {
+		log.Printf("Error building PUT /users/self/recovery-codes request: %s", err)
+		return err
+	}
+
+	_, err = r.client.Do(ctx, req, nil)
+	if err != nil {
+		log.Printf("Error performing PUT /users/self/recovery-codes request: %s", err)
+	}
+	return err
+}
+
+// Start begins recovering the account with the given email, using the code
+// with the given lookup value. The code can't be used again.
+func (r *RecoveryClient) Start(ctx context.Context, email, lookup string) (*RecoveryGrant, error) {
+	req, err := r.client.NewRequest("POST", "/recovery", nil,
+		recoveryStart{Email: email, Lookup: lookup})
+	if err != nil {
+		log.Printf("Error building POST /recovery request: %s", err)
+		return nil, err
+	}
+
+	grant := &RecoveryGrant{}
+	_, err = r.client.Do(ctx, req, grant)
+	if err != nil {
+		log.Printf("Error performing POST /recovery request: %s", err)
+		return nil, err
+	}
+
+	return grant, nil
+}
+
+// Reset replaces the password of the account being recovered, along with its
+// master key, now encrypted with the new password. The request is authorized
+// by the token from Start, as no one is logged in.
+func (r *RecoveryClient) Reset(ctx context.Context, token string,
+	pw *primitive.UserPassword, master *primitive.MasterKey) error {
+
+	req, err := r.client.NewTokenRequest(token, "POST", "/recovery/reset", nil,
+		recoveryReset{Password: pw, Master: master})
+	if err != nil {
+		log.Printf("Error building POST /recovery/reset request: %s", err)
+		return err
+	}
+
+	_, err = r.client.Do(ctx, req, nil)
+	if err != nil {
+		log.Printf("Error performing POST /recovery/reset request: %s", err)
+	}
+	return err
+}
@@ -3,6 +3,7 @@ package registry
 import (
 	"context"
 	"log"
+	"os"
 
 	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/base64"
@@ -32,11 +33,14 @@ type loginTokenResponse struct {
 type authTokenHMACRequest struct {
 	Type      string `json:"type"`
 	TokenHMAC string `json:"login_token_hmac"`
+	MFACode   string `json:"mfa_code,omitempty"`
+	Device    string `json:"device,omitempty"`
 }
 
 type authTokenPDPKARequest struct {
 	Type     string        `json:"type"`
 	TokenSig *base64.Value `json:"login_token_sig"`
+	Device   string        `json:"device,omitempty"`
 }
 
 type authTokenResponse struct {
@@ -89,12 +93,13 @@ func (t *Tokens) PostLogin(ctx context.Context, creds apitypes.LoginCredential)
 }
 
 // PostAuth requests an auth token from the registry for the provided login
-// token value, and it's HMAC.
-func (t *Tokens) PostAuth(ctx context.Context, token, hmac string) (string, error) {
+// token value, and it's HMAC. mfaCode is required if the user has
+// multi-factor authentication enabled.
+func (t *Tokens) PostAuth(ctx context.Context, token, hmac, mfaCode string) (string, error) {
 	auth := authTokenResponse{}
 
 	req, err := t.client.NewTokenRequest(token, "POST", "/tokens", nil,
-		&authTokenHMACRequest{Type: tokenTypeAuth, TokenHMAC: hmac})
+		&authTokenHMACRequest{Type: tokenTypeAuth, TokenHMAC: hmac, MFACode: mfaCode, Device: device()})
 	if err != nil {
 		log.Printf("Error building http request: %s", err)
 		return auth.Token, err
@@ -114,7 +119,7 @@ func (t *Tokens) PostPDPKAuth(ctx context.Context, token string, sig *base64.Val
 	auth := authTokenResponse{}
 
 	req, err := t.client.NewTokenRequest(token, "POST", "/tokens", nil,
-		&authTokenPDPKARequest{Type: tokenTypeAuth, TokenSig: sig})
+		&authTokenPDPKARequest{Type: tokenTypeAuth, TokenSig: sig, Device: device()})
 	if err != nil {
 		log.Printf("Error building http request: %s", err)
 		return auth.Token, err
@@ -144,3 +149,13 @@ func (t *Tokens) Delete(ctx context.Context, token string) error {
 
 	return err
 }
+
+// device returns the name of the machine the daemon runs on, recorded with
+// each session so users can tell theirs apart.
+func device() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,335 @@
+// Package resolver serves a small, stable HTTP API on a loopback address, for
+// programs such as a Terraform provider that need the secrets at a path but
+// can't talk to the daemon's domain socket, or speak its internal API.
+//
+// The API has a single endpoint:
+//
+//	GET /v1/resolve?path=/org/project/environment/service[/identity/instance]
+//
+// which returns the decrypted secrets at path as a JSON object of names to
+// values. Requests must send the token in the daemon's resolve token file as
+// a bearer token.
+package resolver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/facebookgo/httpdown"
+	"github.com/satori/go.uuid"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+
+	"github.com/manifoldco/torus-cli/daemon/logic"
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+// DefaultRate is the number of requests served per second, unless configured
+// otherwise.
+const DefaultRate = 10
+
+// defaultInstance is the instance used when a path doesn't name one, as it is
+// by the CLI.
+const defaultInstance = "1"
+
+// Source is where the Server reads secrets from; usually a logic.Engine.
+type Source interface {
+	RetrievePathCredentials(ctx context.Context, notifier *observer.Notifier,
+		cpath string, offline bool) ([]logic.PlaintextCredentialEnvelope, *time.Time, error)
+}
+
+// Response is the body of a successful resolve request.
+type Response struct {
+	Path     string            `json:"path"`
+	Values   map[string]string `json:"values"`
+	CachedAt *time.Time        `json:"cached_at,omitempty"`
+}
+
+// errorResponse is the body of a failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves the resolve API.
+type Server struct {
+	source  Source
+	self    func() *apitypes.Self
+	token   string
+	limiter *limiter
+	o       *observer.Observer
+
+	l net.Listener
+	s httpdown.Server
+}
+
+// New returns a Server reading secrets from source, for requests with the
+// given token. self returns the logged in user or machine, whose secrets are
+// read when a path doesn't name an identity. At most rate requests are served
+// per second.
+func New(source Source, self func() *apitypes.Self, token string, rate float64) *Server {
+	if rate <= 0 {
+		rate = DefaultRate
+	}
+
+	return &Server{
+		source:  source,
+		self:    self,
+		token:   token,
+		limiter: newLimiter(rate),
+		o:       observer.New(),
+	}
+}
+
+// LoadToken returns the token stored in the file at path, creating the file
+// with a new random token, readable only by the current user, if there is
+// none yet.
+func LoadToken(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err == nil {
+		token := strings.TrimSpace(string(b))
+		if token == "" {
+			return "", errors.New(path + " is empty")
+		}
+		return token, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	raw := make([]byte, 32)
+	_, err = rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+
+	token := hex.EncodeToString(raw)
+	err = ioutil.WriteFile(path, []byte(token+"\n"), 0600)
+	return token, err
+}
+
+// Listen serves the API on addr, which must be a loopback address, until the
+// server is closed.
+func (s *Server) Listen(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("%s is not a loopback address", addr)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.l = l
+
+	go s.o.Start()
+
+	h := httpdown.HTTP{}
+	s.s = h.Serve(&http.Server{Handler: s}, l)
+	return s.s.Wait()
+}
+
+// Close gracefully stops the server.
+func (s *Server) Close() error {
+	if s.s == nil {
+		return nil
+	}
+
+	err := s.s.Stop()
+	s.o.Stop()
+	return err
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() string {
+	if s.l == nil {
+		return ""
+	}
+	return s.l.Addr().String()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/resolve" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		log.Printf("Resolve API: rejected request from %s with a bad token", r.RemoteAddr)
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	if wait := s.limiter.take(time.Now()); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		return
+	}
+
+	path, err := s.fullPath(r.URL.Query().Get("path"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), observer.CtxRequestID, uuid.NewV4().String())
+	n, err := s.o.Notifier(ctx, 1)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	creds, cachedAt, err := s.source.RetrievePathCredentials(ctx, n, path, false)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if apiErr, ok := err.(*apitypes.Error); ok {
+			status = apiErr.StatusCode
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+	n.Notify(observer.Finished, "Completed Operation", true)
+
+	values, err := resolve(creds)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Printf("Resolve API: read %d secrets at %s for %s", len(values), path, r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&Response{Path: path, Values: values, CachedAt: cachedAt})
+}
+
+// fullPath returns the full path for raw, filling in the logged in identity
+// and default instance when they are left out.
+func (s *Server) fullPath(raw string) (string, error) {
+	parts := strings.Split(raw, "/")
+	switch {
+	case len(parts) == 7 && parts[0] == "":
+		return raw, nil
+	case len(parts) != 5 || parts[0] != "":
+		return "", errors.New("path must be /org/project/environment/service[/identity/instance]")
+	}
+
+	self := s.self()
+	if self == nil {
+		return "", errors.New("the daemon is not logged in")
+	}
+
+	var ident string
+	switch i := self.Identity.(type) {
+	case *envelope.User:
+		ident = i.Body.Username
+	case *envelope.Machine:
+		ident = "machine-" + i.Body.Name
+	default:
+		return "", errors.New("the daemon is not logged in")
+	}
+
+	return raw + "/" + ident + "/" + defaultInstance, nil
+}
+
+// resolve returns the value of each secret set in creds. When secrets of the
+// same name are set at more than one path expression, the most specific one
+// wins, as it does for the CLI.
+func resolve(creds []logic.PlaintextCredentialEnvelope) (map[string]string, error) {
+	winners := make(map[string]*logic.PlaintextCredentialEnvelope, len(creds))
+	for i, cred := range creds {
+		name := cred.Body.Name
+		if existing, ok := winners[name]; ok &&
+			cred.Body.PathExp.CompareSpecificity(existing.Body.PathExp) != 1 {
+			continue
+		}
+		winners[name] = &creds[i]
+	}
+
+	values := make(map[string]string, len(winners))
+	for name, cred := range winners {
+		if cred.Body.State != nil && *cred.Body.State == "unset" {
+			continue
+		}
+
+		cv := &apitypes.CredentialValue{}
+		err := cv.UnmarshalJSON([]byte(strconv.Quote(cred.Body.Value)))
+		if err != nil {
+			return nil, err
+		}
+		if cv.IsUnset() {
+			continue
+		}
+
+		raw, err := cv.Raw()
+		if err != nil {
+			return nil, err
+		}
+		values[name] = fmt.Sprint(raw)
+	}
+
+	return values, nil
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&errorResponse{Error: msg})
+}
+
+// limiter is a token bucket, allowing bursts of up to one second's worth of
+// requests.
+type limiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64) *limiter {
+	return &limiter{rate: rate, tokens: rate}
+}
+
+// take takes a token from the bucket, returning zero if it could, or how long
+// until one is available.
+func (l *limiter) take(now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.rate {
+			l.tokens = l.rate
+		}
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	}
+
+	l.tokens--
+	return 0
+}
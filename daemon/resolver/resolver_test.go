@@ -0,0 +1,155 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/logic"
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+type fakeSource struct {
+	path  string
+	creds []logic.PlaintextCredentialEnvelope
+}
+
+func (f *fakeSource) RetrievePathCredentials(ctx context.Context, notifier *observer.Notifier,
+	cpath string, offline bool) ([]logic.PlaintextCredentialEnvelope, *time.Time, error) {
+
+	f.path = cpath
+	return f.creds, nil, nil
+}
+
+func credential(t *testing.T, pe, name string, value *apitypes.CredentialValue) logic.PlaintextCredentialEnvelope {
+	p, err := pathexp.Parse(pe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := value.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	state := "set"
+	if value.IsUnset() {
+		state = "unset"
+	}
+
+	return logic.PlaintextCredentialEnvelope{
+		Body: &logic.PlaintextCredential{Name: name, PathExp: p, Value: raw, State: &state},
+	}
+}
+
+func self() *apitypes.Self {
+	return &apitypes.Self{
+		Identity: &envelope.User{Body: &primitive.User{Username: "jo"}},
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	source := &fakeSource{
+		creds: []logic.PlaintextCredentialEnvelope{
+			credential(t, "/acme/app/*/*/*/*", "url", apitypes.NewStringCredentialValue("general")),
+			credential(t, "/acme/app/dev/api/*/*", "url", apitypes.NewStringCredentialValue("specific")),
+			credential(t, "/acme/app/dev/*/*/*", "port", apitypes.NewIntCredentialValue(80)),
+			credential(t, "/acme/app/dev/*/*/*", "old", apitypes.NewUnsetCredentialValue()),
+		},
+	}
+
+	s := New(source, self, "token", 1)
+
+	tcs := []struct {
+		name   string
+		token  string
+		path   string
+		status int
+	}{
+		{"bad token", "nope", "/acme/app/dev/api", http.StatusUnauthorized},
+		{"ok", "token", "/acme/app/dev/api", http.StatusOK},
+		{"rate limited", "token", "/acme/app/dev/api", http.StatusTooManyRequests},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/resolve?path="+tc.path, nil)
+			r.Header.Set("Authorization", "Bearer "+tc.token)
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, r)
+			if w.Code != tc.status {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tc.status, w.Body)
+			}
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			resp := Response{}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if source.path != "/acme/app/dev/api/jo/1" {
+				t.Errorf("got path %s, want the identity and instance filled in", source.path)
+			}
+
+			want := map[string]string{"url": "specific", "port": "80"}
+			if !reflect.DeepEqual(resp.Values, want) {
+				t.Errorf("got values %v, want %v", resp.Values, want)
+			}
+		})
+	}
+}
+
+func TestFullPath(t *testing.T) {
+	s := New(nil, self, "token", 0)
+
+	tcs := []struct {
+		path, want string
+		err        bool
+	}{
+		{"/acme/app/dev/api", "/acme/app/dev/api/jo/1", false},
+		{"/acme/app/dev/api/machine-ci/2", "/acme/app/dev/api/machine-ci/2", false},
+		{"/acme/app/dev", "", true},
+		{"acme/app/dev/api/jo", "", true},
+	}
+
+	for _, tc := range tcs {
+		got, err := s.fullPath(tc.path)
+		if (err != nil) != tc.err || got != tc.want {
+			t.Errorf("fullPath(%q) = %q, %v; want %q, error %t", tc.path, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if wait := l.take(now); wait != 0 {
+			t.Fatalf("request %d was limited", i)
+		}
+	}
+	if wait := l.take(now); wait != 500*time.Millisecond {
+		t.Errorf("got wait %s, want 500ms", wait)
+	}
+	if wait := l.take(now.Add(500 * time.Millisecond)); wait != 0 {
+		t.Errorf("request was limited after the bucket refilled")
+	}
+}
@@ -7,7 +7,13 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"time"
 
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+
+	"github.com/manifoldco/torus-cli/daemon/ctxutil"
 	"github.com/manifoldco/torus-cli/daemon/logic"
 	"github.com/manifoldco/torus-cli/daemon/observer"
 )
@@ -16,7 +22,7 @@ func credentialsGetRoute(engine *logic.Engine, o *observer.Observer) http.Handle
 	return func(w http.ResponseWriter, r *http.Request) {
 		var err error
 
-		ctx := r.Context()
+		ctx := ctxutil.WithBreakGlass(r.Context(), r.Header.Get(apitypes.BreakGlassHeader))
 		q := r.URL.Query()
 		n, err := o.Notifier(ctx, 1)
 		if err != nil {
@@ -36,7 +42,12 @@ func credentialsGetRoute(engine *logic.Engine, o *observer.Observer) http.Handle
 
 		var creds []logic.PlaintextCredentialEnvelope
 		if path != "" {
-			creds, err = engine.RetrieveCredentials(ctx, n, &path, nil)
+			var cachedAt *time.Time
+			offline := q.Get("offline") == "true"
+			creds, cachedAt, err = engine.RetrievePathCredentials(ctx, n, path, offline)
+			if cachedAt != nil {
+				w.Header().Set(apitypes.CachedAtHeader, cachedAt.Format(time.RFC3339))
+			}
 		} else {
 			creds, err = engine.RetrieveCredentials(ctx, n, nil, &pathexp)
 		}
@@ -78,7 +89,24 @@ func credentialsPostRoute(engine *logic.Engine, o *observer.Observer) http.Handl
 			return
 		}
 
-		cred, err = engine.AppendCredential(ctx, n, cred)
+		q := r.URL.Query()
+		force := q.Get("force") == "true"
+		confirmed := q.Get("confirmed") == "true"
+		if generate := q.Get("generate"); generate != "" {
+			var spec *apitypes.GeneratorSpec
+			spec, err = apitypes.ParseGeneratorSpec(generate)
+			if err != nil {
+				encodeResponseErr(w, &apitypes.Error{
+					StatusCode: http.StatusBadRequest,
+					Type:       apitypes.BadRequestError,
+					Err:        []string{"Invalid generator: " + err.Error()},
+				})
+				return
+			}
+			cred, err = engine.AppendGeneratedCredential(ctx, n, cred, spec, force, confirmed)
+		} else {
+			cred, err = engine.AppendCredential(ctx, n, cred, force, confirmed)
+		}
 		if err != nil {
 			// Rely on logs inside engine for debugging
 			encodeResponseErr(w, err)
@@ -96,3 +124,234 @@ func credentialsPostRoute(engine *logic.Engine, o *observer.Observer) http.Handl
 		}
 	}
 }
+
+func credentialsBatchPostRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		creds := []*logic.PlaintextCredentialEnvelope{}
+
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&creds)
+		if err != nil {
+			log.Printf("error decoding credentials: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n, err := o.Notifier(ctx, 1)
+		if err != nil {
+			log.Printf("error constructing Notifier: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		q := r.URL.Query()
+		force := q.Get("force") == "true"
+		confirmed := q.Get("confirmed") == "true"
+		creds, err = engine.AppendCredentials(ctx, n, creds, force, confirmed)
+		if err != nil {
+			// Rely on logs inside engine for debugging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n.Notify(observer.Finished, "Completed Operation", true)
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(creds)
+		if err != nil {
+			log.Printf("error encoding credential batch create resp: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+	}
+}
+
+func credentialsClonePostRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req := apitypes.EnvironmentCloneRequest{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&req)
+		if err != nil {
+			log.Printf("error decoding environment clone request: %s", err)
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"invalid clone request"},
+			})
+			return
+		}
+
+		n, err := o.Notifier(ctx, 1)
+		if err != nil {
+			log.Printf("error constructing Notifier: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		confirmed := r.URL.Query().Get("confirmed") == "true"
+		clone, err := engine.CloneEnvironment(ctx, n, &req, confirmed)
+		if err != nil {
+			// Rely on logs inside engine for debugging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n.Notify(observer.Finished, "Completed Operation", true)
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(clone)
+		if err != nil {
+			log.Printf("error encoding environment clone resp: %s", err)
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func credentialsHistoryGetRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := ctxutil.WithBreakGlass(r.Context(), r.Header.Get(apitypes.BreakGlassHeader))
+		q := r.URL.Query()
+
+		name := q.Get("name")
+		pe, err := pathexp.Parse(q.Get("pathexp"))
+		if err != nil || name == "" {
+			log.Printf("error constructing history request: %s", err)
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"A valid pathexp and name are required"},
+			})
+			return
+		}
+
+		n, err := o.Notifier(ctx, 1)
+		if err != nil {
+			log.Printf("Error creating parent Notifier: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		creds, err := engine.CredentialHistory(ctx, n, pe, name)
+		if err != nil {
+			// Rely on logs inside engine for debugging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n.Notify(observer.Finished, "Completed Operation", true)
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(creds)
+		if err != nil {
+			log.Printf("error encoding credential history: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+	}
+}
+
+func credentialsSearchGetRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		orgID, err := identity.DecodeFromString(q.Get("org_id"))
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		query := &logic.CredentialQuery{
+			Pattern: q.Get("pattern"),
+			Regex:   q.Get("regex") == "true",
+			Exact:   q.Get("exact") == "true",
+		}
+		if path := q.Get("path"); path != "" {
+			query.PathExp, err = pathexp.ParsePartial(path)
+			if err != nil {
+				encodeResponseErr(w, &apitypes.Error{
+					StatusCode: http.StatusBadRequest,
+					Type:       apitypes.BadRequestError,
+					Err:        []string{"Invalid path: " + err.Error()},
+				})
+				return
+			}
+		}
+
+		locations, err := engine.SearchCredentials(r.Context(), &orgID, query)
+		if err != nil {
+			log.Printf("Error searching credentials: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(locations)
+		if err != nil {
+			log.Printf("error encoding credential search results: %s", err)
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func credentialsWatchGetRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		path := q.Get("path")
+		if path == "" {
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"A path is required"},
+			})
+			return
+		}
+
+		rev, err := engine.AwaitCredentialsChange(r.Context(), path, q.Get("revision"))
+		if err != nil {
+			// Rely on logs inside engine for debugging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(rev)
+		if err != nil {
+			log.Printf("error encoding credentials revision: %s", err)
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func credentialsPrefetchGetRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		err := enc.Encode(engine.PrefetchStats())
+		if err != nil {
+			log.Printf("error encoding prefetch stats: %s", err)
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func credentialsPrefetchPostRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := apitypes.PrefetchRequest{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&req)
+		if err != nil || req.Path == "" {
+			log.Printf("error decoding prefetch request: %s", err)
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"A path is required"},
+			})
+			return
+		}
+
+		engine.WatchCredentials(req.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
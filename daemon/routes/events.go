@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/manifoldco/torus-cli/identity"
+
+	"github.com/manifoldco/torus-cli/daemon/logic"
+)
+
+// eventsRoute streams the events in an org as server-sent events, until the
+// client disconnects.
+func eventsRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		orgID, err := identity.DecodeFromString(r.URL.Query().Get("org_id"))
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		events, err := engine.SubscribeEvents(ctx, &orgID)
+		if err != nil {
+			log.Printf("error subscribing to org events: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		rwf := w.(http.Flusher)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Connection", "keep-alive")
+		rwf.Flush()
+
+		// events is closed when the request's context is done, which
+		// happens when the client disconnects.
+		for evt := range events {
+			b, err := json.Marshal(evt)
+			if err != nil {
+				log.Printf("error marshaling org event: %s", err)
+				continue
+			}
+
+			// Ignore write errors, and let the context tell us when to stop.
+			w.Write([]byte("event: " + evt.Type + "\ndata: "))
+			w.Write(append(b, '\n', '\n'))
+			rwf.Flush()
+		}
+	}
+}
@@ -53,7 +53,7 @@ func keypairsGenerateRoute(engine *logic.Engine, o *observer.Observer) http.Hand
 			return
 		}
 
-		n.Notify(observer.Progress, "Encryption keys uploaded", true)
+		n.NotifyStep(observer.Progress, apitypes.KeypairStepUpload, "Encryption keys uploaded", true)
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
@@ -95,3 +95,41 @@ func keypairsRevokeRoute(engine *logic.Engine, o *observer.Observer) http.Handle
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+func keypairsRenewRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		dec := json.NewDecoder(r.Body)
+		renewReq := keyPairRequest{}
+		err := dec.Decode(&renewReq)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		if renewReq.OrgID == nil {
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"missing or invalid OrgID provided"},
+			})
+			return
+		}
+
+		n, err := o.Notifier(ctx, 0)
+		if err != nil {
+			log.Printf("Error creating Notifier: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		err = engine.RenewKeypairs(ctx, n, renewReq.OrgID)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
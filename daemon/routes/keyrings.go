@@ -0,0 +1,186 @@
+package routes
+
+// This file contains routes related to keyrings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+
+	"github.com/manifoldco/torus-cli/daemon/logic"
+	"github.com/manifoldco/torus-cli/daemon/observer"
+)
+
+func keyringsAuditRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		orgID, err := identity.DecodeFromString(r.URL.Query().Get("org_id"))
+		if err != nil {
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"missing or invalid org_id provided"},
+			})
+			return
+		}
+
+		n, err := o.Notifier(ctx, 1)
+		if err != nil {
+			log.Printf("Error creating Notifier: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		audit, err := engine.AuditKeyrings(ctx, n, &orgID)
+		if err != nil {
+			// Rely on engine for debug logging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n.Notify(observer.Finished, "Completed Operation", true)
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(audit)
+		if err != nil {
+			log.Printf("error encoding keyring audit: %s", err)
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func keyringsRotateRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req := apitypes.KeyringRotationRequest{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&req)
+
+		var pe *pathexp.PathExp
+		if err == nil {
+			pe, err = pathexp.Parse(req.PathExp)
+		}
+		if err != nil {
+			log.Printf("error decoding keyring rotation request: %s", err)
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"missing or invalid pathexp provided"},
+			})
+			return
+		}
+
+		n, err := o.Notifier(ctx, 1)
+		if err != nil {
+			log.Printf("Error creating Notifier: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		rotation, err := engine.RotateKeyring(ctx, n, pe)
+		if err != nil {
+			// Rely on engine for debug logging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n.Notify(observer.Finished, "Completed Operation", true)
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(rotation)
+		if err != nil {
+			log.Printf("error encoding keyring rotation: %s", err)
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+// keyringsFreezeRoute handles freezing or unfreezing keyrings with fn.
+func keyringsFreezeRoute(fn func(context.Context, *apitypes.KeyringFreezeRequest) (*apitypes.KeyringFreezeResult, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := apitypes.KeyringFreezeRequest{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&req)
+		if err == nil && req.OrgID == nil {
+			err = errors.New("missing org_id")
+		}
+		if err == nil {
+			_, err = pathexp.Parse(req.PathExp)
+		}
+		if err != nil {
+			log.Printf("error decoding keyring freeze request: %s", err)
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"missing or invalid org_id or pathexp provided"},
+			})
+			return
+		}
+
+		result, err := fn(r.Context(), &req)
+		if err != nil {
+			// Rely on engine for debug logging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(result)
+		if err != nil {
+			log.Printf("error encoding keyring freeze result: %s", err)
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func keyringsMembersRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		q := r.URL.Query()
+
+		orgID, err := identity.DecodeFromString(q.Get("org_id"))
+		var pe *pathexp.PathExp
+		if err == nil {
+			pe, err = pathexp.Parse(q.Get("pathexp"))
+		}
+		if err != nil {
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"missing or invalid org_id or pathexp provided"},
+			})
+			return
+		}
+
+		n, err := o.Notifier(ctx, 1)
+		if err != nil {
+			log.Printf("Error creating Notifier: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		keyrings, err := engine.KeyringMembers(ctx, n, &orgID, pe)
+		if err != nil {
+			// Rely on engine for debug logging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n.Notify(observer.Finished, "Completed Operation", true)
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(keyrings)
+		if err != nil {
+			log.Printf("error encoding keyring members: %s", err)
+			encodeResponseErr(w, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-zoo/bone"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+
+	"github.com/manifoldco/torus-cli/daemon/lease"
+)
+
+// leaseNotFound is returned for a lease that doesn't exist, has expired, or
+// was asked for with the wrong token.
+var leaseNotFound = &apitypes.Error{
+	StatusCode: http.StatusNotFound,
+	Type:       apitypes.NotFoundError,
+	Err:        []string{"Lease not found, or it has expired"},
+}
+
+func leasesCreateRoute(store *lease.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dec := json.NewDecoder(r.Body)
+		req := apitypes.LeaseRequest{}
+		err := dec.Decode(&req)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		vars := make([]lease.Var, len(req.Vars))
+		for i, v := range req.Vars {
+			vars[i] = lease.Var{Name: v.Name, Value: v.Value}
+		}
+
+		l, err := store.Create(vars, time.Duration(req.TTL)*time.Second)
+		if err != nil {
+			log.Printf("error creating lease: %s", err)
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{err.Error()},
+			})
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(&apitypes.Lease{ID: l.ID, Token: l.Token, ExpiresAt: l.ExpiresAt})
+		if err != nil {
+			log.Printf("error encoding lease: %s", err)
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func leasesGetRoute(store *lease.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars, err := store.Get(bone.GetValue(r, "id"), r.Header.Get(apitypes.LeaseTokenHeader))
+		if err != nil {
+			encodeResponseErr(w, leaseNotFound)
+			return
+		}
+
+		resp := make([]apitypes.LeaseVar, len(vars))
+		for i, v := range vars {
+			resp[i] = apitypes.LeaseVar{Name: v.Name, Value: v.Value}
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(resp)
+		if err != nil {
+			log.Printf("error encoding lease vars: %s", err)
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func leasesRevokeRoute(store *lease.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := store.Revoke(bone.GetValue(r, "id"), r.Header.Get(apitypes.LeaseTokenHeader))
+		if err != nil {
+			encodeResponseErr(w, leaseNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
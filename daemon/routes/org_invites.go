@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-zoo/bone"
 
+	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/identity"
 
 	"github.com/manifoldco/torus-cli/daemon/logic"
@@ -50,3 +51,45 @@ func orgInvitesApproveRoute(engine *logic.Engine, o *observer.Observer) http.Han
 		}
 	}
 }
+
+func orgInvitesApproveAllRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req := apitypes.InvitesApproveRequest{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&req)
+		if err != nil || req.OrgID == nil {
+			log.Printf("error decoding invites approve request: %s", err)
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"missing or invalid org id provided"},
+			})
+			return
+		}
+
+		n, err := o.Notifier(ctx, 1)
+		if err != nil {
+			log.Printf("Error creating Notififer: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		approvals, err := engine.ApproveInvites(ctx, n, req.OrgID)
+		if err != nil {
+			// Allow engine to log debugs
+			encodeResponseErr(w, err)
+			return
+		}
+
+		n.Notify(observer.Finished, "Completed", true)
+		enc := json.NewEncoder(w)
+		err = enc.Encode(approvals)
+		if err != nil {
+			log.Printf("error encoding invites approve resp: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package routes
+
+// This file contains routes related to orgs
+
+import (
+	"net/http"
+
+	"github.com/go-zoo/bone"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+
+	"github.com/manifoldco/torus-cli/daemon/logic"
+)
+
+func orgsDeleteRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orgID, err := identity.DecodeFromString(bone.GetValue(r, "id"))
+		if err != nil {
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"invalid org id provided"},
+			})
+			return
+		}
+
+		err = engine.DeleteOrg(r.Context(), &orgID)
+		if err != nil {
+			// Rely on engine for debug logging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,112 @@
+package routes
+
+// This file contains routes related to policies
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-zoo/bone"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+
+	"github.com/manifoldco/torus-cli/daemon/logic"
+)
+
+func policiesCreateRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dec := json.NewDecoder(r.Body)
+		policy := primitive.Policy{}
+		err := dec.Decode(&policy)
+		if err != nil {
+			log.Printf("Error decoding policy: %s", err)
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"invalid policy provided"},
+			})
+			return
+		}
+
+		created, err := engine.CreatePolicy(r.Context(), &policy)
+		if err != nil {
+			// Rely on engine for debug logging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(created)
+		if err != nil {
+			log.Printf("error encoding policy: %s", err)
+		}
+	}
+}
+
+func policySignaturesRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orgID, err := identity.DecodeFromString(r.URL.Query().Get("org_id"))
+		if err != nil {
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"missing or invalid org_id provided"},
+			})
+			return
+		}
+
+		signatures, err := engine.PolicySignatures(r.Context(), &orgID)
+		if err != nil {
+			// Rely on engine for debug logging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(signatures)
+		if err != nil {
+			log.Printf("error encoding policy signatures: %s", err)
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func policySignRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orgID, err := identity.DecodeFromString(r.URL.Query().Get("org_id"))
+		if err != nil {
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"missing or invalid org_id provided"},
+			})
+			return
+		}
+
+		policyID, err := identity.DecodeFromString(bone.GetValue(r, "id"))
+		if err != nil {
+			encodeResponseErr(w, &apitypes.Error{
+				StatusCode: http.StatusBadRequest,
+				Type:       apitypes.BadRequestError,
+				Err:        []string{"invalid policy id provided"},
+			})
+			return
+		}
+
+		policy, err := engine.SignPolicy(r.Context(), &orgID, &policyID)
+		if err != nil {
+			// Rely on engine for debug logging
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(policy)
+		if err != nil {
+			log.Printf("error encoding policy: %s", err)
+		}
+	}
+}
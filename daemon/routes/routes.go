@@ -29,20 +29,51 @@ func NewRouteMux(c *config.Config, s session.Session, db *db.DB,
 	mux.PostFunc("/login", loginRoute(lEngine))
 	mux.PostFunc("/logout", logoutRoute(lEngine))
 	mux.GetFunc("/session", sessionRoute(s))
+	mux.PostFunc("/session/keychain", keychainRoute(lEngine))
 	mux.GetFunc("/self", selfRoute(s))
-	mux.PatchFunc("/self", updateSelfRoute(client, s, lEngine))
+	mux.PatchFunc("/self", updateSelfRoute(client, s))
+	mux.PostFunc("/self/password", passwordRoute(lEngine))
+	mux.PostFunc("/self/recovery-codes", recoveryCodesRoute(lEngine))
+	mux.PostFunc("/recovery", recoveryRoute(lEngine))
 
 	mux.PostFunc("/machines", machinesCreateRoute(client, s, lEngine, o))
 
 	mux.PostFunc("/keypairs/generate", keypairsGenerateRoute(lEngine, o))
 	mux.PostFunc("/keypairs/revoke", keypairsRevokeRoute(lEngine, o))
+	mux.PostFunc("/keypairs/renew", keypairsRenewRoute(lEngine, o))
+
+	mux.GetFunc("/keyrings/audit", keyringsAuditRoute(lEngine, o))
+	mux.GetFunc("/keyrings/members", keyringsMembersRoute(lEngine, o))
+	mux.PostFunc("/keyrings/rotate", keyringsRotateRoute(lEngine, o))
+	mux.PostFunc("/keyrings/freeze", keyringsFreezeRoute(lEngine.FreezeKeyrings))
+	mux.PostFunc("/keyrings/unfreeze", keyringsFreezeRoute(lEngine.UnfreezeKeyrings))
 
 	mux.GetFunc("/credentials", credentialsGetRoute(lEngine, o))
 	mux.PostFunc("/credentials", credentialsPostRoute(lEngine, o))
+	mux.PostFunc("/credentials/batch", credentialsBatchPostRoute(lEngine, o))
+	mux.PostFunc("/credentials/clone", credentialsClonePostRoute(lEngine, o))
+	mux.GetFunc("/credentials/history", credentialsHistoryGetRoute(lEngine, o))
+	mux.GetFunc("/credentials/watch", credentialsWatchGetRoute(lEngine))
+	mux.GetFunc("/credentials/search", credentialsSearchGetRoute(lEngine))
+	mux.GetFunc("/credentials/prefetch", credentialsPrefetchGetRoute(lEngine))
+	mux.PostFunc("/credentials/prefetch", credentialsPrefetchPostRoute(lEngine))
+
+	mux.PostFunc("/leases", leasesCreateRoute(lEngine.Leases))
+	mux.GetFunc("/leases/:id", leasesGetRoute(lEngine.Leases))
+	mux.DeleteFunc("/leases/:id", leasesRevokeRoute(lEngine.Leases))
+
+	mux.DeleteFunc("/orgs/:id", orgsDeleteRoute(lEngine))
 
+	mux.PostFunc("/policies", policiesCreateRoute(lEngine))
+	mux.GetFunc("/policies/signatures", policySignaturesRoute(lEngine))
+	mux.PostFunc("/policies/:id/sign", policySignRoute(lEngine))
+
+	mux.PostFunc("/org-invites/approve", orgInvitesApproveAllRoute(lEngine, o))
 	mux.PostFunc("/org-invites/:id/approve",
 		orgInvitesApproveRoute(lEngine, o))
 
+	mux.GetFunc("/events", eventsRoute(lEngine))
+
 	mux.GetFunc("/worklog", worklogListRoute(lEngine, o))
 	mux.GetFunc("/worklog/:id", worklogGetRoute(lEngine, o))
 	mux.PostFunc("/worklog/:id", worklogResolveRoute(lEngine, o))
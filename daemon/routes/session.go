@@ -10,7 +10,6 @@ import (
 	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/envelope"
 	"github.com/manifoldco/torus-cli/identity"
-	"github.com/manifoldco/torus-cli/primitive"
 
 	"github.com/manifoldco/torus-cli/daemon/crypto"
 	"github.com/manifoldco/torus-cli/daemon/db"
@@ -62,6 +61,18 @@ func loginRoute(engine *logic.Engine) http.HandlerFunc {
 	}
 }
 
+func keychainRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := engine.Session.SaveToKeychain()
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
 func logoutRoute(engine *logic.Engine) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -110,12 +121,7 @@ type updateEmail struct {
 	Email string `json:"email"`
 }
 
-type updatePassword struct {
-	Password *primitive.UserPassword `json:"password"`
-	Master   *primitive.MasterKey    `json:"master"`
-}
-
-func updateSelfRoute(client *registry.Client, s session.Session, e *logic.Engine) http.HandlerFunc {
+func updateSelfRoute(client *registry.Client, s session.Session) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		c := r.Context()
 		dec := json.NewDecoder(r.Body)
@@ -134,7 +140,16 @@ func updateSelfRoute(client *registry.Client, s session.Session, e *logic.Engine
 			encodeResponseErr(w, err)
 			return
 		}
-		if req.Name == "" && req.Email == "" && req.Password == "" {
+		// Changing the password requires the current one, so it is only
+		// done by passwordRoute.
+		if req.Password != "" {
+			encodeResponseErr(w, &apitypes.Error{
+				Type: apitypes.BadRequestError,
+				Err:  []string{"password can't be changed here; use /self/password"},
+			})
+			return
+		}
+		if req.Name == "" && req.Email == "" {
 			encodeResponseErr(w, &apitypes.Error{
 				Type: apitypes.BadRequestError,
 				Err:  []string{"missing profile fields"},
@@ -163,22 +178,6 @@ func updateSelfRoute(client *registry.Client, s session.Session, e *logic.Engine
 			result = envelope
 		}
 
-		if req.Password != "" {
-			// Encrypt the new password and re-encrypt the original master key
-			passwordObj, masterObj, err := e.ChangePassword(c, req.Password)
-			if err != nil {
-				log.Printf("Error generating password object: %s", err)
-				encodeResponseErr(w, err)
-				return
-			}
-			envelope, err := client.Users.Update(c, updatePassword{Password: passwordObj, Master: masterObj})
-			if err != nil {
-				encodeResponseErr(w, err)
-				return
-			}
-			result = envelope
-		}
-
 		// Update the local session to have the new user details
 		s.SetIdentity(apitypes.UserSession, result, result)
 
@@ -190,6 +189,72 @@ func updateSelfRoute(client *registry.Client, s session.Session, e *logic.Engine
 	}
 }
 
+func passwordRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := r.Context()
+		dec := json.NewDecoder(r.Body)
+
+		req := apitypes.PasswordChange{}
+		err := dec.Decode(&req)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		user, err := engine.Session.ChangePassword(c, req.Current, req.Password)
+		if err != nil {
+			log.Printf("Could not change password: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(user)
+		if err != nil {
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func recoveryCodesRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		codes, err := engine.Session.GenerateRecoveryCodes(r.Context())
+		if err != nil {
+			log.Printf("Could not generate recovery codes: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		err = enc.Encode(codes)
+		if err != nil {
+			encodeResponseErr(w, err)
+		}
+	}
+}
+
+func recoveryRoute(engine *logic.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dec := json.NewDecoder(r.Body)
+
+		req := apitypes.RecoveryRequest{}
+		err := dec.Decode(&req)
+		if err != nil {
+			encodeResponseErr(w, err)
+			return
+		}
+
+		err = engine.Session.Recover(r.Context(), &req)
+		if err != nil {
+			log.Printf("Could not recover account: %s", err)
+			encodeResponseErr(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
 func selfRoute(s session.Session) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		enc := json.NewEncoder(w)
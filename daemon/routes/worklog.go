@@ -23,7 +23,23 @@ func worklogListRoute(engine *logic.Engine, o *observer.Observer) http.HandlerFu
 			return
 		}
 
-		items, err := engine.Worklog.List(ctx, &orgID, apitypes.AnyWorklogType)
+		itemType := apitypes.AnyWorklogType
+		if names, ok := r.URL.Query()["type"]; ok {
+			itemType = 0
+			for _, name := range names {
+				t, err := apitypes.ParseWorklogType(name)
+				if err != nil {
+					encodeResponseErr(w, &apitypes.Error{
+						Type: apitypes.BadRequestError,
+						Err:  []string{err.Error()},
+					})
+					return
+				}
+				itemType |= t
+			}
+		}
+
+		items, err := engine.Worklog.List(ctx, &orgID, itemType)
 		if err != nil {
 			log.Printf("error getting worklog list: %s", err)
 			encodeResponseErr(w, err)
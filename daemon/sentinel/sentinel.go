@@ -0,0 +1,207 @@
+// Package sentinel continuously verifies the daemon's view of the world, so
+// tampering is noticed when it happens rather than the next time a secret is
+// shared.
+//
+// On every pass, the sentinel re-verifies the claim chains of each org the
+// session belongs to, looks for unexpected members of the keyrings it guards,
+// and checks that the offline cache can still be read. New anomalies are
+// alerted on once, for as long as they persist.
+package sentinel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"log/syslog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/manifoldco/torus-cli/pathexp"
+
+	"github.com/manifoldco/torus-cli/daemon/logic"
+)
+
+// DefaultInterval is how often the checks are run, when no interval is set.
+const DefaultInterval = 15 * time.Minute
+
+// webhookTimeout bounds a single webhook delivery.
+const webhookTimeout = 10 * time.Second
+
+// ErrAnomaly is returned by Run when Exit is set and an anomaly is found.
+var ErrAnomaly = errors.New("sentinel found anomalies")
+
+// Config controls what the sentinel checks, and how it alerts.
+type Config struct {
+	// Interval is the time between passes.
+	Interval time.Duration
+
+	// Paths are the keyring path expressions to watch for unexpected
+	// members.
+	Paths []*pathexp.PathExp
+
+	// Webhook is a URL anomalies are POSTed to as JSON.
+	Webhook string
+
+	// Syslog sends anomalies to the system log.
+	Syslog bool
+
+	// Exit stops the sentinel after the first pass that finds an anomaly.
+	Exit bool
+}
+
+// Alert is the body POSTed to the webhook.
+type Alert struct {
+	Hostname  string                  `json:"hostname"`
+	Time      time.Time               `json:"time"`
+	Anomalies []logic.SentinelAnomaly `json:"anomalies"`
+}
+
+// Sentinel periodically runs the engine's sentinel checks.
+type Sentinel struct {
+	engine *logic.Engine
+	config Config
+	syslog *syslog.Writer
+	client *http.Client
+
+	// seen holds the keys of the anomalies found on the last pass.
+	seen map[string]bool
+}
+
+// New returns a Sentinel for the given engine.
+func New(engine *logic.Engine, cfg Config) (*Sentinel, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+
+	s := &Sentinel{
+		engine: engine,
+		config: cfg,
+		client: &http.Client{Timeout: webhookTimeout},
+		seen:   make(map[string]bool),
+	}
+
+	if cfg.Syslog {
+		w, err := syslog.New(syslog.LOG_WARNING|syslog.LOG_DAEMON, "torus")
+		if err != nil {
+			return nil, fmt.Errorf("Could not connect to syslog: %s", err)
+		}
+		s.syslog = w
+	}
+
+	return s, nil
+}
+
+// Run runs the checks every interval until ctx is done. If Exit is set, it
+// returns ErrAnomaly after the first pass that finds an anomaly, or that
+// can't be completed.
+func (s *Sentinel) Run(ctx context.Context) error {
+	if s.syslog != nil {
+		defer s.syslog.Close()
+	}
+
+	log.Printf("Sentinel checking every %s", s.config.Interval)
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		anomalies, err := s.engine.SentinelCheck(ctx, s.config.Paths)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			log.Printf("Error running sentinel checks: %s", err)
+			anomalies = append(anomalies, checkFailed(err))
+		}
+
+		if found := s.fresh(anomalies); len(found) > 0 {
+			s.alert(found)
+			if s.config.Exit {
+				return ErrAnomaly
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkFailed returns the anomaly for a pass of the checks failing with err.
+// A pass that can't complete may be hiding the very tampering it looks for,
+// such as a registry that refuses to serve claims, so it is alerted on like
+// any other anomaly.
+func checkFailed(err error) logic.SentinelAnomaly {
+	return logic.SentinelAnomaly{
+		Check:   logic.IncompleteCheck,
+		Subject: "sentinel",
+		Problem: "could not complete the checks: " + err.Error(),
+	}
+}
+
+// fresh returns the anomalies that were not found on the last pass. Anomalies
+// that have gone away are forgotten, so they are alerted on again if they
+// come back.
+func (s *Sentinel) fresh(anomalies []logic.SentinelAnomaly) []logic.SentinelAnomaly {
+	seen := make(map[string]bool, len(anomalies))
+	var found []logic.SentinelAnomaly
+	for _, a := range anomalies {
+		key := a.Key()
+		if !s.seen[key] && !seen[key] {
+			found = append(found, a)
+		}
+		seen[key] = true
+	}
+
+	s.seen = seen
+	return found
+}
+
+// alert reports anomalies to the log, and to syslog and the webhook, if set.
+// Delivery errors are logged, as the checks keep running regardless.
+func (s *Sentinel) alert(anomalies []logic.SentinelAnomaly) {
+	for _, a := range anomalies {
+		log.Printf("Sentinel anomaly: %s", a.String())
+		if s.syslog != nil {
+			if err := s.syslog.Warning(a.String()); err != nil {
+				log.Printf("Error writing to syslog: %s", err)
+			}
+		}
+	}
+
+	if s.config.Webhook != "" {
+		if err := s.post(anomalies); err != nil {
+			log.Printf("Error sending sentinel webhook: %s", err)
+		}
+	}
+}
+
+func (s *Sentinel) post(anomalies []logic.SentinelAnomaly) error {
+	hostname, _ := os.Hostname()
+	b, err := json.Marshal(&Alert{
+		Hostname:  hostname,
+		Time:      time.Now().UTC(),
+		Anomalies: anomalies,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.config.Webhook, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package sentinel
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/daemon/logic"
+)
+
+func TestFresh(t *testing.T) {
+	a := logic.SentinelAnomaly{Check: logic.ClaimsCheck, Subject: "org a", Problem: "bad"}
+	b := logic.SentinelAnomaly{Check: logic.OfflineCacheCheck, Subject: "/a/b", Problem: "corrupt"}
+
+	s := &Sentinel{seen: make(map[string]bool)}
+
+	passes := []struct {
+		anomalies []logic.SentinelAnomaly
+		fresh     int
+	}{
+		{[]logic.SentinelAnomaly{a, a}, 1},
+		{[]logic.SentinelAnomaly{a, b}, 1},
+		{[]logic.SentinelAnomaly{a, b}, 0},
+		{[]logic.SentinelAnomaly{b}, 0},
+		{[]logic.SentinelAnomaly{a, b}, 1}, // a came back
+	}
+
+	for i, p := range passes {
+		if got := len(s.fresh(p.anomalies)); got != p.fresh {
+			t.Errorf("pass %d: got %d fresh anomalies, want %d", i, got, p.fresh)
+		}
+	}
+}
+
+func TestPost(t *testing.T) {
+	var alert Alert
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	s := &Sentinel{config: Config{Webhook: srv.URL}, client: http.DefaultClient}
+	anomalies := []logic.SentinelAnomaly{
+		{Check: logic.KeyringMembersCheck, Subject: "/o/p/e/*/*/*", Problem: "unexpected"},
+	}
+
+	if err := s.post(anomalies); err != nil {
+		t.Fatal(err)
+	}
+	if len(alert.Anomalies) != 1 || alert.Anomalies[0] != anomalies[0] {
+		t.Errorf("webhook got %+v", alert.Anomalies)
+	}
+
+	status = http.StatusInternalServerError
+	if err := s.post(anomalies); err == nil {
+		t.Error("expected an error for a failed delivery")
+	}
+}
+
+func TestCheckFailed(t *testing.T) {
+	s := &Sentinel{seen: make(map[string]bool)}
+	failed := checkFailed(errors.New("registry unavailable"))
+
+	if failed.Check != logic.IncompleteCheck {
+		t.Errorf("wrong check: %s", failed.Check)
+	}
+
+	passes := []struct {
+		anomalies []logic.SentinelAnomaly
+		fresh     int
+	}{
+		{[]logic.SentinelAnomaly{failed}, 1},
+		{[]logic.SentinelAnomaly{failed}, 0},
+		{nil, 0},
+		{[]logic.SentinelAnomaly{failed}, 1}, // failing again after a good pass
+	}
+
+	for i, p := range passes {
+		if got := len(s.fresh(p.anomalies)); got != p.fresh {
+			t.Errorf("pass %d: got %d fresh anomalies, want %d", i, got, p.fresh)
+		}
+	}
+}
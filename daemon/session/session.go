@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/base64"
@@ -25,15 +26,18 @@ type session struct {
 	// sensitive values
 	token      string
 	passphrase []byte
+
+	authenticated time.Time
 }
 
 // Session is the interface for access to secure session details.
 type Session interface {
 	Type() apitypes.SessionType
-	Set(apitypes.SessionType, envelope.Envelope, envelope.Envelope, []byte, string) error
+	Set(apitypes.SessionType, envelope.Envelope, envelope.Envelope, []byte, string, time.Time) error
 	SetIdentity(apitypes.SessionType, envelope.Envelope, envelope.Envelope) error
 	ID() *identity.ID
 	AuthID() *identity.ID
+	AuthTime() time.Time
 	Token() string
 	Passphrase() []byte
 	MasterKey() (*base64.Value, error)
@@ -82,6 +86,16 @@ func (s *session) AuthID() *identity.ID {
 	return s.auth.GetID()
 }
 
+// AuthTime returns when the session was last given credentials, by logging in
+// or changing password. It is the zero time when not logged in, or when the
+// credentials came from the keychain.
+func (s *session) AuthTime() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.authenticated
+}
+
 // Token returns the auth token stored in this session.
 func (s *session) Token() string {
 	s.mutex.Lock()
@@ -143,11 +157,13 @@ func checkSessionType(sessionType apitypes.SessionType, identity, auth envelope.
 	return nil
 }
 
-// Set atomically sets all relevant session details.
+// Set atomically sets all relevant session details. authenticated is when the
+// user or machine gave its credentials, or the zero time if it didn't, such
+// as when logging in from the keychain.
 //
 // It returns an error if any values are empty.
 func (s *session) Set(sessionType apitypes.SessionType, identity, auth envelope.Envelope,
-	passphrase []byte, token string) error {
+	passphrase []byte, token string, authenticated time.Time) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -169,6 +185,7 @@ func (s *session) Set(sessionType apitypes.SessionType, identity, auth envelope.
 	s.token = token
 	s.identity = identity
 	s.auth = auth
+	s.authenticated = authenticated
 
 	return nil
 }
@@ -235,5 +252,6 @@ func (s *session) Logout() error {
 	s.auth = nil
 	s.token = ""
 	s.passphrase = []byte{}
+	s.authenticated = time.Time{}
 	return nil
 }
@@ -21,6 +21,7 @@ import (
 	"github.com/manifoldco/torus-cli/apitypes"
 	"github.com/manifoldco/torus-cli/config"
 
+	"github.com/manifoldco/torus-cli/daemon/ctxutil"
 	"github.com/manifoldco/torus-cli/daemon/db"
 	"github.com/manifoldco/torus-cli/daemon/logic"
 	"github.com/manifoldco/torus-cli/daemon/observer"
@@ -87,7 +88,7 @@ func CreateHTTPTransport(cfg *config.Config) *http.Transport {
 func (p *AuthProxy) Listen() error {
 	mux := bone.New()
 	proxy := &httputil.ReverseProxy{
-		Transport: p.t,
+		Transport: &rejectionTransport{RoundTripper: p.t, rejected: p.logic.Session.TokenRejected},
 		Director: func(r *http.Request) {
 			r.URL.Scheme = p.u.Scheme
 			r.URL.Host = p.u.Host
@@ -110,7 +111,12 @@ func (p *AuthProxy) Listen() error {
 	mux.SubRoute("/v1", routes.NewRouteMux(p.c, p.sess, p.db, p.t, p.o, p.client, p.logic))
 
 	h := httpdown.HTTP{}
-	p.s = h.Serve(&http.Server{Handler: requestIDHandler(loggingHandler(mux))}, p.l)
+	var handler http.Handler = mux
+	if p.c.ReadOnly {
+		handler = readOnlyHandler(handler)
+	}
+
+	p.s = h.Serve(&http.Server{Handler: requestIDHandler(loggingHandler(handler))}, p.l)
 
 	return p.s.Wait()
 }
@@ -127,11 +133,62 @@ func (p *AuthProxy) Addr() string {
 	return p.l.Addr().String()
 }
 
+// rejectionTransport calls rejected with the token of each proxied request the
+// registry rejects as unauthorized, so a session revoked remotely is noticed.
+type rejectionTransport struct {
+	http.RoundTripper
+	rejected func(token string)
+}
+
+func (t *rejectionTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := t.RoundTripper.RoundTrip(r)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		t.rejected(registry.BearerToken(r))
+	}
+	return resp, err
+}
+
+// readOnlyPaths are the daemon endpoints a read-only daemon serves despite
+// their method, as they don't change anything in the registry beyond the
+// daemon's own session.
+var readOnlyPaths = map[string]bool{
+	"/v1/login":                true,
+	"/v1/logout":               true,
+	"/v1/session/keychain":     true,
+	"/v1/credentials/prefetch": true,
+}
+
+// readOnlyHandler rejects every request that could change something in the
+// registry, whether proxied or made through the daemon. Leases of dynamic
+// secrets are still served, as reading them is how they are issued.
+func readOnlyHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" || r.Method == "HEAD" || readOnlyPaths[r.URL.Path] ||
+			r.URL.Path == "/v1/leases" || strings.HasPrefix(r.URL.Path, "/v1/leases/") {
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		log.Printf("Rejected %s %s: the daemon is read-only", r.Method, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(&apitypes.Error{
+			Type: apitypes.ReadOnlyError,
+			Err:  []string{"the daemon is running in read-only mode"},
+		})
+	})
+}
+
 func loggingHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		p := r.URL.Path
+		start := time.Now()
 		next.ServeHTTP(w, r)
-		log.Printf("%s %s", r.Method, p)
+
+		// The request ID matches the CLI's trace, when run with --verbose.
+		id, _ := r.Context().Value(observer.CtxRequestID).(string)
+		log.Printf("%s %s %s %s", id, r.Method, p, time.Since(start))
 	})
 }
 
@@ -142,6 +199,7 @@ func requestIDHandler(next http.Handler) http.Handler {
 			id = uuid.NewV4().String()
 		}
 		ctx := context.WithValue(r.Context(), observer.CtxRequestID, id)
+		ctx = ctxutil.WithMFACode(ctx, r.Header.Get(apitypes.MFACodeHeader))
 
 		r = r.WithContext(ctx)
 		next.ServeHTTP(w, r)
@@ -0,0 +1,271 @@
+package socket
+
+import (
+	"errors"
+	"log"
+	"net"
+	"strconv"
+
+	"github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/rpc"
+
+	"github.com/manifoldco/torus-cli/daemon/logic"
+	"github.com/manifoldco/torus-cli/daemon/observer"
+	"github.com/manifoldco/torus-cli/daemon/session"
+)
+
+// RPCServer exposes the daemon's session and credential operations over gRPC
+// on a domain socket, for use by other programs through the rpc package.
+type RPCServer struct {
+	l     net.Listener
+	s     *grpc.Server
+	o     *observer.Observer
+	sess  session.Session
+	logic *logic.Engine
+
+	readOnly bool
+}
+
+// NewRPCServer returns a new RPCServer listening on the configured gRPC
+// domain socket. Like NewAuthProxy, the socket is shared with the user's
+// group if groupShared is true.
+func NewRPCServer(c *config.Config, sess session.Session, logic *logic.Engine,
+	groupShared bool) (*RPCServer, error) {
+
+	l, err := makeSocket(c.GRPCSocketPath, groupShared)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &RPCServer{
+		l:     l,
+		o:     observer.New(),
+		sess:  sess,
+		logic: logic,
+
+		readOnly: c.ReadOnly,
+	}
+
+	srv.s = grpc.NewServer(rpc.ServerCodec(), grpc.UnaryInterceptor(rpcLogger))
+	rpc.RegisterDaemonServer(srv.s, srv)
+	return srv, nil
+}
+
+// Listen serves requests until the RPCServer is closed.
+func (s *RPCServer) Listen() error {
+	go s.o.Start()
+	return s.s.Serve(s.l)
+}
+
+// Close stops the RPCServer, after finishing any in flight requests.
+func (s *RPCServer) Close() error {
+	s.s.GracefulStop()
+	s.o.Stop()
+	return nil
+}
+
+// Addr returns the domain socket the RPCServer is listening on.
+func (s *RPCServer) Addr() string {
+	return s.l.Addr().String()
+}
+
+// Session implements rpc.DaemonServer.
+func (s *RPCServer) Session(ctx context.Context, req *rpc.SessionRequest) (*rpc.SessionResponse, error) {
+	resp := &rpc.SessionResponse{Type: s.sess.Type()}
+	if resp.Type == apitypes.NotLoggedIn {
+		return resp, nil
+	}
+
+	resp.ID = s.sess.ID()
+	resp.AuthID = s.sess.AuthID()
+
+	switch ident := s.sess.Self().Identity.(type) {
+	case *envelope.User:
+		resp.Name = ident.Body.Username
+	case *envelope.Machine:
+		resp.Name = ident.Body.Name
+	}
+
+	return resp, nil
+}
+
+// GetCredentials implements rpc.DaemonServer.
+func (s *RPCServer) GetCredentials(ctx context.Context, req *rpc.GetCredentialsRequest) (*rpc.GetCredentialsResponse, error) {
+	if (req.Path == "") == (req.PathExp == "") {
+		return nil, grpc.Errorf(codes.InvalidArgument, "one of path or pathexp is required")
+	}
+
+	ctx, n, err := s.notifier(ctx)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	resp := &rpc.GetCredentialsResponse{}
+	var creds []logic.PlaintextCredentialEnvelope
+	if req.Path != "" {
+		creds, resp.CachedAt, err = s.logic.RetrievePathCredentials(ctx, n, req.Path, req.Offline)
+	} else {
+		creds, err = s.logic.RetrieveCredentials(ctx, n, nil, &req.PathExp)
+	}
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	n.Notify(observer.Finished, "Completed Operation", true)
+
+	resp.Credentials = make([]rpc.Credential, len(creds))
+	for i, cred := range creds {
+		c, err := fromPlaintext(&cred)
+		if err != nil {
+			return nil, rpcError(err)
+		}
+		resp.Credentials[i] = *c
+	}
+
+	return resp, nil
+}
+
+// SetCredential implements rpc.DaemonServer.
+func (s *RPCServer) SetCredential(ctx context.Context, req *rpc.SetCredentialRequest) (*rpc.SetCredentialResponse, error) {
+	if s.readOnly {
+		return nil, grpc.Errorf(codes.PermissionDenied, "the daemon is running in read-only mode")
+	}
+
+	c := req.Credential
+	if c == nil || c.Name == "" || c.PathExp == nil || c.OrgID == nil || c.ProjectID == nil {
+		return nil, grpc.Errorf(codes.InvalidArgument,
+			"a credential with a name, pathexp, org_id and project_id is required")
+	}
+
+	cred, err := toPlaintext(c)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "invalid credential value: %s", err)
+	}
+
+	ctx, n, err := s.notifier(ctx)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	cred, err = s.logic.AppendCredential(ctx, n, cred, req.Force, req.Confirmed)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	n.Notify(observer.Finished, "Completed Operation", true)
+
+	out, err := fromPlaintext(cred)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	return &rpc.SetCredentialResponse{Credential: out}, nil
+}
+
+// notifier tags ctx with a request id, and returns a Notifier for it. gRPC
+// requests have no progress events to report to, but the engine requires a
+// notifier for every operation.
+func (s *RPCServer) notifier(ctx context.Context) (context.Context, *observer.Notifier, error) {
+	ctx = context.WithValue(ctx, observer.CtxRequestID, uuid.NewV4().String())
+	n, err := s.o.Notifier(ctx, 1)
+	return ctx, n, err
+}
+
+func rpcLogger(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+
+	resp, err := handler(ctx, req)
+	log.Printf("RPC %s", info.FullMethod)
+	return resp, err
+}
+
+// rpcError converts errors from the engine to gRPC errors with a matching
+// status code.
+func rpcError(err error) error {
+	apiErr, ok := err.(*apitypes.Error)
+	if !ok {
+		return grpc.Errorf(codes.Internal, "%s", err)
+	}
+
+	code := codes.Unknown
+	switch apiErr.Type {
+	case apitypes.BadRequestError:
+		code = codes.InvalidArgument
+	case apitypes.UnauthorizedError:
+		code = codes.Unauthenticated
+	case apitypes.NotFoundError:
+		code = codes.NotFound
+	case apitypes.ConflictError:
+		code = codes.Aborted
+	case apitypes.ConfirmationRequiredError:
+		code = codes.FailedPrecondition
+	case apitypes.InternalServerError:
+		code = codes.Internal
+	case apitypes.NotImplementedError:
+		code = codes.Unimplemented
+	}
+
+	return grpc.Errorf(code, "%s", apiErr)
+}
+
+// fromPlaintext converts a credential from the engine for an rpc response.
+// The engine holds the credential's value in its JSON encoded form.
+func fromPlaintext(cred *logic.PlaintextCredentialEnvelope) (*rpc.Credential, error) {
+	value := &apitypes.CredentialValue{}
+	err := value.UnmarshalJSON([]byte(strconv.Quote(cred.Body.Value)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpc.Credential{
+		ID:                cred.ID,
+		Name:              cred.Body.Name,
+		OrgID:             cred.Body.OrgID,
+		ProjectID:         cred.Body.ProjectID,
+		PathExp:           cred.Body.PathExp,
+		Value:             value,
+		CredentialMeta:    cred.Body.CredentialMeta,
+		CredentialVersion: cred.Body.CredentialVersion,
+	}, nil
+}
+
+// toPlaintext converts a credential from an rpc request for the engine.
+func toPlaintext(c *rpc.Credential) (*logic.PlaintextCredentialEnvelope, error) {
+	if c.Value == nil {
+		return nil, errors.New("missing value")
+	}
+
+	b, err := c.Value.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	value, err := strconv.Unquote(string(b))
+	if err != nil {
+		return nil, err
+	}
+
+	state := "set"
+	if c.Value.IsUnset() {
+		state = "unset"
+	}
+
+	return &logic.PlaintextCredentialEnvelope{
+		Version: 3,
+		Body: &logic.PlaintextCredential{
+			Name:           c.Name,
+			OrgID:          c.OrgID,
+			ProjectID:      c.ProjectID,
+			PathExp:        c.PathExp,
+			Value:          value,
+			State:          &state,
+			CredentialMeta: c.CredentialMeta,
+		},
+	}, nil
+}
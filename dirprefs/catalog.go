@@ -0,0 +1,105 @@
+package dirprefs
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Types of values a CatalogEntry may require.
+const (
+	CatalogTypeString = "string"
+	CatalogTypeNumber = "number"
+	CatalogTypeBool   = "bool"
+)
+
+// CatalogEntry describes a credential that a service requires in order to
+// run.
+type CatalogEntry struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+}
+
+// Catalog lists the credentials required by each service in a project, keyed
+// by service name.
+type Catalog map[string][]CatalogEntry
+
+// CatalogProblem is a required credential that is either missing, or has a
+// value that does not match its declared type.
+type CatalogProblem struct {
+	Entry  CatalogEntry
+	Reason string
+}
+
+// Validate returns an error if any entry in the catalog is missing a name or
+// declares an unknown type.
+func (c Catalog) Validate() error {
+	for service, entries := range c {
+		for _, e := range entries {
+			if e.Name == "" {
+				return errors.New("catalog entry for service " + service + " is missing a name")
+			}
+
+			switch e.Type {
+			case "", CatalogTypeString, CatalogTypeNumber, CatalogTypeBool:
+			default:
+				return errors.New("catalog entry " + e.Name + " has unknown type " + e.Type)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Required returns the entries the given service requires. Entries listed
+// under the "*" service are required by every service.
+func (c Catalog) Required(service string) []CatalogEntry {
+	entries := append([]CatalogEntry{}, c["*"]...)
+	if service != "*" {
+		entries = append(entries, c[service]...)
+	}
+
+	return entries
+}
+
+// Missing checks the given credential values, keyed by name, against the
+// entries required by the given service. Names are compared case
+// insensitively, as credential names are case insensitive.
+func (c Catalog) Missing(service string, values map[string]string) []CatalogProblem {
+	lowered := make(map[string]string, len(values))
+	for k, v := range values {
+		lowered[strings.ToLower(k)] = v
+	}
+
+	var problems []CatalogProblem
+	for _, e := range c.Required(service) {
+		v, ok := lowered[strings.ToLower(e.Name)]
+		if !ok {
+			problems = append(problems, CatalogProblem{Entry: e, Reason: "not set"})
+			continue
+		}
+
+		if !matchesType(e.Type, v) {
+			problems = append(problems, CatalogProblem{
+				Entry:  e,
+				Reason: "not a " + e.Type,
+			})
+		}
+	}
+
+	return problems
+}
+
+func matchesType(t, v string) bool {
+	switch t {
+	case CatalogTypeNumber:
+		_, err := strconv.ParseFloat(v, 64)
+		return err == nil
+	case CatalogTypeBool:
+		_, err := strconv.ParseBool(v)
+		return err == nil
+	default:
+		return true
+	}
+}
@@ -0,0 +1,45 @@
+package dirprefs
+
+import "testing"
+
+func TestCatalogMissing(t *testing.T) {
+	c := Catalog{
+		"*":   {{Name: "LOG_LEVEL"}},
+		"api": {{Name: "port", Type: CatalogTypeNumber}, {Name: "debug", Type: CatalogTypeBool}},
+	}
+
+	problems := c.Missing("api", map[string]string{
+		"log_level": "info",
+		"port":      "eighty",
+	})
+
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems, got %d", len(problems))
+	}
+
+	if problems[0].Entry.Name != "port" || problems[0].Reason != "not a number" {
+		t.Errorf("unexpected problem: %+v", problems[0])
+	}
+
+	if problems[1].Entry.Name != "debug" || problems[1].Reason != "not set" {
+		t.Errorf("unexpected problem: %+v", problems[1])
+	}
+
+	if len(c.Missing("web", map[string]string{"LOG_LEVEL": "info"})) != 0 {
+		t.Error("expected no problems for web service")
+	}
+}
+
+func TestCatalogValidate(t *testing.T) {
+	if err := (Catalog{"api": {{Name: "port", Type: "int"}}}).Validate(); err == nil {
+		t.Error("expected error for unknown type")
+	}
+
+	if err := (Catalog{"api": {{Type: CatalogTypeString}}}).Validate(); err == nil {
+		t.Error("expected error for missing name")
+	}
+
+	if err := (Catalog{"api": {{Name: "port"}}}).Validate(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -3,15 +3,41 @@ package dirprefs
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 )
 
-// DirPreferences holds preferences for arguments set in .torus.json files
+// DirPreferences holds preferences for arguments set in .torus.json files.
+//
+// The fields hold the file's contents as written. Use Resolve for the values
+// that apply to a command, with any overrides, parent files, and variables
+// applied.
 type DirPreferences struct {
+	// Extends is the path of another .torus.json file, relative to this one,
+	// whose values apply unless this file sets them.
+	Extends string `json:"extends,omitempty"`
+
+	Organization string  `json:"org,omitempty"`
+	Project      string  `json:"project,omitempty"`
+	Environment  string  `json:"environment,omitempty"`
+	Service      string  `json:"service,omitempty"`
+	Catalog      Catalog `json:"catalog,omitempty"`
+	Rules        Rules   `json:"rules,omitempty"`
+
+	// Environments and Services override the values above for commands run
+	// with that environment or service, keyed by its name.
+	Environments map[string]*Override `json:"environments,omitempty"`
+	Services     map[string]*Override `json:"services,omitempty"`
+
+	Path string `json:"-"`
+}
+
+// Override holds the values that apply to a single environment or service.
+type Override struct {
 	Organization string `json:"org,omitempty"`
 	Project      string `json:"project,omitempty"`
-	Path         string `json:"-"`
+	Environment  string `json:"environment,omitempty"`
 }
 
 // Load loads DirPreferences. It starts in the current working directory,
@@ -27,14 +53,12 @@ func Load(recurse bool) (*DirPreferences, error) {
 		return nil, err
 	}
 
-	prefs := &DirPreferences{}
-
 	var f *os.File
 	for {
 		f, err = os.Open(filepath.Join(path, ".torus.json"))
 		if err != nil {
 			if len(path) == 1 && path == string(os.PathSeparator) || !recurse {
-				return prefs, nil
+				return &DirPreferences{}, nil
 			}
 
 			path = filepath.Dir(path)
@@ -45,13 +69,37 @@ func Load(recurse bool) (*DirPreferences, error) {
 	}
 
 	defer f.Close()
+	return decode(f)
+}
 
-	dec := json.NewDecoder(f)
-	err = dec.Decode(prefs)
+func loadFile(path string) (*DirPreferences, error) {
+	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 
+	defer f.Close()
+	return decode(f)
+}
+
+func decode(f *os.File) (*DirPreferences, error) {
+	prefs := &DirPreferences{}
+	dec := json.NewDecoder(f)
+	err := dec.Decode(prefs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", f.Name(), err)
+	}
+
+	err = prefs.Catalog.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", f.Name(), err)
+	}
+
+	err = prefs.Rules.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", f.Name(), err)
+	}
+
 	prefs.Path = f.Name()
 	return prefs, nil
 }
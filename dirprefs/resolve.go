@@ -0,0 +1,225 @@
+package dirprefs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// maxExtends is how many files deep a chain of extends may go.
+const maxExtends = 10
+
+// Context is the org, project, environment, and service a .torus.json file
+// sets for a command, along with the catalog and rules that apply to it.
+type Context struct {
+	Organization string  `json:"org,omitempty"`
+	Project      string  `json:"project,omitempty"`
+	Environment  string  `json:"environment,omitempty"`
+	Service      string  `json:"service,omitempty"`
+	Catalog      Catalog `json:"-"`
+	Rules        Rules   `json:"-"`
+}
+
+// variable matches ${NAME} and ${NAME:-default}.
+var variable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// Resolve returns the context for a command run with the given environment
+// and service, either of which may be empty if not given.
+//
+// Values are taken from the files this one extends, then this file, then the
+// override for the environment, and then the override for the service. The
+// service defaults to the file's service, and the environment to the one set
+// by the service override or the file.
+//
+// Values may refer to environment variables as ${NAME}, or ${NAME:-default}
+// if NAME may be unset, and to the resolved environment and service as
+// ${environment} and ${service}.
+func (d *DirPreferences) Resolve(environment, service string) (*Context, error) {
+	if d.Path == "" {
+		return &Context{}, nil
+	}
+
+	m, err := d.merged(0)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := map[string]string{}
+	if service == "" {
+		service, err = interpolate(m.Service, vars)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	svc := m.Services[service]
+	if environment == "" {
+		environment = m.Environment
+		if svc != nil && svc.Environment != "" {
+			environment = svc.Environment
+		}
+
+		environment, err = interpolate(environment, vars)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c := &Context{
+		Organization: m.Organization,
+		Project:      m.Project,
+		Environment:  environment,
+		Service:      service,
+		Catalog:      m.Catalog,
+		Rules:        m.Rules,
+	}
+	for _, o := range []*Override{m.Environments[environment], svc} {
+		if o == nil {
+			continue
+		}
+		if o.Organization != "" {
+			c.Organization = o.Organization
+		}
+		if o.Project != "" {
+			c.Project = o.Project
+		}
+	}
+
+	vars["environment"] = environment
+	vars["service"] = service
+
+	c.Organization, err = interpolate(c.Organization, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Project, err = interpolate(c.Project, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// merged returns the preferences with those of the files it extends applied
+// beneath them.
+func (d *DirPreferences) merged(depth int) (*DirPreferences, error) {
+	if d.Extends == "" {
+		return d, nil
+	}
+	if depth >= maxExtends {
+		return nil, errors.New(d.Path + ": too many levels of extends")
+	}
+
+	path := d.Extends
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(d.Path), path)
+	}
+
+	parent, err := loadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not load extends: %s", d.Path, err)
+	}
+
+	parent, err = parent.merged(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return merge(parent, d), nil
+}
+
+// merge returns parent with the values set in child replacing its own.
+// Overrides for the same environment or service are merged value by value,
+// catalogs service by service, and rules name by name.
+func merge(parent, child *DirPreferences) *DirPreferences {
+	m := &DirPreferences{
+		Organization: pick(child.Organization, parent.Organization),
+		Project:      pick(child.Project, parent.Project),
+		Environment:  pick(child.Environment, parent.Environment),
+		Service:      pick(child.Service, parent.Service),
+		Catalog:      Catalog{},
+		Rules:        Rules{},
+		Environments: mergeOverrides(parent.Environments, child.Environments),
+		Services:     mergeOverrides(parent.Services, child.Services),
+		Path:         child.Path,
+	}
+
+	for _, c := range []Catalog{parent.Catalog, child.Catalog} {
+		for service, entries := range c {
+			m.Catalog[service] = entries
+		}
+	}
+
+	for _, r := range []Rules{parent.Rules, child.Rules} {
+		for name, rule := range r {
+			m.Rules[name] = rule
+		}
+	}
+
+	return m
+}
+
+func mergeOverrides(parent, child map[string]*Override) map[string]*Override {
+	m := make(map[string]*Override, len(parent)+len(child))
+	for name, o := range parent {
+		m[name] = o
+	}
+
+	for name, o := range child {
+		p, ok := m[name]
+		if !ok || p == nil || o == nil {
+			m[name] = o
+			continue
+		}
+
+		m[name] = &Override{
+			Organization: pick(o.Organization, p.Organization),
+			Project:      pick(o.Project, p.Project),
+			Environment:  pick(o.Environment, p.Environment),
+		}
+	}
+
+	return m
+}
+
+func pick(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
+
+// interpolate replaces the variables in s with their values from vars, or
+// the environment. It is an error to refer to an unset variable without a
+// default.
+func interpolate(s string, vars map[string]string) (string, error) {
+	var err error
+	out := variable.ReplaceAllStringFunc(s, func(match string) string {
+		parts := variable.FindStringSubmatch(match)
+		name, hasDefault, def := parts[1], parts[2] != "", parts[3]
+
+		value, ok := vars[name]
+		if !ok {
+			value, ok = os.LookupEnv(name)
+		}
+		if ok && value != "" {
+			return value
+		}
+		if hasDefault {
+			return def
+		}
+		if ok {
+			return value
+		}
+
+		if err == nil {
+			err = errors.New("variable " + name + " in .torus.json is not set")
+		}
+		return ""
+	})
+
+	return out, err
+}
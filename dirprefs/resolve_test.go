@@ -0,0 +1,111 @@
+package dirprefs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePrefs(t *testing.T, dir, name, contents string) string {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, []byte(contents), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "torus-dirprefs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writePrefs(t, dir, "base.json", `{
+		"org": "acme",
+		"project": "shared",
+		"catalog": {"*": [{"name": "LOG_LEVEL"}]},
+		"services": {"web": {"project": "frontend"}}
+	}`)
+	path := writePrefs(t, dir, ".torus.json", `{
+		"extends": "base.json",
+		"environment": "${TORUS_TEST_ENV:-dev-shared}",
+		"environments": {"production": {"org": "acme-prod"}},
+		"services": {
+			"api": {"project": "${service}-${environment}"},
+			"worker": {"environment": "jobs"}
+		},
+		"catalog": {"api": [{"name": "PORT"}]}
+	}`)
+
+	d, err := loadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Unsetenv("TORUS_TEST_ENV")
+
+	tcs := []struct {
+		environment, service string
+		want                 Context
+	}{
+		{"", "", Context{Organization: "acme", Project: "shared", Environment: "dev-shared"}},
+		{"", "web", Context{Organization: "acme", Project: "frontend", Environment: "dev-shared", Service: "web"}},
+		{"production", "api", Context{Organization: "acme-prod", Project: "api-production", Environment: "production", Service: "api"}},
+		{"", "worker", Context{Organization: "acme", Project: "shared", Environment: "jobs", Service: "worker"}},
+	}
+
+	for _, tc := range tcs {
+		c, err := d.Resolve(tc.environment, tc.service)
+		if err != nil {
+			t.Errorf("%s/%s: %s", tc.environment, tc.service, err)
+			continue
+		}
+
+		if c.Organization != tc.want.Organization || c.Project != tc.want.Project ||
+			c.Environment != tc.want.Environment || c.Service != tc.want.Service {
+			t.Errorf("%s/%s: got %+v, want %+v", tc.environment, tc.service, *c, tc.want)
+		}
+	}
+
+	c, err := d.Resolve("", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Catalog.Required("api")) != 2 {
+		t.Errorf("catalog not merged with base: %+v", c.Catalog)
+	}
+
+	os.Setenv("TORUS_TEST_ENV", "staging")
+	defer os.Unsetenv("TORUS_TEST_ENV")
+	c, err = d.Resolve("", "")
+	if err != nil || c.Environment != "staging" {
+		t.Errorf("got environment %q, err %v, want staging", c.Environment, err)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "torus-dirprefs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	unset := writePrefs(t, dir, "unset.json", `{"project": "${TORUS_TEST_UNSET}"}`)
+	cycle := writePrefs(t, dir, "cycle.json", `{"extends": "cycle.json"}`)
+	missing := writePrefs(t, dir, "missing.json", `{"extends": "nope.json"}`)
+
+	os.Unsetenv("TORUS_TEST_UNSET")
+	for _, path := range []string{unset, cycle, missing} {
+		d, err := loadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := d.Resolve("", ""); err == nil {
+			t.Errorf("%s: expected error", filepath.Base(path))
+		}
+	}
+}
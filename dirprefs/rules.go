@@ -0,0 +1,120 @@
+package dirprefs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Formats a Rule may require values to be in.
+const (
+	RuleFormatURL  = "url"
+	RuleFormatJSON = "json"
+)
+
+// Rule describes the values a credential may be set to.
+type Rule struct {
+	// Pattern is a regular expression the whole value must match.
+	Pattern   string `json:"pattern,omitempty"`
+	MinLength int    `json:"min_length,omitempty"`
+	Format    string `json:"format,omitempty"`
+}
+
+// Rules holds the rules credentials are validated against, keyed by
+// credential name. Names may be glob patterns, such as *_url, and are
+// compared case insensitively. A credential must pass every rule whose name
+// matches it.
+type Rules map[string]Rule
+
+// RuleProblem is a reason a value breaks a rule.
+type RuleProblem struct {
+	Name   string
+	Reason string
+}
+
+// Validate returns an error if any rule has a bad name, pattern, minimum
+// length, or format.
+func (r Rules) Validate() error {
+	for name, rule := range r {
+		if _, err := path.Match(name, ""); err != nil || name == "" {
+			return errors.New("rule name " + strconv.Quote(name) + " is not a valid name or pattern")
+		}
+		if _, err := rule.regexp(); err != nil {
+			return errors.New("rule " + name + " has an invalid pattern: " + err.Error())
+		}
+		if rule.MinLength < 0 {
+			return errors.New("rule " + name + " has a negative min_length")
+		}
+
+		switch rule.Format {
+		case "", RuleFormatURL, RuleFormatJSON:
+		default:
+			return errors.New("rule " + name + " has unknown format " + rule.Format)
+		}
+	}
+
+	return nil
+}
+
+// Check returns the reasons the named credential's value breaks the rules
+// that apply to it, sorted by the rule they come from.
+func (r Rules) Check(name, value string) []RuleProblem {
+	name = strings.ToLower(name)
+
+	keys := make([]string, 0, len(r))
+	for k := range r {
+		if ok, _ := path.Match(strings.ToLower(k), name); ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var problems []RuleProblem
+	for _, k := range keys {
+		for _, reason := range r[k].check(value) {
+			problems = append(problems, RuleProblem{Name: k, Reason: reason})
+		}
+	}
+
+	return problems
+}
+
+func (r Rule) check(value string) []string {
+	var reasons []string
+	if r.MinLength > 0 && len(value) < r.MinLength {
+		reasons = append(reasons, "shorter than "+strconv.Itoa(r.MinLength)+" characters")
+	}
+
+	if re, _ := r.regexp(); re != nil && !re.MatchString(value) {
+		reasons = append(reasons, "does not match "+r.Pattern)
+	}
+
+	switch r.Format {
+	case RuleFormatURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" && u.Opaque == "" {
+			reasons = append(reasons, "not a URL")
+		}
+	case RuleFormatJSON:
+		var v interface{}
+		if json.Unmarshal([]byte(value), &v) != nil {
+			reasons = append(reasons, "not valid JSON")
+		}
+	}
+
+	return reasons
+}
+
+// regexp returns the rule's pattern, anchored to match the whole value, or
+// nil if it has none.
+func (r Rule) regexp() (*regexp.Regexp, error) {
+	if r.Pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + r.Pattern + ")$")
+}
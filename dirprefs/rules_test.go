@@ -0,0 +1,58 @@
+package dirprefs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRulesCheck(t *testing.T) {
+	r := Rules{
+		"*_URL":        {Format: RuleFormatURL},
+		"database_url": {Pattern: "postgres://.*"},
+		"api_key":      {MinLength: 8, Pattern: "[a-z0-9]+"},
+		"config":       {Format: RuleFormatJSON},
+	}
+
+	tcs := []struct {
+		name, value string
+		want        []RuleProblem
+	}{
+		{"database_url", "postgres://db/app", nil},
+		{"DATABASE_URL", "mysql://db/app", []RuleProblem{{"database_url", "does not match postgres://.*"}}},
+		{"redis_url", "localhost", []RuleProblem{{"*_URL", "not a URL"}}},
+		{"api_key", "ABC", []RuleProblem{
+			{"api_key", "shorter than 8 characters"},
+			{"api_key", "does not match [a-z0-9]+"},
+		}},
+		{"config", `{"a": 1}`, nil},
+		{"config", `{"a": }`, []RuleProblem{{"config", "not valid JSON"}}},
+		{"port", "", nil},
+	}
+
+	for _, tc := range tcs {
+		got := r.Check(tc.name, tc.value)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Check(%q, %q) = %v, want %v", tc.name, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestRulesValidate(t *testing.T) {
+	tcs := []struct {
+		name  string
+		rules Rules
+		err   bool
+	}{
+		{"ok", Rules{"port": {Pattern: "[0-9]+"}}, false},
+		{"bad pattern", Rules{"port": {Pattern: "[0-9"}}, true},
+		{"bad name", Rules{"port[": {}}, true},
+		{"negative length", Rules{"port": {MinLength: -1}}, true},
+		{"unknown format", Rules{"port": {Format: "number"}}, true},
+	}
+
+	for _, tc := range tcs {
+		if err := tc.rules.Validate(); (err != nil) != tc.err {
+			t.Errorf("%s: got error %v, want error %t", tc.name, err, tc.err)
+		}
+	}
+}
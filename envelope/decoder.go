@@ -0,0 +1,247 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+// typed is implemented by the envelopes wrapping a specific primitive schema
+// version, such as Credential.
+type typed interface {
+	Envelope
+	generic() Envelope // returns the Signed or Unsigned equivalent
+}
+
+// schema identifies a primitive by its type byte and schema version.
+type schema struct {
+	t       byte
+	version uint8
+}
+
+// registry maps every known primitive schema to a constructor for the typed
+// envelope wrapping it. It is populated by the generated code.
+var registry = make(map[schema]func() typed)
+
+func register(t byte, version uint8, fn func() typed) {
+	registry[schema{t: t, version: version}] = fn
+}
+
+// header holds the fields needed to pick the type of an envelope's body.
+type header struct {
+	ID      *identity.ID `json:"id"`
+	Version uint8        `json:"version"`
+}
+
+// decode decodes a single envelope into the typed envelope registered for
+// its primitive type and schema version.
+//
+// Unsigned envelopes with a newer schema version than any known for their
+// type are decoded as the newest known version, keeping their version and the
+// fields of their body that are unknown, so they are written back unchanged.
+// Signed envelopes hold keys and secrets, whose meaning can change between
+// schema versions, so they must be of a known version.
+func decode(b []byte) (typed, error) {
+	h := header{}
+	err := json.Unmarshal(b, &h)
+	if err != nil {
+		return nil, err
+	}
+	if h.ID == nil {
+		return nil, errors.New("Missing envelope id")
+	}
+
+	t := h.ID.Type()
+	fn, ok := registry[schema{t: t, version: h.Version}]
+	if !ok {
+		fn, err = newerSchema(t, h.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	e := fn()
+	err = json.Unmarshal(b, e)
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Decode decodes the JSON encoded envelope b into the typed envelope for its
+// primitive type and schema version, such as *CredentialV2.
+func Decode(b []byte) (Envelope, error) {
+	e, err := decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// newerSchema returns the constructor for the newest known schema version of
+// the primitive type t, to decode a newer, unknown version of it with.
+func newerSchema(t byte, version uint8) (func() typed, error) {
+	var newest *schema
+	for s := range registry {
+		if s.t == t && (newest == nil || s.version > newest.version) {
+			found := s
+			newest = &found
+		}
+	}
+	if newest == nil {
+		return nil, fmt.Errorf("Unknown primitive type id: %#02x", t)
+	}
+
+	fn := registry[*newest]
+	if _, signed := fn().generic().(*Signed); version < newest.version || signed {
+		return nil, fmt.Errorf("Unknown schema version %d for primitive type id: %#02x", version, t)
+	}
+
+	return fn, nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Signed
+// envelopes.
+func (e *Signed) UnmarshalJSON(b []byte) error {
+	te, err := decode(b)
+	if err != nil {
+		return err
+	}
+
+	s, ok := te.generic().(*Signed)
+	if !ok {
+		return fmt.Errorf("Primitive type id %#02x is not signed", te.GetID().Type())
+	}
+
+	*e = *s
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface for Signed envelopes,
+// writing back any unknown fields of the body.
+func (e *Signed) MarshalJSON() ([]byte, error) {
+	body, err := mergeFields(e.Body, e.Unknown)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&struct {
+		ID        *identity.ID        `json:"id"`
+		Version   uint8               `json:"version"`
+		Body      json.RawMessage     `json:"body"`
+		Signature primitive.Signature `json:"sig"`
+	}{ID: e.ID, Version: e.Version, Body: body, Signature: e.Signature})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Unsigned
+// envelopes.
+func (e *Unsigned) UnmarshalJSON(b []byte) error {
+	te, err := decode(b)
+	if err != nil {
+		return err
+	}
+
+	u, ok := te.generic().(*Unsigned)
+	if !ok {
+		return fmt.Errorf("Primitive type id %#02x is signed", te.GetID().Type())
+	}
+
+	*e = *u
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface for Unsigned envelopes,
+// writing back any unknown fields of the body.
+func (e *Unsigned) MarshalJSON() ([]byte, error) {
+	body, err := mergeFields(e.Body, e.Unknown)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&struct {
+		ID      *identity.ID    `json:"id"`
+		Version uint8           `json:"version"`
+		Body    json.RawMessage `json:"body"`
+	}{ID: e.ID, Version: e.Version, Body: body})
+}
+
+// Decoder reads a JSON list of envelopes from a stream, one at a time. Each
+// envelope is decoded into the typed envelope for its primitive type and
+// schema version, such as *Credential or *Keyring, so lists holding several
+// kinds of objects can be decoded in a single pass.
+type Decoder struct {
+	dec     *json.Decoder
+	started bool
+}
+
+// NewDecoder returns a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{dec: json.NewDecoder(r)}
+}
+
+// Decode returns the next envelope in the list. It returns io.EOF once the
+// end of the list has been reached.
+func (d *Decoder) Decode() (Envelope, error) {
+	if !d.started {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return nil, errors.New("Expected a list of envelopes")
+		}
+		d.started = true
+	}
+
+	if !d.dec.More() {
+		_, err := d.dec.Token() // consume the closing ]
+		if err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	var raw json.RawMessage
+	err := d.dec.Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return decode(raw)
+}
+
+// List is a list of envelopes of any type. It can be used in place of a
+// slice of a specific envelope type when decoding responses that hold
+// several kinds of objects.
+type List []Envelope
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Lists.
+func (l *List) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		*l = nil
+		return nil
+	}
+
+	list := List{}
+	d := NewDecoder(bytes.NewReader(b))
+	for {
+		e, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		list = append(list, e)
+	}
+
+	*l = list
+	return nil
+}
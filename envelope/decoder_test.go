@@ -0,0 +1,178 @@
+package envelope
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+func TestDecoder(t *testing.T) {
+	org := &primitive.Org{Name: "knotty-buoy"}
+	orgID, err := identity.NewMutable(org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cred := &primitive.CredentialV2{
+		BaseCredential: primitive.BaseCredential{Name: "port", CredentialVersion: 2},
+	}
+	credID := identity.ID{0x01, cred.Type()}
+
+	orgJSON, err := json.Marshal(&Org{ID: &orgID, Version: 1, Body: org})
+	if err != nil {
+		t.Fatal(err)
+	}
+	credJSON, err := json.Marshal(&CredentialV2{ID: &credID, Version: 2, Body: cred})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := "[" + string(orgJSON) + "," + string(credJSON) + "]"
+
+	t.Run("decoder", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(list))
+
+		e, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if o, ok := e.(*Org); !ok || o.Body.Name != "knotty-buoy" {
+			t.Errorf("expected the org, got %#v", e)
+		}
+
+		e, err = d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c, ok := e.(CredentialInf); !ok || c.Name() != "port" || c.CredentialVersion() != 2 {
+			t.Errorf("expected the credential, got %#v", e)
+		}
+
+		if _, err := d.Decode(); err != io.EOF {
+			t.Errorf("expected EOF, got %v", err)
+		}
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		s := Signed{}
+		err := json.Unmarshal(credJSON, &s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := s.Body.(*primitive.CredentialV2); !ok || s.ID.Type() != cred.Type() {
+			t.Errorf("expected the credential, got %#v", s)
+		}
+
+		err = json.Unmarshal(orgJSON, &s)
+		if err == nil {
+			t.Error("expected an error decoding an org as a signed envelope")
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		l := List{}
+		err := json.Unmarshal([]byte(list), &l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(l) != 2 {
+			t.Errorf("expected 2 envelopes, got %d", len(l))
+		}
+	})
+
+	t.Run("unknown schema", func(t *testing.T) {
+		d := NewDecoder(strings.NewReader(`[{"id":"` + credID.String() + `","version":9,"body":{}}]`))
+		if _, err := d.Decode(); err == nil || !strings.Contains(err.Error(), "schema version 9") {
+			t.Errorf("expected an unknown schema error, got %v", err)
+		}
+	})
+
+	t.Run("newer schema", func(t *testing.T) {
+		newer := `{"id":"` + orgID.String() + `","version":2,"body":{"name":"knotty-buoy","plan":{"seats":5}}}`
+		d := NewDecoder(strings.NewReader("[" + newer + "]"))
+		e, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		o, ok := e.(*Org)
+		if !ok || o.Body.Name != "knotty-buoy" || o.Version != 2 {
+			t.Fatalf("expected the org at version 2, got %#v", e)
+		}
+		if string(o.Unknown["plan"]) != `{"seats":5}` {
+			t.Errorf("expected the unknown plan field to be kept, got %v", o.Unknown)
+		}
+
+		b, err := json.Marshal(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != newer {
+			t.Errorf("expected the org to round trip, got %s", b)
+		}
+	})
+}
+
+func TestUnknownFields(t *testing.T) {
+	cred := &primitive.Credential{
+		BaseCredential: primitive.BaseCredential{Name: "port", CredentialVersion: 1},
+	}
+	credID := identity.ID{0x01, cred.Type()}
+
+	b, err := json.Marshal(&Credential{ID: &credID, Version: 3, Body: cred})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A field added by a newer client, in the sorted place it was signed in.
+	signed := strings.Replace(string(b), `"org_id":null`, `"org_id":null,"owner":"jo"`, 1)
+
+	t.Run("typed", func(t *testing.T) {
+		c := Credential{}
+		err := json.Unmarshal([]byte(signed), &c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.Unknown) != 1 || string(c.Unknown["owner"]) != `"jo"` {
+			t.Errorf("expected only the owner field to be unknown, got %v", c.Unknown)
+		}
+
+		out, err := json.Marshal(&c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != signed {
+			t.Errorf("expected\n%s\ngot\n%s", signed, out)
+		}
+	})
+
+	t.Run("generic", func(t *testing.T) {
+		s := Signed{}
+		err := json.Unmarshal([]byte(signed), &s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := json.Marshal(&s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != signed {
+			t.Errorf("expected\n%s\ngot\n%s", signed, out)
+		}
+	})
+
+	t.Run("known", func(t *testing.T) {
+		c := Credential{}
+		err := json.Unmarshal(b, &c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Unknown != nil {
+			t.Errorf("expected no unknown fields, got %v", c.Unknown)
+		}
+	})
+}
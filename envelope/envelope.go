@@ -22,6 +22,7 @@ type Signed struct {
 	Version   uint8               `json:"version"`
 	Body      identity.Immutable  `json:"body"`
 	Signature primitive.Signature `json:"sig"`
+	Unknown   Fields              `json:"-"`
 }
 
 // GetID returns the ID of the object encapsulated in this envelope.
@@ -35,6 +36,7 @@ type Unsigned struct {
 	ID      *identity.ID     `json:"id"`
 	Version uint8            `json:"version"`
 	Body    identity.Mutable `json:"body"`
+	Unknown Fields           `json:"-"`
 }
 
 // GetID returns the ID of the object encapsulated in this envelope.
@@ -99,6 +101,8 @@ type CredentialInf interface {
 
 	OrgID() *identity.ID
 	ProjectID() *identity.ID
+
+	Meta() *primitive.CredentialMeta
 }
 
 // GetVersion returns the schema version of this Credential.
@@ -154,6 +158,12 @@ func (c *CredentialV1) ProjectID() *identity.ID {
 	return c.Body.ProjectID
 }
 
+// Meta returns the metadata for this Credential. Version 1 credentials do not
+// have any.
+func (CredentialV1) Meta() *primitive.CredentialMeta {
+	return &primitive.CredentialMeta{}
+}
+
 // GetVersion returns the schema version of this Credential.
 func (c *Credential) GetVersion() uint8 {
 	return c.Version
@@ -205,3 +215,66 @@ func (c *Credential) OrgID() *identity.ID {
 func (c *Credential) ProjectID() *identity.ID {
 	return c.Body.ProjectID
 }
+
+// Meta returns the metadata for this Credential.
+func (c *Credential) Meta() *primitive.CredentialMeta {
+	return &c.Body.CredentialMeta
+}
+
+// GetVersion returns the schema version of this Credential.
+func (c *CredentialV2) GetVersion() uint8 {
+	return c.Version
+}
+
+// Previous returns the ID of the previous versino of this Credential, or nil
+// if this Credential has no previous version.
+func (c *CredentialV2) Previous() *identity.ID {
+	return c.Body.Previous
+}
+
+// CredentialVersion returns the monotomically incremented version of the
+// Credential for this PathExp/Name pair.
+func (c *CredentialV2) CredentialVersion() int {
+	return c.Body.CredentialVersion
+}
+
+// PathExp returns the path expression for this Credential's location.
+func (c *CredentialV2) PathExp() *pathexp.PathExp {
+	return c.Body.PathExp
+}
+
+// Name returns this Credential's name.
+func (c *CredentialV2) Name() string {
+	return c.Body.Name
+}
+
+// Unset returns a bool indicating if this Credential has been explicitly unset.
+func (c *CredentialV2) Unset() bool {
+	return c.Body.State != nil && *c.Body.State == "unset"
+}
+
+// Nonce returns the Nonce for this Credential's encrypted value.
+func (c *CredentialV2) Nonce() *base64.Value {
+	return c.Body.Nonce
+}
+
+// Credential returns the encrypted CredentialValue for this Credential.
+func (c *CredentialV2) Credential() *primitive.CredentialValue {
+	return c.Body.Credential
+}
+
+// OrgID returns the ID of the Org that this Credential belongs to.
+func (c *CredentialV2) OrgID() *identity.ID {
+	return c.Body.OrgID
+}
+
+// ProjectID returns the ID of the Project that this Credential belongs to.
+func (c *CredentialV2) ProjectID() *identity.ID {
+	return c.Body.ProjectID
+}
+
+// Meta returns the metadata for this Credential. Version 2 credentials do not
+// have any.
+func (CredentialV2) Meta() *primitive.CredentialMeta {
+	return &primitive.CredentialMeta{}
+}
@@ -0,0 +1,110 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Fields holds the members of an envelope's body that this version of torus
+// doesn't know about, keyed by name. They are sent by registries and clients
+// using a newer schema, and are kept so the body can be written back without
+// losing them.
+type Fields map[string]json.RawMessage
+
+// unknownFields returns the members of the body of the JSON encoded envelope
+// b that aren't fields of body, the value it was decoded into.
+func unknownFields(b []byte, body interface{}) (Fields, error) {
+	raw := struct {
+		Body json.RawMessage `json:"body"`
+	}{}
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw.Body) == 0 || bytes.Equal(raw.Body, []byte("null")) {
+		return nil, nil
+	}
+
+	members := make(map[string]json.RawMessage)
+	err = json.Unmarshal(raw.Body, &members)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	jsonFields(reflect.TypeOf(body), known)
+
+	var unknown Fields
+	for name, value := range members {
+		// encoding/json matches names regardless of case.
+		if known[strings.ToLower(name)] {
+			continue
+		}
+		if unknown == nil {
+			unknown = make(Fields)
+		}
+		unknown[name] = value
+	}
+
+	return unknown, nil
+}
+
+// jsonFields adds the lower cased JSON names of the fields of the struct type
+// t, or the struct t points to, to names. The fields of embedded structs are
+// included, as encoding/json hoists them.
+func jsonFields(t reflect.Type, names map[string]bool) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			jsonFields(f.Type, names)
+			continue
+		}
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		names[strings.ToLower(name)] = true
+	}
+}
+
+// mergeFields returns the JSON encoding of body, with the unknown fields
+// added back. Fields are written in sorted order, as for signed bodies, so
+// an unchanged body encodes the same as it was signed.
+func mergeFields(body interface{}, unknown Fields) (json.RawMessage, error) {
+	b, err := json.Marshal(body)
+	if err != nil || len(unknown) == 0 || bytes.Equal(b, []byte("null")) {
+		return b, err
+	}
+
+	members := make(map[string]json.RawMessage)
+	err = json.Unmarshal(b, &members)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range unknown {
+		if _, ok := members[name]; !ok {
+			members[name] = value
+		}
+	}
+
+	return json.Marshal(members)
+}
@@ -2,8 +2,11 @@ package errs
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/urfave/cli"
+
+	"github.com/manifoldco/torus-cli/i18n"
 )
 
 // Word without punctuation or space
@@ -11,7 +14,12 @@ var wordRegex = regexp.MustCompile(`\w`)
 
 func usageString(ctx *cli.Context) string {
 	spacer := "    "
-	return "Usage:\n" + spacer + ctx.App.HelpName + " " + ctx.Command.Name + " [command options] " + ctx.Command.ArgsUsage
+	return i18n.T("Usage:") + "\n" + spacer + ctx.App.HelpName + " " + ctx.Command.Name + " [command options] " + ctx.Command.ArgsUsage
+}
+
+// usageExitError is an ExitError caused by a command being used incorrectly.
+type usageExitError struct {
+	*cli.ExitError
 }
 
 // NewUsageExitError creates an ExitError with appended usage text
@@ -19,7 +27,13 @@ func NewUsageExitError(message string, ctx *cli.Context) error {
 	if wordRegex.MatchString(message[len(message)-1:]) {
 		message += "."
 	}
-	return cli.NewExitError(message+"\n"+usageString(ctx), -1)
+	return &usageExitError{cli.NewExitError(i18n.T(message)+"\n"+usageString(ctx), -1)}
+}
+
+// IsUsageError returns whether err was created by NewUsageExitError.
+func IsUsageError(err error) bool {
+	_, ok := err.(*usageExitError)
+	return ok
 }
 
 // NewErrorExitError creates an ExitError with an appended error message
@@ -27,13 +41,44 @@ func NewErrorExitError(message string, err error) error {
 	if wordRegex.MatchString(message[len(message)-1:]) {
 		message += "."
 	}
-	return cli.NewExitError(message+"\n"+err.Error(), -1)
+	return cli.NewExitError(i18n.T(message)+"\n"+err.Error(), -1)
 }
 
 // NewExitError creates an ExitError with -1
+//
+// Messages of all ExitErrors are translated into the user's language.
 func NewExitError(message string) error {
 	if wordRegex.MatchString(message[len(message)-1:]) {
 		message += "."
 	}
-	return cli.NewExitError(message, -1)
+	return cli.NewExitError(i18n.T(message), -1)
+}
+
+// PromptExitStatus is the exit status of a command that needed to prompt for
+// input while prompting was turned off.
+const PromptExitStatus = 4
+
+// promptExitError is an ExitError caused by a command needing to prompt for
+// input while prompting was turned off.
+type promptExitError struct {
+	*cli.ExitError
+}
+
+// NewPromptExitError creates an ExitError for a prompt that could not be
+// shown, with a hint on how to provide its answer instead.
+func NewPromptExitError(label, hint string) error {
+	message := i18n.T("Cannot prompt for %s, as prompting is turned off.", strings.ToLower(label))
+	if hint != "" {
+		message += " " + i18n.T(hint)
+		if wordRegex.MatchString(message[len(message)-1:]) {
+			message += "."
+		}
+	}
+	return &promptExitError{cli.NewExitError(message, PromptExitStatus)}
+}
+
+// IsPromptError returns whether err was created by NewPromptExitError.
+func IsPromptError(err error) bool {
+	_, ok := err.(*promptExitError)
+	return ok
 }
@@ -0,0 +1,167 @@
+package gatekeeper
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+const gcpIdentityURL = "http://metadata.google.internal/computeMetadata/v1/" +
+	"instance/service-accounts/default/identity"
+
+// Bootstrap fetches this instance's identity document from the provider's
+// metadata service, and exchanges it with the gatekeeper at gatekeeperURL
+// for the credentials of a new machine in the given role. A challenge is
+// requested from the gatekeeper first, and sent back with the identity.
+func Bootstrap(ctx context.Context, gatekeeperURL string,
+	provider apitypes.BootstrapProvider, role string) (*apitypes.BootstrapResponse, error) {
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	challenge := &apitypes.BootstrapChallenge{}
+	err := post(ctx, client, gatekeeperURL, ChallengePath, nil, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &apitypes.BootstrapRequest{
+		Provider:  provider,
+		Role:      role,
+		Challenge: challenge.Challenge,
+	}
+
+	switch provider {
+	case apitypes.AWSBootstrapProvider:
+		req.Identity, req.Signature, err = awsIdentity()
+	case apitypes.GCPBootstrapProvider:
+		req.Identity, err = gcpIdentity(ctx, client, gcpAudience(gatekeeperURL, challenge.Challenge))
+	default:
+		err = fmt.Errorf("unknown provider: %s", provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &apitypes.BootstrapResponse{}
+	err = post(ctx, client, gatekeeperURL, BootstrapPath, req, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// post sends body, if any, to path on the gatekeeper, decoding the response
+// into v.
+func post(ctx context.Context, client *http.Client, gatekeeperURL, path string,
+	body, v interface{}) error {
+
+	b := []byte("{}")
+	if body != nil {
+		var err error
+		b, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	u := strings.TrimSuffix(gatekeeperURL, "/") + path
+	req, err := http.NewRequest("POST", u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &apitypes.Error{StatusCode: resp.StatusCode}
+		err = json.NewDecoder(resp.Body).Decode(apiErr)
+		if err != nil || apiErr.Type == "" {
+			return fmt.Errorf("gatekeeper returned %s", resp.Status)
+		}
+		return apiErr
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// randomChallenge returns a new random challenge.
+func randomChallenge() (string, error) {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// awsIdentity returns the EC2 instance identity document and its signature.
+func awsIdentity() (string, string, error) {
+	md := ec2metadata.New(session.New())
+	if !md.Available() {
+		return "", "", errors.New("EC2 metadata service is not available")
+	}
+
+	doc, err := md.GetDynamicData("instance-identity/document")
+	if err != nil {
+		return "", "", err
+	}
+
+	sig, err := md.GetDynamicData("instance-identity/signature")
+	if err != nil {
+		return "", "", err
+	}
+
+	return doc, sig, nil
+}
+
+// gcpIdentity returns a GCE instance identity token issued for the given
+// audience.
+func gcpIdentity(ctx context.Context, client *http.Client, audience string) (string, error) {
+	v := url.Values{}
+	v.Set("audience", audience)
+	v.Set("format", "full")
+
+	req, err := http.NewRequest("GET", gcpIdentityURL+"?"+v.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", errors.New("GCE metadata service is not available")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not fetch instance identity: %s", resp.Status)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,133 @@
+package gatekeeper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+// Constraints decide which cloud instances may bootstrap, and into which
+// machine roles. They are loaded from a JSON file:
+//
+//	{
+//	  "aws_certificate_file": "aws.pem",
+//	  "aws_max_age": "15m",
+//	  "rules": [
+//	    {"provider": "aws", "accounts": ["123456789012"],
+//	     "regions": ["us-east-1"], "roles": ["api"]}
+//	  ]
+//	}
+//
+// AWS identity documents never expire, so they are only accepted for
+// aws_max_age after the instance was launched or last started, 10 minutes by
+// default.
+type Constraints struct {
+	AWSCertificateFile string `json:"aws_certificate_file,omitempty"`
+	AWSMaxAge          string `json:"aws_max_age,omitempty"`
+	Rules              []Rule `json:"rules"`
+
+	awsMaxAge time.Duration
+}
+
+// defaultAWSMaxAge is how long after an EC2 instance starts its identity
+// document is accepted, unless aws_max_age is set.
+const defaultAWSMaxAge = 10 * time.Minute
+
+// Rule allows instances from any of the listed accounts (AWS account ids or
+// GCP project ids) and regions to join any of the listed machine roles. An
+// empty region list allows every region.
+type Rule struct {
+	Provider apitypes.BootstrapProvider `json:"provider"`
+	Accounts []string                   `json:"accounts"`
+	Regions  []string                   `json:"regions,omitempty"`
+	Roles    []string                   `json:"roles"`
+}
+
+// LoadConstraints reads and validates the constraints file at the given path.
+// A relative aws_certificate_file is resolved against the file's directory.
+func LoadConstraints(path string) (*Constraints, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Constraints{}
+	err = json.Unmarshal(b, c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.AWSCertificateFile != "" && !filepath.IsAbs(c.AWSCertificateFile) {
+		c.AWSCertificateFile = filepath.Join(filepath.Dir(path), c.AWSCertificateFile)
+	}
+
+	return c, c.Validate()
+}
+
+// Validate returns an error if any rule is too permissive or malformed.
+func (c *Constraints) Validate() error {
+	if len(c.Rules) == 0 {
+		return errors.New("no bootstrap rules defined")
+	}
+
+	c.awsMaxAge = defaultAWSMaxAge
+	if c.AWSMaxAge != "" {
+		d, err := time.ParseDuration(c.AWSMaxAge)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("aws_max_age %q is not a valid duration", c.AWSMaxAge)
+		}
+		c.awsMaxAge = d
+	}
+
+	for i, r := range c.Rules {
+		switch r.Provider {
+		case apitypes.AWSBootstrapProvider:
+			if c.AWSCertificateFile == "" {
+				return fmt.Errorf("rule %d: aws rules require aws_certificate_file", i+1)
+			}
+		case apitypes.GCPBootstrapProvider:
+		default:
+			return fmt.Errorf("rule %d: unknown provider %q", i+1, r.Provider)
+		}
+
+		if len(r.Accounts) == 0 {
+			return fmt.Errorf("rule %d: at least one account is required", i+1)
+		}
+		if len(r.Roles) == 0 {
+			return fmt.Errorf("rule %d: at least one role is required", i+1)
+		}
+	}
+
+	return nil
+}
+
+// Allows returns whether any rule lets the instance join the given role.
+func (c *Constraints) Allows(instance *Instance, role string) bool {
+	for _, r := range c.Rules {
+		if r.Provider != instance.Provider {
+			continue
+		}
+
+		if contains(r.Accounts, instance.Account) && contains(r.Roles, role) &&
+			(len(r.Regions) == 0 || contains(r.Regions, instance.Region)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
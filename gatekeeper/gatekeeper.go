@@ -0,0 +1,306 @@
+// Package gatekeeper lets new cloud instances bootstrap machine credentials
+// without a pre-shared token baked into their images.
+//
+// A gatekeeper runs with an authenticated daemon session that is allowed to
+// create machines in an org. Instances send it their signed instance identity
+// document; once the signature and the operator's constraints (account,
+// region, and machine role) are verified, the gatekeeper creates a machine for
+// the instance and returns its token.
+package gatekeeper
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/manifoldco/torus-cli/api"
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+// BootstrapPath is the path instances post their bootstrap requests to.
+const BootstrapPath = "/v1/bootstrap"
+
+// ChallengePath is the path instances post to for a challenge, which they
+// send back with their bootstrap request.
+const ChallengePath = "/v1/challenge"
+
+const (
+	// challengeTTL is how long a challenge can be used for.
+	challengeTTL = 5 * time.Minute
+
+	// maxChallenges limits the challenges outstanding at once, so they
+	// can't be requested until the gatekeeper runs out of memory.
+	maxChallenges = 10000
+)
+
+// Gatekeeper verifies instance identities and issues machine credentials for
+// a single org.
+type Gatekeeper struct {
+	client      *api.Client
+	org         *envelope.Org
+	audience    string
+	constraints *Constraints
+	awsCerts    []*x509.Certificate
+	http        *http.Client
+
+	// mutex serializes machine creation, so a replayed identity document
+	// cannot race the original to create two machines for one instance.
+	mutex sync.Mutex
+
+	// challenges holds when each outstanding challenge expires. Each is
+	// removed once used, and expired ones are pruned as new ones are issued.
+	challengeMutex sync.Mutex
+	challenges     map[string]time.Time
+}
+
+// New returns a Gatekeeper that creates machines in org using client.
+// audience is the URL instances reach the gatekeeper on; GCP identity tokens
+// must be issued for it.
+func New(client *api.Client, org *envelope.Org, audience string,
+	constraints *Constraints) (*Gatekeeper, error) {
+
+	g := &Gatekeeper{
+		client:      client,
+		org:         org,
+		audience:    audience,
+		constraints: constraints,
+		http:        &http.Client{Timeout: 10 * time.Second},
+		challenges:  make(map[string]time.Time),
+	}
+
+	if constraints.AWSCertificateFile != "" {
+		b, err := ioutil.ReadFile(constraints.AWSCertificateFile)
+		if err != nil {
+			return nil, err
+		}
+
+		g.awsCerts, err = parseCertificates(b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// ServeHTTP implements http.Handler, handling challenge and bootstrap
+// requests.
+func (g *Gatekeeper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != BootstrapPath && r.URL.Path != ChallengePath {
+		writeError(w, http.StatusNotFound, apitypes.NotFoundError, "Not found")
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, apitypes.BadRequestError,
+			"Method not allowed")
+		return
+	}
+
+	if r.URL.Path == ChallengePath {
+		challenge, err := g.challenge(time.Now())
+		if err != nil {
+			log.Printf("Could not issue challenge to %s: %s", r.RemoteAddr, err)
+			writeError(w, http.StatusServiceUnavailable, apitypes.InternalServerError,
+				"Could not issue challenge")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(challenge)
+		return
+	}
+
+	req := apitypes.BootstrapRequest{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, apitypes.BadRequestError,
+			"Could not decode bootstrap request")
+		return
+	}
+
+	// Challenges are used up by any attempt, so each can only be tried once.
+	if !g.redeem(req.Challenge, time.Now()) {
+		log.Printf("Rejected %s bootstrap from %s: unknown or expired challenge",
+			req.Provider, r.RemoteAddr)
+		writeError(w, http.StatusUnauthorized, apitypes.UnauthorizedError,
+			"Challenge is unknown or has expired")
+		return
+	}
+
+	ctx := r.Context()
+	instance, err := g.verify(ctx, &req)
+	if err != nil {
+		log.Printf("Rejected %s bootstrap from %s: %s", req.Provider, r.RemoteAddr, err)
+		writeError(w, http.StatusUnauthorized, apitypes.UnauthorizedError,
+			"Instance identity could not be verified")
+		return
+	}
+
+	if !g.constraints.Allows(instance, req.Role) {
+		log.Printf("Rejected %s instance %s (account %s, region %s) for role %s",
+			instance.Provider, instance.ID, instance.Account, instance.Region, req.Role)
+		writeError(w, http.StatusUnauthorized, apitypes.UnauthorizedError,
+			"Instance is not allowed to join role "+req.Role)
+		return
+	}
+
+	resp, err := g.issue(ctx, instance, req.Role)
+	if err != nil {
+		if apiErr, ok := err.(*apitypes.Error); ok {
+			writeError(w, apiErr.StatusCode, apiErr.Type, apiErr.Err...)
+			return
+		}
+
+		log.Printf("Could not create machine for %s instance %s: %s",
+			instance.Provider, instance.ID, err)
+		writeError(w, http.StatusInternalServerError, apitypes.InternalServerError,
+			"Could not create machine")
+		return
+	}
+
+	log.Printf("Bootstrapped %s instance %s as machine %s in role %s",
+		instance.Provider, instance.ID, resp.Name, req.Role)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (g *Gatekeeper) verify(ctx context.Context, req *apitypes.BootstrapRequest) (*Instance, error) {
+	switch req.Provider {
+	case apitypes.AWSBootstrapProvider:
+		return verifyAWS(req.Identity, req.Signature, g.awsCerts, time.Now(),
+			g.constraints.awsMaxAge)
+	case apitypes.GCPBootstrapProvider:
+		keys, err := fetchGoogleKeys(ctx, g.http)
+		if err != nil {
+			return nil, err
+		}
+		return verifyGCP(req.Identity, gcpAudience(g.audience, req.Challenge), keys, time.Now())
+	default:
+		return nil, &apitypes.Error{
+			StatusCode: http.StatusBadRequest,
+			Type:       apitypes.BadRequestError,
+			Err:        []string{"Unknown provider: " + string(req.Provider)},
+		}
+	}
+}
+
+// challenge issues a new challenge at now, first pruning expired ones. It
+// fails if too many are outstanding.
+func (g *Gatekeeper) challenge(now time.Time) (*apitypes.BootstrapChallenge, error) {
+	value, err := randomChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	g.challengeMutex.Lock()
+	defer g.challengeMutex.Unlock()
+
+	for c, expires := range g.challenges {
+		if !now.Before(expires) {
+			delete(g.challenges, c)
+		}
+	}
+	if len(g.challenges) >= maxChallenges {
+		return nil, errors.New("too many outstanding challenges")
+	}
+
+	expires := now.Add(challengeTTL)
+	g.challenges[value] = expires
+	return &apitypes.BootstrapChallenge{Challenge: value, Expires: expires}, nil
+}
+
+// redeem returns whether challenge was issued by the gatekeeper and has not
+// expired as of now. It can only be redeemed once.
+func (g *Gatekeeper) redeem(challenge string, now time.Time) bool {
+	g.challengeMutex.Lock()
+	defer g.challengeMutex.Unlock()
+
+	expires, ok := g.challenges[challenge]
+	if !ok {
+		return false
+	}
+
+	delete(g.challenges, challenge)
+	return now.Before(expires)
+}
+
+// gcpAudience returns the audience GCP identity tokens are issued for: the
+// gatekeeper's URL, with the challenge added, so each token can only be used
+// with the challenge it was issued for.
+func gcpAudience(audience, challenge string) string {
+	return strings.TrimSuffix(audience, "/") + "?challenge=" + url.QueryEscape(challenge)
+}
+
+// issue creates a machine for the instance in the given role, returning its
+// credentials. Each instance may only bootstrap once.
+func (g *Gatekeeper) issue(ctx context.Context, instance *Instance,
+	role string) (*apitypes.BootstrapResponse, error) {
+
+	name := strings.ToLower(string(instance.Provider) + "-" + instance.ID)
+	if !pathexp.ValidSlug(name) {
+		return nil, &apitypes.Error{
+			StatusCode: http.StatusBadRequest,
+			Type:       apitypes.BadRequestError,
+			Err:        []string{"Instance id cannot be used as a machine name"},
+		}
+	}
+
+	teams, err := g.client.Teams.List(ctx, g.org.ID, role, primitive.MachineTeamType)
+	if err != nil {
+		return nil, err
+	}
+	if len(teams) < 1 {
+		return nil, &apitypes.Error{
+			StatusCode: http.StatusNotFound,
+			Type:       apitypes.NotFoundError,
+			Err:        []string{"Machine role not found: " + role},
+		}
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	state := primitive.MachineActiveState
+	existing, err := g.client.Machines.List(ctx, g.org.ID, &state, &name, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, &apitypes.Error{
+			StatusCode: http.StatusConflict,
+			Type:       apitypes.ConflictError,
+			Err:        []string{"Instance has already been bootstrapped"},
+		}
+	}
+
+	machine, secret, err := g.client.Machines.Create(ctx, g.org.ID, teams[0].ID, name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apitypes.BootstrapResponse{
+		Name:    name,
+		TokenID: machine.Tokens[0].Token.ID,
+		Secret:  secret,
+	}, nil
+}
+
+func writeError(w http.ResponseWriter, status int, errType string, msgs ...string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&apitypes.Error{Type: errType, Err: msgs})
+}
@@ -0,0 +1,228 @@
+package gatekeeper
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+func newKey(t *testing.T) (*rsa.PrivateKey, *x509.Certificate) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, cert
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, b []byte) []byte {
+	digest := sha256.Sum256(b)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sig
+}
+
+func TestVerifyAWS(t *testing.T) {
+	key, cert := newKey(t)
+	_, other := newKey(t)
+
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	doc := `{"accountId":"123456789012","region":"us-east-1","instanceId":"i-0abc",` +
+		`"pendingTime":"2017-03-01T11:55:00Z"}`
+	sig := base64.StdEncoding.EncodeToString(sign(t, key, []byte(doc)))
+
+	instance, err := verifyAWS(doc, sig, []*x509.Certificate{other, cert}, now, defaultAWSMaxAge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.Account != "123456789012" || instance.Region != "us-east-1" ||
+		instance.ID != "i-0abc" {
+		t.Errorf("unexpected instance: %+v", instance)
+	}
+
+	tampered := `{"accountId":"999999999999","region":"us-east-1","instanceId":"i-0abc",` +
+		`"pendingTime":"2017-03-01T11:55:00Z"}`
+	if _, err := verifyAWS(tampered, sig, []*x509.Certificate{cert}, now, defaultAWSMaxAge); err == nil {
+		t.Error("expected tampered document to fail verification")
+	}
+
+	later := now.Add(defaultAWSMaxAge)
+	if _, err := verifyAWS(doc, sig, []*x509.Certificate{cert}, later, defaultAWSMaxAge); err == nil {
+		t.Error("expected old document to fail verification")
+	}
+
+	undated := `{"accountId":"123456789012","region":"us-east-1","instanceId":"i-0abc"}`
+	undatedSig := base64.StdEncoding.EncodeToString(sign(t, key, []byte(undated)))
+	if _, err := verifyAWS(undated, undatedSig, []*x509.Certificate{cert}, now, defaultAWSMaxAge); err == nil {
+		t.Error("expected document without a pending time to fail verification")
+	}
+}
+
+func TestChallenges(t *testing.T) {
+	g := &Gatekeeper{challenges: make(map[string]time.Time)}
+	now := time.Now()
+
+	c, err := g.challenge(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := g.challenge(now.Add(-challengeTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		name      string
+		challenge string
+		ok        bool
+	}{
+		{"unknown", "made-up", false},
+		{"empty", "", false},
+		{"expired", expired.Challenge, false},
+		{"issued", c.Challenge, true},
+		{"reused", c.Challenge, false},
+	}
+
+	for _, tc := range tcs {
+		if ok := g.redeem(tc.challenge, now); ok != tc.ok {
+			t.Errorf("%s: got %t, want %t", tc.name, ok, tc.ok)
+		}
+	}
+}
+
+func TestChallengesPruned(t *testing.T) {
+	g := &Gatekeeper{challenges: make(map[string]time.Time)}
+	now := time.Now()
+
+	for i := 0; i < maxChallenges; i++ {
+		g.challenges[strconv.Itoa(i)] = now.Add(time.Minute)
+	}
+	if _, err := g.challenge(now); err == nil {
+		t.Error("expected an error with too many outstanding challenges")
+	}
+
+	if _, err := g.challenge(now.Add(time.Minute)); err != nil {
+		t.Errorf("expected expired challenges to be pruned: %s", err)
+	}
+	if len(g.challenges) != 1 {
+		t.Errorf("got %d challenges, want 1", len(g.challenges))
+	}
+}
+
+func TestVerifyGCP(t *testing.T) {
+	key, _ := newKey(t)
+	keys := map[string]*rsa.PublicKey{"k1": &key.PublicKey}
+	now := time.Now()
+
+	token := func(aud string, exp time.Time) string {
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+		claims, _ := json.Marshal(map[string]interface{}{
+			"iss": "https://accounts.google.com",
+			"aud": aud,
+			"exp": exp.Unix(),
+			"google": map[string]interface{}{
+				"compute_engine": map[string]string{
+					"project_id":  "acme",
+					"zone":        "us-central1-a",
+					"instance_id": "4242",
+				},
+			},
+		})
+
+		signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+			base64.RawURLEncoding.EncodeToString(claims)
+		return signed + "." + base64.RawURLEncoding.EncodeToString(sign(t, key, []byte(signed)))
+	}
+
+	instance, err := verifyGCP(token("https://gk", now.Add(time.Hour)), "https://gk", keys, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.Account != "acme" || instance.Region != "us-central1" || instance.ID != "4242" {
+		t.Errorf("unexpected instance: %+v", instance)
+	}
+
+	if _, err := verifyGCP(token("https://other", now.Add(time.Hour)), "https://gk", keys, now); err == nil {
+		t.Error("expected wrong audience to fail verification")
+	}
+	if _, err := verifyGCP(token("https://gk", now.Add(-time.Hour)), "https://gk", keys, now); err == nil {
+		t.Error("expected expired token to fail verification")
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	c := &Constraints{
+		AWSCertificateFile: "aws.pem",
+		Rules: []Rule{
+			{
+				Provider: apitypes.AWSBootstrapProvider,
+				Accounts: []string{"123"},
+				Regions:  []string{"us-east-1"},
+				Roles:    []string{"api"},
+			},
+			{
+				Provider: apitypes.GCPBootstrapProvider,
+				Accounts: []string{"acme"},
+				Roles:    []string{"worker"},
+			},
+		},
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		instance Instance
+		role     string
+		allowed  bool
+	}{
+		{Instance{apitypes.AWSBootstrapProvider, "123", "us-east-1", "i-1"}, "api", true},
+		{Instance{apitypes.AWSBootstrapProvider, "123", "us-west-2", "i-1"}, "api", false},
+		{Instance{apitypes.AWSBootstrapProvider, "456", "us-east-1", "i-1"}, "api", false},
+		{Instance{apitypes.AWSBootstrapProvider, "123", "us-east-1", "i-1"}, "worker", false},
+		{Instance{apitypes.GCPBootstrapProvider, "acme", "europe-west1", "1"}, "worker", true},
+		{Instance{apitypes.GCPBootstrapProvider, "123", "us-east-1", "1"}, "api", false},
+	}
+
+	for _, tc := range tcs {
+		if got := c.Allows(&tc.instance, tc.role); got != tc.allowed {
+			t.Errorf("%+v in %s: got %t, want %t", tc.instance, tc.role, got, tc.allowed)
+		}
+	}
+
+	c.Rules[1].Accounts = nil
+	if err := c.Validate(); err == nil {
+		t.Error("expected rule without accounts to be invalid")
+	}
+}
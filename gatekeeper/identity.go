@@ -0,0 +1,255 @@
+package gatekeeper
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+// googleCertsURL holds the certificates Google signs instance identity tokens
+// with, keyed by key id.
+const googleCertsURL = "https://www.googleapis.com/oauth2/v1/certs"
+
+var googleIssuers = []string{"https://accounts.google.com", "accounts.google.com"}
+
+var errBadSignature = errors.New("instance identity signature is invalid")
+
+// Instance describes a cloud instance whose identity has been verified.
+type Instance struct {
+	Provider apitypes.BootstrapProvider
+	Account  string
+	Region   string
+	ID       string
+}
+
+// awsIdentityDocument holds the fields we care about from an EC2 instance
+// identity document.
+type awsIdentityDocument struct {
+	AccountID   string    `json:"accountId"`
+	Region      string    `json:"region"`
+	InstanceID  string    `json:"instanceId"`
+	PendingTime time.Time `json:"pendingTime"`
+}
+
+// verifyAWS checks the RSA-SHA256 signature of an EC2 instance identity
+// document against the given AWS certificates. The document is the same for
+// as long as the instance runs, so it is only accepted for maxAge after the
+// instance was launched or last started.
+func verifyAWS(document, signature string, certs []*x509.Certificate,
+	now time.Time, maxAge time.Duration) (*Instance, error) {
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return nil, errBadSignature
+	}
+
+	verified := false
+	for _, cert := range certs {
+		err = cert.CheckSignature(x509.SHA256WithRSA, []byte(document), sig)
+		if err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errBadSignature
+	}
+
+	doc := awsIdentityDocument{}
+	err = json.Unmarshal([]byte(document), &doc)
+	if err != nil {
+		return nil, err
+	}
+	if doc.AccountID == "" || doc.InstanceID == "" || doc.PendingTime.IsZero() {
+		return nil, errors.New("instance identity document is incomplete")
+	}
+	if now.Sub(doc.PendingTime) > maxAge {
+		return nil, errors.New("instance identity document is too old")
+	}
+
+	return &Instance{
+		Provider: apitypes.AWSBootstrapProvider,
+		Account:  doc.AccountID,
+		Region:   doc.Region,
+		ID:       doc.InstanceID,
+	}, nil
+}
+
+type gcpTokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type gcpTokenClaims struct {
+	Iss    string `json:"iss"`
+	Aud    string `json:"aud"`
+	Exp    int64  `json:"exp"`
+	Google struct {
+		ComputeEngine struct {
+			ProjectID  string `json:"project_id"`
+			Zone       string `json:"zone"`
+			InstanceID string `json:"instance_id"`
+		} `json:"compute_engine"`
+	} `json:"google"`
+}
+
+// verifyGCP checks a GCE instance identity token, which is a JWT signed by
+// Google, for the given audience.
+func verifyGCP(token, audience string, keys map[string]*rsa.PublicKey,
+	now time.Time) (*Instance, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed instance identity token")
+	}
+
+	header := gcpTokenHeader{}
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return nil, err
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported token algorithm: %s", header.Alg)
+	}
+
+	key, ok := keys[header.Kid]
+	if !ok {
+		return nil, errBadSignature
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errBadSignature
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig)
+	if err != nil {
+		return nil, errBadSignature
+	}
+
+	claims := gcpTokenClaims{}
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return nil, err
+	}
+
+	validIssuer := false
+	for _, iss := range googleIssuers {
+		validIssuer = validIssuer || claims.Iss == iss
+	}
+	if !validIssuer {
+		return nil, errors.New("instance identity token has the wrong issuer")
+	}
+	if claims.Aud != audience {
+		return nil, errors.New("instance identity token has the wrong audience")
+	}
+	if now.After(time.Unix(claims.Exp, 0)) {
+		return nil, errors.New("instance identity token has expired")
+	}
+
+	ce := claims.Google.ComputeEngine
+	if ce.ProjectID == "" || ce.InstanceID == "" {
+		return nil, errors.New("instance identity token is missing instance details")
+	}
+
+	// Zones are a region with a single letter suffix, ie us-central1-a.
+	region := ce.Zone
+	if idx := strings.LastIndex(region, "-"); idx != -1 {
+		region = region[:idx]
+	}
+
+	return &Instance{
+		Provider: apitypes.GCPBootstrapProvider,
+		Account:  ce.ProjectID,
+		Region:   region,
+		ID:       ce.InstanceID,
+	}, nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// fetchGoogleKeys retrieves the public keys Google currently signs instance
+// identity tokens with.
+func fetchGoogleKeys(ctx context.Context, client *http.Client) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequest("GET", googleCertsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch google certificates: %s", resp.Status)
+	}
+
+	raw := make(map[string]string)
+	err = json.NewDecoder(resp.Body).Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(raw))
+	for kid, p := range raw {
+		certs, err := parseCertificates([]byte(p))
+		if err != nil || len(certs) != 1 {
+			continue
+		}
+
+		if key, ok := certs[0].PublicKey.(*rsa.PublicKey); ok {
+			keys[kid] = key
+		}
+	}
+
+	return keys, nil
+}
+
+// parseCertificates decodes every PEM encoded certificate in b.
+func parseCertificates(b []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+
+	return certs, nil
+}
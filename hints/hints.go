@@ -21,12 +21,24 @@ func Init() {
 		"deny": {
 			"Restrict access to secrets for a team or role using `torus deny`",
 		},
+		"guests remove": {
+			"Revoke a guest's access to an environment with `torus guests remove`",
+		},
+		"history": {
+			"List the previous values of a secret with `torus history`",
+		},
 		"invites approve": {
 			"Approve multiple invites with `torus worklog resolve`",
 		},
+		"invites resend": {
+			"Resend a bounced or lost invitation email with `torus invites resend`",
+		},
 		"invites send": {
 			"Invite another user to join your organization with `torus invites send`",
 		},
+		"keyrings members": {
+			"See who can decrypt the secrets at a path with `torus keyrings members`",
+		},
 		"link": {
 			"Define an organization and project for your current working directory using `torus link`",
 		},
@@ -41,15 +53,27 @@ func Init() {
 			"Display policies for your organization with `torus policies list`",
 			"View details of an existing policy with `torus policies view`",
 		},
+		"policies detach": {
+			"Remove a policy from a team or role with `torus policies detach`",
+		},
 		"projects": {
 			"Create a project for your secrets using `torus projects create`",
 		},
+		"rollback": {
+			"Restore a previous value of a secret with `torus rollback --version`",
+		},
 		"run": {
 			"Start your process with your decrypted secrets using `torus run`",
 		},
+		"sessions revoke": {
+			"Log out a lost or unused device with `torus sessions revoke`",
+		},
 		"teams members": {
 			"Display current members of your organization with `torus members member`",
 		},
+		"worklog": {
+			"Find secrets that should be rotated after removing access with `torus worklog list`",
+		},
 		"view": {
 			"View secret values which have been set using `torus view`",
 			"See the exact path for each secret set using `torus view -v`",
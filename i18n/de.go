@@ -0,0 +1,117 @@
+package i18n
+
+// de is the German catalog.
+var de = map[string]string{
+	// Errors and usage
+	"Usage:":                             "Verwendung:",
+	"Missing flags: %s":                  "Fehlende Optionen: %s",
+	"Org not found.":                     "Organisation nicht gefunden.",
+	"Project not found.":                 "Projekt nicht gefunden.",
+	"Role not found.":                    "Rolle nicht gefunden.",
+	"Too many arguments provided.":       "Zu viele Argumente angegeben.",
+	"Name or path is required.":          "Ein Name oder Pfad ist erforderlich.",
+	"Could not communicate with daemon.": "Kommunikation mit dem Daemon nicht möglich.",
+	"The daemon version is incorrect. Check for stale processes.": "Die Version des Daemons ist falsch. Prüfen Sie, " +
+		"ob noch alte Prozesse laufen.",
+	"The daemon version is out of date and is being restarted.": "Der Daemon ist veraltet und wird neu gestartet.",
+	"You will need to login again.":                             "Sie müssen sich erneut anmelden.",
+	"Attempting to login with email: %s":                        "Anmeldung mit E-Mail-Adresse %s wird versucht",
+	"Attempting to login with token id: %s":                     "Anmeldung mit Token-ID %s wird versucht",
+	"Could not log in.":                                         "Anmeldung fehlgeschlagen.",
+	"You must be logged in to run '%s'.\nLogin using 'login' or create an account using 'signup'.": "Sie müssen " +
+		"angemeldet sein, um '%s' auszuführen.\nMelden Sie sich mit 'login' an, oder erstellen Sie ein Konto " +
+		"mit 'signup'.",
+
+	// Prompts
+	"Do you wish to continue":                               "Möchten Sie fortfahren",
+	"The action you are about to perform cannot be undone.": "Diese Aktion kann nicht rückgängig gemacht werden.",
+	"Name":                      "Name",
+	"Email":                     "E-Mail",
+	"Username":                  "Benutzername",
+	"Password":                  "Passwort",
+	"Confirm %s":                "%s bestätigen",
+	"Verification code":         "Bestätigungscode",
+	"Invite Code":               "Einladungscode",
+	"Select project":            "Projekt auswählen",
+	"Create a new project":      "Neues Projekt erstellen",
+	"Select organization":       "Organisation auswählen",
+	"Create a new organization": "Neue Organisation erstellen",
+	"Select Team":               "Team auswählen",
+	"Create a new team":         "Neues Team erstellen",
+	"Select Machine Role":       "Maschinenrolle auswählen",
+	"Create a new role":         "Neue Rolle erstellen",
+	"Machine Role":              "Maschinenrolle",
+	"Project name":              "Projektname",
+	"Org name":                  "Name der Organisation",
+	"Do you want to login or create an account?": "Möchten Sie sich anmelden oder ein Konto erstellen?",
+	"What would you like to update?":             "Was möchten Sie ändern?",
+	"Please enter a valid invite code":           "Bitte geben Sie einen gültigen Einladungscode ein",
+	"Please enter a valid code":                  "Bitte geben Sie einen gültigen Code ein",
+	"Please enter a valid email address":         "Bitte geben Sie eine gültige E-Mail-Adresse ein",
+	"Please enter a valid username":              "Bitte geben Sie einen gültigen Benutzernamen ein",
+	"Please enter a valid name":                  "Bitte geben Sie einen gültigen Namen ein",
+	"Please enter your password":                 "Bitte geben Sie Ihr Passwort ein",
+	"Please confirm your password":               "Bitte bestätigen Sie Ihr Passwort",
+	"Passwords do not match":                     "Die Passwörter stimmen nicht überein",
+	"Passwords must be at least 8 characters":    "Passwörter müssen mindestens 8 Zeichen lang sein",
+
+	// Progress
+	"Keypairs generated":       "Schlüsselpaare erzeugt",
+	"Signing keys signed":      "Signaturschlüssel signiert",
+	"Signing keys uploaded":    "Signaturschlüssel hochgeladen",
+	"Encryption keys signed":   "Verschlüsselungsschlüssel signiert",
+	"Encryption keys uploaded": "Verschlüsselungsschlüssel hochgeladen",
+	"(about %ds left)":         "(noch etwa %ds)",
+
+	// Hints
+	"Protip:": "Tipp:",
+
+	// Table headers
+	"ACCESS":        "ZUGRIFF",
+	"ACTION":        "AKTION",
+	"ACTOR":         "AKTEUR",
+	"ALLOWED FROM":  "ERLAUBT VON",
+	"ATTACHED TO":   "ZUGEWIESEN AN",
+	"ATTACHMENT ID": "ZUWEISUNGS-ID",
+	"COMMAND":       "BEFEHL",
+	"CREATED":       "ERSTELLT",
+	"CREATED BY":    "ERSTELLT VON",
+	"CREATED ON":    "ERSTELLT AM",
+	"CREATION DATE": "ERSTELLUNGSDATUM",
+	"DELIVERY":      "ZUSTELLUNG",
+	"DEVICE":        "GERÄT",
+	"EFFECT":        "WIRKUNG",
+	"EMAIL":         "E-MAIL",
+	"ENCRYPTED BY":  "VERSCHLÜSSELT VON",
+	"EXPIRES":       "LÄUFT AB",
+	"IDENTITY":      "IDENTITÄT",
+	"INVITED BY":    "EINGELADEN VON",
+	"KEY TYPE":      "SCHLÜSSELTYP",
+	"LAST USED":     "ZULETZT VERWENDET",
+	"MEMBER":        "MITGLIED",
+	"MEMBERS":       "MITGLIEDER",
+	"MISSING FROM":  "FEHLT IN",
+	"ORG":           "ORGANISATION",
+	"OWNER":         "EIGENTÜMER",
+	"PATH":          "PFAD",
+	"POLICY":        "RICHTLINIE",
+	"POLICY NAME":   "RICHTLINIENNAME",
+	"PROBLEMS":      "PROBLEME",
+	"PUBLIC KEY":    "ÖFFENTLICHER SCHLÜSSEL",
+	"RESULT":        "ERGEBNIS",
+	"ROLE":          "ROLLE",
+	"SECRET":        "GEHEIMNIS",
+	"SHARED":        "GETEILT",
+	"SIGNATURE":     "SIGNATUR",
+	"SOURCE":        "QUELLE",
+	"STATE":         "ZUSTAND",
+	"STATEMENT":     "ANWEISUNG",
+	"SUBJECT":       "OBJEKT",
+	"TIME":          "ZEIT",
+	"TOKEN ID":      "TOKEN-ID",
+	"TYPE":          "TYP",
+	"USERNAME":      "BENUTZERNAME",
+	"VALID":         "GÜLTIG",
+	"VALUE":         "WERT",
+	"WAITING":       "WARTEZEIT",
+}
@@ -0,0 +1,93 @@
+// Package i18n translates user facing messages into the user's language.
+//
+// Messages are written in English throughout the CLI, and the English text is
+// used as the key into each language's catalog. Messages missing from a
+// catalog are displayed in English.
+//
+// To add a language, add a file named after its code (such as de.go)
+// containing its catalog, and register it in catalogs. Translations must keep
+// the same formatting verbs, in the same order, as the messages they
+// translate.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultLanguage is the language messages are written in.
+const DefaultLanguage = "en"
+
+// catalogs holds the translations for every supported language, keyed by
+// lower cased language code.
+var catalogs = map[string]map[string]string{
+	"de": de,
+}
+
+// active is the catalog for the selected language. It is nil for English.
+var active map[string]string
+
+// Init selects the language messages are displayed in. lang is used if set,
+// otherwise the language is detected from the environment.
+func Init(lang string) {
+	active = catalogs[Detect(lang)]
+}
+
+// Detect returns the supported language best matching lang, or, if lang is
+// empty, the locale set in the environment. It returns DefaultLanguage if no
+// supported language matches.
+func Detect(lang string) string {
+	if lang == "" {
+		for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+			if lang = os.Getenv(name); lang != "" {
+				break
+			}
+		}
+	}
+
+	// Locales look like de_DE.UTF-8 or de_DE@euro; only the language and
+	// region matter.
+	if idx := strings.IndexAny(lang, ".@"); idx != -1 {
+		lang = lang[:idx]
+	}
+	lang = strings.ToLower(strings.Replace(lang, "-", "_", -1))
+
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+
+	if idx := strings.Index(lang, "_"); idx != -1 {
+		if _, ok := catalogs[lang[:idx]]; ok {
+			return lang[:idx]
+		}
+	}
+
+	return DefaultLanguage
+}
+
+// Languages returns the codes of all supported languages.
+func Languages() []string {
+	langs := []string{DefaultLanguage}
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+
+	sort.Strings(langs)
+	return langs
+}
+
+// T returns msg translated into the selected language. If args are given,
+// the translated message is used as a format string for them.
+func T(msg string, args ...interface{}) string {
+	if translated, ok := active[msg]; ok {
+		msg = translated
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+
+	return msg
+}
@@ -0,0 +1,67 @@
+package i18n
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var verbPattern = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+func TestCatalogsKeepFormatVerbs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for msg, translated := range catalog {
+			want := strings.Join(verbPattern.FindAllString(msg, -1), " ")
+			got := strings.Join(verbPattern.FindAllString(translated, -1), " ")
+			if got != want {
+				t.Errorf("%s: translation of %q has verbs %q, want %q", lang, msg, got, want)
+			}
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Unsetenv(name)
+	}
+
+	tcs := []struct {
+		lang string
+		env  string
+		out  string
+	}{
+		{"de", "", "de"},
+		{"de-AT", "", "de"},
+		{"DE_de.UTF-8", "", "de"},
+		{"xx", "de_DE.UTF-8", DefaultLanguage},
+		{"", "de_CH.UTF-8@euro", "de"},
+		{"", "C", DefaultLanguage},
+		{"", "", DefaultLanguage},
+	}
+
+	for _, tc := range tcs {
+		os.Setenv("LANG", tc.env)
+		if out := Detect(tc.lang); out != tc.out {
+			t.Errorf("Detect(%q) with LANG=%q: got %q want %q", tc.lang, tc.env, out, tc.out)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	defer Init(DefaultLanguage)
+
+	Init("de")
+	if out := T("Missing flags: %s", "--org"); out != "Fehlende Optionen: --org" {
+		t.Errorf("wrong translation: %q", out)
+	}
+	if out := T("Not in any catalog"); out != "Not in any catalog" {
+		t.Errorf("untranslated message changed: %q", out)
+	}
+
+	Init(DefaultLanguage)
+	if out := T("Missing flags: %s", "--org"); out != "Missing flags: --org" {
+		t.Errorf("wrong message: %q", out)
+	}
+}
@@ -4,13 +4,12 @@ package identity
 
 import (
 	"crypto/rand"
-	"encoding/json"
 	"errors"
-	"strconv"
 
 	"github.com/dchest/blake2b"
 
 	"github.com/manifoldco/torus-cli/base32"
+	"github.com/manifoldco/torus-cli/canonical"
 )
 
 const (
@@ -72,15 +71,13 @@ func NewImmutable(body Immutable, sig interface{}) (ID, error) {
 		return ID{}, err
 	}
 
-	h.Write([]byte(strconv.Itoa(body.Version())))
-
-	b, err := json.Marshal(body)
+	b, err := canonical.SigningBytes(body.Version(), body)
 	if err != nil {
 		return ID{}, err
 	}
 	h.Write(b)
 
-	b, err = json.Marshal(sig)
+	b, err = canonical.Marshal(sig)
 	if err != nil {
 		return ID{}, err
 	}
@@ -0,0 +1,318 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultNamespace is used when neither the caller nor the kubeconfig context
+// name a namespace.
+const DefaultNamespace = "default"
+
+// kubeconfig is the subset of a kubeconfig file needed to reach a cluster.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+			Username              string `yaml:"username"`
+			Password              string `yaml:"password"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// KubeconfigPath returns the kubeconfig file kubectl would use: the first
+// file listed in $KUBECONFIG, or ~/.kube/config.
+func KubeconfigPath() string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return filepath.SplitList(env)[0]
+	}
+
+	return filepath.Join(os.Getenv("HOME"), ".kube", "config")
+}
+
+// Client talks to the API server of a Kubernetes cluster.
+type Client struct {
+	http      *http.Client
+	server    string
+	header    http.Header
+	namespace string
+}
+
+// NewClient returns a Client for the cluster of the named context in the
+// kubeconfig file at path. The current context is used if name is empty.
+//
+// Token, client certificate and basic authentication are supported.
+// Authentication through exec or auth provider plugins is not.
+func NewClient(path, name string) (*Client, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := kubeconfig{}
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig %s: %s", path, err)
+	}
+
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+
+	// Files named in a kubeconfig are relative to it.
+	dir := filepath.Dir(path)
+	readFile := func(file string) ([]byte, error) {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		return ioutil.ReadFile(file)
+	}
+
+	c := &Client{header: http.Header{}}
+	var clusterName, userName string
+	found := false
+	for _, ctx := range cfg.Contexts {
+		if ctx.Name == name {
+			clusterName = ctx.Context.Cluster
+			userName = ctx.Context.User
+			c.namespace = ctx.Context.Namespace
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("context %q not found in %s", name, path)
+	}
+
+	tlsConfig := &tls.Config{}
+	found = false
+	for _, cluster := range cfg.Clusters {
+		if cluster.Name != clusterName {
+			continue
+		}
+
+		found = true
+		c.server = strings.TrimRight(cluster.Cluster.Server, "/")
+		tlsConfig.InsecureSkipVerify = cluster.Cluster.InsecureSkipTLSVerify
+
+		var ca []byte
+		switch {
+		case cluster.Cluster.CertificateAuthorityData != "":
+			ca, err = base64.StdEncoding.DecodeString(cluster.Cluster.CertificateAuthorityData)
+		case cluster.Cluster.CertificateAuthority != "":
+			ca, err = readFile(cluster.Cluster.CertificateAuthority)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read certificate authority: %s", err)
+		}
+		if ca != nil {
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+				return nil, errors.New("invalid certificate authority")
+			}
+		}
+		break
+	}
+	if !found {
+		return nil, fmt.Errorf("cluster %q not found in %s", clusterName, path)
+	}
+
+	for _, user := range cfg.Users {
+		if user.Name != userName {
+			continue
+		}
+
+		u := user.User
+		switch {
+		case u.Token != "":
+			c.header.Set("Authorization", "Bearer "+u.Token)
+		case u.TokenFile != "":
+			token, err := readFile(u.TokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("could not read token: %s", err)
+			}
+			c.header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+		case u.Username != "":
+			auth := base64.StdEncoding.EncodeToString([]byte(u.Username + ":" + u.Password))
+			c.header.Set("Authorization", "Basic "+auth)
+		}
+
+		var cert, key []byte
+		if u.ClientCertificateData != "" {
+			cert, err = base64.StdEncoding.DecodeString(u.ClientCertificateData)
+		} else if u.ClientCertificate != "" {
+			cert, err = readFile(u.ClientCertificate)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read client certificate: %s", err)
+		}
+
+		if u.ClientKeyData != "" {
+			key, err = base64.StdEncoding.DecodeString(u.ClientKeyData)
+		} else if u.ClientKey != "" {
+			key, err = readFile(u.ClientKey)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read client key: %s", err)
+		}
+
+		if cert != nil && key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid client certificate: %s", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+		break
+	}
+
+	c.http = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	return c, nil
+}
+
+// Namespace returns the namespace of the kubeconfig context, or
+// DefaultNamespace if it does not name one.
+func (c *Client) Namespace() string {
+	if c.namespace == "" {
+		return DefaultNamespace
+	}
+	return c.namespace
+}
+
+// Server returns the address of the cluster's API server.
+func (c *Client) Server() string {
+	return c.server
+}
+
+// GetSecret returns the named Secret, or nil if it does not exist.
+func (c *Client) GetSecret(ctx context.Context, namespace, name string) (*Secret, error) {
+	s := &Secret{}
+	err := c.do(ctx, "GET", secretPath(namespace, name), nil, s)
+	if e, ok := err.(*Error); ok && e.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// CreateSecret creates s.
+func (c *Client) CreateSecret(ctx context.Context, s *Secret) error {
+	return c.do(ctx, "POST", secretPath(s.Metadata.Namespace, ""), s, nil)
+}
+
+// UpdateSecret replaces an existing Secret with s. The update fails with a
+// conflict if s.Metadata.ResourceVersion is set, and the Secret has changed
+// since that version.
+func (c *Client) UpdateSecret(ctx context.Context, s *Secret) error {
+	return c.do(ctx, "PUT", secretPath(s.Metadata.Namespace, s.Metadata.Name), s, nil)
+}
+
+func secretPath(namespace, name string) string {
+	p := "/api/v1/namespaces/" + namespace + "/secrets"
+	if name != "" {
+		p += "/" + name
+	}
+	return p
+}
+
+// Error is an error returned by the Kubernetes API server.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, v interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, c.server+path, r)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		// The API server describes errors with a Status object.
+		status := struct {
+			Message string `json:"message"`
+		}{}
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(msg, &status) != nil || status.Message == "" {
+			status.Message = string(bytes.TrimSpace(msg))
+		}
+		return &Error{StatusCode: resp.StatusCode, Message: status.Message}
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
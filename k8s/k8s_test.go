@@ -0,0 +1,107 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	stored := NewSecret("apps", "api", "/org/project/prod/api/*/*",
+		map[string]string{"PORT": "8080"})
+	stored.Metadata.ResourceVersion = "7"
+
+	var updated *Secret
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/namespaces/apps/secrets/api":
+			json.NewEncoder(w).Encode(stored)
+		case "PUT /api/v1/namespaces/apps/secrets/api":
+			updated = &Secret{}
+			json.NewDecoder(r.Body).Decode(updated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"kind":"Status","message":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "torus-k8s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kubeconfig := `
+current-context: prod
+clusters:
+- name: prod
+  cluster:
+    server: ` + srv.URL + `
+users:
+- name: deploy
+  user:
+    token: abc
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: deploy
+    namespace: apps
+`
+	path := filepath.Join(dir, "config")
+	if err := ioutil.WriteFile(path, []byte(kubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClient(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Namespace() != "apps" {
+		t.Errorf("wrong namespace %q", c.Namespace())
+	}
+
+	ctx := context.Background()
+	s, err := c.GetSecret(ctx, "apps", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s == nil || !s.Managed() {
+		t.Fatalf("expected a managed secret, got %+v", s)
+	}
+	values, err := s.Values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := values["PORT"]; v == nil || *v != "8080" {
+		t.Errorf("wrong values %v", values)
+	}
+
+	missing, err := c.GetSecret(ctx, "apps", "other")
+	if err != nil || missing != nil {
+		t.Errorf("expected no secret, got %v, %v", missing, err)
+	}
+
+	err = c.UpdateSecret(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated == nil || updated.Metadata.ResourceVersion != "7" {
+		t.Errorf("expected the update to keep the resource version, got %+v", updated)
+	}
+
+	if _, err := NewClient(path, "staging"); err == nil {
+		t.Error("expected an error for an unknown context")
+	}
+}
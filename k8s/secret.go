@@ -0,0 +1,86 @@
+// Package k8s syncs secrets into Kubernetes Secret objects, so workloads in a
+// cluster receive managed values without running the torus daemon.
+package k8s
+
+import (
+	"encoding/base64"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Secrets written by torus are marked with these labels and annotations, so
+// that later syncs can tell them apart from Secrets managed by other tools.
+const (
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	PathAnnotation = "torus.sh/path"
+
+	managedBy = "torus"
+)
+
+// Secret is a Kubernetes Secret object.
+type Secret struct {
+	APIVersion string     `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string     `json:"kind" yaml:"kind"`
+	Metadata   ObjectMeta `json:"metadata" yaml:"metadata"`
+	Type       string     `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// Data holds the base64 encoded value of each key.
+	Data map[string]string `json:"data" yaml:"data"`
+}
+
+// ObjectMeta is the metadata of a Kubernetes object.
+type ObjectMeta struct {
+	Name            string            `json:"name" yaml:"name"`
+	Namespace       string            `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty" yaml:"resourceVersion,omitempty"`
+}
+
+// NewSecret returns an Opaque Secret holding values, marked as managed by
+// torus and synced from path.
+func NewSecret(namespace, name, path string, values map[string]string) *Secret {
+	data := make(map[string]string, len(values))
+	for k, v := range values {
+		data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+
+	return &Secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Type:       "Opaque",
+		Metadata: ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      map[string]string{ManagedByLabel: managedBy},
+			Annotations: map[string]string{PathAnnotation: path},
+		},
+		Data: data,
+	}
+}
+
+// Managed returns whether the Secret was written by torus.
+func (s *Secret) Managed() bool {
+	return s.Metadata.Labels[ManagedByLabel] == managedBy
+}
+
+// Values returns the decoded value of each key in the Secret.
+func (s *Secret) Values() (map[string]*string, error) {
+	values := make(map[string]*string, len(s.Data))
+	for k, v := range s.Data {
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, err
+		}
+
+		value := string(b)
+		values[k] = &value
+	}
+
+	return values, nil
+}
+
+// YAML returns the Secret as a YAML manifest, suitable for `kubectl apply`.
+func (s *Secret) YAML() ([]byte, error) {
+	return yaml.Marshal(s)
+}
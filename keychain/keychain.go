@@ -0,0 +1,38 @@
+// Package keychain stores secrets in the operating system's keychain: the
+// macOS Keychain, the Windows Credential Manager, or a Secret Service such as
+// GNOME Keyring, through libsecret's secret-tool.
+package keychain
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+// service names the entries Torus keeps in the keychain.
+const service = "torus"
+
+// ErrNotFound is returned when the keychain has no secret for an account.
+var ErrNotFound = errors.New("no secret found in the keychain")
+
+// ErrUnsupported is returned when there's no keychain to use on this system.
+var ErrUnsupported = errors.New("no keychain is available on this system")
+
+// Set stores secret for account, replacing any secret stored for it before.
+func Set(account string, secret []byte) error {
+	return set(account, base64.StdEncoding.EncodeToString(secret))
+}
+
+// Get returns the secret stored for account.
+func Get(account string) ([]byte, error) {
+	encoded, err := get(account)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+// Delete removes the secret stored for account, if there is one.
+func Delete(account string) error {
+	return del(account)
+}
@@ -0,0 +1,76 @@
+package keychain
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// errSecItemNotFound is the exit status of security when there's no item.
+const errSecItemNotFound = 44
+
+// set adds the item with security's interactive mode, so the secret is read
+// from stdin rather than given as an argument other processes could see.
+func set(account, secret string) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf(
+		"add-generic-password -U -s %q -a %q -w %q\n", service, account, secret))
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return securityError(err, out)
+	}
+
+	// The interactive mode exits successfully even when a command fails.
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return fmt.Errorf("security: %s", msg)
+	}
+	return nil
+}
+
+func get(account string) (string, error) {
+	cmd := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", securityError(err, stderr.Bytes())
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+func del(account string) error {
+	out, err := exec.Command("security", "delete-generic-password",
+		"-s", service, "-a", account).CombinedOutput()
+
+	err = securityError(err, out)
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func securityError(err error, out []byte) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*exec.Error); ok {
+		return ErrUnsupported
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		status, ok := exitErr.Sys().(syscall.WaitStatus)
+		if ok && status.ExitStatus() == errSecItemNotFound {
+			return ErrNotFound
+		}
+	}
+
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return fmt.Errorf("security: %s", msg)
+	}
+	return err
+}
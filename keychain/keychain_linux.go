@@ -0,0 +1,61 @@
+package keychain
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// set stores the secret with secret-tool, which reads it from stdin.
+func set(account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=Torus login",
+		"service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+
+	out, err := cmd.CombinedOutput()
+	return secretToolError(err, out)
+}
+
+// get looks up the secret. secret-tool exits with status 1, and prints
+// nothing, when there is none.
+func get(account string) (string, error) {
+	cmd := exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok && len(out) == 0 && stderr.Len() == 0 {
+			return "", ErrNotFound
+		}
+		return "", secretToolError(err, stderr.Bytes())
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+func del(account string) error {
+	out, err := exec.Command("secret-tool", "clear",
+		"service", service, "account", account).CombinedOutput()
+
+	// Clearing a secret that isn't there exits with status 1 too.
+	if _, ok := err.(*exec.ExitError); ok && len(bytes.TrimSpace(out)) == 0 {
+		return nil
+	}
+	return secretToolError(err, out)
+}
+
+func secretToolError(err error, out []byte) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*exec.Error); ok {
+		return ErrUnsupported
+	}
+
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return fmt.Errorf("secret-tool: %s", msg)
+	}
+	return err
+}
@@ -0,0 +1,16 @@
+//go:build !darwin && !linux && !windows
+// +build !darwin,!linux,!windows
+
+package keychain
+
+func set(account, secret string) error {
+	return ErrUnsupported
+}
+
+func get(account string) (string, error) {
+	return "", ErrUnsupported
+}
+
+func del(account string) error {
+	return ErrUnsupported
+}
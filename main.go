@@ -2,12 +2,16 @@ package main
 
 import (
 	"os"
+	"strings"
 
 	"github.com/urfave/cli"
 
 	"github.com/manifoldco/torus-cli/cmd"
+	"github.com/manifoldco/torus-cli/cmd/output"
 	"github.com/manifoldco/torus-cli/config"
+	"github.com/manifoldco/torus-cli/errs"
 	"github.com/manifoldco/torus-cli/hints"
+	"github.com/manifoldco/torus-cli/i18n"
 	"github.com/manifoldco/torus-cli/prefs"
 	"github.com/manifoldco/torus-cli/ui"
 )
@@ -26,6 +30,87 @@ func main() {
 	app.HelpName = "torus"
 	app.Usage = "A secure, shared workspace for secrets"
 	app.Version = config.Version
-	app.Commands = cmd.Cmds
-	app.Run(os.Args)
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "lang",
+			Usage:  "Display messages in this language (" + strings.Join(i18n.Languages(), ", ") + ")",
+			EnvVar: "TORUS_LANG",
+		},
+		cli.StringFlag{
+			Name:  "output-format",
+			Usage: "Write the results of list and view commands as text or json",
+			Value: output.Text,
+		},
+		cli.BoolFlag{
+			Name:   "no-prompt",
+			Usage:  "Never prompt for input; read answers from the environment, or fail",
+			EnvVar: cmd.NoPromptEnv,
+		},
+		cli.StringFlag{
+			Name:   "break-glass",
+			Usage:  "Read secrets from frozen keyrings, giving this reason (org owners only)",
+			EnvVar: "TORUS_BREAK_GLASS",
+		},
+		cli.BoolFlag{
+			Name:   "verbose",
+			Usage:  "Print a transcript of requests made to the daemon and registry to stderr",
+			EnvVar: "TORUS_VERBOSE",
+		},
+		cli.StringFlag{
+			Name:   "trace-file",
+			Usage:  "Append the transcript of requests to `FILE`, as JSON lines, instead",
+			EnvVar: "TORUS_TRACE_FILE",
+		},
+	}
+	app.Before = func(ctx *cli.Context) error {
+		lang := ctx.GlobalString("lang")
+		if lang == "" && preferences != nil {
+			lang = preferences.Core.Lang
+		}
+		i18n.Init(lang)
+
+		err := output.Validate(ctx.GlobalString("output-format"))
+		if err != nil {
+			return errs.NewExitError(err.Error())
+		}
+
+		// Prompts check the environment, so commands run by plugins and
+		// aliases don't prompt either.
+		if ctx.GlobalBool("no-prompt") {
+			err = os.Setenv(cmd.NoPromptEnv, "true")
+			if err != nil {
+				return err
+			}
+		}
+
+		// The api client reads these from the environment, as it does for
+		// any command run with them set.
+		env := map[string]string{
+			"TORUS_BREAK_GLASS": ctx.GlobalString("break-glass"),
+			"TORUS_TRACE_FILE":  ctx.GlobalString("trace-file"),
+		}
+		if ctx.GlobalBool("verbose") {
+			env["TORUS_VERBOSE"] = "true"
+		}
+		for k, v := range env {
+			if v == "" {
+				continue
+			}
+			err = os.Setenv(k, v)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	app.Commands = append(cmd.Cmds, cmd.Plugins(cmd.Cmds)...)
+	cmd.HideUnsupported(app.Commands)
+	cmd.Instrument(app.Commands)
+
+	args, err := cmd.ExpandAlias(os.Args, app.Commands)
+	if err != nil {
+		cli.HandleExitCoder(err)
+		return
+	}
+	app.Run(args)
 }
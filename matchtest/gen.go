@@ -0,0 +1,276 @@
+package matchtest
+
+import (
+	"math/rand"
+	"strings"
+)
+
+// Gen generates path expressions, paths, and policy resources. Values are
+// drawn from a small alphabet, so that generated expressions often overlap.
+type Gen struct {
+	rand *rand.Rand
+}
+
+// NewGen returns a Gen whose values are determined by seed, so a failing case
+// can be generated again.
+func NewGen(seed int64) *Gen {
+	return &Gen{rand: rand.New(rand.NewSource(seed))}
+}
+
+const (
+	slugStart = "ab1"
+	slugRest  = "ab1-_"
+)
+
+// Slug returns a valid literal segment. Most are short, but some are as long
+// as a slug may be.
+func (g *Gen) Slug() string {
+	n := 1 + g.rand.Intn(3)
+	if g.rand.Intn(20) == 0 {
+		n = 64
+	}
+
+	b := []byte{slugStart[g.rand.Intn(len(slugStart))]}
+	for len(b) < n {
+		b = append(b, slugRest[g.rand.Intn(len(slugRest))])
+	}
+	return string(b)
+}
+
+// item returns a literal or glob.
+func (g *Gen) item() string {
+	if g.rand.Intn(3) == 0 {
+		return g.Slug() + "*"
+	}
+	return g.Slug()
+}
+
+// Segment returns a valid environment, service, identity, or instance
+// segment: a literal, glob, alternation, or full glob.
+func (g *Gen) Segment() string {
+	switch g.rand.Intn(5) {
+	case 0:
+		return "*"
+	case 1:
+		items := []string{g.item(), g.item()}
+		if g.rand.Intn(2) == 0 {
+			items = append(items, g.item())
+		}
+		return "[" + strings.Join(items, "|") + "]"
+	default:
+		return g.item()
+	}
+}
+
+func (g *Gen) segments() []string {
+	parts := []string{g.Slug(), g.Slug()}
+	for i := 0; i < 4; i++ {
+		parts = append(parts, g.Segment())
+	}
+	return parts
+}
+
+// doubleGlob replaces a run of at least one segment after the first two
+// with **.
+func (g *Gen) doubleGlob(parts []string) []string {
+	start := 2 + g.rand.Intn(len(parts)-2)
+	end := start + 1 + g.rand.Intn(len(parts)-start)
+
+	out := append([]string{}, parts[:start]...)
+	out = append(out, "**")
+	return append(out, parts[end:]...)
+}
+
+// PathExp returns a valid path expression.
+func (g *Gen) PathExp() string {
+	parts := g.segments()
+	if g.rand.Intn(5) == 0 {
+		parts = g.doubleGlob(parts)
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// InvalidPathExp returns a path expression that is invalid in one way.
+func (g *Gen) InvalidPathExp() string {
+	parts := g.segments()
+	i := 2 + g.rand.Intn(4)
+
+	switch g.rand.Intn(10) {
+	case 0:
+		return strings.Join(parts, "/")
+	case 1:
+		return "/" + strings.Join(parts, "/") + "/"
+	case 2:
+		parts = append(parts, g.Slug())
+	case 3:
+		parts = parts[:len(parts)-1]
+	case 4:
+		parts[g.rand.Intn(2)] = g.Segment() + "*"
+	case 5:
+		parts[i] = "A" + g.Slug()
+	case 6:
+		parts[i] = "[" + g.item() + "]"
+	case 7:
+		parts[i] = "[" + g.item() + "|*]"
+	case 8:
+		parts[i] = ""
+	default:
+		parts[2], parts[4] = "**", "**"
+	}
+
+	return "/" + strings.Join(parts, "/")
+}
+
+// Path returns a path of six literal segments.
+func (g *Gen) Path() string {
+	parts := make([]string, 6)
+	for i := range parts {
+		parts[i] = g.Slug()
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// PathIn returns a path contained by the path expression exp, or Path if exp
+// isn't valid.
+func (g *Gen) PathIn(exp string) string {
+	parts, err := parsePathExp(exp)
+	if err != nil {
+		return g.Path()
+	}
+
+	for i, part := range parts {
+		parts[i] = g.valueIn(part)
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// valueIn returns a literal matched by the segment s.
+func (g *Gen) valueIn(s string) string {
+	items := alternativesOf(s)
+	item := items[g.rand.Intn(len(items))]
+
+	switch {
+	case item == "*":
+		return g.Slug()
+	case strings.HasSuffix(item, "*"):
+		value := strings.TrimSuffix(item, "*")
+		for n := g.rand.Intn(3); n > 0 && len(value) < 64; n-- {
+			value += string(slugRest[g.rand.Intn(len(slugRest))])
+		}
+		return value
+	default:
+		return item
+	}
+}
+
+// Resource returns a valid policy resource. Most are paths of seven
+// segments, from the org to the secret name, but some are shorter, use **,
+// or are named objects such as teams:owner.
+func (g *Gen) Resource() string {
+	if g.rand.Intn(10) == 0 {
+		if g.rand.Intn(3) == 0 {
+			return "teams:*"
+		}
+		return "teams:" + g.Slug()
+	}
+
+	n := 7
+	if g.rand.Intn(10) == 0 {
+		n = 1 + g.rand.Intn(6)
+	}
+
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = g.Segment()
+	}
+	if n == 7 && g.rand.Intn(5) == 0 {
+		parts = g.doubleGlob(parts)
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// InvalidResource returns a policy resource that is invalid in one way.
+func (g *Gen) InvalidResource() string {
+	parts := make([]string, 7)
+	for i := range parts {
+		parts[i] = g.Segment()
+	}
+	i := g.rand.Intn(len(parts))
+
+	switch g.rand.Intn(6) {
+	case 0:
+		return strings.Join(parts, "/")
+	case 1:
+		parts = append(parts, g.Slug())
+	case 2:
+		parts[i] = "A" + g.Slug()
+	case 3:
+		parts[i] = ""
+	case 4:
+		parts[i] = "[" + g.Slug() + "|$]"
+	default:
+		parts[1], parts[3] = "**", "**"
+	}
+
+	return "/" + strings.Join(parts, "/")
+}
+
+// Vary returns the path expression or resource exp with one segment
+// generated again, or exp itself if it isn't valid. The org and project of a
+// path expression are kept. Matching values against their variations checks
+// values that differ only slightly.
+func (g *Gen) Vary(exp string) string {
+	if parts, err := parsePathExp(exp); err == nil {
+		parts[2+g.rand.Intn(4)] = g.Segment()
+		return "/" + strings.Join(parts, "/")
+	}
+
+	kind, parts, err := parseResource(exp)
+	if err != nil {
+		return exp
+	}
+
+	parts[g.rand.Intn(len(parts))] = g.Segment()
+	if kind != "" {
+		return kind + ":" + parts[0]
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// Narrow returns a resource covered by the resource r, by narrowing some of
+// its segments, or r itself if r isn't valid.
+func (g *Gen) Narrow(r string) string {
+	kind, parts, err := parseResource(r)
+	if err != nil {
+		return r
+	}
+
+	for i, part := range parts {
+		if g.rand.Intn(2) == 0 {
+			parts[i] = g.narrowSegment(part)
+		}
+	}
+
+	if kind != "" {
+		return kind + ":" + parts[0]
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// narrowSegment returns a segment matching a subset of the values matched by
+// s: one of its alternatives, a longer glob, or a literal it matches.
+func (g *Gen) narrowSegment(s string) string {
+	items := alternativesOf(s)
+	item := items[g.rand.Intn(len(items))]
+
+	switch {
+	case item == "*" && g.rand.Intn(2) == 0:
+		return g.item()
+	case strings.HasSuffix(item, "*") && g.rand.Intn(2) == 0:
+		return g.valueIn(item) + "*"
+	case item == "*" || strings.HasSuffix(item, "*"):
+		return g.valueIn(item)
+	default:
+		return item
+	}
+}
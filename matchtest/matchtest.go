@@ -0,0 +1,286 @@
+// Package matchtest checks implementations of path expression and policy
+// resource matching against the CLI's, so that anything else deciding which
+// secrets a path or policy applies to, such as a self-hosted registry, can
+// verify that it agrees with the CLI exactly.
+//
+// Cases are generated from a seed by Gen. Compare runs them against two
+// Matchers and reports every case they disagree on, and Check tests the
+// properties any Matcher must have. Reference is a second implementation,
+// written from the grammar in the pathexp docs rather than from the CLI's
+// code, that the CLI is itself checked against.
+package matchtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/policyeval"
+)
+
+// Matcher is an implementation of path expression and policy resource
+// matching. Each method returns an error if one of its arguments is invalid.
+type Matcher interface {
+	// ParsePathExp returns the normalized form of the path expression raw.
+	ParsePathExp(raw string) (string, error)
+
+	// Contains returns whether the path expression exp contains path, a
+	// path of six literal segments.
+	Contains(exp, path string) (bool, error)
+
+	// Overlaps returns whether there is a path both path expressions
+	// contain.
+	Overlaps(a, b string) (bool, error)
+
+	// ParseResource returns the normalized form of the policy resource raw.
+	ParseResource(raw string) (string, error)
+
+	// Covers returns whether a statement with the resource stmt applies to
+	// everything matched by resource.
+	Covers(stmt, resource string) (bool, error)
+}
+
+// CLI is the matching used by the CLI, from the pathexp and policyeval
+// packages.
+var CLI Matcher = cliMatcher{}
+
+type cliMatcher struct{}
+
+func (cliMatcher) ParsePathExp(raw string) (string, error) {
+	pe, err := pathexp.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	return pe.String(), nil
+}
+
+func (cliMatcher) Contains(exp, path string) (bool, error) {
+	pe, err := pathexp.Parse(exp)
+	if err != nil {
+		return false, err
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 7 || parts[0] != "" {
+		return false, fmt.Errorf("invalid path %q", path)
+	}
+	for _, part := range parts[1:] {
+		if !pathexp.ValidSlug(part) {
+			return false, fmt.Errorf("invalid path %q", path)
+		}
+	}
+
+	return pe.Org.Contains(parts[1]) && pe.Project.Contains(parts[2]) &&
+		pe.Envs.Contains(parts[3]) && pe.Services.Contains(parts[4]) &&
+		pe.Identities.Contains(parts[5]) && pe.Instances.Contains(parts[6]), nil
+}
+
+func (cliMatcher) Overlaps(a, b string) (bool, error) {
+	pa, err := pathexp.Parse(a)
+	if err != nil {
+		return false, err
+	}
+	pb, err := pathexp.Parse(b)
+	if err != nil {
+		return false, err
+	}
+	return pa.Overlaps(pb), nil
+}
+
+func (cliMatcher) ParseResource(raw string) (string, error) {
+	r, err := policyeval.ParseResource(raw)
+	if err != nil {
+		return "", err
+	}
+	return r.String(), nil
+}
+
+func (cliMatcher) Covers(stmt, resource string) (bool, error) {
+	s, err := policyeval.ParseResource(stmt)
+	if err != nil {
+		return false, err
+	}
+	r, err := policyeval.ParseResource(resource)
+	if err != nil {
+		return false, err
+	}
+	return s.Covers(r), nil
+}
+
+// Failure is a generated case that a Matcher got wrong.
+type Failure struct {
+	// Op is the Matcher method, or property, that failed.
+	Op   string
+	Args []string
+
+	// Want and Got are the expected and actual results. An error is
+	// written as "error", as only whether there is one is compared.
+	Want string
+	Got  string
+}
+
+func (f Failure) String() string {
+	return fmt.Sprintf("%s(%q): want %s, got %s", f.Op, f.Args, f.Want, f.Got)
+}
+
+// result formats the result of a Matcher method for comparison.
+func result(v interface{}, err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	switch v := v.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Compare runs n cases of each Matcher method, generated from seed, against
+// want and got, and returns the cases on which their results differ.
+func Compare(want, got Matcher, seed int64, n int) []Failure {
+	g := NewGen(seed)
+
+	var failures []Failure
+	compare := func(op string, args []string, w, gt string) {
+		if w != gt {
+			failures = append(failures, Failure{Op: op, Args: args, Want: w, Got: gt})
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		var exp string
+		if i%4 == 0 {
+			exp = g.InvalidPathExp()
+		} else {
+			exp = g.PathExp()
+		}
+		compare("ParsePathExp", []string{exp},
+			result(want.ParsePathExp(exp)), result(got.ParsePathExp(exp)))
+
+		path := g.Path()
+		if i%2 == 0 {
+			path = g.PathIn(exp)
+		}
+		compare("Contains", []string{exp, path},
+			result(want.Contains(exp, path)), result(got.Contains(exp, path)))
+
+		other := g.PathExp()
+		if i%2 == 0 {
+			other = g.Vary(exp)
+		}
+		compare("Overlaps", []string{exp, other},
+			result(want.Overlaps(exp, other)), result(got.Overlaps(exp, other)))
+
+		var resource string
+		if i%4 == 0 {
+			resource = g.InvalidResource()
+		} else {
+			resource = g.Resource()
+		}
+		compare("ParseResource", []string{resource},
+			result(want.ParseResource(resource)), result(got.ParseResource(resource)))
+
+		stmt := g.Resource()
+		switch i % 3 {
+		case 0:
+			resource = g.Narrow(stmt)
+		case 1:
+			resource = g.Narrow(g.Vary(stmt))
+		}
+		compare("Covers", []string{stmt, resource},
+			result(want.Covers(stmt, resource)), result(got.Covers(stmt, resource)))
+	}
+
+	return failures
+}
+
+// Check runs n cases, generated from seed, of the properties any Matcher must
+// have, and returns the cases on which m doesn't have them:
+//
+//   - generated path expressions and resources are valid, and invalid ones
+//     are rejected
+//   - normalizing is idempotent
+//   - a path expression contains the paths generated within it
+//   - overlapping is symmetric
+//   - a resource covers itself, and the resources narrowed from it
+func Check(m Matcher, seed int64, n int) []Failure {
+	g := NewGen(seed)
+
+	var failures []Failure
+	check := func(op string, args []string, want, got string) {
+		if want != got {
+			failures = append(failures, Failure{Op: op, Args: args, Want: want, Got: got})
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		exp := g.PathExp()
+		norm, err := m.ParsePathExp(exp)
+		check("ParsePathExp", []string{exp}, "valid", valid(err))
+		if err == nil {
+			check("normalized ParsePathExp", []string{norm}, norm, result(m.ParsePathExp(norm)))
+		}
+
+		invalid := g.InvalidPathExp()
+		_, err = m.ParsePathExp(invalid)
+		check("ParsePathExp", []string{invalid}, "invalid", valid(err))
+
+		path := g.PathIn(exp)
+		check("Contains", []string{exp, path}, "true", result(m.Contains(exp, path)))
+
+		other := g.Vary(exp)
+		check("symmetric Overlaps", []string{exp, other},
+			result(m.Overlaps(exp, other)), result(m.Overlaps(other, exp)))
+
+		resource := g.Resource()
+		rnorm, err := m.ParseResource(resource)
+		check("ParseResource", []string{resource}, "valid", valid(err))
+		if err == nil {
+			check("normalized ParseResource", []string{rnorm}, rnorm, result(m.ParseResource(rnorm)))
+		}
+
+		invalid = g.InvalidResource()
+		_, err = m.ParseResource(invalid)
+		check("ParseResource", []string{invalid}, "invalid", valid(err))
+
+		check("reflexive Covers", []string{resource, resource}, "true", result(m.Covers(resource, resource)))
+
+		narrow := g.Narrow(resource)
+		check("Covers", []string{resource, narrow}, "true", result(m.Covers(resource, narrow)))
+	}
+
+	return failures
+}
+
+func valid(err error) string {
+	if err != nil {
+		return "invalid"
+	}
+	return "valid"
+}
+
+// Fuzz is an entry point for go-fuzz. It parses data as both a path
+// expression and a policy resource with the CLI and Reference, and panics if
+// they disagree.
+func Fuzz(data []byte) int {
+	raw := string(data)
+
+	want, got := result(Reference.ParsePathExp(raw)), result(CLI.ParsePathExp(raw))
+	if want != got {
+		panic(Failure{Op: "ParsePathExp", Args: []string{raw}, Want: want, Got: got}.String())
+	}
+	interesting := want != "error"
+
+	want, got = result(Reference.ParseResource(raw)), result(CLI.ParseResource(raw))
+	if want != got {
+		panic(Failure{Op: "ParseResource", Args: []string{raw}, Want: want, Got: got}.String())
+	}
+
+	if interesting || want != "error" {
+		return 1
+	}
+	return 0
+}
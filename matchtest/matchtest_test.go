@@ -0,0 +1,54 @@
+package matchtest
+
+import "testing"
+
+const cases = 5000
+
+func TestCompare(t *testing.T) {
+	for _, f := range Compare(Reference, CLI, 1, cases) {
+		t.Error(f)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	for name, m := range map[string]Matcher{"cli": CLI, "reference": Reference} {
+		t.Run(name, func(t *testing.T) {
+			for _, f := range Check(m, 2, cases) {
+				t.Error(f)
+			}
+		})
+	}
+}
+
+func TestFuzz(t *testing.T) {
+	g := NewGen(3)
+	for i := 0; i < cases; i++ {
+		Fuzz([]byte(g.PathExp()))
+		Fuzz([]byte(g.InvalidPathExp()))
+		Fuzz([]byte(g.Resource()))
+		Fuzz([]byte(g.InvalidResource()))
+	}
+}
+
+// sloppyMatcher treats globs as full globs when checking coverage.
+type sloppyMatcher struct {
+	Matcher
+}
+
+func (s sloppyMatcher) Covers(stmt, resource string) (bool, error) {
+	_, err := s.Matcher.Covers(stmt, resource)
+	return err == nil, err
+}
+
+func TestCompareFindsDisagreements(t *testing.T) {
+	failures := Compare(CLI, sloppyMatcher{CLI}, 1, cases)
+	if len(failures) == 0 {
+		t.Fatal("expected failures from a broken matcher")
+	}
+
+	for _, f := range failures {
+		if f.Op != "Covers" || f.Want != "false" || f.Got != "true" {
+			t.Errorf("unexpected failure %s", f)
+		}
+	}
+}
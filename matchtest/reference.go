@@ -0,0 +1,269 @@
+package matchtest
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Reference is an implementation of matching written from the grammar of
+// path expressions and policy resources, independently of the CLI's. Values
+// are matched by translating segments into regular expressions.
+var Reference Matcher = referenceMatcher{}
+
+type referenceMatcher struct{}
+
+const (
+	refSlug = `[a-z0-9][a-z0-9_-]{0,63}`
+	refItem = refSlug + `\*?`
+)
+
+var (
+	refLiteral  = regexp.MustCompile(`^` + refSlug + `$`)
+	refSegment  = regexp.MustCompile(`^(\*|` + refItem + `|\[` + refItem + `(\|` + refItem + `)+\])$`)
+	refResource = regexp.MustCompile(`^(\*|` + refItem + `)$`)
+)
+
+// alternativesOf returns the literals, globs, and full globs that make up the
+// segment s.
+func alternativesOf(s string) []string {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		return strings.Split(s[1:len(s)-1], "|")
+	}
+	return []string{s}
+}
+
+// expand replaces the first ** in parts with as many full globs as it takes
+// to make n segments.
+func expand(parts []string, n int) []string {
+	for i, p := range parts {
+		if p != "**" {
+			continue
+		}
+
+		out := append([]string{}, parts[:i]...)
+		for len(out)+len(parts)-i-1 < n {
+			out = append(out, "*")
+		}
+		return append(out, parts[i+1:]...)
+	}
+	return parts
+}
+
+// parsePathExp returns the normalized segments of the path expression raw.
+func parsePathExp(raw string) ([]string, error) {
+	if !strings.HasPrefix(raw, "/") {
+		return nil, errors.New("path expressions must start with /")
+	}
+
+	parts := strings.Split(raw[1:], "/")
+	if len(parts) > 6 {
+		return nil, errors.New("too many segments")
+	}
+	doubleGlobs := 0
+	for _, part := range parts {
+		if part == "**" {
+			doubleGlobs++
+		}
+	}
+	if doubleGlobs > 1 {
+		return nil, errors.New("more than one **")
+	}
+
+	parts = expand(parts, 6)
+	if len(parts) != 6 {
+		return nil, errors.New("wrong number of segments")
+	}
+
+	for i, part := range parts {
+		if i < 2 {
+			if !refLiteral.MatchString(part) {
+				return nil, fmt.Errorf("invalid segment %q", part)
+			}
+			continue
+		}
+
+		if !refSegment.MatchString(part) {
+			return nil, fmt.Errorf("invalid segment %q", part)
+		}
+
+		if items := alternativesOf(part); len(items) > 1 {
+			sort.Strings(items)
+			parts[i] = "[" + strings.Join(items, "|") + "]"
+		}
+	}
+
+	return parts, nil
+}
+
+func (referenceMatcher) ParsePathExp(raw string) (string, error) {
+	parts, err := parsePathExp(raw)
+	if err != nil {
+		return "", err
+	}
+	return "/" + strings.Join(parts, "/"), nil
+}
+
+// segmentRegexp returns a regular expression matching the values the segment
+// s matches.
+func segmentRegexp(s string) *regexp.Regexp {
+	var items []string
+	for _, item := range alternativesOf(s) {
+		if strings.HasSuffix(item, "*") {
+			items = append(items, regexp.QuoteMeta(strings.TrimSuffix(item, "*"))+".*")
+		} else {
+			items = append(items, regexp.QuoteMeta(item))
+		}
+	}
+	return regexp.MustCompile(`^(` + strings.Join(items, "|") + `)$`)
+}
+
+func (referenceMatcher) Contains(exp, path string) (bool, error) {
+	segments, err := parsePathExp(exp)
+	if err != nil {
+		return false, err
+	}
+
+	values := strings.Split(path, "/")
+	if len(values) != 7 || values[0] != "" {
+		return false, fmt.Errorf("invalid path %q", path)
+	}
+	values = values[1:]
+
+	contains := true
+	for i, v := range values {
+		if !refLiteral.MatchString(v) {
+			return false, fmt.Errorf("invalid path %q", path)
+		}
+		contains = contains && segmentRegexp(segments[i]).MatchString(v)
+	}
+
+	return contains, nil
+}
+
+// itemsOverlap returns whether there is a value matched by both the literal,
+// glob, or full glob items.
+func itemsOverlap(a, b string) bool {
+	ap, aGlob := strings.TrimSuffix(a, "*"), strings.HasSuffix(a, "*")
+	bp, bGlob := strings.TrimSuffix(b, "*"), strings.HasSuffix(b, "*")
+
+	switch {
+	case aGlob && bGlob:
+		return strings.HasPrefix(ap, bp) || strings.HasPrefix(bp, ap)
+	case aGlob:
+		return strings.HasPrefix(b, ap)
+	case bGlob:
+		return strings.HasPrefix(a, bp)
+	default:
+		return a == b
+	}
+}
+
+func (referenceMatcher) Overlaps(a, b string) (bool, error) {
+	as, err := parsePathExp(a)
+	if err != nil {
+		return false, err
+	}
+	bs, err := parsePathExp(b)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range as {
+		overlap := false
+		for _, ai := range alternativesOf(as[i]) {
+			for _, bi := range alternativesOf(bs[i]) {
+				overlap = overlap || itemsOverlap(ai, bi)
+			}
+		}
+		if !overlap {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// parseResource returns the kind and segments of the policy resource raw.
+// Paths have no kind.
+func parseResource(raw string) (string, []string, error) {
+	if !strings.HasPrefix(raw, "/") {
+		i := strings.Index(raw, ":")
+		if i < 1 {
+			return "", nil, errors.New("resources must be a path or kind:name")
+		}
+		return raw[:i], []string{raw[i+1:]}, nil
+	}
+
+	parts := expand(strings.Split(raw[1:], "/"), 7)
+	if len(parts) > 7 {
+		return "", nil, errors.New("too many segments")
+	}
+
+	for _, part := range parts {
+		for _, item := range alternativesOf(part) {
+			if !refResource.MatchString(item) {
+				return "", nil, fmt.Errorf("invalid segment %q", part)
+			}
+		}
+	}
+
+	return "", parts, nil
+}
+
+func (referenceMatcher) ParseResource(raw string) (string, error) {
+	kind, parts, err := parseResource(raw)
+	if err != nil {
+		return "", err
+	}
+
+	if kind != "" {
+		return kind + ":" + parts[0], nil
+	}
+	return "/" + strings.Join(parts, "/"), nil
+}
+
+// itemCovers returns whether every value matched by the resource item r is
+// matched by the statement item s.
+func itemCovers(s, r string) bool {
+	switch {
+	case r == "*":
+		return s == "*"
+	case strings.HasSuffix(r, "*"):
+		return s == "*" || (strings.HasSuffix(s, "*") &&
+			strings.HasPrefix(r, strings.TrimSuffix(s, "*")))
+	default:
+		return segmentRegexp(s).MatchString(r)
+	}
+}
+
+func (referenceMatcher) Covers(stmt, resource string) (bool, error) {
+	skind, ss, err := parseResource(stmt)
+	if err != nil {
+		return false, err
+	}
+	rkind, rs, err := parseResource(resource)
+	if err != nil {
+		return false, err
+	}
+
+	if skind != rkind || len(ss) != len(rs) {
+		return false, nil
+	}
+
+	for i := range rs {
+		for _, ri := range alternativesOf(rs[i]) {
+			covered := false
+			for _, si := range alternativesOf(ss[i]) {
+				covered = covered || itemCovers(si, ri)
+			}
+			if !covered {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
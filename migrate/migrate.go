@@ -0,0 +1,209 @@
+// Package migrate records the progress of copying an org from one registry to
+// another, so that a migration can be stopped, and run again to pick up where
+// it left off, and to copy whatever has changed since.
+//
+// Secrets are end to end encrypted, so they can't be copied by the
+// registries themselves. Each object is copied by a member of the org, and
+// recorded here once it has been.
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// The kinds of objects copied by a migration.
+const (
+	Org         = "org"
+	Project     = "project"
+	Environment = "environment"
+	Service     = "service"
+	Team        = "team"
+	Member      = "member"
+	Machine     = "machine"
+	Policy      = "policy"
+	Attachment  = "attachment"
+	Credential  = "credential"
+)
+
+// Status is how far along copying an object is.
+type Status string
+
+// The statuses of an object.
+const (
+	// Done objects have been copied, and are skipped until their source
+	// changes.
+	Done Status = "done"
+
+	// Waiting objects can't be copied until something else happens, such
+	// as a user joining the org on the destination registry.
+	Waiting Status = "waiting"
+
+	// Failed objects could not be copied, and are tried again.
+	Failed Status = "failed"
+)
+
+// Object is the state of one object being migrated.
+type Object struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+
+	// Source identifies the version of the object that was copied, for
+	// objects that can change, such as the ID of a secret's current value.
+	Source string `json:"source,omitempty"`
+
+	// Message explains why an object is waiting, or failed.
+	Message string    `json:"message,omitempty"`
+	Updated time.Time `json:"updated_at"`
+}
+
+// State is the progress of migrating one org between two registries.
+type State struct {
+	From    string             `json:"from"`
+	To      string             `json:"to"`
+	Org     string             `json:"org"`
+	Objects map[string]*Object `json:"objects"`
+
+	path string
+}
+
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// hostName returns a name for the registry at u that is safe to use in file
+// names.
+func hostName(u *url.URL) string {
+	return unsafeChars.ReplaceAllString(u.Host, "_")
+}
+
+// RegistryRoot returns the Torus root, inside torusRoot, used by the daemon
+// that talks to the registry at u during migrations.
+func RegistryRoot(torusRoot string, u *url.URL) string {
+	return filepath.Join(torusRoot, "registries", hostName(u))
+}
+
+// StatePath returns the path of the state file for migrating org between the
+// registries, inside torusRoot.
+func StatePath(torusRoot string, from, to *url.URL, org string) string {
+	name := hostName(from) + "-" + hostName(to) + "-" + org + ".json"
+	return filepath.Join(torusRoot, "migrations", name)
+}
+
+// Load returns the state stored at path, or a new State if there is none yet.
+func Load(path string, from, to *url.URL, org string) (*State, error) {
+	s := &State{
+		From:    from.String(),
+		To:      to.String(),
+		Org:     org,
+		Objects: make(map[string]*Object),
+		path:    path,
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(b, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.From != from.String() || s.To != to.String() || s.Org != org {
+		return nil, errors.New(path + " is for a different migration")
+	}
+	if s.Objects == nil {
+		s.Objects = make(map[string]*Object)
+	}
+
+	return s, nil
+}
+
+// Save writes the state to its file, readable only by the current user.
+func (s *State) Save() error {
+	err := os.MkdirAll(filepath.Dir(s.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so an interrupted write never loses
+	// the progress already recorded.
+	tmp := s.path + ".tmp"
+	err = ioutil.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+func key(kind, name string) string {
+	return kind + ":" + name
+}
+
+// Done returns whether the object was copied, from the given source version.
+func (s *State) Done(kind, name, source string) bool {
+	o, ok := s.Objects[key(kind, name)]
+	return ok && o.Status == Done && o.Source == source
+}
+
+// Record sets the status of an object.
+func (s *State) Record(kind, name string, status Status, source, message string) {
+	s.Objects[key(kind, name)] = &Object{
+		Kind:    kind,
+		Name:    name,
+		Status:  status,
+		Source:  source,
+		Message: message,
+		Updated: time.Now().UTC(),
+	}
+}
+
+// Counts returns how many objects have each status.
+func (s *State) Counts() map[Status]int {
+	counts := make(map[Status]int)
+	for _, o := range s.Objects {
+		counts[o.Status]++
+	}
+	return counts
+}
+
+// Pending returns the objects that are waiting, or failed, sorted by kind and
+// name.
+func (s *State) Pending() []*Object {
+	var pending []*Object
+	for _, o := range s.Objects {
+		if o.Status != Done {
+			pending = append(pending, o)
+		}
+	}
+
+	sort.Sort(objectSorter(pending))
+	return pending
+}
+
+type objectSorter []*Object
+
+func (o objectSorter) Len() int      { return len(o) }
+func (o objectSorter) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
+func (o objectSorter) Less(i, j int) bool {
+	if o[i].Kind != o[j].Kind {
+		return o[i].Kind < o[j].Kind
+	}
+	return o[i].Name < o[j].Name
+}
@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "torus-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	from, _ := url.Parse("https://registry.torus.sh")
+	to, _ := url.Parse("https://torus.example.com:8443")
+
+	path := StatePath(dir, from, to, "acme")
+	if filepath.Base(path) != "registry.torus.sh-torus.example.com_8443-acme.json" {
+		t.Errorf("unexpected state file name %s", path)
+	}
+
+	s, err := Load(path, from, to, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Record(Project, "web", Done, "", "")
+	s.Record(Credential, "/acme/web/*/*/*/*/port", Done, "1", "")
+	s.Record(Member, "jeff", Waiting, "", "not on the destination registry")
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("progress is resumed", func(t *testing.T) {
+		s, err := Load(path, from, to, "acme")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !s.Done(Project, "web", "") {
+			t.Error("project should be done")
+		}
+		if s.Done(Credential, "/acme/web/*/*/*/*/port", "2") {
+			t.Error("changed secret should be copied again")
+		}
+		if s.Done(Member, "jeff", "") {
+			t.Error("waiting member should not be done")
+		}
+
+		counts := s.Counts()
+		if counts[Done] != 2 || counts[Waiting] != 1 {
+			t.Errorf("wrong counts: %v", counts)
+		}
+
+		pending := s.Pending()
+		if len(pending) != 1 || pending[0].Name != "jeff" {
+			t.Errorf("wrong pending objects: %v", pending)
+		}
+	})
+
+	t.Run("state is private", func(t *testing.T) {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("got mode %s, want 0600", info.Mode().Perm())
+		}
+	})
+
+	t.Run("other migrations are refused", func(t *testing.T) {
+		if _, err := Load(path, from, to, "other"); err == nil {
+			t.Error("expected an error loading state for another org")
+		}
+	})
+}
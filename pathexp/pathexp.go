@@ -183,6 +183,34 @@ func segmentsEqual(a, b segment) bool {
 	}
 }
 
+// segmentsOverlap returns whether there is a value both segments contain.
+func segmentsOverlap(a, b segment) bool {
+	switch at := a.(type) {
+	case literal:
+		return b.Contains(string(at))
+	case glob:
+		switch bt := b.(type) {
+		case glob:
+			return at.Contains(string(bt)) || bt.Contains(string(at))
+		case literal:
+			return at.Contains(string(bt))
+		default:
+			return segmentsOverlap(b, a)
+		}
+	case alternation:
+		for _, av := range at {
+			if segmentsOverlap(av, b) {
+				return true
+			}
+		}
+		return false
+	case fullglob:
+		return true
+	default:
+		panic("Bad type for segment!")
+	}
+}
+
 // New creates a new path expression from the given path segments
 // It returns an error if any of the values fail to validate
 // and it must contain all relevant parts
@@ -496,6 +524,29 @@ func (pe *PathExp) Equal(other *PathExp) bool {
 	}
 }
 
+// Overlaps returns whether there is a path that both PathExps contain. An
+// org or project of "*", as left by ParsePartial, overlaps any other.
+func (pe *PathExp) Overlaps(other *PathExp) bool {
+	literalsOverlap := func(a, b literal) bool {
+		return a == "*" || b == "*" || a == b
+	}
+
+	switch {
+	case !literalsOverlap(pe.Org, other.Org):
+		return false
+	case !literalsOverlap(pe.Project, other.Project):
+		return false
+	case !segmentsOverlap(pe.Envs, other.Envs):
+		return false
+	case !segmentsOverlap(pe.Services, other.Services):
+		return false
+	case !segmentsOverlap(pe.Identities, other.Identities):
+		return false
+	default:
+		return segmentsOverlap(pe.Instances, other.Instances)
+	}
+}
+
 // CompareSpecificity returns an int indicating if this PathExp is more
 // specific than PathExp b.
 //
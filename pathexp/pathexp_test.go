@@ -309,3 +309,47 @@ func TestContains(t *testing.T) {
 		t.Errorf("FullGlob contains failed to match any value")
 	}
 }
+
+func TestOverlaps(t *testing.T) {
+	tcs := []struct {
+		a, b     string
+		overlaps bool
+	}{
+		{"/o/p/production/api/*/*", "/o/p/production/api/*/*", true},
+		{"/o/p/production/api/*/*", "/o/p/staging/api/*/*", false},
+		{"/o/p/*/api/*/*", "/o/p/staging/api/*/*", true},
+		{"/o/p/dev-*/api/*/*", "/o/p/dev-jeff/api/*/*", true},
+		{"/o/p/dev-*/api/*/*", "/o/p/dev*/api/*/*", true},
+		{"/o/p/dev-*/api/*/*", "/o/p/prod*/api/*/*", false},
+		{"/o/p/[staging|dev-*]/api/*/*", "/o/p/dev-jeff/api/*/*", true},
+		{"/o/p/[staging|dev-*]/api/*/*", "/o/p/[production|qa]/api/*/*", false},
+		{"/o/p/production/api/*/*", "/o/q/production/api/*/*", false},
+	}
+
+	for _, tc := range tcs {
+		a, err := Parse(tc.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Parse(tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if a.Overlaps(b) != tc.overlaps || b.Overlaps(a) != tc.overlaps {
+			t.Errorf("%s overlaps %s: expected %t", tc.a, tc.b, tc.overlaps)
+		}
+	}
+
+	partial, err := ParsePartial("/o/*/production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := Parse("/o/p/production/api/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !partial.Overlaps(full) {
+		t.Errorf("%s should overlap %s", partial, full)
+	}
+}
@@ -0,0 +1,165 @@
+package policyeval
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Request describes the circumstances an action is taken in, to evaluate
+// statement conditions against.
+type Request struct {
+	Time time.Time
+
+	// MachineTeams are the names of the teams of the machine taking the
+	// action. It is empty for users.
+	MachineTeams []string
+
+	// Authenticated is when the actor last logged in. The zero time means it
+	// is not known.
+	Authenticated time.Time
+}
+
+// ValidateConditions returns an error if c can't be evaluated.
+func ValidateConditions(c *primitive.PolicyConditions) error {
+	_, err := checkConditions(c, &Request{Time: time.Now()})
+	return err
+}
+
+// checkConditions returns a description of the first condition of c that
+// req does not meet, or an empty string if it meets them all.
+func checkConditions(c *primitive.PolicyConditions, req *Request) (string, error) {
+	if c == nil {
+		return "", nil
+	}
+
+	loc := time.UTC
+	if c.TimeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(c.TimeZone)
+		if err != nil {
+			return "", fmt.Errorf("invalid time zone %q", c.TimeZone)
+		}
+	}
+	now := req.Time.In(loc)
+
+	if c.Hours != "" {
+		start, end, err := parseHours(c.Hours)
+		if err != nil {
+			return "", err
+		}
+
+		minute := now.Hour()*60 + now.Minute()
+		in := minute >= start && minute < end
+		if end <= start { // wraps past midnight
+			in = minute >= start || minute < end
+		}
+		if !in {
+			return "only allowed between " + c.Hours + " " + loc.String(), nil
+		}
+	}
+
+	if len(c.Days) > 0 {
+		today := weekdays[now.Weekday()]
+		allowed := false
+		for _, d := range c.Days {
+			if dayIndex(d) < 0 {
+				return "", fmt.Errorf("invalid day %q", d)
+			}
+			if strings.ToLower(d) == today {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return "only allowed on " + strings.Join(c.Days, ", "), nil
+		}
+	}
+
+	if c.MachineTeam != "" {
+		member := false
+		for _, t := range req.MachineTeams {
+			if t == c.MachineTeam {
+				member = true
+			}
+		}
+		if !member {
+			return "only allowed for machines in the " + c.MachineTeam + " team", nil
+		}
+	}
+
+	if c.RecentAuth != "" {
+		d, err := time.ParseDuration(c.RecentAuth)
+		if err != nil || d <= 0 {
+			return "", fmt.Errorf("invalid recent_auth %q", c.RecentAuth)
+		}
+		if req.Authenticated.IsZero() || req.Time.Sub(req.Authenticated) > d {
+			return "requires logging in again within " + c.RecentAuth, nil
+		}
+	}
+
+	return "", nil
+}
+
+// parseHours parses a window like 09:00-17:00 into minutes past midnight.
+func parseHours(raw string) (int, int, error) {
+	parts := strings.Split(raw, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid hours %q: expected HH:MM-HH:MM", raw)
+	}
+
+	var minutes [2]int
+	for i, p := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(p))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid hours %q: expected HH:MM-HH:MM", raw)
+		}
+		minutes[i] = t.Hour()*60 + t.Minute()
+	}
+
+	if minutes[0] == minutes[1] {
+		return 0, 0, fmt.Errorf("invalid hours %q: window is empty", raw)
+	}
+
+	return minutes[0], minutes[1], nil
+}
+
+// ParseDays parses a comma separated list of days, which may include ranges,
+// such as mon-fri or mon,wed,fri.
+func ParseDays(raw string) ([]string, error) {
+	var days []string
+	for _, part := range strings.Split(raw, ",") {
+		bounds := strings.Split(strings.TrimSpace(part), "-")
+		if len(bounds) > 2 {
+			return nil, fmt.Errorf("invalid days %q", raw)
+		}
+
+		start := dayIndex(bounds[0])
+		end := dayIndex(bounds[len(bounds)-1])
+		if start < 0 || end < 0 {
+			return nil, fmt.Errorf("invalid days %q: use sun, mon, tue, wed, thu, fri, or sat", raw)
+		}
+
+		for i := start; ; i = (i + 1) % len(weekdays) {
+			days = append(days, weekdays[i])
+			if i == end {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+func dayIndex(day string) int {
+	day = strings.ToLower(day)
+	for i, d := range weekdays {
+		if d == day {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,288 @@
+// Package policyeval predicts whether a set of policies allows an action on a
+// resource, so access can be checked before a policy is pushed.
+//
+// Statements are evaluated the way the registry evaluates them: of the
+// statements whose resource covers the resource being checked, the most
+// specific one decides. When equally specific statements disagree, deny wins.
+// If no statement covers the resource, the action is denied.
+//
+// An allow statement with conditions, such as a time of day window, denies
+// its actions when any condition is not met. Among equally specific allow
+// statements, one with conditions decides over one without.
+package policyeval
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+// Vars are the values substituted for variables in statement resources, such
+// as ${username} in the default member policy.
+type Vars struct {
+	Org      string
+	Username string
+}
+
+// Decision is the outcome of evaluating a single action.
+type Decision struct {
+	Action  primitive.PolicyAction
+	Allowed bool
+
+	// Policy and Statement are the statement that decided the action, and
+	// the policy it belongs to. Both are nil if no statement matched.
+	Policy    *envelope.Policy
+	Statement *primitive.PolicyStatement
+
+	// Unmet describes the condition of Statement that was not met, if that
+	// is why the action was denied.
+	Unmet string
+}
+
+// Resource is a parsed policy resource: either a path of up to seven
+// segments (org through secret name), or a named object such as teams:owner.
+type Resource struct {
+	kind     string
+	segments []string
+}
+
+// ParseResource parses a policy resource. Path segments may be literals,
+// globs, alternations, or full globs, as in a path expression. A ** segment
+// in a full secret path is expanded to fill the missing segments.
+func ParseResource(raw string) (*Resource, error) {
+	r, err := parseResource(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range r.segments {
+		for _, v := range alternatives(s) {
+			if !pathexp.ValidSecret(v) {
+				return nil, fmt.Errorf("invalid resource %q: bad segment %q", raw, s)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// parseResource splits raw into its segments without validating them, as
+// statements may hold values users can't, such as dev-@.
+func parseResource(raw string) (*Resource, error) {
+	if i := strings.Index(raw, ":"); i > 0 && !strings.HasPrefix(raw, "/") {
+		return &Resource{kind: raw[:i], segments: []string{raw[i+1:]}}, nil
+	}
+
+	if !strings.HasPrefix(raw, "/") {
+		return nil, fmt.Errorf("invalid resource %q: must be a path or kind:name", raw)
+	}
+
+	parts := expandDoubleGlob(strings.Split(raw[1:], "/"))
+	if len(parts) > 7 {
+		return nil, fmt.Errorf("invalid resource %q: too many segments", raw)
+	}
+
+	return &Resource{segments: parts}, nil
+}
+
+func (r *Resource) String() string {
+	if r.kind != "" {
+		return r.kind + ":" + r.segments[0]
+	}
+	return "/" + strings.Join(r.segments, "/")
+}
+
+// Evaluate decides each action in action for resource, given the statements
+// of policies, and the request conditions are checked against. One Decision
+// is returned per action, in crudl order.
+func Evaluate(policies []envelope.Policy, vars Vars, req *Request,
+	action primitive.PolicyAction, resource *Resource) ([]Decision, error) {
+
+	var decisions []Decision
+	for bit := primitive.PolicyAction(primitive.PolicyActionCreate); bit <= primitive.PolicyActionList; bit <<= 1 {
+		if action&bit == 0 {
+			continue
+		}
+
+		d, err := evaluate(policies, vars, req, bit, resource)
+		if err != nil {
+			return nil, err
+		}
+		decisions = append(decisions, *d)
+	}
+
+	if len(decisions) == 0 {
+		return nil, errors.New("no actions to evaluate")
+	}
+
+	return decisions, nil
+}
+
+func evaluate(policies []envelope.Policy, vars Vars, req *Request,
+	action primitive.PolicyAction, resource *Resource) (*Decision, error) {
+
+	d := &Decision{Action: action}
+
+	var best *Resource
+	for i := range policies {
+		stmts := policies[i].Body.Policy.Statements
+		for j := range stmts {
+			stmt := &stmts[j]
+			if stmt.Action&action == 0 {
+				continue
+			}
+
+			sr, err := parseResource(substitute(stmt.Resource, vars))
+			if err != nil {
+				// A statement we can't parse can't be evaluated the way the
+				// registry would, so don't guess.
+				return nil, fmt.Errorf("policy %s: %s", policies[i].Body.Policy.Name, err)
+			}
+
+			if !sr.Covers(resource) {
+				continue
+			}
+
+			cmp := 1
+			if best != nil {
+				cmp = sr.compareSpecificity(best)
+			}
+			stricter := stmt.Effect == primitive.PolicyEffectDeny ||
+				(d.Allowed && d.Statement.Conditions == nil && stmt.Conditions != nil)
+			if cmp > 0 || (cmp == 0 && stricter) {
+				best = sr
+				d.Policy = &policies[i]
+				d.Statement = stmt
+				d.Allowed = bool(stmt.Effect)
+			}
+		}
+	}
+
+	if d.Allowed {
+		unmet, err := checkConditions(d.Statement.Conditions, req)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %s", d.Policy.Body.Policy.Name, err)
+		}
+		if unmet != "" {
+			d.Allowed = false
+			d.Unmet = unmet
+		}
+	}
+
+	return d, nil
+}
+
+// Covers returns whether r, a statement resource, applies to every resource
+// matched by other.
+func (r *Resource) Covers(other *Resource) bool {
+	if r.kind != other.kind || len(r.segments) != len(other.segments) {
+		return false
+	}
+
+	for i, s := range r.segments {
+		if !segmentCovers(s, other.segments[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareSpecificity returns 1 if r is more specific than other, -1 if it is
+// less specific, and 0 if they are as specific. Segments are compared from
+// the org down, as with pathexp.PathExp.CompareSpecificity.
+func (r *Resource) compareSpecificity(other *Resource) int {
+	for i, s := range r.segments {
+		a, b := segmentRank(s), segmentRank(other.segments[i])
+		switch {
+		case a > b:
+			return 1
+		case a < b:
+			return -1
+		}
+	}
+	return 0
+}
+
+// segmentRank ranks a segment by type, from most to least specific: literal,
+// glob, alternation, full glob.
+func segmentRank(s string) int {
+	switch {
+	case s == "*":
+		return 0
+	case strings.HasPrefix(s, "["):
+		return 1
+	case strings.HasSuffix(s, "*"):
+		return 2
+	default:
+		return 3
+	}
+}
+
+// segmentCovers returns whether the statement segment s matches every value
+// matched by the resource segment r.
+func segmentCovers(s, r string) bool {
+	for _, rv := range alternatives(r) {
+		covered := false
+		for _, sv := range alternatives(s) {
+			if valueCovers(sv, rv) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func valueCovers(s, r string) bool {
+	switch {
+	case s == "*":
+		return true
+	case r == "*":
+		return false
+	case strings.HasSuffix(s, "*"):
+		return pathexp.GlobContains(strings.TrimSuffix(s, "*"), strings.TrimSuffix(r, "*"))
+	case strings.HasSuffix(r, "*"):
+		return false
+	default:
+		return s == r
+	}
+}
+
+func alternatives(s string) []string {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		return strings.Split(s[1:len(s)-1], "|")
+	}
+	return []string{s}
+}
+
+// expandDoubleGlob replaces a ** segment with enough full globs to make a
+// complete secret path.
+func expandDoubleGlob(parts []string) []string {
+	for i, p := range parts {
+		if p != "**" {
+			continue
+		}
+
+		out := append([]string{}, parts[:i]...)
+		for n := len(parts) - 1; n < 7; n++ {
+			out = append(out, "*")
+		}
+		return append(out, parts[i+1:]...)
+	}
+	return parts
+}
+
+func substitute(resource string, vars Vars) string {
+	return strings.NewReplacer(
+		"${org}", vars.Org,
+		"${username}", vars.Username,
+	).Replace(resource)
+}
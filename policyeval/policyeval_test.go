@@ -0,0 +1,219 @@
+package policyeval
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/manifoldco/torus-cli/envelope"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+const crudl = primitive.PolicyActionCreate | primitive.PolicyActionRead |
+	primitive.PolicyActionUpdate | primitive.PolicyActionDelete | primitive.PolicyActionList
+
+func policy(name string, stmts ...primitive.PolicyStatement) envelope.Policy {
+	p := envelope.Policy{Body: &primitive.Policy{}}
+	p.Body.Policy.Name = name
+	p.Body.Policy.Statements = stmts
+	return p
+}
+
+func stmt(effect primitive.PolicyEffect, action primitive.PolicyAction, resource string) primitive.PolicyStatement {
+	return primitive.PolicyStatement{Effect: effect, Action: action, Resource: resource}
+}
+
+func TestEvaluate(t *testing.T) {
+	allow, deny := primitive.PolicyEffect(primitive.PolicyEffectAllow), primitive.PolicyEffect(primitive.PolicyEffectDeny)
+
+	member := policy("member",
+		stmt(allow, crudl, "/${org}/*/[dev-${username}|dev-@]/*/*/*/*"),
+	)
+	guest := policy("guest",
+		stmt(deny, crudl, "/acme/*/*/*/*/*/*"),
+		stmt(allow, primitive.PolicyActionRead|primitive.PolicyActionList, "/acme/api/staging/*/*/*/*"),
+	)
+	admin := policy("admin",
+		stmt(deny, crudl, "teams:owner"),
+		stmt(allow, crudl, "teams:*"),
+		stmt(allow, crudl, "/${org}/*/*/*/*/*/*"),
+	)
+
+	vars := Vars{Org: "acme", Username: "jeff"}
+
+	tcs := []struct {
+		name     string
+		policies []envelope.Policy
+		action   primitive.PolicyAction
+		resource string
+		allowed  bool
+		policy   string
+	}{
+		{"own dev env", []envelope.Policy{member}, primitive.PolicyActionUpdate,
+			"/acme/api/dev-jeff/web/jeff/1/port", true, "member"},
+		{"other dev env", []envelope.Policy{member}, primitive.PolicyActionRead,
+			"/acme/api/dev-bob/web/bob/1/port", false, ""},
+		{"specific allow wins", []envelope.Policy{guest}, primitive.PolicyActionRead,
+			"/acme/api/staging/web/*/*/port", true, "guest"},
+		{"allow must cover whole resource", []envelope.Policy{guest}, primitive.PolicyActionRead,
+			"/acme/api/[staging|production]/web/*/*/port", false, "guest"},
+		{"broad deny applies", []envelope.Policy{guest}, primitive.PolicyActionUpdate,
+			"/acme/api/staging/web/*/*/port", false, "guest"},
+		{"deny wins a tie", []envelope.Policy{admin, guest}, primitive.PolicyActionRead,
+			"/acme/api/production/**/port", false, "guest"},
+		{"named resource", []envelope.Policy{admin}, primitive.PolicyActionCreate,
+			"teams:owner", false, "admin"},
+		{"named glob", []envelope.Policy{admin}, primitive.PolicyActionCreate,
+			"teams:ops", true, "admin"},
+		{"segment count must match", []envelope.Policy{admin}, primitive.PolicyActionRead,
+			"/acme/api", false, ""},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := ParseResource(tc.resource)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ds, err := Evaluate(tc.policies, vars, &Request{}, tc.action, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ds) != 1 {
+				t.Fatalf("got %d decisions, want 1", len(ds))
+			}
+
+			d := ds[0]
+			if d.Allowed != tc.allowed {
+				t.Errorf("got allowed=%t, want %t", d.Allowed, tc.allowed)
+			}
+
+			name := ""
+			if d.Policy != nil {
+				name = d.Policy.Body.Policy.Name
+			}
+			if name != tc.policy {
+				t.Errorf("decided by policy %q, want %q", name, tc.policy)
+			}
+		})
+	}
+}
+
+func TestEvaluateActions(t *testing.T) {
+	p := policy("read", primitive.PolicyStatement{
+		Effect:   primitive.PolicyEffectAllow,
+		Action:   primitive.PolicyActionRead,
+		Resource: "/acme/*/*/*/*/*/*",
+	})
+
+	r, err := ParseResource("/acme/api/prod/web/*/1/port")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := Evaluate([]envelope.Policy{p}, Vars{}, &Request{},
+		primitive.PolicyActionRead|primitive.PolicyActionUpdate, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds) != 2 || ds[0].Action != primitive.PolicyActionRead || !ds[0].Allowed ||
+		ds[1].Action != primitive.PolicyActionUpdate || ds[1].Allowed {
+		t.Errorf("unexpected decisions: %+v", ds)
+	}
+}
+
+func TestEvaluateConditions(t *testing.T) {
+	allow := primitive.PolicyEffect(primitive.PolicyEffectAllow)
+	prod := "/acme/api/prod/*/*/*/*"
+
+	businessHours := stmt(allow, primitive.PolicyActionUpdate, prod)
+	businessHours.Conditions = &primitive.PolicyConditions{
+		Hours: "09:00-17:00", Days: []string{"mon", "tue", "wed", "thu", "fri"},
+	}
+	stepUp := stmt(allow, primitive.PolicyActionRead, prod)
+	stepUp.Conditions = &primitive.PolicyConditions{RecentAuth: "15m"}
+	ci := stmt(allow, primitive.PolicyActionRead, "/acme/api/ci/*/*/*/*")
+	ci.Conditions = &primitive.PolicyConditions{MachineTeam: "ci", Hours: "22:00-06:00"}
+
+	policies := []envelope.Policy{
+		policy("member", stmt(allow, crudl, "/acme/*/*/*/*/*/*")),
+		policy("prod", businessHours, stepUp, stmt(allow, primitive.PolicyActionRead, prod)),
+		policy("ci", ci),
+	}
+
+	tuesday := time.Date(2017, 5, 2, 10, 0, 0, 0, time.UTC)
+	saturday := time.Date(2017, 5, 6, 10, 0, 0, 0, time.UTC)
+	night := time.Date(2017, 5, 2, 23, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		name     string
+		req      Request
+		action   primitive.PolicyAction
+		resource string
+		allowed  bool
+	}{
+		{"within hours", Request{Time: tuesday}, primitive.PolicyActionUpdate, "/acme/api/prod/web/*/1/port", true},
+		{"weekend", Request{Time: saturday}, primitive.PolicyActionUpdate, "/acme/api/prod/web/*/1/port", false},
+		{"stale login", Request{Time: tuesday, Authenticated: tuesday.Add(-time.Hour)},
+			primitive.PolicyActionRead, "/acme/api/prod/web/*/1/port", false},
+		{"recent login", Request{Time: tuesday, Authenticated: tuesday.Add(-time.Minute)},
+			primitive.PolicyActionRead, "/acme/api/prod/web/*/1/port", true},
+		{"unconditioned elsewhere", Request{Time: saturday}, primitive.PolicyActionUpdate, "/acme/api/dev/web/*/1/port", true},
+		{"machine team at night", Request{Time: night, MachineTeams: []string{"ci"}},
+			primitive.PolicyActionRead, "/acme/api/ci/web/*/1/port", true},
+		{"user at night", Request{Time: night}, primitive.PolicyActionRead, "/acme/api/ci/web/*/1/port", false},
+		{"machine team by day", Request{Time: tuesday, MachineTeams: []string{"ci"}},
+			primitive.PolicyActionRead, "/acme/api/ci/web/*/1/port", false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := ParseResource(tc.resource)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ds, err := Evaluate(policies, Vars{}, &tc.req, tc.action, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ds[0].Allowed != tc.allowed {
+				t.Errorf("got allowed=%t (%s), want %t", ds[0].Allowed, ds[0].Unmet, tc.allowed)
+			}
+			if !tc.allowed && ds[0].Unmet == "" {
+				t.Error("expected an unmet condition")
+			}
+		})
+	}
+}
+
+func TestParseDays(t *testing.T) {
+	days, err := ParseDays("fri-mon,wed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"fri", "sat", "sun", "mon", "wed"}; !reflect.DeepEqual(days, want) {
+		t.Errorf("got %v, want %v", days, want)
+	}
+
+	if _, err := ParseDays("mon-funday"); err == nil {
+		t.Error("expected an error for an unknown day")
+	}
+}
+
+func TestParseResource(t *testing.T) {
+	for _, raw := range []string{"acme/api", "/acme/API", "/a/b/c/d/e/f/g/h", "/acme/[a|b$]"} {
+		if _, err := ParseResource(raw); err == nil {
+			t.Errorf("expected %q to be invalid", raw)
+		}
+	}
+
+	r, err := ParseResource("/acme/api/**/port")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != "/acme/api/*/*/*/*/port" {
+		t.Errorf("got %s", r)
+	}
+}
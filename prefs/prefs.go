@@ -1,6 +1,8 @@
 package prefs
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
 	"os/user"
 	"path"
@@ -8,20 +10,39 @@ import (
 	"strings"
 
 	"github.com/manifoldco/torus-cli/errs"
+	"github.com/manifoldco/torus-cli/pathexp"
 
 	"github.com/go-ini/ini"
 	"gopkg.in/oleiade/reflections.v1"
 )
 
 const (
-	rcFilename  = ".torusrc"
-	registryURI = "https://registry.arigato.sh"
+	rcFilename   = ".torusrc"
+	registryURI  = "https://registry.arigato.sh"
+	aliasSection = "alias"
+
+	defaultRetries = 3
 )
 
 // Preferences represents the configuration as user has in their torusrc file
 type Preferences struct {
-	Core     Core     `ini:"core"`
-	Defaults Defaults `ini:"defaults"`
+	// Version is the version of the schema the file follows.
+	Version int `ini:"version"`
+
+	Core      Core      `ini:"core"`
+	Defaults  Defaults  `ini:"defaults"`
+	Telemetry Telemetry `ini:"telemetry"`
+	Cache     Cache     `ini:"cache"`
+
+	// Aliases maps user defined command names to the arguments they
+	// expand to. They are stored in the [alias] section.
+	Aliases map[string]string `ini:"-"`
+
+	// Profiles, Registries, and Backends are stored in [profile.NAME],
+	// [registry.NAME], and [backend.NAME] sections.
+	Profiles   map[string]*Profile  `ini:"-"`
+	Registries map[string]*Registry `ini:"-"`
+	Backends   map[string]*Backend  `ini:"-"`
 }
 
 // CountFields returns the number of defined fields on sub-field struct
@@ -50,7 +71,12 @@ type Core struct {
 	AutoConfirm    bool   `ini:"auto_confirm,omitempty"`
 	EnableProgress bool   `ini:"progress"`
 	EnableHints    bool   `ini:"hints"`
+	Retries        int    `ini:"retries"`
+	CircuitBreaker bool   `ini:"circuit_breaker"`
 	Vim            bool   `ini:"vim,omitempty"`
+	Lang           string `ini:"lang,omitempty"`
+	Profile        string `ini:"profile,omitempty"`
+	ReadOnly       bool   `ini:"read_only,omitempty"`
 }
 
 // Defaults contains default values for use in command argument flags
@@ -61,52 +87,156 @@ type Defaults struct {
 	Service      string `ini:"service,omitempty"`
 }
 
+// Telemetry contains the settings for recording anonymous usage data
+type Telemetry struct {
+	Enabled bool `ini:"enabled,omitempty"`
+}
+
+// Cache contains the settings for the daemon's caches
+type Cache struct {
+	Prefetch bool `ini:"prefetch"`
+}
+
+// Profile is a named set of defaults, and optionally a registry, selected
+// with core.profile or TORUS_PROFILE
+type Profile struct {
+	Organization string `ini:"org,omitempty"`
+	Project      string `ini:"project,omitempty"`
+	Environment  string `ini:"environment,omitempty"`
+	Service      string `ini:"service,omitempty"`
+	Registry     string `ini:"registry,omitempty"`
+}
+
+// Registry is a named registry for use by profiles
+type Registry struct {
+	URI          string `ini:"uri"`
+	CABundleFile string `ini:"ca_bundle_file,omitempty"`
+}
+
+// Backend is a store of secrets other than the registry, used by the daemon
+// for the secrets at the path expressions it's configured for
+type Backend struct {
+	// Type is file, for an encrypted file on this machine, or registry, for
+	// another registry instance.
+	Type string `ini:"type"`
+
+	// Paths is a comma separated list of the path expressions the backend
+	// holds secrets for.
+	Paths string `ini:"paths"`
+
+	// File is where a file backend keeps its secrets, by default
+	// backends/NAME.json in the torus root.
+	File string `ini:"file,omitempty"`
+
+	// Registry is the name of the registry section of a registry backend.
+	Registry string `ini:"registry,omitempty"`
+}
+
+// PathExps returns the path expressions of the backend's Paths.
+func (b *Backend) PathExps() ([]*pathexp.PathExp, error) {
+	var pes []*pathexp.PathExp
+	for _, raw := range strings.Split(b.Paths, ",") {
+		pe, err := pathexp.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, err
+		}
+		pes = append(pes, pe)
+	}
+	return pes, nil
+}
+
 // SetValue for ini key on preferences struct
 func (prefs Preferences) SetValue(key string, value string) (Preferences, error) {
+	name := key
 	parts := strings.Split(key, ".")
 	section := parts[0] // [Core|Default]
 	key = parts[1]      // Rest of the property name
 
-	// Identify category struct by ini tag name [Core|Default]
-	values := reflect.ValueOf(&prefs).Elem()
-	target := findElemByName(values, section)
+	var values reflect.Value
+	switch section {
+	case "profile", "registry", "backend":
+		if len(parts) != 3 {
+			return prefs, errs.NewExitError("error: " + section + " preferences are set as `" +
+				section + ".<name>.<property>`")
+		}
+		values = prefs.namedSection(section, parts[1])
+		key = parts[2]
+	default:
+		// Identify category struct by ini tag name [Core|Default]
+		target := findElemByName(reflect.ValueOf(&prefs).Elem(), section)
+		if target == "" {
+			return prefs, errs.NewExitError("error: unknown section `" + section + "`")
+		}
+		values = reflect.ValueOf(&prefs).Elem().FieldByName(target)
+		if values.Kind() != reflect.Struct {
+			return prefs, errs.NewExitError("error: unknown section `" + section + "`")
+		}
+	}
 
 	// Identify field to update by ini tag name
-	values = reflect.ValueOf(&prefs).Elem().FieldByName(target)
-	if values == reflect.Zero(reflect.TypeOf(values)).Interface() {
-		return prefs, errs.NewExitError("error: unknown section `" + section + "`")
-	}
 	property := findElemByName(values, key)
-
-	// Ensure the field is not zero
-	field := values.Addr().Elem().FieldByName(property)
-	if field == reflect.Zero(reflect.TypeOf(field)).Interface() {
+	if property == "" {
 		return prefs, errs.NewExitError("error: unknown property `" + key + "`")
 	}
 
-	switch field.Type() {
-	case reflect.TypeOf(true):
-		v := true
+	field := values.FieldByName(property)
+	if field.Kind() == reflect.Bool {
+		// Anything but an explicit true turns a setting off.
 		if value != "true" && value != "1" {
-			v = false
+			value = "false"
 		}
-		field.SetBool(v)
-	default:
-		field.SetString(value)
+	}
+
+	err := setField(field, name, value)
+	if err == nil {
+		err = prefs.validate()
+	}
+	if err != nil {
+		return prefs, errs.NewExitError("error: " + err.Error())
 	}
 
 	return prefs, nil
 }
 
+// namedSection returns the profile, registry, or backend with the given name, creating
+// it if it doesn't exist yet.
+func (prefs *Preferences) namedSection(section, name string) reflect.Value {
+	if section == "backend" {
+		if prefs.Backends == nil {
+			prefs.Backends = make(map[string]*Backend)
+		}
+		if prefs.Backends[name] == nil {
+			prefs.Backends[name] = &Backend{}
+		}
+		return reflect.ValueOf(prefs.Backends[name]).Elem()
+	}
+
+	if section == "profile" {
+		if prefs.Profiles == nil {
+			prefs.Profiles = make(map[string]*Profile)
+		}
+		if prefs.Profiles[name] == nil {
+			prefs.Profiles[name] = &Profile{}
+		}
+		return reflect.ValueOf(prefs.Profiles[name]).Elem()
+	}
+
+	if prefs.Registries == nil {
+		prefs.Registries = make(map[string]*Registry)
+	}
+	if prefs.Registries[name] == nil {
+		prefs.Registries[name] = &Registry{}
+	}
+	return reflect.ValueOf(prefs.Registries[name]).Elem()
+}
+
 func findElemByName(values reflect.Value, iniField string) string {
 	var fieldName string
 	for i := 0; i < values.NumField(); i++ {
 		tag := values.Type().Field(i).Tag
 		names := strings.Split(tag.Get("ini"), ",")
-		for _, a := range names {
-			if a == iniField {
-				fieldName = values.Type().Field(i).Name
-			}
+		if names[0] == iniField {
+			fieldName = values.Type().Field(i).Name
 		}
 	}
 	return fieldName
@@ -121,19 +251,47 @@ func RcPath() (string, error) {
 	return path.Join(u.HomeDir, rcFilename), nil
 }
 
-// NewPreferences returns a new instance of preferences struct
+// NewPreferences returns the preferences in effect, with those of the profile
+// named by TORUS_PROFILE or core.profile applied.
 func NewPreferences() (*Preferences, error) {
+	prefs, err := LoadFile()
+	if err != nil {
+		return prefs, err
+	}
+
+	name := os.Getenv("TORUS_PROFILE")
+	if name == "" {
+		name = prefs.Core.Profile
+	}
+	if name == "" {
+		return prefs, nil
+	}
+
+	err = prefs.applyProfile(name)
+	return prefs, err
+}
+
+// LoadFile returns the preferences as they are written in the torusrc file,
+// for changing and saving. Files written with an older version of the schema
+// are migrated, and rewritten with a copy of the original kept alongside.
+func LoadFile() (*Preferences, error) {
 	prefs := &Preferences{
+		Version: Version,
 		Core: Core{
 			RegistryURI:    registryURI,
 			Context:        true,
 			EnableHints:    true,
 			EnableProgress: true,
+			Retries:        defaultRetries,
+			CircuitBreaker: true,
+		},
+		Cache: Cache{
+			Prefetch: true,
 		},
 	}
 
-	filePath, _ := RcPath()
-	_, err := os.Stat(filePath)
+	rcPath, _ := RcPath()
+	_, err := os.Stat(rcPath)
 	if os.IsNotExist(err) {
 		return prefs, nil
 	}
@@ -142,7 +300,48 @@ func NewPreferences() (*Preferences, error) {
 		return prefs, err
 	}
 
-	rcPath, _ := RcPath()
-	err = ini.MapTo(prefs, rcPath)
-	return prefs, err
+	original, err := ioutil.ReadFile(rcPath)
+	if err != nil {
+		return prefs, err
+	}
+
+	cfg, err := ini.Load(original)
+	if err != nil {
+		return prefs, fmt.Errorf("%s: %s", rcPath, err)
+	}
+
+	version, err := migrate(cfg)
+	if err == nil {
+		err = decode(cfg, prefs)
+	}
+	if err == nil {
+		err = prefs.validate()
+	}
+	if err != nil {
+		return prefs, fmt.Errorf("%s: %s", rcPath, err)
+	}
+
+	if version < Version {
+		// The migrated preferences are used even if they can't be saved.
+		backup := fmt.Sprintf("%s.v%d", rcPath, version)
+		if ioutil.WriteFile(backup, original, 0600) == nil {
+			prefs.Save()
+		}
+	}
+
+	return prefs, nil
+}
+
+// Save writes the preferences to the torusrc file
+func (prefs *Preferences) Save() error {
+	cfg, err := prefs.encode()
+	if err != nil {
+		return err
+	}
+
+	rcPath, err := RcPath()
+	if err != nil {
+		return err
+	}
+	return cfg.SaveTo(rcPath)
 }
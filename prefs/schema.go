@@ -0,0 +1,344 @@
+package prefs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ini/ini"
+)
+
+// Version is the version of the torusrc schema written by this version of
+// the CLI. Files written before the schema was versioned are version 1.
+const Version = 2
+
+const (
+	profilePrefix  = "profile."
+	registryPrefix = "registry."
+	backendPrefix  = "backend."
+)
+
+// migrations upgrade a file from the version at their index plus one to the
+// next version.
+var migrations = []func(*ini.File){
+	migrateV1,
+}
+
+// migrateV1 moves the prefetch and telemetry preferences out of the core
+// section into their own sections.
+func migrateV1(f *ini.File) {
+	core := f.Section("core")
+	moveKey(core, "prefetch", f.Section("cache"), "prefetch")
+	moveKey(core, "telemetry", f.Section("telemetry"), "enabled")
+}
+
+func moveKey(from *ini.Section, fromName string, to *ini.Section, toName string) {
+	if !from.HasKey(fromName) {
+		return
+	}
+
+	value := from.Key(fromName).String()
+	from.DeleteKey(fromName)
+	if !to.HasKey(toName) {
+		to.NewKey(toName, value)
+	}
+}
+
+// migrate upgrades f to the current version, returning the version it was
+// written with.
+func migrate(f *ini.File) (int, error) {
+	version := 1
+	root := f.Section(ini.DEFAULT_SECTION)
+	if root.HasKey("version") {
+		v, err := strconv.Atoi(root.Key("version").String())
+		if err != nil || v < 1 {
+			return 0, errors.New("version must be a whole number")
+		}
+		version = v
+	}
+
+	if version > Version {
+		return 0, fmt.Errorf("version %d was written by a newer version of torus, "+
+			"which supports up to version %d", version, Version)
+	}
+	if version == Version {
+		return version, nil
+	}
+
+	for _, m := range migrations[version-1:] {
+		m(f)
+	}
+
+	root.Key("version").SetValue(strconv.Itoa(Version))
+	return version, nil
+}
+
+// decode reads the preferences in f, which must have been migrated to the
+// current version, into prefs. It fails on any section or key that is not part
+// of the schema, or any value of the wrong type.
+func decode(f *ini.File, prefs *Preferences) error {
+	values := reflect.ValueOf(prefs).Elem()
+	for _, section := range f.Sections() {
+		name := section.Name()
+
+		switch {
+		case name == ini.DEFAULT_SECTION:
+			for _, key := range section.Keys() {
+				if key.Name() != "version" {
+					return fmt.Errorf("%s must be in a section", key.Name())
+				}
+			}
+			prefs.Version = Version
+		case name == aliasSection:
+			prefs.Aliases = section.KeysHash()
+		case strings.HasPrefix(name, profilePrefix):
+			p := &Profile{}
+			if err := decodeSection(section, reflect.ValueOf(p).Elem()); err != nil {
+				return err
+			}
+			if prefs.Profiles == nil {
+				prefs.Profiles = make(map[string]*Profile)
+			}
+			prefs.Profiles[strings.TrimPrefix(name, profilePrefix)] = p
+		case strings.HasPrefix(name, registryPrefix):
+			r := &Registry{}
+			if err := decodeSection(section, reflect.ValueOf(r).Elem()); err != nil {
+				return err
+			}
+			if prefs.Registries == nil {
+				prefs.Registries = make(map[string]*Registry)
+			}
+			prefs.Registries[strings.TrimPrefix(name, registryPrefix)] = r
+		case strings.HasPrefix(name, backendPrefix):
+			b := &Backend{}
+			if err := decodeSection(section, reflect.ValueOf(b).Elem()); err != nil {
+				return err
+			}
+			if prefs.Backends == nil {
+				prefs.Backends = make(map[string]*Backend)
+			}
+			prefs.Backends[strings.TrimPrefix(name, backendPrefix)] = b
+		default:
+			field := findElemByName(values, name)
+			if field == "" || values.FieldByName(field).Kind() != reflect.Struct {
+				return fmt.Errorf("unknown section [%s]", name)
+			}
+			if err := decodeSection(section, values.FieldByName(field)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeSection reads the keys of section into the struct v.
+func decodeSection(section *ini.Section, v reflect.Value) error {
+	for _, key := range section.Keys() {
+		name := section.Name() + "." + key.Name()
+		field := findElemByName(v, key.Name())
+		if field == "" {
+			return fmt.Errorf("unknown preference %s", name)
+		}
+
+		if err := setField(v.FieldByName(field), name, key.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setField sets field, the preference named name, from value.
+func setField(field reflect.Value, name, value string) error {
+	switch field.Kind() {
+	case reflect.Bool:
+		v, err := parseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false", name)
+		}
+		field.SetBool(v)
+	case reflect.Int:
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 0 {
+			return fmt.Errorf("%s must be a whole number", name)
+		}
+		field.SetInt(int64(v))
+	default:
+		field.SetString(value)
+	}
+
+	return nil
+}
+
+// parseBool accepts the same spellings of true and false as the ini package
+// did when it read preferences.
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "1", "t", "true", "y", "yes", "on":
+		return true, nil
+	case "0", "f", "false", "n", "no", "off":
+		return false, nil
+	}
+	return false, errors.New("invalid boolean " + value)
+}
+
+// validate checks that the values of prefs make sense together.
+func (prefs *Preferences) validate() error {
+	if err := validateURI("core.registry_uri", prefs.Core.RegistryURI); err != nil {
+		return err
+	}
+
+	for _, name := range sortedKeys(prefs.Registries) {
+		err := validateURI(registryPrefix+name+".uri", prefs.Registries[name].URI)
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, p := range prefs.Profiles {
+		if p.Registry == "" {
+			continue
+		}
+		if _, ok := prefs.Registries[p.Registry]; !ok {
+			return fmt.Errorf("%s%s.registry: unknown registry %s", profilePrefix, name, p.Registry)
+		}
+	}
+
+	for _, name := range sortedKeys(prefs.Backends) {
+		if err := prefs.validateBackend(name); err != nil {
+			return err
+		}
+	}
+
+	if prefs.Core.Profile != "" {
+		if _, ok := prefs.Profiles[prefs.Core.Profile]; !ok {
+			return fmt.Errorf("core.profile: unknown profile %s", prefs.Core.Profile)
+		}
+	}
+
+	return nil
+}
+
+// validateBackend checks the settings of the named backend. A backend is
+// unused until its paths are set, so that it can be set up one preference at
+// a time; until then, only its type is checked.
+func (prefs *Preferences) validateBackend(name string) error {
+	b := prefs.Backends[name]
+	section := backendPrefix + name
+
+	if b.Type != "file" && b.Type != "registry" && (b.Type != "" || b.Paths != "") {
+		return fmt.Errorf("%s.type must be file or registry", section)
+	}
+	if b.Paths == "" {
+		return nil
+	}
+
+	if _, ok := prefs.Registries[b.Registry]; b.Type == "registry" && !ok {
+		return fmt.Errorf("%s.registry: unknown registry %s", section, b.Registry)
+	}
+
+	if _, err := b.PathExps(); err != nil {
+		return fmt.Errorf("%s.paths must be a comma separated list of path expressions: %s",
+			section, err)
+	}
+
+	return nil
+}
+
+func validateURI(name, value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%s must be a URL, such as https://registry.example.com", name)
+	}
+
+	return nil
+}
+
+// applyProfile replaces the defaults and registry of prefs with those set by
+// the named profile.
+func (prefs *Preferences) applyProfile(name string) error {
+	p, ok := prefs.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %s", name)
+	}
+
+	d := &prefs.Defaults
+	d.Organization = pick(p.Organization, d.Organization)
+	d.Project = pick(p.Project, d.Project)
+	d.Environment = pick(p.Environment, d.Environment)
+	d.Service = pick(p.Service, d.Service)
+
+	if p.Registry != "" {
+		r := prefs.Registries[p.Registry]
+		prefs.Core.RegistryURI = r.URI
+		prefs.Core.CABundleFile = r.CABundleFile
+	}
+
+	return nil
+}
+
+func pick(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
+
+// encode writes prefs to a new ini file.
+func (prefs *Preferences) encode() (*ini.File, error) {
+	cfg := ini.Empty()
+	err := ini.ReflectFrom(cfg, prefs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedKeys(prefs.Registries) {
+		err = cfg.Section(registryPrefix + name).ReflectFrom(prefs.Registries[name])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range sortedKeys(prefs.Profiles) {
+		err = cfg.Section(profilePrefix + name).ReflectFrom(prefs.Profiles[name])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range sortedKeys(prefs.Backends) {
+		err = cfg.Section(backendPrefix + name).ReflectFrom(prefs.Backends[name])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(prefs.Aliases) > 0 {
+		section := cfg.Section(aliasSection)
+		for _, name := range sortedKeys(prefs.Aliases) {
+			_, err = section.NewKey(name, prefs.Aliases[name])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// sortedKeys returns the keys of m, which must be a map with string keys, in
+// order.
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.String()
+	}
+	sort.Strings(names)
+	return names
+}
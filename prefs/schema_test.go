@@ -0,0 +1,153 @@
+package prefs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-ini/ini"
+)
+
+func load(t *testing.T, src string) (*Preferences, int, error) {
+	f, err := ini.Load([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefs := &Preferences{Core: Core{RegistryURI: registryURI}}
+	version, err := migrate(f)
+	if err == nil {
+		err = decode(f, prefs)
+	}
+	if err == nil {
+		err = prefs.validate()
+	}
+	return prefs, version, err
+}
+
+func TestMigrateV1(t *testing.T) {
+	prefs, version, err := load(t, `
+[core]
+prefetch = false
+telemetry = true
+retries = 5
+
+[alias]
+vp = view -e production
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 {
+		t.Errorf("expected version 1, got %d", version)
+	}
+	if prefs.Cache.Prefetch || !prefs.Telemetry.Enabled || prefs.Core.Retries != 5 {
+		t.Errorf("preferences not migrated: %+v", prefs)
+	}
+	if prefs.Aliases["vp"] != "view -e production" {
+		t.Errorf("alias not kept: %v", prefs.Aliases)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tcs := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{"unknown section", "version = 2\n[colours]\nred = 1", "unknown section [colours]"},
+		{"unknown key", "version = 2\n[core]\nregistry = x", "unknown preference core.registry"},
+		{"bad bool", "version = 2\n[cache]\nprefetch = maybe", "cache.prefetch must be true or false"},
+		{"bad int", "version = 2\n[core]\nretries = -1", "core.retries must be a whole number"},
+		{"bad uri", "version = 2\n[core]\nregistry_uri = registry", "core.registry_uri must be a URL"},
+		{"newer", "version = 3", "newer version of torus"},
+		{"unknown registry", "version = 2\n[profile.work]\nregistry = work",
+			"profile.work.registry: unknown registry work"},
+		{"unknown profile", "version = 2\n[core]\nprofile = work", "core.profile: unknown profile work"},
+		{"backend type", "version = 2\n[backend.local]\ntype = s3\npaths = /acme/app/**",
+			"backend.local.type must be file or registry"},
+		{"backend registry", "version = 2\n[backend.old]\ntype = registry\npaths = /acme/app/**",
+			"backend.old.registry: unknown registry"},
+		{"backend paths", "version = 2\n[backend.local]\ntype = file\npaths = /acme/app/**,acme",
+			"backend.local.paths must be a comma separated list of path expressions"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := load(t, tc.src)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	prefs, _, err := load(t, `
+version = 2
+
+[defaults]
+org = personal
+project = site
+
+[registry.work]
+uri = https://registry.example.com
+
+[profile.work]
+org = acme
+registry = work
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = prefs.applyProfile("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if prefs.Defaults.Organization != "acme" || prefs.Defaults.Project != "site" {
+		t.Errorf("profile defaults not applied: %+v", prefs.Defaults)
+	}
+	if prefs.Core.RegistryURI != "https://registry.example.com" {
+		t.Errorf("profile registry not applied: %s", prefs.Core.RegistryURI)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	prefs, _, err := load(t, `
+version = 2
+
+[telemetry]
+enabled = true
+
+[registry.work]
+uri = https://registry.example.com
+
+[profile.work]
+registry = work
+
+[backend.local]
+type = file
+paths = /acme/app/dev/**, /acme/app/ci/**
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := prefs.encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &Preferences{}
+	err = decode(f, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Version != Version || !out.Telemetry.Enabled ||
+		out.Profiles["work"].Registry != "work" ||
+		out.Registries["work"].URI != "https://registry.example.com" ||
+		out.Backends["local"].Type != "file" {
+		t.Errorf("preferences not round tripped: %+v", out)
+	}
+}
@@ -39,6 +39,14 @@ func (v2Schema) Version() int {
 	return 2
 }
 
+// v3Schema embeds in other structs to indicate their schema version is 3.
+type v3Schema struct{}
+
+// Version returns the schema version of structs that embed this type.
+func (v3Schema) Version() int {
+	return 3
+}
+
 // User is the body of a user object
 type User struct { // type: 0x01
 	v1Schema
@@ -116,6 +124,12 @@ type PublicKey struct { // type: 0x06
 	KeyType   KeyType        `json:"type"`
 }
 
+// Expired returns whether the key's expiry time is before now. Keys without
+// an expiry time never expire.
+func (pk *PublicKey) Expired(now time.Time) bool {
+	return !pk.Expires.IsZero() && pk.Expires.Before(now)
+}
+
 // ClaimType is the enumeration of all claims that can be made against public
 // keys.
 type ClaimType string
@@ -153,6 +167,16 @@ func NewClaim(orgID, ownerID, previous, pubKeyID *identity.ID, claimType ClaimTy
 // Credential is a secret value shared between a group of services based
 // on users identity, operating environment, project, and organization
 type Credential struct { // type: 0x0b
+	v3Schema
+	immutable
+	BaseCredential
+	CredentialMeta
+	State *string `json:"state"`
+}
+
+// CredentialV2 is a secret value shared between a group of services based
+// on users identity, operating environment, project, and organization
+type CredentialV2 struct { // type: 0x0b
 	v2Schema
 	immutable
 	BaseCredential
@@ -181,6 +205,52 @@ type BaseCredential struct {
 	CredentialVersion int              `json:"version"`
 }
 
+// CredentialMeta is optional, unencrypted information about a Credential.
+// It is signed along with the rest of the Credential.
+type CredentialMeta struct {
+	Description string     `json:"description"`
+	Tags        []string   `json:"tags"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+
+	// Honeytoken marks a secret that should never be read, so that reading
+	// it alerts the org.
+	Honeytoken bool `json:"honeytoken,omitempty"`
+
+	// Broker, if set, makes the secret dynamic. Its value configures a
+	// provider, which mints a new short-lived value each time it is read.
+	Broker *BrokerSpec `json:"broker,omitempty"`
+}
+
+// BrokerSpec describes how the values of a dynamic secret are minted.
+type BrokerSpec struct {
+	// Provider names the plugin that mints values, such as postgres.
+	Provider string `json:"provider"`
+
+	// TTL is how many seconds a minted value lasts before it is revoked.
+	TTL int64 `json:"ttl"`
+}
+
+// Empty returns whether no metadata is set.
+func (m *CredentialMeta) Empty() bool {
+	return m.Description == "" && len(m.Tags) == 0 && m.ExpiresAt == nil && !m.Honeytoken &&
+		m.Broker == nil
+}
+
+// HasTag returns whether the metadata includes tag.
+func (m *CredentialMeta) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired returns whether the credential expired before now.
+func (m *CredentialMeta) Expired(now time.Time) bool {
+	return m.ExpiresAt != nil && m.ExpiresAt.Before(now)
+}
+
 // CredentialValue is the secretbox encrypted value of the containing
 // Credential.
 type CredentialValue struct {
@@ -317,6 +387,22 @@ const (
 	OrgInviteApprovedState   = "approved"
 )
 
+// The invitation email for an OrgInvite is delivered asynchronously. The
+// registry tracks its progress through these states.
+const (
+	OrgInviteDeliveryQueued  = "queued"
+	OrgInviteDeliverySent    = "sent"
+	OrgInviteDeliveryBounced = "bounced"
+	OrgInviteDeliveryOpened  = "opened"
+)
+
+// OrgInviteDelivery holds the delivery status of an OrgInvite's email.
+type OrgInviteDelivery struct {
+	State   string     `json:"state"`
+	Reason  string     `json:"reason,omitempty"`
+	Updated *time.Time `json:"updated_at"`
+}
+
 // OrgInvite is an invitation for an individual to join an organization
 type OrgInvite struct { // type: 0x13
 	v1Schema
@@ -336,6 +422,10 @@ type OrgInvite struct { // type: 0x13
 	Created      *time.Time    `json:"created_at"`
 	Accepted     *time.Time    `json:"accepted_at"`
 	Approved     *time.Time    `json:"approved_at"`
+
+	// Delivery is set by the registry, and may be absent for invites sent
+	// before delivery tracking existed.
+	Delivery *OrgInviteDelivery `json:"delivery,omitempty"`
 }
 
 // Machines can be in one of two states: active or destroyed
@@ -376,6 +466,11 @@ type MachineToken struct { // type: 0x18
 	DestroyedBy *identity.ID           `json:"destroyed_by"`
 	Destroyed   *time.Time             `json:"destroyed_at"`
 	State       string                 `json:"state"`
+
+	// AllowedCIDRs are the networks, in CIDR notation, the registry accepts
+	// logins with this token from. The token can be used from anywhere if it
+	// is empty.
+	AllowedCIDRs []string `json:"allowed_cidrs,omitempty"`
 }
 
 // MachineTokenPublicKey represents a public used by a machine to authenticate
@@ -386,15 +481,44 @@ type MachineTokenPublicKey struct {
 	Value *base64.Value `json:"value"`
 }
 
+// Projects are active, or archived. Archived projects are hidden from
+// listings, and no new secrets can be set in them, but their keyrings are
+// kept, so they can be restored.
+const (
+	ProjectActiveState   = "active"
+	ProjectArchivedState = "archived"
+)
+
+// ProjectV1 is the old project format, without a state.
+type ProjectV1 struct { // type: 0x04
+	v1Schema
+	mutable
+	Name  string       `json:"name"`
+	OrgID *identity.ID `json:"org_id"`
+}
+
 // Project is an entity that represents a group of services
 type Project struct { // type: 0x04
-	v1Schema
+	v2Schema
 	mutable
 	Name  string       `json:"name"`
 	OrgID *identity.ID `json:"org_id"`
+
+	// State is empty for projects created before projects had states, which
+	// are active.
+	State string `json:"state,omitempty"`
+}
+
+// Archived returns whether the project has been archived.
+func (p *Project) Archived() bool {
+	return p.State == ProjectArchivedState
 }
 
 // Policy is an entity that represents a group of statements for acl
+//
+// Policies written by users are signed by their author's signing key, over
+// the rest of the body. System policies, and those written by older clients,
+// have no signature.
 type Policy struct { // type: 0x11
 	v1Schema
 	mutable
@@ -406,13 +530,58 @@ type Policy struct { // type: 0x11
 		Description string            `json:"description"`
 		Statements  []PolicyStatement `json:"statements"`
 	} `json:"policy"`
+	Signature *Signature `json:"sig,omitempty"`
 }
 
 // PolicyStatement is an acl statement on a policy object
 type PolicyStatement struct {
-	Effect   PolicyEffect `json:"effect"`
-	Action   PolicyAction `json:"action"`
-	Resource string       `json:"resource"`
+	Effect     PolicyEffect      `json:"effect"`
+	Action     PolicyAction      `json:"action"`
+	Resource   string            `json:"resource"`
+	Conditions *PolicyConditions `json:"conditions,omitempty"`
+}
+
+// PolicyConditions restrict when an allow statement applies. When any of
+// them is not met, the statement denies its actions instead.
+type PolicyConditions struct {
+	// Hours is a daily window, such as 09:00-17:00. A window ending before
+	// it starts wraps past midnight.
+	Hours string `json:"hours,omitempty"`
+
+	// Days are the days of the week the statement applies on, such as mon.
+	Days []string `json:"days,omitempty"`
+
+	// TimeZone is the IANA time zone of Hours and Days. UTC if empty.
+	TimeZone string `json:"time_zone,omitempty"`
+
+	// MachineTeam is a machine team the actor must belong to.
+	MachineTeam string `json:"machine_team,omitempty"`
+
+	// RecentAuth is a duration, such as 15m, within which the actor must have
+	// logged in.
+	RecentAuth string `json:"recent_auth,omitempty"`
+}
+
+// String returns a short description of the conditions, such as
+// "09:00-17:00 mon,tue UTC".
+func (pc *PolicyConditions) String() string {
+	var out []string
+	if pc.Hours != "" || len(pc.Days) > 0 {
+		when := strings.TrimSpace(pc.Hours + " " + strings.Join(pc.Days, ","))
+		zone := pc.TimeZone
+		if zone == "" {
+			zone = "UTC"
+		}
+		out = append(out, when+" "+zone)
+	}
+	if pc.MachineTeam != "" {
+		out = append(out, "machine team "+pc.MachineTeam)
+	}
+	if pc.RecentAuth != "" {
+		out = append(out, "login within "+pc.RecentAuth)
+	}
+
+	return strings.Join(out, "; ")
 }
 
 // PolicyEffect is the effect type of the statement (allow or deny)
@@ -579,6 +748,11 @@ const (
 	MachineTeamType TeamType = "machine"
 )
 
+// GuestTeamPrefix is the name prefix of user teams created for guests. Guests
+// are only encoded into the keyrings that the policies attached to their guest
+// team allow them to read.
+const GuestTeamPrefix = "guest-"
+
 // Teams are used to represent a group of identities and their associated
 // access control policies
 const (
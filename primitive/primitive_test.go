@@ -64,3 +64,44 @@ func TestPolicyActionUnarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestCredentialMetaMarshalJSON(t *testing.T) {
+	state := "set"
+
+	t.Run("v2", func(t *testing.T) {
+		out, err := json.Marshal(&CredentialV2{State: &state})
+		if err != nil {
+			t.Fatal("Error while marshaling:", err)
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(out, &fields); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, k := range []string{"description", "tags", "expires_at"} {
+			if _, ok := fields[k]; ok {
+				t.Errorf("Expected no %s in v2 credential, Got: %s", k, out)
+			}
+		}
+	})
+
+	t.Run("v3", func(t *testing.T) {
+		cred := Credential{State: &state}
+		cred.CredentialMeta = CredentialMeta{Description: "db", Tags: []string{"prod"}}
+
+		out, err := json.Marshal(&cred)
+		if err != nil {
+			t.Fatal("Error while marshaling:", err)
+		}
+
+		got := Credential{}
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Description != "db" || !got.HasTag("prod") || got.ExpiresAt != nil {
+			t.Errorf("Metadata did not round trip, Got: %s", out)
+		}
+	})
+}
@@ -0,0 +1,241 @@
+package promptui
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+// queueRows is the number of items shown at once in a Queue.
+const queueRows = 10
+
+// QueueAction is an action that can be taken on the marked items of a Queue.
+type QueueAction struct {
+	Key   rune   // Key is the key that chooses the action.
+	Label string // Label describes the action, such as "approve".
+}
+
+// Queue represents a list of items that are marked with the space bar, and
+// then acted on all at once. Pressing an action's key chooses it, and enter
+// takes it; pressing the key again changes your mind.
+type Queue struct {
+	Label     string        // Label is the value displayed on the command line prompt.
+	Items     []string      // Items are the items to use in the list.
+	Actions   []QueueAction // Actions are the actions that can be taken.
+	IsVimMode bool          // Whether readline is using Vim mode.
+}
+
+// Run runs the Queue. It returns the action that was taken, and the indices
+// of the items it applies to. If no items were marked, the action applies to
+// the highlighted item. A nil action is returned if enter is pressed before an
+// action is chosen.
+func (q *Queue) Run() (*QueueAction, []int, error) {
+	stdin := readline.NewCancelableStdin(os.Stdin)
+	c := &readline.Config{}
+	err := c.Init()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.Stdin = stdin
+	c.VimMode = q.IsVimMode
+	c.HistoryLimit = -1
+	c.UniqueEditLine = true
+
+	rl, err := readline.NewEx(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s := newQueueState(len(q.Items))
+	rows := s.end - s.start + 2
+
+	rl.Write([]byte(hideCursor))
+	rl.Write([]byte(strings.Repeat("\n", rows)))
+
+	rl.Operation.ExitVimInsertMode()
+
+	c.SetListener(func(line []rune, pos int, key rune) ([]rune, int, bool) {
+		if rl.Operation.IsEnableVimMode() {
+			rl.Operation.ExitVimInsertMode()
+		}
+
+		s.handle(key, q.Actions)
+
+		list := make([]string, 0, rows)
+		for i := s.start; i <= s.end; i++ {
+			page := ' '
+			switch {
+			case i == s.start && i > 0:
+				page = '↑'
+			case i == s.end && i < len(q.Items)-1:
+				page = '↓'
+			}
+
+			pointer := " "
+			item := q.Items[i]
+			if i == s.selected {
+				pointer = "▸"
+				item = underlined(item)
+			}
+
+			mark := "[ ]"
+			if s.marked[i] {
+				mark = "[" + iconGood + "]"
+			}
+
+			list = append(list, clearLine+"\r"+string(page)+" "+pointer+" "+mark+" "+item)
+		}
+		list = append(list, clearLine+"\r"+faint(q.footer(s)))
+
+		prefix := upLine(uint(len(list))) + "\r" + clearLine
+		p := prefix + bold(iconInitial) + " " + bold(q.Label+": ") + downLine(1) +
+			strings.Join(list, downLine(1))
+		rl.SetPrompt(p)
+		rl.Refresh()
+
+		return nil, 0, true
+	})
+
+	_, err = rl.Readline()
+	rl.Close()
+
+	if err != nil {
+		switch {
+		case err == readline.ErrInterrupt, err.Error() == "Interrupt":
+			err = ErrInterrupt
+		case err == io.EOF:
+			err = ErrEOF
+		}
+
+		rl.Write([]byte("\n"))
+		rl.Write([]byte(showCursor))
+		rl.Refresh()
+		return nil, nil, err
+	}
+
+	rl.Write(bytes.Repeat([]byte(clearLine+upLine(1)), rows))
+	rl.Write([]byte("\r"))
+
+	items := s.items()
+	summary := "nothing"
+	if s.action != nil {
+		summary = s.action.Label + " " + strconv.Itoa(len(items))
+	}
+	rl.Write([]byte(iconGood + " " + q.Label + ": " + faint(summary) + "\n"))
+	rl.Write([]byte(showCursor))
+
+	if s.action == nil {
+		return nil, nil, nil
+	}
+	return s.action, items, nil
+}
+
+// footer describes the keys that can be pressed, or the action about to be
+// taken.
+func (q *Queue) footer(s *queueState) string {
+	if s.action != nil {
+		return "press enter to " + s.action.Label + " " + strconv.Itoa(len(s.items())) +
+			", or " + string(s.action.Key) + " again to cancel"
+	}
+
+	keys := []string{"space mark", "* mark all"}
+	for _, a := range q.Actions {
+		keys = append(keys, string(a.Key)+" "+a.Label)
+	}
+	keys = append(keys, "enter quit")
+	return strconv.Itoa(len(s.marked)) + " marked · " + strings.Join(keys, " · ")
+}
+
+// queueState is the position and marked items of a Queue, updated as keys
+// are pressed.
+type queueState struct {
+	count    int
+	selected int
+	start    int
+	end      int
+	marked   map[int]bool
+	action   *QueueAction
+}
+
+func newQueueState(count int) *queueState {
+	end := queueRows - 1
+	if count <= queueRows {
+		end = count - 1
+	}
+
+	return &queueState{count: count, end: end, marked: make(map[int]bool)}
+}
+
+func (s *queueState) handle(key rune, actions []QueueAction) {
+	if s.count == 0 {
+		return
+	}
+
+	switch key {
+	case readline.CharNext, 'j':
+		if s.selected < s.count-1 {
+			s.selected++
+			if s.selected > s.end {
+				s.start++
+				s.end++
+			}
+		}
+	case readline.CharPrev, 'k':
+		if s.selected > 0 {
+			s.selected--
+			if s.selected < s.start {
+				s.start--
+				s.end--
+			}
+		}
+	case ' ':
+		if s.marked[s.selected] {
+			delete(s.marked, s.selected)
+		} else {
+			s.marked[s.selected] = true
+		}
+	case '*':
+		if len(s.marked) == s.count {
+			s.marked = make(map[int]bool)
+		} else {
+			for i := 0; i < s.count; i++ {
+				s.marked[i] = true
+			}
+		}
+	default:
+		for i := range actions {
+			if actions[i].Key != key {
+				continue
+			}
+			if s.action == &actions[i] {
+				s.action = nil
+			} else {
+				s.action = &actions[i]
+			}
+		}
+	}
+}
+
+// items returns the marked items in order, or the selected item if none are
+// marked.
+func (s *queueState) items() []int {
+	if len(s.marked) == 0 {
+		if s.count == 0 {
+			return nil
+		}
+		return []int{s.selected}
+	}
+
+	items := make([]int, 0, len(s.marked))
+	for i := 0; i < s.count; i++ {
+		if s.marked[i] {
+			items = append(items, i)
+		}
+	}
+	return items
+}
@@ -0,0 +1,67 @@
+package promptui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chzyer/readline"
+)
+
+func TestQueueState(t *testing.T) {
+	actions := []QueueAction{{Key: 'a', Label: "approve"}, {Key: 'r', Label: "reject"}}
+
+	press := func(s *queueState, keys ...rune) {
+		for _, k := range keys {
+			s.handle(k, actions)
+		}
+	}
+
+	t.Run("the highlighted item is used when none are marked", func(t *testing.T) {
+		s := newQueueState(3)
+		press(s, readline.CharNext, readline.CharNext, readline.CharNext)
+		if !reflect.DeepEqual(s.items(), []int{2}) {
+			t.Errorf("expected item 2, got %v", s.items())
+		}
+	})
+
+	t.Run("marked items are used in order", func(t *testing.T) {
+		s := newQueueState(3)
+		press(s, 'j', 'j', ' ', 'k', 'k', ' ', 'a')
+		if !reflect.DeepEqual(s.items(), []int{0, 2}) {
+			t.Errorf("expected items 0 and 2, got %v", s.items())
+		}
+		if s.action == nil || s.action.Label != "approve" {
+			t.Errorf("expected approve, got %v", s.action)
+		}
+	})
+
+	t.Run("actions can be cancelled", func(t *testing.T) {
+		s := newQueueState(3)
+		press(s, 'r', 'r')
+		if s.action != nil {
+			t.Errorf("expected no action, got %v", s.action)
+		}
+	})
+
+	t.Run("all items can be marked", func(t *testing.T) {
+		s := newQueueState(3)
+		press(s, '*')
+		if len(s.items()) != 3 {
+			t.Errorf("expected 3 items, got %v", s.items())
+		}
+		press(s, '*')
+		if len(s.marked) != 0 {
+			t.Errorf("expected no marked items, got %v", s.marked)
+		}
+	})
+
+	t.Run("long lists scroll", func(t *testing.T) {
+		s := newQueueState(queueRows + 5)
+		for i := 0; i < queueRows+2; i++ {
+			press(s, 'j')
+		}
+		if s.start != 3 || s.end != queueRows+2 || s.selected != queueRows+2 {
+			t.Errorf("unexpected window %d-%d, selected %d", s.start, s.end, s.selected)
+		}
+	})
+}
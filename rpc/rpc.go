@@ -0,0 +1,266 @@
+// Package rpc describes the gRPC interface of the torus daemon, and provides
+// a client for it.
+//
+// The daemon serves the interface on a domain socket next to its HTTP
+// socket, so that other Go programs can read and write secrets through a
+// running daemon without shelling out to the CLI:
+//
+//	conn, err := rpc.Dial(cfg.GRPCSocketPath)
+//	if err != nil {
+//		return err
+//	}
+//	defer conn.Close()
+//
+//	client := rpc.NewDaemonClient(conn)
+//	creds, err := client.GetCredentials(ctx, &rpc.GetCredentialsRequest{
+//		Path: "/myorg/myproject/dev/default/*/*",
+//	})
+//
+// The service is described here by hand rather than generated from a .proto
+// file. Messages are encoded as JSON, so they share their types with the rest
+// of the CLI.
+package rpc
+
+import (
+	"encoding/json"
+	"net"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+	"github.com/manifoldco/torus-cli/identity"
+	"github.com/manifoldco/torus-cli/pathexp"
+	"github.com/manifoldco/torus-cli/primitive"
+)
+
+const serviceName = "torus.Daemon"
+
+// SessionRequest requests the status of the daemon's session.
+type SessionRequest struct{}
+
+// SessionResponse describes the daemon's session.
+type SessionResponse struct {
+	Type apitypes.SessionType `json:"type"`
+
+	// ID is the id of the logged in user or machine, and AuthID the id of
+	// the user or machine token they logged in with.
+	ID     *identity.ID `json:"id,omitempty"`
+	AuthID *identity.ID `json:"auth_id,omitempty"`
+
+	// Name is the username of a logged in user, or the name of a logged in
+	// machine.
+	Name string `json:"name,omitempty"`
+}
+
+// GetCredentialsRequest requests the credentials at a path, or matching a
+// path expression. Only one of Path and PathExp may be set.
+type GetCredentialsRequest struct {
+	Path    string `json:"path,omitempty"`
+	PathExp string `json:"pathexp,omitempty"`
+
+	// Offline reads the credentials at Path from the daemon's cache without
+	// contacting the registry.
+	Offline bool `json:"offline,omitempty"`
+}
+
+// GetCredentialsResponse holds the decrypted credentials for a
+// GetCredentialsRequest.
+type GetCredentialsResponse struct {
+	Credentials []Credential `json:"credentials"`
+
+	// CachedAt is set when the credentials were read from the daemon's cache.
+	CachedAt *time.Time `json:"cached_at,omitempty"`
+}
+
+// SetCredentialRequest requests that a credential is encrypted and stored.
+type SetCredentialRequest struct {
+	Credential *Credential `json:"credential"`
+
+	// Force writes the credential even if another version of it was written
+	// at the same time.
+	Force bool `json:"force,omitempty"`
+
+	// Confirmed allows writing to a protected environment.
+	Confirmed bool `json:"confirmed,omitempty"`
+}
+
+// SetCredentialResponse holds the credential stored by a
+// SetCredentialRequest.
+type SetCredentialResponse struct {
+	Credential *Credential `json:"credential"`
+}
+
+// Credential is a decrypted credential.
+type Credential struct {
+	ID        *identity.ID              `json:"id,omitempty"`
+	Name      string                    `json:"name"`
+	OrgID     *identity.ID              `json:"org_id"`
+	ProjectID *identity.ID              `json:"project_id"`
+	PathExp   *pathexp.PathExp          `json:"pathexp"`
+	Value     *apitypes.CredentialValue `json:"value"`
+
+	primitive.CredentialMeta
+
+	// CredentialVersion is only set when reading credentials.
+	CredentialVersion int `json:"credential_version,omitempty"`
+}
+
+// DaemonClient is the client API for the daemon's gRPC service.
+type DaemonClient interface {
+	Session(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*SessionResponse, error)
+	GetCredentials(ctx context.Context, in *GetCredentialsRequest, opts ...grpc.CallOption) (*GetCredentialsResponse, error)
+	SetCredential(ctx context.Context, in *SetCredentialRequest, opts ...grpc.CallOption) (*SetCredentialResponse, error)
+}
+
+type daemonClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewDaemonClient returns a DaemonClient using the given connection. The
+// connection must be created with Dial, or use the Codec.
+func NewDaemonClient(cc *grpc.ClientConn) DaemonClient {
+	return &daemonClient{cc: cc}
+}
+
+func (c *daemonClient) Session(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*SessionResponse, error) {
+	out := new(SessionResponse)
+	err := grpc.Invoke(ctx, "/"+serviceName+"/Session", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) GetCredentials(ctx context.Context, in *GetCredentialsRequest, opts ...grpc.CallOption) (*GetCredentialsResponse, error) {
+	out := new(GetCredentialsResponse)
+	err := grpc.Invoke(ctx, "/"+serviceName+"/GetCredentials", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) SetCredential(ctx context.Context, in *SetCredentialRequest, opts ...grpc.CallOption) (*SetCredentialResponse, error) {
+	out := new(SetCredentialResponse)
+	err := grpc.Invoke(ctx, "/"+serviceName+"/SetCredential", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DaemonServer is the server API for the daemon's gRPC service.
+type DaemonServer interface {
+	Session(context.Context, *SessionRequest) (*SessionResponse, error)
+	GetCredentials(context.Context, *GetCredentialsRequest) (*GetCredentialsResponse, error)
+	SetCredential(context.Context, *SetCredentialRequest) (*SetCredentialResponse, error)
+}
+
+// RegisterDaemonServer registers srv with s. s must be created with the
+// ServerOption returned by ServerCodec.
+func RegisterDaemonServer(s *grpc.Server, srv DaemonServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+func sessionHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).Session(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + serviceName + "/Session",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).Session(ctx, req.(*SessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func getCredentialsHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCredentialsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).GetCredentials(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + serviceName + "/GetCredentials",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).GetCredentials(ctx, req.(*GetCredentialsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func setCredentialHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetCredentialRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).SetCredential(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + serviceName + "/SetCredential",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).SetCredential(ctx, req.(*SetCredentialRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*DaemonServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Session", Handler: sessionHandler},
+		{MethodName: "GetCredentials", Handler: getCredentialsHandler},
+		{MethodName: "SetCredential", Handler: setCredentialHandler},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// Codec encodes the daemon's gRPC messages as JSON.
+type Codec struct{}
+
+// Marshal implements the grpc.Codec interface.
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements the grpc.Codec interface.
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// String implements the grpc.Codec interface.
+func (Codec) String() string {
+	return "json"
+}
+
+// ServerCodec returns the ServerOption for serving the daemon's service.
+func ServerCodec() grpc.ServerOption {
+	return grpc.CustomCodec(Codec{})
+}
+
+// Dial connects to the daemon's gRPC domain socket at socketPath.
+func Dial(socketPath string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithCodec(Codec{}),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, timeout)
+		}),
+	}, opts...)
+
+	return grpc.Dial(socketPath, opts...)
+}
@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/manifoldco/torus-cli/apitypes"
+)
+
+type testServer struct{}
+
+func (testServer) Session(ctx context.Context, req *SessionRequest) (*SessionResponse, error) {
+	return &SessionResponse{Type: apitypes.UserSession, Name: "jeff"}, nil
+}
+
+func (testServer) GetCredentials(ctx context.Context, req *GetCredentialsRequest) (*GetCredentialsResponse, error) {
+	return &GetCredentialsResponse{Credentials: []Credential{
+		{Name: "port", Value: apitypes.NewIntCredentialValue(8080)},
+	}}, nil
+}
+
+func (testServer) SetCredential(ctx context.Context, req *SetCredentialRequest) (*SetCredentialResponse, error) {
+	return &SetCredentialResponse{Credential: req.Credential}, nil
+}
+
+func TestClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "torus-rpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socketPath := filepath.Join(dir, "daemon.grpc.socket")
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := grpc.NewServer(ServerCodec())
+	RegisterDaemonServer(s, testServer{})
+	go s.Serve(l)
+	defer s.Stop()
+
+	conn, err := Dial(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := NewDaemonClient(conn)
+	ctx := context.Background()
+
+	sess, err := client.Session(ctx, &SessionRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.Type != apitypes.UserSession || sess.Name != "jeff" {
+		t.Errorf("unexpected session %+v", sess)
+	}
+
+	creds, err := client.GetCredentials(ctx, &GetCredentialsRequest{Path: "/o/p/e/s/u/i"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(creds.Credentials) != 1 || creds.Credentials[0].Value.String() != "8080" {
+		t.Errorf("unexpected credentials %+v", creds.Credentials)
+	}
+
+	set, err := client.SetCredential(ctx, &SetCredentialRequest{Credential: &Credential{
+		Name:  "url",
+		Value: apitypes.NewStringCredentialValue("https://example.com"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.Credential.Name != "url" || set.Credential.Value.String() != "https://example.com" {
+		t.Errorf("unexpected credential %+v", set.Credential)
+	}
+}
@@ -0,0 +1,222 @@
+// Package telemetry queues anonymous usage events for users who opt in with
+// `torus prefs telemetry on`. Events are only kept on disk, in a queue capped
+// at maxQueued events; nothing sends them yet.
+//
+// An event only records which command ran, how long it took, and the kind of
+// error it failed with, if any. Arguments, flag values, paths, secret names
+// and values, and error messages are never recorded; Redact enforces this on
+// every event before it is queued.
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	queueFile = "telemetry.json"
+
+	// maxQueued is the number of events kept in the queue. The oldest are
+	// dropped first.
+	maxQueued = 500
+
+	maxCommandWords = 3
+	maxWordLength   = 32
+)
+
+// Other replaces a command name or error category that can't be recorded.
+const Other = "other"
+
+// These are the error categories an event may have. Any other category is
+// recorded as Other.
+const (
+	ErrUsage          = "usage"
+	ErrInterrupted    = "interrupted"
+	ErrBadRequest     = "bad_request"
+	ErrUnauthorized   = "unauthorized"
+	ErrNotFound       = "not_found"
+	ErrConflict       = "conflict"
+	ErrInternal       = "internal_server"
+	ErrNotImplemented = "not_implemented"
+	ErrUnavailable    = "unavailable"
+	ErrExit           = "exit"
+)
+
+var categories = map[string]bool{
+	ErrUsage:          true,
+	ErrInterrupted:    true,
+	ErrBadRequest:     true,
+	ErrUnauthorized:   true,
+	ErrNotFound:       true,
+	ErrConflict:       true,
+	ErrInternal:       true,
+	ErrNotImplemented: true,
+	ErrUnavailable:    true,
+	ErrExit:           true,
+	Other:             true,
+}
+
+var commandWord = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Event records one run of a command.
+type Event struct {
+	// Command is the full name of the command, such as "keypairs generate".
+	Command string `json:"command"`
+
+	// Duration is how long the command took, in milliseconds.
+	Duration int64 `json:"duration_ms"`
+
+	// Error is the category of error the command failed with, or empty if it
+	// succeeded.
+	Error string `json:"error,omitempty"`
+
+	// Time is when the command ran, to the hour.
+	Time time.Time `json:"time"`
+}
+
+// Payload is what is sent for the queued events.
+type Payload struct {
+	Version string  `json:"version"`
+	OS      string  `json:"os"`
+	Arch    string  `json:"arch"`
+	Events  []Event `json:"events"`
+}
+
+// NewPayload returns the payload for events, sent from this version of the
+// CLI.
+func NewPayload(version string, events []Event) *Payload {
+	if events == nil {
+		events = []Event{}
+	}
+
+	return &Payload{
+		Version: version,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Events:  events,
+	}
+}
+
+// Redact returns e with anything that may identify the user or their data
+// removed. Command names that aren't made of plain command words, such as
+// those containing a path or value, become Other, as do unknown error
+// categories.
+func Redact(e Event) Event {
+	words := strings.Fields(e.Command)
+	valid := len(words) > 0 && len(words) <= maxCommandWords
+	for _, w := range words {
+		if len(w) > maxWordLength || !commandWord.MatchString(w) {
+			valid = false
+		}
+	}
+
+	command := Other
+	if valid {
+		command = strings.Join(words, " ")
+	}
+
+	category := e.Error
+	if category != "" && !categories[category] {
+		category = Other
+	}
+
+	duration := e.Duration
+	if duration < 0 {
+		duration = 0
+	}
+
+	return Event{
+		Command:  command,
+		Duration: duration,
+		Error:    category,
+		Time:     e.Time.UTC().Truncate(time.Hour),
+	}
+}
+
+// Queue holds events on disk until they are sent.
+type Queue struct {
+	path string
+}
+
+// NewQueue returns the queue kept in the given torus root directory.
+func NewQueue(torusRoot string) *Queue {
+	return &Queue{path: filepath.Join(torusRoot, queueFile)}
+}
+
+// Add redacts e and appends it to the queue, dropping the oldest events if
+// the queue is full.
+func (q *Queue) Add(e Event) error {
+	events, err := q.Events()
+	if err != nil {
+		return err
+	}
+
+	events = append(events, Redact(e))
+	if len(events) > maxQueued {
+		events = events[len(events)-maxQueued:]
+	}
+
+	return q.write(events)
+}
+
+// Events returns the queued events, oldest first. Lines that can't be read
+// are skipped.
+func (q *Queue) Events() ([]Event, error) {
+	raw, err := ioutil.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		var e Event
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		events = append(events, Redact(e))
+	}
+
+	return events, scanner.Err()
+}
+
+// Clear removes every queued event.
+func (q *Queue) Clear() error {
+	err := os.Remove(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (q *Queue) write(events []Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		err := enc.Encode(e)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Write the new queue beside the old one and move it into place, so a
+	// crash never leaves it half written.
+	tmp := q.path + ".tmp"
+	err := ioutil.WriteFile(tmp, buf.Bytes(), 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, q.path)
+}
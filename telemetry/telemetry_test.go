@@ -0,0 +1,100 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedact(t *testing.T) {
+	at := time.Date(2017, 3, 1, 14, 35, 12, 0, time.UTC)
+
+	tcs := []struct {
+		name    string
+		command string
+		err     string
+		wantCmd string
+		wantErr string
+	}{
+		{"command", "keypairs generate", "", "keypairs generate", ""},
+		{"category", "view", ErrNotFound, "view", ErrNotFound},
+		{"extra space", " orgs  list ", "", "orgs list", ""},
+		{"path", "view /acme/api/prod", "", Other, ""},
+		{"value", "set DB_PASSWORD hunter2", "", Other, ""},
+		{"upper case", "set Secret", "", Other, ""},
+		{"flag", "run --org=acme", "", Other, ""},
+		{"too long", strings.Repeat("a", maxWordLength+1), "", Other, ""},
+		{"empty", "", "", Other, ""},
+		{"error message", "login", "unauthorized: bad password for jo@example.com", "login", Other},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			e := Redact(Event{Command: tc.command, Error: tc.err, Duration: 42, Time: at})
+			if e.Command != tc.wantCmd {
+				t.Errorf("command = %q, want %q", e.Command, tc.wantCmd)
+			}
+			if e.Error != tc.wantErr {
+				t.Errorf("error = %q, want %q", e.Error, tc.wantErr)
+			}
+			if !e.Time.Equal(time.Date(2017, 3, 1, 14, 0, 0, 0, time.UTC)) {
+				t.Errorf("time not truncated to the hour: %s", e.Time)
+			}
+		})
+	}
+}
+
+func TestQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "torus-telemetry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	q := NewQueue(dir)
+	for i := 0; i < maxQueued+2; i++ {
+		cmd := "view"
+		if i == 0 {
+			cmd = "first"
+		}
+		err = q.Add(Event{Command: cmd, Duration: int64(i), Time: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = q.Add(Event{Command: "set FOO secretvalue", Time: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := q.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != maxQueued {
+		t.Fatalf("got %d events, want %d", len(events), maxQueued)
+	}
+	if events[0].Command == "first" {
+		t.Error("oldest event was not dropped")
+	}
+
+	raw, err := json.Marshal(NewPayload("0.0.0", events))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "secretvalue") {
+		t.Error("queued event contains a value")
+	}
+
+	err = q.Clear()
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err = q.Events()
+	if err != nil || len(events) != 0 {
+		t.Errorf("got %d events after clear, err %v", len(events), err)
+	}
+}
@@ -0,0 +1,46 @@
+// release-signer signs release checksum manifests with the Torus ed25519
+// signing key, so `torus version --verify` can trust them.
+//
+// The private key is read from TORUS_RELEASE_SIGNING_KEY, base64url encoded.
+// The signature of each manifest given is written next to it, with a .sig
+// extension.
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/manifoldco/torus-cli/base64"
+)
+
+func main() {
+	encoded, ok := os.LookupEnv("TORUS_RELEASE_SIGNING_KEY")
+	if !ok {
+		log.Fatal("Please set TORUS_RELEASE_SIGNING_KEY")
+	}
+
+	key, err := base64.NewValueFromString(encoded)
+	if err != nil || len(*key) != ed25519.PrivateKeySize {
+		log.Fatal("TORUS_RELEASE_SIGNING_KEY is not a valid ed25519 private key")
+	}
+
+	if len(os.Args) < 2 {
+		log.Fatal("usage: release-signer <manifest>...")
+	}
+
+	for _, path := range os.Args[1:] {
+		manifest, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		sig := ed25519.Sign(ed25519.PrivateKey(*key), manifest)
+		err = ioutil.WriteFile(path+".sig", []byte(base64.NewValue(sig).String()+"\n"), 0644)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
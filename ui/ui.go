@@ -2,11 +2,14 @@ package ui
 
 import (
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/chzyer/readline"
 	"github.com/kr/text"
 
+	"github.com/manifoldco/torus-cli/i18n"
 	"github.com/manifoldco/torus-cli/prefs"
 )
 
@@ -27,7 +30,58 @@ func Progress(str string) {
 	if !enableProgress {
 		return
 	}
-	fmt.Println(str)
+	fmt.Println(i18n.T(str))
+}
+
+// progressBarWidth is the number of cells in a progress bar
+const progressBarWidth = 25
+
+// barDrawn is whether a progress bar is on the current line
+var barDrawn = false
+
+// ProgressBar draws a progress bar in place of the previous one, naming the
+// latest step and the estimated time left, when progress is enabled. A
+// negative eta is not shown. When stdout is not a terminal, only the message
+// is output, as with Progress.
+func ProgressBar(str string, completed, total int, eta time.Duration) {
+	if !enableProgress {
+		return
+	}
+	if !readline.IsTerminal(int(os.Stdout.Fd())) {
+		Progress(str)
+		return
+	}
+
+	filled := 0
+	if total > 0 {
+		filled = progressBarWidth * completed / total
+	}
+	if filled > progressBarWidth {
+		filled = progressBarWidth
+	}
+
+	line := fmt.Sprintf("[%s%s] %d/%d %s", strings.Repeat("=", filled),
+		strings.Repeat(" ", progressBarWidth-filled), completed, total, i18n.T(str))
+	if eta > 0 {
+		secs := int64((eta + time.Second/2) / time.Second)
+		line += " " + i18n.T("(about %ds left)", secs)
+	}
+
+	cols := readline.GetScreenWidth() - 1
+	if runes := []rune(line); len(runes) > cols {
+		line = string(runes[:cols])
+	}
+
+	fmt.Printf("\r%-*s", cols, line)
+	barDrawn = true
+}
+
+// ProgressDone ends the line of a progress bar drawn by ProgressBar, if any.
+func ProgressDone() {
+	if barDrawn {
+		fmt.Println("")
+		barDrawn = false
+	}
 }
 
 // Hint handles the ui output for hint/onboarding messages, when enabled
@@ -38,7 +92,7 @@ func Hint(str string, noPadding bool) {
 	if !noPadding {
 		fmt.Println("")
 	}
-	printWrapLabeled("Protip:", str)
+	printWrapLabeled(i18n.T("Protip:"), i18n.T(str))
 }
 
 func printWrapLabeled(label, message string) {